@@ -1,21 +1,32 @@
 package main
 
 import (
+	"context"
 	"flag"
+	"fmt"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/emzola/issuetracker/config"
 	_ "github.com/emzola/issuetracker/docs"
 	"github.com/emzola/issuetracker/internal/controller/issuetracker"
 	httpHandler "github.com/emzola/issuetracker/internal/handler/http"
 	"github.com/emzola/issuetracker/internal/repository/postgres"
+	"github.com/emzola/issuetracker/pkg/model"
 	"github.com/emzola/issuetracker/pkg/rbac"
+	"github.com/emzola/issuetracker/pkg/validator"
 
 	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
 )
 
+// version is the application version reported by the health check. It can be set at
+// build time with -ldflags "-X main.version=...".
+var version = "1.0.0"
+
 // @title  Issue Tracker API
 // @version 1.0.0
 // @description This is an API service for an issue tracker.
@@ -35,31 +46,121 @@ func main() {
 		logger.Fatal("failed to load roles", zap.Error(err))
 	}
 	var cfg config.App
+	cfg.Version = version
 	// Read server settings from command-line flags into the config struct.
 	flag.IntVar(&cfg.Port, "port", 8080, "API server port")
 	flag.StringVar(&cfg.Env, "env", "development", "Environment(development|staging|production)")
+	flag.DurationVar(&cfg.RequestTimeout, "request-timeout", 5*time.Second, "Time given to requests before they are cancelled")
+	flag.IntVar(&cfg.Pagination.MaxPageSize, "max-page-size", model.DefaultMaxPageSize, "Largest page size of paginated lists")
+	flag.IntVar(&cfg.Pagination.ExportMaxPageSize, "export-max-page-size", 10_000, "Largest page size of issue exports")
 	// Read database connection pool settings from command-line flags into the config struct.
 	flag.StringVar(&cfg.Database.Dsn, "db-dsn", os.Getenv("DSN"), "PostgreSQL DSN")
 	flag.IntVar(&cfg.Database.MaxOpenConns, "db-max-open-conns", 25, "PostgreSQL max open connections")
 	flag.IntVar(&cfg.Database.MaxIdleConns, "db-max-idle-conns", 25, "PostgreSQL max idle connections")
 	flag.StringVar(&cfg.Database.MaxIdleTime, "db-max-idle-time", "15m", "PostgreSQL max connection")
+	flag.StringVar(&cfg.Database.TextSearchConfig, "db-text-search-config", "simple", "PostgreSQL text search configuration (simple|english|...)")
 	// Read SMTP settings from command-line flags into the config struct.
 	flag.StringVar(&cfg.Smtp.Host, "smtp-host", os.Getenv("SMTP_HOST"), "SMTP host")
 	flag.IntVar(&cfg.Smtp.Port, "smtp-port", 2525, "SMTP port")
 	flag.StringVar(&cfg.Smtp.Username, "smtp-username", os.Getenv("SMTP_USERNAME"), "SMTP username")
 	flag.StringVar(&cfg.Smtp.Password, "smtp-password", os.Getenv("SMTP_PASSWORD"), "SMTP password")
 	flag.StringVar(&cfg.Smtp.Sender, "smtp-sender", "Issue Tracker <no-reply@github.com/emzola/issuetracker>", "SMTP sender")
+	flag.IntVar(&cfg.Smtp.Workers, "smtp-workers", 4, "Number of emails sent concurrently")
 	// Read JWT signing secret from command-line flags into the config struct.
 	flag.StringVar(&cfg.Jwt.Secret, "jwt-secret", "", "JWT secret")
+	cfg.Jwt.Expiry = 24 * time.Hour
+	flag.Func("jwt-expiry", "JWT authentication token expiry, between 5m and 168h (default 24h)", func(s string) error {
+		duration, err := time.ParseDuration(s)
+		if err != nil || duration < 5*time.Minute || duration > 7*24*time.Hour {
+			return fmt.Errorf("invalid JWT expiry %q", s)
+		}
+		cfg.Jwt.Expiry = duration
+		return nil
+	})
+	// Read the password hashing cost from command-line flags into the config struct.
+	cfg.BcryptCost = model.DefaultBcryptCost
+	flag.Func("bcrypt-cost", "bcrypt cost of password hashes, between 4 and 31 (default 12)", func(s string) error {
+		cost, err := strconv.Atoi(s)
+		if err != nil || cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+			return fmt.Errorf("invalid bcrypt cost %q", s)
+		}
+		cfg.BcryptCost = cost
+		return nil
+	})
+	// Read the password policy from command-line flags into the config struct.
+	flag.BoolVar(&cfg.PasswordPolicy.RequireUpper, "password-require-upper", false, "Require new passwords to contain an uppercase letter")
+	flag.BoolVar(&cfg.PasswordPolicy.RequireLower, "password-require-lower", false, "Require new passwords to contain a lowercase letter")
+	flag.BoolVar(&cfg.PasswordPolicy.RequireDigit, "password-require-digit", false, "Require new passwords to contain a digit")
+	flag.BoolVar(&cfg.PasswordPolicy.RejectCommon, "password-reject-common", false, "Reject commonly used passwords")
 	// Read Rate Limiter settings from command-line flags into the config struct.
 	flag.Float64Var(&cfg.Limiter.Rps, "limiter-rps", 4, "Rate limiter maximum requests per second")
 	flag.IntVar(&cfg.Limiter.Burst, "limiter-burst", 8, "Rate limiter maximum burst")
+	flag.Float64Var(&cfg.Limiter.AnonymousRps, "limiter-anonymous-rps", 2, "Rate limiter maximum requests per second for anonymous users")
+	flag.IntVar(&cfg.Limiter.AnonymousBurst, "limiter-anonymous-burst", 4, "Rate limiter maximum burst for anonymous users")
 	flag.BoolVar(&cfg.Limiter.Enabled, "limiter-enabled", true, "Enable rate limiter")
 	// Read CORS configuration from command-line flags into the config struct.
 	flag.Func("cors-trusted-origins", "Trusted CORS origins (space separated)", func(s string) error {
 		cfg.Cors.TrustedOrigins = strings.Fields(s)
 		return nil
 	})
+	// Read due date reminder settings from command-line flags into the config struct.
+	flag.BoolVar(&cfg.Reminder.Enabled, "reminder-enabled", true, "Enable due date reminders")
+	cfg.Reminder.Interval = time.Hour
+	flag.Func("reminder-interval", "Interval between due date reminder runs, greater than 0 (default 1h)", func(s string) error {
+		duration, err := time.ParseDuration(s)
+		if err != nil || duration <= 0 {
+			return fmt.Errorf("invalid reminder interval %q", s)
+		}
+		cfg.Reminder.Interval = duration
+		return nil
+	})
+	cfg.Reminder.LeadTimes = map[string]time.Duration{
+		"low":      24 * time.Hour,
+		"medium":   24 * time.Hour,
+		"high":     48 * time.Hour,
+		"critical": 72 * time.Hour,
+	}
+	flag.Func("reminder-lead-times", "Due date reminder lead times per priority (e.g. low=24h,critical=72h)", func(s string) error {
+		for _, field := range strings.Split(s, ",") {
+			priority, leadTime, ok := strings.Cut(strings.TrimSpace(field), "=")
+			if !ok || !validator.In(priority, model.IssuePriorities...) {
+				return fmt.Errorf("invalid lead time %q", field)
+			}
+			duration, err := time.ParseDuration(leadTime)
+			if err != nil || duration <= 0 {
+				return fmt.Errorf("invalid lead time %q", field)
+			}
+			cfg.Reminder.LeadTimes[priority] = duration
+		}
+		return nil
+	})
+	// Read project settings from command-line flags into the config struct.
+	cfg.Project.TargetEndDateCheck = "warn"
+	flag.Func("project-target-end-date-check", "What to do when a project's target end date is moved before its issues' target dates (warn|block|ignore)", func(s string) error {
+		if !validator.In(s, "warn", "block", "ignore") {
+			return fmt.Errorf("invalid target end date check %q", s)
+		}
+		cfg.Project.TargetEndDateCheck = s
+		return nil
+	})
+	// Read auto-close settings from command-line flags into the config struct.
+	flag.BoolVar(&cfg.AutoClose.Enabled, "auto-close-enabled", true, "Enable automatic closing of resolved issues")
+	flag.DurationVar(&cfg.AutoClose.Interval, "auto-close-interval", time.Hour, "Interval between automatic closing runs")
+	// Read the interval at which changes made through other instances are picked up.
+	flag.DurationVar(&cfg.Reload.Interval, "reload-interval", time.Minute, "Interval between reloads of runtime settings and roles changed through other instances (0 disables reloading)")
+	// Read daily digest settings from command-line flags into the config struct.
+	flag.BoolVar(&cfg.Digest.Enabled, "digest-enabled", true, "Enable the daily digest of assigned issues")
+	cfg.Digest.Time = 8 * time.Hour
+	flag.Func("digest-time", "Time of day the daily digest is sent at, in the server's time zone (default 08:00)", func(s string) error {
+		at, err := time.Parse("15:04", s)
+		if err != nil {
+			return fmt.Errorf("invalid digest time %q", s)
+		}
+		cfg.Digest.Time = time.Duration(at.Hour())*time.Hour + time.Duration(at.Minute())*time.Minute
+		return nil
+	})
+	// Read issue watcher settings from command-line flags into the config struct.
+	flag.BoolVar(&cfg.Watchers.AutoWatchReporter, "auto-watch-reporter", true, "Make reporters watchers of the issues they create")
 	flag.Parse()
 	// Establish database connection pool.
 	db, err := config.DbConn(cfg)
@@ -69,11 +170,35 @@ func main() {
 	logger.Info("database connection pool established")
 	var wg sync.WaitGroup
 	// Instantiate app layers.
-	repo := postgres.New(db)
+	repo := postgres.New(db, cfg.Database.TextSearchConfig)
 	ctrl := issuetracker.New(repo, cfg, &wg, logger)
-	handler := httpHandler.New(ctrl, cfg, roles)
+	authorizer := rbac.New(roles)
+	handler := httpHandler.New(ctrl, cfg, authorizer)
+	// Apply runtime settings changed through the API over the command-line flags.
+	err = ctrl.LoadSettings(context.Background())
+	if err != nil {
+		logger.Fatal("failed to load runtime settings", zap.Error(err))
+	}
+	// Replace the roles read from roles.json with the ones managed through the API.
+	// The first start seeds the database with roles.json.
+	err = ctrl.LoadRoles(context.Background(), authorizer)
+	if err != nil {
+		logger.Fatal("failed to load roles", zap.Error(err))
+	}
+	// Start background jobs. They are stopped when the server shuts down. Jobs
+	// disabled in the runtime settings stay idle until they are enabled.
+	ctx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+	ctrl.StartSettingsReload(ctx)
+	ctrl.StartRolesReload(ctx)
+	ctrl.StartDueDateReminders(ctx)
+	ctrl.StartAutoClose(ctx)
+	ctrl.StartRevokedTokenPurge(ctx)
+	if cfg.Digest.Enabled {
+		ctrl.StartDailyDigest(ctx)
+	}
 	// Start server.
-	err = serve(handler.Routes(), cfg, &wg, logger)
+	err = serve(handler.Routes(ctx), cfg, &wg, stopBackground, logger)
 	if err != nil {
 		logger.Fatal("failed to start server", zap.Error(err))
 	}
@@ -15,7 +15,7 @@ import (
 	"go.uber.org/zap"
 )
 
-func serve(handler http.Handler, cfg config.App, wg *sync.WaitGroup, logger *zap.Logger) error {
+func serve(handler http.Handler, cfg config.App, wg *sync.WaitGroup, stopBackground context.CancelFunc, logger *zap.Logger) error {
 	srv := &http.Server{
 		Addr:         fmt.Sprintf(":%d", cfg.Port),
 		Handler:      handler,
@@ -41,6 +41,7 @@ func serve(handler http.Handler, cfg config.App, wg *sync.WaitGroup, logger *zap
 		logger.Info("completing background tasks", zap.Any("properties", map[string]string{
 			"addr": srv.Addr,
 		}))
+		stopBackground()
 		wg.Wait()
 		shutdownErr <- nil
 	}()
@@ -1,15 +1,23 @@
 package config
 
+import "time"
+
 // config defines configuration values. Values are read via
 // command-line flags and environment variables.
 type App struct {
 	Port     int
 	Env      string
+	Version  string
 	Database struct {
 		Dsn          string
 		MaxOpenConns int
 		MaxIdleConns int
 		MaxIdleTime  string
+		// TextSearchConfig is the PostgreSQL text search configuration used for
+		// full-text search. 'simple' matches words as written, whereas a language
+		// configuration such as 'english' adds stemming and stop-word removal at the
+		// cost of occasionally over-matching, e.g. "organ" matching "organization".
+		TextSearchConfig string
 	}
 	Smtp struct {
 		Host     string
@@ -17,16 +25,82 @@ type App struct {
 		Username string
 		Password string
 		Sender   string
+		// Workers is the number of emails sent at the same time.
+		Workers int
 	}
 	Jwt struct {
 		Secret string
+		// Expiry is how long authentication tokens are valid, between 5 minutes and
+		// 7 days.
+		Expiry time.Duration
 	}
 	Limiter struct {
-		Rps     float64
-		Burst   int
-		Enabled bool
+		Rps            float64
+		Burst          int
+		AnonymousRps   float64
+		AnonymousBurst int
+		Enabled        bool
 	}
 	Cors struct {
 		TrustedOrigins []string
 	}
+	Reminder struct {
+		Enabled  bool
+		Interval time.Duration
+		// LeadTimes holds how long before an issue's target resolution date its
+		// assignee is reminded, keyed by issue priority.
+		LeadTimes map[string]time.Duration
+	}
+	Project struct {
+		// TargetEndDateCheck controls what happens when a project's target end date is
+		// moved before the target resolution date of its unresolved issues: "warn"
+		// updates the project and reports the issues, "block" rejects the update and
+		// "ignore" skips the check.
+		TargetEndDateCheck string
+	}
+	// AutoClose controls the job that closes resolved issues once the verification
+	// period configured on their project has passed.
+	AutoClose struct {
+		Enabled  bool
+		Interval time.Duration
+	}
+	// Digest controls the daily email digest of their open assigned issues sent to
+	// users who opted in.
+	Digest struct {
+		Enabled bool
+		// Time is the time of day the digest is sent at, as a duration since midnight
+		// in the server's time zone.
+		Time time.Duration
+	}
+	// Watchers controls how users are subscribed to issues.
+	Watchers struct {
+		// AutoWatchReporter makes reporters watchers of the issues they create. They
+		// can stop watching them like any other issue.
+		AutoWatchReporter bool
+	}
+	// Reload controls how often runtime settings and roles changed through another
+	// instance of the application are picked up. 0 turns reloading off.
+	Reload struct {
+		Interval time.Duration
+	}
+	// BcryptCost is the bcrypt cost of password hashes, between 4 and 31. 0 uses the
+	// default cost of 12.
+	BcryptCost int
+	// PasswordPolicy holds the complexity rules new passwords must follow on top of
+	// their length. None are enforced by default.
+	PasswordPolicy struct {
+		RequireUpper bool
+		RequireLower bool
+		RequireDigit bool
+		RejectCommon bool
+	}
+	// RequestTimeout is how long requests are given before they are cancelled, except
+	// for the long-running bulk update and export requests.
+	RequestTimeout time.Duration
+	// Pagination holds the largest page sizes clients can request, from paginated lists
+	// and from issue exports.
+	Pagination struct {
+		MaxPageSize       int
+		ExportMaxPageSize int
+	}
 }
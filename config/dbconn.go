@@ -3,6 +3,7 @@ package config
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"time"
 
 	_ "github.com/jackc/pgx/v5/stdlib"
@@ -26,5 +27,11 @@ func DbConn(app App) (*sql.DB, error) {
 	if err != nil {
 		return nil, err
 	}
+	// Check that the text search configuration exists in the database.
+	var textSearchConfig string
+	err = db.QueryRowContext(ctx, "SELECT $1::regconfig::text", app.Database.TextSearchConfig).Scan(&textSearchConfig)
+	if err != nil {
+		return nil, fmt.Errorf("invalid text search configuration %q: %w", app.Database.TextSearchConfig, err)
+	}
 	return db, nil
 }
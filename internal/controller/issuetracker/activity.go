@@ -0,0 +1,207 @@
+package issuetracker
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/emzola/issuetracker/pkg/model"
+	"github.com/emzola/issuetracker/pkg/validator"
+	"go.uber.org/zap"
+)
+
+type activityRepository interface {
+	GetIssueActivity(ctx context.Context, issueID int64, filters model.Filters) ([]*model.IssueActivity, model.Metadata, error)
+	GetIssueActivitySince(ctx context.Context, issueID, version int64) ([]*model.IssueActivity, error)
+	RecordProjectActivity(ctx context.Context, activity []*model.ProjectActivity) error
+	GetProjectActivity(ctx context.Context, projectID int64, filters model.Filters) ([]*model.ProjectActivity, model.Metadata, error)
+}
+
+func (c *Controller) GetIssueActivity(ctx context.Context, issueID int64, user *model.User, filters model.Filters, v *validator.Validator) ([]*model.IssueActivity, model.Metadata, error) {
+	if filters.Validate(v); !v.Valid() {
+		return nil, model.Metadata{}, failedValidationErr(v.Errors)
+	}
+	_, err := c.GetIssue(ctx, issueID, user)
+	if err != nil {
+		return nil, model.Metadata{}, err
+	}
+	activity, metadata, err := c.repo.GetIssueActivity(ctx, issueID, filters)
+	if err != nil {
+		return nil, model.Metadata{}, err
+	}
+	return activity, metadata, nil
+}
+
+// trackedIssueFields holds the issue fields recorded in the activity log.
+var trackedIssueFields = []string{"title", "description", "status", "priority", "type", "assigned_to", "milestone_id", "progress", "resolution_summary"}
+
+// issueFieldValues returns the values of the tracked fields of an issue, as they are
+// recorded in the activity log.
+func issueFieldValues(issue *model.Issue) map[string]string {
+	id := func(id *int64) string {
+		if id == nil {
+			return ""
+		}
+		return strconv.FormatInt(*id, 10)
+	}
+	return map[string]string{
+		"title":              issue.Title,
+		"description":        issue.Description,
+		"status":             issue.Status,
+		"priority":           issue.Priority,
+		"type":               issue.Type,
+		"assigned_to":        id(issue.AssignedTo),
+		"milestone_id":       id(issue.MilestoneID),
+		"progress":           issue.Progress,
+		"resolution_summary": issue.ResolutionSummary,
+	}
+}
+
+// issueActivity returns an activity entry for every tracked field whose value differs
+// between before and after. The entries are recorded against the version the changes
+// produce.
+func issueActivity(before, after *model.Issue, user *model.User) []*model.IssueActivity {
+	beforeValues, afterValues := issueFieldValues(before), issueFieldValues(after)
+	var activity []*model.IssueActivity
+	for _, field := range trackedIssueFields {
+		if beforeValues[field] != afterValues[field] {
+			activity = append(activity, &model.IssueActivity{
+				IssueID:     after.ID,
+				Field:       field,
+				OldValue:    beforeValues[field],
+				NewValue:    afterValues[field],
+				ChangedBy:   user.Name,
+				ChangedByID: user.ID,
+			})
+		}
+	}
+	return activity
+}
+
+// lifecycleActivity returns the activity entry for a change to an issue that is not one
+// of its tracked fields, such as publishing or deleting it. The entry is recorded against
+// the version the change produces.
+func lifecycleActivity(issueID int64, field, oldValue, newValue string, user *model.User) []*model.IssueActivity {
+	return []*model.IssueActivity{{
+		IssueID:     issueID,
+		Field:       field,
+		OldValue:    oldValue,
+		NewValue:    newValue,
+		ChangedBy:   user.Name,
+		ChangedByID: user.ID,
+	}}
+}
+
+// GetIssueDiff returns the tracked fields that differ between two versions of an issue.
+// Both versions are reconstructed from the issue's current state by undoing the changes
+// recorded in its activity log after them.
+func (c *Controller) GetIssueDiff(ctx context.Context, issueID, fromVersion, toVersion int64, user *model.User, v *validator.Validator) (*model.IssueDiff, error) {
+	issue, err := c.GetIssue(ctx, issueID, user)
+	if err != nil {
+		return nil, err
+	}
+	v.Check(fromVersion >= 1, "from_version", "must be provided and at least 1")
+	v.Check(toVersion >= 1, "to_version", "must be provided and at least 1")
+	v.Check(fromVersion <= toVersion, "from_version", "must not be more than to_version")
+	v.Check(toVersion <= issue.Version, "to_version", fmt.Sprintf("must not be more than the issue's current version %d", issue.Version))
+	if !v.Valid() {
+		return nil, failedValidationErr(v.Errors)
+	}
+	activity, err := c.repo.GetIssueActivitySince(ctx, issue.ID, fromVersion)
+	if err != nil {
+		return nil, err
+	}
+	values := issueFieldValues(issue)
+	var to map[string]string
+	for _, a := range activity {
+		if to == nil && a.Version <= toVersion {
+			to = copyValues(values)
+		}
+		values[a.Field] = a.OldValue
+	}
+	if to == nil {
+		to = values
+	}
+	diff := &model.IssueDiff{IssueID: issue.ID, FromVersion: fromVersion, ToVersion: toVersion, Changes: []model.IssueFieldDiff{}}
+	for _, field := range trackedIssueFields {
+		if values[field] != to[field] {
+			diff.Changes = append(diff.Changes, model.IssueFieldDiff{Field: field, From: values[field], To: to[field]})
+		}
+	}
+	return diff, nil
+}
+
+func copyValues(values map[string]string) map[string]string {
+	copied := make(map[string]string, len(values))
+	for k, v := range values {
+		copied[k] = v
+	}
+	return copied
+}
+
+func (c *Controller) GetProjectActivity(ctx context.Context, projectID int64, filters model.Filters, v *validator.Validator) ([]*model.ProjectActivity, model.Metadata, error) {
+	if filters.Validate(v); !v.Valid() {
+		return nil, model.Metadata{}, failedValidationErr(v.Errors)
+	}
+	_, err := c.GetProject(ctx, projectID)
+	if err != nil {
+		return nil, model.Metadata{}, err
+	}
+	activity, metadata, err := c.repo.GetProjectActivity(ctx, projectID, filters)
+	if err != nil {
+		return nil, model.Metadata{}, err
+	}
+	return activity, metadata, nil
+}
+
+// projectActivity returns an activity entry for every tracked field whose value
+// differs between before and after.
+func projectActivity(before, after *model.Project, changedBy string) []*model.ProjectActivity {
+	id := func(id *int64) string {
+		if id == nil {
+			return ""
+		}
+		return strconv.FormatInt(*id, 10)
+	}
+	date := func(date *time.Time) string {
+		if date == nil {
+			return ""
+		}
+		return date.Format("2006-01-02")
+	}
+	fields := []struct {
+		name          string
+		before, after string
+	}{
+		{"name", before.Name, after.Name},
+		{"description", before.Description, after.Description},
+		{"assigned_to", id(before.AssignedTo), id(after.AssignedTo)},
+		{"start_date", date(&before.StartDate), date(&after.StartDate)},
+		{"target_end_date", date(&before.TargetEndDate), date(&after.TargetEndDate)},
+		{"actual_end_date", date(before.ActualEndDate), date(after.ActualEndDate)},
+		{"archived", strconv.FormatBool(before.Archived), strconv.FormatBool(after.Archived)},
+	}
+	var activity []*model.ProjectActivity
+	for _, field := range fields {
+		if field.before != field.after {
+			activity = append(activity, &model.ProjectActivity{
+				ProjectID: after.ID,
+				Field:     field.name,
+				OldValue:  field.before,
+				NewValue:  field.after,
+				ChangedBy: changedBy,
+			})
+		}
+	}
+	return activity
+}
+
+// recordProjectActivity records the changes made to a project. Failures are logged
+// rather than returned, since the project has already been updated.
+func (c *Controller) recordProjectActivity(ctx context.Context, activity []*model.ProjectActivity) {
+	err := c.repo.RecordProjectActivity(ctx, activity)
+	if err != nil {
+		c.Logger.Error("failed to record project activity", zap.Error(err))
+	}
+}
@@ -0,0 +1,140 @@
+package issuetracker
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/emzola/issuetracker/config"
+	"github.com/emzola/issuetracker/pkg/model"
+	"github.com/emzola/issuetracker/pkg/validator"
+	"go.uber.org/zap"
+)
+
+func TestIssueActivity(t *testing.T) {
+	assignee := int64(3)
+	resolved := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	before := &model.Issue{ID: 1, Title: "Login fails", Status: "in progress", Priority: "high", Progress: "Investigating"}
+	after := &model.Issue{ID: 1, Title: "Login fails", Status: "closed", Priority: "high", AssignedTo: &assignee, Progress: "Investigating", ResolutionSummary: "Fixed session cookie", ActualResolutionDate: &resolved}
+	activity := issueActivity(before, after, &model.User{ID: 2, Name: "Ada Lovelace"})
+	want := []model.IssueActivity{
+		{IssueID: 1, Field: "status", OldValue: "in progress", NewValue: "closed", ChangedBy: "Ada Lovelace", ChangedByID: 2},
+		{IssueID: 1, Field: "assigned_to", OldValue: "", NewValue: "3", ChangedBy: "Ada Lovelace", ChangedByID: 2},
+		{IssueID: 1, Field: "resolution_summary", OldValue: "", NewValue: "Fixed session cookie", ChangedBy: "Ada Lovelace", ChangedByID: 2},
+	}
+	if len(activity) != len(want) {
+		t.Fatalf("issueActivity() returned %d entries, want %d", len(activity), len(want))
+	}
+	for i, a := range activity {
+		if *a != want[i] {
+			t.Errorf("issueActivity()[%d] = %+v, want %+v", i, *a, want[i])
+		}
+	}
+	if activity := issueActivity(after, after, &model.User{ID: 2, Name: "Ada Lovelace"}); len(activity) != 0 {
+		t.Errorf("issueActivity() of an unchanged issue = %v, want none", activity)
+	}
+}
+
+func TestProjectActivity(t *testing.T) {
+	lead := int64(2)
+	ended := time.Date(2024, 11, 30, 0, 0, 0, 0, time.UTC)
+	before := &model.Project{ID: 1, Name: "Issue Tracker", StartDate: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), TargetEndDate: time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)}
+	after := &model.Project{ID: 1, Name: "Bug Tracker", AssignedTo: &lead, StartDate: before.StartDate, TargetEndDate: before.TargetEndDate, ActualEndDate: &ended}
+	activity := projectActivity(before, after, "Ada Lovelace")
+	want := []model.ProjectActivity{
+		{ProjectID: 1, Field: "name", OldValue: "Issue Tracker", NewValue: "Bug Tracker", ChangedBy: "Ada Lovelace"},
+		{ProjectID: 1, Field: "assigned_to", OldValue: "", NewValue: "2", ChangedBy: "Ada Lovelace"},
+		{ProjectID: 1, Field: "actual_end_date", OldValue: "", NewValue: "2024-11-30", ChangedBy: "Ada Lovelace"},
+	}
+	if len(activity) != len(want) {
+		t.Fatalf("projectActivity() returned %d entries, want %d", len(activity), len(want))
+	}
+	for i, a := range activity {
+		if *a != want[i] {
+			t.Errorf("projectActivity()[%d] = %+v, want %+v", i, *a, want[i])
+		}
+	}
+	if activity := projectActivity(after, after, "Ada Lovelace"); len(activity) != 0 {
+		t.Errorf("projectActivity() of an unchanged project = %v, want none", activity)
+	}
+}
+
+// fakeDiffRepository serves the activity log that led issue 1 to version 5. Version 3
+// changed no tracked field.
+type fakeDiffRepository struct {
+	fakeRepository
+}
+
+func (r *fakeDiffRepository) GetIssueActivitySince(ctx context.Context, issueID, version int64) ([]*model.IssueActivity, error) {
+	log := []*model.IssueActivity{
+		{IssueID: 1, Version: 5, Field: "status", OldValue: "in progress", NewValue: "resolved"},
+		{IssueID: 1, Version: 4, Field: "priority", OldValue: "low", NewValue: "high"},
+		{IssueID: 1, Version: 4, Field: "title", OldValue: "Login fails", NewValue: "Login fails on Safari"},
+		{IssueID: 1, Version: 2, Field: "status", OldValue: "open", NewValue: "in progress"},
+	}
+	activity := []*model.IssueActivity{}
+	for _, a := range log {
+		if a.Version > version {
+			activity = append(activity, a)
+		}
+	}
+	return activity, nil
+}
+
+func TestGetIssueDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to int64
+		want     []model.IssueFieldDiff
+		wantErr  error
+	}{
+		{
+			name: "first to current",
+			from: 1,
+			to:   5,
+			want: []model.IssueFieldDiff{
+				{Field: "title", From: "Login fails", To: "Login fails on Safari"},
+				{Field: "status", From: "open", To: "resolved"},
+				{Field: "priority", From: "low", To: "high"},
+			},
+		},
+		{
+			name: "between past versions",
+			from: 2,
+			to:   4,
+			want: []model.IssueFieldDiff{
+				{Field: "title", From: "Login fails", To: "Login fails on Safari"},
+				{Field: "priority", From: "low", To: "high"},
+			},
+		},
+		{name: "version without tracked changes", from: 2, to: 3, want: []model.IssueFieldDiff{}},
+		{name: "past current version", from: 4, to: 6, wantErr: ErrFailedValidation},
+		{name: "missing from version", from: 0, to: 2, wantErr: ErrFailedValidation},
+		{name: "from after to", from: 3, to: 2, wantErr: ErrFailedValidation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var wg sync.WaitGroup
+			repo := &fakeDiffRepository{fakeRepository{issues: map[int64]*model.Issue{
+				1: {ID: 1, Title: "Login fails on Safari", Status: "resolved", Priority: "high", Version: 5},
+			}}}
+			c := New(repo, config.App{}, &wg, zap.NewNop())
+			diff, err := c.GetIssueDiff(context.Background(), 1, tt.from, tt.to, &model.User{ID: 1}, validator.New())
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("GetIssueDiff() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetIssueDiff() error = %v", err)
+			}
+			if !reflect.DeepEqual(diff.Changes, tt.want) {
+				t.Errorf("GetIssueDiff() changes = %+v, want %+v", diff.Changes, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package issuetracker
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/emzola/issuetracker/internal/repository"
+	"github.com/emzola/issuetracker/pkg/model"
+	"go.uber.org/zap"
+)
+
+type autoCloseRepository interface {
+	GetIssuesDueForAutoClose(ctx context.Context) ([]*model.IssueAutoClose, error)
+	AutoCloseIssue(ctx context.Context, issueID int64, status, closedStatus string, activity []*model.IssueActivity) error
+}
+
+// StartAutoClose closes resolved issues in a background goroutine once every
+// auto-close interval until ctx is cancelled. Runs are skipped while automatic
+// closing is disabled in the runtime settings.
+func (c *Controller) StartAutoClose(ctx context.Context) {
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		ticker := time.NewTicker(interval(c.Settings().AutoCloseInterval, c.Config.AutoClose.Interval))
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if !c.Settings().AutoCloseEnabled {
+					continue
+				}
+				err := c.CloseResolvedIssues(ctx)
+				if err != nil {
+					c.Logger.Info("failed to close resolved issues", zap.Error(err))
+				}
+			}
+		}
+	}()
+}
+
+// CloseResolvedIssues closes every issue waiting to be closed that has not been modified
+// for the verification period configured on its project, sends issue.closed to the
+// project's webhooks, and notifies the issue's reporter if the project delivers
+// notifications by email. Issues wait to be closed in
+// the last open state of their project's workflow, and are moved to the first closed one.
+// Projects without a verification period are skipped.
+func (c *Controller) CloseResolvedIssues(ctx context.Context) error {
+	issues, err := c.repo.GetIssuesDueForAutoClose(ctx)
+	if err != nil {
+		return err
+	}
+	for _, issue := range issues {
+		activity := []*model.IssueActivity{{IssueID: issue.IssueID, Field: "status", OldValue: issue.Status, NewValue: issue.ClosedStatus, ChangedBy: "system"}}
+		err := c.repo.AutoCloseIssue(ctx, issue.IssueID, issue.Status, issue.ClosedStatus, activity)
+		if err != nil {
+			switch {
+			// The issue was reopened or updated since it was fetched.
+			case errors.Is(err, repository.ErrEditConflict):
+				continue
+			default:
+				return err
+			}
+		}
+		c.autoClosed(ctx, issue.IssueID)
+		if !issue.NotifyByEmail {
+			continue
+		}
+		data := map[string]string{
+			"name":          issue.ReporterName,
+			"issueID":       strconv.Itoa(int(issue.IssueID)),
+			"issueTitle":    issue.Title,
+			"autoCloseDays": strconv.Itoa(issue.AutoCloseDays),
+		}
+		c.SendEmail(data, issue.ReporterEmail, issue.ReporterLocale, "issue_auto_closed.tmpl")
+	}
+	return nil
+}
+
+// autoClosed sends issue.closed to the webhooks of an issue that has been closed
+// automatically. Failures are logged, since the issue has already been closed.
+func (c *Controller) autoClosed(ctx context.Context, issueID int64) {
+	issue, err := c.repo.GetIssue(ctx, issueID)
+	if err != nil {
+		c.Logger.Error("failed to load auto-closed issue", zap.Int64("issue_id", issueID), zap.Error(err))
+		return
+	}
+	if !issue.Draft {
+		c.dispatchWebhooks(ctx, "issue.closed", issue)
+	}
+}
@@ -0,0 +1,120 @@
+package issuetracker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/emzola/issuetracker/config"
+	"github.com/emzola/issuetracker/internal/repository"
+	"github.com/emzola/issuetracker/pkg/model"
+	"go.uber.org/zap"
+)
+
+// fakeBulkRepository serves issues in project 1, which uses the default workflow, and
+// fails to save the issues if one of them has the ID conflictID.
+type fakeBulkRepository struct {
+	fakeRepository
+	conflictID int64
+}
+
+func (r *fakeBulkRepository) UpdateIssues(ctx context.Context, issues []*model.Issue, activity [][]*model.IssueActivity) error {
+	for i, issue := range issues {
+		if issue.ID == r.conflictID {
+			return &repository.BatchError{Index: i, Err: repository.ErrEditConflict}
+		}
+	}
+	return r.fakeRepository.UpdateIssues(ctx, issues, activity)
+}
+
+func newFakeBulkRepository() *fakeBulkRepository {
+	assignee := int64(3)
+	issue := func(id, reporterID int64, status string) *model.Issue {
+		return &model.Issue{
+			ID:                   id,
+			Title:                "Login fails",
+			Description:          "Login fails with valid credentials",
+			ReporterID:           reporterID,
+			ReportedDate:         time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			ProjectID:            1,
+			AssignedTo:           &assignee,
+			Status:               status,
+			Priority:             "low",
+			Type:                 "bug",
+			TargetResolutionDate: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		}
+	}
+	return &fakeBulkRepository{fakeRepository: fakeRepository{
+		issues: map[int64]*model.Issue{
+			1: issue(1, 2, "in progress"),
+			2: issue(2, 2, "closed"),
+			3: issue(3, 5, "open"),
+			4: issue(4, 2, "in progress"),
+		},
+		projects: fakeProjects(1, model.NotificationChannels...),
+	}}
+}
+
+func TestBulkUpdateIssues(t *testing.T) {
+	member := &model.User{ID: 2, Name: "Ada Lovelace", Role: "member"}
+	status := "resolved"
+	tests := []struct {
+		name       string
+		ids        []int64
+		conflictID int64
+		want       []error
+		wantSaved  int
+	}{
+		{"all updated", []int64{1, 4}, 0, []error{nil, nil}, 2},
+		{"per issue failures", []int64{1, 2, 3, 9}, 0, []error{nil, ErrFailedValidation, ErrNotPermitted, ErrNotFound}, 1},
+		{"failed save rolls back", []int64{1, 4, 3}, 4, []error{ErrRolledBack, ErrEditConflict, ErrNotPermitted}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeBulkRepository()
+			repo.conflictID = tt.conflictID
+			var wg sync.WaitGroup
+			c := New(repo, config.App{}, &wg, zap.NewNop())
+			errs, err := c.BulkUpdateIssues(context.Background(), tt.ids, nil, &status, nil, member)
+			if err != nil {
+				t.Fatalf("BulkUpdateIssues() error = %v", err)
+			}
+			if len(errs) != len(tt.want) {
+				t.Fatalf("BulkUpdateIssues() returned %d results, want %d", len(errs), len(tt.want))
+			}
+			for i, want := range tt.want {
+				if !errors.Is(errs[i], want) || (want == nil && errs[i] != nil) {
+					t.Errorf("BulkUpdateIssues() issue %d error = %v, want %v", tt.ids[i], errs[i], want)
+				}
+			}
+			if len(repo.saved) != tt.wantSaved {
+				t.Errorf("BulkUpdateIssues() saved %v, want %d issues", repo.saved, tt.wantSaved)
+			}
+		})
+	}
+}
+
+func TestBulkUpdateIssuesValidation(t *testing.T) {
+	status := "resolved"
+	tests := []struct {
+		name   string
+		ids    []int64
+		status *string
+	}{
+		{"no issues", nil, &status},
+		{"duplicate issues", []int64{1, 1}, &status},
+		{"no changes", []int64{1}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var wg sync.WaitGroup
+			c := New(newFakeBulkRepository(), config.App{}, &wg, zap.NewNop())
+			_, err := c.BulkUpdateIssues(context.Background(), tt.ids, nil, tt.status, nil, &model.User{ID: 1, Role: "manager"})
+			if !errors.Is(err, ErrFailedValidation) {
+				t.Errorf("BulkUpdateIssues() error = %v, want ErrFailedValidation", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package issuetracker
+
+import (
+	"context"
+	"errors"
+
+	"github.com/emzola/issuetracker/internal/repository"
+	"github.com/emzola/issuetracker/pkg/model"
+	"github.com/emzola/issuetracker/pkg/validator"
+)
+
+type commentRepository interface {
+	CreateComment(ctx context.Context, comment *model.Comment) error
+	GetComment(ctx context.Context, id int64) (*model.Comment, error)
+	GetAllComments(ctx context.Context, issueID int64, filters model.Filters) ([]*model.Comment, model.Metadata, error)
+	UpdateComment(ctx context.Context, comment *model.Comment) error
+	DeleteComment(ctx context.Context, id int64) error
+}
+
+func (c *Controller) CreateComment(ctx context.Context, issueID int64, body string, user *model.User) (*model.Comment, error) {
+	// Comments can only be left on issues visible to the user.
+	_, err := c.GetIssue(ctx, issueID, user)
+	if err != nil {
+		return nil, err
+	}
+	comment := &model.Comment{
+		IssueID: issueID,
+		UserID:  user.ID,
+		Body:    body,
+	}
+	v := validator.New()
+	if comment.Validate(v); !v.Valid() {
+		return nil, failedValidationErr(v.Errors)
+	}
+	err = c.repo.CreateComment(ctx, comment)
+	if err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+func (c *Controller) GetAllComments(ctx context.Context, issueID int64, user *model.User, filters model.Filters, v *validator.Validator) ([]*model.Comment, model.Metadata, error) {
+	if filters.Validate(v); !v.Valid() {
+		return nil, model.Metadata{}, failedValidationErr(v.Errors)
+	}
+	_, err := c.GetIssue(ctx, issueID, user)
+	if err != nil {
+		return nil, model.Metadata{}, err
+	}
+	comments, metadata, err := c.repo.GetAllComments(ctx, issueID, filters)
+	if err != nil {
+		return nil, model.Metadata{}, err
+	}
+	return comments, metadata, nil
+}
+
+// getEditableComment returns the comment if the user can edit or delete it. Only the
+// comment's author or a manager can edit or delete a comment.
+func (c *Controller) getEditableComment(ctx context.Context, id int64, user *model.User) (*model.Comment, error) {
+	comment, err := c.repo.GetComment(ctx, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+	_, err = c.GetIssue(ctx, comment.IssueID, user)
+	if err != nil {
+		return nil, err
+	}
+	if user.Role != "manager" && comment.UserID != user.ID {
+		return nil, ErrNotPermitted
+	}
+	return comment, nil
+}
+
+func (c *Controller) UpdateComment(ctx context.Context, id int64, body *string, user *model.User) (*model.Comment, error) {
+	comment, err := c.getEditableComment(ctx, id, user)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		comment.Body = *body
+	}
+	v := validator.New()
+	if comment.Validate(v); !v.Valid() {
+		return nil, failedValidationErr(v.Errors)
+	}
+	err = c.repo.UpdateComment(ctx, comment)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrEditConflict):
+			return nil, ErrEditConflict
+		default:
+			return nil, err
+		}
+	}
+	return comment, nil
+}
+
+func (c *Controller) DeleteComment(ctx context.Context, id int64, user *model.User) error {
+	_, err := c.getEditableComment(ctx, id, user)
+	if err != nil {
+		return err
+	}
+	err = c.repo.DeleteComment(ctx, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return ErrNotFound
+		default:
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package issuetracker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/emzola/issuetracker/config"
+	"github.com/emzola/issuetracker/pkg/model"
+	"go.uber.org/zap"
+)
+
+// fakeCommentRepository serves a single comment on one of the issues it holds.
+type fakeCommentRepository struct {
+	fakeRepository
+	comment *model.Comment
+	updated bool
+}
+
+func (r *fakeCommentRepository) GetComment(ctx context.Context, id int64) (*model.Comment, error) {
+	comment := *r.comment
+	return &comment, nil
+}
+
+func (r *fakeCommentRepository) UpdateComment(ctx context.Context, comment *model.Comment) error {
+	r.updated = true
+	return nil
+}
+
+func TestUpdateCommentPermissions(t *testing.T) {
+	tests := []struct {
+		name    string
+		user    *model.User
+		wantErr error
+	}{
+		{"author", &model.User{ID: 2, Role: "member"}, nil},
+		{"manager", &model.User{ID: 3, Role: "manager"}, nil},
+		{"lead", &model.User{ID: 4, Role: "lead"}, ErrNotPermitted},
+		{"other member", &model.User{ID: 5, Role: "member"}, ErrNotPermitted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeCommentRepository{
+				fakeRepository: fakeRepository{issues: map[int64]*model.Issue{1: {ID: 1, ReporterID: 1}}},
+				comment:        &model.Comment{ID: 1, IssueID: 1, UserID: 2, Body: "Reproduced on staging"},
+			}
+			var wg sync.WaitGroup
+			c := New(repo, config.App{}, &wg, zap.NewNop())
+			body := "Reproduced on staging and production"
+			_, err := c.UpdateComment(context.Background(), 1, &body, tt.user)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UpdateComment() error = %v, want %v", err, tt.wantErr)
+			}
+			if repo.updated != (tt.wantErr == nil) {
+				t.Errorf("UpdateComment() updated = %v, want %v", repo.updated, tt.wantErr == nil)
+			}
+		})
+	}
+}
@@ -4,6 +4,9 @@ import (
 	"sync"
 
 	"github.com/emzola/issuetracker/config"
+	"github.com/emzola/issuetracker/pkg/mailer"
+	"github.com/emzola/issuetracker/pkg/rbac"
+	"github.com/emzola/issuetracker/pkg/webhook"
 	"go.uber.org/zap"
 )
 
@@ -13,15 +16,47 @@ type issueTrackerRepository interface {
 	tokenRepository
 	issueRepository
 	issuesReportRepository
+	reminderRepository
+	autoCloseRepository
+	dataIssuesRepository
+	workflowRepository
+	settingsRepository
+	commentRepository
+	labelRepository
+	watcherRepository
+	activityRepository
+	healthRepository
+	linkRepository
+	worklogRepository
+	milestoneRepository
+	webhookRepository
+	roleRepository
+	digestRepository
+	projectTemplateRepository
+	savedFilterRepository
 }
 
 type Controller struct {
-	repo   issueTrackerRepository
-	Config config.App
-	wg     *sync.WaitGroup
-	Logger *zap.Logger
+	repo       issueTrackerRepository
+	Config     config.App
+	wg         *sync.WaitGroup
+	Logger     *zap.Logger
+	settings   *runtimeSettings
+	authorizer *rbac.Authorizer
+	emails     *emailPool
+	webhooks   webhookSender
 }
 
 func New(repo issueTrackerRepository, cfg config.App, wg *sync.WaitGroup, logger *zap.Logger) *Controller {
-	return &Controller{repo, cfg, wg, logger}
+	c := &Controller{
+		repo:     repo,
+		Config:   cfg,
+		wg:       wg,
+		Logger:   logger,
+		settings: newRuntimeSettings(cfg),
+		webhooks: webhook.New(),
+	}
+	mailer := mailer.New(cfg.Smtp.Host, cfg.Smtp.Port, cfg.Smtp.Username, cfg.Smtp.Password, cfg.Smtp.Sender)
+	c.emails = c.startEmailPool(mailer, cfg.Smtp.Workers)
+	return c
 }
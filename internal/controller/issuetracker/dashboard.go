@@ -0,0 +1,56 @@
+package issuetracker
+
+import (
+	"context"
+
+	"github.com/emzola/issuetracker/pkg/model"
+	"golang.org/x/sync/errgroup"
+)
+
+// dashboardPageSize is the number of items listed in each part of a dashboard.
+const dashboardPageSize = 5
+
+// GetDashboard returns the user's dashboard. Its open assigned issues, reported issues
+// and projects are fetched concurrently, and the first error cancels the others.
+func (c *Controller) GetDashboard(ctx context.Context, user *model.User) (*model.Dashboard, error) {
+	dashboard := &model.Dashboard{}
+	g, ctx := errgroup.WithContext(ctx)
+	g.Go(func() error {
+		// Whether an issue is open depends on its project's workflow, so every open
+		// assigned issue is read to count them.
+		issues, err := c.openAssignedIssues(ctx, user, map[int64]*model.Workflow{})
+		if err != nil {
+			return err
+		}
+		dashboard.AssignedIssuesCount = len(issues)
+		if len(issues) > dashboardPageSize {
+			issues = issues[:dashboardPageSize]
+		}
+		dashboard.AssignedIssues = issues
+		return nil
+	})
+	g.Go(func() error {
+		filters := model.Filters{Page: 1, PageSize: dashboardPageSize, Sort: "-id", SortSafelist: []string{"-id"}}
+		issues, metadata, err := c.repo.GetAllIssues(ctx, model.IssueFilters{ReporterID: user.ID}, user.ID, filters)
+		if err != nil {
+			return err
+		}
+		dashboard.ReportedIssues = issues
+		dashboard.ReportedIssuesCount = metadata.TotalRecords
+		return nil
+	})
+	g.Go(func() error {
+		filters := model.Filters{Page: 1, PageSize: dashboardPageSize, Sort: "name", SortSafelist: []string{"name"}}
+		projects, metadata, err := c.repo.GetAllProjectsForUser(ctx, user.ID, filters)
+		if err != nil {
+			return err
+		}
+		dashboard.Projects = projects
+		dashboard.ProjectsCount = metadata.TotalRecords
+		return nil
+	})
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	return dashboard, nil
+}
@@ -0,0 +1,62 @@
+package issuetracker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/emzola/issuetracker/config"
+	"github.com/emzola/issuetracker/pkg/model"
+	"go.uber.org/zap"
+)
+
+// fakeDashboardRepository holds issues and the projects of the user whose dashboard is
+// fetched. Projects use the default workflow.
+type fakeDashboardRepository struct {
+	fakeRepository
+	userProjects []*model.Project
+	projectsErr  error
+}
+
+func (r *fakeDashboardRepository) GetAllProjectsForUser(ctx context.Context, userID int64, filters model.Filters) ([]*model.Project, model.Metadata, error) {
+	if r.projectsErr != nil {
+		return nil, model.Metadata{}, r.projectsErr
+	}
+	return r.userProjects, model.CalculateMetadata(len(r.userProjects), filters.Page, filters.PageSize), nil
+}
+
+func TestGetDashboard(t *testing.T) {
+	ada, grace := int64(1), int64(2)
+	repo := &fakeDashboardRepository{
+		fakeRepository: fakeRepository{issues: map[int64]*model.Issue{
+			8:  {ID: 8, ProjectID: 1, ReporterID: grace, AssignedTo: &ada, Status: "closed"},
+			9:  {ID: 9, ProjectID: 1, ReporterID: ada, Status: "open"},
+			10: {ID: 10, ProjectID: 1, ReporterID: ada, AssignedTo: &grace, Status: "closed"},
+		}},
+		userProjects: []*model.Project{{ID: 1, Name: "Issue Tracker"}},
+	}
+	for id := int64(1); id <= 7; id++ {
+		repo.issues[id] = &model.Issue{ID: id, ProjectID: 1, ReporterID: grace, AssignedTo: &ada, Status: "open"}
+	}
+	var wg sync.WaitGroup
+	c := New(repo, config.App{}, &wg, zap.NewNop())
+	dashboard, err := c.GetDashboard(context.Background(), &model.User{ID: ada})
+	if err != nil {
+		t.Fatalf("GetDashboard() error = %v", err)
+	}
+	if len(dashboard.AssignedIssues) != dashboardPageSize || dashboard.AssignedIssuesCount != 7 {
+		t.Errorf("GetDashboard() listed %d of %d assigned issues, want %d of 7", len(dashboard.AssignedIssues), dashboard.AssignedIssuesCount, dashboardPageSize)
+	}
+	if len(dashboard.ReportedIssues) != 2 || dashboard.ReportedIssuesCount != 2 {
+		t.Errorf("GetDashboard() listed %d of %d reported issues, want 2 of 2", len(dashboard.ReportedIssues), dashboard.ReportedIssuesCount)
+	}
+	if len(dashboard.Projects) != 1 || dashboard.ProjectsCount != 1 {
+		t.Errorf("GetDashboard() listed %d of %d projects, want 1 of 1", len(dashboard.Projects), dashboard.ProjectsCount)
+	}
+
+	repo.projectsErr = errors.New("connection refused")
+	if _, err := c.GetDashboard(context.Background(), &model.User{ID: ada}); !errors.Is(err, repo.projectsErr) {
+		t.Errorf("GetDashboard() error = %v, want %v", err, repo.projectsErr)
+	}
+}
@@ -0,0 +1,45 @@
+package issuetracker
+
+import (
+	"context"
+
+	"github.com/emzola/issuetracker/pkg/model"
+	"github.com/emzola/issuetracker/pkg/validator"
+)
+
+type dataIssuesRepository interface {
+	GetIssuesWithAssigneeNotOnProject(ctx context.Context, filters model.Filters) ([]*model.Issue, model.Metadata, error)
+	GetClosedIssuesWithoutResolutionSummary(ctx context.Context, filters model.Filters) ([]*model.Issue, model.Metadata, error)
+	GetClosedIssuesWithoutResolutionDate(ctx context.Context, filters model.Filters) ([]*model.Issue, model.Metadata, error)
+	GetIssuesWithTargetBeforeReported(ctx context.Context, filters model.Filters) ([]*model.Issue, model.Metadata, error)
+}
+
+// GetDataIssues returns issues with inconsistent data, grouped by the kind of
+// inconsistency, so that they can be cleaned up. Only managers can run the scan.
+func (c *Controller) GetDataIssues(ctx context.Context, user *model.User, filters model.Filters, v *validator.Validator) (*model.DataIssues, error) {
+	if user.Role != "manager" {
+		return nil, ErrNotPermitted
+	}
+	if filters.Validate(v); !v.Valid() {
+		return nil, failedValidationErr(v.Errors)
+	}
+	var dataIssues model.DataIssues
+	var err error
+	dataIssues.AssigneeNotOnProject.Issues, dataIssues.AssigneeNotOnProject.Metadata, err = c.repo.GetIssuesWithAssigneeNotOnProject(ctx, filters)
+	if err != nil {
+		return nil, err
+	}
+	dataIssues.ClosedWithoutResolutionSummary.Issues, dataIssues.ClosedWithoutResolutionSummary.Metadata, err = c.repo.GetClosedIssuesWithoutResolutionSummary(ctx, filters)
+	if err != nil {
+		return nil, err
+	}
+	dataIssues.ClosedWithoutResolutionDate.Issues, dataIssues.ClosedWithoutResolutionDate.Metadata, err = c.repo.GetClosedIssuesWithoutResolutionDate(ctx, filters)
+	if err != nil {
+		return nil, err
+	}
+	dataIssues.TargetBeforeReported.Issues, dataIssues.TargetBeforeReported.Metadata, err = c.repo.GetIssuesWithTargetBeforeReported(ctx, filters)
+	if err != nil {
+		return nil, err
+	}
+	return &dataIssues, nil
+}
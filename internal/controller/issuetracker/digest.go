@@ -0,0 +1,107 @@
+package issuetracker
+
+import (
+	"context"
+	"time"
+
+	"github.com/emzola/issuetracker/pkg/model"
+	"go.uber.org/zap"
+)
+
+type digestRepository interface {
+	GetDigestRecipients(ctx context.Context) ([]*model.User, error)
+}
+
+// StartDailyDigest sends the daily digest in a background goroutine once a day, at the
+// configured time of day, until ctx is cancelled.
+func (c *Controller) StartDailyDigest(ctx context.Context) {
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		timer := time.NewTimer(time.Until(nextDigest(time.Now(), c.Config.Digest.Time)))
+		defer timer.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-timer.C:
+				err := c.SendDailyDigests(ctx)
+				if err != nil {
+					c.Logger.Info("failed to send daily digests", zap.Error(err))
+				}
+				timer.Reset(time.Until(nextDigest(time.Now(), c.Config.Digest.Time)))
+			}
+		}
+	}()
+}
+
+// nextDigest returns the first time after now that is at the given time of day, as a
+// duration since midnight, in now's time zone.
+func nextDigest(now time.Time, at time.Duration) time.Time {
+	year, month, day := now.Date()
+	next := time.Date(year, month, day, 0, 0, 0, 0, now.Location()).Add(at)
+	if !next.After(now) {
+		next = time.Date(year, month, day+1, 0, 0, 0, 0, now.Location()).Add(at)
+	}
+	return next
+}
+
+// SendDailyDigests emails every user who opted in to the daily digest a summary of the
+// open issues assigned to them. Users without open assigned issues get no email.
+func (c *Controller) SendDailyDigests(ctx context.Context) error {
+	users, err := c.repo.GetDigestRecipients(ctx)
+	if err != nil {
+		return err
+	}
+	// Workflows are shared by the users' issues, so they are only fetched once per run.
+	workflows := map[int64]*model.Workflow{}
+	for _, user := range users {
+		issues, err := c.openAssignedIssues(ctx, user, workflows)
+		if err != nil {
+			return err
+		}
+		if len(issues) == 0 {
+			continue
+		}
+		data := map[string]any{
+			"name":   user.Name,
+			"issues": issues,
+		}
+		c.SendEmail(data, user.Email, user.Locale, "daily_digest.tmpl")
+	}
+	return nil
+}
+
+// openAssignedIssues returns the published issues assigned to the user that are not in a
+// closed state of their project's workflow.
+func (c *Controller) openAssignedIssues(ctx context.Context, user *model.User, workflows map[int64]*model.Workflow) ([]*model.Issue, error) {
+	filters := model.Filters{Page: 1, PageSize: 100, Sort: "id", SortSafelist: []string{"id"}}
+	issues := []*model.Issue{}
+	for {
+		page, metadata, err := c.repo.GetAllIssues(ctx, model.IssueFilters{AssignedTo: user.ID}, user.ID, filters)
+		if err != nil {
+			return nil, err
+		}
+		for _, issue := range page {
+			if issue.Draft {
+				continue
+			}
+			workflow, ok := workflows[issue.ProjectID]
+			if !ok {
+				workflow, err = c.projectWorkflow(ctx, issue.ProjectID)
+				if err != nil {
+					return nil, err
+				}
+				workflows[issue.ProjectID] = workflow
+			}
+			if state, _ := workflow.State(issue.Status); state.Category == "closed" {
+				continue
+			}
+			issues = append(issues, issue)
+		}
+		if filters.Page >= metadata.LastPage {
+			return issues, nil
+		}
+		filters.Page++
+	}
+}
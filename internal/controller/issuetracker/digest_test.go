@@ -0,0 +1,80 @@
+package issuetracker
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/emzola/issuetracker/config"
+	"github.com/emzola/issuetracker/pkg/model"
+	"go.uber.org/zap"
+)
+
+// fakeDigestRepository holds the users who opted in to the digest and the issues
+// assigned to them. Projects use the default workflow.
+type fakeDigestRepository struct {
+	fakeRepository
+	users []*model.User
+}
+
+func (r *fakeDigestRepository) GetDigestRecipients(ctx context.Context) ([]*model.User, error) {
+	return r.users, nil
+}
+
+func TestSendDailyDigests(t *testing.T) {
+	ada, grace := int64(1), int64(2)
+	repo := &fakeDigestRepository{
+		fakeRepository: fakeRepository{issues: map[int64]*model.Issue{
+			1: {ID: 1, ProjectID: 1, AssignedTo: &ada, Status: "open"},
+			2: {ID: 2, ProjectID: 1, AssignedTo: &ada, Status: "closed"},
+			3: {ID: 3, ProjectID: 1, AssignedTo: &ada, Status: "in progress", Draft: true},
+			4: {ID: 4, ProjectID: 1, AssignedTo: &grace, Status: "closed"},
+		}},
+		users: []*model.User{
+			{ID: ada, Name: "Ada Lovelace", Email: "ada@example.com"},
+			{ID: grace, Name: "Grace Hopper", Email: "grace@example.com"},
+		},
+	}
+	sender := newFakeEmailSender()
+	var wg sync.WaitGroup
+	c := New(repo, config.App{}, &wg, zap.NewNop())
+	c.emails = c.startEmailPool(sender, 1)
+	err := c.SendDailyDigests(context.Background())
+	if err != nil {
+		t.Fatalf("SendDailyDigests() error = %v", err)
+	}
+	wg.Wait()
+	// Users without open issues don't get a digest.
+	if _, ok := sender.sent["grace@example.com"]; ok {
+		t.Error("SendDailyDigests() sent a digest to a user without open issues")
+	}
+	data, ok := sender.sent["ada@example.com"].(map[string]any)
+	if !ok {
+		t.Fatal("SendDailyDigests() sent no digest to a user with open issues")
+	}
+	issues := data["issues"].([]*model.Issue)
+	if len(issues) != 1 || issues[0].ID != 1 {
+		t.Errorf("SendDailyDigests() digest issues = %v, want issue 1", issues)
+	}
+}
+
+func TestNextDigest(t *testing.T) {
+	at := 8 * time.Hour
+	tests := []struct {
+		name string
+		now  time.Time
+		want time.Time
+	}{
+		{"before the time", time.Date(2024, 3, 1, 7, 0, 0, 0, time.UTC), time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)},
+		{"at the time", time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC), time.Date(2024, 3, 2, 8, 0, 0, 0, time.UTC)},
+		{"after the time", time.Date(2024, 12, 31, 9, 0, 0, 0, time.UTC), time.Date(2025, 1, 1, 8, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextDigest(tt.now, at); !got.Equal(tt.want) {
+				t.Errorf("nextDigest(%v) = %v, want %v", tt.now, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package issuetracker
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+)
+
+// emailQueueSize is the number of emails that can wait for a worker before SendEmail
+// drops them.
+const emailQueueSize = 100
+
+// errEmailQueueFull is logged for emails dropped because the email queue is full.
+var errEmailQueueFull = errors.New("email queue is full")
+
+// emailSender sends templated emails. It is satisfied by mailer.Mailer.
+type emailSender interface {
+	Send(recipient, locale, templateFile string, data any) error
+}
+
+// email is an email waiting to be sent.
+type email struct {
+	data      any
+	recipient string
+	locale    string
+	template  string
+}
+
+// emailPool sends emails in the background with a fixed number of workers, and counts
+// the emails sent and failed.
+type emailPool struct {
+	sender emailSender
+	queue  chan email
+	sent   atomic.Int64
+	failed atomic.Int64
+}
+
+// startEmailPool starts the workers of an email pool. The workers run for the lifetime
+// of the process.
+func (c *Controller) startEmailPool(sender emailSender, workers int) *emailPool {
+	if workers < 1 {
+		workers = 1
+	}
+	pool := &emailPool{
+		sender: sender,
+		queue:  make(chan email, emailQueueSize),
+	}
+	for i := 0; i < workers; i++ {
+		go func() {
+			for e := range pool.queue {
+				c.deliverEmail(pool, e)
+			}
+		}()
+	}
+	return pool
+}
+
+// SendEmail is a helper function which the service layer uses to send emails in the
+// background. It accepts the template data, recipient, the recipient's locale and
+// template. Emails are queued for the email workers. SendEmail never blocks its caller:
+// while the queue is full, emails are dropped and counted as failed. Failures are
+// logged, since there is no one left to return them to.
+func (c *Controller) SendEmail(data any, recipient, locale, template string) {
+	e := email{data: data, recipient: recipient, locale: locale, template: template}
+	// Queued emails are pending background tasks, so that shutdown waits for them.
+	c.wg.Add(1)
+	select {
+	case c.emails.queue <- e:
+	default:
+		c.wg.Done()
+		c.emailFailed(c.emails, e, errEmailQueueFull)
+	}
+}
+
+// sendEmails queues a batch of emails, such as the invites of a user import, which may
+// not all fit in the queue at once. Unlike SendEmail, it doesn't drop emails while the
+// queue is full: a background goroutine waits for room instead, so the caller doesn't.
+func (c *Controller) sendEmails(emails []email) {
+	if len(emails) == 0 {
+		return
+	}
+	c.wg.Add(len(emails))
+	go func() {
+		for _, e := range emails {
+			c.emails.queue <- e
+		}
+	}()
+}
+
+// deliverEmail sends a queued email and logs the outcome along with the number of
+// emails sent and failed so far.
+func (c *Controller) deliverEmail(pool *emailPool, e email) {
+	defer c.wg.Done()
+	defer func() {
+		if err := recover(); err != nil {
+			c.emailFailed(pool, e, fmt.Errorf("%s", err))
+		}
+	}()
+	err := pool.sender.Send(e.recipient, e.locale, e.template, e.data)
+	if err != nil {
+		c.emailFailed(pool, e, err)
+		return
+	}
+	sent := pool.sent.Add(1)
+	c.Logger.Info("email sent", zap.String("template", e.template), zap.Int64("emails_sent", sent), zap.Int64("emails_failed", pool.failed.Load()))
+}
+
+// emailFailed counts and logs an email that couldn't be sent.
+func (c *Controller) emailFailed(pool *emailPool, e email, err error) {
+	failed := pool.failed.Add(1)
+	c.Logger.Error("failed to send email", zap.String("template", e.template), zap.Error(err), zap.Int64("emails_sent", pool.sent.Load()), zap.Int64("emails_failed", failed))
+}
@@ -0,0 +1,104 @@
+package issuetracker
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/emzola/issuetracker/config"
+	"go.uber.org/zap"
+)
+
+// fakeEmailSender records the emails sent and the number of emails being sent at the
+// same time. Sends block until release is closed, and panic for the recipient panicOn.
+type fakeEmailSender struct {
+	mu        sync.Mutex
+	sent      map[string]any // recipient to data
+	active    int
+	maxActive int
+	started   chan struct{}
+	release   chan struct{}
+	panicOn   string
+}
+
+// newFakeEmailSender returns a fake email sender whose sends don't block.
+func newFakeEmailSender() *fakeEmailSender {
+	sender := &fakeEmailSender{started: make(chan struct{}, emailQueueSize), release: make(chan struct{})}
+	close(sender.release)
+	return sender
+}
+
+func (s *fakeEmailSender) Send(recipient, locale, templateFile string, data any) error {
+	if recipient == s.panicOn {
+		panic("template exploded")
+	}
+	s.mu.Lock()
+	if s.sent == nil {
+		s.sent = map[string]any{}
+	}
+	s.sent[recipient] = data
+	s.active++
+	if s.active > s.maxActive {
+		s.maxActive = s.active
+	}
+	s.mu.Unlock()
+	s.started <- struct{}{}
+	<-s.release
+	s.mu.Lock()
+	s.active--
+	s.mu.Unlock()
+	return nil
+}
+
+func TestSendEmailBoundsConcurrency(t *testing.T) {
+	sender := &fakeEmailSender{started: make(chan struct{}, 10), release: make(chan struct{})}
+	var wg sync.WaitGroup
+	c := New(nil, config.App{}, &wg, zap.NewNop())
+	c.emails = c.startEmailPool(sender, 2)
+	for i := 0; i < 6; i++ {
+		c.SendEmail(map[string]string{}, "ada@example.com", "en", "user_welcome.tmpl")
+	}
+	// Wait for both workers to be busy before letting the sends finish.
+	<-sender.started
+	<-sender.started
+	close(sender.release)
+	wg.Wait()
+	if sender.maxActive != 2 {
+		t.Errorf("SendEmail() sent %d emails at the same time, want 2", sender.maxActive)
+	}
+	if sent := c.emails.sent.Load(); sent != 6 {
+		t.Errorf("SendEmail() counted %d emails sent, want 6", sent)
+	}
+}
+
+func TestSendEmailRecoversPanic(t *testing.T) {
+	sender := newFakeEmailSender()
+	sender.panicOn = "grace@example.com"
+	var wg sync.WaitGroup
+	c := New(nil, config.App{}, &wg, zap.NewNop())
+	c.emails = c.startEmailPool(sender, 1)
+	c.SendEmail(map[string]string{}, "grace@example.com", "en", "user_welcome.tmpl")
+	c.SendEmail(map[string]string{}, "ada@example.com", "en", "user_welcome.tmpl")
+	// The worker survives the panic and sends the next email.
+	wg.Wait()
+	if failed, sent := c.emails.failed.Load(), c.emails.sent.Load(); failed != 1 || sent != 1 {
+		t.Errorf("SendEmail() counted %d emails failed and %d sent, want 1 and 1", failed, sent)
+	}
+}
+
+func TestSendEmailDropsWhenQueueFull(t *testing.T) {
+	sender := &fakeEmailSender{started: make(chan struct{}, emailQueueSize+2), release: make(chan struct{})}
+	var wg sync.WaitGroup
+	c := New(nil, config.App{}, &wg, zap.NewNop())
+	c.emails = c.startEmailPool(sender, 1)
+	c.SendEmail(map[string]string{}, "ada@example.com", "en", "user_welcome.tmpl")
+	// Wait for the worker to be busy, then fill the queue and overflow it by one.
+	<-sender.started
+	for i := 0; i <= emailQueueSize; i++ {
+		c.SendEmail(map[string]string{}, "ada@example.com", "en", "user_welcome.tmpl")
+	}
+	close(sender.release)
+	wg.Wait()
+	if failed, sent := c.emails.failed.Load(), c.emails.sent.Load(); failed != 1 || sent != emailQueueSize+1 {
+		t.Errorf("SendEmail() counted %d emails failed and %d sent, want 1 and %d", failed, sent, emailQueueSize+1)
+	}
+}
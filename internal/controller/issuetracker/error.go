@@ -5,29 +5,57 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+
+	"github.com/emzola/issuetracker/pkg/model"
 )
 
 var (
 	ErrNotFound           = errors.New("not found")
 	ErrFailedValidation   = errors.New("failed validation")
 	ErrEditConflict       = errors.New("edit conflict")
+	ErrPreconditionFailed = errors.New("precondition failed")
 	ErrInvalidCredentials = errors.New("invalid credentials")
 	ErrInvalidRole        = errors.New("invalid role")
-	ErrActivated          = errors.New("invalid role")
+	ErrActivated          = errors.New("already activated")
 	ErrNotPermitted       = errors.New("not permitted")
+	ErrRolledBack         = errors.New("rolled back")
+	ErrReportedIssues     = errors.New("user reported issues")
 )
 
-// failedValidationErr loops through an errors map and returns ErrFailedValidation
-// which contains the keys and values of the errors map.
-func failedValidationErr(errors map[string]string) error {
-	if len(errors) == 0 {
-		return nil
+// TargetEndDateConflictError is returned when a project's target end date is moved
+// before the target resolution date of issues in the project.
+type TargetEndDateConflictError struct {
+	Issues []*model.Issue
+}
+
+func (e *TargetEndDateConflictError) Error() string {
+	return fmt.Sprintf("target end date is before the target resolution date of %d issues", len(e.Issues))
+}
+
+// OpenIssuesError is returned when a user with open issues assigned to them is deleted
+// without force.
+type OpenIssuesError struct {
+	Count int
+}
+
+func (e *OpenIssuesError) Error() string {
+	return fmt.Sprintf("user has %d open issues assigned", e.Count)
+}
+
+// ValidationError is the error returned when validation fails. Fields maps each field
+// that failed validation to the reason, and its message lists them. It wraps
+// ErrFailedValidation, so that errors.Is matches every validation error against it.
+type ValidationError struct {
+	Fields map[string]string
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Fields) == 0 {
+		return ErrFailedValidation.Error()
 	}
-	keys := make([]string, len(errors))
-	i := 0
-	for key := range errors {
-		keys[i] = key
-		i++
+	keys := make([]string, 0, len(e.Fields))
+	for key := range e.Fields {
+		keys = append(keys, key)
 	}
 	sort.Strings(keys)
 	var s strings.Builder
@@ -35,9 +63,22 @@ func failedValidationErr(errors map[string]string) error {
 		if i > 0 {
 			s.WriteString("; ")
 		}
-		fmt.Fprintf(&s, "%v: %v", key, errors[key])
+		fmt.Fprintf(&s, "%v: %v", key, e.Fields[key])
 	}
 	s.WriteString(".")
-	ErrFailedValidation = fmt.Errorf("%s", s.String())
+	return s.String()
+}
+
+func (e *ValidationError) Unwrap() error {
 	return ErrFailedValidation
 }
+
+// failedValidationErr returns a ValidationError for the errors map of a validator, or nil
+// if the map is empty. Each call returns a new error, so concurrent requests never see
+// each other's fields.
+func failedValidationErr(errors map[string]string) error {
+	if len(errors) == 0 {
+		return nil
+	}
+	return &ValidationError{Fields: errors}
+}
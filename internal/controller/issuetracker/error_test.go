@@ -0,0 +1,41 @@
+package issuetracker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/emzola/issuetracker/config"
+	"go.uber.org/zap"
+)
+
+// TestFailedValidationConcurrent checks that concurrent validation failures each get
+// their own fields and still match ErrFailedValidation. Run it with -race.
+func TestFailedValidationConcurrent(t *testing.T) {
+	var wg sync.WaitGroup
+	c := New(nil, config.App{}, &wg, zap.NewNop())
+	sentinel := ErrFailedValidation
+	var requests sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		requests.Add(1)
+		go func(i int) {
+			defer requests.Done()
+			email := fmt.Sprintf("user-%d", i)
+			_, err := c.GetUserByEmail(context.Background(), email)
+			if !errors.Is(err, ErrFailedValidation) {
+				t.Errorf("GetUserByEmail(%q) error = %v, want ErrFailedValidation", email, err)
+				return
+			}
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) || validationErr.Fields["email"] == "" {
+				t.Errorf("GetUserByEmail(%q) error = %v, want an email field error", email, err)
+			}
+		}(i)
+	}
+	requests.Wait()
+	if ErrFailedValidation != sentinel {
+		t.Errorf("ErrFailedValidation changed to %v", ErrFailedValidation)
+	}
+}
@@ -0,0 +1,113 @@
+package issuetracker
+
+import (
+	"context"
+	"sort"
+
+	"github.com/emzola/issuetracker/internal/repository"
+	"github.com/emzola/issuetracker/pkg/model"
+)
+
+// fakeRepository is the in-memory repository the controller tests build on. It serves
+// the issues, projects and project members it holds, with the workflow, webhooks and
+// watchers set on it, and records the issues it saves. Test fakes embed it and
+// override or add the methods their tests need. Methods that are neither implemented
+// here nor overridden are not expected to be called.
+type fakeRepository struct {
+	issueTrackerRepository
+	issues   map[int64]*model.Issue
+	projects map[int64]*model.Project
+	members  map[int64]*model.User
+	states   []model.WorkflowState
+	webhooks []*model.Webhook
+	watchers []*model.Watcher
+	saved    []*model.Issue
+	activity []*model.IssueActivity
+}
+
+func (r *fakeRepository) GetIssue(ctx context.Context, id int64) (*model.Issue, error) {
+	issue, ok := r.issues[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	found := *issue
+	return &found, nil
+}
+
+func (r *fakeRepository) UpdateIssue(ctx context.Context, issue *model.Issue, activity []*model.IssueActivity) error {
+	r.saved = append(r.saved, issue)
+	r.activity = append(r.activity, activity...)
+	return nil
+}
+
+func (r *fakeRepository) UpdateIssues(ctx context.Context, issues []*model.Issue, activity [][]*model.IssueActivity) error {
+	for i, issue := range issues {
+		if err := r.UpdateIssue(ctx, issue, activity[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetAllIssues serves the page of issues, in ID order, that match the project, assignee
+// and reporter filters.
+func (r *fakeRepository) GetAllIssues(ctx context.Context, issueFilters model.IssueFilters, viewerID int64, filters model.Filters) ([]*model.Issue, model.Metadata, error) {
+	issues := []*model.Issue{}
+	for _, issue := range r.issues {
+		if issueFilters.ProjectID != 0 && issue.ProjectID != issueFilters.ProjectID {
+			continue
+		}
+		if issueFilters.AssignedTo != 0 && (issue.AssignedTo == nil || *issue.AssignedTo != issueFilters.AssignedTo) {
+			continue
+		}
+		if issueFilters.ReporterID != 0 && issue.ReporterID != issueFilters.ReporterID {
+			continue
+		}
+		issues = append(issues, issue)
+	}
+	sort.Slice(issues, func(i, j int) bool { return issues[i].ID < issues[j].ID })
+	metadata := model.CalculateMetadata(len(issues), filters.Page, filters.PageSize)
+	if filters.Offset() >= len(issues) {
+		return []*model.Issue{}, metadata, nil
+	}
+	issues = issues[filters.Offset():]
+	if len(issues) > filters.Limit() {
+		issues = issues[:filters.Limit()]
+	}
+	return issues, metadata, nil
+}
+
+func (r *fakeRepository) GetProject(ctx context.Context, id int64) (*model.Project, error) {
+	project, ok := r.projects[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	found := *project
+	return &found, nil
+}
+
+func (r *fakeRepository) GetProjectUser(ctx context.Context, projectID, userID int64) (*model.User, error) {
+	user, ok := r.members[userID]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return user, nil
+}
+
+func (r *fakeRepository) GetProjectWorkflow(ctx context.Context, projectID int64) ([]model.WorkflowState, error) {
+	return r.states, nil
+}
+
+func (r *fakeRepository) GetProjectWebhooks(ctx context.Context, projectID int64) ([]*model.Webhook, error) {
+	return r.webhooks, nil
+}
+
+func (r *fakeRepository) GetIssueWatchers(ctx context.Context, issueID int64) ([]*model.Watcher, error) {
+	return r.watchers, nil
+}
+
+// fakeProjects holds a single project with the given notification channels, for
+// seeding fakeRepository.projects.
+func fakeProjects(id int64, channels ...string) map[int64]*model.Project {
+	return map[int64]*model.Project{id: {ID: id, NotificationChannels: channels}}
+}
@@ -0,0 +1,12 @@
+package issuetracker
+
+import "context"
+
+type healthRepository interface {
+	Ping(ctx context.Context) error
+}
+
+// Ready checks that the controller's dependencies, such as the database, are reachable.
+func (c *Controller) Ready(ctx context.Context) error {
+	return c.repo.Ping(ctx)
+}
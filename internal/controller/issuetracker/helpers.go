@@ -1,27 +1,23 @@
 package issuetracker
 
 import (
-	"fmt"
+	"context"
 
-	"github.com/emzola/issuetracker/pkg/mailer"
+	"github.com/emzola/issuetracker/pkg/validator"
 	"go.uber.org/zap"
 )
 
-// SendEmail is a helper function which the service layer uses to send emails
-// in a background goroutine. It accepts a data map, recipient and template.
-func (c *Controller) SendEmail(data map[string]string, recipient, template string) {
-	c.wg.Add(1)
-	go func() {
-		defer c.wg.Done()
-		defer func() {
-			if err := recover(); err != nil {
-				c.Logger.Info(fmt.Sprintf("%s", err))
-			}
-		}()
-		mailer := mailer.New(c.Config.Smtp.Host, c.Config.Smtp.Port, c.Config.Smtp.Username, c.Config.Smtp.Password, c.Config.Smtp.Sender)
-		err := mailer.Send(recipient, template, data)
-		if err != nil {
-			c.Logger.Info("failed to send email", zap.Error(err))
-		}
-	}()
+// notifyIssueEvent emails an issue event notification, if email is enabled on the
+// issue's project. It accepts the project ID, a data map, recipient, the recipient's
+// locale and template. Webhooks are sent by dispatchWebhooks.
+// Failures are logged rather than returned, since the event has already happened.
+func (c *Controller) notifyIssueEvent(ctx context.Context, projectID int64, data map[string]string, recipient, locale, template string) {
+	project, err := c.repo.GetProject(ctx, projectID)
+	if err != nil {
+		c.Logger.Info("failed to load project notification channels", zap.Error(err))
+		return
+	}
+	if validator.In("email", project.NotificationChannels...) {
+		c.SendEmail(data, recipient, locale, template)
+	}
 }
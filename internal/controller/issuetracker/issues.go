@@ -3,40 +3,79 @@ package issuetracker
 import (
 	"context"
 	"errors"
+	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/emzola/issuetracker/internal/repository"
 	"github.com/emzola/issuetracker/pkg/model"
 	"github.com/emzola/issuetracker/pkg/validator"
+	"go.uber.org/zap"
 )
 
 type issueRepository interface {
 	CreateIssue(ctx context.Context, issue *model.Issue) error
+	CreateIssueWithComment(ctx context.Context, issue *model.Issue, comment *model.Comment) error
 	GetIssue(ctx context.Context, id int64) (*model.Issue, error)
-	GetAllIssues(ctx context.Context, title string, reportedDate time.Time, projectID, assignedTo int64, status, priority string, filters model.Filters) ([]*model.Issue, model.Metadata, error)
-	UpdateIssue(ctx context.Context, issue *model.Issue) error
-	DeleteIssue(ctx context.Context, id int64) error
+	GetAllIssues(ctx context.Context, issueFilters model.IssueFilters, viewerID int64, filters model.Filters) ([]*model.Issue, model.Metadata, error)
+	ExportIssues(ctx context.Context, issueFilters model.IssueFilters, viewerID int64, sort model.Filters, fn func(*model.IssueExport) error) error
+	UpdateIssue(ctx context.Context, issue *model.Issue, activity []*model.IssueActivity) error
+	UpdateIssues(ctx context.Context, issues []*model.Issue, activity [][]*model.IssueActivity) error
+	DeleteIssue(ctx context.Context, id int64, activity []*model.IssueActivity) error
+	RestoreIssue(ctx context.Context, id int64, activity []*model.IssueActivity) error
+	PurgeIssue(ctx context.Context, id int64, purgedBy string) error
+	GetUserInvolvedIssues(ctx context.Context, userID int64, involvement string, viewerID int64, viewerRole string, filters model.Filters) ([]*model.Issue, model.Metadata, error)
+	GetOpenIssuesAssignedTo(ctx context.Context, userID int64) ([]*model.Issue, error)
+	GetIssuesTargetedAfter(ctx context.Context, projectID int64, date time.Time) ([]*model.Issue, error)
 }
 
-func (c *Controller) CreateIssue(ctx context.Context, title, description string, reporterID, projectID int64, assignedTo *int64, priority, targetResolutionDate, createdBy, modifiedBy string) (*model.Issue, error) {
+// CreateIssue creates an issue. If comment is not empty, it is added as the issue's first
+// comment by the reporter, and the issue is only created along with it.
+func (c *Controller) CreateIssue(ctx context.Context, title, description string, reporterID, projectID int64, assignedTo *int64, priority, issueType, targetResolutionDate string, milestoneID *int64, estimatedHours *float64, draft bool, comment, createdBy, modifiedBy string) (*model.Issue, *model.Comment, error) {
+	priority = model.NormalizePriority(priority)
 	if priority == "" {
 		priority = "low"
 	}
+	if issueType == "" {
+		issueType = "bug"
+	}
+	project, err := c.repo.GetProject(ctx, projectID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return nil, nil, ErrNotFound
+		default:
+			return nil, nil, err
+		}
+	}
+	// Archived projects don't accept new issues.
+	v := validator.New()
+	if v.Check(!project.Archived, "project_id", "must not be an archived project"); !v.Valid() {
+		return nil, nil, failedValidationErr(v.Errors)
+	}
+	// New issues start in the first state of the project's workflow.
+	workflow, err := c.projectWorkflow(ctx, projectID)
+	if err != nil {
+		return nil, nil, err
+	}
 	issue := &model.Issue{
-		Title:       title,
-		Description: description,
-		ReporterID:  reporterID,
-		ProjectID:   projectID,
-		Priority:    priority,
-		Status:      "open",
-		CreatedBy:   createdBy,
-		ModifiedBy:  modifiedBy,
+		Title:          title,
+		Description:    description,
+		ReporterID:     reporterID,
+		ProjectID:      projectID,
+		Priority:       priority,
+		Type:           issueType,
+		Status:         workflow.Initial(),
+		EstimatedHours: estimatedHours,
+		Draft:          draft,
+		CreatedBy:      createdBy,
+		ModifiedBy:     modifiedBy,
 	}
 	if targetResolutionDate != "" {
 		targetResolution, err := time.Parse("2006-01-02", targetResolutionDate)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		issue.TargetResolutionDate = targetResolution
 	}
@@ -44,45 +83,78 @@ func (c *Controller) CreateIssue(ctx context.Context, title, description string,
 	// Before issue is assigned, attempt to fetch the assignee. If the assignee's role is
 	// not 'member', return an error.
 	var assignee *model.User
-	var err error
 	if assignedTo != nil {
 		assignee, err = c.repo.GetProjectUser(ctx, issue.ProjectID, *assignedTo)
 		if err != nil {
 			switch {
 			case errors.Is(err, repository.ErrNotFound):
-				return nil, ErrNotFound
+				return nil, nil, ErrNotFound
 			default:
-				return nil, err
+				return nil, nil, err
 			}
 		}
 		if assignee.Role != "member" {
-			return nil, ErrInvalidRole
+			return nil, nil, ErrInvalidRole
 		}
 		// Assign issue to member
 		issue.AssignedTo = &assignee.ID
 	}
-	v := validator.New()
-	if issue.Validate(v); !v.Valid() {
-		return nil, failedValidationErr(v.Errors)
+	if milestoneID != nil {
+		err = c.setIssueMilestone(ctx, issue, *milestoneID, v)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	if issue.Draft {
+		issue.ValidateDraft(v)
+	} else {
+		issue.Validate(v)
+	}
+	var firstComment *model.Comment
+	if comment != "" {
+		firstComment = &model.Comment{UserID: reporterID, Body: comment}
+		cv := validator.New()
+		if firstComment.Validate(cv); !cv.Valid() {
+			v.AddError("comment", cv.Errors["body"])
+		}
+	}
+	if !v.Valid() {
+		return nil, nil, failedValidationErr(v.Errors)
+	}
+	if firstComment != nil {
+		err = c.repo.CreateIssueWithComment(ctx, issue, firstComment)
+	} else {
+		err = c.repo.CreateIssue(ctx, issue)
 	}
-	err = c.repo.CreateIssue(ctx, issue)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	// Send email notification to assigned user if issue is assigned.
-	if assignedTo != nil {
+	// Keep the reporter informed of progress on the issue. Failures are logged rather
+	// than returned, since the issue has already been created.
+	if c.Config.Watchers.AutoWatchReporter {
+		err = c.repo.SubscribeToIssue(ctx, issue.ID, issue.ReporterID)
+		if err != nil {
+			c.Logger.Error("failed to subscribe reporter to issue", zap.Int64("issue_id", issue.ID), zap.Error(err))
+		}
+	}
+	// Send email notification to assigned user if issue is assigned. Notifications
+	// for drafts are sent when the draft is published.
+	if assignedTo != nil && !issue.Draft {
 		data := map[string]string{
 			"name":          assignee.Name,
 			"issueID":       strconv.Itoa(int(issue.ID)),
 			"issueTitle":    issue.Title,
 			"issuePriority": issue.Priority,
 		}
-		c.SendEmail(data, assignee.Email, "issue_assign.tmpl")
+		c.notifyIssueEvent(ctx, issue.ProjectID, data, assignee.Email, assignee.Locale, "issue_assign.tmpl")
 	}
-	return issue, nil
+	if !issue.Draft {
+		c.dispatchWebhooks(ctx, "issue.created", issue)
+	}
+	return issue, firstComment, nil
 }
 
-func (c *Controller) GetIssue(ctx context.Context, id int64) (*model.Issue, error) {
+func (c *Controller) GetIssue(ctx context.Context, id int64, user *model.User) (*model.Issue, error) {
 	issue, err := c.repo.GetIssue(ctx, id)
 	if err != nil {
 		switch {
@@ -92,29 +164,144 @@ func (c *Controller) GetIssue(ctx context.Context, id int64) (*model.Issue, erro
 			return nil, err
 		}
 	}
+	// Drafts are only visible to their reporter.
+	if issue.Draft && issue.ReporterID != user.ID {
+		return nil, ErrNotFound
+	}
 	return issue, nil
 }
 
-func (c *Controller) GetAllIssues(ctx context.Context, title, reportedDate string, projectID, assignedTo int64, status, priority string, filters model.Filters, v *validator.Validator) ([]*model.Issue, model.Metadata, error) {
+// GetAllIssues returns the issues matching issueFilters. Only managers can include
+// deleted issues.
+func (c *Controller) GetAllIssues(ctx context.Context, issueFilters model.IssueFilters, user *model.User, filters model.Filters, v *validator.Validator) ([]*model.Issue, model.Metadata, error) {
+	if issueFilters.IncludeDeleted && user.Role != "manager" {
+		return nil, model.Metadata{}, ErrNotPermitted
+	}
+	issueFilters = validateIssueFilters(v, issueFilters)
 	if filters.Validate(v); !v.Valid() {
 		return nil, model.Metadata{}, failedValidationErr(v.Errors)
 	}
-	var reported time.Time
-	var err error
-	if reportedDate != "" {
-		reported, err = time.Parse("2006-01-02", reportedDate)
-		if err != nil {
-			return nil, model.Metadata{}, err
-		}
-	}
-	issues, metadata, err := c.repo.GetAllIssues(ctx, title, reported, projectID, assignedTo, status, priority, filters)
+	issues, metadata, err := c.repo.GetAllIssues(ctx, issueFilters, user.ID, filters)
 	if err != nil {
 		return nil, model.Metadata{}, err
 	}
 	return issues, metadata, nil
 }
 
-func (c *Controller) UpdateIssue(ctx context.Context, id int64, title, description *string, assignedTo *int64, status, priority, targetResolutionDate, progress, actualResolutionDate, resolutionSummary *string, user *model.User) (*model.Issue, error) {
+// ExportIssues calls fn with each of a project's issues that match issueFilters, which
+// are validated like GetAllIssues'. Exports are only paginated if sort has a page size,
+// which can be larger than GetAllIssues'. Issues are passed to fn as they are read, so
+// that large exports aren't held in memory.
+func (c *Controller) ExportIssues(ctx context.Context, issueFilters model.IssueFilters, user *model.User, sort model.Filters, v *validator.Validator, fn func(*model.IssueExport) error) error {
+	v.Check(issueFilters.ProjectID > 0, "project_id", "must be provided")
+	issueFilters = validateIssueFilters(v, issueFilters)
+	if sort.PageSize != 0 {
+		sort.Validate(v)
+	} else {
+		sort.ValidateSort(v)
+	}
+	if !v.Valid() {
+		return failedValidationErr(v.Errors)
+	}
+	return c.repo.ExportIssues(ctx, issueFilters, user.ID, sort, fn)
+}
+
+// validateIssueFilters checks the filters of issue lists and exports, and returns them
+// with the priority and labels in the form they are stored. unassigned only matches
+// issues without an assignee, and can't be combined with the assignee filters.
+func validateIssueFilters(v *validator.Validator, issueFilters model.IssueFilters) model.IssueFilters {
+	v.Check(validator.In(issueFilters.LabelMatch, model.LabelMatches...), "label_match", "must be any or all")
+	v.Check(!issueFilters.Unassigned || (issueFilters.AssignedTo == 0 && issueFilters.AssigneeName == ""), "unassigned", "must not be combined with assigned_to or assignee_name")
+	if issueFilters.Type != "" {
+		v.Check(validator.In(issueFilters.Type, model.IssueTypes...), "type", "must be bug, feature, task or improvement")
+	}
+	if issueFilters.Priority != "" {
+		issueFilters.Priority = model.NormalizePriority(issueFilters.Priority)
+		model.ValidatePriority(v, issueFilters.Priority)
+	}
+	if issueFilters.ReportedDate != "" {
+		parseDate(v, "reported_date", issueFilters.ReportedDate)
+	}
+	dateRange(v, "reported_from", issueFilters.ReportedFrom, "reported_to", issueFilters.ReportedTo)
+	dateRange(v, "target_from", issueFilters.TargetFrom, "target_to", issueFilters.TargetTo)
+	issueFilters.Labels = labelNames(issueFilters.Labels)
+	return issueFilters
+}
+
+// parseDate parses a date, reporting an error under key if it isn't a valid date.
+func parseDate(v *validator.Validator, key, value string) time.Time {
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		v.AddError(key, "must be a valid date (YYYY-MM-DD)")
+	}
+	return date
+}
+
+// dateRange parses the optional from and to bounds of a date range, reporting errors
+// under fromKey and toKey. Missing bounds are returned as the zero time.
+func dateRange(v *validator.Validator, fromKey, from, toKey, to string) (time.Time, time.Time) {
+	parse := func(key, value string) time.Time {
+		if value == "" {
+			return time.Time{}
+		}
+		return parseDate(v, key, value)
+	}
+	fromDate := parse(fromKey, from)
+	toDate := parse(toKey, to)
+	if !fromDate.IsZero() && !toDate.IsZero() {
+		v.Check(!fromDate.After(toDate), fromKey, "must not be after "+toKey)
+	}
+	return fromDate, toDate
+}
+
+// labelNames returns the label names to filter issues by. Label names are stored in
+// lowercase. Duplicates are dropped so that matching all labels compares against the
+// number of distinct labels.
+func labelNames(labels []string) []string {
+	names := []string{}
+	for _, label := range labels {
+		name := strings.ToLower(strings.TrimSpace(label))
+		if !validator.In(name, names...) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// UpdateIssue applies the changes to an issue. If version is not 0, the issue is only
+// updated if it is still at that version, and ErrPreconditionFailed is returned otherwise.
+func (c *Controller) UpdateIssue(ctx context.Context, id, version int64, title, description *string, assignedTo *int64, status, priority, issueType, targetResolutionDate, progress, actualResolutionDate, resolutionSummary *string, milestoneID *int64, estimatedHours *float64, user *model.User) (*model.Issue, error) {
+	update, err := c.prepareIssueUpdate(ctx, id, version, title, description, assignedTo, status, priority, issueType, targetResolutionDate, progress, actualResolutionDate, resolutionSummary, milestoneID, estimatedHours, user)
+	if err != nil {
+		return nil, err
+	}
+	err = c.repo.UpdateIssue(ctx, update.issue, issueActivity(&update.before, update.issue, user))
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrEditConflict):
+			return nil, ErrEditConflict
+		default:
+			return nil, err
+		}
+	}
+	c.issueUpdated(ctx, update, user)
+	return update.issue, nil
+}
+
+// issueUpdate holds an issue with changes that have been validated but not yet saved.
+type issueUpdate struct {
+	issue  *model.Issue
+	before model.Issue
+	// assignee is the user the issue is being assigned to, if any.
+	assignee *model.User
+	// closed is whether the update moves the issue into a closed state.
+	closed bool
+}
+
+// prepareIssueUpdate applies the changes to the issue, after checking that the user can
+// make them and, if version is not 0, that the issue is at that version, and validates
+// the result.
+func (c *Controller) prepareIssueUpdate(ctx context.Context, id, version int64, title, description *string, assignedTo *int64, status, priority, issueType, targetResolutionDate, progress, actualResolutionDate, resolutionSummary *string, milestoneID *int64, estimatedHours *float64, user *model.User) (*issueUpdate, error) {
 	issue, err := c.repo.GetIssue(ctx, id)
 	if err != nil {
 		switch {
@@ -124,11 +311,19 @@ func (c *Controller) UpdateIssue(ctx context.Context, id int64, title, descripti
 			return nil, err
 		}
 	}
+	// Drafts are only visible to, and editable by, their reporter.
+	if issue.Draft && issue.ReporterID != user.ID {
+		return nil, ErrNotFound
+	}
 	// Check whether user has permission to update issue. Besides managers and leads,
 	// members can update issue details only if it's assigned to or reported by them.
-	if user.Role == "member" && *issue.AssignedTo != user.ID && issue.ReporterID != user.ID {
+	if user.Role == "member" && (issue.AssignedTo == nil || *issue.AssignedTo != user.ID) && issue.ReporterID != user.ID {
 		return nil, ErrNotPermitted
 	}
+	if version != 0 && issue.Version != version {
+		return nil, ErrPreconditionFailed
+	}
+	update := &issueUpdate{issue: issue, before: *issue}
 	// At this point, update issue as usual.
 	if title != nil {
 		issue.Title = *title
@@ -139,9 +334,8 @@ func (c *Controller) UpdateIssue(ctx context.Context, id int64, title, descripti
 	// Issues can only be assigned to users with role 'member'.
 	// Before issue is assigned, attempt to fetch the assignee.
 	// If the assignee's role is not 'member', return an error.
-	var assignee *model.User
 	if assignedTo != nil {
-		assignee, err = c.repo.GetProjectUser(ctx, issue.ProjectID, *assignedTo)
+		assignee, err := c.repo.GetProjectUser(ctx, issue.ProjectID, *assignedTo)
 		if err != nil {
 			switch {
 			case errors.Is(err, repository.ErrNotFound):
@@ -155,12 +349,27 @@ func (c *Controller) UpdateIssue(ctx context.Context, id int64, title, descripti
 		}
 		// Assign issue to member
 		issue.AssignedTo = &assignee.ID
+		update.assignee = assignee
+	}
+	// Statuses are validated against the project's workflow, which also decides
+	// which status changes are allowed.
+	v := validator.New()
+	var workflow *model.Workflow
+	if status != nil || actualResolutionDate != nil {
+		workflow, err = c.projectWorkflow(ctx, issue.ProjectID)
+		if err != nil {
+			return nil, err
+		}
 	}
 	if status != nil {
+		workflow.ValidateTransition(v, issue.Status, *status)
 		issue.Status = *status
 	}
 	if priority != nil {
-		issue.Priority = *priority
+		issue.Priority = model.NormalizePriority(*priority)
+	}
+	if issueType != nil {
+		issue.Type = *issueType
 	}
 	if targetResolutionDate != nil {
 		targetResolution, err := time.Parse("2006-01-02", *targetResolutionDate)
@@ -178,17 +387,94 @@ func (c *Controller) UpdateIssue(ctx context.Context, id int64, title, descripti
 			return nil, err
 		}
 		issue.ActualResolutionDate = &actualResolution
-		issue.Status = "closed"
+		// Resolving an issue closes it, unless it is already in a closed state. Like
+		// status changes, issues can only be closed from the last open state.
+		if state, _ := workflow.State(issue.Status); state.Category != "closed" {
+			closed := workflow.FirstClosed()
+			v.Check(workflow.CanTransition(issue.Status, closed), "actual resolution date", fmt.Sprintf("cannot be set while the issue is %q", issue.Status))
+			issue.Status = closed
+		}
+	}
+	if workflow != nil {
+		before, _ := workflow.State(update.before.Status)
+		after, _ := workflow.State(issue.Status)
+		update.closed = before.Category != "closed" && after.Category == "closed"
 	}
 	if resolutionSummary != nil {
 		issue.ResolutionSummary = *resolutionSummary
 	}
-	issue.ModifiedBy = user.ModifiedBy
+	if milestoneID != nil {
+		err = c.setIssueMilestone(ctx, issue, *milestoneID, v)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if estimatedHours != nil {
+		issue.EstimatedHours = estimatedHours
+	}
+	issue.ModifiedBy = user.Name
+	if issue.Draft {
+		issue.ValidateDraft(v)
+	} else {
+		issue.Validate(v)
+	}
+	if !v.Valid() {
+		return nil, failedValidationErr(v.Errors)
+	}
+	return update, nil
+}
+
+// issueUpdated notifies the assignee, watchers and webhooks of a saved issue update.
+func (c *Controller) issueUpdated(ctx context.Context, update *issueUpdate, user *model.User) {
+	issue, assignee := update.issue, update.assignee
+	// Send email notification to assignee if issue is assigned.
+	if assignee != nil && !issue.Draft {
+		data := map[string]string{
+			"name":          assignee.Name,
+			"issueID":       strconv.Itoa(int(issue.ID)),
+			"issueTitle":    issue.Title,
+			"issuePriority": issue.Priority,
+		}
+		c.notifyIssueEvent(ctx, issue.ProjectID, data, assignee.Email, assignee.Locale, "issue_assign.tmpl")
+	}
+	// Notify watchers of changes to the issue's status, priority or assignee.
+	if changes := watchedChanges(&update.before, issue, assignee); changes != "" && !issue.Draft {
+		c.notifyWatchers(ctx, issue, changes, user)
+	}
+	if !issue.Draft {
+		event := "issue.updated"
+		if update.closed {
+			event = "issue.closed"
+		}
+		c.dispatchWebhooks(ctx, event, issue)
+	}
+}
+
+// ReopenIssue moves a closed issue back to the first state of its project's workflow and
+// clears its actual resolution date and resolution summary. Users who can update the
+// issue can reopen it.
+func (c *Controller) ReopenIssue(ctx context.Context, id int64, user *model.User) (*model.Issue, error) {
+	update, err := c.prepareIssueUpdate(ctx, id, 0, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, user)
+	if err != nil {
+		return nil, err
+	}
+	issue := update.issue
+	workflow, err := c.projectWorkflow(ctx, issue.ProjectID)
+	if err != nil {
+		return nil, err
+	}
 	v := validator.New()
+	if state, _ := workflow.State(issue.Status); state.Category != "closed" {
+		v.AddError("status", fmt.Sprintf("cannot reopen an issue that is %q", issue.Status))
+		return nil, failedValidationErr(v.Errors)
+	}
+	issue.Status = workflow.Initial()
+	issue.ActualResolutionDate = nil
+	issue.ResolutionSummary = ""
 	if issue.Validate(v); !v.Valid() {
 		return nil, failedValidationErr(v.Errors)
 	}
-	err = c.repo.UpdateIssue(ctx, issue)
+	err = c.repo.UpdateIssue(ctx, issue, issueActivity(&update.before, issue, user))
 	if err != nil {
 		switch {
 		case errors.Is(err, repository.ErrEditConflict):
@@ -197,21 +483,222 @@ func (c *Controller) UpdateIssue(ctx context.Context, id int64, title, descripti
 			return nil, err
 		}
 	}
-	// Send email notification to assignee if issue is assigned.
-	if assignedTo != nil {
-		data := map[string]string{
-			"name":          assignee.Name,
-			"issueID":       strconv.Itoa(int(issue.ID)),
-			"issueTitle":    issue.Title,
-			"issuePriority": issue.Priority,
+	c.issueUpdated(ctx, update, user)
+	return issue, nil
+}
+
+// AssignIssueToSelf assigns an unassigned issue to the user, who must be a member of the
+// issue's project. Like other assignees, they must have the member role. Issues already
+// assigned to the user are returned unchanged.
+func (c *Controller) AssignIssueToSelf(ctx context.Context, id int64, user *model.User) (*model.Issue, error) {
+	issue, err := c.repo.GetIssue(ctx, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return nil, ErrNotFound
+		default:
+			return nil, err
 		}
-		c.SendEmail(data, assignee.Email, "issue_assign.tmpl")
 	}
+	// Drafts are only visible to their reporter.
+	if issue.Draft && issue.ReporterID != user.ID {
+		return nil, ErrNotFound
+	}
+	assignee, err := c.repo.GetProjectUser(ctx, issue.ProjectID, user.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return nil, ErrNotPermitted
+		default:
+			return nil, err
+		}
+	}
+	if assignee.Role != "member" {
+		return nil, ErrInvalidRole
+	}
+	if issue.AssignedTo != nil {
+		if *issue.AssignedTo == user.ID {
+			return issue, nil
+		}
+		v := validator.New()
+		v.AddError("assigned_to", "issue is already assigned to another user")
+		return nil, failedValidationErr(v.Errors)
+	}
+	update := &issueUpdate{issue: issue, before: *issue, assignee: assignee}
+	issue.AssignedTo = &assignee.ID
+	issue.ModifiedBy = user.Name
+	err = c.repo.UpdateIssue(ctx, issue, issueActivity(&update.before, issue, user))
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrEditConflict):
+			return nil, ErrEditConflict
+		default:
+			return nil, err
+		}
+	}
+	c.issueUpdated(ctx, update, user)
 	return issue, nil
 }
 
-func (c *Controller) DeleteIssue(ctx context.Context, id int64) error {
-	err := c.repo.DeleteIssue(ctx, id)
+// maxBulkIssues is the maximum number of issues that can be updated in one bulk update.
+const maxBulkIssues = 100
+
+// BulkUpdateIssues applies the same status, priority and assignee changes to several
+// issues. Each issue is checked as if it were updated on its own, and the outcome for
+// each is returned in the order of ids: nil if it was updated, or the error that kept it
+// from being updated. The issues that pass the checks are saved in a single transaction,
+// so if saving any of them fails, none of them are saved and the others are reported
+// with ErrRolledBack.
+func (c *Controller) BulkUpdateIssues(ctx context.Context, ids []int64, assignedTo *int64, status, priority *string, user *model.User) ([]error, error) {
+	v := validator.New()
+	v.Check(len(ids) > 0, "issue_ids", "must contain at least one issue")
+	v.Check(len(ids) <= maxBulkIssues, "issue_ids", fmt.Sprintf("must not contain more than %d issues", maxBulkIssues))
+	v.Check(validator.Unique(ids), "issue_ids", "must not contain duplicate values")
+	v.Check(assignedTo != nil || status != nil || priority != nil, "issue_ids", "must be updated with at least one of status, priority or assigned_to")
+	if !v.Valid() {
+		return nil, failedValidationErr(v.Errors)
+	}
+	results := make([]error, len(ids))
+	var updates []*issueUpdate
+	var positions []int
+	for i, id := range ids {
+		update, err := c.prepareIssueUpdate(ctx, id, 0, nil, nil, assignedTo, status, priority, nil, nil, nil, nil, nil, nil, nil, user)
+		if err != nil {
+			if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+				return nil, err
+			}
+			results[i] = err
+			continue
+		}
+		updates = append(updates, update)
+		positions = append(positions, i)
+	}
+	if len(updates) == 0 {
+		return results, nil
+	}
+	issues := make([]*model.Issue, len(updates))
+	activity := make([][]*model.IssueActivity, len(updates))
+	for i, update := range updates {
+		issues[i] = update.issue
+		activity[i] = issueActivity(&update.before, update.issue, user)
+	}
+	err := c.repo.UpdateIssues(ctx, issues, activity)
+	if err != nil {
+		var batchErr *repository.BatchError
+		if !errors.As(err, &batchErr) {
+			return nil, err
+		}
+		for _, position := range positions {
+			results[position] = ErrRolledBack
+		}
+		switch {
+		case errors.Is(batchErr.Err, repository.ErrEditConflict):
+			results[positions[batchErr.Index]] = ErrEditConflict
+		default:
+			results[positions[batchErr.Index]] = batchErr.Err
+		}
+		return results, nil
+	}
+	for _, update := range updates {
+		c.issueUpdated(ctx, update, user)
+	}
+	return results, nil
+}
+
+// PublishIssue publishes a draft issue, making it visible to other users. The issue is
+// validated in full and the assignee, if any, is notified.
+func (c *Controller) PublishIssue(ctx context.Context, id int64, user *model.User) (*model.Issue, error) {
+	issue, err := c.repo.GetIssue(ctx, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+	// Drafts are only visible to, and can only be published by, their reporter.
+	if issue.Draft && issue.ReporterID != user.ID {
+		return nil, ErrNotFound
+	}
+	v := validator.New()
+	if !issue.Draft {
+		v.AddError("draft", "issue has already been published")
+		return nil, failedValidationErr(v.Errors)
+	}
+	issue.Draft = false
+	issue.ModifiedBy = user.Name
+	if issue.Validate(v); !v.Valid() {
+		return nil, failedValidationErr(v.Errors)
+	}
+	err = c.repo.UpdateIssue(ctx, issue, lifecycleActivity(issue.ID, "draft", "true", "false", user))
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrEditConflict):
+			return nil, ErrEditConflict
+		default:
+			return nil, err
+		}
+	}
+	// Send email notification to assignee if issue is assigned. The issue is published
+	// by now, so a failed lookup only costs the notification.
+	if issue.AssignedTo != nil {
+		assignee, err := c.repo.GetUserByID(ctx, *issue.AssignedTo)
+		if err != nil {
+			c.Logger.Error("failed to load assignee of published issue", zap.Int64("issue_id", issue.ID), zap.Error(err))
+		} else {
+			data := map[string]string{
+				"name":          assignee.Name,
+				"issueID":       strconv.Itoa(int(issue.ID)),
+				"issueTitle":    issue.Title,
+				"issuePriority": issue.Priority,
+			}
+			c.notifyIssueEvent(ctx, issue.ProjectID, data, assignee.Email, assignee.Locale, "issue_assign.tmpl")
+		}
+	}
+	c.dispatchWebhooks(ctx, "issue.created", issue)
+	return issue, nil
+}
+
+// DeleteIssue soft deletes an issue. Deleted issues are hidden until they are restored.
+func (c *Controller) DeleteIssue(ctx context.Context, id int64, user *model.User) error {
+	err := c.repo.DeleteIssue(ctx, id, lifecycleActivity(id, "deleted", "false", "true", user))
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return ErrNotFound
+		default:
+			return err
+		}
+	}
+	return nil
+}
+
+// RestoreIssue restores a deleted issue. Only managers can restore issues.
+func (c *Controller) RestoreIssue(ctx context.Context, id int64, user *model.User) error {
+	if user.Role != "manager" {
+		return ErrNotPermitted
+	}
+	err := c.repo.RestoreIssue(ctx, id, lifecycleActivity(id, "deleted", "true", "false", user))
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return ErrNotFound
+		default:
+			return err
+		}
+	}
+	return nil
+}
+
+// PurgeIssue permanently deletes an issue, along with its comments, labels, links,
+// watchers and activity. The purge is recorded in the project's activity. Only managers
+// can purge issues.
+func (c *Controller) PurgeIssue(ctx context.Context, id int64, user *model.User) error {
+	if user.Role != "manager" {
+		return ErrNotPermitted
+	}
+	err := c.repo.PurgeIssue(ctx, id, user.Name)
 	if err != nil {
 		switch {
 		case errors.Is(err, repository.ErrNotFound):
@@ -222,3 +709,64 @@ func (c *Controller) DeleteIssue(ctx context.Context, id int64) error {
 	}
 	return nil
 }
+
+// GetUserInvolvement returns the issues a user has reported, is assigned, has commented
+// on or is mentioned in, limited to the projects the viewer can access.
+func (c *Controller) GetUserInvolvement(ctx context.Context, userID int64, viewer *model.User, filters model.Filters, v *validator.Validator) (*model.UserInvolvement, error) {
+	if filters.Validate(v); !v.Valid() {
+		return nil, failedValidationErr(v.Errors)
+	}
+	_, err := c.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+	var involvement model.UserInvolvement
+	involvement.Reported.Issues, involvement.Reported.Metadata, err = c.repo.GetUserInvolvedIssues(ctx, userID, "reporter", viewer.ID, viewer.Role, filters)
+	if err != nil {
+		return nil, err
+	}
+	involvement.Assigned.Issues, involvement.Assigned.Metadata, err = c.repo.GetUserInvolvedIssues(ctx, userID, "assignee", viewer.ID, viewer.Role, filters)
+	if err != nil {
+		return nil, err
+	}
+	involvement.Commented.Issues, involvement.Commented.Metadata, err = c.repo.GetUserInvolvedIssues(ctx, userID, "commenter", viewer.ID, viewer.Role, filters)
+	if err != nil {
+		return nil, err
+	}
+	involvement.Mentioned.Issues, involvement.Mentioned.Metadata, err = c.repo.GetUserInvolvedIssues(ctx, userID, "mentioned", viewer.ID, viewer.Role, filters)
+	if err != nil {
+		return nil, err
+	}
+	return &involvement, nil
+}
+
+// GetCalendarIssues returns the open issues assigned to the owner of a calendar feed
+// token. An invalid or expired token returns ErrInvalidCredentials.
+func (c *Controller) GetCalendarIssues(ctx context.Context, tokenPlaintext string) ([]*model.Issue, error) {
+	v := validator.New()
+	if model.ValidateTokenPlaintext(v, tokenPlaintext); !v.Valid() {
+		return nil, ErrInvalidCredentials
+	}
+	user, err := c.repo.GetUserForToken(ctx, model.ScopeCalendar, tokenPlaintext)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return nil, ErrInvalidCredentials
+		default:
+			return nil, err
+		}
+	}
+	if !user.Activated {
+		return nil, ErrInvalidCredentials
+	}
+	issues, err := c.repo.GetOpenIssuesAssignedTo(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	return issues, nil
+}
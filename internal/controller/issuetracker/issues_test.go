@@ -0,0 +1,405 @@
+package issuetracker
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/emzola/issuetracker/config"
+	"github.com/emzola/issuetracker/pkg/model"
+	"github.com/emzola/issuetracker/pkg/validator"
+	"go.uber.org/zap"
+)
+
+func TestDeletedIssuesRequireManager(t *testing.T) {
+	filters := model.Filters{Page: 1, PageSize: 20, Sort: "id", SortSafelist: []string{"id"}}
+	for _, role := range []string{"member", "lead"} {
+		t.Run(role, func(t *testing.T) {
+			// The repository is never reached, so none is needed.
+			var wg sync.WaitGroup
+			c := New(nil, config.App{}, &wg, zap.NewNop())
+			user := &model.User{ID: 1, Role: role}
+			_, _, err := c.GetAllIssues(context.Background(), model.IssueFilters{LabelMatch: "any", IncludeDeleted: true}, user, filters, validator.New())
+			if !errors.Is(err, ErrNotPermitted) {
+				t.Errorf("GetAllIssues() including deleted issues error = %v, want ErrNotPermitted", err)
+			}
+			if err := c.RestoreIssue(context.Background(), 1, user); !errors.Is(err, ErrNotPermitted) {
+				t.Errorf("RestoreIssue() error = %v, want ErrNotPermitted", err)
+			}
+			if err := c.PurgeIssue(context.Background(), 1, user); !errors.Is(err, ErrNotPermitted) {
+				t.Errorf("PurgeIssue() error = %v, want ErrNotPermitted", err)
+			}
+		})
+	}
+}
+
+func TestGetAllIssuesDateRanges(t *testing.T) {
+	filters := model.Filters{Page: 1, PageSize: 20, Sort: "id", SortSafelist: []string{"id"}}
+	tests := []struct {
+		name                                           string
+		reportedFrom, reportedTo, targetFrom, targetTo string
+		wantErrKey                                     string
+	}{
+		{"reported range reversed", "2024-03-01", "2024-02-01", "", "", "reported_from"},
+		{"target range reversed", "", "", "2024-03-01", "2024-02-01", "target_from"},
+		{"malformed bound", "", "03/01/2024", "", "", "reported_to"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Invalid ranges are rejected before the repository is reached.
+			var wg sync.WaitGroup
+			c := New(nil, config.App{}, &wg, zap.NewNop())
+			v := validator.New()
+			_, _, err := c.GetAllIssues(context.Background(), model.IssueFilters{ReportedFrom: tt.reportedFrom, ReportedTo: tt.reportedTo, TargetFrom: tt.targetFrom, TargetTo: tt.targetTo, LabelMatch: "any"}, &model.User{ID: 1}, filters, v)
+			if !errors.Is(err, ErrFailedValidation) {
+				t.Fatalf("GetAllIssues() error = %v, want ErrFailedValidation", err)
+			}
+			if _, ok := v.Errors[tt.wantErrKey]; !ok {
+				t.Errorf("GetAllIssues() validation errors = %v, want an error for %s", v.Errors, tt.wantErrKey)
+			}
+		})
+	}
+}
+
+func TestUpdateIssueType(t *testing.T) {
+	tests := []struct {
+		name      string
+		issueType string
+		wantErr   bool
+	}{
+		{"feature", "feature", false},
+		{"improvement", "improvement", false},
+		{"unknown type", "epic", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeRepository{
+				issues: map[int64]*model.Issue{1: {
+					ID:                   1,
+					Title:                "Login fails",
+					Description:          "Login fails with valid credentials",
+					ReporterID:           2,
+					ReportedDate:         time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+					ProjectID:            1,
+					Status:               "open",
+					Priority:             "low",
+					Type:                 "bug",
+					TargetResolutionDate: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+				}},
+				projects: fakeProjects(1),
+			}
+			var wg sync.WaitGroup
+			c := New(repo, config.App{}, &wg, zap.NewNop())
+			issueType := tt.issueType
+			issue, err := c.UpdateIssue(context.Background(), 1, 0, nil, nil, nil, nil, nil, &issueType, nil, nil, nil, nil, nil, nil, &model.User{ID: 1, Name: "Ada Lovelace", Role: "manager"})
+			if tt.wantErr {
+				if !errors.Is(err, ErrFailedValidation) {
+					t.Fatalf("UpdateIssue() error = %v, want ErrFailedValidation", err)
+				}
+				if len(repo.saved) > 0 {
+					t.Error("UpdateIssue() updated the issue, want the update rejected")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("UpdateIssue() error = %v", err)
+			}
+			if issue.Type != tt.issueType {
+				t.Errorf("UpdateIssue() type = %q, want %q", issue.Type, tt.issueType)
+			}
+		})
+	}
+}
+
+func TestUpdateIssuePriority(t *testing.T) {
+	tests := []struct {
+		name     string
+		priority string
+		want     string
+		wantErr  bool
+	}{
+		{"high", "high", "high", false},
+		{"mixed case", " Critical ", "critical", false},
+		{"unknown priority", "urgent", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeRepository{
+				issues: map[int64]*model.Issue{1: {
+					ID:                   1,
+					Title:                "Login fails",
+					Description:          "Login fails with valid credentials",
+					ReporterID:           2,
+					ReportedDate:         time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+					ProjectID:            1,
+					Status:               "open",
+					Priority:             "low",
+					Type:                 "bug",
+					TargetResolutionDate: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+				}},
+				projects: fakeProjects(1),
+			}
+			var wg sync.WaitGroup
+			c := New(repo, config.App{}, &wg, zap.NewNop())
+			priority := tt.priority
+			issue, err := c.UpdateIssue(context.Background(), 1, 0, nil, nil, nil, nil, &priority, nil, nil, nil, nil, nil, nil, nil, &model.User{ID: 1, Name: "Ada Lovelace", Role: "manager"})
+			if tt.wantErr {
+				if !errors.Is(err, ErrFailedValidation) {
+					t.Fatalf("UpdateIssue() error = %v, want ErrFailedValidation", err)
+				}
+				if len(repo.saved) > 0 {
+					t.Error("UpdateIssue() updated the issue, want the update rejected")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("UpdateIssue() error = %v", err)
+			}
+			if issue.Priority != tt.want {
+				t.Errorf("UpdateIssue() priority = %q, want %q", issue.Priority, tt.want)
+			}
+		})
+	}
+}
+
+func TestReopenIssue(t *testing.T) {
+	states := []model.WorkflowState{
+		{Name: "triage", Category: "open"},
+		{Name: "in review", Category: "open"},
+		{Name: "done", Category: "closed"},
+	}
+	tests := []struct {
+		name    string
+		status  string
+		wantErr bool
+	}{
+		{"closed issue", "done", false},
+		{"open issue", "in review", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolved := time.Date(2024, 1, 20, 0, 0, 0, 0, time.UTC)
+			repo := &fakeRepository{
+				issues: map[int64]*model.Issue{1: {
+					ID:                   1,
+					Title:                "Login fails",
+					Description:          "Login fails with valid credentials",
+					ReporterID:           2,
+					ReportedDate:         time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+					ProjectID:            1,
+					Status:               tt.status,
+					Priority:             "low",
+					Type:                 "bug",
+					TargetResolutionDate: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+					ActualResolutionDate: &resolved,
+					ResolutionSummary:    "Fixed session cookie",
+				}},
+				projects: fakeProjects(1),
+				states:   states,
+			}
+			var wg sync.WaitGroup
+			c := New(repo, config.App{}, &wg, zap.NewNop())
+			issue, err := c.ReopenIssue(context.Background(), 1, &model.User{ID: 1, Name: "Ada Lovelace", Role: "manager"})
+			if tt.wantErr {
+				if !errors.Is(err, ErrFailedValidation) {
+					t.Fatalf("ReopenIssue() error = %v, want ErrFailedValidation", err)
+				}
+				if len(repo.saved) > 0 {
+					t.Error("ReopenIssue() updated the issue, want the reopen rejected")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReopenIssue() error = %v", err)
+			}
+			if issue.Status != "triage" {
+				t.Errorf("ReopenIssue() status = %q, want %q", issue.Status, "triage")
+			}
+			if issue.ActualResolutionDate != nil || issue.ResolutionSummary != "" {
+				t.Errorf("ReopenIssue() resolution = %v, %q, want both cleared", issue.ActualResolutionDate, issue.ResolutionSummary)
+			}
+		})
+	}
+}
+
+func TestAssignIssueToSelf(t *testing.T) {
+	ada := &model.User{ID: 1, Name: "Ada Lovelace", Email: "ada@example.com", Role: "member"}
+	grace := &model.User{ID: 2, Name: "Grace Hopper", Email: "grace@example.com", Role: "lead"}
+	alan := &model.User{ID: 3, Name: "Alan Turing", Email: "alan@example.com", Role: "member"}
+	tests := []struct {
+		name       string
+		user       *model.User
+		assignedTo *int64
+		wantErr    error
+		wantEmail  bool
+	}{
+		{"member", ada, nil, nil, true},
+		{"already assigned to the member", ada, &ada.ID, nil, false},
+		{"assigned to someone else", ada, &alan.ID, ErrFailedValidation, false},
+		{"lead", grace, nil, ErrInvalidRole, false},
+		{"not a project member", alan, nil, ErrNotPermitted, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeRepository{
+				issues:   map[int64]*model.Issue{1: {ID: 1, Title: "Login fails", ProjectID: 1, ReporterID: 2, AssignedTo: tt.assignedTo, Status: "open", Priority: "low"}},
+				projects: fakeProjects(1, "email"),
+				members:  map[int64]*model.User{ada.ID: ada, grace.ID: grace},
+			}
+			sender := newFakeEmailSender()
+			var wg sync.WaitGroup
+			c := New(repo, config.App{}, &wg, zap.NewNop())
+			c.emails = c.startEmailPool(sender, 1)
+			issue, err := c.AssignIssueToSelf(context.Background(), 1, tt.user)
+			wg.Wait()
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("AssignIssueToSelf() error = %v, want %v", err, tt.wantErr)
+				}
+				if repo.saved != nil {
+					t.Error("AssignIssueToSelf() saved the issue, want it unchanged")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("AssignIssueToSelf() error = %v", err)
+			}
+			if issue.AssignedTo == nil || *issue.AssignedTo != tt.user.ID {
+				t.Errorf("AssignIssueToSelf() assigned to %v, want %d", issue.AssignedTo, tt.user.ID)
+			}
+			if repo.saved != nil && (len(repo.activity) != 1 || repo.activity[0].Field != "assigned_to" || repo.activity[0].NewValue != strconv.FormatInt(tt.user.ID, 10)) {
+				t.Errorf("AssignIssueToSelf() saved activity %+v, want the assignment", repo.activity)
+			}
+			if _, sent := sender.sent[tt.user.Email]; sent != tt.wantEmail {
+				t.Errorf("AssignIssueToSelf() emailed the assignee = %v, want %v", sent, tt.wantEmail)
+			}
+		})
+	}
+}
+
+// fakeCreateIssueRepository serves project 1, which uses the default workflow, and
+// records the issues and comments it creates, failing with createErr if it is set.
+type fakeCreateIssueRepository struct {
+	fakeRepository
+	createErr error
+	created   []*model.Issue
+	comments  []*model.Comment
+}
+
+func newFakeCreateIssueRepository(createErr error) *fakeCreateIssueRepository {
+	return &fakeCreateIssueRepository{fakeRepository: fakeRepository{projects: fakeProjects(1)}, createErr: createErr}
+}
+
+func (r *fakeCreateIssueRepository) CreateIssue(ctx context.Context, issue *model.Issue) error {
+	if r.createErr != nil {
+		return r.createErr
+	}
+	issue.ID = int64(len(r.created) + 1)
+	r.created = append(r.created, issue)
+	return nil
+}
+
+func (r *fakeCreateIssueRepository) CreateIssueWithComment(ctx context.Context, issue *model.Issue, comment *model.Comment) error {
+	// Like the transaction it stands in for, nothing is kept if either insert fails.
+	if r.createErr != nil {
+		return r.createErr
+	}
+	issue.ID = int64(len(r.created) + 1)
+	comment.IssueID = issue.ID
+	r.created = append(r.created, issue)
+	r.comments = append(r.comments, comment)
+	return nil
+}
+
+// fakeAutoWatchRepository records the watchers subscribed to the issues it creates.
+type fakeAutoWatchRepository struct {
+	*fakeCreateIssueRepository
+	subscribed map[int64][]int64
+}
+
+func (r *fakeAutoWatchRepository) SubscribeToIssue(ctx context.Context, issueID, userID int64) error {
+	r.subscribed[issueID] = append(r.subscribed[issueID], userID)
+	return nil
+}
+
+func TestCreateIssueAutoWatchReporter(t *testing.T) {
+	tests := []struct {
+		name         string
+		autoWatch    bool
+		wantWatchers []int64
+	}{
+		{"enabled", true, []int64{7}},
+		{"disabled", false, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeAutoWatchRepository{fakeCreateIssueRepository: newFakeCreateIssueRepository(nil), subscribed: map[int64][]int64{}}
+			cfg := config.App{}
+			cfg.Watchers.AutoWatchReporter = tt.autoWatch
+			var wg sync.WaitGroup
+			c := New(repo, cfg, &wg, zap.NewNop())
+			issue, _, err := c.CreateIssue(context.Background(), "Crash on login", "The app crashes on login", 7, 1, nil, "", "", "2030-01-01", nil, nil, false, "", "Ada Lovelace", "Ada Lovelace")
+			if err != nil {
+				t.Fatalf("CreateIssue() error = %v", err)
+			}
+			if !reflect.DeepEqual(repo.subscribed[issue.ID], tt.wantWatchers) {
+				t.Errorf("CreateIssue() watchers = %v, want %v", repo.subscribed[issue.ID], tt.wantWatchers)
+			}
+		})
+	}
+}
+
+func TestCreateIssueWithComment(t *testing.T) {
+	errInsert := errors.New("insert or update on table \"comments\" violates foreign key constraint")
+	tests := []struct {
+		name         string
+		comment      string
+		createErr    error
+		wantErr      error
+		wantIssues   int
+		wantComments int
+	}{
+		{"with comment", "Open the app and sign in with a valid account", nil, nil, 1, 1},
+		{"without comment", "", nil, nil, 1, 0},
+		{"invalid comment", strings.Repeat("a", 5001), nil, ErrFailedValidation, 0, 0},
+		{"failed insert", "Open the app and sign in with a valid account", errInsert, errInsert, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeCreateIssueRepository(tt.createErr)
+			var wg sync.WaitGroup
+			c := New(repo, config.App{}, &wg, zap.NewNop())
+			issue, comment, err := c.CreateIssue(context.Background(), "Crash on login", "The app crashes on login", 1, 1, nil, "", "", "2030-01-01", nil, nil, false, tt.comment, "Ada Lovelace", "Ada Lovelace")
+			if len(repo.created) != tt.wantIssues || len(repo.comments) != tt.wantComments {
+				t.Errorf("CreateIssue() created %d issues and %d comments, want %d and %d", len(repo.created), len(repo.comments), tt.wantIssues, tt.wantComments)
+			}
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("CreateIssue() error = %v, want %v", err, tt.wantErr)
+				}
+				var validationErr *ValidationError
+				if errors.As(err, &validationErr) && validationErr.Fields["comment"] == "" {
+					t.Errorf("CreateIssue() invalid fields = %v, want comment", validationErr.Fields)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreateIssue() error = %v", err)
+			}
+			if tt.comment == "" {
+				if comment != nil {
+					t.Errorf("CreateIssue() comment = %v, want none", comment)
+				}
+				return
+			}
+			if comment == nil || comment.IssueID != issue.ID || comment.UserID != 1 || comment.Body != tt.comment {
+				t.Errorf("CreateIssue() comment = %+v, want the reporter's comment on issue %d", comment, issue.ID)
+			}
+		})
+	}
+}
@@ -2,54 +2,205 @@ package issuetracker
 
 import (
 	"context"
+	"errors"
+	"time"
 
+	"github.com/emzola/issuetracker/internal/repository"
 	"github.com/emzola/issuetracker/pkg/model"
+	"github.com/emzola/issuetracker/pkg/validator"
 )
 
 type issuesReportRepository interface {
-	GetIssuesStatusReport(ctx context.Context, projectID int64) ([]*model.IssuesStatus, error)
-	GetIssuesAssigneeReport(ctx context.Context, projectID int64) ([]*model.IssuesAssignee, error)
-	GetIssuesReporterReport(ctx context.Context, projectID int64) ([]*model.IssuesReporter, error)
-	GetIssuesPriorityLevelReport(ctx context.Context, projectID int64) ([]*model.IssuesPriority, error)
-	GetIssuesTargetDateReport(ctx context.Context, projectID int64) ([]*model.IssuesTargetDate, error)
+	GetIssuesStatusReport(ctx context.Context, projectID int64, from, to time.Time) ([]*model.IssuesStatus, error)
+	GetIssuesTypeReport(ctx context.Context, projectID int64, from, to time.Time) ([]*model.IssuesType, error)
+	GetIssuesAssigneeReport(ctx context.Context, projectID int64, from, to time.Time) ([]*model.IssuesAssignee, error)
+	GetIssuesReporterReport(ctx context.Context, projectID int64, from, to time.Time) ([]*model.IssuesReporter, error)
+	GetIssuesPriorityLevelReport(ctx context.Context, projectID int64, from, to time.Time) ([]*model.IssuesPriority, error)
+	GetIssuesTargetDateReport(ctx context.Context, projectID int64, from, to time.Time) ([]*model.IssuesTargetDate, error)
+	GetIssuesBurndownReport(ctx context.Context, projectID int64, from, to time.Time) ([]*model.IssuesBurndown, error)
+	GetIssuesEffortReport(ctx context.Context, projectID int64, from, to time.Time) ([]*model.IssuesEffort, error)
+	GetIssuesMilestoneReport(ctx context.Context, projectID int64, from, to time.Time) ([]*model.IssuesByMilestone, error)
+	GetIssuesByProjectReport(ctx context.Context, viewerID int64, viewerRole string, leadOnly bool) ([]*model.IssuesByProject, error)
+	GetUserResolutionVelocity(ctx context.Context, userID, projectID int64, interval string, from, to time.Time, viewerID int64, viewerRole string) ([]*model.ResolutionVelocity, error)
 }
 
-func (c *Controller) GetIssuesStatusReport(ctx context.Context, projectID int64) ([]*model.IssuesStatus, error) {
-	statuses, err := c.repo.GetIssuesStatusReport(ctx, projectID)
+func (c *Controller) GetIssuesStatusReport(ctx context.Context, projectID int64, from, to string, v *validator.Validator) ([]*model.IssuesStatus, error) {
+	start, end := dateRange(v, "from", from, "to", to)
+	if !v.Valid() {
+		return nil, failedValidationErr(v.Errors)
+	}
+	statuses, err := c.repo.GetIssuesStatusReport(ctx, projectID, start, end)
 	if err != nil {
 		return nil, err
 	}
 	return statuses, nil
 }
 
-func (c *Controller) GetIssuesAssigneeReport(ctx context.Context, projectID int64) ([]*model.IssuesAssignee, error) {
-	assignees, err := c.repo.GetIssuesAssigneeReport(ctx, projectID)
+func (c *Controller) GetIssuesTypeReport(ctx context.Context, projectID int64, from, to string, v *validator.Validator) ([]*model.IssuesType, error) {
+	start, end := dateRange(v, "from", from, "to", to)
+	if !v.Valid() {
+		return nil, failedValidationErr(v.Errors)
+	}
+	types, err := c.repo.GetIssuesTypeReport(ctx, projectID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	return types, nil
+}
+
+func (c *Controller) GetIssuesAssigneeReport(ctx context.Context, projectID int64, from, to string, v *validator.Validator) ([]*model.IssuesAssignee, error) {
+	start, end := dateRange(v, "from", from, "to", to)
+	if !v.Valid() {
+		return nil, failedValidationErr(v.Errors)
+	}
+	assignees, err := c.repo.GetIssuesAssigneeReport(ctx, projectID, start, end)
 	if err != nil {
 		return nil, err
 	}
 	return assignees, nil
 }
 
-func (c *Controller) GetIssuesReporterReport(ctx context.Context, projectID int64) ([]*model.IssuesReporter, error) {
-	reporters, err := c.repo.GetIssuesReporterReport(ctx, projectID)
+func (c *Controller) GetIssuesReporterReport(ctx context.Context, projectID int64, from, to string, v *validator.Validator) ([]*model.IssuesReporter, error) {
+	start, end := dateRange(v, "from", from, "to", to)
+	if !v.Valid() {
+		return nil, failedValidationErr(v.Errors)
+	}
+	reporters, err := c.repo.GetIssuesReporterReport(ctx, projectID, start, end)
 	if err != nil {
 		return nil, err
 	}
 	return reporters, nil
 }
 
-func (c *Controller) GetIssuesPriorityLevelReport(ctx context.Context, projectID int64) ([]*model.IssuesPriority, error) {
-	priorityLevels, err := c.repo.GetIssuesPriorityLevelReport(ctx, projectID)
+func (c *Controller) GetIssuesPriorityLevelReport(ctx context.Context, projectID int64, from, to string, v *validator.Validator) ([]*model.IssuesPriority, error) {
+	start, end := dateRange(v, "from", from, "to", to)
+	if !v.Valid() {
+		return nil, failedValidationErr(v.Errors)
+	}
+	priorityLevels, err := c.repo.GetIssuesPriorityLevelReport(ctx, projectID, start, end)
 	if err != nil {
 		return nil, err
 	}
 	return priorityLevels, nil
 }
 
-func (c *Controller) GetIssuesTargetDateReport(ctx context.Context, projectID int64) ([]*model.IssuesTargetDate, error) {
-	targetDates, err := c.repo.GetIssuesTargetDateReport(ctx, projectID)
+func (c *Controller) GetIssuesTargetDateReport(ctx context.Context, projectID int64, from, to string, v *validator.Validator) ([]*model.IssuesTargetDate, error) {
+	start, end := dateRange(v, "from", from, "to", to)
+	if !v.Valid() {
+		return nil, failedValidationErr(v.Errors)
+	}
+	targetDates, err := c.repo.GetIssuesTargetDateReport(ctx, projectID, start, end)
 	if err != nil {
 		return nil, err
 	}
 	return targetDates, nil
 }
+
+func (c *Controller) GetIssuesEffortReport(ctx context.Context, projectID int64, from, to string, v *validator.Validator) ([]*model.IssuesEffort, error) {
+	start, end := dateRange(v, "from", from, "to", to)
+	if !v.Valid() {
+		return nil, failedValidationErr(v.Errors)
+	}
+	efforts, err := c.repo.GetIssuesEffortReport(ctx, projectID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	return efforts, nil
+}
+
+func (c *Controller) GetIssuesMilestoneReport(ctx context.Context, projectID int64, from, to string, v *validator.Validator) ([]*model.IssuesByMilestone, error) {
+	start, end := dateRange(v, "from", from, "to", to)
+	if !v.Valid() {
+		return nil, failedValidationErr(v.Errors)
+	}
+	milestones, err := c.repo.GetIssuesMilestoneReport(ctx, projectID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	return milestones, nil
+}
+
+// maxBurndownRange bounds the date range a burndown report can span.
+const maxBurndownRange = 366 * 24 * time.Hour
+
+// GetIssuesBurndownReport returns the number of the project's issues opened and closed on
+// each day between from and to, and the number open at the end of each day. If from or to
+// are empty, the report defaults to the 30 days ending today.
+func (c *Controller) GetIssuesBurndownReport(ctx context.Context, projectID int64, from, to string, v *validator.Validator) ([]*model.IssuesBurndown, error) {
+	start, end := dateRange(v, "from", from, "to", to)
+	if end.IsZero() {
+		end = time.Now().UTC().Truncate(24 * time.Hour)
+	}
+	if start.IsZero() {
+		start = end.AddDate(0, 0, -29)
+	}
+	// dateRange only orders the bounds that were given, not a bound and its default.
+	if v.Valid() {
+		v.Check(!start.After(end), "from", "must not be after to")
+		v.Check(end.Sub(start) <= maxBurndownRange, "from", "must not be more than one year before to")
+	}
+	if !v.Valid() {
+		return nil, failedValidationErr(v.Errors)
+	}
+	burndown, err := c.repo.GetIssuesBurndownReport(ctx, projectID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	return burndown, nil
+}
+
+func (c *Controller) GetIssuesByProjectReport(ctx context.Context, viewer *model.User, leadOnly bool, v *validator.Validator) ([]*model.IssuesByProject, error) {
+	if !v.Valid() {
+		return nil, failedValidationErr(v.Errors)
+	}
+	projects, err := c.repo.GetIssuesByProjectReport(ctx, viewer.ID, viewer.Role, leadOnly)
+	if err != nil {
+		return nil, err
+	}
+	return projects, nil
+}
+
+// maxVelocityRange bounds the date range a resolution velocity time series can span.
+const maxVelocityRange = 366 * 24 * time.Hour
+
+// GetUserResolutionVelocity returns the number of issues a user closed per interval
+// between from and to, limited to the projects the viewer can access. If from or to are
+// empty, the series defaults to the twelve intervals ending today.
+func (c *Controller) GetUserResolutionVelocity(ctx context.Context, userID, projectID int64, interval, from, to string, viewer *model.User, v *validator.Validator) ([]*model.ResolutionVelocity, error) {
+	v.Check(validator.In(interval, model.VelocityIntervals...), "interval", "must be one of day, week or month")
+	start, end := dateRange(v, "from", from, "to", to)
+	if end.IsZero() {
+		end = time.Now().UTC().Truncate(24 * time.Hour)
+	}
+	if start.IsZero() {
+		switch interval {
+		case "day":
+			start = end.AddDate(0, 0, -11)
+		case "week":
+			start = end.AddDate(0, 0, -7*11)
+		default:
+			start = end.AddDate(0, -11, 0)
+		}
+	}
+	if v.Valid() {
+		v.Check(!start.After(end), "from", "must not be after to")
+		v.Check(end.Sub(start) <= maxVelocityRange, "from", "must not be more than one year before to")
+	}
+	if !v.Valid() {
+		return nil, failedValidationErr(v.Errors)
+	}
+	_, err := c.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+	velocity, err := c.repo.GetUserResolutionVelocity(ctx, userID, projectID, interval, start, end, viewer.ID, viewer.Role)
+	if err != nil {
+		return nil, err
+	}
+	return velocity, nil
+}
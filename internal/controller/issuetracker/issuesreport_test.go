@@ -0,0 +1,91 @@
+package issuetracker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/emzola/issuetracker/config"
+	"github.com/emzola/issuetracker/pkg/model"
+	"github.com/emzola/issuetracker/pkg/validator"
+	"go.uber.org/zap"
+)
+
+// fakeBurndownRepository records the date range of the burndown report it is asked for.
+type fakeBurndownRepository struct {
+	fakeRepository
+	from, to time.Time
+}
+
+func (r *fakeBurndownRepository) GetIssuesBurndownReport(ctx context.Context, projectID int64, from, to time.Time) ([]*model.IssuesBurndown, error) {
+	r.from, r.to = from, to
+	return nil, nil
+}
+
+func TestGetIssuesBurndownReportRange(t *testing.T) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	tests := []struct {
+		name     string
+		from, to string
+		wantFrom time.Time
+		wantTo   time.Time
+		wantErr  bool
+	}{
+		{"default", "", "", today.AddDate(0, 0, -29), today, false},
+		{"range", "2024-01-01", "2024-01-31", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC), false},
+		{"only to", "", "2024-01-31", time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC), false},
+		{"from after to", "2024-02-01", "2024-01-31", time.Time{}, time.Time{}, true},
+		{"single day", "2024-01-31", "2024-01-31", time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC), false},
+		{"invalid to", "", "2024-02-30", time.Time{}, time.Time{}, true},
+		{"more than a year", "2023-01-01", "2024-06-30", time.Time{}, time.Time{}, true},
+		{"invalid date", "01/01/2024", "", time.Time{}, time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeBurndownRepository{}
+			var wg sync.WaitGroup
+			c := New(repo, config.App{}, &wg, zap.NewNop())
+			_, err := c.GetIssuesBurndownReport(context.Background(), 1, tt.from, tt.to, validator.New())
+			if tt.wantErr {
+				if !errors.Is(err, ErrFailedValidation) {
+					t.Errorf("GetIssuesBurndownReport() error = %v, want ErrFailedValidation", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetIssuesBurndownReport() error = %v", err)
+			}
+			if !repo.from.Equal(tt.wantFrom) || !repo.to.Equal(tt.wantTo) {
+				t.Errorf("GetIssuesBurndownReport() range = %v to %v, want %v to %v", repo.from, repo.to, tt.wantFrom, tt.wantTo)
+			}
+		})
+	}
+}
+
+func TestGetUserResolutionVelocityRejected(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to string
+		wantKey  string
+	}{
+		{"invalid from", "01/01/2024", "", "from"},
+		{"invalid to", "", "2024-02-30", "to"},
+		{"from after to", "2024-02-01", "2024-01-31", "from"},
+		{"from after today", "9999-01-01", "", "from"},
+		{"more than a year", "2023-01-01", "2024-06-30", "from"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var wg sync.WaitGroup
+			c := New(&fakeRepository{}, config.App{}, &wg, zap.NewNop())
+			viewer := &model.User{ID: 1, Role: "manager"}
+			_, err := c.GetUserResolutionVelocity(context.Background(), 2, 0, "week", tt.from, tt.to, viewer, validator.New())
+			var verr *ValidationError
+			if !errors.As(err, &verr) || verr.Fields[tt.wantKey] == "" {
+				t.Errorf("GetUserResolutionVelocity() error = %v, want a validation error for %s", err, tt.wantKey)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package issuetracker
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/emzola/issuetracker/internal/repository"
+	"github.com/emzola/issuetracker/pkg/model"
+	"github.com/emzola/issuetracker/pkg/validator"
+)
+
+type labelRepository interface {
+	AddLabelToIssue(ctx context.Context, issueID int64, label *model.Label) error
+	RemoveLabelFromIssue(ctx context.Context, issueID, labelID int64) error
+	GetIssueLabels(ctx context.Context, issueID int64) ([]*model.Label, error)
+}
+
+// getEditableIssue returns the issue if the user can change its labels or links. Like
+// issue updates, members can only change issues assigned to or reported by them.
+func (c *Controller) getEditableIssue(ctx context.Context, issueID int64, user *model.User) (*model.Issue, error) {
+	issue, err := c.GetIssue(ctx, issueID, user)
+	if err != nil {
+		return nil, err
+	}
+	if user.Role == "member" && (issue.AssignedTo == nil || *issue.AssignedTo != user.ID) && issue.ReporterID != user.ID {
+		return nil, ErrNotPermitted
+	}
+	return issue, nil
+}
+
+// AddLabelToIssue adds a label to an issue, creating the label if it doesn't exist yet,
+// and returns the issue's labels.
+func (c *Controller) AddLabelToIssue(ctx context.Context, issueID int64, name string, user *model.User) ([]*model.Label, error) {
+	_, err := c.getEditableIssue(ctx, issueID, user)
+	if err != nil {
+		return nil, err
+	}
+	label := &model.Label{Name: strings.ToLower(strings.TrimSpace(name))}
+	v := validator.New()
+	if label.Validate(v); !v.Valid() {
+		return nil, failedValidationErr(v.Errors)
+	}
+	err = c.repo.AddLabelToIssue(ctx, issueID, label)
+	if err != nil {
+		return nil, err
+	}
+	return c.repo.GetIssueLabels(ctx, issueID)
+}
+
+func (c *Controller) RemoveLabelFromIssue(ctx context.Context, issueID, labelID int64, user *model.User) error {
+	_, err := c.getEditableIssue(ctx, issueID, user)
+	if err != nil {
+		return err
+	}
+	err = c.repo.RemoveLabelFromIssue(ctx, issueID, labelID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return ErrNotFound
+		default:
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package issuetracker
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/emzola/issuetracker/config"
+	"github.com/emzola/issuetracker/pkg/model"
+	"github.com/emzola/issuetracker/pkg/validator"
+	"go.uber.org/zap"
+)
+
+// fakeLabelRepository records the labels added to its issues and the filters its
+// issues are listed with.
+type fakeLabelRepository struct {
+	fakeRepository
+	labels       []*model.Label
+	issueFilters model.IssueFilters
+}
+
+func (r *fakeLabelRepository) AddLabelToIssue(ctx context.Context, issueID int64, label *model.Label) error {
+	label.ID = int64(len(r.labels) + 1)
+	r.labels = append(r.labels, label)
+	return nil
+}
+
+func (r *fakeLabelRepository) GetIssueLabels(ctx context.Context, issueID int64) ([]*model.Label, error) {
+	return r.labels, nil
+}
+
+func (r *fakeLabelRepository) GetAllIssues(ctx context.Context, issueFilters model.IssueFilters, viewerID int64, filters model.Filters) ([]*model.Issue, model.Metadata, error) {
+	r.issueFilters = issueFilters
+	return nil, model.Metadata{}, nil
+}
+
+func TestAddLabelToIssue(t *testing.T) {
+	tests := []struct {
+		name     string
+		label    string
+		user     *model.User
+		want     string
+		wantErr  error
+		validErr bool
+	}{
+		{"lowercased", "  Regression ", &model.User{ID: 2, Role: "member"}, "regression", nil, false},
+		{"too long", "a-label-that-is-far-too-long-to-be-useful-to-anyone", &model.User{ID: 2, Role: "member"}, "", nil, true},
+		{"empty", " ", &model.User{ID: 2, Role: "member"}, "", nil, true},
+		{"not involved", "ui", &model.User{ID: 5, Role: "member"}, "", ErrNotPermitted, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeLabelRepository{fakeRepository: fakeRepository{issues: map[int64]*model.Issue{1: {ID: 1, ReporterID: 2}}}}
+			var wg sync.WaitGroup
+			c := New(repo, config.App{}, &wg, zap.NewNop())
+			labels, err := c.AddLabelToIssue(context.Background(), 1, tt.label, tt.user)
+			wantErr := tt.wantErr
+			if tt.validErr {
+				wantErr = ErrFailedValidation
+			}
+			if !errors.Is(err, wantErr) {
+				t.Fatalf("AddLabelToIssue() error = %v, want %v", err, wantErr)
+			}
+			if wantErr != nil {
+				return
+			}
+			if len(labels) != 1 || labels[0].Name != tt.want {
+				t.Errorf("AddLabelToIssue() labels = %v, want [%q]", labels, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetAllIssuesLabels(t *testing.T) {
+	repo := &fakeLabelRepository{}
+	var wg sync.WaitGroup
+	c := New(repo, config.App{}, &wg, zap.NewNop())
+	filters := model.Filters{Page: 1, PageSize: 20, Sort: "id", SortSafelist: []string{"id"}}
+	_, _, err := c.GetAllIssues(context.Background(), model.IssueFilters{Labels: []string{"UI", "backend", "ui"}, LabelMatch: "all"}, &model.User{ID: 1}, filters, validator.New())
+	if err != nil {
+		t.Fatalf("GetAllIssues() error = %v", err)
+	}
+	if want := []string{"ui", "backend"}; !reflect.DeepEqual(repo.issueFilters.Labels, want) || repo.issueFilters.LabelMatch != "all" {
+		t.Errorf("GetAllIssues() passed labels %v (match %s), want %v (match all)", repo.issueFilters.Labels, repo.issueFilters.LabelMatch, want)
+	}
+}
@@ -0,0 +1,142 @@
+package issuetracker
+
+import (
+	"context"
+	"errors"
+
+	"github.com/emzola/issuetracker/internal/repository"
+	"github.com/emzola/issuetracker/pkg/model"
+	"github.com/emzola/issuetracker/pkg/validator"
+)
+
+type linkRepository interface {
+	CreateIssueLink(ctx context.Context, link *model.IssueLink) error
+	DeleteIssueLink(ctx context.Context, issueID, linkID int64) error
+	GetIssueLinks(ctx context.Context, issueID, viewerID int64) ([]*model.IssueLink, error)
+	GetDuplicateChain(ctx context.Context, issueID, viewerID int64) ([]*model.DuplicateChainIssue, error)
+}
+
+// CreateIssueLink links an issue to another issue. Links of type blocks, blocked_by and
+// relates_to also create the reciprocal link from the target issue.
+func (c *Controller) CreateIssueLink(ctx context.Context, issueID, targetID int64, linkType string, user *model.User) (*model.IssueLink, error) {
+	_, err := c.getEditableIssue(ctx, issueID, user)
+	if err != nil {
+		return nil, err
+	}
+	link := &model.IssueLink{
+		SourceID:  issueID,
+		TargetID:  targetID,
+		LinkType:  linkType,
+		CreatedBy: user.Name,
+	}
+	v := validator.New()
+	if link.Validate(v); !v.Valid() {
+		return nil, failedValidationErr(v.Errors)
+	}
+	target, err := c.GetIssue(ctx, targetID, user)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrNotFound):
+			v.AddError("target_id", "issue not found")
+			return nil, failedValidationErr(v.Errors)
+		default:
+			return nil, err
+		}
+	}
+	err = c.repo.CreateIssueLink(ctx, link)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrDuplicateKey):
+			v.AddError("target_id", "issues are already linked with this link type")
+			return nil, failedValidationErr(v.Errors)
+		default:
+			return nil, err
+		}
+	}
+	link.TargetTitle = target.Title
+	link.TargetStatus = target.Status
+	return link, nil
+}
+
+func (c *Controller) DeleteIssueLink(ctx context.Context, issueID, linkID int64, user *model.User) error {
+	_, err := c.getEditableIssue(ctx, issueID, user)
+	if err != nil {
+		return err
+	}
+	err = c.repo.DeleteIssueLink(ctx, issueID, linkID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return ErrNotFound
+		default:
+			return err
+		}
+	}
+	return nil
+}
+
+// GetIssueLinks returns the links from an issue to the issues visible to the user.
+func (c *Controller) GetIssueLinks(ctx context.Context, issueID int64, user *model.User) ([]*model.IssueLink, error) {
+	return c.repo.GetIssueLinks(ctx, issueID, user.ID)
+}
+
+// GetDuplicateChain follows the duplicates links from an issue to the canonical issue it
+// ended up merged into, taking the lowest issue ID where an issue duplicates several, and
+// returns the canonical issue followed by every issue merged into it, nearest first. If
+// the links lead back to an issue already followed, the chain is a cycle without a
+// canonical issue, and every issue in it is returned.
+func (c *Controller) GetDuplicateChain(ctx context.Context, issueID int64, user *model.User) (*model.DuplicateChain, error) {
+	_, err := c.GetIssue(ctx, issueID, user)
+	if err != nil {
+		return nil, err
+	}
+	issues, err := c.repo.GetDuplicateChain(ctx, issueID, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[int64]*model.DuplicateChainIssue, len(issues))
+	for _, issue := range issues {
+		byID[issue.ID] = issue
+	}
+	canonical, ok := byID[issueID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	followed := map[int64]bool{}
+	for {
+		var next *model.DuplicateChainIssue
+		for _, id := range canonical.DuplicateOf {
+			if next, ok = byID[id]; ok {
+				break
+			}
+		}
+		if next == nil {
+			break
+		}
+		followed[canonical.ID] = true
+		if followed[next.ID] {
+			return &model.DuplicateChain{Cycle: true, Issues: issues}, nil
+		}
+		canonical = next
+	}
+	// Walk the links back from the canonical issue, one level of duplicates at a time.
+	chain := &model.DuplicateChain{CanonicalID: &canonical.ID, Issues: []*model.DuplicateChainIssue{canonical}}
+	reached := map[int64]bool{canonical.ID: true}
+	for i := 0; i < len(chain.Issues); i++ {
+		target := chain.Issues[i]
+		for _, issue := range issues {
+			if reached[issue.ID] {
+				continue
+			}
+			for _, id := range issue.DuplicateOf {
+				if id == target.ID {
+					reached[issue.ID] = true
+					issue.Depth = target.Depth + 1
+					chain.Issues = append(chain.Issues, issue)
+					break
+				}
+			}
+		}
+	}
+	return chain, nil
+}
@@ -0,0 +1,150 @@
+package issuetracker
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/emzola/issuetracker/config"
+	"github.com/emzola/issuetracker/internal/repository"
+	"github.com/emzola/issuetracker/pkg/model"
+	"go.uber.org/zap"
+)
+
+// fakeLinkRepository stores the links between the issues it holds.
+type fakeLinkRepository struct {
+	fakeRepository
+	links []*model.IssueLink
+}
+
+func (r *fakeLinkRepository) CreateIssueLink(ctx context.Context, link *model.IssueLink) error {
+	for _, l := range r.links {
+		if l.SourceID == link.SourceID && l.TargetID == link.TargetID && l.LinkType == link.LinkType {
+			return repository.ErrDuplicateKey
+		}
+	}
+	r.links = append(r.links, link)
+	return nil
+}
+
+func TestCreateIssueLink(t *testing.T) {
+	reporter := &model.User{ID: 2, Name: "Ada Lovelace", Role: "member"}
+	tests := []struct {
+		name     string
+		targetID int64
+		linkType string
+		user     *model.User
+		wantErr  error
+	}{
+		{"valid", 2, "blocks", reporter, nil},
+		{"self link", 1, "blocks", reporter, ErrFailedValidation},
+		{"invalid link type", 2, "causes", reporter, ErrFailedValidation},
+		{"missing target", 3, "blocks", reporter, ErrFailedValidation},
+		{"duplicate", 2, "relates_to", reporter, ErrFailedValidation},
+		{"member not involved", 2, "blocks", &model.User{ID: 3, Name: "Alan Turing", Role: "member"}, ErrNotPermitted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeLinkRepository{
+				fakeRepository: fakeRepository{issues: map[int64]*model.Issue{
+					1: {ID: 1, Title: "Login fails", Status: "open", ReporterID: 2},
+					2: {ID: 2, Title: "Login fails", Status: "open", ReporterID: 2},
+				}},
+				links: []*model.IssueLink{{SourceID: 1, TargetID: 2, LinkType: "relates_to"}},
+			}
+			var wg sync.WaitGroup
+			c := New(repo, config.App{}, &wg, zap.NewNop())
+			link, err := c.CreateIssueLink(context.Background(), 1, tt.targetID, tt.linkType, tt.user)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("CreateIssueLink() error = %v, want %v", err, tt.wantErr)
+				}
+				if len(repo.links) != 1 {
+					t.Error("CreateIssueLink() created a link, want the link rejected")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreateIssueLink() error = %v", err)
+			}
+			if link.TargetTitle != "Login fails" || link.CreatedBy != tt.user.Name {
+				t.Errorf("CreateIssueLink() link = %+v, want the target title and creator set", link)
+			}
+		})
+	}
+}
+
+// fakeDuplicateChainRepository serves the issues connected by the duplicates links in
+// chain.
+type fakeDuplicateChainRepository struct {
+	fakeRepository
+	chain []*model.DuplicateChainIssue
+}
+
+func (r *fakeDuplicateChainRepository) GetDuplicateChain(ctx context.Context, issueID, viewerID int64) ([]*model.DuplicateChainIssue, error) {
+	return r.chain, nil
+}
+
+func TestGetDuplicateChain(t *testing.T) {
+	canonical := int64(1)
+	tests := []struct {
+		name          string
+		issueID       int64
+		duplicateOf   map[int64][]int64
+		wantCanonical *int64
+		wantIDs       []int64
+		wantDepths    []int
+	}{
+		{
+			name:          "merged chain",
+			issueID:       3,
+			duplicateOf:   map[int64][]int64{1: nil, 2: {1}, 3: {2}, 4: {1}},
+			wantCanonical: &canonical,
+			wantIDs:       []int64{1, 2, 4, 3},
+			wantDepths:    []int{0, 1, 1, 2},
+		},
+		{
+			name:          "canonical issue",
+			issueID:       1,
+			duplicateOf:   map[int64][]int64{1: nil, 2: {1}},
+			wantCanonical: &canonical,
+			wantIDs:       []int64{1, 2},
+			wantDepths:    []int{0, 1},
+		},
+		{
+			name:        "cycle",
+			issueID:     2,
+			duplicateOf: map[int64][]int64{1: {3}, 2: {1}, 3: {2}},
+			wantIDs:     []int64{1, 2, 3},
+			wantDepths:  []int{0, 0, 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeDuplicateChainRepository{fakeRepository: fakeRepository{issues: map[int64]*model.Issue{}}}
+			for id := int64(1); id <= int64(len(tt.duplicateOf)); id++ {
+				repo.chain = append(repo.chain, &model.DuplicateChainIssue{ID: id, DuplicateOf: tt.duplicateOf[id]})
+				repo.issues[id] = &model.Issue{ID: id}
+			}
+			var wg sync.WaitGroup
+			c := New(repo, config.App{}, &wg, zap.NewNop())
+			chain, err := c.GetDuplicateChain(context.Background(), tt.issueID, &model.User{ID: 2})
+			if err != nil {
+				t.Fatalf("GetDuplicateChain() error = %v", err)
+			}
+			if !reflect.DeepEqual(chain.CanonicalID, tt.wantCanonical) || chain.Cycle != (tt.wantCanonical == nil) {
+				t.Errorf("GetDuplicateChain() canonical = %v, cycle = %t, want %v", chain.CanonicalID, chain.Cycle, tt.wantCanonical)
+			}
+			ids, depths := []int64{}, []int{}
+			for _, issue := range chain.Issues {
+				ids = append(ids, issue.ID)
+				depths = append(depths, issue.Depth)
+			}
+			if !reflect.DeepEqual(ids, tt.wantIDs) || !reflect.DeepEqual(depths, tt.wantDepths) {
+				t.Errorf("GetDuplicateChain() issues = %v at depths %v, want %v at depths %v", ids, depths, tt.wantIDs, tt.wantDepths)
+			}
+		})
+	}
+}
@@ -0,0 +1,260 @@
+package issuetracker
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/emzola/issuetracker/internal/repository"
+	"github.com/emzola/issuetracker/pkg/model"
+	"github.com/emzola/issuetracker/pkg/validator"
+)
+
+type milestoneRepository interface {
+	CreateMilestone(ctx context.Context, milestone *model.Milestone) error
+	GetMilestone(ctx context.Context, id int64) (*model.Milestone, error)
+	GetAllMilestones(ctx context.Context, projectID int64, status string, filters model.Filters) ([]*model.Milestone, model.Metadata, error)
+	UpdateMilestone(ctx context.Context, milestone *model.Milestone) error
+	DeleteMilestone(ctx context.Context, id int64) error
+	GetProjectBacklog(ctx context.Context, projectID int64, closedStatuses []string, viewerID int64, filters model.Filters) ([]*model.Issue, model.Metadata, error)
+	GetMilestoneAtRiskIssues(ctx context.Context, milestoneID int64, dueDate time.Time, closedStatuses []string, viewerID int64, filters model.Filters) ([]*model.AtRiskIssue, model.Metadata, error)
+}
+
+// getPlannableProject returns the project if the user can plan its milestones and
+// manage its webhooks. Like project updates, leads can only plan the projects assigned
+// to them.
+func (c *Controller) getPlannableProject(ctx context.Context, projectID int64, user *model.User) (*model.Project, error) {
+	project, err := c.GetProject(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	switch user.Role {
+	case "manager":
+		return project, nil
+	case "lead":
+		if project.AssignedTo != nil && *project.AssignedTo == user.ID {
+			return project, nil
+		}
+	}
+	return nil, ErrNotPermitted
+}
+
+func (c *Controller) CreateMilestone(ctx context.Context, projectID int64, title, dueDate string, user *model.User) (*model.Milestone, error) {
+	project, err := c.getPlannableProject(ctx, projectID, user)
+	if err != nil {
+		return nil, err
+	}
+	milestone := &model.Milestone{
+		ProjectID:  project.ID,
+		Title:      title,
+		Status:     "open",
+		CreatedBy:  user.Name,
+		ModifiedBy: user.Name,
+	}
+	v := validator.New()
+	if dueDate != "" {
+		milestone.DueDate = parseDate(v, "due_date", dueDate)
+		if !v.Valid() {
+			return nil, failedValidationErr(v.Errors)
+		}
+	}
+	if milestone.Validate(v, project); !v.Valid() {
+		return nil, failedValidationErr(v.Errors)
+	}
+	err = c.repo.CreateMilestone(ctx, milestone)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrDuplicateKey):
+			v.AddError("title", "a milestone with this title already exists in the project")
+			return nil, failedValidationErr(v.Errors)
+		default:
+			return nil, err
+		}
+	}
+	return milestone, nil
+}
+
+// GetMilestone returns one of a project's milestones. Milestones of other projects
+// aren't found.
+func (c *Controller) GetMilestone(ctx context.Context, projectID, milestoneID int64) (*model.Milestone, error) {
+	milestone, err := c.repo.GetMilestone(ctx, milestoneID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+	if milestone.ProjectID != projectID {
+		return nil, ErrNotFound
+	}
+	return milestone, nil
+}
+
+func (c *Controller) GetAllMilestones(ctx context.Context, projectID int64, status string, filters model.Filters, v *validator.Validator) ([]*model.Milestone, model.Metadata, error) {
+	if status != "" {
+		v.Check(validator.In(status, model.MilestoneStatuses...), "status", "must be open or closed")
+	}
+	if filters.Validate(v); !v.Valid() {
+		return nil, model.Metadata{}, failedValidationErr(v.Errors)
+	}
+	_, err := c.GetProject(ctx, projectID)
+	if err != nil {
+		return nil, model.Metadata{}, err
+	}
+	milestones, metadata, err := c.repo.GetAllMilestones(ctx, projectID, status, filters)
+	if err != nil {
+		return nil, model.Metadata{}, err
+	}
+	return milestones, metadata, nil
+}
+
+func (c *Controller) UpdateMilestone(ctx context.Context, projectID, milestoneID int64, title, dueDate, status *string, user *model.User) (*model.Milestone, error) {
+	project, err := c.getPlannableProject(ctx, projectID, user)
+	if err != nil {
+		return nil, err
+	}
+	milestone, err := c.GetMilestone(ctx, project.ID, milestoneID)
+	if err != nil {
+		return nil, err
+	}
+	if title != nil {
+		milestone.Title = *title
+	}
+	v := validator.New()
+	if dueDate != nil {
+		milestone.DueDate = parseDate(v, "due_date", *dueDate)
+		if !v.Valid() {
+			return nil, failedValidationErr(v.Errors)
+		}
+	}
+	if status != nil {
+		milestone.Status = *status
+	}
+	milestone.ModifiedBy = user.Name
+	if milestone.Validate(v, project); !v.Valid() {
+		return nil, failedValidationErr(v.Errors)
+	}
+	err = c.repo.UpdateMilestone(ctx, milestone)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrDuplicateKey):
+			v.AddError("title", "a milestone with this title already exists in the project")
+			return nil, failedValidationErr(v.Errors)
+		case errors.Is(err, repository.ErrEditConflict):
+			return nil, ErrEditConflict
+		default:
+			return nil, err
+		}
+	}
+	return milestone, nil
+}
+
+// DeleteMilestone deletes a milestone. Its issues are kept, without a milestone.
+func (c *Controller) DeleteMilestone(ctx context.Context, projectID, milestoneID int64, user *model.User) error {
+	project, err := c.getPlannableProject(ctx, projectID, user)
+	if err != nil {
+		return err
+	}
+	_, err = c.GetMilestone(ctx, project.ID, milestoneID)
+	if err != nil {
+		return err
+	}
+	err = c.repo.DeleteMilestone(ctx, milestoneID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return ErrNotFound
+		default:
+			return err
+		}
+	}
+	return nil
+}
+
+// GetMilestoneIssues returns the issues planned into a milestone, optionally filtered by
+// status.
+func (c *Controller) GetMilestoneIssues(ctx context.Context, milestoneID int64, status string, user *model.User, filters model.Filters, v *validator.Validator) ([]*model.Issue, model.Metadata, error) {
+	if filters.Validate(v); !v.Valid() {
+		return nil, model.Metadata{}, failedValidationErr(v.Errors)
+	}
+	milestone, err := c.repo.GetMilestone(ctx, milestoneID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return nil, model.Metadata{}, ErrNotFound
+		default:
+			return nil, model.Metadata{}, err
+		}
+	}
+	issues, metadata, err := c.repo.GetAllIssues(ctx, model.IssueFilters{ProjectID: milestone.ProjectID, MilestoneID: milestone.ID, Status: status}, user.ID, filters)
+	if err != nil {
+		return nil, model.Metadata{}, err
+	}
+	return issues, metadata, nil
+}
+
+// GetProjectBacklog returns the issues of a project that aren't planned into a milestone
+// and aren't closed in the project's workflow. Projects that don't use milestones have
+// every issue still open in their backlog.
+func (c *Controller) GetProjectBacklog(ctx context.Context, projectID int64, user *model.User, filters model.Filters, v *validator.Validator) ([]*model.Issue, model.Metadata, error) {
+	if filters.Validate(v); !v.Valid() {
+		return nil, model.Metadata{}, failedValidationErr(v.Errors)
+	}
+	project, err := c.GetProject(ctx, projectID)
+	if err != nil {
+		return nil, model.Metadata{}, err
+	}
+	workflow, err := c.projectWorkflow(ctx, project.ID)
+	if err != nil {
+		return nil, model.Metadata{}, err
+	}
+	issues, metadata, err := c.repo.GetProjectBacklog(ctx, project.ID, workflow.ClosedStatuses(), user.ID, filters)
+	if err != nil {
+		return nil, model.Metadata{}, err
+	}
+	return issues, metadata, nil
+}
+
+// GetMilestoneAtRiskIssues returns the issues of a milestone that aren't closed and are
+// targeted for resolution after the milestone is due, with how many days late they are
+// targeted.
+func (c *Controller) GetMilestoneAtRiskIssues(ctx context.Context, projectID, milestoneID int64, user *model.User, filters model.Filters, v *validator.Validator) ([]*model.AtRiskIssue, model.Metadata, error) {
+	if filters.Validate(v); !v.Valid() {
+		return nil, model.Metadata{}, failedValidationErr(v.Errors)
+	}
+	milestone, err := c.GetMilestone(ctx, projectID, milestoneID)
+	if err != nil {
+		return nil, model.Metadata{}, err
+	}
+	workflow, err := c.projectWorkflow(ctx, milestone.ProjectID)
+	if err != nil {
+		return nil, model.Metadata{}, err
+	}
+	issues, metadata, err := c.repo.GetMilestoneAtRiskIssues(ctx, milestone.ID, milestone.DueDate, workflow.ClosedStatuses(), user.ID, filters)
+	if err != nil {
+		return nil, model.Metadata{}, err
+	}
+	return issues, metadata, nil
+}
+
+// setIssueMilestone plans the issue into a milestone of its project, adding an error to
+// v if there is no such milestone. A milestoneID of 0 takes the issue out of its
+// milestone.
+func (c *Controller) setIssueMilestone(ctx context.Context, issue *model.Issue, milestoneID int64, v *validator.Validator) error {
+	if milestoneID == 0 {
+		issue.MilestoneID = nil
+		return nil
+	}
+	milestone, err := c.repo.GetMilestone(ctx, milestoneID)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return err
+	}
+	if err != nil || milestone.ProjectID != issue.ProjectID {
+		v.AddError("milestone_id", "must be a milestone of the issue's project")
+		return nil
+	}
+	issue.MilestoneID = &milestone.ID
+	return nil
+}
@@ -0,0 +1,302 @@
+package issuetracker
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/emzola/issuetracker/config"
+	"github.com/emzola/issuetracker/internal/repository"
+	"github.com/emzola/issuetracker/pkg/model"
+	"github.com/emzola/issuetracker/pkg/validator"
+	"go.uber.org/zap"
+)
+
+// fakeMilestoneRepository serves project 1, led by user 2 and started on 2024-01-01, and
+// the milestones created in it.
+type fakeMilestoneRepository struct {
+	fakeRepository
+	milestones []*model.Milestone
+}
+
+func newFakeMilestoneRepository() *fakeMilestoneRepository {
+	lead := int64(2)
+	return &fakeMilestoneRepository{fakeRepository: fakeRepository{projects: map[int64]*model.Project{
+		1: {ID: 1, Name: "Issue Tracker", AssignedTo: &lead, StartDate: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}}}
+}
+
+func (r *fakeMilestoneRepository) CreateMilestone(ctx context.Context, milestone *model.Milestone) error {
+	r.milestones = append(r.milestones, milestone)
+	return nil
+}
+
+func (r *fakeMilestoneRepository) GetMilestone(ctx context.Context, id int64) (*model.Milestone, error) {
+	for _, milestone := range r.milestones {
+		if milestone.ID == id {
+			found := *milestone
+			return &found, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *fakeMilestoneRepository) UpdateMilestone(ctx context.Context, milestone *model.Milestone) error {
+	for i := range r.milestones {
+		if r.milestones[i].ID == milestone.ID {
+			r.milestones[i] = milestone
+			return nil
+		}
+	}
+	return repository.ErrEditConflict
+}
+
+func TestCreateMilestone(t *testing.T) {
+	manager := &model.User{ID: 1, Name: "Grace Hopper", Role: "manager"}
+	tests := []struct {
+		name      string
+		projectID int64
+		title     string
+		dueDate   string
+		user      *model.User
+		wantErr   error
+	}{
+		{"valid", 1, "Sprint 1", "2024-01-15", manager, nil},
+		{"lead of project", 1, "Sprint 1", "2024-01-15", &model.User{ID: 2, Name: "Ada Lovelace", Role: "lead"}, nil},
+		{"due before project start", 1, "Sprint 1", "2023-12-15", manager, ErrFailedValidation},
+		{"invalid due date", 1, "Sprint 1", "15/01/2024", manager, ErrFailedValidation},
+		{"missing title", 1, "", "2024-01-15", manager, ErrFailedValidation},
+		{"missing project", 2, "Sprint 1", "2024-01-15", manager, ErrNotFound},
+		{"lead of another project", 1, "Sprint 1", "2024-01-15", &model.User{ID: 3, Name: "Alan Turing", Role: "lead"}, ErrNotPermitted},
+		{"member", 1, "Sprint 1", "2024-01-15", &model.User{ID: 4, Name: "Edsger Dijkstra", Role: "member"}, ErrNotPermitted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeMilestoneRepository()
+			var wg sync.WaitGroup
+			c := New(repo, config.App{}, &wg, zap.NewNop())
+			milestone, err := c.CreateMilestone(context.Background(), tt.projectID, tt.title, tt.dueDate, tt.user)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("CreateMilestone() error = %v, want %v", err, tt.wantErr)
+				}
+				if len(repo.milestones) != 0 {
+					t.Error("CreateMilestone() created a milestone, want the milestone rejected")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreateMilestone() error = %v", err)
+			}
+			if milestone.Status != "open" || milestone.CreatedBy != tt.user.Name {
+				t.Errorf("CreateMilestone() milestone = %+v, want an open milestone created by %s", milestone, tt.user.Name)
+			}
+		})
+	}
+}
+
+func TestUpdateMilestoneInvalidDueDate(t *testing.T) {
+	repo := newFakeMilestoneRepository()
+	due := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	repo.milestones = []*model.Milestone{{ID: 1, ProjectID: 1, Title: "Sprint 1", Status: "open", DueDate: due}}
+	var wg sync.WaitGroup
+	c := New(repo, config.App{}, &wg, zap.NewNop())
+	manager := &model.User{ID: 1, Name: "Grace Hopper", Role: "manager"}
+	dueDate := "2024-02-30"
+	_, err := c.UpdateMilestone(context.Background(), 1, 1, nil, &dueDate, nil, manager)
+	var verr *ValidationError
+	if !errors.As(err, &verr) || verr.Fields["due_date"] == "" {
+		t.Fatalf("UpdateMilestone() error = %v, want a validation error for due_date", err)
+	}
+	if !repo.milestones[0].DueDate.Equal(due) {
+		t.Errorf("UpdateMilestone() due date = %v, want %v unchanged", repo.milestones[0].DueDate, due)
+	}
+}
+
+// fakeBacklogRepository serves the issues of project 1, filtering its backlog the way
+// the database does, and records the closed statuses it was asked to leave out.
+type fakeBacklogRepository struct {
+	*fakeMilestoneRepository
+	projectIssues  []*model.Issue
+	closedStatuses []string
+}
+
+func newFakeBacklogRepository(states []model.WorkflowState, issues []*model.Issue) *fakeBacklogRepository {
+	r := &fakeBacklogRepository{fakeMilestoneRepository: newFakeMilestoneRepository(), projectIssues: issues}
+	r.states = states
+	return r
+}
+
+func (r *fakeBacklogRepository) GetProjectBacklog(ctx context.Context, projectID int64, closedStatuses []string, viewerID int64, filters model.Filters) ([]*model.Issue, model.Metadata, error) {
+	r.closedStatuses = closedStatuses
+	issues := []*model.Issue{}
+	for _, issue := range r.projectIssues {
+		if issue.ProjectID == projectID && issue.MilestoneID == nil && !validator.In(issue.Status, closedStatuses...) {
+			issues = append(issues, issue)
+		}
+	}
+	return issues, model.CalculateMetadata(len(issues), filters.Page, filters.PageSize), nil
+}
+
+func TestGetProjectBacklog(t *testing.T) {
+	sprint := int64(1)
+	member := &model.User{ID: 4, Name: "Edsger Dijkstra", Role: "member"}
+	customStates := []model.WorkflowState{{Name: "todo", Category: "open"}, {Name: "done", Category: "closed"}, {Name: "won't fix", Category: "closed"}}
+	tests := []struct {
+		name       string
+		projectID  int64
+		states     []model.WorkflowState
+		issues     []*model.Issue
+		sort       string
+		wantIDs    []int64
+		wantClosed []string
+		wantErr    error
+	}{
+		{
+			name:      "with milestones",
+			projectID: 1,
+			issues: []*model.Issue{
+				{ID: 1, ProjectID: 1, Status: "open", MilestoneID: &sprint},
+				{ID: 2, ProjectID: 1, Status: "in progress"},
+				{ID: 3, ProjectID: 1, Status: "closed"},
+				{ID: 4, ProjectID: 1, Status: "resolved"},
+			},
+			wantIDs:    []int64{2, 4},
+			wantClosed: []string{"closed"},
+		},
+		{
+			name:      "without milestones",
+			projectID: 1,
+			issues: []*model.Issue{
+				{ID: 1, ProjectID: 1, Status: "open"},
+				{ID: 2, ProjectID: 1, Status: "closed"},
+				{ID: 3, ProjectID: 1, Status: "in progress"},
+			},
+			wantIDs:    []int64{1, 3},
+			wantClosed: []string{"closed"},
+		},
+		{
+			name:      "custom workflow",
+			projectID: 1,
+			states:    customStates,
+			issues: []*model.Issue{
+				{ID: 1, ProjectID: 1, Status: "todo"},
+				{ID: 2, ProjectID: 1, Status: "done"},
+				{ID: 3, ProjectID: 1, Status: "won't fix"},
+			},
+			wantIDs:    []int64{1},
+			wantClosed: []string{"done", "won't fix"},
+		},
+		{name: "missing project", projectID: 2, wantErr: ErrNotFound},
+		{name: "unknown sort", projectID: 1, sort: "milestone_id", wantErr: ErrFailedValidation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeBacklogRepository(tt.states, tt.issues)
+			var wg sync.WaitGroup
+			c := New(repo, config.App{}, &wg, zap.NewNop())
+			sort := tt.sort
+			if sort == "" {
+				sort = "id"
+			}
+			filters := model.Filters{Page: 1, PageSize: 20, MaxPageSize: 100, Sort: sort, SortSafelist: []string{"id"}}
+			issues, metadata, err := c.GetProjectBacklog(context.Background(), tt.projectID, member, filters, validator.New())
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("GetProjectBacklog() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetProjectBacklog() error = %v", err)
+			}
+			if !reflect.DeepEqual(repo.closedStatuses, tt.wantClosed) {
+				t.Errorf("GetProjectBacklog() left out statuses %v, want %v", repo.closedStatuses, tt.wantClosed)
+			}
+			ids := []int64{}
+			for _, issue := range issues {
+				ids = append(ids, issue.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.wantIDs) || metadata.TotalRecords != len(tt.wantIDs) {
+				t.Errorf("GetProjectBacklog() = %v (%d records), want %v", ids, metadata.TotalRecords, tt.wantIDs)
+			}
+		})
+	}
+}
+
+// fakeAtRiskRepository serves milestone 1 of project 1, due on 2024-02-01, and finds its
+// at-risk issues the way the database does.
+type fakeAtRiskRepository struct {
+	*fakeBacklogRepository
+}
+
+func (r *fakeAtRiskRepository) GetMilestone(ctx context.Context, id int64) (*model.Milestone, error) {
+	if id != 1 {
+		return nil, repository.ErrNotFound
+	}
+	return &model.Milestone{ID: 1, ProjectID: 1, Title: "Sprint 1", DueDate: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), Status: "open"}, nil
+}
+
+func (r *fakeAtRiskRepository) GetMilestoneAtRiskIssues(ctx context.Context, milestoneID int64, dueDate time.Time, closedStatuses []string, viewerID int64, filters model.Filters) ([]*model.AtRiskIssue, model.Metadata, error) {
+	r.closedStatuses = closedStatuses
+	issues := []*model.AtRiskIssue{}
+	for _, issue := range r.projectIssues {
+		if issue.MilestoneID != nil && *issue.MilestoneID == milestoneID && issue.TargetResolutionDate.After(dueDate) && !validator.In(issue.Status, closedStatuses...) {
+			days := int(issue.TargetResolutionDate.Sub(dueDate).Hours() / 24)
+			issues = append(issues, &model.AtRiskIssue{Issue: *issue, DaysPastMilestone: days})
+		}
+	}
+	return issues, model.CalculateMetadata(len(issues), filters.Page, filters.PageSize), nil
+}
+
+func TestGetMilestoneAtRiskIssues(t *testing.T) {
+	sprint := int64(1)
+	member := &model.User{ID: 4, Name: "Edsger Dijkstra", Role: "member"}
+	date := func(day int) time.Time { return time.Date(2024, 2, day, 0, 0, 0, 0, time.UTC) }
+	issues := []*model.Issue{
+		{ID: 1, ProjectID: 1, Status: "open", MilestoneID: &sprint, TargetResolutionDate: date(1)},
+		{ID: 2, ProjectID: 1, Status: "in progress", MilestoneID: &sprint, TargetResolutionDate: date(4)},
+		{ID: 3, ProjectID: 1, Status: "closed", MilestoneID: &sprint, TargetResolutionDate: date(10)},
+		{ID: 4, ProjectID: 1, Status: "open", TargetResolutionDate: date(10)},
+		{ID: 5, ProjectID: 1, Status: "resolved", MilestoneID: &sprint, TargetResolutionDate: date(11)},
+	}
+	tests := []struct {
+		name        string
+		projectID   int64
+		milestoneID int64
+		wantDays    map[int64]int
+		wantErr     error
+	}{
+		{name: "at risk", projectID: 1, milestoneID: 1, wantDays: map[int64]int{2: 3, 5: 10}},
+		{name: "missing milestone", projectID: 1, milestoneID: 2, wantErr: ErrNotFound},
+		{name: "milestone of another project", projectID: 2, milestoneID: 1, wantErr: ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeAtRiskRepository{newFakeBacklogRepository(nil, issues)}
+			var wg sync.WaitGroup
+			c := New(repo, config.App{}, &wg, zap.NewNop())
+			filters := model.Filters{Page: 1, PageSize: 20, MaxPageSize: 100, Sort: "id", SortSafelist: []string{"id"}}
+			atRisk, _, err := c.GetMilestoneAtRiskIssues(context.Background(), tt.projectID, tt.milestoneID, member, filters, validator.New())
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("GetMilestoneAtRiskIssues() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetMilestoneAtRiskIssues() error = %v", err)
+			}
+			days := map[int64]int{}
+			for _, issue := range atRisk {
+				days[issue.ID] = issue.DaysPastMilestone
+			}
+			if !reflect.DeepEqual(days, tt.wantDays) {
+				t.Errorf("GetMilestoneAtRiskIssues() days past milestone = %v, want %v", days, tt.wantDays)
+			}
+		})
+	}
+}
@@ -14,20 +14,34 @@ import (
 type projectRepository interface {
 	CreateProject(ctx context.Context, project *model.Project) error
 	GetProject(ctx context.Context, id int64) (*model.Project, error)
-	GetAllProjects(ctx context.Context, name string, assignedTo int64, startDate, targetEndDate, actualEndDate time.Time, createdBy string, filters model.Filters) ([]*model.Project, model.Metadata, error)
+	GetAllProjects(ctx context.Context, name string, assignedTo int64, startDate, targetEndDate, actualEndDate time.Time, createdBy string, includeArchived bool, filters model.Filters) ([]*model.Project, model.Metadata, error)
 	UpdateProject(ctx context.Context, project *model.Project) error
 	DeleteProject(ctx context.Context, id int64) error
 	GetProjectUsers(ctx context.Context, projectID int64, role string, filters model.Filters) ([]*model.User, model.Metadata, error)
 	GetProjectUser(ctx context.Context, projectID, userID int64) (*model.User, error)
+	GetProjectUnassignedMembers(ctx context.Context, projectID int64, filters model.Filters) ([]*model.User, model.Metadata, error)
+	GetProjectActiveUsers(ctx context.Context, projectID int64, closedStatuses []string, since time.Time, filters model.Filters) ([]*model.ActiveUser, model.Metadata, error)
+	GetProjectSummariesForUser(ctx context.Context, userID int64, role string, filters model.Filters) ([]*model.ProjectSummary, model.Metadata, error)
 }
 
-func (c *Controller) CreateProject(ctx context.Context, name, description string, assignedTo *int64, startDate, targetEndDate, createdBy, modifiedBy string) (*model.Project, error) {
+func (c *Controller) CreateProject(ctx context.Context, key, name, description string, assignedTo *int64, startDate, targetEndDate string, autoCloseDays *int, notificationChannels []string, createdBy, modifiedBy string) (*model.Project, error) {
 	project := &model.Project{
+		Key:         key,
 		Name:        name,
 		Description: description,
 		CreatedBy:   createdBy,
 		ModifiedBy:  modifiedBy,
 	}
+	// A verification period of 0 disables automatic closing of resolved issues.
+	if autoCloseDays != nil && *autoCloseDays != 0 {
+		project.AutoCloseDays = autoCloseDays
+	}
+	// Notifications are delivered by email and to webhooks unless the project chooses
+	// otherwise.
+	project.NotificationChannels = []string{"email", "webhook"}
+	if notificationChannels != nil {
+		project.NotificationChannels = notificationChannels
+	}
 	if startDate != "" {
 		start, err := time.Parse("2006-01-02", startDate)
 		if err != nil {
@@ -70,6 +84,9 @@ func (c *Controller) CreateProject(ctx context.Context, name, description string
 	err = c.repo.CreateProject(ctx, project)
 	if err != nil {
 		switch {
+		case errors.Is(err, repository.ErrDuplicateProjectKey):
+			v.AddError("key", "a project with this key already exists")
+			return nil, failedValidationErr(v.Errors)
 		case errors.Is(err, repository.ErrDuplicateKey):
 			v.AddError("name", "a project with this name already exists")
 			return nil, failedValidationErr(v.Errors)
@@ -84,7 +101,7 @@ func (c *Controller) CreateProject(ctx context.Context, name, description string
 			"projectID":   strconv.Itoa(int(project.ID)),
 			"projectName": project.Name,
 		}
-		c.SendEmail(data, assignee.Email, "project_assign.tmpl")
+		c.SendEmail(data, assignee.Email, assignee.Locale, "project_assign.tmpl")
 	}
 	return project, nil
 }
@@ -102,7 +119,9 @@ func (c *Controller) GetProject(ctx context.Context, id int64) (*model.Project,
 	return project, nil
 }
 
-func (c *Controller) GetAllProjects(ctx context.Context, name string, assignedTo int64, startDate, targetEndDate, actualEndDate, createdBy string, filters model.Filters, v *validator.Validator) ([]*model.Project, model.Metadata, error) {
+// GetAllProjects returns the projects matching the filters. Archived projects are left
+// out unless includeArchived is set.
+func (c *Controller) GetAllProjects(ctx context.Context, name string, assignedTo int64, startDate, targetEndDate, actualEndDate, createdBy string, includeArchived bool, filters model.Filters, v *validator.Validator) ([]*model.Project, model.Metadata, error) {
 	if filters.Validate(v); !v.Valid() {
 		return nil, model.Metadata{}, failedValidationErr(v.Errors)
 	}
@@ -126,28 +145,54 @@ func (c *Controller) GetAllProjects(ctx context.Context, name string, assignedTo
 			return nil, model.Metadata{}, err
 		}
 	}
-	projects, metadata, err := c.repo.GetAllProjects(ctx, name, assignedTo, start, targetEnd, actualEnd, createdBy, filters)
+	projects, metadata, err := c.repo.GetAllProjects(ctx, name, assignedTo, start, targetEnd, actualEnd, createdBy, includeArchived, filters)
 	if err != nil {
 		return nil, model.Metadata{}, err
 	}
 	return projects, metadata, nil
 }
 
-func (c *Controller) UpdateProject(ctx context.Context, id int64, name, description *string, assignedTo *int64, startDate, targetEndDate, actualEndDate *string, user *model.User) (*model.Project, error) {
+// GetAssignedProjects returns the projects assigned to the user. Users without assigned
+// projects, including anonymous users, get an empty list.
+func (c *Controller) GetAssignedProjects(ctx context.Context, user *model.User, filters model.Filters, v *validator.Validator) ([]*model.Project, model.Metadata, error) {
+	if filters.Validate(v); !v.Valid() {
+		return nil, model.Metadata{}, failedValidationErr(v.Errors)
+	}
+	// Anonymous users have no ID, which wouldn't filter projects by assignee at all.
+	if user.IsAnonymous() {
+		return []*model.Project{}, model.Metadata{}, nil
+	}
+	projects, metadata, err := c.repo.GetAllProjects(ctx, "", user.ID, time.Time{}, time.Time{}, time.Time{}, "", false, filters)
+	if err != nil {
+		return nil, model.Metadata{}, err
+	}
+	return projects, metadata, nil
+}
+
+// UpdateProject updates a project. If the project's target end date is moved before the
+// target resolution date of unresolved issues in the project, the update is blocked with
+// a TargetEndDateConflictError or the conflicting issues are returned alongside the
+// project, depending on the configured target end date check. If version is not 0, the
+// project is only updated if it is still at that version.
+func (c *Controller) UpdateProject(ctx context.Context, id, version int64, name, description *string, assignedTo *int64, startDate, targetEndDate, actualEndDate *string, autoCloseDays *int, notificationChannels *[]string, user *model.User) (*model.Project, []*model.Issue, error) {
 	project, err := c.repo.GetProject(ctx, id)
 	if err != nil {
 		switch {
 		case errors.Is(err, repository.ErrNotFound):
-			return nil, ErrNotFound
+			return nil, nil, ErrNotFound
 		default:
-			return nil, err
+			return nil, nil, err
 		}
 	}
 	// Check whether user has permission to update project.
 	// Leads can update project details only if it's assigned to them.
-	if user.Role == "lead" && *project.AssignedTo != user.ID {
-		return nil, ErrNotPermitted
+	if user.Role == "lead" && (project.AssignedTo == nil || *project.AssignedTo != user.ID) {
+		return nil, nil, ErrNotPermitted
+	}
+	if version != 0 && project.Version != version {
+		return nil, nil, ErrPreconditionFailed
 	}
+	before := *project
 	// At this point, update project as usual.
 	if name != nil {
 		project.Name = *name
@@ -155,28 +200,31 @@ func (c *Controller) UpdateProject(ctx context.Context, id int64, name, descript
 	if description != nil {
 		project.Description = *description
 	}
+	v := validator.New()
 	if startDate != nil {
-		start, err := time.Parse("2006-01-02", *startDate)
-		if err != nil {
-			return nil, err
-		}
-		project.StartDate = start
+		project.StartDate = parseDate(v, "start date", *startDate)
 	}
 	if targetEndDate != nil {
-		targetEnd, err := time.Parse("2006-01-02", *targetEndDate)
-		if err != nil {
-			return nil, err
-		}
-		project.TargetEndDate = targetEnd
+		project.TargetEndDate = parseDate(v, "target end date", *targetEndDate)
 	}
 	if actualEndDate != nil {
-		actualEnd, err := time.Parse("2006-01-02", *actualEndDate)
-		if err != nil {
-			return nil, err
-		}
+		actualEnd := parseDate(v, "actual end date", *actualEndDate)
 		project.ActualEndDate = &actualEnd
 	}
-	project.ModifiedBy = user.ModifiedBy
+	// A verification period of 0 disables automatic closing of resolved issues.
+	if autoCloseDays != nil {
+		project.AutoCloseDays = autoCloseDays
+		if *autoCloseDays == 0 {
+			project.AutoCloseDays = nil
+		}
+	}
+	if notificationChannels != nil {
+		project.NotificationChannels = *notificationChannels
+		if project.NotificationChannels == nil {
+			project.NotificationChannels = []string{}
+		}
+	}
+	project.ModifiedBy = user.Name
 	// Only managers can assign projects to leads. Before project is assigned,
 	// attempt to fetch the assignee. If the assignee's role is not 'lead', return an error.
 	var assignee *model.User
@@ -185,30 +233,45 @@ func (c *Controller) UpdateProject(ctx context.Context, id int64, name, descript
 		if err != nil {
 			switch {
 			case errors.Is(err, repository.ErrNotFound):
-				return nil, ErrNotFound
+				return nil, nil, ErrNotFound
 			default:
-				return nil, err
+				return nil, nil, err
 			}
 		}
 		if assignee.Role != "lead" {
-			return nil, ErrInvalidRole
+			return nil, nil, ErrInvalidRole
 		}
 		// Assign lead to project.
 		project.AssignedTo = &assignee.ID
 	}
-	v := validator.New()
+	// The project is validated with the changes merged in, so that changing one date is
+	// checked against the others.
 	if project.Validate(v); !v.Valid() {
-		return nil, failedValidationErr(v.Errors)
+		return nil, nil, failedValidationErr(v.Errors)
+	}
+	// Moving the target end date earlier can leave issues targeted after the end of the
+	// project.
+	var conflicts []*model.Issue
+	targetEndDateCheck := c.Settings().ProjectTargetEndDateCheck
+	if project.TargetEndDate.Before(before.TargetEndDate) && targetEndDateCheck != "ignore" {
+		conflicts, err = c.repo.GetIssuesTargetedAfter(ctx, project.ID, project.TargetEndDate)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(conflicts) > 0 && targetEndDateCheck == "block" {
+			return nil, nil, &TargetEndDateConflictError{Issues: conflicts}
+		}
 	}
 	err = c.repo.UpdateProject(ctx, project)
 	if err != nil {
 		switch {
 		case errors.Is(err, repository.ErrEditConflict):
-			return nil, ErrEditConflict
+			return nil, nil, ErrEditConflict
 		default:
-			return nil, err
+			return nil, nil, err
 		}
 	}
+	c.recordProjectActivity(ctx, projectActivity(&before, project, user.Name))
 	// Send email notification to assigned lead if project is assigned.
 	if assignedTo != nil && user.Role == "manager" {
 		data := map[string]string{
@@ -216,12 +279,66 @@ func (c *Controller) UpdateProject(ctx context.Context, id int64, name, descript
 			"projectID":   strconv.Itoa(int(project.ID)),
 			"projectName": project.Name,
 		}
-		c.SendEmail(data, assignee.Email, "project_assign.tmpl")
+		c.SendEmail(data, assignee.Email, assignee.Locale, "project_assign.tmpl")
+	}
+	return project, conflicts, nil
+}
+
+// ArchiveProject archives a project. Archived projects keep their issues and history,
+// but are hidden from the list of projects and don't accept new issues. Only managers
+// can archive projects.
+func (c *Controller) ArchiveProject(ctx context.Context, id int64, user *model.User) (*model.Project, error) {
+	return c.setProjectArchived(ctx, id, true, user)
+}
+
+// UnarchiveProject restores an archived project. Only managers can unarchive projects.
+func (c *Controller) UnarchiveProject(ctx context.Context, id int64, user *model.User) (*model.Project, error) {
+	return c.setProjectArchived(ctx, id, false, user)
+}
+
+func (c *Controller) setProjectArchived(ctx context.Context, id int64, archived bool, user *model.User) (*model.Project, error) {
+	if user.Role != "manager" {
+		return nil, ErrNotPermitted
+	}
+	project, err := c.repo.GetProject(ctx, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+	if project.Archived == archived {
+		return project, nil
+	}
+	before := *project
+	project.Archived = archived
+	project.ArchivedOn = nil
+	if archived {
+		now := time.Now()
+		project.ArchivedOn = &now
+	}
+	project.ModifiedBy = user.Name
+	err = c.repo.UpdateProject(ctx, project)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrEditConflict):
+			return nil, ErrEditConflict
+		default:
+			return nil, err
+		}
 	}
+	c.recordProjectActivity(ctx, projectActivity(&before, project, user.Name))
 	return project, nil
 }
 
-func (c *Controller) DeleteProject(ctx context.Context, id int64) error {
+// DeleteProject deletes a project along with its issues and history. Projects that are
+// no longer active should usually be archived instead. Only managers can delete projects.
+func (c *Controller) DeleteProject(ctx context.Context, id int64, user *model.User) error {
+	if user.Role != "manager" {
+		return ErrNotPermitted
+	}
 	err := c.repo.DeleteProject(ctx, id)
 	if err != nil {
 		switch {
@@ -257,3 +374,91 @@ func (c *Controller) GetProjectUser(ctx context.Context, projectID, userID int64
 	}
 	return user, nil
 }
+
+// GetProjectUnassignedMembers returns the project's members who have no open issues
+// assigned to them in the project. Managers can view any project, while other users
+// can only view projects they lead or are members of.
+func (c *Controller) GetProjectUnassignedMembers(ctx context.Context, projectID int64, user *model.User, filters model.Filters, v *validator.Validator) ([]*model.User, model.Metadata, error) {
+	if filters.Validate(v); !v.Valid() {
+		return nil, model.Metadata{}, failedValidationErr(v.Errors)
+	}
+	project, err := c.repo.GetProject(ctx, projectID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return nil, model.Metadata{}, ErrNotFound
+		default:
+			return nil, model.Metadata{}, err
+		}
+	}
+	err = c.checkProjectAccess(ctx, project, user)
+	if err != nil {
+		return nil, model.Metadata{}, err
+	}
+	users, metadata, err := c.repo.GetProjectUnassignedMembers(ctx, projectID, filters)
+	if err != nil {
+		return nil, model.Metadata{}, err
+	}
+	return users, metadata, nil
+}
+
+// GetProjectActiveUsers returns the project's members who created, updated, closed or
+// commented on the project's issues in the last days, with how many of each. Managers
+// can view any project, while other users can only view projects they lead or are
+// members of.
+func (c *Controller) GetProjectActiveUsers(ctx context.Context, projectID int64, days int, user *model.User, filters model.Filters, v *validator.Validator) ([]*model.ActiveUser, model.Metadata, error) {
+	v.Check(days >= 1, "days", "must be at least 1")
+	v.Check(days <= 365, "days", "must not be more than 365")
+	if filters.Validate(v); !v.Valid() {
+		return nil, model.Metadata{}, failedValidationErr(v.Errors)
+	}
+	project, err := c.GetProject(ctx, projectID)
+	if err != nil {
+		return nil, model.Metadata{}, err
+	}
+	err = c.checkProjectAccess(ctx, project, user)
+	if err != nil {
+		return nil, model.Metadata{}, err
+	}
+	workflow, err := c.projectWorkflow(ctx, project.ID)
+	if err != nil {
+		return nil, model.Metadata{}, err
+	}
+	since := time.Now().AddDate(0, 0, -days)
+	users, metadata, err := c.repo.GetProjectActiveUsers(ctx, project.ID, workflow.ClosedStatuses(), since, filters)
+	if err != nil {
+		return nil, model.Metadata{}, err
+	}
+	return users, metadata, nil
+}
+
+// checkProjectAccess returns ErrNotPermitted unless the user is a manager, the project's
+// lead or one of its members.
+func (c *Controller) checkProjectAccess(ctx context.Context, project *model.Project, user *model.User) error {
+	if user.Role == "manager" || (project.AssignedTo != nil && *project.AssignedTo == user.ID) {
+		return nil
+	}
+	_, err := c.repo.GetProjectUser(ctx, project.ID, user.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return ErrNotPermitted
+		default:
+			return err
+		}
+	}
+	return nil
+}
+
+// GetMyProjects returns a summary of every project the user can access that isn't
+// archived, for picking a project from a list.
+func (c *Controller) GetMyProjects(ctx context.Context, user *model.User, filters model.Filters, v *validator.Validator) ([]*model.ProjectSummary, model.Metadata, error) {
+	if filters.Validate(v); !v.Valid() {
+		return nil, model.Metadata{}, failedValidationErr(v.Errors)
+	}
+	projects, metadata, err := c.repo.GetProjectSummariesForUser(ctx, user.ID, user.Role, filters)
+	if err != nil {
+		return nil, model.Metadata{}, err
+	}
+	return projects, metadata, nil
+}
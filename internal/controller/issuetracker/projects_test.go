@@ -0,0 +1,272 @@
+package issuetracker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/emzola/issuetracker/config"
+	"github.com/emzola/issuetracker/pkg/model"
+	"github.com/emzola/issuetracker/pkg/validator"
+	"go.uber.org/zap"
+)
+
+// fakeProjectRepository serves a single project and its issues.
+type fakeProjectRepository struct {
+	fakeRepository
+	updated    bool
+	assignedTo int64
+}
+
+func newFakeProjectRepository(project *model.Project, issues ...*model.Issue) *fakeProjectRepository {
+	r := &fakeProjectRepository{fakeRepository: fakeRepository{
+		issues:   map[int64]*model.Issue{},
+		projects: map[int64]*model.Project{project.ID: project},
+	}}
+	for _, issue := range issues {
+		r.issues[issue.ID] = issue
+	}
+	return r
+}
+
+func (r *fakeProjectRepository) GetAllProjects(ctx context.Context, name string, assignedTo int64, startDate, targetEndDate, actualEndDate time.Time, createdBy string, includeArchived bool, filters model.Filters) ([]*model.Project, model.Metadata, error) {
+	r.assignedTo = assignedTo
+	var projects []*model.Project
+	for _, project := range r.projects {
+		found := *project
+		projects = append(projects, &found)
+	}
+	return projects, model.Metadata{}, nil
+}
+
+func (r *fakeProjectRepository) GetIssuesTargetedAfter(ctx context.Context, projectID int64, date time.Time) ([]*model.Issue, error) {
+	var issues []*model.Issue
+	for _, issue := range r.issues {
+		if issue.TargetResolutionDate.After(date) {
+			issues = append(issues, issue)
+		}
+	}
+	return issues, nil
+}
+
+func (r *fakeProjectRepository) UpdateProject(ctx context.Context, project *model.Project) error {
+	r.projects[project.ID] = project
+	r.updated = true
+	return nil
+}
+
+func (r *fakeProjectRepository) RecordProjectActivity(ctx context.Context, activity []*model.ProjectActivity) error {
+	return nil
+}
+
+func TestUpdateProjectTargetEndDateBlocked(t *testing.T) {
+	lead := int64(2)
+	repo := newFakeProjectRepository(
+		&model.Project{
+			ID:            1,
+			Name:          "Issue Tracker",
+			Description:   "Tracks issues",
+			AssignedTo:    &lead,
+			StartDate:     time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			TargetEndDate: time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC),
+		},
+		&model.Issue{ID: 1, ProjectID: 1, TargetResolutionDate: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)},
+		&model.Issue{ID: 2, ProjectID: 1, TargetResolutionDate: time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC)},
+	)
+	var cfg config.App
+	cfg.Project.TargetEndDateCheck = "block"
+	var wg sync.WaitGroup
+	c := New(repo, cfg, &wg, zap.NewNop())
+	targetEndDate := "2024-06-30"
+	_, _, err := c.UpdateProject(context.Background(), 1, 0, nil, nil, nil, nil, &targetEndDate, nil, nil, nil, &model.User{ID: 1, Name: "Ada Lovelace", Role: "manager"})
+	var conflictErr *TargetEndDateConflictError
+	if !errors.As(err, &conflictErr) {
+		t.Fatalf("UpdateProject() error = %v, want TargetEndDateConflictError", err)
+	}
+	if len(conflictErr.Issues) != 1 || conflictErr.Issues[0].ID != 2 {
+		t.Errorf("UpdateProject() conflicting issues = %v, want issue 2", conflictErr.Issues)
+	}
+	if repo.updated {
+		t.Error("UpdateProject() updated the project, want the update blocked")
+	}
+}
+
+func TestUpdateProjectDates(t *testing.T) {
+	date := func(s string) *string { return &s }
+	tests := []struct {
+		name                                    string
+		startDate, targetEndDate, actualEndDate *string
+		wantErrKey                              string
+	}{
+		{name: "actual end date after start date", actualEndDate: date("2024-06-30")},
+		{name: "actual end date before start date", actualEndDate: date("2023-12-01"), wantErrKey: "actual end date"},
+		{name: "start date after target end date", startDate: date("2025-01-01"), wantErrKey: "target end date"},
+		{name: "target end date before start date", targetEndDate: date("2023-06-30"), wantErrKey: "target end date"},
+		{name: "invalid start date", startDate: date("2024-13-01"), wantErrKey: "start date"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeProjectRepository(&model.Project{
+				ID:            1,
+				Name:          "Issue Tracker",
+				Description:   "Tracks issues",
+				StartDate:     time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+				TargetEndDate: time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC),
+			})
+			var wg sync.WaitGroup
+			c := New(repo, config.App{}, &wg, zap.NewNop())
+			_, _, err := c.UpdateProject(context.Background(), 1, 0, nil, nil, nil, tt.startDate, tt.targetEndDate, tt.actualEndDate, nil, nil, &model.User{ID: 1, Name: "Ada Lovelace", Role: "manager"})
+			if tt.wantErrKey == "" {
+				if err != nil {
+					t.Fatalf("UpdateProject() error = %v", err)
+				}
+				return
+			}
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("UpdateProject() error = %v, want a ValidationError", err)
+			}
+			if _, ok := validationErr.Fields[tt.wantErrKey]; !ok {
+				t.Errorf("UpdateProject() validation errors = %v, want an error for %s", validationErr.Fields, tt.wantErrKey)
+			}
+			if repo.updated {
+				t.Error("UpdateProject() updated the project, want the update rejected")
+			}
+		})
+	}
+}
+
+func TestUpdateUnassignedProjectAsLead(t *testing.T) {
+	repo := newFakeProjectRepository(&model.Project{
+		ID:            1,
+		Name:          "Issue Tracker",
+		StartDate:     time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		TargetEndDate: time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC),
+	})
+	var wg sync.WaitGroup
+	c := New(repo, config.App{}, &wg, zap.NewNop())
+	name := "Bug Tracker"
+	_, _, err := c.UpdateProject(context.Background(), 1, 0, &name, nil, nil, nil, nil, nil, nil, nil, &model.User{ID: 2, Name: "Grace Hopper", Role: "lead"})
+	if !errors.Is(err, ErrNotPermitted) {
+		t.Errorf("UpdateProject() error = %v, want ErrNotPermitted", err)
+	}
+}
+
+func TestGetAssignedProjects(t *testing.T) {
+	filters := model.Filters{Page: 1, PageSize: 20, Sort: "id", SortSafelist: []string{"id"}}
+	repo := newFakeProjectRepository(&model.Project{ID: 1, Name: "Issue Tracker"})
+	var wg sync.WaitGroup
+	c := New(repo, config.App{}, &wg, zap.NewNop())
+	_, _, err := c.GetAssignedProjects(context.Background(), &model.User{ID: 2, Role: "lead"}, filters, validator.New())
+	if err != nil {
+		t.Fatalf("GetAssignedProjects() error = %v", err)
+	}
+	if repo.assignedTo != 2 {
+		t.Errorf("GetAssignedProjects() filtered by assignee %d, want 2", repo.assignedTo)
+	}
+	// Anonymous users would otherwise list every project, since they have no ID.
+	repo.assignedTo = -1
+	projects, _, err := c.GetAssignedProjects(context.Background(), model.AnonymousUser, filters, validator.New())
+	if err != nil {
+		t.Fatalf("GetAssignedProjects() for an anonymous user error = %v", err)
+	}
+	if len(projects) != 0 || repo.assignedTo != -1 {
+		t.Errorf("GetAssignedProjects() for an anonymous user = %v, want an empty list", projects)
+	}
+}
+
+func TestArchiveProject(t *testing.T) {
+	repo := newFakeProjectRepository(&model.Project{ID: 1, Name: "Issue Tracker"})
+	var wg sync.WaitGroup
+	c := New(repo, config.App{}, &wg, zap.NewNop())
+	_, err := c.ArchiveProject(context.Background(), 1, &model.User{ID: 2, Name: "Grace Hopper", Role: "lead"})
+	if !errors.Is(err, ErrNotPermitted) {
+		t.Fatalf("ArchiveProject() as a lead error = %v, want ErrNotPermitted", err)
+	}
+	manager := &model.User{ID: 1, Name: "Ada Lovelace", Role: "manager"}
+	project, err := c.ArchiveProject(context.Background(), 1, manager)
+	if err != nil {
+		t.Fatalf("ArchiveProject() error = %v", err)
+	}
+	if !project.Archived || project.ArchivedOn == nil {
+		t.Errorf("ArchiveProject() archived = %v, archived on %v, want the project archived", project.Archived, project.ArchivedOn)
+	}
+	// Archived projects don't accept new issues.
+	_, _, err = c.CreateIssue(context.Background(), "Crash on login", "The app crashes on login", 1, 1, nil, "", "", "", nil, nil, false, "", manager.Name, manager.Name)
+	if !errors.Is(err, ErrFailedValidation) {
+		t.Errorf("CreateIssue() in an archived project error = %v, want ErrFailedValidation", err)
+	}
+	project, err = c.UnarchiveProject(context.Background(), 1, manager)
+	if err != nil {
+		t.Fatalf("UnarchiveProject() error = %v", err)
+	}
+	if project.Archived || project.ArchivedOn != nil {
+		t.Errorf("UnarchiveProject() archived = %v, archived on %v, want the project restored", project.Archived, project.ArchivedOn)
+	}
+}
+
+func TestDeleteProjectAsLead(t *testing.T) {
+	var wg sync.WaitGroup
+	c := New(newFakeProjectRepository(&model.Project{ID: 1, Name: "Issue Tracker"}), config.App{}, &wg, zap.NewNop())
+	err := c.DeleteProject(context.Background(), 1, &model.User{ID: 2, Name: "Grace Hopper", Role: "lead"})
+	if !errors.Is(err, ErrNotPermitted) {
+		t.Errorf("DeleteProject() error = %v, want ErrNotPermitted", err)
+	}
+}
+
+// fakeActiveUsersRepository serves project 1, led by user 2, with user 3 as a member, and
+// records what it was asked for its active users.
+type fakeActiveUsersRepository struct {
+	*fakeProjectRepository
+	closedStatuses []string
+	since          time.Time
+}
+
+func (r *fakeActiveUsersRepository) GetProjectActiveUsers(ctx context.Context, projectID int64, closedStatuses []string, since time.Time, filters model.Filters) ([]*model.ActiveUser, model.Metadata, error) {
+	r.closedStatuses, r.since = closedStatuses, since
+	return []*model.ActiveUser{{ID: 3, Name: "Alan Turing", Comments: 2, Total: 2}}, model.Metadata{}, nil
+}
+
+func TestGetProjectActiveUsers(t *testing.T) {
+	lead := int64(2)
+	tests := []struct {
+		name    string
+		days    int
+		user    *model.User
+		wantErr error
+	}{
+		{"manager", 7, &model.User{ID: 1, Role: "manager"}, nil},
+		{"lead of project", 7, &model.User{ID: 2, Role: "lead"}, nil},
+		{"member of project", 30, &model.User{ID: 3, Role: "lead"}, nil},
+		{"outside project", 7, &model.User{ID: 4, Role: "lead"}, ErrNotPermitted},
+		{"no days", 0, &model.User{ID: 1, Role: "manager"}, ErrFailedValidation},
+		{"too many days", 366, &model.User{ID: 1, Role: "manager"}, ErrFailedValidation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeActiveUsersRepository{fakeProjectRepository: newFakeProjectRepository(&model.Project{ID: 1, AssignedTo: &lead})}
+			repo.members = map[int64]*model.User{3: {ID: 3, Role: "member"}}
+			var wg sync.WaitGroup
+			c := New(repo, config.App{}, &wg, zap.NewNop())
+			filters := model.Filters{Page: 1, PageSize: 20, MaxPageSize: 100, Sort: "-total", SortSafelist: []string{"-total"}}
+			users, _, err := c.GetProjectActiveUsers(context.Background(), 1, tt.days, tt.user, filters, validator.New())
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("GetProjectActiveUsers() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetProjectActiveUsers() error = %v", err)
+			}
+			if len(users) != 1 || len(repo.closedStatuses) != 1 || repo.closedStatuses[0] != "closed" {
+				t.Errorf("GetProjectActiveUsers() = %v with closed statuses %v, want one user and [closed]", users, repo.closedStatuses)
+			}
+			if wantSince := time.Now().AddDate(0, 0, -tt.days); repo.since.Sub(wantSince).Abs() > time.Minute {
+				t.Errorf("GetProjectActiveUsers() counted activity since %v, want %v", repo.since, wantSince)
+			}
+		})
+	}
+}
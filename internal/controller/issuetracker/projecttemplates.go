@@ -0,0 +1,155 @@
+package issuetracker
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/emzola/issuetracker/internal/repository"
+	"github.com/emzola/issuetracker/pkg/model"
+	"github.com/emzola/issuetracker/pkg/validator"
+)
+
+type projectTemplateRepository interface {
+	GetAllProjectTemplates(ctx context.Context) ([]*model.ProjectTemplate, error)
+	GetProjectTemplate(ctx context.Context, id int64) (*model.ProjectTemplate, error)
+	CreateProjectTemplate(ctx context.Context, template *model.ProjectTemplate) error
+	UpdateProjectTemplate(ctx context.Context, template *model.ProjectTemplate) error
+	DeleteProjectTemplate(ctx context.Context, id int64) error
+	CreateProjectFromPlan(ctx context.Context, plan *model.ProjectPlan) error
+}
+
+// GetAllProjectTemplates returns every project template. Only managers can manage
+// project templates.
+func (c *Controller) GetAllProjectTemplates(ctx context.Context, user *model.User) ([]*model.ProjectTemplate, error) {
+	if user.Role != "manager" {
+		return nil, ErrNotPermitted
+	}
+	return c.repo.GetAllProjectTemplates(ctx)
+}
+
+func (c *Controller) GetProjectTemplate(ctx context.Context, id int64, user *model.User) (*model.ProjectTemplate, error) {
+	if user.Role != "manager" {
+		return nil, ErrNotPermitted
+	}
+	template, err := c.repo.GetProjectTemplate(ctx, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+	return template, nil
+}
+
+func (c *Controller) CreateProjectTemplate(ctx context.Context, name string, body model.ProjectTemplateBody, user *model.User) (*model.ProjectTemplate, error) {
+	if user.Role != "manager" {
+		return nil, ErrNotPermitted
+	}
+	template := &model.ProjectTemplate{
+		Name:       name,
+		Body:       body,
+		CreatedBy:  user.Name,
+		ModifiedBy: user.Name,
+	}
+	v := validator.New()
+	if template.Validate(v); !v.Valid() {
+		return nil, failedValidationErr(v.Errors)
+	}
+	err := c.repo.CreateProjectTemplate(ctx, template)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrDuplicateKey):
+			v.AddError("name", "a project template with this name already exists")
+			return nil, failedValidationErr(v.Errors)
+		default:
+			return nil, err
+		}
+	}
+	return template, nil
+}
+
+// UpdateProjectTemplate renames a project template or replaces its body.
+func (c *Controller) UpdateProjectTemplate(ctx context.Context, id int64, name *string, body *model.ProjectTemplateBody, user *model.User) (*model.ProjectTemplate, error) {
+	template, err := c.GetProjectTemplate(ctx, id, user)
+	if err != nil {
+		return nil, err
+	}
+	if name != nil {
+		template.Name = *name
+	}
+	if body != nil {
+		template.Body = *body
+	}
+	template.ModifiedBy = user.Name
+	v := validator.New()
+	if template.Validate(v); !v.Valid() {
+		return nil, failedValidationErr(v.Errors)
+	}
+	err = c.repo.UpdateProjectTemplate(ctx, template)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrDuplicateKey):
+			v.AddError("name", "a project template with this name already exists")
+			return nil, failedValidationErr(v.Errors)
+		case errors.Is(err, repository.ErrEditConflict):
+			return nil, ErrEditConflict
+		default:
+			return nil, err
+		}
+	}
+	return template, nil
+}
+
+func (c *Controller) DeleteProjectTemplate(ctx context.Context, id int64, user *model.User) error {
+	if user.Role != "manager" {
+		return ErrNotPermitted
+	}
+	err := c.repo.DeleteProjectTemplate(ctx, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return ErrNotFound
+		default:
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateProjectFromTemplate creates a project named name along with the milestones and
+// seed issues of a template, dated from startDate or from today if it is empty. The
+// template is validated again before it is applied, as the models may have changed
+// since it was stored.
+func (c *Controller) CreateProjectFromTemplate(ctx context.Context, templateID int64, name, startDate string, user *model.User) (*model.Project, error) {
+	v := validator.New()
+	template, err := c.repo.GetProjectTemplate(ctx, templateID)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+	if v.Check(err == nil, "template_id", "must be an existing project template"); !v.Valid() {
+		return nil, failedValidationErr(v.Errors)
+	}
+	start := time.Now().UTC().Truncate(24 * time.Hour)
+	if startDate != "" {
+		start = parseDate(v, "start_date", startDate)
+	}
+	// The project is new, so its issues start in the first state of the default workflow.
+	plan := template.Body.Plan(name, start, model.DefaultWorkflow(0).Initial(), user.ID, user.Name)
+	if plan.Validate(v); !v.Valid() {
+		return nil, failedValidationErr(v.Errors)
+	}
+	err = c.repo.CreateProjectFromPlan(ctx, plan)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrDuplicateKey):
+			v.AddError("name", "a project with this name already exists")
+			return nil, failedValidationErr(v.Errors)
+		default:
+			return nil, err
+		}
+	}
+	return plan.Project, nil
+}
@@ -0,0 +1,131 @@
+package issuetracker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/emzola/issuetracker/config"
+	"github.com/emzola/issuetracker/internal/repository"
+	"github.com/emzola/issuetracker/pkg/model"
+	"go.uber.org/zap"
+)
+
+// fakeProjectTemplateRepository holds project templates and records the plans applied
+// through it.
+type fakeProjectTemplateRepository struct {
+	fakeRepository
+	templates map[int64]*model.ProjectTemplate
+	plan      *model.ProjectPlan
+}
+
+func (r *fakeProjectTemplateRepository) GetProjectTemplate(ctx context.Context, id int64) (*model.ProjectTemplate, error) {
+	template, ok := r.templates[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	copied := *template
+	return &copied, nil
+}
+
+func (r *fakeProjectTemplateRepository) CreateProjectTemplate(ctx context.Context, template *model.ProjectTemplate) error {
+	template.ID = int64(len(r.templates) + 1)
+	r.templates[template.ID] = template
+	return nil
+}
+
+func (r *fakeProjectTemplateRepository) CreateProjectFromPlan(ctx context.Context, plan *model.ProjectPlan) error {
+	plan.Project.ID = 7
+	r.plan = plan
+	return nil
+}
+
+func newProjectTemplateBody() model.ProjectTemplateBody {
+	return model.ProjectTemplateBody{
+		Description:  "A two week web project",
+		DurationDays: 14,
+		Milestones: []model.ProjectTemplateMilestone{
+			{Title: "Sprint 1", DueInDays: 7},
+			{Title: "Sprint 2", DueInDays: 14},
+		},
+		Issues: []model.ProjectTemplateIssue{
+			{Title: "Set up the repository", Description: "Create the repository and CI", TargetInDays: 2, Milestone: "Sprint 1"},
+			{Title: "Write the README", Description: "Document how to run the project", Priority: "High", Type: "task", TargetInDays: 10},
+		},
+	}
+}
+
+func TestCreateProjectTemplate(t *testing.T) {
+	missingMilestone := newProjectTemplateBody()
+	missingMilestone.Issues[1].Milestone = "Sprint 3"
+	invalidIssue := newProjectTemplateBody()
+	invalidIssue.Issues[0].TargetInDays = 0
+	tests := []struct {
+		name      string
+		body      model.ProjectTemplateBody
+		user      *model.User
+		wantErr   error
+		wantField string
+	}{
+		{"valid template", newProjectTemplateBody(), &model.User{Name: "Grace Hopper", Role: "manager"}, nil, ""},
+		{"unknown milestone", missingMilestone, &model.User{Role: "manager"}, ErrFailedValidation, "issues[1].milestone"},
+		{"invalid issue", invalidIssue, &model.User{Role: "manager"}, ErrFailedValidation, "issues[0].target resolution date"},
+		{"not a manager", newProjectTemplateBody(), &model.User{Role: "lead"}, ErrNotPermitted, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeProjectTemplateRepository{templates: map[int64]*model.ProjectTemplate{}}
+			var wg sync.WaitGroup
+			c := New(repo, config.App{}, &wg, zap.NewNop())
+			_, err := c.CreateProjectTemplate(context.Background(), "Web project", tt.body, tt.user)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CreateProjectTemplate() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantField != "" {
+				var verr *ValidationError
+				if !errors.As(err, &verr) || verr.Fields[tt.wantField] == "" {
+					t.Errorf("CreateProjectTemplate() error = %v, want an error for %q", err, tt.wantField)
+				}
+			}
+			if saved := len(repo.templates) == 1; saved != (tt.wantErr == nil) {
+				t.Errorf("CreateProjectTemplate() saved the template = %v, want %v", saved, tt.wantErr == nil)
+			}
+		})
+	}
+}
+
+func TestCreateProjectFromTemplate(t *testing.T) {
+	repo := &fakeProjectTemplateRepository{templates: map[int64]*model.ProjectTemplate{
+		1: {ID: 1, Name: "Web project", Body: newProjectTemplateBody()},
+	}}
+	var wg sync.WaitGroup
+	c := New(repo, config.App{}, &wg, zap.NewNop())
+	user := &model.User{ID: 3, Name: "Grace Hopper", Role: "manager"}
+	project, err := c.CreateProjectFromTemplate(context.Background(), 1, "Company website", "2026-03-02", user)
+	if err != nil {
+		t.Fatalf("CreateProjectFromTemplate() error = %v", err)
+	}
+	start := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	if project.ID != 7 || project.Name != "Company website" || !project.TargetEndDate.Equal(start.AddDate(0, 0, 14)) {
+		t.Errorf("CreateProjectFromTemplate() = %+v, want project 7 named Company website ending on %v", project, start.AddDate(0, 0, 14))
+	}
+	plan := repo.plan
+	if len(plan.Milestones) != 2 || !plan.Milestones[1].DueDate.Equal(start.AddDate(0, 0, 14)) {
+		t.Errorf("CreateProjectFromPlan() milestones = %+v, want 2 milestones dated from the start date", plan.Milestones)
+	}
+	if len(plan.Issues) != 2 || plan.IssueMilestones[0] != 0 || plan.IssueMilestones[1] != -1 {
+		t.Fatalf("CreateProjectFromPlan() issues = %+v, milestones %v, want the first issue in Sprint 1", plan.Issues, plan.IssueMilestones)
+	}
+	issue := plan.Issues[1]
+	if issue.Status != "open" || issue.Priority != "high" || issue.ReporterID != user.ID || issue.CreatedBy != user.Name {
+		t.Errorf("CreateProjectFromPlan() issue = %+v, want an open high priority issue reported by %s", issue, user.Name)
+	}
+
+	_, err = c.CreateProjectFromTemplate(context.Background(), 2, "Company website", "", user)
+	var verr *ValidationError
+	if !errors.As(err, &verr) || verr.Fields["template_id"] == "" {
+		t.Errorf("CreateProjectFromTemplate(missing template) error = %v, want a template_id validation error", err)
+	}
+}
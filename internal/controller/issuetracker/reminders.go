@@ -0,0 +1,75 @@
+package issuetracker
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/emzola/issuetracker/pkg/model"
+	"go.uber.org/zap"
+)
+
+type reminderRepository interface {
+	GetIssuesDueForReminder(ctx context.Context, priority string, dueBefore time.Time) ([]*model.IssueReminder, error)
+	MarkIssueReminded(ctx context.Context, issueID int64) error
+}
+
+// StartDueDateReminders sends due date reminders in a background goroutine once
+// every reminder interval until ctx is cancelled. Runs are skipped while reminders
+// are disabled in the runtime settings. Reminders aren't started without an interval
+// greater than 0.
+func (c *Controller) StartDueDateReminders(ctx context.Context) {
+	every := interval(c.Settings().ReminderInterval, c.Config.Reminder.Interval)
+	if every <= 0 {
+		c.Logger.Error("due date reminders not started", zap.Duration("interval", every))
+		return
+	}
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		ticker := time.NewTicker(every)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if !c.Settings().ReminderEnabled {
+					continue
+				}
+				err := c.SendDueDateReminders(ctx)
+				if err != nil {
+					c.Logger.Info("failed to send due date reminders", zap.Error(err))
+				}
+			}
+		}
+	}()
+}
+
+// SendDueDateReminders emails the assignee of every open issue whose target resolution
+// date falls within the lead time configured for its priority. Resolved issues are
+// awaiting verification and aren't reminded. Each issue is reminded at most once per
+// target resolution date.
+func (c *Controller) SendDueDateReminders(ctx context.Context) error {
+	for priority, leadTime := range c.Config.Reminder.LeadTimes {
+		reminders, err := c.repo.GetIssuesDueForReminder(ctx, priority, time.Now().Add(leadTime))
+		if err != nil {
+			return err
+		}
+		for _, reminder := range reminders {
+			err = c.repo.MarkIssueReminded(ctx, reminder.IssueID)
+			if err != nil {
+				return err
+			}
+			data := map[string]string{
+				"name":                 reminder.AssigneeName,
+				"issueID":              strconv.Itoa(int(reminder.IssueID)),
+				"issueTitle":           reminder.Title,
+				"issuePriority":        reminder.Priority,
+				"targetResolutionDate": reminder.TargetResolutionDate.Format("2006-01-02"),
+			}
+			c.SendEmail(data, reminder.AssigneeEmail, reminder.AssigneeLocale, "issue_due_reminder.tmpl")
+		}
+	}
+	return nil
+}
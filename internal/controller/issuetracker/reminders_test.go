@@ -0,0 +1,78 @@
+package issuetracker
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/emzola/issuetracker/config"
+	"github.com/emzola/issuetracker/pkg/model"
+	"go.uber.org/zap"
+)
+
+// fakeReminderRepository serves an issue due for a reminder for each priority in due,
+// and records the issues marked as reminded.
+type fakeReminderRepository struct {
+	fakeRepository
+	mu       sync.Mutex
+	due      map[string]*model.IssueReminder
+	reminded []int64
+}
+
+func (r *fakeReminderRepository) GetIssuesDueForReminder(ctx context.Context, priority string, dueBefore time.Time) ([]*model.IssueReminder, error) {
+	reminder, ok := r.due[priority]
+	if !ok || reminder.TargetResolutionDate.After(dueBefore) {
+		return nil, nil
+	}
+	return []*model.IssueReminder{reminder}, nil
+}
+
+func (r *fakeReminderRepository) MarkIssueReminded(ctx context.Context, issueID int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reminded = append(r.reminded, issueID)
+	return nil
+}
+
+func TestSendDueDateReminders(t *testing.T) {
+	now := time.Now()
+	repo := &fakeReminderRepository{due: map[string]*model.IssueReminder{
+		"low":      {IssueID: 1, Priority: "low", TargetResolutionDate: now.Add(12 * time.Hour), AssigneeEmail: "ada@example.com"},
+		"critical": {IssueID: 2, Priority: "critical", TargetResolutionDate: now.Add(96 * time.Hour), AssigneeEmail: "alan@example.com"},
+	}}
+	cfg := config.App{}
+	cfg.Reminder.LeadTimes = map[string]time.Duration{"low": 24 * time.Hour, "critical": 72 * time.Hour}
+	sender := newFakeEmailSender()
+	var wg sync.WaitGroup
+	c := New(repo, cfg, &wg, zap.NewNop())
+	c.emails = c.startEmailPool(sender, 1)
+	err := c.SendDueDateReminders(context.Background())
+	if err != nil {
+		t.Fatalf("SendDueDateReminders() error = %v", err)
+	}
+	wg.Wait()
+	if len(repo.reminded) != 1 || repo.reminded[0] != 1 {
+		t.Errorf("SendDueDateReminders() marked %v as reminded, want [1]", repo.reminded)
+	}
+	if _, ok := sender.sent["ada@example.com"]; !ok || len(sender.sent) != 1 {
+		t.Errorf("SendDueDateReminders() emailed %v, want only ada@example.com", sender.sent)
+	}
+}
+
+func TestStartDueDateRemindersWithoutInterval(t *testing.T) {
+	var wg sync.WaitGroup
+	c := New(&fakeReminderRepository{}, config.App{}, &wg, zap.NewNop())
+	// A ticker without an interval would panic, so reminders aren't started at all.
+	c.StartDueDateReminders(context.Background())
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Error("StartDueDateReminders() started reminders without an interval")
+	}
+}
@@ -0,0 +1,228 @@
+package issuetracker
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/emzola/issuetracker/internal/repository"
+	"github.com/emzola/issuetracker/pkg/model"
+	"github.com/emzola/issuetracker/pkg/rbac"
+	"github.com/emzola/issuetracker/pkg/validator"
+	"go.uber.org/zap"
+)
+
+type roleRepository interface {
+	GetAllRoles(ctx context.Context) ([]*model.Role, error)
+	GetRole(ctx context.Context, name string) (*model.Role, error)
+	CreateRole(ctx context.Context, role *model.Role) error
+	UpdateRole(ctx context.Context, role *model.Role) error
+	DeleteRole(ctx context.Context, name string) error
+	CountRoleUsers(ctx context.Context, name string) (int, error)
+}
+
+// LoadRoles replaces the roles of authorizer with the ones stored in the database, and
+// keeps authorizer up to date with later role changes. When no roles are stored yet,
+// the database is seeded with the roles authorizer was created with. It should be
+// called before the server starts.
+func (c *Controller) LoadRoles(ctx context.Context, authorizer *rbac.Authorizer) error {
+	roles, err := c.repo.GetAllRoles(ctx)
+	if err != nil {
+		return err
+	}
+	if len(roles) == 0 {
+		for name, actions := range authorizer.Roles() {
+			role := &model.Role{
+				Name:        name,
+				Permissions: map[string][]string{},
+				CreatedBy:   "system",
+				ModifiedBy:  "system",
+			}
+			for action, resources := range actions {
+				role.Permissions[action] = resources
+			}
+			err = c.repo.CreateRole(ctx, role)
+			if err != nil {
+				return err
+			}
+		}
+		c.authorizer = authorizer
+		return nil
+	}
+	authorizer.Reload(authorizerRoles(roles))
+	c.authorizer = authorizer
+	return nil
+}
+
+// reloadRoles refreshes the authorizer after a role change, so that the change applies
+// to the next requests.
+func (c *Controller) reloadRoles(ctx context.Context) error {
+	if c.authorizer == nil {
+		return nil
+	}
+	roles, err := c.repo.GetAllRoles(ctx)
+	if err != nil {
+		return err
+	}
+	c.authorizer.Reload(authorizerRoles(roles))
+	return nil
+}
+
+// StartRolesReload reloads the roles in a background goroutine once every reload
+// interval until ctx is cancelled, so that role changes made through other instances of
+// the application apply here too. It should be called after LoadRoles.
+func (c *Controller) StartRolesReload(ctx context.Context) {
+	if c.Config.Reload.Interval <= 0 {
+		return
+	}
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		ticker := time.NewTicker(c.Config.Reload.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				err := c.reloadRoles(ctx)
+				if err != nil {
+					c.Logger.Error("failed to reload roles", zap.Error(err))
+				}
+			}
+		}
+	}()
+}
+
+// authorizerRoles returns roles in the form used by the authorizer.
+func authorizerRoles(roles []*model.Role) rbac.Roles {
+	authRoles := make(rbac.Roles, len(roles))
+	for _, role := range roles {
+		actions := make(rbac.Actions, len(role.Permissions))
+		for action, resources := range role.Permissions {
+			actions[action] = resources
+		}
+		authRoles[role.Name] = actions
+	}
+	return authRoles
+}
+
+// GetAllRoles returns every role. Only managers can manage roles.
+func (c *Controller) GetAllRoles(ctx context.Context, user *model.User) ([]*model.Role, error) {
+	if user.Role != "manager" {
+		return nil, ErrNotPermitted
+	}
+	return c.repo.GetAllRoles(ctx)
+}
+
+func (c *Controller) GetRole(ctx context.Context, name string, user *model.User) (*model.Role, error) {
+	if user.Role != "manager" {
+		return nil, ErrNotPermitted
+	}
+	role, err := c.repo.GetRole(ctx, name)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+	return role, nil
+}
+
+func (c *Controller) CreateRole(ctx context.Context, name string, permissions map[string][]string, user *model.User) (*model.Role, error) {
+	if user.Role != "manager" {
+		return nil, ErrNotPermitted
+	}
+	if permissions == nil {
+		permissions = map[string][]string{}
+	}
+	role := &model.Role{
+		Name:        name,
+		Permissions: permissions,
+		CreatedBy:   user.Name,
+		ModifiedBy:  user.Name,
+	}
+	v := validator.New()
+	if role.Validate(v); !v.Valid() {
+		return nil, failedValidationErr(v.Errors)
+	}
+	err := c.repo.CreateRole(ctx, role)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrDuplicateKey):
+			v.AddError("name", "a role with this name already exists")
+			return nil, failedValidationErr(v.Errors)
+		default:
+			return nil, err
+		}
+	}
+	err = c.reloadRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return role, nil
+}
+
+// UpdateRole replaces the permissions of a role.
+func (c *Controller) UpdateRole(ctx context.Context, name string, permissions *map[string][]string, user *model.User) (*model.Role, error) {
+	role, err := c.GetRole(ctx, name, user)
+	if err != nil {
+		return nil, err
+	}
+	if permissions != nil {
+		role.Permissions = *permissions
+		if role.Permissions == nil {
+			role.Permissions = map[string][]string{}
+		}
+	}
+	role.ModifiedBy = user.Name
+	v := validator.New()
+	if role.Validate(v); !v.Valid() {
+		return nil, failedValidationErr(v.Errors)
+	}
+	err = c.repo.UpdateRole(ctx, role)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrEditConflict):
+			return nil, ErrEditConflict
+		default:
+			return nil, err
+		}
+	}
+	err = c.reloadRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return role, nil
+}
+
+// DeleteRole deletes a role. Built-in roles and roles that are still assigned to users
+// can't be deleted.
+func (c *Controller) DeleteRole(ctx context.Context, name string, user *model.User) error {
+	if user.Role != "manager" {
+		return ErrNotPermitted
+	}
+	v := validator.New()
+	if v.Check(!validator.In(name, model.BuiltInRoles...), "role", "built-in roles can't be deleted"); !v.Valid() {
+		return failedValidationErr(v.Errors)
+	}
+	count, err := c.repo.CountRoleUsers(ctx, name)
+	if err != nil {
+		return err
+	}
+	if v.Check(count == 0, "role", "must not be assigned to any users"); !v.Valid() {
+		return failedValidationErr(v.Errors)
+	}
+	err = c.repo.DeleteRole(ctx, name)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return ErrNotFound
+		default:
+			return err
+		}
+	}
+	return c.reloadRoles(ctx)
+}
@@ -0,0 +1,186 @@
+package issuetracker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/emzola/issuetracker/config"
+	"github.com/emzola/issuetracker/internal/repository"
+	"github.com/emzola/issuetracker/pkg/model"
+	"github.com/emzola/issuetracker/pkg/rbac"
+	"go.uber.org/zap"
+)
+
+// fakeRoleRepository holds roles and the number of users with each role.
+type fakeRoleRepository struct {
+	fakeRepository
+	roles map[string]*model.Role
+	users map[string]int
+}
+
+func (r *fakeRoleRepository) GetAllRoles(ctx context.Context) ([]*model.Role, error) {
+	roles := []*model.Role{}
+	for _, role := range r.roles {
+		roles = append(roles, role)
+	}
+	return roles, nil
+}
+
+func (r *fakeRoleRepository) GetRole(ctx context.Context, name string) (*model.Role, error) {
+	role, ok := r.roles[name]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	copied := *role
+	return &copied, nil
+}
+
+func (r *fakeRoleRepository) CreateRole(ctx context.Context, role *model.Role) error {
+	if _, ok := r.roles[role.Name]; ok {
+		return repository.ErrDuplicateKey
+	}
+	r.roles[role.Name] = role
+	return nil
+}
+
+func (r *fakeRoleRepository) UpdateRole(ctx context.Context, role *model.Role) error {
+	r.roles[role.Name] = role
+	return nil
+}
+
+func (r *fakeRoleRepository) DeleteRole(ctx context.Context, name string) error {
+	if _, ok := r.roles[name]; !ok {
+		return repository.ErrNotFound
+	}
+	delete(r.roles, name)
+	return nil
+}
+
+func (r *fakeRoleRepository) CountRoleUsers(ctx context.Context, name string) (int, error) {
+	return r.users[name], nil
+}
+
+func TestLoadRolesSeedsDatabase(t *testing.T) {
+	repo := &fakeRoleRepository{roles: map[string]*model.Role{}}
+	var wg sync.WaitGroup
+	c := New(repo, config.App{}, &wg, zap.NewNop())
+	authorizer := rbac.New(rbac.Roles{"member": {"read": {"issues"}}})
+	err := c.LoadRoles(context.Background(), authorizer)
+	if err != nil {
+		t.Fatalf("LoadRoles() error = %v", err)
+	}
+	role, ok := repo.roles["member"]
+	if !ok || len(role.Permissions["read"]) != 1 || role.Permissions["read"][0] != "issues" {
+		t.Errorf("LoadRoles() seeded roles %v, want the member role from the authorizer", repo.roles)
+	}
+}
+
+func TestRoleChangesReloadAuthorizer(t *testing.T) {
+	repo := &fakeRoleRepository{
+		roles: map[string]*model.Role{
+			"manager": {Name: "manager", Permissions: map[string][]string{
+				"create": {"roles"}, "read": {"roles"}, "update": {"roles"}, "delete": {"roles"},
+			}},
+		},
+		users: map[string]int{},
+	}
+	var wg sync.WaitGroup
+	c := New(repo, config.App{}, &wg, zap.NewNop())
+	authorizer := rbac.New(rbac.Roles{})
+	err := c.LoadRoles(context.Background(), authorizer)
+	if err != nil {
+		t.Fatalf("LoadRoles() error = %v", err)
+	}
+	manager := &model.User{Name: "Grace Hopper", Role: "manager"}
+	auditor := &model.User{Name: "Alan Turing", Role: "auditor"}
+	_, err = c.CreateRole(context.Background(), "auditor", map[string][]string{"read": {"issuesreport"}}, manager)
+	if err != nil {
+		t.Fatalf("CreateRole() error = %v", err)
+	}
+	if !authorizer.HasPermission(auditor, "read", "issuesreport") {
+		t.Error("HasPermission() = false after CreateRole(), want the new role applied")
+	}
+	err = c.DeleteRole(context.Background(), "auditor", manager)
+	if err != nil {
+		t.Fatalf("DeleteRole() error = %v", err)
+	}
+	if authorizer.HasPermission(auditor, "read", "issuesreport") {
+		t.Error("HasPermission() = true after DeleteRole(), want the role removed")
+	}
+}
+
+func TestDeleteRole(t *testing.T) {
+	tests := []struct {
+		name    string
+		role    string
+		user    *model.User
+		wantErr error
+	}{
+		{"unused role", "auditor", &model.User{Role: "manager"}, nil},
+		{"built-in role", "lead", &model.User{Role: "manager"}, ErrFailedValidation},
+		{"assigned role", "contractor", &model.User{Role: "manager"}, ErrFailedValidation},
+		{"missing role", "intern", &model.User{Role: "manager"}, ErrNotFound},
+		{"not a manager", "auditor", &model.User{Role: "lead"}, ErrNotPermitted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeRoleRepository{
+				roles: map[string]*model.Role{
+					"lead":       {Name: "lead"},
+					"auditor":    {Name: "auditor"},
+					"contractor": {Name: "contractor"},
+				},
+				users: map[string]int{"lead": 2, "contractor": 1},
+			}
+			var wg sync.WaitGroup
+			c := New(repo, config.App{}, &wg, zap.NewNop())
+			err := c.DeleteRole(context.Background(), tt.role, tt.user)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("DeleteRole() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUpdateRoleKeepsManagerAccess(t *testing.T) {
+	repo := &fakeRoleRepository{roles: map[string]*model.Role{
+		"manager": {Name: "manager", Permissions: map[string][]string{
+			"create": {"roles"}, "read": {"roles"}, "update": {"roles"}, "delete": {"roles"},
+		}},
+	}}
+	var wg sync.WaitGroup
+	c := New(repo, config.App{}, &wg, zap.NewNop())
+	permissions := map[string][]string{"read": {"roles", "issues"}}
+	_, err := c.UpdateRole(context.Background(), "manager", &permissions, &model.User{Role: "manager"})
+	if !errors.Is(err, ErrFailedValidation) {
+		t.Errorf("UpdateRole() error = %v, want ErrFailedValidation", err)
+	}
+}
+
+func TestStartRolesReload(t *testing.T) {
+	// Another instance granted members read access to projects.
+	repo := &fakeRoleRepository{roles: map[string]*model.Role{
+		"member": {Name: "member", Permissions: map[string][]string{"read": {"issues", "projects"}}},
+	}}
+	var wg sync.WaitGroup
+	cfg := config.App{}
+	cfg.Reload.Interval = 10 * time.Millisecond
+	c := New(repo, cfg, &wg, zap.NewNop())
+	authorizer := rbac.New(rbac.Roles{"member": {"read": {"issues"}}})
+	c.authorizer = authorizer
+	ctx, cancel := context.WithCancel(context.Background())
+	c.StartRolesReload(ctx)
+	member := &model.User{ID: 1, Role: "member"}
+	deadline := time.Now().Add(time.Second)
+	for !authorizer.HasPermission(member, "read", "projects") && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	wg.Wait()
+	if !authorizer.HasPermission(member, "read", "projects") {
+		t.Error("StartRolesReload() didn't pick up the role change")
+	}
+}
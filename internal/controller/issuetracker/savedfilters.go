@@ -0,0 +1,106 @@
+package issuetracker
+
+import (
+	"context"
+	"errors"
+
+	"github.com/emzola/issuetracker/internal/repository"
+	"github.com/emzola/issuetracker/pkg/model"
+	"github.com/emzola/issuetracker/pkg/validator"
+)
+
+type savedFilterRepository interface {
+	CreateSavedFilter(ctx context.Context, filter *model.SavedFilter) error
+	GetSavedFilter(ctx context.Context, id, userID int64) (*model.SavedFilter, error)
+	GetAllSavedFilters(ctx context.Context, userID int64) ([]*model.SavedFilter, error)
+	UpdateSavedFilter(ctx context.Context, filter *model.SavedFilter) error
+	DeleteSavedFilter(ctx context.Context, id, userID int64) error
+}
+
+// GetAllSavedFilters returns the user's saved filters. Saved filters are private to
+// the user who saved them.
+func (c *Controller) GetAllSavedFilters(ctx context.Context, user *model.User) ([]*model.SavedFilter, error) {
+	return c.repo.GetAllSavedFilters(ctx, user.ID)
+}
+
+// GetSavedFilter returns one of the user's saved filters. Saved filters of other users
+// are not found.
+func (c *Controller) GetSavedFilter(ctx context.Context, id int64, user *model.User) (*model.SavedFilter, error) {
+	filter, err := c.repo.GetSavedFilter(ctx, id, user.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+	return filter, nil
+}
+
+func (c *Controller) CreateSavedFilter(ctx context.Context, name, queryString string, user *model.User) (*model.SavedFilter, error) {
+	filter := &model.SavedFilter{
+		UserID:      user.ID,
+		Name:        name,
+		QueryString: queryString,
+	}
+	v := validator.New()
+	if filter.Validate(v); !v.Valid() {
+		return nil, failedValidationErr(v.Errors)
+	}
+	err := c.repo.CreateSavedFilter(ctx, filter)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrDuplicateKey):
+			v.AddError("name", "a saved filter with this name already exists")
+			return nil, failedValidationErr(v.Errors)
+		default:
+			return nil, err
+		}
+	}
+	return filter, nil
+}
+
+// UpdateSavedFilter renames one of the user's saved filters or replaces its query string.
+func (c *Controller) UpdateSavedFilter(ctx context.Context, id int64, name, queryString *string, user *model.User) (*model.SavedFilter, error) {
+	filter, err := c.GetSavedFilter(ctx, id, user)
+	if err != nil {
+		return nil, err
+	}
+	if name != nil {
+		filter.Name = *name
+	}
+	if queryString != nil {
+		filter.QueryString = *queryString
+	}
+	v := validator.New()
+	if filter.Validate(v); !v.Valid() {
+		return nil, failedValidationErr(v.Errors)
+	}
+	err = c.repo.UpdateSavedFilter(ctx, filter)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrDuplicateKey):
+			v.AddError("name", "a saved filter with this name already exists")
+			return nil, failedValidationErr(v.Errors)
+		case errors.Is(err, repository.ErrEditConflict):
+			return nil, ErrEditConflict
+		default:
+			return nil, err
+		}
+	}
+	return filter, nil
+}
+
+func (c *Controller) DeleteSavedFilter(ctx context.Context, id int64, user *model.User) error {
+	err := c.repo.DeleteSavedFilter(ctx, id, user.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return ErrNotFound
+		default:
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package issuetracker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/emzola/issuetracker/config"
+	"github.com/emzola/issuetracker/internal/repository"
+	"github.com/emzola/issuetracker/pkg/model"
+	"go.uber.org/zap"
+)
+
+// fakeSavedFilterRepository stores saved filters in memory, scoping them to their user
+// the way the database does.
+type fakeSavedFilterRepository struct {
+	fakeRepository
+	filters []*model.SavedFilter
+}
+
+func (r *fakeSavedFilterRepository) CreateSavedFilter(ctx context.Context, filter *model.SavedFilter) error {
+	for _, f := range r.filters {
+		if f.UserID == filter.UserID && f.Name == filter.Name {
+			return repository.ErrDuplicateKey
+		}
+	}
+	filter.ID = int64(len(r.filters) + 1)
+	r.filters = append(r.filters, filter)
+	return nil
+}
+
+func (r *fakeSavedFilterRepository) GetSavedFilter(ctx context.Context, id, userID int64) (*model.SavedFilter, error) {
+	for _, f := range r.filters {
+		if f.ID == id && f.UserID == userID {
+			filter := *f
+			return &filter, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *fakeSavedFilterRepository) UpdateSavedFilter(ctx context.Context, filter *model.SavedFilter) error {
+	for i, f := range r.filters {
+		if f.ID == filter.ID && f.UserID == filter.UserID {
+			r.filters[i] = filter
+			return nil
+		}
+	}
+	return repository.ErrEditConflict
+}
+
+func (r *fakeSavedFilterRepository) DeleteSavedFilter(ctx context.Context, id, userID int64) error {
+	for i, f := range r.filters {
+		if f.ID == id && f.UserID == userID {
+			r.filters = append(r.filters[:i], r.filters[i+1:]...)
+			return nil
+		}
+	}
+	return repository.ErrNotFound
+}
+
+func TestCreateSavedFilter(t *testing.T) {
+	owner := &model.User{ID: 1, Name: "Grace Hopper", Role: "member"}
+	repo := &fakeSavedFilterRepository{}
+	var wg sync.WaitGroup
+	c := New(repo, config.App{}, &wg, zap.NewNop())
+	ctx := context.Background()
+	filter, err := c.CreateSavedFilter(ctx, "My open bugs", "status=open&type=bug", owner)
+	if err != nil {
+		t.Fatalf("CreateSavedFilter() error = %v", err)
+	}
+	if filter.UserID != owner.ID {
+		t.Errorf("CreateSavedFilter() user = %d, want %d", filter.UserID, owner.ID)
+	}
+	var validationErr *ValidationError
+	_, err = c.CreateSavedFilter(ctx, "My open bugs", "status=closed", owner)
+	if !errors.As(err, &validationErr) || validationErr.Fields["name"] == "" {
+		t.Errorf("CreateSavedFilter() with a duplicate name error = %v, want a name validation error", err)
+	}
+	_, err = c.CreateSavedFilter(ctx, "Colourful", "colour=red", owner)
+	if !errors.As(err, &validationErr) || validationErr.Fields["query_string"] == "" {
+		t.Errorf("CreateSavedFilter() with an unknown key error = %v, want a query_string validation error", err)
+	}
+	if _, err = c.CreateSavedFilter(ctx, "My open bugs", "status=open", &model.User{ID: 2, Name: "Ada Lovelace", Role: "member"}); err != nil {
+		t.Errorf("CreateSavedFilter() with another user's name error = %v, want the filter saved", err)
+	}
+}
+
+func TestSavedFiltersAreScopedToTheirUser(t *testing.T) {
+	owner := &model.User{ID: 1, Name: "Grace Hopper", Role: "member"}
+	other := &model.User{ID: 2, Name: "Ada Lovelace", Role: "manager"}
+	repo := &fakeSavedFilterRepository{filters: []*model.SavedFilter{{ID: 1, UserID: owner.ID, Name: "My open bugs", QueryString: "status=open&type=bug"}}}
+	var wg sync.WaitGroup
+	c := New(repo, config.App{}, &wg, zap.NewNop())
+	ctx := context.Background()
+	name := "Stolen"
+	if _, err := c.GetSavedFilter(ctx, 1, other); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetSavedFilter() by another user error = %v, want %v", err, ErrNotFound)
+	}
+	if _, err := c.UpdateSavedFilter(ctx, 1, &name, nil, other); !errors.Is(err, ErrNotFound) {
+		t.Errorf("UpdateSavedFilter() by another user error = %v, want %v", err, ErrNotFound)
+	}
+	if err := c.DeleteSavedFilter(ctx, 1, other); !errors.Is(err, ErrNotFound) {
+		t.Errorf("DeleteSavedFilter() by another user error = %v, want %v", err, ErrNotFound)
+	}
+	filter, err := c.UpdateSavedFilter(ctx, 1, &name, nil, owner)
+	if err != nil {
+		t.Fatalf("UpdateSavedFilter() error = %v", err)
+	}
+	if filter.Name != name || filter.QueryString != "status=open&type=bug" {
+		t.Errorf("UpdateSavedFilter() = %+v, want the filter renamed with its query string kept", filter)
+	}
+	if err := c.DeleteSavedFilter(ctx, 1, owner); err != nil {
+		t.Errorf("DeleteSavedFilter() error = %v", err)
+	}
+}
@@ -0,0 +1,200 @@
+package issuetracker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/emzola/issuetracker/config"
+	"github.com/emzola/issuetracker/internal/repository"
+	"github.com/emzola/issuetracker/pkg/model"
+	"github.com/emzola/issuetracker/pkg/validator"
+	"go.uber.org/zap"
+)
+
+type settingsRepository interface {
+	GetSettings(ctx context.Context, settings *model.Settings) error
+	SaveSettings(ctx context.Context, changes map[string]model.SettingChange, modifiedBy string) error
+}
+
+// runtimeSettings holds the current runtime settings. It is safe for concurrent use. mu
+// guards settings and is only held to read or swap them, so that readers never wait on
+// the database; updateMu serializes loads and updates, which do.
+type runtimeSettings struct {
+	mu       sync.RWMutex
+	updateMu sync.Mutex
+	settings model.Settings
+}
+
+// newRuntimeSettings returns runtime settings initialized from the application config.
+func newRuntimeSettings(cfg config.App) *runtimeSettings {
+	return &runtimeSettings{settings: model.Settings{
+		LimiterEnabled:            cfg.Limiter.Enabled,
+		LimiterRps:                cfg.Limiter.Rps,
+		LimiterBurst:              cfg.Limiter.Burst,
+		LimiterAnonymousRps:       cfg.Limiter.AnonymousRps,
+		LimiterAnonymousBurst:     cfg.Limiter.AnonymousBurst,
+		ReminderEnabled:           cfg.Reminder.Enabled,
+		ReminderInterval:          cfg.Reminder.Interval.String(),
+		AutoCloseEnabled:          cfg.AutoClose.Enabled,
+		AutoCloseInterval:         cfg.AutoClose.Interval.String(),
+		ProjectTargetEndDateCheck: cfg.Project.TargetEndDateCheck,
+	}}
+}
+
+// Settings returns the current runtime settings.
+func (c *Controller) Settings() model.Settings {
+	c.settings.mu.RLock()
+	defer c.settings.mu.RUnlock()
+	return c.settings.settings
+}
+
+// set swaps in new runtime settings.
+func (s *runtimeSettings) set(settings model.Settings) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.settings = settings
+}
+
+// interval returns the duration held in a runtime setting, or fallback if it can't be parsed.
+func interval(setting string, fallback time.Duration) time.Duration {
+	d, err := time.ParseDuration(setting)
+	if err != nil || d <= 0 {
+		return fallback
+	}
+	return d
+}
+
+// LoadSettings applies the runtime settings stored in the database over the ones read
+// from the application config. It should be called before background jobs are started.
+func (c *Controller) LoadSettings(ctx context.Context) error {
+	c.settings.updateMu.Lock()
+	defer c.settings.updateMu.Unlock()
+	settings := newRuntimeSettings(c.Config).settings
+	err := c.repo.GetSettings(ctx, &settings)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			// No settings have been changed at runtime yet.
+		default:
+			return err
+		}
+	}
+	c.settings.set(settings)
+	return nil
+}
+
+// StartSettingsReload reloads the runtime settings in a background goroutine once every
+// reload interval until ctx is cancelled, so that changes made through other instances
+// of the application apply here too.
+func (c *Controller) StartSettingsReload(ctx context.Context) {
+	if c.Config.Reload.Interval <= 0 {
+		return
+	}
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		ticker := time.NewTicker(c.Config.Reload.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				err := c.LoadSettings(ctx)
+				if err != nil {
+					c.Logger.Error("failed to reload runtime settings", zap.Error(err))
+				}
+			}
+		}
+	}()
+}
+
+// UpdateSettings changes the runtime settings, stores them and records who changed
+// what. Only managers can change settings. Changes take effect immediately, except
+// for the settings listed in model.SettingsRequiringRestart.
+func (c *Controller) UpdateSettings(ctx context.Context, limiterEnabled *bool, limiterRps *float64, limiterBurst *int, limiterAnonymousRps *float64, limiterAnonymousBurst *int, reminderEnabled *bool, reminderInterval *string, autoCloseEnabled *bool, autoCloseInterval *string, projectTargetEndDateCheck *string, user *model.User) (model.Settings, error) {
+	if user.Role != "manager" {
+		return model.Settings{}, ErrNotPermitted
+	}
+	c.settings.updateMu.Lock()
+	defer c.settings.updateMu.Unlock()
+	before := c.Settings()
+	settings := before
+	if limiterEnabled != nil {
+		settings.LimiterEnabled = *limiterEnabled
+	}
+	if limiterRps != nil {
+		settings.LimiterRps = *limiterRps
+	}
+	if limiterBurst != nil {
+		settings.LimiterBurst = *limiterBurst
+	}
+	if limiterAnonymousRps != nil {
+		settings.LimiterAnonymousRps = *limiterAnonymousRps
+	}
+	if limiterAnonymousBurst != nil {
+		settings.LimiterAnonymousBurst = *limiterAnonymousBurst
+	}
+	if reminderEnabled != nil {
+		settings.ReminderEnabled = *reminderEnabled
+	}
+	if reminderInterval != nil {
+		settings.ReminderInterval = *reminderInterval
+	}
+	if autoCloseEnabled != nil {
+		settings.AutoCloseEnabled = *autoCloseEnabled
+	}
+	if autoCloseInterval != nil {
+		settings.AutoCloseInterval = *autoCloseInterval
+	}
+	if projectTargetEndDateCheck != nil {
+		settings.ProjectTargetEndDateCheck = *projectTargetEndDateCheck
+	}
+	v := validator.New()
+	if settings.Validate(v); !v.Valid() {
+		return model.Settings{}, failedValidationErr(v.Errors)
+	}
+	changes, err := settingsChanges(before, settings)
+	if err != nil {
+		return model.Settings{}, err
+	}
+	if len(changes) == 0 {
+		return settings, nil
+	}
+	err = c.repo.SaveSettings(ctx, changes, user.Name)
+	if err != nil {
+		return model.Settings{}, err
+	}
+	c.settings.set(settings)
+	return settings, nil
+}
+
+// settingsChanges returns the settings whose values differ between before and after,
+// keyed by their JSON names.
+func settingsChanges(before, after model.Settings) (map[string]model.SettingChange, error) {
+	var beforeFields, afterFields map[string]any
+	js, err := json.Marshal(before)
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(js, &beforeFields); err != nil {
+		return nil, err
+	}
+	js, err = json.Marshal(after)
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(js, &afterFields); err != nil {
+		return nil, err
+	}
+	changes := make(map[string]model.SettingChange)
+	for key, value := range afterFields {
+		if beforeFields[key] != value {
+			changes[key] = model.SettingChange{Old: beforeFields[key], New: value}
+		}
+	}
+	return changes, nil
+}
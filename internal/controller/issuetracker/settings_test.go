@@ -0,0 +1,161 @@
+package issuetracker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/emzola/issuetracker/config"
+	"github.com/emzola/issuetracker/internal/repository"
+	"github.com/emzola/issuetracker/pkg/model"
+	"go.uber.org/zap"
+)
+
+// fakeSettingsRepository records the settings saved through it.
+type fakeSettingsRepository struct {
+	fakeRepository
+	stored     string
+	changes    map[string]model.SettingChange
+	modifiedBy string
+}
+
+func (r *fakeSettingsRepository) GetSettings(ctx context.Context, settings *model.Settings) error {
+	if r.stored == "" {
+		return repository.ErrNotFound
+	}
+	return json.Unmarshal([]byte(r.stored), settings)
+}
+
+func (r *fakeSettingsRepository) SaveSettings(ctx context.Context, changes map[string]model.SettingChange, modifiedBy string) error {
+	r.changes = changes
+	r.modifiedBy = modifiedBy
+	return nil
+}
+
+func newSettingsController(repo issueTrackerRepository) *Controller {
+	var cfg config.App
+	cfg.Limiter.Enabled = true
+	cfg.Limiter.Rps = 4
+	cfg.Limiter.Burst = 8
+	cfg.Limiter.AnonymousRps = 2
+	cfg.Limiter.AnonymousBurst = 4
+	cfg.Reminder.Interval = time.Hour
+	cfg.AutoClose.Interval = time.Hour
+	cfg.Project.TargetEndDateCheck = "warn"
+	var wg sync.WaitGroup
+	return New(repo, cfg, &wg, zap.NewNop())
+}
+
+func TestUpdateSettings(t *testing.T) {
+	repo := &fakeSettingsRepository{}
+	c := newSettingsController(repo)
+	rps := 10.0
+	check := "block"
+	manager := &model.User{ID: 1, Name: "Ada Lovelace", Role: "manager"}
+	_, err := c.UpdateSettings(context.Background(), nil, &rps, nil, nil, nil, nil, nil, nil, nil, &check, manager)
+	if err != nil {
+		t.Fatalf("UpdateSettings() error = %v", err)
+	}
+	if got := c.Settings(); got.LimiterRps != rps || got.ProjectTargetEndDateCheck != check {
+		t.Errorf("Settings() = %+v, want limiter_rps %v and project_target_end_date_check %q", got, rps, check)
+	}
+	if len(repo.changes) != 2 || repo.changes["limiter_rps"].New != rps || repo.changes["project_target_end_date_check"].Old != "warn" {
+		t.Errorf("SaveSettings() changes = %v", repo.changes)
+	}
+	if repo.modifiedBy != manager.Name {
+		t.Errorf("SaveSettings() modifiedBy = %q, want %q", repo.modifiedBy, manager.Name)
+	}
+}
+
+func TestUpdateSettingsRejected(t *testing.T) {
+	burst := 0
+	tests := []struct {
+		name    string
+		user    *model.User
+		wantErr error
+	}{
+		{"not a manager", &model.User{ID: 2, Role: "lead"}, ErrNotPermitted},
+		{"invalid value", &model.User{ID: 1, Role: "manager"}, ErrFailedValidation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeSettingsRepository{}
+			c := newSettingsController(repo)
+			_, err := c.UpdateSettings(context.Background(), nil, nil, &burst, nil, nil, nil, nil, nil, nil, nil, tt.user)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UpdateSettings() error = %v, want %v", err, tt.wantErr)
+			}
+			if repo.changes != nil {
+				t.Error("UpdateSettings() saved the settings, want the update rejected")
+			}
+			if got := c.Settings().LimiterBurst; got != 8 {
+				t.Errorf("Settings().LimiterBurst = %v, want 8", got)
+			}
+		})
+	}
+}
+
+func TestLoadSettingsOverConfig(t *testing.T) {
+	repo := &fakeSettingsRepository{}
+	c := newSettingsController(repo)
+	if err := c.LoadSettings(context.Background()); err != nil {
+		t.Fatalf("LoadSettings() without stored settings error = %v", err)
+	}
+	if got := c.Settings().LimiterRps; got != 4 {
+		t.Errorf("Settings().LimiterRps = %v, want 4 from the config", got)
+	}
+	// Another instance changed the rate, and only the rate is stored.
+	repo.stored = `{"limiter_rps": 10}`
+	if err := c.LoadSettings(context.Background()); err != nil {
+		t.Fatalf("LoadSettings() error = %v", err)
+	}
+	if got := c.Settings(); got.LimiterRps != 10 || got.LimiterBurst != 8 {
+		t.Errorf("Settings() = %+v, want limiter_rps 10 and limiter_burst 8 from the config", got)
+	}
+}
+
+// slowSettingsRepository blocks in SaveSettings until release is closed.
+type slowSettingsRepository struct {
+	fakeSettingsRepository
+	saving  chan struct{}
+	release chan struct{}
+}
+
+func (r *slowSettingsRepository) SaveSettings(ctx context.Context, changes map[string]model.SettingChange, modifiedBy string) error {
+	close(r.saving)
+	<-r.release
+	return r.fakeSettingsRepository.SaveSettings(ctx, changes, modifiedBy)
+}
+
+func TestSettingsReadableWhileSaving(t *testing.T) {
+	repo := &slowSettingsRepository{saving: make(chan struct{}), release: make(chan struct{})}
+	c := newSettingsController(repo)
+	rps := 10.0
+	manager := &model.User{ID: 1, Name: "Ada Lovelace", Role: "manager"}
+	done := make(chan error)
+	go func() {
+		_, err := c.UpdateSettings(context.Background(), nil, &rps, nil, nil, nil, nil, nil, nil, nil, nil, manager)
+		done <- err
+	}()
+	<-repo.saving
+	read := make(chan model.Settings)
+	go func() { read <- c.Settings() }()
+	select {
+	case got := <-read:
+		if got.LimiterRps != 4 {
+			t.Errorf("Settings() while saving = %+v, want limiter_rps 4 until the save is done", got)
+		}
+	case <-time.After(time.Second):
+		t.Error("Settings() blocked while UpdateSettings was saving")
+	}
+	close(repo.release)
+	if err := <-done; err != nil {
+		t.Fatalf("UpdateSettings() error = %v", err)
+	}
+	if got := c.Settings().LimiterRps; got != rps {
+		t.Errorf("Settings().LimiterRps = %v, want %v", got, rps)
+	}
+}
@@ -2,6 +2,8 @@ package issuetracker
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"strconv"
 	"time"
@@ -10,18 +12,23 @@ import (
 	"github.com/emzola/issuetracker/pkg/model"
 	"github.com/emzola/issuetracker/pkg/validator"
 	"github.com/pascaldekloe/jwt"
+	"go.uber.org/zap"
 )
 
 type tokenRepository interface {
 	CreateToken(ctx context.Context, userID int64, ttl time.Duration, scope string) (*model.Token, error)
 	DeleteAllTokensForUser(ctx context.Context, scope string, userID int64) error
+	DeleteToken(ctx context.Context, scope, tokenPlaintext string) error
+	RevokeAuthenticationToken(ctx context.Context, jti string, userID int64, expiry time.Time) error
+	IsAuthenticationTokenRevoked(ctx context.Context, jti string) (bool, error)
+	DeleteExpiredRevokedTokens(ctx context.Context) error
 }
 
 func (c *Controller) CreateActivationToken(ctx context.Context, user *model.User) error {
 	if user.Activated {
 		return ErrActivated
 	}
-	token, err := c.repo.CreateToken(ctx, user.ID, 3*24*time.Hour, model.ScopeActivation)
+	token, err := c.repo.CreateToken(ctx, user.ID, activationTokenTTL, model.ScopeActivation)
 	if err != nil {
 		return err
 	}
@@ -30,43 +37,227 @@ func (c *Controller) CreateActivationToken(ctx context.Context, user *model.User
 		"activationToken": token.Plaintext,
 		"name":            user.Name,
 	}
-	c.SendEmail(data, user.Email, "token_activation.tmpl")
+	c.SendEmail(data, user.Email, user.Locale, "token_activation.tmpl")
 	return nil
 }
 
-func (c *Controller) CreateAuthenticationToken(ctx context.Context, email, password string) ([]byte, error) {
+// CreateAuthenticationToken returns a JWT authentication token for the user with the
+// given credentials, along with a refresh token that can be exchanged for a new JWT.
+func (c *Controller) CreateAuthenticationToken(ctx context.Context, email, password string) (*model.AuthenticationToken, *model.Token, error) {
 	v := validator.New()
 	model.ValidateEmail(v, email)
 	model.ValidatePasswordPlaintext(v, password)
 	if !v.Valid() {
-		return nil, failedValidationErr(v.Errors)
+		return nil, nil, failedValidationErr(v.Errors)
 	}
 	user, err := c.repo.GetUserByEmail(ctx, email)
 	if err != nil {
 		switch {
 		case errors.Is(err, repository.ErrNotFound):
-			return nil, ErrInvalidCredentials
+			return nil, nil, ErrInvalidCredentials
 		default:
-			return nil, err
+			return nil, nil, err
 		}
 	}
 	match, err := user.Password.Matches(password)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	if !match {
-		return nil, ErrInvalidCredentials
+		return nil, nil, ErrInvalidCredentials
+	}
+	authToken, err := c.signAuthenticationToken(user)
+	if err != nil {
+		return nil, nil, err
+	}
+	refreshToken, err := c.repo.CreateToken(ctx, user.ID, refreshTokenTTL, model.ScopeRefresh)
+	if err != nil {
+		return nil, nil, err
+	}
+	return authToken, refreshToken, nil
+}
+
+// refreshTokenTTL is how long a refresh token can be exchanged for a new JWT.
+const refreshTokenTTL = 30 * 24 * time.Hour
+
+// RefreshAuthenticationToken exchanges a refresh token for a new JWT authentication
+// token. The refresh token is rotated: it is deleted and a new one is returned, so
+// that each refresh token can only be used once. An invalid or expired refresh token
+// returns ErrInvalidCredentials.
+func (c *Controller) RefreshAuthenticationToken(ctx context.Context, tokenPlaintext string) (*model.AuthenticationToken, *model.Token, error) {
+	v := validator.New()
+	if model.ValidateTokenPlaintext(v, tokenPlaintext); !v.Valid() {
+		return nil, nil, failedValidationErr(v.Errors)
 	}
+	user, err := c.repo.GetUserForToken(ctx, model.ScopeRefresh, tokenPlaintext)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return nil, nil, ErrInvalidCredentials
+		default:
+			return nil, nil, err
+		}
+	}
+	// Deleting the token before issuing a new one means that concurrent requests
+	// replaying the same refresh token fail for all but one of them.
+	err = c.repo.DeleteToken(ctx, model.ScopeRefresh, tokenPlaintext)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return nil, nil, ErrInvalidCredentials
+		default:
+			return nil, nil, err
+		}
+	}
+	authToken, err := c.signAuthenticationToken(user)
+	if err != nil {
+		return nil, nil, err
+	}
+	refreshToken, err := c.repo.CreateToken(ctx, user.ID, refreshTokenTTL, model.ScopeRefresh)
+	if err != nil {
+		return nil, nil, err
+	}
+	return authToken, refreshToken, nil
+}
+
+// RevokeRefreshToken deletes a refresh token so that it can no longer be used.
+func (c *Controller) RevokeRefreshToken(ctx context.Context, tokenPlaintext string) error {
+	v := validator.New()
+	if model.ValidateTokenPlaintext(v, tokenPlaintext); !v.Valid() {
+		return failedValidationErr(v.Errors)
+	}
+	err := c.repo.DeleteToken(ctx, model.ScopeRefresh, tokenPlaintext)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return ErrNotFound
+		default:
+			return err
+		}
+	}
+	return nil
+}
+
+// RevokeAuthenticationToken logs the user out of the session of an authentication
+// token, by revoking the token's JWT ID until the token expires.
+func (c *Controller) RevokeAuthenticationToken(ctx context.Context, user *model.User, jti string, expiry time.Time) error {
+	return c.repo.RevokeAuthenticationToken(ctx, jti, user.ID, expiry)
+}
+
+// IsAuthenticationTokenRevoked reports whether the authentication token with the JWT
+// ID has been revoked.
+func (c *Controller) IsAuthenticationTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	return c.repo.IsAuthenticationTokenRevoked(ctx, jti)
+}
+
+// StartRevokedTokenPurge deletes expired revoked authentication tokens in a background
+// goroutine once an hour until ctx is cancelled, so that the revocation checks made on
+// every request stay cheap.
+func (c *Controller) StartRevokedTokenPurge(ctx context.Context) {
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				err := c.repo.DeleteExpiredRevokedTokens(ctx)
+				if err != nil {
+					c.Logger.Info("failed to delete expired revoked tokens", zap.Error(err))
+				}
+			}
+		}
+	}()
+}
+
+// defaultAuthenticationTokenTTL is how long a JWT authentication token is valid when no
+// expiry is configured.
+const defaultAuthenticationTokenTTL = 24 * time.Hour
+
+// signAuthenticationToken returns a signed JWT authentication token for the user. Each
+// token gets a cryptographically random JWT ID, by which it can be revoked, and is valid
+// from the time it is issued for the configured expiry.
+func (c *Controller) signAuthenticationToken(user *model.User) (*model.AuthenticationToken, error) {
+	randomBytes := make([]byte, 16)
+	_, err := rand.Read(randomBytes)
+	if err != nil {
+		return nil, err
+	}
+	ttl := c.Config.Jwt.Expiry
+	if ttl == 0 {
+		ttl = defaultAuthenticationTokenTTL
+	}
+	now := time.Now()
 	var claims jwt.Claims
+	claims.ID = hex.EncodeToString(randomBytes)
 	claims.Subject = strconv.FormatInt(user.ID, 10)
-	claims.Issued = jwt.NewNumericTime(time.Now())
-	claims.NotBefore = jwt.NewNumericTime(time.Now())
-	claims.Expires = jwt.NewNumericTime(time.Now().Add(24 * time.Hour))
+	claims.Issued = jwt.NewNumericTime(now)
+	claims.NotBefore = jwt.NewNumericTime(now)
+	claims.Expires = jwt.NewNumericTime(now.Add(ttl))
 	claims.Issuer = "github.com/emzola/issuetracker"
 	claims.Audiences = []string{"github.com/emzola/issuetracker"}
+	// Embed the user's token epoch so that the token is invalidated when the
+	// user's role or password changes.
+	claims.Set = map[string]interface{}{"epoch": user.TokenEpoch}
 	jwtBytes, err := claims.HMACSign(jwt.HS256, []byte(c.Config.Jwt.Secret))
 	if err != nil {
 		return nil, err
 	}
-	return jwtBytes, nil
+	return &model.AuthenticationToken{
+		Token:  string(jwtBytes),
+		ID:     claims.ID,
+		Issued: claims.Issued.Time(),
+		Expiry: claims.Expires.Time(),
+	}, nil
+}
+
+// CreateCalendarToken creates a token for the user's calendar feed. Any previously
+// created calendar token is revoked, so the feed URL can be regenerated if it leaks.
+func (c *Controller) CreateCalendarToken(ctx context.Context, user *model.User) (*model.Token, error) {
+	err := c.repo.DeleteAllTokensForUser(ctx, model.ScopeCalendar, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	token, err := c.repo.CreateToken(ctx, user.ID, 365*24*time.Hour, model.ScopeCalendar)
+	if err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// CreatePasswordResetToken emails the user with the given email address a short-lived
+// token for resetting their password. Only activated users can reset their password.
+// Nothing is sent for addresses without an activated user, and no error is returned for
+// them either, so that callers can't tell which addresses have an account.
+func (c *Controller) CreatePasswordResetToken(ctx context.Context, email string) error {
+	v := validator.New()
+	if model.ValidateEmail(v, email); !v.Valid() {
+		return failedValidationErr(v.Errors)
+	}
+	user, err := c.repo.GetUserByEmail(ctx, email)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return nil
+		default:
+			return err
+		}
+	}
+	if !user.Activated {
+		return nil
+	}
+	token, err := c.repo.CreateToken(ctx, user.ID, 45*time.Minute, model.ScopePasswordReset)
+	if err != nil {
+		return err
+	}
+	// Send email with password reset token in a background goroutine.
+	data := map[string]string{
+		"passwordResetToken": token.Plaintext,
+		"name":               user.Name,
+	}
+	c.SendEmail(data, user.Email, user.Locale, "token_password_reset.tmpl")
+	return nil
 }
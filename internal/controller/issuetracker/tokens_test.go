@@ -0,0 +1,281 @@
+package issuetracker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/emzola/issuetracker/config"
+	"github.com/emzola/issuetracker/internal/repository"
+	"github.com/emzola/issuetracker/pkg/model"
+	"github.com/pascaldekloe/jwt"
+	"go.uber.org/zap"
+)
+
+// fakeTokenRepository holds a single user, the scoped tokens issued to them and the
+// authentication tokens they revoked.
+type fakeTokenRepository struct {
+	fakeUserRepository
+	tokens  map[string]string // plaintext to scope
+	revoked map[string]time.Time
+}
+
+func (r *fakeTokenRepository) GetUserForToken(ctx context.Context, tokenScope, tokenPlaintext string) (*model.User, error) {
+	if scope, ok := r.tokens[tokenPlaintext]; !ok || scope != tokenScope {
+		return nil, repository.ErrNotFound
+	}
+	user := *r.user
+	return &user, nil
+}
+
+func (r *fakeTokenRepository) GetUserByEmail(ctx context.Context, email string) (*model.User, error) {
+	if email != r.user.Email {
+		return nil, repository.ErrNotFound
+	}
+	user := *r.user
+	return &user, nil
+}
+
+func (r *fakeTokenRepository) CreateToken(ctx context.Context, userID int64, ttl time.Duration, scope string) (*model.Token, error) {
+	plaintext := fmt.Sprintf("NEWTOKEN%018d", len(r.tokens))
+	r.tokens[plaintext] = scope
+	return &model.Token{Plaintext: plaintext, UserID: userID, Scope: scope}, nil
+}
+
+func (r *fakeTokenRepository) DeleteToken(ctx context.Context, scope, tokenPlaintext string) error {
+	if r.tokens[tokenPlaintext] != scope {
+		return repository.ErrNotFound
+	}
+	delete(r.tokens, tokenPlaintext)
+	return nil
+}
+
+func (r *fakeTokenRepository) RevokeAuthenticationToken(ctx context.Context, jti string, userID int64, expiry time.Time) error {
+	r.revoked[jti] = expiry
+	return nil
+}
+
+func (r *fakeTokenRepository) IsAuthenticationTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	_, ok := r.revoked[jti]
+	return ok, nil
+}
+
+const (
+	testResetToken      = "RESETRESETRESETRESETRESETA"
+	testActivationToken = "ACTIVATEACTIVATEACTIVATEAB"
+	testRefreshToken    = "REFRESHREFRESHREFRESHREFRE"
+)
+
+func newFakeTokenRepository() *fakeTokenRepository {
+	return &fakeTokenRepository{
+		fakeUserRepository: fakeUserRepository{
+			user: &model.User{ID: 1, Name: "Ada Lovelace", Email: "ada@example.com", Activated: true, Role: "member"},
+		},
+		tokens: map[string]string{
+			testResetToken:      model.ScopePasswordReset,
+			testActivationToken: model.ScopeActivation,
+			testRefreshToken:    model.ScopeRefresh,
+		},
+		revoked: map[string]time.Time{},
+	}
+}
+
+func TestResetPassword(t *testing.T) {
+	tests := []struct {
+		name     string
+		token    string
+		password string
+		wantErr  bool
+	}{
+		{"valid", testResetToken, "correct horse battery", false},
+		{"activation token", testActivationToken, "correct horse battery", true},
+		{"short password", testResetToken, "short", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeTokenRepository()
+			var wg sync.WaitGroup
+			c := New(repo, config.App{}, &wg, zap.NewNop())
+			_, err := c.ResetPassword(context.Background(), tt.token, tt.password)
+			if tt.wantErr {
+				if !errors.Is(err, ErrFailedValidation) {
+					t.Fatalf("ResetPassword() error = %v, want ErrFailedValidation", err)
+				}
+				if repo.updated {
+					t.Error("ResetPassword() updated the user, want the reset rejected")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResetPassword() error = %v", err)
+			}
+			match, err := repo.user.Password.Matches(tt.password)
+			if err != nil || !match {
+				t.Errorf("ResetPassword() did not set the new password (match %v, error %v)", match, err)
+			}
+			if want := []string{model.ScopePasswordReset, model.ScopeRefresh}; !reflect.DeepEqual(repo.deletedScopes, want) {
+				t.Errorf("ResetPassword() deleted tokens with scopes %v, want %v", repo.deletedScopes, want)
+			}
+		})
+	}
+}
+
+func TestGetUserForTokenScope(t *testing.T) {
+	tests := []struct {
+		name    string
+		scope   string
+		token   string
+		wantErr bool
+	}{
+		{"password reset token", model.ScopePasswordReset, testResetToken, false},
+		{"activation token", model.ScopeActivation, testActivationToken, false},
+		{"password reset token with activation scope", model.ScopeActivation, testResetToken, true},
+		{"activation token with password reset scope", model.ScopePasswordReset, testActivationToken, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var wg sync.WaitGroup
+			c := New(newFakeTokenRepository(), config.App{}, &wg, zap.NewNop())
+			user, err := c.GetUserForToken(context.Background(), tt.scope, tt.token)
+			if tt.wantErr {
+				if !errors.Is(err, ErrFailedValidation) {
+					t.Errorf("GetUserForToken() error = %v, want ErrFailedValidation", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetUserForToken() error = %v", err)
+			}
+			if user.ID != 1 {
+				t.Errorf("GetUserForToken() user ID = %d, want 1", user.ID)
+			}
+		})
+	}
+}
+
+func TestRefreshAuthenticationToken(t *testing.T) {
+	repo := newFakeTokenRepository()
+	var wg sync.WaitGroup
+	var cfg config.App
+	cfg.Jwt.Secret = "secret"
+	c := New(repo, cfg, &wg, zap.NewNop())
+	authToken, refreshToken, err := c.RefreshAuthenticationToken(context.Background(), testRefreshToken)
+	if err != nil {
+		t.Fatalf("RefreshAuthenticationToken() error = %v", err)
+	}
+	if authToken.Token == "" {
+		t.Error("RefreshAuthenticationToken() returned an empty authentication token")
+	}
+	if refreshToken.Plaintext == testRefreshToken || refreshToken.Scope != model.ScopeRefresh {
+		t.Errorf("RefreshAuthenticationToken() refresh token = %+v, want a new refresh token", refreshToken)
+	}
+	// The old refresh token has been rotated out and can't be replayed.
+	_, _, err = c.RefreshAuthenticationToken(context.Background(), testRefreshToken)
+	if !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("RefreshAuthenticationToken() with a used token error = %v, want ErrInvalidCredentials", err)
+	}
+	// Tokens of other scopes can't be exchanged for an authentication token.
+	_, _, err = c.RefreshAuthenticationToken(context.Background(), testResetToken)
+	if !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("RefreshAuthenticationToken() with a password reset token error = %v, want ErrInvalidCredentials", err)
+	}
+}
+
+func TestRevokeAuthenticationToken(t *testing.T) {
+	repo := newFakeTokenRepository()
+	var wg sync.WaitGroup
+	var cfg config.App
+	cfg.Jwt.Secret = "secret"
+	c := New(repo, cfg, &wg, zap.NewNop())
+	var ids []string
+	for i := 0; i < 2; i++ {
+		authToken, err := c.signAuthenticationToken(repo.user)
+		if err != nil {
+			t.Fatalf("signAuthenticationToken() error = %v", err)
+		}
+		ids = append(ids, authToken.ID)
+	}
+	err := c.RevokeAuthenticationToken(context.Background(), repo.user, ids[0], time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("RevokeAuthenticationToken() error = %v", err)
+	}
+	// Only the session that logged out is revoked.
+	for i, want := range []bool{true, false} {
+		revoked, err := c.IsAuthenticationTokenRevoked(context.Background(), ids[i])
+		if err != nil {
+			t.Fatalf("IsAuthenticationTokenRevoked() error = %v", err)
+		}
+		if revoked != want {
+			t.Errorf("IsAuthenticationTokenRevoked(%q) = %v, want %v", ids[i], revoked, want)
+		}
+	}
+}
+
+func TestCreateAuthenticationTokenJTI(t *testing.T) {
+	repo := newFakeTokenRepository()
+	err := repo.user.Password.Set("correct horse battery")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	var cfg config.App
+	cfg.Jwt.Secret = "secret"
+	cfg.Jwt.Expiry = 15 * time.Minute
+	c := New(repo, cfg, &wg, zap.NewNop())
+	var ids []string
+	for i := 0; i < 2; i++ {
+		authToken, _, err := c.CreateAuthenticationToken(context.Background(), "ada@example.com", "correct horse battery")
+		if err != nil {
+			t.Fatalf("CreateAuthenticationToken() error = %v", err)
+		}
+		claims, err := jwt.HMACCheck([]byte(authToken.Token), []byte(cfg.Jwt.Secret))
+		if err != nil {
+			t.Fatalf("HMACCheck() error = %v", err)
+		}
+		if claims.ID == "" || claims.ID != authToken.ID {
+			t.Errorf("CreateAuthenticationToken() jti claim = %q, want the returned ID %q", claims.ID, authToken.ID)
+		}
+		if !authToken.Expiry.Equal(authToken.Issued.Add(cfg.Jwt.Expiry)) {
+			t.Errorf("CreateAuthenticationToken() valid from %v to %v, want %v", authToken.Issued, authToken.Expiry, cfg.Jwt.Expiry)
+		}
+		ids = append(ids, claims.ID)
+	}
+	if ids[0] == ids[1] {
+		t.Errorf("CreateAuthenticationToken() issued two tokens with the jti %q, want distinct values", ids[0])
+	}
+}
+
+func TestCreatePasswordResetTokenHidesAccounts(t *testing.T) {
+	tests := []struct {
+		name      string
+		email     string
+		activated bool
+		wantEmail bool
+	}{
+		{"activated user", "ada@example.com", true, true},
+		{"user not activated", "ada@example.com", false, false},
+		{"no user", "grace@example.com", true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeTokenRepository()
+			repo.user.Activated = tt.activated
+			sender := newFakeEmailSender()
+			var wg sync.WaitGroup
+			c := New(repo, config.App{}, &wg, zap.NewNop())
+			c.emails = c.startEmailPool(sender, 1)
+			err := c.CreatePasswordResetToken(context.Background(), tt.email)
+			wg.Wait()
+			if err != nil {
+				t.Fatalf("CreatePasswordResetToken() error = %v, want nil whether or not the account exists", err)
+			}
+			if _, sent := sender.sent[tt.email]; sent != tt.wantEmail {
+				t.Errorf("CreatePasswordResetToken() emailed %s = %v, want %v", tt.email, sent, tt.wantEmail)
+			}
+		})
+	}
+}
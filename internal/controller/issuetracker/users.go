@@ -3,6 +3,8 @@ package issuetracker
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/emzola/issuetracker/internal/repository"
@@ -13,55 +15,188 @@ import (
 
 type userRepository interface {
 	CreateUser(ctx context.Context, user *model.User) error
+	CreateUsers(ctx context.Context, users []*model.User, ttl time.Duration, atomic bool) ([]*model.Token, []error, error)
 	GetUserByEmail(ctx context.Context, email string) (*model.User, error)
 	GetUserByID(ctx context.Context, id int64) (*model.User, error)
-	GetAllUsers(ctx context.Context, name, email, role string, filters model.Filters) ([]*model.User, model.Metadata, error)
+	GetAllUsers(ctx context.Context, name, email, role, q string, filters model.Filters) ([]*model.User, model.Metadata, error)
 	CreateToken(ctx context.Context, userID int64, ttl time.Duration, scope string) (*model.Token, error)
 	GetUserForToken(ctx context.Context, tokenScope, tokenPlaintext string) (*model.User, error)
 	UpdateUser(ctx context.Context, user *model.User) error
-	DeleteUser(ctx context.Context, id int64) error
+	DeleteUser(ctx context.Context, id int64, force bool, deletedBy *model.User) error
 	AssignUserToProject(ctx context.Context, userID, projectID int64) error
 	GetAllProjectsForUser(ctx context.Context, userID int64, filters model.Filters) ([]*model.Project, model.Metadata, error)
 }
 
-func (c *Controller) CreateUser(ctx context.Context, name, email, password, role, createdBy, modifiedBy string) (*model.User, error) {
+func (c *Controller) CreateUser(ctx context.Context, name, email, password, role, locale, createdBy, modifiedBy string) (*model.User, error) {
+	user, err := c.newUser(name, email, password, role, locale, createdBy, modifiedBy)
+	if err != nil {
+		return nil, err
+	}
+	err = c.repo.CreateUser(ctx, user)
+	if err != nil {
+		return nil, createUserErr(err)
+	}
+	// Generate an activation token.
+	token, err := c.repo.CreateToken(ctx, user.ID, activationTokenTTL, model.ScopeActivation)
+	if err != nil {
+		return nil, err
+	}
+	// Send welcome email with activation token in a background goroutine.
+	data := map[string]string{
+		"activationToken": token.Plaintext,
+		"name":            user.Name,
+	}
+	c.SendEmail(data, user.Email, user.Locale, "user_welcome.tmpl")
+	return user, nil
+}
+
+// activationTokenTTL is how long the activation tokens of new users are valid for.
+const activationTokenTTL = 3 * 24 * time.Hour
+
+// newUser returns a user who isn't activated yet, validated like every new user.
+func (c *Controller) newUser(name, email, password, role, locale, createdBy, modifiedBy string) (*model.User, error) {
 	user := &model.User{
 		Name:       name,
 		Email:      email,
 		Role:       role,
+		Locale:     locale,
 		Activated:  false,
 		CreatedBy:  createdBy,
 		ModifiedBy: modifiedBy,
 	}
-	err := user.Password.Set(password)
+	if user.Locale == "" {
+		user.Locale = model.DefaultLocale
+	}
+	err := user.Password.SetWithCost(password, c.Config.BcryptCost)
 	if err != nil {
 		return nil, err
 	}
 	v := validator.New()
-	if user.Validate(v); !v.Valid() {
+	user.Validate(v)
+	model.ValidatePasswordPolicy(v, password, model.PasswordPolicy(c.Config.PasswordPolicy))
+	if !v.Valid() {
 		return nil, failedValidationErr(v.Errors)
 	}
-	err = c.repo.CreateUser(ctx, user)
+	return user, nil
+}
+
+// createUserErr maps an error creating a user to the error returned to the caller. An
+// email that is already taken is a validation error.
+func createUserErr(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrDuplicateKey):
+		return failedValidationErr(map[string]string{"email": "a user with this email already exists"})
+	default:
+		return err
+	}
+}
+
+// maxImportUsers is the maximum number of users that can be imported at once.
+const maxImportUsers = 200
+
+// ImportUsers creates a user for each row on behalf of a manager, and returns the
+// outcome for each row in order: the created user, or the error that kept the row from
+// being created. Rows are validated like new users, and must name an existing role, or
+// none for members. Imported users have no usable password and are emailed an activation
+// token; once activated, they choose their own password with a password reset. If atomic is set, a row that fails keeps every row from being created, and the
+// other rows are reported with ErrRolledBack. Otherwise the valid rows are created
+// regardless.
+func (c *Controller) ImportUsers(ctx context.Context, rows []model.UserImport, atomic bool, author *model.User, v *validator.Validator) ([]*model.User, []error, error) {
+	if author.Role != "manager" {
+		return nil, nil, ErrNotPermitted
+	}
+	v.Check(len(rows) > 0, "file", "must contain at least one user")
+	v.Check(len(rows) <= maxImportUsers, "file", fmt.Sprintf("must not contain more than %d users", maxImportUsers))
+	if !v.Valid() {
+		return nil, nil, failedValidationErr(v.Errors)
+	}
+	roles, err := c.repo.GetAllRoles(ctx)
 	if err != nil {
-		switch {
-		case errors.Is(err, repository.ErrDuplicateKey):
-			v.AddError("email", "a user with this email already exists")
-			return nil, failedValidationErr(v.Errors)
-		default:
-			return nil, err
+		return nil, nil, err
+	}
+	roleNames := make([]string, len(roles))
+	for i, role := range roles {
+		roleNames[i] = role.Name
+	}
+	users := make([]*model.User, len(rows))
+	results := make([]error, len(rows))
+	var valid []*model.User
+	var positions []int
+	for i, row := range rows {
+		if row.Role == "" {
+			row.Role = "member"
 		}
+		user, err := newInvitedUser(row.Name, row.Email, row.Role, author.Name)
+		var verr *ValidationError
+		if err != nil && !errors.As(err, &verr) {
+			return nil, nil, err
+		}
+		rv := validator.New()
+		if verr != nil {
+			rv.Errors = verr.Fields
+		}
+		if rv.Check(validator.In(row.Role, roleNames...), "role", "must be an existing role"); !rv.Valid() {
+			results[i] = failedValidationErr(rv.Errors)
+			continue
+		}
+		valid = append(valid, user)
+		positions = append(positions, i)
 	}
-	// Generate an activation token.
-	token, err := c.repo.CreateToken(ctx, user.ID, 3*24*time.Hour, model.ScopeActivation)
+	if len(valid) == 0 {
+		return users, results, nil
+	}
+	if atomic && len(valid) < len(rows) {
+		for _, position := range positions {
+			results[position] = ErrRolledBack
+		}
+		return users, results, nil
+	}
+	tokens, errs, err := c.repo.CreateUsers(ctx, valid, activationTokenTTL, atomic)
 	if err != nil {
-		return nil, err
+		var batchErr *repository.BatchError
+		if !errors.As(err, &batchErr) {
+			return nil, nil, err
+		}
+		for _, position := range positions {
+			results[position] = ErrRolledBack
+		}
+		results[positions[batchErr.Index]] = createUserErr(batchErr.Err)
+		return users, results, nil
 	}
-	// Send welcome email with activation token in a background goroutine.
-	data := map[string]string{
-		"activationToken": token.Plaintext,
-		"name":            user.Name,
+	var invites []email
+	for i, user := range valid {
+		if errs[i] != nil {
+			results[positions[i]] = createUserErr(errs[i])
+			continue
+		}
+		users[positions[i]] = user
+		data := map[string]string{
+			"activationToken": tokens[i].Plaintext,
+			"name":            user.Name,
+			"invitedBy":       author.Name,
+		}
+		invites = append(invites, email{data: data, recipient: user.Email, locale: user.Locale, template: "user_invite.tmpl"})
+	}
+	c.sendEmails(invites)
+	return users, results, nil
+}
+
+// newInvitedUser returns a user without a password, for users who choose theirs with a
+// password reset once activated.
+func newInvitedUser(name, email, role, createdBy string) (*model.User, error) {
+	user := &model.User{
+		Name:       name,
+		Email:      email,
+		Role:       role,
+		Locale:     model.DefaultLocale,
+		CreatedBy:  createdBy,
+		ModifiedBy: createdBy,
+	}
+	user.Password.SetUnusable()
+	v := validator.New()
+	if user.Validate(v); !v.Valid() {
+		return nil, failedValidationErr(v.Errors)
 	}
-	c.SendEmail(data, user.Email, "user_welcome.tmpl")
 	return user, nil
 }
 
@@ -96,11 +231,11 @@ func (c *Controller) GetUserByID(ctx context.Context, id int64) (*model.User, er
 	return user, nil
 }
 
-func (c *Controller) GetAllUsers(ctx context.Context, name, email, role string, filters model.Filters, v *validator.Validator) ([]*model.User, model.Metadata, error) {
+func (c *Controller) GetAllUsers(ctx context.Context, name, email, role, q string, filters model.Filters, v *validator.Validator) ([]*model.User, model.Metadata, error) {
 	if filters.Validate(v); !v.Valid() {
 		return nil, model.Metadata{}, failedValidationErr(v.Errors)
 	}
-	users, metadata, err := c.repo.GetAllUsers(ctx, name, email, role, filters)
+	users, metadata, err := c.repo.GetAllUsers(ctx, name, email, role, q, filters)
 	if err != nil {
 		return nil, model.Metadata{}, err
 	}
@@ -112,11 +247,11 @@ func (c *Controller) GetUserForToken(ctx context.Context, tokenScope, tokenPlain
 	if model.ValidateTokenPlaintext(v, tokenPlaintext); !v.Valid() {
 		return nil, failedValidationErr(v.Errors)
 	}
-	user, err := c.repo.GetUserForToken(ctx, model.ScopeActivation, tokenPlaintext)
+	user, err := c.repo.GetUserForToken(ctx, tokenScope, tokenPlaintext)
 	if err != nil {
 		switch {
 		case errors.Is(err, repository.ErrNotFound):
-			v.AddError("token", "invalid or expired activation token")
+			v.AddError("token", "invalid or expired "+tokenScope+" token")
 			return nil, failedValidationErr(v.Errors)
 		default:
 			return nil, err
@@ -125,7 +260,12 @@ func (c *Controller) GetUserForToken(ctx context.Context, tokenScope, tokenPlain
 	return user, nil
 }
 
+// ActivateUser activates the user. Users who are already activated, including by a
+// concurrent activation that changed the user first, get ErrActivated.
 func (c *Controller) ActivateUser(ctx context.Context, user *model.User, modifiedBy string) error {
+	if user.Activated {
+		return ErrActivated
+	}
 	// Update user.
 	user.Activated = true
 	user.ModifiedBy = modifiedBy
@@ -133,6 +273,14 @@ func (c *Controller) ActivateUser(ctx context.Context, user *model.User, modifie
 	if err != nil {
 		switch {
 		case errors.Is(err, repository.ErrEditConflict):
+			user.Activated = false
+			current, err := c.repo.GetUserByID(ctx, user.ID)
+			if err != nil {
+				return err
+			}
+			if current.Activated {
+				return ErrActivated
+			}
 			return ErrEditConflict
 		default:
 			return err
@@ -146,7 +294,221 @@ func (c *Controller) ActivateUser(ctx context.Context, user *model.User, modifie
 	return nil
 }
 
-func (c *Controller) UpdateUser(ctx context.Context, id int64, name, email, role *string, modifiedBy string) (*model.User, error) {
+// ResetPassword sets a new password for the owner of a password reset token. Changing
+// the password also invalidates the user's existing authentication tokens.
+func (c *Controller) ResetPassword(ctx context.Context, tokenPlaintext, newPassword string) (*model.User, error) {
+	v := validator.New()
+	model.ValidatePasswordPlaintext(v, newPassword)
+	model.ValidatePasswordPolicy(v, newPassword, model.PasswordPolicy(c.Config.PasswordPolicy))
+	model.ValidateTokenPlaintext(v, tokenPlaintext)
+	if !v.Valid() {
+		return nil, failedValidationErr(v.Errors)
+	}
+	user, err := c.GetUserForToken(ctx, model.ScopePasswordReset, tokenPlaintext)
+	if err != nil {
+		return nil, err
+	}
+	err = user.Password.SetWithCost(newPassword, c.Config.BcryptCost)
+	if err != nil {
+		return nil, err
+	}
+	// Users who reset their own password are recorded as the modifier.
+	user.ModifiedBy = user.Name
+	err = c.repo.UpdateUser(ctx, user)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrEditConflict):
+			return nil, ErrEditConflict
+		default:
+			return nil, err
+		}
+	}
+	// Delete all password reset tokens for user, and the refresh tokens issued with
+	// the old password.
+	for _, scope := range []string{model.ScopePasswordReset, model.ScopeRefresh} {
+		err = c.repo.DeleteAllTokensForUser(ctx, scope, user.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return user, nil
+}
+
+// ChangePassword replaces the user's password after checking their current one. A wrong
+// current password returns ErrInvalidCredentials. Changing the password also invalidates
+// the user's existing authentication tokens.
+func (c *Controller) ChangePassword(ctx context.Context, userID int64, currentPassword, newPassword string) (*model.User, error) {
+	user, err := c.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	match, err := user.Password.Matches(currentPassword)
+	if err != nil {
+		return nil, err
+	}
+	if !match {
+		return nil, ErrInvalidCredentials
+	}
+	v := validator.New()
+	model.ValidatePasswordPlaintext(v, newPassword)
+	model.ValidatePasswordPolicy(v, newPassword, model.PasswordPolicy(c.Config.PasswordPolicy))
+	v.Check(newPassword != currentPassword, "password", "must be different from the current password")
+	if !v.Valid() {
+		return nil, failedValidationErr(v.Errors)
+	}
+	err = user.Password.SetWithCost(newPassword, c.Config.BcryptCost)
+	if err != nil {
+		return nil, err
+	}
+	user.ModifiedBy = user.Name
+	err = c.repo.UpdateUser(ctx, user)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrEditConflict):
+			return nil, ErrEditConflict
+		default:
+			return nil, err
+		}
+	}
+	// Refresh tokens issued with the old password are revoked.
+	err = c.repo.DeleteAllTokensForUser(ctx, model.ScopeRefresh, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// RequestEmailChange emails a token for confirming the change of the user's email to
+// the new address. The user's email is only changed once the token is confirmed, and
+// only the token of the latest request can be confirmed.
+func (c *Controller) RequestEmailChange(ctx context.Context, user *model.User, email string) error {
+	v := validator.New()
+	v.Check(!strings.EqualFold(email, user.Email), "email", "must be different from the current email")
+	err := c.validateEmailChange(ctx, v, email)
+	if err != nil {
+		return err
+	}
+	if !v.Valid() {
+		return failedValidationErr(v.Errors)
+	}
+	user.PendingEmail = email
+	user.ModifiedBy = user.Name
+	err = c.repo.UpdateUser(ctx, user)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrEditConflict):
+			return ErrEditConflict
+		default:
+			return err
+		}
+	}
+	return c.sendEmailChangeToken(ctx, user)
+}
+
+// validateEmailChange checks that email is valid and not taken by another user.
+func (c *Controller) validateEmailChange(ctx context.Context, v *validator.Validator, email string) error {
+	if model.ValidateEmail(v, email); !v.Valid() {
+		return nil
+	}
+	_, err := c.repo.GetUserByEmail(ctx, email)
+	switch {
+	case err == nil:
+		v.AddError("email", "a user with this email already exists")
+	case !errors.Is(err, repository.ErrNotFound):
+		return err
+	}
+	return nil
+}
+
+// sendEmailChangeToken replaces the user's email change token, and emails the new one to
+// their pending email.
+func (c *Controller) sendEmailChangeToken(ctx context.Context, user *model.User) error {
+	err := c.repo.DeleteAllTokensForUser(ctx, model.ScopeEmailChange, user.ID)
+	if err != nil {
+		return err
+	}
+	token, err := c.repo.CreateToken(ctx, user.ID, 24*time.Hour, model.ScopeEmailChange)
+	if err != nil {
+		return err
+	}
+	// Send email with email change token to the new address in a background goroutine,
+	// so that only its owner can confirm it.
+	data := map[string]string{
+		"emailChangeToken": token.Plaintext,
+		"name":             user.Name,
+	}
+	c.SendEmail(data, user.PendingEmail, user.Locale, "token_email_change.tmpl")
+	return nil
+}
+
+// ConfirmEmailChange changes the email of the owner of an email change token to the
+// email they asked to change to.
+func (c *Controller) ConfirmEmailChange(ctx context.Context, tokenPlaintext string) (*model.User, error) {
+	user, err := c.GetUserForToken(ctx, model.ScopeEmailChange, tokenPlaintext)
+	if err != nil {
+		return nil, err
+	}
+	v := validator.New()
+	if user.PendingEmail == "" {
+		v.AddError("token", "invalid or expired "+model.ScopeEmailChange+" token")
+		return nil, failedValidationErr(v.Errors)
+	}
+	user.Email = user.PendingEmail
+	user.PendingEmail = ""
+	// Users who confirm their own email change are recorded as the modifier.
+	user.ModifiedBy = user.Name
+	err = c.repo.UpdateUser(ctx, user)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrDuplicateKey):
+			v.AddError("email", "a user with this email already exists")
+			return nil, failedValidationErr(v.Errors)
+		case errors.Is(err, repository.ErrEditConflict):
+			return nil, ErrEditConflict
+		default:
+			return nil, err
+		}
+	}
+	err = c.repo.DeleteAllTokensForUser(ctx, model.ScopeEmailChange, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// UpdateCurrentUser updates the preferences users can change on their own profile.
+func (c *Controller) UpdateCurrentUser(ctx context.Context, user *model.User, digestOptIn *bool, locale *string) (*model.User, error) {
+	if digestOptIn != nil {
+		user.DigestOptIn = *digestOptIn
+	}
+	if locale != nil {
+		user.Locale = *locale
+	}
+	v := validator.New()
+	if model.ValidateLocale(v, user.Locale); !v.Valid() {
+		return nil, failedValidationErr(v.Errors)
+	}
+	user.ModifiedBy = user.Name
+	err := c.repo.UpdateUser(ctx, user)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrEditConflict):
+			return nil, ErrEditConflict
+		default:
+			return nil, err
+		}
+	}
+	return user, nil
+}
+
+// UpdateUser updates a user on behalf of the author. Only managers can update other
+// users or change roles; everyone else can only update their own profile. A new email
+// goes through the same confirmation as RequestEmailChange: it is emailed a token, and
+// only replaces the current email once the token is confirmed.
+func (c *Controller) UpdateUser(ctx context.Context, id int64, name, email, role, locale *string, author *model.User) (*model.User, error) {
+	if author.Role != "manager" && author.ID != id {
+		return nil, ErrNotPermitted
+	}
 	user, err := c.repo.GetUserByID(ctx, id)
 	if err != nil {
 		switch {
@@ -159,38 +521,63 @@ func (c *Controller) UpdateUser(ctx context.Context, id int64, name, email, role
 	if name != nil {
 		user.Name = *name
 	}
-	if email != nil {
-		user.Email = *email
+	v := validator.New()
+	changeEmail := email != nil && !strings.EqualFold(*email, user.Email)
+	if changeEmail {
+		err = c.validateEmailChange(ctx, v, *email)
+		if err != nil {
+			return nil, err
+		}
+		user.PendingEmail = *email
 	}
-	if role != nil {
+	if role != nil && *role != user.Role {
+		if author.Role != "manager" {
+			return nil, ErrNotPermitted
+		}
 		user.Role = *role
 	}
-	user.ModifiedBy = modifiedBy
-	v := validator.New()
+	if locale != nil {
+		user.Locale = *locale
+	}
+	user.ModifiedBy = author.Name
 	if user.Validate(v); !v.Valid() {
 		return nil, failedValidationErr(v.Errors)
 	}
 	err = c.repo.UpdateUser(ctx, user)
 	if err != nil {
 		switch {
-		case errors.Is(err, repository.ErrDuplicateKey):
-			v.AddError("email", "a user with this email already exists")
-			return nil, failedValidationErr(v.Errors)
 		case errors.Is(err, repository.ErrEditConflict):
 			return nil, ErrEditConflict
 		default:
 			return nil, err
 		}
 	}
+	if changeEmail {
+		err = c.sendEmailChangeToken(ctx, user)
+		if err != nil {
+			return nil, err
+		}
+	}
 	return user, nil
 }
 
-func (c *Controller) DeleteUser(ctx context.Context, id int64) error {
-	err := c.repo.DeleteUser(ctx, id)
+// DeleteUser deletes a user. Users with open issues assigned to them are only deleted if
+// force is set, in which case their issues are unassigned by deletedBy, and an
+// OpenIssuesError is returned otherwise. Users who reported issues can't be deleted.
+func (c *Controller) DeleteUser(ctx context.Context, id int64, force bool, deletedBy *model.User, v *validator.Validator) error {
+	if !v.Valid() {
+		return failedValidationErr(v.Errors)
+	}
+	err := c.repo.DeleteUser(ctx, id, force, deletedBy)
 	if err != nil {
+		var openIssuesErr *repository.OpenIssuesError
 		switch {
+		case errors.As(err, &openIssuesErr):
+			return &OpenIssuesError{Count: openIssuesErr.Count}
 		case errors.Is(err, repository.ErrNotFound):
 			return ErrNotFound
+		case errors.Is(err, repository.ErrReferenced):
+			return ErrReportedIssues
 		default:
 			return err
 		}
@@ -0,0 +1,533 @@
+package issuetracker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/emzola/issuetracker/config"
+	"github.com/emzola/issuetracker/internal/repository"
+	"github.com/emzola/issuetracker/pkg/model"
+	"github.com/emzola/issuetracker/pkg/validator"
+	"go.uber.org/zap"
+)
+
+// fakeUserRepository holds a single user.
+type fakeUserRepository struct {
+	fakeRepository
+	user          *model.User
+	updated       bool
+	deletedScopes []string
+	openIssues    int
+	deleted       bool
+}
+
+func (r *fakeUserRepository) GetUserByID(ctx context.Context, id int64) (*model.User, error) {
+	user := *r.user
+	return &user, nil
+}
+
+func (r *fakeUserRepository) UpdateUser(ctx context.Context, user *model.User) error {
+	if user.Version != r.user.Version {
+		return repository.ErrEditConflict
+	}
+	user.Version++
+	updated := *user
+	r.user = &updated
+	r.updated = true
+	return nil
+}
+
+func (r *fakeUserRepository) DeleteAllTokensForUser(ctx context.Context, scope string, userID int64) error {
+	r.deletedScopes = append(r.deletedScopes, scope)
+	return nil
+}
+
+func (r *fakeUserRepository) DeleteUser(ctx context.Context, id int64, force bool, deletedBy *model.User) error {
+	if !force && r.openIssues > 0 {
+		return &repository.OpenIssuesError{Count: r.openIssues}
+	}
+	r.deleted = true
+	return nil
+}
+
+func TestChangePassword(t *testing.T) {
+	tests := []struct {
+		name     string
+		current  string
+		password string
+		wantErr  bool
+	}{
+		{"valid", "pa55word-old", "pa55word-new", false},
+		{"wrong current password", "pa55word-wrong", "pa55word-new", true},
+		{"same password", "pa55word-old", "pa55word-old", true},
+		{"short password", "pa55word-old", "short", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := &model.User{ID: 1, Name: "Ada Lovelace", Email: "ada@example.com", Activated: true, Role: "member"}
+			if err := user.Password.Set("pa55word-old"); err != nil {
+				t.Fatal(err)
+			}
+			repo := &fakeUserRepository{user: user}
+			var wg sync.WaitGroup
+			c := New(repo, config.App{}, &wg, zap.NewNop())
+			_, err := c.ChangePassword(context.Background(), 1, tt.current, tt.password)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidCredentials) && !errors.Is(err, ErrFailedValidation) {
+					t.Fatalf("ChangePassword() error = %v, want ErrInvalidCredentials or ErrFailedValidation", err)
+				}
+				if repo.updated {
+					t.Error("ChangePassword() updated the user, want the change rejected")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ChangePassword() error = %v", err)
+			}
+			if match, _ := repo.user.Password.Matches(tt.password); !match {
+				t.Error("ChangePassword() did not set the new password")
+			}
+			if len(repo.deletedScopes) != 1 || repo.deletedScopes[0] != model.ScopeRefresh {
+				t.Errorf("ChangePassword() deleted tokens with scopes %v, want [%q]", repo.deletedScopes, model.ScopeRefresh)
+			}
+		})
+	}
+}
+
+func TestChangePasswordPolicy(t *testing.T) {
+	user := &model.User{ID: 1, Name: "Ada Lovelace", Email: "ada@example.com", Activated: true, Role: "member"}
+	if err := user.Password.SetWithCost("pa55word-old", 4); err != nil {
+		t.Fatal(err)
+	}
+	repo := &fakeUserRepository{user: user}
+	var wg sync.WaitGroup
+	cfg := config.App{BcryptCost: 4}
+	cfg.PasswordPolicy.RequireUpper = true
+	cfg.PasswordPolicy.RequireDigit = true
+	c := New(repo, cfg, &wg, zap.NewNop())
+	_, err := c.ChangePassword(context.Background(), 1, "pa55word-old", "no-uppercase-9")
+	if !errors.Is(err, ErrFailedValidation) || !strings.Contains(err.Error(), "password_uppercase") {
+		t.Fatalf("ChangePassword() error = %v, want a password_uppercase validation error", err)
+	}
+	if repo.updated {
+		t.Error("ChangePassword() updated the user, want the change rejected")
+	}
+	_, err = c.ChangePassword(context.Background(), 1, "pa55word-old", "Uppercase-9")
+	if err != nil {
+		t.Fatalf("ChangePassword() error = %v", err)
+	}
+}
+
+func TestUpdateCurrentUserLocale(t *testing.T) {
+	tests := []struct {
+		name    string
+		locale  string
+		wantErr bool
+	}{
+		{"supported locale", "fr", false},
+		{"unsupported locale", "xx", true},
+		{"region", "fr-CA", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := &model.User{ID: 1, Name: "Ada Lovelace", Email: "ada@example.com", Activated: true, Role: "member", Locale: model.DefaultLocale}
+			repo := &fakeUserRepository{user: user}
+			var wg sync.WaitGroup
+			c := New(repo, config.App{}, &wg, zap.NewNop())
+			_, err := c.UpdateCurrentUser(context.Background(), user, nil, &tt.locale)
+			if tt.wantErr {
+				if !errors.Is(err, ErrFailedValidation) {
+					t.Fatalf("UpdateCurrentUser() error = %v, want ErrFailedValidation", err)
+				}
+				if repo.updated {
+					t.Error("UpdateCurrentUser() updated the user, want the change rejected")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("UpdateCurrentUser() error = %v", err)
+			}
+			if repo.user.Locale != tt.locale {
+				t.Errorf("UpdateCurrentUser() locale = %q, want %q", repo.user.Locale, tt.locale)
+			}
+		})
+	}
+}
+
+func TestUpdateUserPermissions(t *testing.T) {
+	manager, member := "manager", "member"
+	name := "Ada King"
+	tests := []struct {
+		name    string
+		author  *model.User
+		role    *string
+		wantErr error
+	}{
+		{"member promotes themselves", &model.User{ID: 1, Role: "member"}, &manager, ErrNotPermitted},
+		{"member updates their name", &model.User{ID: 1, Role: "member"}, nil, nil},
+		{"member keeps their role", &model.User{ID: 1, Role: "member"}, &member, nil},
+		{"member updates another user", &model.User{ID: 2, Role: "member"}, nil, ErrNotPermitted},
+		{"lead promotes themselves", &model.User{ID: 1, Role: "lead"}, &manager, ErrNotPermitted},
+		{"manager promotes a member", &model.User{ID: 2, Role: "manager"}, &manager, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := &model.User{ID: 1, Name: "Ada Lovelace", Email: "ada@example.com", Activated: true, Role: "member", Locale: model.DefaultLocale}
+			if err := user.Password.SetWithCost("pa55word", 4); err != nil {
+				t.Fatal(err)
+			}
+			repo := &fakeUserRepository{user: user}
+			var wg sync.WaitGroup
+			c := New(repo, config.App{}, &wg, zap.NewNop())
+			_, err := c.UpdateUser(context.Background(), 1, &name, nil, tt.role, nil, tt.author)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UpdateUser() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if repo.updated {
+					t.Error("UpdateUser() updated the user, want the update rejected")
+				}
+				return
+			}
+			if !repo.updated || repo.user.Name != name {
+				t.Errorf("UpdateUser() name = %q, want %q", repo.user.Name, name)
+			}
+			if tt.role != nil && repo.user.Role != *tt.role {
+				t.Errorf("UpdateUser() role = %q, want %q", repo.user.Role, *tt.role)
+			}
+		})
+	}
+}
+
+func TestRequestEmailChange(t *testing.T) {
+	tests := []struct {
+		name    string
+		email   string
+		wantErr bool
+	}{
+		{"valid", "ada@analytical.example.com", false},
+		{"current email", "ADA@example.com", true},
+		{"invalid email", "ada", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeTokenRepository()
+			sender := newFakeEmailSender()
+			var wg sync.WaitGroup
+			c := New(repo, config.App{}, &wg, zap.NewNop())
+			c.emails = c.startEmailPool(sender, 1)
+			user := *repo.user
+			err := c.RequestEmailChange(context.Background(), &user, tt.email)
+			wg.Wait()
+			if tt.wantErr {
+				if !errors.Is(err, ErrFailedValidation) {
+					t.Fatalf("RequestEmailChange() error = %v, want ErrFailedValidation", err)
+				}
+				if repo.updated || len(sender.sent) != 0 {
+					t.Error("RequestEmailChange() requested the change, want it rejected")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RequestEmailChange() error = %v", err)
+			}
+			// The current email stays in use until the change is confirmed.
+			if repo.user.Email != "ada@example.com" || repo.user.PendingEmail != tt.email {
+				t.Errorf("RequestEmailChange() email = %q, pending email %q, want %q pending", repo.user.Email, repo.user.PendingEmail, tt.email)
+			}
+			data, ok := sender.sent[tt.email].(map[string]string)
+			if !ok {
+				t.Fatal("RequestEmailChange() sent no token to the new email")
+			}
+			if repo.tokens[data["emailChangeToken"]] != model.ScopeEmailChange {
+				t.Errorf("RequestEmailChange() sent token %q, want an email change token", data["emailChangeToken"])
+			}
+		})
+	}
+}
+
+func TestUpdateUserEmailNeedsConfirmation(t *testing.T) {
+	tests := []struct {
+		name    string
+		email   string
+		wantErr bool
+	}{
+		{"valid", "ada@analytical.example.com", false},
+		{"invalid email", "ada", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeTokenRepository()
+			repo.user.Locale = model.DefaultLocale
+			if err := repo.user.Password.SetWithCost("pa55word", 4); err != nil {
+				t.Fatal(err)
+			}
+			sender := newFakeEmailSender()
+			var wg sync.WaitGroup
+			c := New(repo, config.App{}, &wg, zap.NewNop())
+			c.emails = c.startEmailPool(sender, 1)
+			_, err := c.UpdateUser(context.Background(), 1, nil, &tt.email, nil, nil, &model.User{ID: 2, Name: "Grace Hopper", Role: "manager"})
+			wg.Wait()
+			if tt.wantErr {
+				if !errors.Is(err, ErrFailedValidation) {
+					t.Fatalf("UpdateUser() error = %v, want ErrFailedValidation", err)
+				}
+				if repo.updated || len(sender.sent) != 0 {
+					t.Error("UpdateUser() requested the change, want it rejected")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("UpdateUser() error = %v", err)
+			}
+			if repo.user.Email != "ada@example.com" || repo.user.PendingEmail != tt.email {
+				t.Errorf("UpdateUser() email = %q, pending email %q, want %q pending", repo.user.Email, repo.user.PendingEmail, tt.email)
+			}
+			data, ok := sender.sent[tt.email].(map[string]string)
+			if !ok || repo.tokens[data["emailChangeToken"]] != model.ScopeEmailChange {
+				t.Error("UpdateUser() sent no email change token to the new email")
+			}
+		})
+	}
+}
+
+func TestConfirmEmailChange(t *testing.T) {
+	repo := newFakeTokenRepository()
+	repo.user.PendingEmail = "ada@analytical.example.com"
+	var wg sync.WaitGroup
+	c := New(repo, config.App{}, &wg, zap.NewNop())
+	// Tokens of other scopes can't confirm the change.
+	_, err := c.ConfirmEmailChange(context.Background(), testResetToken)
+	if !errors.Is(err, ErrFailedValidation) || repo.updated {
+		t.Fatalf("ConfirmEmailChange(password reset token) error = %v, updated %v, want ErrFailedValidation", err, repo.updated)
+	}
+	token, err := repo.CreateToken(context.Background(), repo.user.ID, time.Hour, model.ScopeEmailChange)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user, err := c.ConfirmEmailChange(context.Background(), token.Plaintext)
+	if err != nil {
+		t.Fatalf("ConfirmEmailChange() error = %v", err)
+	}
+	if user.Email != "ada@analytical.example.com" || repo.user.Email != user.Email || repo.user.PendingEmail != "" {
+		t.Errorf("ConfirmEmailChange() email = %q, pending email %q, want the pending email confirmed", repo.user.Email, repo.user.PendingEmail)
+	}
+	if len(repo.deletedScopes) != 1 || repo.deletedScopes[0] != model.ScopeEmailChange {
+		t.Errorf("ConfirmEmailChange() deleted tokens with scopes %v, want [%q]", repo.deletedScopes, model.ScopeEmailChange)
+	}
+}
+
+func TestDeleteUserWithOpenIssues(t *testing.T) {
+	tests := []struct {
+		name        string
+		openIssues  int
+		force       bool
+		wantErr     error
+		wantDeleted bool
+	}{
+		{"no open issues", 0, false, nil, true},
+		{"blocked", 2, false, &OpenIssuesError{Count: 2}, false},
+		{"forced", 2, true, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeUserRepository{user: &model.User{ID: 2}, openIssues: tt.openIssues}
+			var wg sync.WaitGroup
+			c := New(repo, config.App{}, &wg, zap.NewNop())
+			err := c.DeleteUser(context.Background(), 2, tt.force, &model.User{ID: 1, Name: "Ada Lovelace", Role: "manager"}, validator.New())
+			var openIssuesErr *OpenIssuesError
+			switch {
+			case tt.wantErr == nil && err != nil:
+				t.Fatalf("DeleteUser() error = %v", err)
+			case tt.wantErr != nil && (!errors.As(err, &openIssuesErr) || openIssuesErr.Count != tt.openIssues):
+				t.Fatalf("DeleteUser() error = %v, want %v", err, tt.wantErr)
+			}
+			if repo.deleted != tt.wantDeleted {
+				t.Errorf("DeleteUser() deleted the user = %v, want %v", repo.deleted, tt.wantDeleted)
+			}
+		})
+	}
+}
+
+func TestActivateUserTwice(t *testing.T) {
+	repo := &fakeUserRepository{user: &model.User{ID: 1, Name: "Ada Lovelace", Email: "ada@example.com", Role: "member", Version: 1}}
+	var wg sync.WaitGroup
+	c := New(repo, config.App{}, &wg, zap.NewNop())
+	// Both activations read the user before either of them activates it, as concurrent
+	// requests with the same token would.
+	first, _ := repo.GetUserByID(context.Background(), 1)
+	second, _ := repo.GetUserByID(context.Background(), 1)
+	if err := c.ActivateUser(context.Background(), first, first.Name); err != nil {
+		t.Fatalf("ActivateUser() error = %v", err)
+	}
+	if !repo.user.Activated {
+		t.Fatal("ActivateUser() left the user deactivated")
+	}
+	if err := c.ActivateUser(context.Background(), second, second.Name); !errors.Is(err, ErrActivated) {
+		t.Errorf("ActivateUser() again error = %v, want ErrActivated", err)
+	}
+	if err := c.ActivateUser(context.Background(), repo.user, repo.user.Name); !errors.Is(err, ErrActivated) {
+		t.Errorf("ActivateUser() of an activated user error = %v, want ErrActivated", err)
+	}
+}
+
+// fakeImportRepository creates users whose emails aren't taken yet.
+type fakeImportRepository struct {
+	fakeRepository
+	emails  map[string]bool
+	created []*model.User
+	calls   int
+}
+
+func (r *fakeImportRepository) GetAllRoles(ctx context.Context) ([]*model.Role, error) {
+	return []*model.Role{{Name: "lead"}, {Name: "manager"}, {Name: "member"}}, nil
+}
+
+func (r *fakeImportRepository) CreateUsers(ctx context.Context, users []*model.User, ttl time.Duration, atomic bool) ([]*model.Token, []error, error) {
+	r.calls++
+	tokens := make([]*model.Token, len(users))
+	errs := make([]error, len(users))
+	var created []*model.User
+	for i, user := range users {
+		if r.emails[user.Email] {
+			if atomic {
+				return nil, nil, &repository.BatchError{Index: i, Err: repository.ErrDuplicateKey}
+			}
+			errs[i] = repository.ErrDuplicateKey
+			continue
+		}
+		user.ID = int64(100 + i)
+		tokens[i] = &model.Token{Plaintext: "TOKEN", UserID: user.ID, Scope: model.ScopeActivation}
+		created = append(created, user)
+	}
+	r.created = append(r.created, created...)
+	return tokens, errs, nil
+}
+
+func TestImportUsers(t *testing.T) {
+	ada := model.UserImport{Name: "Ada Lovelace", Email: "ada@example.com"}
+	grace := model.UserImport{Name: "Grace Hopper", Email: "grace@example.com", Role: "lead"}
+	badEmail := model.UserImport{Name: "Alan Turing", Email: "alan"}
+	badRole := model.UserImport{Name: "Edsger Dijkstra", Email: "edsger@example.com", Role: "admin"}
+	taken := model.UserImport{Name: "Barbara Liskov", Email: "barbara@example.com"}
+	tests := []struct {
+		name        string
+		rows        []model.UserImport
+		atomic      bool
+		wantErrs    []error
+		wantCreated []string
+	}{
+		{"best effort", []model.UserImport{ada, badEmail, badRole, taken, grace}, false, []error{nil, ErrFailedValidation, ErrFailedValidation, ErrFailedValidation, nil}, []string{"ada@example.com", "grace@example.com"}},
+		{"atomic with an invalid row", []model.UserImport{ada, badRole, grace}, true, []error{ErrRolledBack, ErrFailedValidation, ErrRolledBack}, nil},
+		{"atomic with a taken email", []model.UserImport{ada, taken}, true, []error{ErrRolledBack, ErrFailedValidation}, nil},
+		{"atomic", []model.UserImport{ada, grace}, true, []error{nil, nil}, []string{"ada@example.com", "grace@example.com"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeImportRepository{emails: map[string]bool{"barbara@example.com": true}}
+			var wg sync.WaitGroup
+			c := New(repo, config.App{BcryptCost: 4}, &wg, zap.NewNop())
+			sender := newFakeEmailSender()
+			c.emails = c.startEmailPool(sender, 1)
+			manager := &model.User{ID: 1, Name: "Margaret Hamilton", Role: "manager"}
+			users, errs, err := c.ImportUsers(context.Background(), tt.rows, tt.atomic, manager, validator.New())
+			if err != nil {
+				t.Fatalf("ImportUsers() error = %v", err)
+			}
+			wg.Wait()
+			if len(errs) != len(tt.wantErrs) {
+				t.Fatalf("ImportUsers() returned %d results, want %d", len(errs), len(tt.wantErrs))
+			}
+			for i, want := range tt.wantErrs {
+				if !errors.Is(errs[i], want) {
+					t.Errorf("ImportUsers() row %d error = %v, want %v", i+1, errs[i], want)
+				}
+				if (users[i] != nil) != (want == nil) {
+					t.Errorf("ImportUsers() row %d user = %v, want a user only for created rows", i+1, users[i])
+				}
+			}
+			if len(repo.created) != len(tt.wantCreated) {
+				t.Fatalf("CreateUsers() created %d users, want %d", len(repo.created), len(tt.wantCreated))
+			}
+			for i, email := range tt.wantCreated {
+				user := repo.created[i]
+				if user.Email != email || user.Activated || user.CreatedBy != manager.Name {
+					t.Errorf("CreateUsers() user %d = %+v, want an inactive %s created by %s", i, user, email, manager.Name)
+				}
+				if matches, _ := user.Password.Matches(""); matches || user.Password.Hash == nil {
+					t.Errorf("CreateUsers() user %d has password hash %q, want an unusable password", i, user.Password.Hash)
+				}
+				if _, ok := sender.sent[email]; !ok {
+					t.Errorf("ImportUsers() sent no activation email to %s", email)
+				}
+			}
+			if len(sender.sent) != len(tt.wantCreated) {
+				t.Errorf("ImportUsers() sent %d emails, want %d", len(sender.sent), len(tt.wantCreated))
+			}
+		})
+	}
+}
+
+func TestImportUsersQueuesEveryInvite(t *testing.T) {
+	rows := make([]model.UserImport, maxImportUsers)
+	for i := range rows {
+		rows[i] = model.UserImport{Name: fmt.Sprintf("User %d", i), Email: fmt.Sprintf("user%d@example.com", i)}
+	}
+	repo := &fakeImportRepository{}
+	var wg sync.WaitGroup
+	c := New(repo, config.App{}, &wg, zap.NewNop())
+	// Sends block until released, so that the invites outnumber the room in the queue.
+	sender := &fakeEmailSender{started: make(chan struct{}, maxImportUsers), release: make(chan struct{})}
+	c.emails = c.startEmailPool(sender, 1)
+	manager := &model.User{ID: 1, Name: "Margaret Hamilton", Role: "manager"}
+	_, errs, err := c.ImportUsers(context.Background(), rows, true, manager, validator.New())
+	if err != nil {
+		t.Fatalf("ImportUsers() error = %v", err)
+	}
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("ImportUsers() row %d error = %v", i+1, err)
+		}
+	}
+	close(sender.release)
+	wg.Wait()
+	if len(sender.sent) != maxImportUsers {
+		t.Errorf("ImportUsers() sent %d invites, want %d", len(sender.sent), maxImportUsers)
+	}
+	if failed := c.emails.failed.Load(); failed != 0 {
+		t.Errorf("ImportUsers() dropped %d invites, want none", failed)
+	}
+}
+
+func TestImportUsersRejected(t *testing.T) {
+	rows := make([]model.UserImport, maxImportUsers+1)
+	tests := []struct {
+		name    string
+		rows    []model.UserImport
+		user    *model.User
+		wantErr error
+	}{
+		{"not a manager", rows[:1], &model.User{Role: "lead"}, ErrNotPermitted},
+		{"no rows", nil, &model.User{Role: "manager"}, ErrFailedValidation},
+		{"too many rows", rows, &model.User{Role: "manager"}, ErrFailedValidation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeImportRepository{}
+			var wg sync.WaitGroup
+			c := New(repo, config.App{}, &wg, zap.NewNop())
+			_, _, err := c.ImportUsers(context.Background(), tt.rows, true, tt.user, validator.New())
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ImportUsers() error = %v, want %v", err, tt.wantErr)
+			}
+			if repo.calls != 0 {
+				t.Error("ImportUsers() created users, want the import rejected")
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package issuetracker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/emzola/issuetracker/internal/repository"
+	"github.com/emzola/issuetracker/pkg/model"
+	"github.com/emzola/issuetracker/pkg/validator"
+	"go.uber.org/zap"
+)
+
+type watcherRepository interface {
+	SubscribeToIssue(ctx context.Context, issueID, userID int64) error
+	UnsubscribeFromIssue(ctx context.Context, issueID, userID int64) error
+	GetIssueWatchers(ctx context.Context, issueID int64) ([]*model.Watcher, error)
+}
+
+// SubscribeToIssue makes the user a watcher of the issue, so that they are notified
+// when its status, priority or assignee changes.
+func (c *Controller) SubscribeToIssue(ctx context.Context, issueID int64, user *model.User) error {
+	_, err := c.GetIssue(ctx, issueID, user)
+	if err != nil {
+		return err
+	}
+	return c.repo.SubscribeToIssue(ctx, issueID, user.ID)
+}
+
+func (c *Controller) UnsubscribeFromIssue(ctx context.Context, issueID int64, user *model.User) error {
+	_, err := c.GetIssue(ctx, issueID, user)
+	if err != nil {
+		return err
+	}
+	err = c.repo.UnsubscribeFromIssue(ctx, issueID, user.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return ErrNotFound
+		default:
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Controller) GetIssueWatchers(ctx context.Context, issueID int64, user *model.User) ([]*model.Watcher, error) {
+	_, err := c.GetIssue(ctx, issueID, user)
+	if err != nil {
+		return nil, err
+	}
+	return c.repo.GetIssueWatchers(ctx, issueID)
+}
+
+// watchedChanges describes the changes to the fields watchers are notified about. It
+// returns an empty string if none of them changed.
+func watchedChanges(before, after *model.Issue, assignee *model.User) string {
+	var changes []string
+	if before.Status != after.Status {
+		changes = append(changes, fmt.Sprintf("status changed from %q to %q", before.Status, after.Status))
+	}
+	if before.Priority != after.Priority {
+		changes = append(changes, fmt.Sprintf("priority changed from %q to %q", before.Priority, after.Priority))
+	}
+	if assignee != nil && (before.AssignedTo == nil || *before.AssignedTo != assignee.ID) {
+		changes = append(changes, fmt.Sprintf("assigned to %s", assignee.Name))
+	}
+	return strings.Join(changes, "; ")
+}
+
+// notifyWatchers emails the issue's watchers about changes made by user, if email is
+// enabled on the issue's project. The user who made the changes is not notified.
+// Failures are logged rather than returned, since the issue has already been updated.
+func (c *Controller) notifyWatchers(ctx context.Context, issue *model.Issue, changes string, user *model.User) {
+	project, err := c.repo.GetProject(ctx, issue.ProjectID)
+	if err != nil {
+		c.Logger.Info("failed to load project notification channels", zap.Error(err))
+		return
+	}
+	if !validator.In("email", project.NotificationChannels...) {
+		return
+	}
+	watchers, err := c.repo.GetIssueWatchers(ctx, issue.ID)
+	if err != nil {
+		c.Logger.Info("failed to load issue watchers", zap.Error(err))
+		return
+	}
+	for _, watcher := range watchers {
+		if watcher.UserID == user.ID {
+			continue
+		}
+		data := map[string]string{
+			"name":       watcher.Name,
+			"issueID":    strconv.Itoa(int(issue.ID)),
+			"issueTitle": issue.Title,
+			"changes":    changes,
+			"modifiedBy": user.Name,
+		}
+		c.SendEmail(data, watcher.Email, watcher.Locale, "issue_updated.tmpl")
+	}
+}
@@ -0,0 +1,81 @@
+package issuetracker
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/emzola/issuetracker/config"
+	"github.com/emzola/issuetracker/pkg/model"
+	"go.uber.org/zap"
+)
+
+// fakeWatcherRepository serves project 1, with email notifications enabled, and counts
+// the times it is loaded.
+type fakeWatcherRepository struct {
+	fakeRepository
+	projectsLoaded int
+}
+
+func (r *fakeWatcherRepository) GetProject(ctx context.Context, id int64) (*model.Project, error) {
+	r.projectsLoaded++
+	return r.fakeRepository.GetProject(ctx, id)
+}
+
+func TestWatchedChanges(t *testing.T) {
+	assignee := int64(3)
+	before := &model.Issue{Status: "open", Priority: "low", AssignedTo: &assignee, Title: "Login fails"}
+	tests := []struct {
+		name     string
+		after    model.Issue
+		assignee *model.User
+		want     string
+	}{
+		{"unwatched field", model.Issue{Status: "open", Priority: "low", AssignedTo: &assignee, Title: "Login always fails"}, nil, ""},
+		{"status", model.Issue{Status: "resolved", Priority: "low", AssignedTo: &assignee}, nil, `status changed from "open" to "resolved"`},
+		{"priority and assignee", model.Issue{Status: "open", Priority: "high"}, &model.User{ID: 4, Name: "Grace Hopper"}, `priority changed from "low" to "high"; assigned to Grace Hopper`},
+		{"same assignee", model.Issue{Status: "open", Priority: "low", AssignedTo: &assignee}, &model.User{ID: 3, Name: "Ada Lovelace"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := watchedChanges(before, &tt.after, tt.assignee); got != tt.want {
+				t.Errorf("watchedChanges() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNotifyWatchers(t *testing.T) {
+	repo := &fakeWatcherRepository{fakeRepository: fakeRepository{
+		projects: fakeProjects(1, "email"),
+		watchers: []*model.Watcher{
+			{IssueID: 1, UserID: 1, Name: "Ada Lovelace", Email: "ada@example.com"},
+			{IssueID: 1, UserID: 2, Name: "Grace Hopper", Email: "grace@example.com"},
+			{IssueID: 1, UserID: 3, Name: "Alan Turing", Email: "alan@example.com"},
+		},
+	}}
+	sender := newFakeEmailSender()
+	var wg sync.WaitGroup
+	c := New(repo, config.App{}, &wg, zap.NewNop())
+	c.emails = c.startEmailPool(sender, 1)
+	c.notifyWatchers(context.Background(), &model.Issue{ID: 1, ProjectID: 1, Title: "Login fails"}, "priority changed", &model.User{ID: 1, Name: "Ada Lovelace"})
+	wg.Wait()
+	if len(sender.sent) != 2 || sender.sent["ada@example.com"] != nil {
+		t.Errorf("notifyWatchers() emailed %v, want every watcher but the user who made the change", sender.sent)
+	}
+	if repo.projectsLoaded != 1 {
+		t.Errorf("notifyWatchers() loaded the project %d times, want once", repo.projectsLoaded)
+	}
+}
+
+func TestWatcherJSONLeavesOutEmail(t *testing.T) {
+	js, err := json.Marshal(model.Watcher{IssueID: 1, UserID: 2, Name: "Grace Hopper", Email: "grace@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(js), "grace@example.com") {
+		t.Errorf("json.Marshal(Watcher) = %s, want the email left out", js)
+	}
+}
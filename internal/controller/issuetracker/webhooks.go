@@ -0,0 +1,180 @@
+package issuetracker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/emzola/issuetracker/internal/repository"
+	"github.com/emzola/issuetracker/pkg/model"
+	"github.com/emzola/issuetracker/pkg/validator"
+	"go.uber.org/zap"
+)
+
+type webhookRepository interface {
+	CreateWebhook(ctx context.Context, webhook *model.Webhook) error
+	GetWebhook(ctx context.Context, id int64) (*model.Webhook, error)
+	GetProjectWebhooks(ctx context.Context, projectID int64) ([]*model.Webhook, error)
+	UpdateWebhook(ctx context.Context, webhook *model.Webhook) error
+	DeleteWebhook(ctx context.Context, id int64) error
+}
+
+// webhookSender delivers signed webhook payloads.
+type webhookSender interface {
+	Send(url, secret string, body []byte) error
+}
+
+// CreateWebhook adds a webhook to a project. New webhooks are active.
+func (c *Controller) CreateWebhook(ctx context.Context, projectID int64, url, secret string, events []string, user *model.User) (*model.Webhook, error) {
+	project, err := c.getPlannableProject(ctx, projectID, user)
+	if err != nil {
+		return nil, err
+	}
+	hook := &model.Webhook{
+		ProjectID:  project.ID,
+		URL:        url,
+		Secret:     secret,
+		Events:     events,
+		Active:     true,
+		CreatedBy:  user.Name,
+		ModifiedBy: user.Name,
+	}
+	v := validator.New()
+	if hook.Validate(v); !v.Valid() {
+		return nil, failedValidationErr(v.Errors)
+	}
+	err = c.repo.CreateWebhook(ctx, hook)
+	if err != nil {
+		return nil, err
+	}
+	return hook, nil
+}
+
+// GetWebhook returns one of a project's webhooks. Webhooks of other projects aren't
+// found.
+func (c *Controller) GetWebhook(ctx context.Context, projectID, webhookID int64, user *model.User) (*model.Webhook, error) {
+	project, err := c.getPlannableProject(ctx, projectID, user)
+	if err != nil {
+		return nil, err
+	}
+	hook, err := c.repo.GetWebhook(ctx, webhookID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+	if hook.ProjectID != project.ID {
+		return nil, ErrNotFound
+	}
+	return hook, nil
+}
+
+func (c *Controller) GetProjectWebhooks(ctx context.Context, projectID int64, user *model.User) ([]*model.Webhook, error) {
+	project, err := c.getPlannableProject(ctx, projectID, user)
+	if err != nil {
+		return nil, err
+	}
+	return c.repo.GetProjectWebhooks(ctx, project.ID)
+}
+
+func (c *Controller) UpdateWebhook(ctx context.Context, projectID, webhookID int64, url, secret *string, events *[]string, active *bool, user *model.User) (*model.Webhook, error) {
+	hook, err := c.GetWebhook(ctx, projectID, webhookID, user)
+	if err != nil {
+		return nil, err
+	}
+	if url != nil {
+		hook.URL = *url
+	}
+	if secret != nil {
+		hook.Secret = *secret
+	}
+	if events != nil {
+		hook.Events = *events
+	}
+	if active != nil {
+		hook.Active = *active
+	}
+	hook.ModifiedBy = user.Name
+	v := validator.New()
+	if hook.Validate(v); !v.Valid() {
+		return nil, failedValidationErr(v.Errors)
+	}
+	err = c.repo.UpdateWebhook(ctx, hook)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrEditConflict):
+			return nil, ErrEditConflict
+		default:
+			return nil, err
+		}
+	}
+	return hook, nil
+}
+
+func (c *Controller) DeleteWebhook(ctx context.Context, projectID, webhookID int64, user *model.User) error {
+	_, err := c.GetWebhook(ctx, projectID, webhookID, user)
+	if err != nil {
+		return err
+	}
+	err = c.repo.DeleteWebhook(ctx, webhookID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return ErrNotFound
+		default:
+			return err
+		}
+	}
+	return nil
+}
+
+// dispatchWebhooks sends the issue event to the project's active webhooks that are
+// subscribed to it, if the project delivers notifications through webhooks. Deliveries
+// happen in background goroutines, so that they don't hold up the request. Failures are
+// logged rather than returned, since the event has already happened.
+func (c *Controller) dispatchWebhooks(ctx context.Context, event string, issue *model.Issue) {
+	project, err := c.repo.GetProject(ctx, issue.ProjectID)
+	if err != nil {
+		c.Logger.Error("failed to load project notification channels", zap.Error(err))
+		return
+	}
+	if !validator.In("webhook", project.NotificationChannels...) {
+		return
+	}
+	hooks, err := c.repo.GetProjectWebhooks(ctx, issue.ProjectID)
+	if err != nil {
+		c.Logger.Error("failed to load project webhooks", zap.Error(err))
+		return
+	}
+	for _, hook := range hooks {
+		if !hook.Subscribed(event) {
+			continue
+		}
+		// The payload is encoded before the goroutine starts, as the issue may be
+		// changed once this returns.
+		body, err := json.Marshal(model.WebhookPayload{Event: event, WebhookID: hook.ID, Issue: issue, SentOn: time.Now().UTC()})
+		if err != nil {
+			c.Logger.Error("failed to encode webhook payload", zap.Int64("webhook_id", hook.ID), zap.Error(err))
+			continue
+		}
+		hookID, url, secret := hook.ID, hook.URL, hook.Secret
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+			defer func() {
+				if err := recover(); err != nil {
+					c.Logger.Error(fmt.Sprintf("%s", err))
+				}
+			}()
+			err := c.webhooks.Send(url, secret, body)
+			if err != nil {
+				c.Logger.Error("failed to deliver webhook", zap.String("event", event), zap.Int64("webhook_id", hookID), zap.Error(err))
+			}
+		}()
+	}
+}
@@ -0,0 +1,202 @@
+package issuetracker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/emzola/issuetracker/config"
+	"github.com/emzola/issuetracker/pkg/model"
+	"github.com/emzola/issuetracker/pkg/webhook"
+	"go.uber.org/zap"
+)
+
+// testWebhookSender delivers webhooks signed like webhook.Sender's, but to any address,
+// so that they reach test servers listening on loopback.
+type testWebhookSender struct{}
+
+func (testWebhookSender) Send(url, secret string, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set(webhook.SignatureHeader, webhook.Sign(secret, body))
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	return res.Body.Close()
+}
+
+func TestUpdateIssueDispatchesWebhooks(t *testing.T) {
+	tests := []struct {
+		name      string
+		from, to  string
+		events    []string
+		channels  []string
+		wantEvent string
+	}{
+		{"updated", "open", "in progress", []string{"issue.updated"}, model.NotificationChannels, "issue.updated"},
+		{"closed", "resolved", "closed", []string{"issue.closed"}, model.NotificationChannels, "issue.closed"},
+		{"not subscribed", "open", "in progress", []string{"issue.closed"}, model.NotificationChannels, ""},
+		{"webhook channel disabled", "open", "in progress", []string{"issue.updated"}, []string{"email"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var events []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				if got, want := r.Header.Get(webhook.SignatureHeader), webhook.Sign("topsecretsecret1", body); got != want {
+					t.Errorf("%s = %q, want %q", webhook.SignatureHeader, got, want)
+				}
+				var payload model.WebhookPayload
+				if err := json.Unmarshal(body, &payload); err != nil {
+					t.Errorf("webhook payload error = %v", err)
+				}
+				mu.Lock()
+				events = append(events, payload.Event)
+				mu.Unlock()
+			}))
+			defer server.Close()
+			repo := &fakeRepository{
+				issues: map[int64]*model.Issue{1: {
+					ID:                   1,
+					Title:                "Login fails",
+					Description:          "Login fails with valid credentials",
+					ReporterID:           2,
+					ReportedDate:         time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+					ProjectID:            1,
+					Status:               tt.from,
+					Priority:             "low",
+					Type:                 "bug",
+					TargetResolutionDate: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+				}},
+				webhooks: []*model.Webhook{
+					{ID: 1, ProjectID: 1, URL: server.URL, Secret: "topsecretsecret1", Events: tt.events, Active: true},
+					{ID: 2, ProjectID: 1, URL: server.URL, Secret: "topsecretsecret1", Events: model.WebhookEvents, Active: false},
+				},
+				projects: fakeProjects(1, tt.channels...),
+			}
+			var wg sync.WaitGroup
+			c := New(repo, config.App{}, &wg, zap.NewNop())
+			c.webhooks = testWebhookSender{}
+			status := tt.to
+			_, err := c.UpdateIssue(context.Background(), 1, 0, nil, nil, nil, &status, nil, nil, nil, nil, nil, nil, nil, nil, &model.User{ID: 1, Name: "Ada Lovelace", Role: "manager"})
+			if err != nil {
+				t.Fatalf("UpdateIssue() error = %v", err)
+			}
+			wg.Wait()
+			var want []string
+			if tt.wantEvent != "" {
+				want = []string{tt.wantEvent}
+			}
+			if len(events) != len(want) || (len(want) == 1 && events[0] != want[0]) {
+				t.Errorf("UpdateIssue() delivered events %v, want %v", events, want)
+			}
+		})
+	}
+}
+
+// fakeWebhookSender records the events of the webhooks sent through it.
+type fakeWebhookSender struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (s *fakeWebhookSender) Send(url, secret string, body []byte) error {
+	var payload model.WebhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, payload.Event)
+	return nil
+}
+
+// fakeAutoCloseRepository reports every resolved issue it holds as due to be closed.
+type fakeAutoCloseRepository struct {
+	fakeRepository
+}
+
+func (r *fakeAutoCloseRepository) GetIssuesDueForAutoClose(ctx context.Context) ([]*model.IssueAutoClose, error) {
+	var due []*model.IssueAutoClose
+	for _, issue := range r.issues {
+		if issue.Status == "resolved" {
+			due = append(due, &model.IssueAutoClose{IssueID: issue.ID, Title: issue.Title, Status: issue.Status, ClosedStatus: "closed"})
+		}
+	}
+	return due, nil
+}
+
+func (r *fakeAutoCloseRepository) AutoCloseIssue(ctx context.Context, issueID int64, status, closedStatus string, activity []*model.IssueActivity) error {
+	r.issues[issueID].Status = closedStatus
+	return nil
+}
+
+func TestCloseResolvedIssuesDispatchesWebhooks(t *testing.T) {
+	repo := &fakeAutoCloseRepository{fakeRepository{
+		issues:   map[int64]*model.Issue{1: {ID: 1, Title: "Login fails", ProjectID: 1, Status: "resolved"}},
+		projects: fakeProjects(1, model.NotificationChannels...),
+		webhooks: []*model.Webhook{{ID: 1, ProjectID: 1, URL: "https://hooks.example.com", Secret: "topsecretsecret1", Events: model.WebhookEvents, Active: true}},
+	}}
+	sender := &fakeWebhookSender{}
+	var wg sync.WaitGroup
+	c := New(repo, config.App{}, &wg, zap.NewNop())
+	c.webhooks = sender
+	err := c.CloseResolvedIssues(context.Background())
+	if err != nil {
+		t.Fatalf("CloseResolvedIssues() error = %v", err)
+	}
+	wg.Wait()
+	if want := []string{"issue.closed"}; !reflect.DeepEqual(sender.events, want) {
+		t.Errorf("CloseResolvedIssues() delivered events %v, want %v", sender.events, want)
+	}
+}
+
+// fakePublishRepository fails to load any user.
+type fakePublishRepository struct {
+	fakeRepository
+}
+
+func (r *fakePublishRepository) GetUserByID(ctx context.Context, id int64) (*model.User, error) {
+	return nil, errors.New("connection reset")
+}
+
+func TestPublishIssueAssigneeLookupFails(t *testing.T) {
+	assignee := int64(2)
+	reported := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	repo := &fakePublishRepository{fakeRepository{
+		issues: map[int64]*model.Issue{1: {
+			ID: 1, Title: "Login fails", Description: "The login form never submits.", ReporterID: 1, ProjectID: 1, AssignedTo: &assignee,
+			Status: "open", Priority: "high", Type: "bug", ReportedDate: reported, TargetResolutionDate: reported.AddDate(0, 0, 7), Draft: true,
+		}},
+		projects: fakeProjects(1, model.NotificationChannels...),
+		webhooks: []*model.Webhook{{ID: 1, ProjectID: 1, URL: "https://hooks.example.com", Secret: "topsecretsecret1", Events: model.WebhookEvents, Active: true}},
+	}}
+	sender := &fakeWebhookSender{}
+	var wg sync.WaitGroup
+	c := New(repo, config.App{}, &wg, zap.NewNop())
+	c.webhooks = sender
+	// The assignee can't be loaded, which only costs the notification.
+	issue, err := c.PublishIssue(context.Background(), 1, &model.User{ID: 1, Name: "Ada Lovelace", Role: "member"})
+	if err != nil {
+		t.Fatalf("PublishIssue() error = %v", err)
+	}
+	wg.Wait()
+	if issue.Draft || len(repo.saved) != 1 {
+		t.Errorf("PublishIssue() issue = %+v, want the issue published", issue)
+	}
+	if want := []string{"issue.created"}; !reflect.DeepEqual(sender.events, want) {
+		t.Errorf("PublishIssue() delivered events %v, want %v", sender.events, want)
+	}
+}
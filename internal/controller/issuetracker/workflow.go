@@ -0,0 +1,81 @@
+package issuetracker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/emzola/issuetracker/internal/repository"
+	"github.com/emzola/issuetracker/pkg/model"
+	"github.com/emzola/issuetracker/pkg/validator"
+)
+
+type workflowRepository interface {
+	GetProjectWorkflow(ctx context.Context, projectID int64) ([]model.WorkflowState, error)
+	SetProjectWorkflow(ctx context.Context, projectID int64, states []model.WorkflowState) error
+	GetProjectIssueStatuses(ctx context.Context, projectID int64) ([]string, error)
+}
+
+// projectWorkflow returns the project's workflow, or the default workflow if the
+// project hasn't defined one.
+func (c *Controller) projectWorkflow(ctx context.Context, projectID int64) (*model.Workflow, error) {
+	states, err := c.repo.GetProjectWorkflow(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	if len(states) == 0 {
+		return model.DefaultWorkflow(projectID), nil
+	}
+	return &model.Workflow{ProjectID: projectID, States: states}, nil
+}
+
+func (c *Controller) GetProjectWorkflow(ctx context.Context, projectID int64) (*model.Workflow, error) {
+	_, err := c.repo.GetProject(ctx, projectID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+	return c.projectWorkflow(ctx, projectID)
+}
+
+// SetProjectWorkflow replaces the project's workflow. Only managers can change a
+// workflow, and every status used by the project's existing issues must remain in it.
+func (c *Controller) SetProjectWorkflow(ctx context.Context, projectID int64, states []model.WorkflowState, user *model.User) (*model.Workflow, error) {
+	if user.Role != "manager" {
+		return nil, ErrNotPermitted
+	}
+	_, err := c.repo.GetProject(ctx, projectID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+	workflow := &model.Workflow{ProjectID: projectID, States: states}
+	v := validator.New()
+	if workflow.Validate(v); !v.Valid() {
+		return nil, failedValidationErr(v.Errors)
+	}
+	statuses, err := c.repo.GetProjectIssueStatuses(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	for _, status := range statuses {
+		_, ok := workflow.State(status)
+		v.Check(ok, "states", fmt.Sprintf("must include status %q used by existing issues", status))
+	}
+	if !v.Valid() {
+		return nil, failedValidationErr(v.Errors)
+	}
+	err = c.repo.SetProjectWorkflow(ctx, projectID, workflow.States)
+	if err != nil {
+		return nil, err
+	}
+	return workflow, nil
+}
@@ -0,0 +1,184 @@
+package issuetracker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/emzola/issuetracker/config"
+	"github.com/emzola/issuetracker/pkg/model"
+	"go.uber.org/zap"
+)
+
+func TestUpdateIssueStatusFollowsWorkflow(t *testing.T) {
+	states := []model.WorkflowState{
+		{Name: "triage", Category: "open"},
+		{Name: "in review", Category: "open"},
+		{Name: "done", Category: "closed"},
+		{Name: "won't fix", Category: "closed"},
+	}
+	tests := []struct {
+		name    string
+		from    string
+		to      string
+		wantErr bool
+	}{
+		{"open to open", "triage", "in review", false},
+		{"open to closed", "in review", "won't fix", false},
+		{"reopen to first state", "done", "triage", false},
+		{"closed to later open state", "done", "in review", true},
+		{"closed to closed", "done", "won't fix", true},
+		{"default workflow status", "triage", "in progress", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assignee := int64(3)
+			repo := &fakeRepository{
+				issues: map[int64]*model.Issue{1: {
+					ID:                   1,
+					Title:                "Login fails",
+					Description:          "Login fails with valid credentials",
+					ReporterID:           2,
+					ReportedDate:         time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+					ProjectID:            1,
+					AssignedTo:           &assignee,
+					Status:               tt.from,
+					Priority:             "low",
+					Type:                 "bug",
+					TargetResolutionDate: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+				}},
+				projects: fakeProjects(1),
+				states:   states,
+			}
+			var wg sync.WaitGroup
+			c := New(repo, config.App{}, &wg, zap.NewNop())
+			to := tt.to
+			issue, err := c.UpdateIssue(context.Background(), 1, 0, nil, nil, nil, &to, nil, nil, nil, nil, nil, nil, nil, nil, &model.User{ID: 1, Name: "Ada Lovelace", Role: "manager"})
+			if tt.wantErr {
+				if !errors.Is(err, ErrFailedValidation) {
+					t.Fatalf("UpdateIssue() error = %v, want ErrFailedValidation", err)
+				}
+				if len(repo.saved) > 0 {
+					t.Error("UpdateIssue() updated the issue, want the update rejected")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("UpdateIssue() error = %v", err)
+			}
+			if issue.Status != tt.to {
+				t.Errorf("UpdateIssue() status = %q, want %q", issue.Status, tt.to)
+			}
+		})
+	}
+}
+
+func TestUpdateIssueStatusDefaultWorkflow(t *testing.T) {
+	tests := []struct {
+		from    string
+		to      string
+		wantErr bool
+	}{
+		{"open", "in progress", false},
+		{"open", "resolved", true},
+		{"open", "closed", true},
+		{"in progress", "open", false},
+		{"in progress", "resolved", false},
+		{"in progress", "closed", true},
+		{"resolved", "open", false},
+		{"resolved", "in progress", false},
+		{"resolved", "closed", false},
+		{"closed", "open", false},
+		{"closed", "in progress", true},
+		{"closed", "resolved", true},
+		{"open", "Opened", true},
+		{"open", "In Progress", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.from+" to "+tt.to, func(t *testing.T) {
+			repo := &fakeRepository{
+				issues: map[int64]*model.Issue{1: {
+					ID:                   1,
+					Title:                "Login fails",
+					Description:          "Login fails with valid credentials",
+					ReporterID:           2,
+					ReportedDate:         time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+					ProjectID:            1,
+					Status:               tt.from,
+					Priority:             "low",
+					Type:                 "bug",
+					TargetResolutionDate: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+				}},
+				projects: fakeProjects(1),
+				states:   model.DefaultWorkflow(1).States,
+			}
+			var wg sync.WaitGroup
+			c := New(repo, config.App{}, &wg, zap.NewNop())
+			to := tt.to
+			_, err := c.UpdateIssue(context.Background(), 1, 0, nil, nil, nil, &to, nil, nil, nil, nil, nil, nil, nil, nil, &model.User{ID: 1, Name: "Ada Lovelace", Role: "manager"})
+			if tt.wantErr {
+				if !errors.Is(err, ErrFailedValidation) {
+					t.Fatalf("UpdateIssue() error = %v, want ErrFailedValidation", err)
+				}
+				if len(repo.saved) > 0 {
+					t.Error("UpdateIssue() updated the issue, want the update rejected")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("UpdateIssue() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestUpdateIssueActualResolutionDate(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    string
+		wantErr bool
+	}{
+		{"resolved", "resolved", false},
+		{"already closed", "closed", false},
+		{"open", "open", true},
+		{"in progress", "in progress", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeRepository{
+				issues: map[int64]*model.Issue{1: {
+					ID:                   1,
+					Title:                "Login fails",
+					Description:          "Login fails with valid credentials",
+					ReporterID:           2,
+					ReportedDate:         time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+					ProjectID:            1,
+					Status:               tt.from,
+					Priority:             "low",
+					Type:                 "bug",
+					TargetResolutionDate: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+				}},
+				projects: fakeProjects(1),
+				states:   model.DefaultWorkflow(1).States,
+			}
+			var wg sync.WaitGroup
+			c := New(repo, config.App{}, &wg, zap.NewNop())
+			resolved := "2024-01-20"
+			issue, err := c.UpdateIssue(context.Background(), 1, 0, nil, nil, nil, nil, nil, nil, nil, nil, &resolved, nil, nil, nil, &model.User{ID: 1, Name: "Ada Lovelace", Role: "manager"})
+			if tt.wantErr {
+				if !errors.Is(err, ErrFailedValidation) {
+					t.Fatalf("UpdateIssue() error = %v, want ErrFailedValidation", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("UpdateIssue() error = %v", err)
+			}
+			if issue.Status != "closed" {
+				t.Errorf("UpdateIssue() status = %q, want closed", issue.Status)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package issuetracker
+
+import (
+	"context"
+	"errors"
+
+	"github.com/emzola/issuetracker/internal/repository"
+	"github.com/emzola/issuetracker/pkg/model"
+	"github.com/emzola/issuetracker/pkg/validator"
+)
+
+type worklogRepository interface {
+	CreateWorklog(ctx context.Context, worklog *model.Worklog) (float64, error)
+	GetIssueWorklogs(ctx context.Context, issueID int64) ([]*model.Worklog, error)
+}
+
+// CreateWorklog logs time against an issue, returning the worklog and the issue's
+// updated effort. Like issue updates, members can only log time against issues assigned
+// to or reported by them.
+func (c *Controller) CreateWorklog(ctx context.Context, issueID int64, hours float64, note string, user *model.User) (*model.Worklog, *model.IssueEffort, error) {
+	issue, err := c.getEditableIssue(ctx, issueID, user)
+	if err != nil {
+		return nil, nil, err
+	}
+	worklog := &model.Worklog{
+		IssueID:  issueID,
+		Hours:    hours,
+		Note:     note,
+		LoggedBy: user.ID,
+	}
+	v := validator.New()
+	if worklog.Validate(v); !v.Valid() {
+		return nil, nil, failedValidationErr(v.Errors)
+	}
+	loggedHours, err := c.repo.CreateWorklog(ctx, worklog)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return nil, nil, ErrNotFound
+		default:
+			return nil, nil, err
+		}
+	}
+	effort := &model.IssueEffort{EstimatedHours: issue.EstimatedHours, LoggedHours: loggedHours}
+	return worklog, effort, nil
+}
+
+// GetIssueWorklogs returns the time logged against an issue, along with the issue's
+// estimated and total logged hours.
+func (c *Controller) GetIssueWorklogs(ctx context.Context, issueID int64, user *model.User) ([]*model.Worklog, *model.IssueEffort, error) {
+	issue, err := c.GetIssue(ctx, issueID, user)
+	if err != nil {
+		return nil, nil, err
+	}
+	worklogs, err := c.repo.GetIssueWorklogs(ctx, issueID)
+	if err != nil {
+		return nil, nil, err
+	}
+	effort := &model.IssueEffort{EstimatedHours: issue.EstimatedHours, LoggedHours: issue.LoggedHours}
+	return worklogs, effort, nil
+}
@@ -0,0 +1,71 @@
+package issuetracker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/emzola/issuetracker/config"
+	"github.com/emzola/issuetracker/pkg/model"
+	"go.uber.org/zap"
+)
+
+// fakeWorklogRepository adds the time logged against its issues to their logged hours.
+type fakeWorklogRepository struct {
+	fakeRepository
+	worklogs []*model.Worklog
+}
+
+func (r *fakeWorklogRepository) CreateWorklog(ctx context.Context, worklog *model.Worklog) (float64, error) {
+	r.worklogs = append(r.worklogs, worklog)
+	issue := r.issues[worklog.IssueID]
+	issue.LoggedHours += worklog.Hours
+	return issue.LoggedHours, nil
+}
+
+func TestCreateWorklog(t *testing.T) {
+	reporter := &model.User{ID: 2, Name: "Ada Lovelace", Role: "member"}
+	tests := []struct {
+		name    string
+		issueID int64
+		hours   float64
+		user    *model.User
+		wantErr error
+	}{
+		{"valid", 1, 1.5, reporter, nil},
+		{"zero hours", 1, 0, reporter, ErrFailedValidation},
+		{"negative hours", 1, -2, reporter, ErrFailedValidation},
+		{"missing issue", 3, 1.5, reporter, ErrNotFound},
+		{"member not involved", 1, 1.5, &model.User{ID: 3, Name: "Alan Turing", Role: "member"}, ErrNotPermitted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			estimatedHours := 8.0
+			repo := &fakeWorklogRepository{fakeRepository: fakeRepository{issues: map[int64]*model.Issue{
+				1: {ID: 1, Title: "Login fails", Status: "open", ReporterID: 2, EstimatedHours: &estimatedHours, LoggedHours: 2},
+			}}}
+			var wg sync.WaitGroup
+			c := New(repo, config.App{}, &wg, zap.NewNop())
+			worklog, effort, err := c.CreateWorklog(context.Background(), tt.issueID, tt.hours, "", tt.user)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("CreateWorklog() error = %v, want %v", err, tt.wantErr)
+				}
+				if len(repo.worklogs) != 0 {
+					t.Error("CreateWorklog() logged time, want the worklog rejected")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreateWorklog() error = %v", err)
+			}
+			if worklog.LoggedBy != tt.user.ID {
+				t.Errorf("CreateWorklog() logged by = %d, want %d", worklog.LoggedBy, tt.user.ID)
+			}
+			if effort.LoggedHours != 3.5 || effort.EstimatedHours == nil || *effort.EstimatedHours != 8 {
+				t.Errorf("CreateWorklog() effort = %+v, want 3.5 of 8 hours logged", effort)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/emzola/issuetracker/internal/controller/issuetracker"
+	"github.com/emzola/issuetracker/pkg/model"
+	"github.com/emzola/issuetracker/pkg/validator"
+)
+
+// GetIssueActivity godoc
+// @Summary Get issue activity
+// @Description This endpoint gets the history of changes made to an issue, newest first by default
+// @Tags issues
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param issue_id path string true "ID of issue to get activity"
+// @Param page query string false "Query string param for pagination (min 1)"
+// @Param page_size query string false "Query string param for pagination (max 100)"
+// @Param sort query string false "Sort by asc or desc order. Asc: changed_on | Desc: -changed_on"
+// @Success 200 {array} model.IssueActivity
+// @Failure 404
+// @Failure 422
+// @Failure 500
+// @Router /v1/issues/{issue_id}/activity [get]
+func (h *Handler) getIssueActivity(w http.ResponseWriter, r *http.Request) {
+	var queryParams struct {
+		Filters model.Filters
+	}
+	issueID, err := h.readIDParam(r, "issue_id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	v := validator.New()
+	qs := r.URL.Query()
+	queryParams.Filters.Page = h.readInt(qs, "page", 1, v)
+	queryParams.Filters.PageSize = h.readInt(qs, "page_size", 20, v)
+	queryParams.Filters.MaxPageSize = h.Config.Pagination.MaxPageSize
+	queryParams.Filters.Sort = h.readString(qs, "sort", "-changed_on")
+	queryParams.Filters.SortSafelist = []string{"changed_on", "-changed_on"}
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	activity, metadata, err := h.ctrl.GetIssueActivity(ctx, issueID, userFromContext, queryParams.Filters, v)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrNotFound):
+			h.notFoundResponse(w, r)
+		case errors.Is(err, issuetracker.ErrFailedValidation):
+			h.failedValidationResponse(w, r, err)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"activity": activity, "metadata": metadata}, h.paginationHeaders(r, metadata))
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// GetIssueDiff godoc
+// @Summary Get the difference between two issue versions
+// @Description This endpoint reconstructs an issue at two versions from its activity log and gets the fields that differ between them
+// @Tags issues
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param issue_id path string true "ID of issue to get diff"
+// @Param from_version query string true "Version to diff from (min 1)"
+// @Param to_version query string true "Version to diff to, at most the issue's current version"
+// @Success 200 {object} model.IssueDiff
+// @Failure 404
+// @Failure 422
+// @Failure 500
+// @Router /v1/issues/{issue_id}/diff [get]
+func (h *Handler) getIssueDiff(w http.ResponseWriter, r *http.Request) {
+	issueID, err := h.readIDParam(r, "issue_id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	v := validator.New()
+	qs := r.URL.Query()
+	fromVersion := h.readInt(qs, "from_version", 0, v)
+	toVersion := h.readInt(qs, "to_version", 0, v)
+	if !v.Valid() {
+		h.failedValidationResponse(w, r, &issuetracker.ValidationError{Fields: v.Errors})
+		return
+	}
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	diff, err := h.ctrl.GetIssueDiff(ctx, issueID, int64(fromVersion), int64(toVersion), userFromContext, v)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrNotFound):
+			h.notFoundResponse(w, r)
+		case errors.Is(err, issuetracker.ErrFailedValidation):
+			h.failedValidationResponse(w, r, err)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"diff": diff}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// GetProjectActivity godoc
+// @Summary Get project activity
+// @Description This endpoint gets the history of changes made to a project's name, description, assigned lead and dates, newest first by default
+// @Tags projects
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param project_id path string true "ID of project to get activity"
+// @Param page query string false "Query string param for pagination (min 1)"
+// @Param page_size query string false "Query string param for pagination (max 100)"
+// @Param sort query string false "Sort by asc or desc order. Asc: changed_on | Desc: -changed_on"
+// @Success 200 {array} model.ProjectActivity
+// @Failure 404
+// @Failure 422
+// @Failure 500
+// @Router /v1/projects/{project_id}/activity [get]
+func (h *Handler) getProjectActivity(w http.ResponseWriter, r *http.Request) {
+	var queryParams struct {
+		Filters model.Filters
+	}
+	projectID, err := h.readIDParam(r, "project_id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	v := validator.New()
+	qs := r.URL.Query()
+	queryParams.Filters.Page = h.readInt(qs, "page", 1, v)
+	queryParams.Filters.PageSize = h.readInt(qs, "page_size", 20, v)
+	queryParams.Filters.MaxPageSize = h.Config.Pagination.MaxPageSize
+	queryParams.Filters.Sort = h.readString(qs, "sort", "-changed_on")
+	queryParams.Filters.SortSafelist = []string{"changed_on", "-changed_on"}
+	ctx := r.Context()
+	activity, metadata, err := h.ctrl.GetProjectActivity(ctx, projectID, queryParams.Filters, v)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrNotFound):
+			h.notFoundResponse(w, r)
+		case errors.Is(err, issuetracker.ErrFailedValidation):
+			h.failedValidationResponse(w, r, err)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"activity": activity, "metadata": metadata}, h.paginationHeaders(r, metadata))
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
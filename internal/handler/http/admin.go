@@ -0,0 +1,81 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/emzola/issuetracker/internal/controller/issuetracker"
+	"github.com/emzola/issuetracker/pkg/model"
+)
+
+// GetConfig godoc
+// @Summary Get runtime configuration
+// @Description This endpoint gets the configuration that can be changed at runtime, along with the settings that only take effect after a restart
+// @Tags admin
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Success 200 {object} model.Settings
+// @Failure 403
+// @Failure 500
+// @Router /v1/admin/config [get]
+func (h *Handler) getConfig(w http.ResponseWriter, r *http.Request) {
+	err := h.encodeJSON(w, http.StatusOK, envelop{"config": h.ctrl.Settings(), "requires_restart": model.SettingsRequiringRestart}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// UpdateConfig godoc
+// @Summary Update runtime configuration
+// @Description Update the configuration that can be changed at runtime with the request payload. Changes are stored and audited, and apply without a restart except for the settings listed in requires_restart
+// @Tags admin
+// @Accept  json
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param payload body updateConfigPayload true "Request payload"
+// @Success 200 {object} model.Settings
+// @Failure 400
+// @Failure 403
+// @Failure 422
+// @Failure 500
+// @Router /v1/admin/config [patch]
+func (h *Handler) updateConfig(w http.ResponseWriter, r *http.Request) {
+	var requestPayload struct {
+		LimiterEnabled            *bool    `json:"limiter_enabled"`
+		LimiterRps                *float64 `json:"limiter_rps"`
+		LimiterBurst              *int     `json:"limiter_burst"`
+		LimiterAnonymousRps       *float64 `json:"limiter_anonymous_rps"`
+		LimiterAnonymousBurst     *int     `json:"limiter_anonymous_burst"`
+		ReminderEnabled           *bool    `json:"reminder_enabled"`
+		ReminderInterval          *string  `json:"reminder_interval"`
+		AutoCloseEnabled          *bool    `json:"auto_close_enabled"`
+		AutoCloseInterval         *string  `json:"auto_close_interval"`
+		ProjectTargetEndDateCheck *string  `json:"project_target_end_date_check"`
+	}
+	err := h.decodeJSON(w, r, &requestPayload)
+	if err != nil {
+		h.badRequestResponse(w, r, err)
+		return
+	}
+	ctx := r.Context()
+	userFromContext := h.contextGetUser(r)
+	settings, err := h.ctrl.UpdateSettings(ctx, requestPayload.LimiterEnabled, requestPayload.LimiterRps, requestPayload.LimiterBurst, requestPayload.LimiterAnonymousRps, requestPayload.LimiterAnonymousBurst, requestPayload.ReminderEnabled, requestPayload.ReminderInterval, requestPayload.AutoCloseEnabled, requestPayload.AutoCloseInterval, requestPayload.ProjectTargetEndDateCheck, userFromContext)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrNotPermitted):
+			h.notPermittedResponse(w, r)
+		case errors.Is(err, issuetracker.ErrFailedValidation):
+			h.failedValidationResponse(w, r, err)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"config": settings, "requires_restart": model.SettingsRequiringRestart}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,98 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/emzola/issuetracker/internal/controller/issuetracker"
+	"github.com/emzola/issuetracker/pkg/model"
+)
+
+// GetIssuesCalendar godoc
+// @Summary Get assigned issue due dates as an iCalendar feed
+// @Description This endpoint gets the open issues assigned to the owner of a calendar token as an iCalendar (RFC 5545) feed of all-day events on their target resolution dates
+// @Tags issues
+// @Produce text/calendar
+// @Param token query string true "Calendar feed token"
+// @Success 200
+// @Failure 401
+// @Failure 500
+// @Router /v1/issues/calendar.ics [get]
+func (h *Handler) getIssuesCalendar(w http.ResponseWriter, r *http.Request) {
+	token := h.readString(r.URL.Query(), "token", "")
+	ctx := r.Context()
+	issues, err := h.ctrl.GetCalendarIssues(ctx, token)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrInvalidCredentials):
+			h.invalidCredentialsResponse(w, r)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(h.encodeICS(issues, time.Now()))
+}
+
+// encodeICS encodes issues as an iCalendar feed with one all-day event per issue on
+// its target resolution date.
+func (h *Handler) encodeICS(issues []*model.Issue, now time.Time) []byte {
+	var buf bytes.Buffer
+	writeLine := func(line string) {
+		buf.WriteString(foldICSLine(line))
+		buf.WriteString("\r\n")
+	}
+	stamp := now.UTC().Format("20060102T150405Z")
+	writeLine("BEGIN:VCALENDAR")
+	writeLine("VERSION:2.0")
+	writeLine("PRODID:-//github.com/emzola/issuetracker//Issue Tracker//EN")
+	writeLine("CALSCALE:GREGORIAN")
+	writeLine("X-WR-CALNAME:Assigned issues")
+	for _, issue := range issues {
+		writeLine("BEGIN:VEVENT")
+		writeLine(fmt.Sprintf("UID:issue-%d@github.com/emzola/issuetracker", issue.ID))
+		writeLine("DTSTAMP:" + stamp)
+		writeLine("DTSTART;VALUE=DATE:" + issue.TargetResolutionDate.Format("20060102"))
+		writeLine("DTEND;VALUE=DATE:" + issue.TargetResolutionDate.AddDate(0, 0, 1).Format("20060102"))
+		writeLine("SUMMARY:" + escapeICSText(fmt.Sprintf("#%d %s", issue.ID, issue.Title)))
+		writeLine("DESCRIPTION:" + escapeICSText(fmt.Sprintf("Status: %s\nPriority: %s\n\n%s", issue.Status, issue.Priority, issue.Description)))
+		writeLine("END:VEVENT")
+	}
+	writeLine("END:VCALENDAR")
+	return buf.Bytes()
+}
+
+// escapeICSText escapes a value of the iCalendar TEXT type.
+func escapeICSText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`).Replace(s)
+}
+
+// foldICSLine folds a content line so that no line is longer than 75 octets, without
+// splitting a multi-byte character. Continuation lines start with a single space.
+func foldICSLine(line string) string {
+	var b strings.Builder
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// The leading space of a continuation line counts towards its length.
+		limit = 74
+	}
+	b.WriteString(line)
+	return b.String()
+}
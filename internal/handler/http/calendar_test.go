@@ -0,0 +1,45 @@
+package http
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emzola/issuetracker/config"
+	"github.com/emzola/issuetracker/pkg/model"
+)
+
+func TestEncodeICS(t *testing.T) {
+	h := New(nil, config.App{}, nil)
+	issues := []*model.Issue{{
+		ID:                   42,
+		Title:                "Login fails; users, admins and guests \\ all " + strings.Repeat("é", 40),
+		Status:               "open",
+		Priority:             "high",
+		TargetResolutionDate: time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC),
+	}}
+	ics := string(h.encodeICS(issues, time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)))
+	if !strings.HasPrefix(ics, "BEGIN:VCALENDAR\r\n") || !strings.HasSuffix(ics, "END:VCALENDAR\r\n") {
+		t.Fatalf("encodeICS() is not wrapped in a VCALENDAR:\n%s", ics)
+	}
+	for _, want := range []string{
+		"UID:issue-42@github.com/emzola/issuetracker\r\n",
+		"DTSTAMP:20240301T120000Z\r\n",
+		"DTSTART;VALUE=DATE:20240331\r\n",
+		"DTEND;VALUE=DATE:20240401\r\n",
+	} {
+		if !strings.Contains(ics, want) {
+			t.Errorf("encodeICS() does not contain %q", want)
+		}
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(ics, "\r\n"), "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("encodeICS() line is %d octets long, want at most 75: %q", len(line), line)
+		}
+	}
+	unfolded := strings.ReplaceAll(ics, "\r\n ", "")
+	want := `SUMMARY:#42 Login fails\; users\, admins and guests \\ all ` + strings.Repeat("é", 40) + "\r\n"
+	if !strings.Contains(unfolded, want) {
+		t.Errorf("encodeICS() unfolded does not contain %q:\n%s", want, unfolded)
+	}
+}
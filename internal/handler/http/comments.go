@@ -0,0 +1,215 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/emzola/issuetracker/internal/controller/issuetracker"
+	"github.com/emzola/issuetracker/pkg/model"
+	"github.com/emzola/issuetracker/pkg/validator"
+)
+
+// CreateComment godoc
+// @Summary Create a new comment
+// @Description Create a new comment on an issue with the request payload
+// @Tags comments
+// @Accept  json
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param issue_id path string true "ID of issue to comment on"
+// @Param payload body createCommentPayload true "Request payload"
+// @Success 201 {object} model.Comment
+// @Failure 400
+// @Failure 404
+// @Failure 422
+// @Failure 500
+// @Router /v1/issues/{issue_id}/comments [post]
+func (h *Handler) createComment(w http.ResponseWriter, r *http.Request) {
+	var requestPayload struct {
+		Body string `json:"body"`
+	}
+	issueID, err := h.readIDParam(r, "issue_id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	err = h.decodeJSON(w, r, &requestPayload)
+	if err != nil {
+		h.badRequestResponse(w, r, err)
+		return
+	}
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	comment, err := h.ctrl.CreateComment(ctx, issueID, requestPayload.Body, userFromContext)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrNotFound):
+			h.notFoundResponse(w, r)
+		case errors.Is(err, issuetracker.ErrFailedValidation):
+			h.failedValidationResponse(w, r, err)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	header := make(http.Header)
+	header.Set("Location", fmt.Sprintf("/v1/comments/%d", comment.ID))
+	err = h.encodeJSON(w, http.StatusCreated, envelop{"comment": comment}, header)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// GetAllComments godoc
+// @Summary Get all comments on an issue
+// @Description This endpoint gets all comments on an issue
+// @Tags comments
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param issue_id path string true "ID of issue to get comments"
+// @Param page query string false "Query string param for pagination (min 1)"
+// @Param page_size query string false "Query string param for pagination (max 100)"
+// @Param sort query string false "Sort by asc or desc order. Asc: id, created_on | Desc: -id, -created_on"
+// @Success 200 {array} model.Comment
+// @Failure 404
+// @Failure 422
+// @Failure 500
+// @Router /v1/issues/{issue_id}/comments [get]
+func (h *Handler) getAllComments(w http.ResponseWriter, r *http.Request) {
+	var queryParams struct {
+		Filters model.Filters
+	}
+	issueID, err := h.readIDParam(r, "issue_id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	v := validator.New()
+	qs := r.URL.Query()
+	queryParams.Filters.Page = h.readInt(qs, "page", 1, v)
+	queryParams.Filters.PageSize = h.readInt(qs, "page_size", 20, v)
+	queryParams.Filters.MaxPageSize = h.Config.Pagination.MaxPageSize
+	queryParams.Filters.Sort = h.readString(qs, "sort", "id")
+	queryParams.Filters.SortSafelist = []string{"id", "created_on", "-id", "-created_on"}
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	comments, metadata, err := h.ctrl.GetAllComments(ctx, issueID, userFromContext, queryParams.Filters, v)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrNotFound):
+			h.notFoundResponse(w, r)
+		case errors.Is(err, issuetracker.ErrFailedValidation):
+			h.failedValidationResponse(w, r, err)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"comments": comments, "metadata": metadata}, h.paginationHeaders(r, metadata))
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// UpdateComment godoc
+// @Summary Update a comment
+// @Description This endpoint updates a comment. Only the comment's author or a manager can update it
+// @Tags comments
+// @Accept  json
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param comment_id path string true "ID of comment to update"
+// @Param payload body updateCommentPayload true "Request payload"
+// @Success 200 {object} model.Comment
+// @Failure 400
+// @Failure 403
+// @Failure 404
+// @Failure 409
+// @Failure 422
+// @Failure 500
+// @Router /v1/comments/{comment_id} [patch]
+func (h *Handler) updateComment(w http.ResponseWriter, r *http.Request) {
+	var requestPayload struct {
+		Body *string `json:"body"`
+	}
+	commentID, err := h.readIDParam(r, "comment_id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	err = h.decodeJSON(w, r, &requestPayload)
+	if err != nil {
+		h.badRequestResponse(w, r, err)
+		return
+	}
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	comment, err := h.ctrl.UpdateComment(ctx, commentID, requestPayload.Body, userFromContext)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrNotPermitted):
+			h.notPermittedResponse(w, r)
+		case errors.Is(err, issuetracker.ErrNotFound):
+			h.notFoundResponse(w, r)
+		case errors.Is(err, issuetracker.ErrFailedValidation):
+			h.failedValidationResponse(w, r, err)
+		case errors.Is(err, issuetracker.ErrEditConflict):
+			h.editConflictResponse(w, r)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"comment": comment}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// DeleteComment godoc
+// @Summary Delete a comment
+// @Description This endpoint deletes a comment. Only the comment's author or a manager can delete it
+// @Tags comments
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param comment_id path string true "ID of comment to delete"
+// @Success 200
+// @Failure 403
+// @Failure 404
+// @Failure 500
+// @Router /v1/comments/{comment_id} [delete]
+func (h *Handler) deleteComment(w http.ResponseWriter, r *http.Request) {
+	commentID, err := h.readIDParam(r, "comment_id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	err = h.ctrl.DeleteComment(ctx, commentID, userFromContext)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrNotPermitted):
+			h.notPermittedResponse(w, r)
+		case errors.Is(err, issuetracker.ErrNotFound):
+			h.notFoundResponse(w, r)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"message": "comment successfully deleted"}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
@@ -5,11 +5,16 @@ import (
 	"net/http"
 
 	"github.com/emzola/issuetracker/pkg/model"
+	"github.com/pascaldekloe/jwt"
 )
 
 type contextKey string
 
-const userContextKey = contextKey("user")
+const (
+	userContextKey      = contextKey("user")
+	claimsContextKey    = contextKey("claims")
+	requestIDContextKey = contextKey("request_id")
+)
 
 func (h *Handler) contextSetUser(r *http.Request, user *model.User) *http.Request {
 	ctx := context.WithValue(r.Context(), userContextKey, user)
@@ -23,3 +28,30 @@ func (h *Handler) contextGetUser(r *http.Request) *model.User {
 	}
 	return user
 }
+
+// contextSetClaims adds the claims of the request's authentication token to the
+// request context.
+func (h *Handler) contextSetClaims(r *http.Request, claims *jwt.Claims) *http.Request {
+	ctx := context.WithValue(r.Context(), claimsContextKey, claims)
+	return r.WithContext(ctx)
+}
+
+// contextGetClaims returns the claims of the request's authentication token. Anonymous
+// requests have none.
+func (h *Handler) contextGetClaims(r *http.Request) (*jwt.Claims, bool) {
+	claims, ok := r.Context().Value(claimsContextKey).(*jwt.Claims)
+	return claims, ok
+}
+
+// contextSetRequestID adds the request's correlation ID to the request context.
+func (h *Handler) contextSetRequestID(r *http.Request, requestID string) *http.Request {
+	ctx := context.WithValue(r.Context(), requestIDContextKey, requestID)
+	return r.WithContext(ctx)
+}
+
+// contextGetRequestID returns the request's correlation ID. Requests that didn't go
+// through the requestID middleware have none.
+func (h *Handler) contextGetRequestID(r *http.Request) string {
+	requestID, _ := r.Context().Value(requestIDContextKey).(string)
+	return requestID
+}
@@ -0,0 +1,37 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// GetDashboard godoc
+// @Summary Get the authenticated user's dashboard
+// @Description This endpoint gets an overview of the authenticated user's work: the first few of their open assigned issues, the issues they reported, newest first, and the projects they're on. The counts give the total number of each, for badges
+// @Tags users
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Success 200 {object} model.Dashboard
+// @Failure 401
+// @Failure 403
+// @Failure 500
+// @Router /v1/dashboard [get]
+func (h *Handler) getDashboard(w http.ResponseWriter, r *http.Request) {
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	dashboard, err := h.ctrl.GetDashboard(ctx, userFromContext)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"dashboard": dashboard}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
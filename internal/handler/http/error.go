@@ -1,9 +1,13 @@
 package http
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 
+	"github.com/emzola/issuetracker/internal/controller/issuetracker"
+	"github.com/emzola/issuetracker/pkg/model"
 	"go.uber.org/zap"
 )
 
@@ -13,11 +17,23 @@ func (h *Handler) logError(r *http.Request, err error) {
 	logger.Info(fmt.Sprintf("%s", err),
 		zap.String("request_method", r.Method),
 		zap.String("request_url", r.URL.String()),
+		zap.String("request_id", h.contextGetRequestID(r)),
 	)
 }
 
-func (h *Handler) errorResponse(w http.ResponseWriter, r *http.Request, status int, message interface{}) {
-	env := envelop{"error": message}
+// errorBody is the body of every error response, under the "error" key. Code identifies
+// the kind of error for clients, message describes it for people, and details hold any
+// data specific to the error, such as the fields that failed validation. RequestID is
+// the request's correlation ID, to quote when reporting the error.
+type errorBody struct {
+	Code      string  `json:"code"`
+	Message   string  `json:"message"`
+	Details   envelop `json:"details,omitempty"`
+	RequestID string  `json:"request_id,omitempty"`
+}
+
+func (h *Handler) errorResponse(w http.ResponseWriter, r *http.Request, status int, code, message string, details envelop) {
+	env := envelop{"error": errorBody{Code: code, Message: message, Details: details, RequestID: h.contextGetRequestID(r)}}
 	err := h.encodeJSON(w, status, env, nil)
 	if err != nil {
 		h.logError(r, err)
@@ -25,72 +41,121 @@ func (h *Handler) errorResponse(w http.ResponseWriter, r *http.Request, status i
 	}
 }
 
+// serverErrorResponse responds to unexpected errors. Errors caused by the request
+// timing out get a timeout response instead.
 func (h *Handler) serverErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
 	h.logError(r, err)
+	if errors.Is(err, context.DeadlineExceeded) {
+		h.timeoutResponse(w, r)
+		return
+	}
 	message := "the server encountered a problem and could not process your request"
-	h.errorResponse(w, r, http.StatusInternalServerError, message)
+	h.errorResponse(w, r, http.StatusInternalServerError, "server_error", message, nil)
+}
+
+func (h *Handler) timeoutResponse(w http.ResponseWriter, r *http.Request) {
+	message := "the server took too long to process your request, please try again"
+	h.errorResponse(w, r, http.StatusServiceUnavailable, "timeout", message, nil)
 }
 
 func (h *Handler) notFoundResponse(w http.ResponseWriter, r *http.Request) {
 	message := "the requested resource could not be found"
-	h.errorResponse(w, r, http.StatusNotFound, message)
+	h.errorResponse(w, r, http.StatusNotFound, "not_found", message, nil)
 }
 
 func (h *Handler) methodNotAllowedResponse(w http.ResponseWriter, r *http.Request) {
 	message := fmt.Sprintf("the %s method is not supported for this resource", r.Method)
-	h.errorResponse(w, r, http.StatusMethodNotAllowed, message)
+	h.errorResponse(w, r, http.StatusMethodNotAllowed, "method_not_allowed", message, nil)
 }
 
 func (h *Handler) badRequestResponse(w http.ResponseWriter, r *http.Request, err error) {
-	h.errorResponse(w, r, http.StatusBadRequest, err.Error())
+	h.errorResponse(w, r, http.StatusBadRequest, "bad_request", err.Error(), nil)
 }
 
 func (h *Handler) editConflictResponse(w http.ResponseWriter, r *http.Request) {
 	message := "unable to update the record due to an edit conflict, please try again"
-	h.errorResponse(w, r, http.StatusConflict, message)
+	h.errorResponse(w, r, http.StatusConflict, "edit_conflict", message, nil)
+}
+
+// preconditionFailedResponse responds when the record no longer matches the request's
+// If-Match header.
+func (h *Handler) preconditionFailedResponse(w http.ResponseWriter, r *http.Request) {
+	message := "the record has been changed since the version in If-Match, please fetch it again"
+	h.errorResponse(w, r, http.StatusPreconditionFailed, "precondition_failed", message, nil)
 }
 
+// openIssuesResponse responds when a user can't be deleted because they have open issues
+// assigned to them.
+func (h *Handler) openIssuesResponse(w http.ResponseWriter, r *http.Request, count int) {
+	message := "the user has open issues assigned to them, reassign the issues or delete the user with force=true"
+	h.errorResponse(w, r, http.StatusConflict, "open_issues_assigned", message, envelop{"open_issues": count})
+}
+
+// reportedIssuesResponse responds when a user can't be deleted because they reported
+// issues.
+func (h *Handler) reportedIssuesResponse(w http.ResponseWriter, r *http.Request) {
+	message := "the user reported issues and can't be deleted"
+	h.errorResponse(w, r, http.StatusConflict, "reported_issues", message, nil)
+}
+
+// failedValidationResponse responds with the fields that failed validation, mapped to
+// the reason, so that clients can show each one next to its field.
 func (h *Handler) failedValidationResponse(w http.ResponseWriter, r *http.Request, err error) {
-	h.errorResponse(w, r, http.StatusUnprocessableEntity, err.Error())
+	message := "one or more fields failed validation"
+	var validationErr *issuetracker.ValidationError
+	if !errors.As(err, &validationErr) || len(validationErr.Fields) == 0 {
+		h.errorResponse(w, r, http.StatusUnprocessableEntity, "failed_validation", message, nil)
+		return
+	}
+	h.errorResponse(w, r, http.StatusUnprocessableEntity, "failed_validation", message, envelop{"fields": validationErr.Fields})
 }
 
 func (h *Handler) invalidCredentialsResponse(w http.ResponseWriter, r *http.Request) {
 	message := "invalid authentication credentials"
-	h.errorResponse(w, r, http.StatusUnauthorized, message)
+	h.errorResponse(w, r, http.StatusUnauthorized, "invalid_credentials", message, nil)
 }
 
 func (h *Handler) invalidAuthenticationTokenResponse(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("WWW-Authenticate", "Bearer")
 	message := "invalid or missing authentication token"
-	h.errorResponse(w, r, http.StatusUnauthorized, message)
+	h.errorResponse(w, r, http.StatusUnauthorized, "invalid_authentication_token", message, nil)
 }
 
 func (h *Handler) authenticationRequiredResponse(w http.ResponseWriter, r *http.Request) {
 	message := "you must be authenticated to access this resource"
-	h.errorResponse(w, r, http.StatusUnauthorized, message)
+	h.errorResponse(w, r, http.StatusUnauthorized, "authentication_required", message, nil)
 }
 
 func (h *Handler) inactiveAccountResponse(w http.ResponseWriter, r *http.Request) {
 	message := "your user account must be activated to access this resource"
-	h.errorResponse(w, r, http.StatusForbidden, message)
+	h.errorResponse(w, r, http.StatusForbidden, "inactive_account", message, nil)
 }
 
 func (h *Handler) invalidRoleResponse(w http.ResponseWriter, r *http.Request) {
 	message := "the user role cannot be assigned to this resource"
-	h.errorResponse(w, r, http.StatusForbidden, message)
+	h.errorResponse(w, r, http.StatusForbidden, "invalid_role", message, nil)
 }
 
 func (h *Handler) notPermittedResponse(w http.ResponseWriter, r *http.Request) {
 	message := "your user account doesn't have the necessary permissions to access this resource"
-	h.errorResponse(w, r, http.StatusForbidden, message)
+	h.errorResponse(w, r, http.StatusForbidden, "not_permitted", message, nil)
 }
 
 func (h *Handler) alreadyActivatedResponse(w http.ResponseWriter, r *http.Request) {
 	message := "your user account has already been activated"
-	h.errorResponse(w, r, http.StatusForbidden, message)
+	h.errorResponse(w, r, http.StatusConflict, "already_activated", message, nil)
 }
 
 func (h *Handler) rateLimitExceededResponse(w http.ResponseWriter, r *http.Request) {
 	message := "rate limit exceeded"
-	h.errorResponse(w, r, http.StatusTooManyRequests, message)
+	h.errorResponse(w, r, http.StatusTooManyRequests, "rate_limit_exceeded", message, nil)
+}
+
+func (h *Handler) targetEndDateConflictResponse(w http.ResponseWriter, r *http.Request, issues []*model.Issue) {
+	message := "one or more fields failed validation"
+	details := envelop{
+		"fields":             map[string]string{"target_end_date": "must not be before the target resolution date of the project's unresolved issues"},
+		"conflicting_issues": issues,
+	}
+	h.errorResponse(w, r, http.StatusUnprocessableEntity, "failed_validation", message, details)
 }
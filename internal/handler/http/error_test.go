@@ -0,0 +1,75 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/emzola/issuetracker/config"
+	"github.com/emzola/issuetracker/internal/controller/issuetracker"
+)
+
+func TestErrorResponse(t *testing.T) {
+	h := New(nil, config.App{}, nil)
+	fields := map[string]string{"title": "must be provided", "priority": "invalid priority"}
+	tests := []struct {
+		name        string
+		respond     func(w http.ResponseWriter, r *http.Request)
+		wantStatus  int
+		wantCode    string
+		wantDetails map[string]any
+	}{
+		{
+			name:       "not found",
+			respond:    h.notFoundResponse,
+			wantStatus: http.StatusNotFound,
+			wantCode:   "not_found",
+		},
+		{
+			name: "failed validation",
+			respond: func(w http.ResponseWriter, r *http.Request) {
+				h.failedValidationResponse(w, r, fmt.Errorf("creating issue: %w", &issuetracker.ValidationError{Fields: fields}))
+			},
+			wantStatus:  http.StatusUnprocessableEntity,
+			wantCode:    "failed_validation",
+			wantDetails: map[string]any{"fields": map[string]any{"title": "must be provided", "priority": "invalid priority"}},
+		},
+		{
+			name: "bad request",
+			respond: func(w http.ResponseWriter, r *http.Request) {
+				h.badRequestResponse(w, r, errors.New("body must not be empty"))
+			},
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "bad_request",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			tt.respond(w, httptest.NewRequest(http.MethodGet, "/v1/issues", nil))
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			var body struct {
+				Error struct {
+					Code    string         `json:"code"`
+					Message string         `json:"message"`
+					Details map[string]any `json:"details"`
+				} `json:"error"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Error.Code != tt.wantCode || body.Error.Message == "" {
+				t.Errorf("error code = %q, message %q, want code %q and a message", body.Error.Code, body.Error.Message, tt.wantCode)
+			}
+			if !reflect.DeepEqual(body.Error.Details, tt.wantDetails) {
+				t.Errorf("error details = %v, want %v", body.Error.Details, tt.wantDetails)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package http
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/emzola/issuetracker/internal/controller/issuetracker"
+	"github.com/emzola/issuetracker/pkg/model"
+	"github.com/emzola/issuetracker/pkg/validator"
+)
+
+// issueExportHeader holds the column names of issue exports.
+var issueExportHeader = []string{"id", "title", "status", "priority", "assignee", "reported_date", "target_resolution_date", "actual_resolution_date"}
+
+// ExportIssues godoc
+// @Summary Export a project's issues
+// @Description This endpoint exports a project's issues as CSV. Issues are filtered and sorted with the same query string params as the list of issues, but are only paginated if page_size is given
+// @Tags issues
+// @Produce text/csv
+// @Param token header string true "Bearer token"
+// @Param project_id query string true "Query string param for project_id"
+// @Param milestone_id query string false "Query string param for milestone_id"
+// @Param format query string false "Query string param for the export format (csv)"
+// @Param title query string false "Query string param for title"
+// @Param q query string false "Query string param for searching titles, descriptions and resolution summaries"
+// @Param reported_date query string false "Query string param for reported_date"
+// @Param reported_from query string false "Query string param for the earliest reported_date (YYYY-MM-DD)"
+// @Param reported_to query string false "Query string param for the latest reported_date (YYYY-MM-DD)"
+// @Param target_from query string false "Query string param for the earliest target_resolution_date (YYYY-MM-DD)"
+// @Param target_to query string false "Query string param for the latest target_resolution_date (YYYY-MM-DD)"
+// @Param assigned_to query string false "Query string param for assigned_to"
+// @Param unassigned query string false "Query string param for only issues without an assignee (true|false)"
+// @Param assignee_name query string false "Query string param for the assignee's name (case-insensitive)"
+// @Param reporter_name query string false "Query string param for the reporter's name (case-insensitive)"
+// @Param status query string false "Query string param for status"
+// @Param priority query string false "Query string param for priority"
+// @Param type query string false "Query string param for type (bug|feature|task|improvement)"
+// @Param label query string false "Query string param for labels (comma separated)"
+// @Param label_match query string false "Query string param for whether issues must have any or all of the labels (any|all)"
+// @Param page query string false "Query string param for the page, if page_size is given"
+// @Param page_size query string false "Query string param for the page size. Issues aren't paginated without it"
+// @Param sort query string false "Sort by asc or desc order, by one or more comma separated fields (e.g. -priority,target_resolution_date). Asc: id, title, reported_date, target_resolution_date, project_id, assigned_to, status, priority, rank | Desc: -id, -title, -reported_date, -target_resolution_date, -project_id, -assigned_to, -status, -priority, -rank"
+// @Success 200
+// @Failure 422
+// @Failure 500
+// @Router /v1/issues/export [get]
+func (h *Handler) exportIssues(w http.ResponseWriter, r *http.Request) {
+	var queryParams struct {
+		Format       string
+		IssueFilters model.IssueFilters
+		Sort         model.Filters
+	}
+	v := validator.New()
+	qs := r.URL.Query()
+	queryParams.Format = h.readString(qs, "format", "csv")
+	queryParams.IssueFilters = h.readIssueFilters(qs, v)
+	defaultSort := "id"
+	if queryParams.IssueFilters.Query != "" {
+		defaultSort = "-rank"
+	}
+	// Exports are only paginated if a page size is given.
+	queryParams.Sort.Page = h.readInt(qs, "page", 1, v)
+	queryParams.Sort.PageSize = h.readInt(qs, "page_size", 0, v)
+	queryParams.Sort.MaxPageSize = h.Config.Pagination.ExportMaxPageSize
+	queryParams.Sort.Sort = h.readString(qs, "sort", defaultSort)
+	queryParams.Sort.SortSafelist = model.IssueSortSafelist
+	v.Check(queryParams.Format == "csv", "format", "must be csv")
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	// The response is only started once the export has been validated and the first
+	// issue read, so that earlier errors still get an error response.
+	cw := csv.NewWriter(w)
+	started := false
+	start := func() error {
+		started = true
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="project-%d-issues.csv"`, queryParams.IssueFilters.ProjectID))
+		w.WriteHeader(http.StatusOK)
+		return cw.Write(issueExportHeader)
+	}
+	err := h.ctrl.ExportIssues(ctx, queryParams.IssueFilters, userFromContext, queryParams.Sort, v, func(issue *model.IssueExport) error {
+		if !started {
+			err := start()
+			if err != nil {
+				return err
+			}
+		}
+		return cw.Write(issueExportRecord(issue))
+	})
+	if err == nil && !started {
+		err = start()
+	}
+	if err == nil {
+		cw.Flush()
+		err = cw.Error()
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case started:
+			// The export can only be cut short once the response has started.
+			h.logError(r, err)
+		case errors.Is(err, issuetracker.ErrFailedValidation):
+			h.failedValidationResponse(w, r, err)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+	}
+}
+
+// issueExportRecord returns the CSV record of an exported issue, with the columns in
+// issueExportHeader.
+func issueExportRecord(issue *model.IssueExport) []string {
+	actualResolutionDate := ""
+	if issue.ActualResolutionDate != nil {
+		actualResolutionDate = issue.ActualResolutionDate.Format("2006-01-02")
+	}
+	return []string{
+		strconv.FormatInt(issue.ID, 10),
+		csvSafe(issue.Title),
+		issue.Status,
+		issue.Priority,
+		csvSafe(issue.Assignee),
+		issue.ReportedDate.Format("2006-01-02"),
+		issue.TargetResolutionDate.Format("2006-01-02"),
+		actualResolutionDate,
+	}
+}
@@ -0,0 +1,82 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/emzola/issuetracker/config"
+	"github.com/emzola/issuetracker/internal/controller/issuetracker"
+	"github.com/emzola/issuetracker/internal/repository/postgres"
+	"github.com/emzola/issuetracker/pkg/model"
+	"go.uber.org/zap"
+)
+
+// exportRepository exports a fixed list of issues. Methods that are not overridden are
+// not expected to be called.
+type exportRepository struct {
+	*postgres.Repository
+	issues []*model.IssueExport
+}
+
+func (r *exportRepository) ExportIssues(ctx context.Context, issueFilters model.IssueFilters, viewerID int64, sort model.Filters, fn func(*model.IssueExport) error) error {
+	for _, issue := range r.issues {
+		if err := fn(issue); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestExportIssues(t *testing.T) {
+	resolved := time.Date(2024, 1, 20, 0, 0, 0, 0, time.UTC)
+	repo := &exportRepository{issues: []*model.IssueExport{
+		{ID: 1, Title: "Login fails, sometimes", Status: "open", Priority: "high", Assignee: "Ada Lovelace", ReportedDate: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), TargetResolutionDate: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{ID: 2, Title: "=HYPERLINK(\"http://example.com\")", Status: "closed", Priority: "low", ReportedDate: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), TargetResolutionDate: time.Date(2024, 2, 2, 0, 0, 0, 0, time.UTC), ActualResolutionDate: &resolved},
+	}}
+	var wg sync.WaitGroup
+	h := New(issuetracker.New(repo, config.App{}, &wg, zap.NewNop()), config.App{}, nil)
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantBody   string
+	}{
+		{"csv", "project_id=1&format=csv", http.StatusOK, "id,title,status,priority,assignee,reported_date,target_resolution_date,actual_resolution_date\n" +
+			"1,\"Login fails, sometimes\",open,high,Ada Lovelace,2024-01-01,2024-02-01,\n" +
+			"2,\"'=HYPERLINK(\"\"http://example.com\"\")\",closed,low,,2024-01-02,2024-02-02,2024-01-20\n"},
+		{"multiple sort fields", "project_id=1&sort=-priority,target_resolution_date", http.StatusOK, "id,title,status,priority,assignee,reported_date,target_resolution_date,actual_resolution_date\n" +
+			"1,\"Login fails, sometimes\",open,high,Ada Lovelace,2024-01-01,2024-02-01,\n" +
+			"2,\"'=HYPERLINK(\"\"http://example.com\"\")\",closed,low,,2024-01-02,2024-02-02,2024-01-20\n"},
+		{"missing project", "format=csv", http.StatusUnprocessableEntity, ""},
+		{"unknown format", "project_id=1&format=xlsx", http.StatusUnprocessableEntity, ""},
+		{"unknown sort", "project_id=1&sort=description", http.StatusUnprocessableEntity, ""},
+		{"page size above maximum", "project_id=1&page_size=20001", http.StatusUnprocessableEntity, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/v1/issues/export?"+tt.query, nil)
+			r = h.contextSetUser(r, &model.User{ID: 1, Role: "lead", Activated: true})
+			w := httptest.NewRecorder()
+			h.exportIssues(w, r)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("exportIssues() status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			if got := w.Header().Get("Content-Type"); got != "text/csv; charset=utf-8" {
+				t.Errorf("exportIssues() Content-Type = %q, want text/csv", got)
+			}
+			if got, want := w.Header().Get("Content-Disposition"), `attachment; filename="project-1-issues.csv"`; got != want {
+				t.Errorf("exportIssues() Content-Disposition = %q, want %q", got, want)
+			}
+			if w.Body.String() != tt.wantBody {
+				t.Errorf("exportIssues() body = %q, want %q", w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
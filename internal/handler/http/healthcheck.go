@@ -1,12 +1,24 @@
 package http
 
-import "net/http"
+import (
+	"context"
+	"net/http"
+	"time"
+)
 
+// HealthCheck godoc
+// @Summary Check that the service is up
+// @Description This endpoint reports the service status, environment and version. It can be used as a liveness probe.
+// @Tags health
+// @Produce json
+// @Success 200
+// @Router /v1/healthcheck [get]
 func (h *Handler) healthCheck(w http.ResponseWriter, r *http.Request) {
 	data := envelop{
 		"status": "available",
 		"system_info": map[string]string{
 			"environment": h.Config.Env,
+			"version":     h.Config.Version,
 		},
 	}
 	err := h.encodeJSON(w, http.StatusOK, data, nil)
@@ -14,3 +26,26 @@ func (h *Handler) healthCheck(w http.ResponseWriter, r *http.Request) {
 		h.serverErrorResponse(w, r, err)
 	}
 }
+
+// Readiness godoc
+// @Summary Check that the service can serve requests
+// @Description This endpoint checks that the database is reachable. It can be used as a readiness probe.
+// @Tags health
+// @Produce json
+// @Success 200
+// @Failure 503
+// @Router /v1/readiness [get]
+func (h *Handler) readiness(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+	defer cancel()
+	err := h.ctrl.Ready(ctx)
+	if err != nil {
+		h.logError(r, err)
+		h.errorResponse(w, r, http.StatusServiceUnavailable, "service_unavailable", "the service is not ready", nil)
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"status": "ready"}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
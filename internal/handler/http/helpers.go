@@ -1,15 +1,21 @@
 package http
 
 import (
+	"bytes"
+	"encoding/csv"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"reflect"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/emzola/issuetracker/internal/controller/issuetracker"
+	"github.com/emzola/issuetracker/pkg/model"
 	"github.com/emzola/issuetracker/pkg/validator"
 	"github.com/julienschmidt/httprouter"
 )
@@ -17,12 +23,17 @@ import (
 // envelop is a wrapper around JSON responses.
 type envelop map[string]interface{}
 
-// readIDParam pulls the url id parameter from the request and returns it or an error if any.
+// errInvalidIDParam is returned by readIDParam for ids that aren't positive integers.
+var errInvalidIDParam = errors.New("invalid id parameter")
+
+// readIDParam pulls the url id parameter from the request and returns it, or
+// errInvalidIDParam if it isn't a positive integer. Handlers respond to the error with a
+// 404, since no record can have such an id.
 func (h *Handler) readIDParam(r *http.Request, idParam string) (int64, error) {
 	params := httprouter.ParamsFromContext(r.Context())
 	id, err := strconv.ParseInt(params.ByName(idParam), 10, 64)
-	if err != nil {
-		return 0, err
+	if err != nil || id < 1 {
+		return 0, errInvalidIDParam
 	}
 	return id, nil
 }
@@ -37,6 +48,16 @@ func (h *Handler) readString(qs url.Values, key string, defaultValue string) str
 	return s
 }
 
+// readCSV reads a comma-separated string value from the query string and splits it
+// into a slice. If no matching key could be found, it returns the provided default value.
+func (h *Handler) readCSV(qs url.Values, key string, defaultValue []string) []string {
+	csv := qs.Get(key)
+	if csv == "" {
+		return defaultValue
+	}
+	return strings.Split(csv, ",")
+}
+
 // readInt() reads a string value from the query string and converts it to an
 // integer before returning. If no matching key could be found it returns the provided
 // default value. If the value couldn't be converted to an integer, it records an
@@ -54,6 +75,23 @@ func (h *Handler) readInt(qs url.Values, key string, defaultValue int, v *valida
 	return i
 }
 
+// readBool reads a string value from the query string and converts it to a
+// boolean before returning. If no matching key could be found it returns the provided
+// default value. If the value couldn't be converted to a boolean, it records an
+// error message in the provided Validator instance.
+func (h *Handler) readBool(qs url.Values, key string, defaultValue bool, v *validator.Validator) bool {
+	s := qs.Get(key)
+	if len(s) == 0 {
+		return defaultValue
+	}
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		v.AddError(key, "must be a boolean value")
+		return defaultValue
+	}
+	return b
+}
+
 // encodeJSON serializes data to JSON and writes the appropriate HTTP status code and headers if necessary.
 func (h *Handler) encodeJSON(w http.ResponseWriter, status int, data envelop, headers http.Header) error {
 	js, err := json.MarshalIndent(data, "", "\t")
@@ -70,6 +108,188 @@ func (h *Handler) encodeJSON(w http.ResponseWriter, status int, data envelop, he
 	return nil
 }
 
+// versionETag returns the strong ETag of a resource at the given version.
+func versionETag(version int64) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
+
+// notModified sets the ETag header of a response and reports whether the request's
+// If-None-Match header matches it. If it does, it responds with 304 Not Modified and
+// the caller must not write a body.
+func (h *Handler) notModified(w http.ResponseWriter, r *http.Request, etag string) bool {
+	w.Header().Set("ETag", etag)
+	for _, match := range strings.Split(r.Header.Get("If-None-Match"), ",") {
+		// If-None-Match uses the weak comparison, so W/ prefixes are ignored.
+		match = strings.TrimPrefix(strings.TrimSpace(match), "W/")
+		if match == "*" || match == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
+
+// readIfMatch returns the version of the record in the request's If-Match header, or 0
+// if the header is missing or "*". The header holds an ETag from a previous response,
+// which starts with the record's version.
+func (h *Handler) readIfMatch(r *http.Request) (int64, error) {
+	match := strings.TrimSpace(r.Header.Get("If-Match"))
+	if match == "" || match == "*" {
+		return 0, nil
+	}
+	if !strings.HasPrefix(match, `"`) || !strings.HasSuffix(match, `"`) || len(match) < 2 {
+		return 0, errors.New("the If-Match header must hold a single strong ETag")
+	}
+	version, _, _ := strings.Cut(match[1:len(match)-1], "-")
+	v, err := strconv.ParseInt(version, 10, 64)
+	if err != nil || v < 1 {
+		return 0, errors.New("the If-Match header must hold an ETag from a previous response")
+	}
+	return v, nil
+}
+
+// paginationHeaders returns the X-Total-Count and Link headers of a page of a list, with
+// links to the first, previous, next and last pages. The links keep the request's query
+// string, apart from the page. Empty lists, including pages past the end of a list, have
+// no links.
+func (h *Handler) paginationHeaders(r *http.Request, metadata model.Metadata) http.Header {
+	header := make(http.Header)
+	header.Set("X-Total-Count", strconv.Itoa(metadata.TotalRecords))
+	if metadata.TotalRecords == 0 {
+		return header
+	}
+	link := func(page int, rel string) string {
+		u := *r.URL
+		qs := u.Query()
+		qs.Set("page", strconv.Itoa(page))
+		u.RawQuery = qs.Encode()
+		return fmt.Sprintf(`<%s>; rel="%s"`, u.RequestURI(), rel)
+	}
+	links := []string{link(metadata.FirstPage, "first")}
+	if metadata.CurrentPage > metadata.FirstPage {
+		links = append(links, link(metadata.CurrentPage-1, "prev"))
+	}
+	if metadata.CurrentPage < metadata.LastPage {
+		links = append(links, link(metadata.CurrentPage+1, "next"))
+	}
+	links = append(links, link(metadata.LastPage, "last"))
+	header.Set("Link", strings.Join(links, ", "))
+	return header
+}
+
+// encodeReport writes a report as JSON, or as a CSV attachment named filename if format
+// is csv.
+func (h *Handler) encodeReport(w http.ResponseWriter, format, filename string, report interface{}) error {
+	if format == "csv" {
+		return h.encodeCSV(w, http.StatusOK, filename, report)
+	}
+	return h.encodeJSON(w, http.StatusOK, envelop{"report": report}, nil)
+}
+
+// encodeCSV writes records, a slice of structs or pointers to structs, as a CSV
+// attachment named filename. The header row holds the JSON names of the struct fields,
+// so that CSV columns match the keys of the JSON representation. Times are written as
+// dates.
+func (h *Handler) encodeCSV(w http.ResponseWriter, status int, filename string, records interface{}) error {
+	rv := reflect.ValueOf(records)
+	if rv.Kind() != reflect.Slice {
+		return fmt.Errorf("cannot encode %T as CSV", records)
+	}
+	rt := rv.Type().Elem()
+	if rt.Kind() == reflect.Pointer {
+		rt = rt.Elem()
+	}
+	if rt.Kind() != reflect.Struct {
+		return fmt.Errorf("cannot encode %T as CSV", records)
+	}
+	var buf bytes.Buffer
+	cw := csv.NewWriter(&buf)
+	header := make([]string, rt.NumField())
+	for i := range header {
+		field := rt.Field(i)
+		header[i] = field.Name
+		if name, _, _ := strings.Cut(field.Tag.Get("json"), ","); name != "" && name != "-" {
+			header[i] = name
+		}
+	}
+	cw.Write(header)
+	for i := 0; i < rv.Len(); i++ {
+		record := reflect.Indirect(rv.Index(i))
+		row := make([]string, rt.NumField())
+		for j := range row {
+			switch value := record.Field(j).Interface().(type) {
+			case time.Time:
+				row[j] = value.Format("2006-01-02")
+			case string:
+				row[j] = csvSafe(value)
+			default:
+				row[j] = fmt.Sprint(value)
+			}
+		}
+		cw.Write(row)
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	w.WriteHeader(status)
+	w.Write(buf.Bytes())
+	return nil
+}
+
+// csvSafe keeps user-supplied CSV fields from being run as formulas when the CSV is
+// opened in a spreadsheet, by prefixing fields that start with a formula character
+// with a single quote.
+func csvSafe(field string) string {
+	if field != "" && strings.ContainsRune("=+-@\t\r", rune(field[0])) {
+		return "'" + field
+	}
+	return field
+}
+
+// encodeMultiStatus writes the per-item outcomes of a bulk operation as a 207 Multi-Status
+// response. Bulk endpoints always respond this way, whether all, some or none of the items
+// succeeded, so that clients can handle every bulk endpoint the same way. The body has
+// the shape of model.BulkResponse, which bulk endpoints document as their 207 response.
+func (h *Handler) encodeMultiStatus(w http.ResponseWriter, results []*model.BulkResult) error {
+	return h.encodeJSON(w, http.StatusMultiStatus, envelop{"results": results}, nil)
+}
+
+// bulkResult returns the outcome of a single item in a bulk operation, mapping err to the
+// status and message the item would have received as an individual request.
+func (h *Handler) bulkResult(r *http.Request, id int64, err error) *model.BulkResult {
+	result := &model.BulkResult{ID: id, Status: http.StatusOK}
+	switch {
+	case err == nil:
+		return result
+	case errors.Is(err, issuetracker.ErrNotFound):
+		result.Status = http.StatusNotFound
+		result.Error = "the requested resource could not be found"
+	case errors.Is(err, issuetracker.ErrNotPermitted):
+		result.Status = http.StatusForbidden
+		result.Error = "your user account doesn't have the necessary permissions to access this resource"
+	case errors.Is(err, issuetracker.ErrInvalidRole):
+		result.Status = http.StatusForbidden
+		result.Error = "the user role cannot be assigned to this resource"
+	case errors.Is(err, issuetracker.ErrEditConflict):
+		result.Status = http.StatusConflict
+		result.Error = "unable to update the record due to an edit conflict, please try again"
+	case errors.Is(err, issuetracker.ErrFailedValidation):
+		result.Status = http.StatusUnprocessableEntity
+		result.Error = err.Error()
+	case errors.Is(err, issuetracker.ErrRolledBack):
+		result.Status = http.StatusFailedDependency
+		result.Error = "the change was not saved because saving another item in the request failed"
+	default:
+		h.logError(r, err)
+		result.Status = http.StatusInternalServerError
+		result.Error = "the server encountered a problem and could not process your request"
+	}
+	return result
+}
+
 // decodeJSON de-serializes JSON data into Go types.
 func (h *Handler) decodeJSON(w http.ResponseWriter, r *http.Request, dst any) error {
 	maxBytes := 1_048_576
@@ -111,3 +331,90 @@ func (h *Handler) decodeJSON(w http.ResponseWriter, r *http.Request, dst any) er
 	}
 	return nil
 }
+
+// wantsMinimalReturn reports whether the client asked for a minimal response to
+// an update, either via a "Prefer: return=minimal" header or a return=minimal
+// query string parameter. The default is return=representation.
+func (h *Handler) wantsMinimalReturn(r *http.Request) bool {
+	if r.URL.Query().Get("return") == "minimal" {
+		return true
+	}
+	for _, header := range r.Header.Values("Prefer") {
+		for _, preference := range strings.FieldsFunc(header, func(c rune) bool { return c == ',' || c == ';' }) {
+			if strings.TrimSpace(preference) == "return=minimal" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// changedFields compares the JSON representations of before and after and returns
+// the fields whose values differ. Fields omitted from after are returned as nil.
+func (h *Handler) changedFields(before, after any) (map[string]any, error) {
+	var beforeFields, afterFields map[string]json.RawMessage
+	js, err := json.Marshal(before)
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(js, &beforeFields); err != nil {
+		return nil, err
+	}
+	js, err = json.Marshal(after)
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(js, &afterFields); err != nil {
+		return nil, err
+	}
+	changed := make(map[string]any)
+	for key, value := range afterFields {
+		if !bytes.Equal(beforeFields[key], value) {
+			changed[key] = value
+		}
+	}
+	for key := range beforeFields {
+		if _, ok := afterFields[key]; !ok {
+			changed[key] = nil
+		}
+	}
+	return changed, nil
+}
+
+// encodeUpdate writes the response for a successful update. When the client asked for
+// return=minimal, only the fields that differ from the pre-update state are written
+// along with the new version. Otherwise the full record is written. Any entries in
+// extra, such as warnings, are written alongside the record.
+func (h *Handler) encodeUpdate(w http.ResponseWriter, r *http.Request, key string, before, after any, version int64, extra envelop) error {
+	data := envelop{key: after}
+	var header http.Header
+	if h.wantsMinimalReturn(r) {
+		changed, err := h.changedFields(before, after)
+		if err != nil {
+			return err
+		}
+		changed["version"] = version
+		data[key] = changed
+		header = make(http.Header)
+		header.Set("Preference-Applied", "return=minimal")
+	}
+	for k, v := range extra {
+		data[k] = v
+	}
+	return h.encodeJSON(w, http.StatusOK, data, header)
+}
+
+// routeStatic dispatches requests whose named URL parameter matches one of the static
+// path segments in static to the corresponding handler, and all other requests to next.
+// httprouter does not allow a static segment and a named parameter in the same position
+// of a path, e.g. /v1/issues/data-issues alongside /v1/issues/:issue_id.
+func (h *Handler) routeStatic(param string, static map[string]http.HandlerFunc, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		params := httprouter.ParamsFromContext(r.Context())
+		if handler, ok := static[params.ByName(param)]; ok {
+			handler.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	}
+}
@@ -0,0 +1,125 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/emzola/issuetracker/config"
+	"github.com/emzola/issuetracker/internal/controller/issuetracker"
+	"github.com/emzola/issuetracker/pkg/model"
+	"github.com/julienschmidt/httprouter"
+)
+
+func TestRouteStatic(t *testing.T) {
+	h := New(nil, config.App{}, nil)
+	respond := func(body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(body))
+		}
+	}
+	router := httprouter.New()
+	router.HandlerFunc(http.MethodGet, "/v1/issues/:issue_id", h.routeStatic("issue_id", map[string]http.HandlerFunc{
+		"data-issues": respond("static"),
+	}, respond("param")))
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/v1/issues/data-issues", "static"},
+		{"/v1/issues/1", "param"},
+		{"/v1/issues/data", "param"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if got := w.Body.String(); got != tt.want {
+				t.Errorf("routeStatic() served %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeMultiStatus(t *testing.T) {
+	h := New(nil, config.App{}, nil)
+	r := httptest.NewRequest(http.MethodPatch, "/v1/issues", nil)
+	results := []*model.BulkResult{
+		h.bulkResult(r, 1, nil),
+		h.bulkResult(r, 2, issuetracker.ErrNotFound),
+		h.bulkResult(r, 3, issuetracker.ErrEditConflict),
+		h.bulkResult(r, 4, issuetracker.ErrRolledBack),
+	}
+	w := httptest.NewRecorder()
+	if err := h.encodeMultiStatus(w, results); err != nil {
+		t.Fatalf("encodeMultiStatus() error = %v", err)
+	}
+	if w.Code != http.StatusMultiStatus {
+		t.Errorf("encodeMultiStatus() status = %v, want %v", w.Code, http.StatusMultiStatus)
+	}
+	var body struct {
+		Results []model.BulkResult `json:"results"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	want := []int{http.StatusOK, http.StatusNotFound, http.StatusConflict, http.StatusFailedDependency}
+	if len(body.Results) != len(want) {
+		t.Fatalf("encodeMultiStatus() wrote %d results, want %d", len(body.Results), len(want))
+	}
+	for i, result := range body.Results {
+		if result.ID != int64(i+1) || result.Status != want[i] {
+			t.Errorf("result %d = {id: %d, status: %d}, want {id: %d, status: %d}", i, result.ID, result.Status, i+1, want[i])
+		}
+		if (result.Error == "") != (want[i] == http.StatusOK) {
+			t.Errorf("result %d error = %q", i, result.Error)
+		}
+	}
+}
+
+func TestPaginationHeaders(t *testing.T) {
+	h := New(nil, config.App{}, nil)
+	tests := []struct {
+		name      string
+		metadata  model.Metadata
+		wantTotal string
+		wantLink  string
+	}{
+		{
+			name:      "empty",
+			metadata:  model.Metadata{},
+			wantTotal: "0",
+		},
+		{
+			name:      "first page",
+			metadata:  model.CalculateMetadata(45, 1, 20),
+			wantTotal: "45",
+			wantLink:  `</v1/issues?page=1&status=open>; rel="first", </v1/issues?page=2&status=open>; rel="next", </v1/issues?page=3&status=open>; rel="last"`,
+		},
+		{
+			name:      "middle page",
+			metadata:  model.CalculateMetadata(45, 2, 20),
+			wantTotal: "45",
+			wantLink:  `</v1/issues?page=1&status=open>; rel="first", </v1/issues?page=1&status=open>; rel="prev", </v1/issues?page=3&status=open>; rel="next", </v1/issues?page=3&status=open>; rel="last"`,
+		},
+		{
+			name:      "last page",
+			metadata:  model.CalculateMetadata(45, 3, 20),
+			wantTotal: "45",
+			wantLink:  `</v1/issues?page=1&status=open>; rel="first", </v1/issues?page=2&status=open>; rel="prev", </v1/issues?page=3&status=open>; rel="last"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/v1/issues?status=open&page=2", nil)
+			header := h.paginationHeaders(r, tt.metadata)
+			if got := header.Get("X-Total-Count"); got != tt.wantTotal {
+				t.Errorf("paginationHeaders() X-Total-Count = %q, want %q", got, tt.wantTotal)
+			}
+			if got := header.Get("Link"); got != tt.wantLink {
+				t.Errorf("paginationHeaders() Link = %q, want %q", got, tt.wantLink)
+			}
+		})
+	}
+}
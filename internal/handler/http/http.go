@@ -7,11 +7,11 @@ import (
 )
 
 type Handler struct {
-	ctrl   *issuetracker.Controller
-	Config config.App
-	roles  rbac.Roles
+	ctrl       *issuetracker.Controller
+	Config     config.App
+	authorizer *rbac.Authorizer
 }
 
-func New(ctrl *issuetracker.Controller, cfg config.App, roles rbac.Roles) *Handler {
-	return &Handler{ctrl, cfg, roles}
+func New(ctrl *issuetracker.Controller, cfg config.App, authorizer *rbac.Authorizer) *Handler {
+	return &Handler{ctrl, cfg, authorizer}
 }
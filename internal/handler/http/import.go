@@ -0,0 +1,123 @@
+package http
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/emzola/issuetracker/internal/controller/issuetracker"
+	"github.com/emzola/issuetracker/pkg/model"
+	"github.com/emzola/issuetracker/pkg/validator"
+)
+
+// maxImportBytes is the maximum size of an import request, including the uploaded file.
+const maxImportBytes = 1_048_576
+
+// userImportHeader holds the column names of user imports.
+var userImportHeader = []string{"name", "email", "role"}
+
+// ImportUsers godoc
+// @Summary Import users from a CSV file
+// @Description This endpoint creates up to 200 users from a CSV file with name, email and role columns, uploaded as the file field of a multipart form. A header row is optional, and users without a role are members. Imported users are emailed an activation token, and choose their password with a password reset once activated. The outcome for each row is reported with the status it would have received if created on its own. By default no users are created if any row fails, and the other rows are reported with status 424; with atomic=false the valid rows are created regardless
+// @Tags users
+// @Accept  multipart/form-data
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param file formData file true "CSV file of users"
+// @Param atomic query string false "Query string param for whether no users are created if any row fails (true|false)"
+// @Success 207 {object} model.BulkResponse
+// @Failure 400
+// @Failure 403
+// @Failure 422
+// @Failure 500
+// @Router /v1/users/import [post]
+func (h *Handler) importUsers(w http.ResponseWriter, r *http.Request) {
+	v := validator.New()
+	atomic := h.readBool(r.URL.Query(), "atomic", true, v)
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportBytes)
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		var maxBytesError *http.MaxBytesError
+		switch {
+		case errors.As(err, &maxBytesError):
+			h.badRequestResponse(w, r, fmt.Errorf("body must not be larger than %d bytes", maxImportBytes))
+		default:
+			h.badRequestResponse(w, r, errors.New("body must be a multipart form with a CSV file in the file field"))
+		}
+		return
+	}
+	defer file.Close()
+	rows, err := readUserImport(file)
+	if err != nil {
+		h.badRequestResponse(w, r, err)
+		return
+	}
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	users, errs, err := h.ctrl.ImportUsers(ctx, rows, atomic, userFromContext, v)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrNotPermitted):
+			h.notPermittedResponse(w, r)
+		case errors.Is(err, issuetracker.ErrFailedValidation):
+			h.failedValidationResponse(w, r, err)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	results := make([]*model.BulkResult, len(errs))
+	for i, err := range errs {
+		var id int64
+		if users[i] != nil {
+			id = users[i].ID
+		}
+		results[i] = h.bulkResult(r, id, err)
+		results[i].Row = i + 1
+	}
+	err = h.encodeMultiStatus(w, results)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// readUserImport reads the rows of a user import CSV file, skipping the header row if
+// there is one.
+func readUserImport(file io.Reader) ([]model.UserImport, error) {
+	cr := csv.NewReader(file)
+	cr.FieldsPerRecord = len(userImportHeader)
+	cr.TrimLeadingSpace = true
+	rows := []model.UserImport{}
+	for line := 0; ; line++ {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+		if err != nil {
+			var parseError *csv.ParseError
+			switch {
+			case errors.As(err, &parseError):
+				return nil, fmt.Errorf("file contains badly-formed CSV (%v)", parseError)
+			default:
+				return nil, err
+			}
+		}
+		for i := range record {
+			record[i] = strings.TrimSpace(record[i])
+		}
+		if line == 0 {
+			// Spreadsheets often start UTF-8 files with a byte order mark.
+			record[0] = strings.TrimPrefix(record[0], "\ufeff")
+			if strings.EqualFold(strings.Join(record, ","), strings.Join(userImportHeader, ",")) {
+				continue
+			}
+		}
+		rows = append(rows, model.UserImport{Name: record[0], Email: record[1], Role: record[2]})
+	}
+}
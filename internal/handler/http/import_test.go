@@ -0,0 +1,81 @@
+package http
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/emzola/issuetracker/config"
+	"github.com/emzola/issuetracker/internal/controller/issuetracker"
+	"github.com/emzola/issuetracker/pkg/model"
+	"go.uber.org/zap"
+)
+
+func TestReadUserImport(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		want    []model.UserImport
+		wantErr bool
+	}{
+		{"header", "\ufeffName,Email,Role\nAda Lovelace, ada@example.com ,lead\n", []model.UserImport{{Name: "Ada Lovelace", Email: "ada@example.com", Role: "lead"}}, false},
+		{"no header", "Ada Lovelace,ada@example.com,\n\"Hopper, Grace\",grace@example.com,member\n", []model.UserImport{{Name: "Ada Lovelace", Email: "ada@example.com"}, {Name: "Hopper, Grace", Email: "grace@example.com", Role: "member"}}, false},
+		{"empty", "", []model.UserImport{}, false},
+		{"missing column", "Ada Lovelace,ada@example.com\n", nil, true},
+		{"unterminated quote", "\"Ada Lovelace,ada@example.com,lead\n", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readUserImport(strings.NewReader(tt.file))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readUserImport() error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readUserImport() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImportUsersRejectsBadUploads(t *testing.T) {
+	var wg sync.WaitGroup
+	h := New(issuetracker.New(nil, config.App{}, &wg, zap.NewNop()), config.App{}, nil)
+	upload := func(field, file string) (*bytes.Buffer, string) {
+		body := &bytes.Buffer{}
+		mw := multipart.NewWriter(body)
+		fw, err := mw.CreateFormFile(field, "users.csv")
+		if err != nil {
+			t.Fatal(err)
+		}
+		fw.Write([]byte(file))
+		mw.Close()
+		return body, mw.FormDataContentType()
+	}
+	tests := []struct {
+		name  string
+		field string
+		file  string
+	}{
+		{"oversized file", "file", strings.Repeat("Ada Lovelace,ada@example.com,member\n", maxImportBytes/36+1)},
+		{"missing file", "users", "Ada Lovelace,ada@example.com,member\n"},
+		{"malformed csv", "file", "Ada Lovelace,ada@example.com\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, contentType := upload(tt.field, tt.file)
+			r := httptest.NewRequest(http.MethodPost, "/v1/users/import", body)
+			r.Header.Set("Content-Type", contentType)
+			r = h.contextSetUser(r, &model.User{ID: 1, Role: "manager", Activated: true})
+			w := httptest.NewRecorder()
+			h.importUsers(w, r)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("importUsers() status = %d, want %d: %s", w.Code, http.StatusBadRequest, w.Body)
+			}
+		})
+	}
+}
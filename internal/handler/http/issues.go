@@ -2,9 +2,12 @@ package http
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"hash/fnv"
 	"net/http"
-	"time"
+	"net/url"
 
 	"github.com/emzola/issuetracker/internal/controller/issuetracker"
 	"github.com/emzola/issuetracker/pkg/model"
@@ -13,7 +16,7 @@ import (
 
 // CreateIssue godoc
 // @Summary Create a new issue
-// @Description Create a new issue with the request payload
+// @Description Create a new issue with the request payload. An optional comment is added as the issue's first comment, and the issue is only created along with it
 // @Tags issues
 // @Accept  json
 // @Produce json
@@ -27,12 +30,17 @@ import (
 // @Router /v1/issues [post]
 func (h *Handler) createIssue(w http.ResponseWriter, r *http.Request) {
 	var requestPayload struct {
-		Title                string `json:"title"`
-		Description          string `json:"description"`
-		ProjectID            int64  `json:"project_id"`
-		AssignedTo           *int64 `json:"assigned_to"`
-		Priority             string `json:"priority"`
-		TargetResolutionDate string `json:"target_resolution_date"`
+		Title                string   `json:"title"`
+		Description          string   `json:"description"`
+		ProjectID            int64    `json:"project_id"`
+		AssignedTo           *int64   `json:"assigned_to"`
+		Priority             string   `json:"priority"`
+		Type                 string   `json:"type"`
+		TargetResolutionDate string   `json:"target_resolution_date"`
+		MilestoneID          *int64   `json:"milestone_id"`
+		EstimatedHours       *float64 `json:"estimated_hours"`
+		Draft                bool     `json:"draft"`
+		Comment              string   `json:"comment"`
 	}
 	err := h.decodeJSON(w, r, &requestPayload)
 	if err != nil {
@@ -40,9 +48,8 @@ func (h *Handler) createIssue(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	userFromContext := h.contextGetUser(r)
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
-	issue, err := h.ctrl.CreateIssue(ctx, requestPayload.Title, requestPayload.Description, userFromContext.ID, requestPayload.ProjectID, requestPayload.AssignedTo, requestPayload.Priority, requestPayload.TargetResolutionDate, userFromContext.Name, userFromContext.Name)
+	ctx := r.Context()
+	issue, comment, err := h.ctrl.CreateIssue(ctx, requestPayload.Title, requestPayload.Description, userFromContext.ID, requestPayload.ProjectID, requestPayload.AssignedTo, requestPayload.Priority, requestPayload.Type, requestPayload.TargetResolutionDate, requestPayload.MilestoneID, requestPayload.EstimatedHours, requestPayload.Draft, requestPayload.Comment, userFromContext.Name, userFromContext.Name)
 	if err != nil {
 		switch {
 		case errors.Is(err, context.Canceled):
@@ -58,7 +65,11 @@ func (h *Handler) createIssue(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
-	err = h.encodeJSON(w, http.StatusCreated, envelop{"issue": issue}, nil)
+	response := envelop{"issue": issue}
+	if comment != nil {
+		response["comment"] = comment
+	}
+	err = h.encodeJSON(w, http.StatusCreated, response, nil)
 	if err != nil {
 		h.serverErrorResponse(w, r, err)
 	}
@@ -66,12 +77,14 @@ func (h *Handler) createIssue(w http.ResponseWriter, r *http.Request) {
 
 // GetIssue godoc
 // @Summary Get issue by ID
-// @Description This endpoint gets an issue by ID
+// @Description This endpoint gets an issue by ID, along with its links to other issues. The response has an ETag of the issue's version and links, and is 304 Not Modified if it matches If-None-Match
 // @Tags issues
 // @Produce json
 // @Param token header string true "Bearer token"
+// @Param If-None-Match header string false "ETag of a previously fetched version of the issue"
 // @Param issue_id path string true "ID of issue to get"
 // @Success 200 {object} model.Issue
+// @Success 304
 // @Failure 404
 // @Failure 500
 // @Router /v1/issues/{issue_id} [get]
@@ -81,9 +94,9 @@ func (h *Handler) getIssue(w http.ResponseWriter, r *http.Request) {
 		h.notFoundResponse(w, r)
 		return
 	}
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
-	issue, err := h.ctrl.GetIssue(ctx, issueID)
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	issue, err := h.ctrl.GetIssue(ctx, issueID, userFromContext)
 	if err != nil {
 		switch {
 		case errors.Is(err, context.Canceled):
@@ -95,12 +108,45 @@ func (h *Handler) getIssue(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
-	err = h.encodeJSON(w, http.StatusOK, envelop{"issue": issue}, nil)
+	links, err := h.ctrl.GetIssueLinks(ctx, issueID, userFromContext)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	etag, err := issueETag(issue, links)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+		return
+	}
+	if h.notModified(w, r, etag) {
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"issue": issue, "links": links}, nil)
 	if err != nil {
 		h.serverErrorResponse(w, r, err)
 	}
 }
 
+// issueETag returns the ETag of an issue along with its links. Links and logged hours
+// don't change the issue's version, so they are hashed into the ETag too.
+func issueETag(issue *model.Issue, links []*model.IssueLink) (string, error) {
+	js, err := json.Marshal(struct {
+		LoggedHours float64            `json:"logged_hours"`
+		Links       []*model.IssueLink `json:"links"`
+	}{issue.LoggedHours, links})
+	if err != nil {
+		return "", err
+	}
+	hash := fnv.New64a()
+	hash.Write(js)
+	return fmt.Sprintf(`"%d-%x"`, issue.Version, hash.Sum64()), nil
+}
+
 // GetAllIssues godoc
 // @Summary Get all issues
 // @Description This endpoint gets all issues
@@ -108,47 +154,64 @@ func (h *Handler) getIssue(w http.ResponseWriter, r *http.Request) {
 // @Produce json
 // @Param token header string true "Bearer token"
 // @Param title query string false "Query string param for title"
+// @Param q query string false "Query string param for searching titles, descriptions and resolution summaries"
 // @Param reported_date query string false "Query string param for reported_date"
+// @Param reported_from query string false "Query string param for the earliest reported_date (YYYY-MM-DD)"
+// @Param reported_to query string false "Query string param for the latest reported_date (YYYY-MM-DD)"
+// @Param target_from query string false "Query string param for the earliest target_resolution_date (YYYY-MM-DD)"
+// @Param target_to query string false "Query string param for the latest target_resolution_date (YYYY-MM-DD)"
 // @Param project_id query string false "Query string param for project_id"
+// @Param milestone_id query string false "Query string param for milestone_id"
 // @Param assigned_to query string false "Query string param for assigned_to"
+// @Param unassigned query string false "Query string param for only issues without an assignee (true|false)"
+// @Param assignee_name query string false "Query string param for the assignee's name (case-insensitive)"
+// @Param reporter_name query string false "Query string param for the reporter's name (case-insensitive)"
 // @Param status query string false "Query string param for status"
 // @Param priority query string false "Query string param for priority"
+// @Param type query string false "Query string param for type (bug|feature|task|improvement)"
+// @Param label query string false "Query string param for labels (comma separated)"
+// @Param label_match query string false "Query string param for whether issues must have any or all of the labels (any|all)"
+// @Param include_deleted query string false "Query string param for whether to include deleted issues (managers only)"
 // @Param page query string false "Query string param for pagination (min 1)"
 // @Param page_size query string false "Query string param for pagination (max 100)"
-// @Param sort query string false "Sort by asc or desc order. Asc: id, title, reported_date, project_id, assigned_to, status, priority | Desc: -id, -title, -reported_date, -project_id, -assigned_to, -status, -priority"
+// @Param sort query string false "Sort by asc or desc order, by one or more comma separated fields (e.g. -priority,target_resolution_date). Asc: id, title, reported_date, target_resolution_date, project_id, assigned_to, status, priority, rank | Desc: -id, -title, -reported_date, -target_resolution_date, -project_id, -assigned_to, -status, -priority, -rank"
 // @Success 200 {array} model.Issue
 // @Failure 422
 // @Failure 500
 // @Router /v1/issues [get]
 func (h *Handler) getAllIssues(w http.ResponseWriter, r *http.Request) {
+	h.listIssues(w, r, r.URL.Query())
+}
+
+// listIssues responds with the issues matching the issue list parameters in qs, so that
+// saved filters run the same way as the issue list.
+func (h *Handler) listIssues(w http.ResponseWriter, r *http.Request, qs url.Values) {
 	var queryParams struct {
-		Title        string
-		ReportedDate string
-		ProjectID    int64
-		AssignedTo   int64
-		Status       string
-		Priority     string
+		IssueFilters model.IssueFilters
 		Filters      model.Filters
 	}
 	v := validator.New()
-	qs := r.URL.Query()
-	queryParams.Title = h.readString(qs, "title", "")
-	queryParams.ReportedDate = h.readString(qs, "reported_date", "")
-	queryParams.ProjectID = int64(h.readInt(qs, "project_id", 0, v))
-	queryParams.AssignedTo = int64(h.readInt(qs, "assigned_to", 0, v))
-	queryParams.Status = h.readString(qs, "status", "")
-	queryParams.Priority = h.readString(qs, "priority", "")
+	queryParams.IssueFilters = h.readIssueFilters(qs, v)
+	queryParams.IssueFilters.IncludeDeleted = h.readBool(qs, "include_deleted", false, v)
 	queryParams.Filters.Page = h.readInt(qs, "page", 1, v)
 	queryParams.Filters.PageSize = h.readInt(qs, "page_size", 20, v)
-	queryParams.Filters.Sort = h.readString(qs, "sort", "id")
-	queryParams.Filters.SortSafelist = []string{"id", "title", "reported_date", "project_id", "assigned_to", "status", "priority", "-id", "-title", "-reported_date", "-project_id", "-assigned_to", "-status", "-priority"}
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
-	issues, metadata, err := h.ctrl.GetAllIssues(ctx, queryParams.Title, queryParams.ReportedDate, queryParams.ProjectID, queryParams.AssignedTo, queryParams.Status, queryParams.Priority, queryParams.Filters, v)
+	queryParams.Filters.MaxPageSize = h.Config.Pagination.MaxPageSize
+	// Searches return the most relevant issues first, unless sorted otherwise.
+	defaultSort := "id"
+	if queryParams.IssueFilters.Query != "" {
+		defaultSort = "-rank"
+	}
+	queryParams.Filters.Sort = h.readString(qs, "sort", defaultSort)
+	queryParams.Filters.SortSafelist = model.IssueSortSafelist
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	issues, metadata, err := h.ctrl.GetAllIssues(ctx, queryParams.IssueFilters, userFromContext, queryParams.Filters, v)
 	if err != nil {
 		switch {
 		case errors.Is(err, context.Canceled):
 			return
+		case errors.Is(err, issuetracker.ErrNotPermitted):
+			h.notPermittedResponse(w, r)
 		case errors.Is(err, issuetracker.ErrFailedValidation):
 			h.failedValidationResponse(w, r, err)
 		default:
@@ -156,12 +219,36 @@ func (h *Handler) getAllIssues(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
-	err = h.encodeJSON(w, http.StatusOK, envelop{"issues": issues, "metadata": metadata}, nil)
+	err = h.encodeJSON(w, http.StatusOK, envelop{"issues": issues, "metadata": metadata}, h.paginationHeaders(r, metadata))
 	if err != nil {
 		h.serverErrorResponse(w, r, err)
 	}
 }
 
+// readIssueFilters reads the filters shared by issue lists and exports from qs.
+func (h *Handler) readIssueFilters(qs url.Values, v *validator.Validator) model.IssueFilters {
+	return model.IssueFilters{
+		Title:        h.readString(qs, "title", ""),
+		Query:        h.readString(qs, "q", ""),
+		ReportedDate: h.readString(qs, "reported_date", ""),
+		ReportedFrom: h.readString(qs, "reported_from", ""),
+		ReportedTo:   h.readString(qs, "reported_to", ""),
+		TargetFrom:   h.readString(qs, "target_from", ""),
+		TargetTo:     h.readString(qs, "target_to", ""),
+		ProjectID:    int64(h.readInt(qs, "project_id", 0, v)),
+		MilestoneID:  int64(h.readInt(qs, "milestone_id", 0, v)),
+		AssignedTo:   int64(h.readInt(qs, "assigned_to", 0, v)),
+		Unassigned:   h.readBool(qs, "unassigned", false, v),
+		AssigneeName: h.readString(qs, "assignee_name", ""),
+		ReporterName: h.readString(qs, "reporter_name", ""),
+		Status:       h.readString(qs, "status", ""),
+		Priority:     h.readString(qs, "priority", ""),
+		Type:         h.readString(qs, "type", ""),
+		Labels:       h.readCSV(qs, "label", []string{}),
+		LabelMatch:   h.readString(qs, "label_match", "any"),
+	}
+}
+
 // UpdateIssue godoc
 // @Summary Update an issue
 // @Description This endpoint updates an issue
@@ -171,25 +258,32 @@ func (h *Handler) getAllIssues(w http.ResponseWriter, r *http.Request) {
 // @Param token header string true "Bearer token"
 // @Param payload body updateIsssuePayload true "Request payload"
 // @Param issue_id path string true "ID of issue to update"
+// @Param If-Match header string false "ETag of the issue version to update, from a previous response"
+// @Param Prefer header string false "return=minimal to only return changed fields and version"
+// @Param return query string false "Query string param for return (minimal|representation)"
 // @Success 200 {object} model.Issue
 // @Failure 400
 // @Failure 403
 // @Failure 404
 // @Failure 409
+// @Failure 412
 // @Failure 422
 // @Failure 500
 // @Router /v1/issues/{issue_id} [patch]
 func (h *Handler) updateIssue(w http.ResponseWriter, r *http.Request) {
 	var requestPayload struct {
-		Title                *string `json:"title"`
-		Description          *string `json:"description"`
-		AssignedTo           *int64  `json:"assigned_to"`
-		Status               *string `json:"status"`
-		Priority             *string `json:"priority"`
-		TargetResolutionDate *string `json:"target_resolution_date"`
-		Progress             *string `json:"progress"`
-		ActualResolutionDate *string `json:"actual_resolution_date"`
-		ResolutionSummary    *string `json:"resolution_summary"`
+		Title                *string  `json:"title"`
+		Description          *string  `json:"description"`
+		AssignedTo           *int64   `json:"assigned_to"`
+		Status               *string  `json:"status"`
+		Priority             *string  `json:"priority"`
+		Type                 *string  `json:"type"`
+		TargetResolutionDate *string  `json:"target_resolution_date"`
+		Progress             *string  `json:"progress"`
+		ActualResolutionDate *string  `json:"actual_resolution_date"`
+		ResolutionSummary    *string  `json:"resolution_summary"`
+		MilestoneID          *int64   `json:"milestone_id"`
+		EstimatedHours       *float64 `json:"estimated_hours"`
 	}
 	issueID, err := h.readIDParam(r, "issue_id")
 	if err != nil {
@@ -201,10 +295,237 @@ func (h *Handler) updateIssue(w http.ResponseWriter, r *http.Request) {
 		h.badRequestResponse(w, r, err)
 		return
 	}
+	version, err := h.readIfMatch(r)
+	if err != nil {
+		h.badRequestResponse(w, r, err)
+		return
+	}
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	// Fetch the pre-update state so that only the changed fields can be returned
+	// when the client asks for a minimal response.
+	var before *model.Issue
+	if h.wantsMinimalReturn(r) {
+		before, err = h.ctrl.GetIssue(ctx, issueID, userFromContext)
+		if err != nil {
+			switch {
+			case errors.Is(err, context.Canceled):
+				return
+			case errors.Is(err, issuetracker.ErrNotFound):
+				h.notFoundResponse(w, r)
+			default:
+				h.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+	}
+	issue, err := h.ctrl.UpdateIssue(ctx, issueID, version, requestPayload.Title, requestPayload.Description, requestPayload.AssignedTo, requestPayload.Status, requestPayload.Priority, requestPayload.Type, requestPayload.TargetResolutionDate, requestPayload.Progress, requestPayload.ActualResolutionDate, requestPayload.ResolutionSummary, requestPayload.MilestoneID, requestPayload.EstimatedHours, userFromContext)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrNotPermitted):
+			h.notPermittedResponse(w, r)
+		case errors.Is(err, issuetracker.ErrNotFound):
+			h.notFoundResponse(w, r)
+		case errors.Is(err, issuetracker.ErrInvalidRole):
+			h.invalidRoleResponse(w, r)
+		case errors.Is(err, issuetracker.ErrFailedValidation):
+			h.failedValidationResponse(w, r, err)
+		case errors.Is(err, issuetracker.ErrEditConflict):
+			h.editConflictResponse(w, r)
+		case errors.Is(err, issuetracker.ErrPreconditionFailed):
+			h.preconditionFailedResponse(w, r)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	// The ETag is the one GET /v1/issues/:id responds with, so that either can be sent
+	// back in If-None-Match.
+	links, err := h.ctrl.GetIssueLinks(ctx, issueID, userFromContext)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	etag, err := issueETag(issue, links)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+		return
+	}
+	w.Header().Set("ETag", etag)
+	err = h.encodeUpdate(w, r, "issue", before, issue, issue.Version, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// BulkUpdateIssues godoc
+// @Summary Update several issues
+// @Description This endpoint applies the same status, priority and assignee changes to up to 100 issues. The outcome for each issue is reported with the status it would have received if updated on its own. The issues that can be updated are saved together: if saving one of them fails, none are saved, and the others are reported with status 424.
+// @Tags issues
+// @Accept  json
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param payload body bulkUpdateIssuesPayload true "Request payload"
+// @Success 207 {object} model.BulkResponse
+// @Failure 400
+// @Failure 422
+// @Failure 500
+// @Router /v1/issues/bulk [post]
+func (h *Handler) bulkUpdateIssues(w http.ResponseWriter, r *http.Request) {
+	var requestPayload struct {
+		IssueIDs   []int64 `json:"issue_ids"`
+		AssignedTo *int64  `json:"assigned_to"`
+		Status     *string `json:"status"`
+		Priority   *string `json:"priority"`
+	}
+	err := h.decodeJSON(w, r, &requestPayload)
+	if err != nil {
+		h.badRequestResponse(w, r, err)
+		return
+	}
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	errs, err := h.ctrl.BulkUpdateIssues(ctx, requestPayload.IssueIDs, requestPayload.AssignedTo, requestPayload.Status, requestPayload.Priority, userFromContext)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrFailedValidation):
+			h.failedValidationResponse(w, r, err)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	results := make([]*model.BulkResult, len(errs))
+	for i, err := range errs {
+		results[i] = h.bulkResult(r, requestPayload.IssueIDs[i], err)
+	}
+	err = h.encodeMultiStatus(w, results)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// PublishIssue godoc
+// @Summary Publish a draft issue
+// @Description This endpoint publishes a draft issue, making it visible to other users
+// @Tags issues
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param issue_id path string true "ID of issue to publish"
+// @Success 200 {object} model.Issue
+// @Failure 404
+// @Failure 409
+// @Failure 422
+// @Failure 500
+// @Router /v1/issues/{issue_id}/publish [post]
+func (h *Handler) publishIssue(w http.ResponseWriter, r *http.Request) {
+	issueID, err := h.readIDParam(r, "issue_id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	issue, err := h.ctrl.PublishIssue(ctx, issueID, userFromContext)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrNotFound):
+			h.notFoundResponse(w, r)
+		case errors.Is(err, issuetracker.ErrFailedValidation):
+			h.failedValidationResponse(w, r, err)
+		case errors.Is(err, issuetracker.ErrEditConflict):
+			h.editConflictResponse(w, r)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"issue": issue}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// ReopenIssue godoc
+// @Summary Reopen a closed issue
+// @Description This endpoint moves a closed issue back to the first status of its project's workflow, and clears its actual resolution date and resolution summary
+// @Tags issues
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param issue_id path string true "ID of issue to reopen"
+// @Success 200 {object} model.Issue
+// @Failure 403
+// @Failure 404
+// @Failure 409
+// @Failure 422
+// @Failure 500
+// @Router /v1/issues/{issue_id}/reopen [post]
+func (h *Handler) reopenIssue(w http.ResponseWriter, r *http.Request) {
+	issueID, err := h.readIDParam(r, "issue_id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	issue, err := h.ctrl.ReopenIssue(ctx, issueID, userFromContext)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrNotPermitted):
+			h.notPermittedResponse(w, r)
+		case errors.Is(err, issuetracker.ErrNotFound):
+			h.notFoundResponse(w, r)
+		case errors.Is(err, issuetracker.ErrFailedValidation):
+			h.failedValidationResponse(w, r, err)
+		case errors.Is(err, issuetracker.ErrEditConflict):
+			h.editConflictResponse(w, r)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"issue": issue}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// AssignIssueToSelf godoc
+// @Summary Assign an issue to yourself
+// @Description This endpoint assigns an unassigned issue to the authenticated user, who must be a member of the issue's project. The user is notified of the assignment like other assignees
+// @Tags issues
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param issue_id path string true "ID of issue to assign"
+// @Success 200 {object} model.Issue
+// @Failure 403
+// @Failure 404
+// @Failure 409
+// @Failure 422
+// @Failure 500
+// @Router /v1/issues/{issue_id}/assign-self [post]
+func (h *Handler) assignIssueToSelf(w http.ResponseWriter, r *http.Request) {
+	issueID, err := h.readIDParam(r, "issue_id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
 	userFromContext := h.contextGetUser(r)
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
-	issue, err := h.ctrl.UpdateIssue(ctx, issueID, requestPayload.Title, requestPayload.Description, requestPayload.AssignedTo, requestPayload.Status, requestPayload.Priority, requestPayload.TargetResolutionDate, requestPayload.Progress, requestPayload.ActualResolutionDate, requestPayload.ResolutionSummary, userFromContext)
+	ctx := r.Context()
+	issue, err := h.ctrl.AssignIssueToSelf(ctx, issueID, userFromContext)
 	if err != nil {
 		switch {
 		case errors.Is(err, context.Canceled):
@@ -230,9 +551,56 @@ func (h *Handler) updateIssue(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// GetDataIssues godoc
+// @Summary Get issues with inconsistent data
+// @Description This endpoint gets issues with inconsistent data, grouped by category, so that they can be cleaned up. Only managers can access it.
+// @Tags issues
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param page query string false "Query string param for pagination (min 1)"
+// @Param page_size query string false "Query string param for pagination (max 100)"
+// @Param sort query string false "Sort by asc or desc order. Asc: id, title, reported_date, project_id | Desc: -id, -title, -reported_date, -project_id"
+// @Success 200 {object} model.DataIssues
+// @Failure 403
+// @Failure 422
+// @Failure 500
+// @Router /v1/issues/data-issues [get]
+func (h *Handler) getDataIssues(w http.ResponseWriter, r *http.Request) {
+	var queryParams struct {
+		Filters model.Filters
+	}
+	v := validator.New()
+	qs := r.URL.Query()
+	queryParams.Filters.Page = h.readInt(qs, "page", 1, v)
+	queryParams.Filters.PageSize = h.readInt(qs, "page_size", 20, v)
+	queryParams.Filters.MaxPageSize = h.Config.Pagination.MaxPageSize
+	queryParams.Filters.Sort = h.readString(qs, "sort", "id")
+	queryParams.Filters.SortSafelist = []string{"id", "title", "reported_date", "project_id", "-id", "-title", "-reported_date", "-project_id"}
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	dataIssues, err := h.ctrl.GetDataIssues(ctx, userFromContext, queryParams.Filters, v)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrNotPermitted):
+			h.notPermittedResponse(w, r)
+		case errors.Is(err, issuetracker.ErrFailedValidation):
+			h.failedValidationResponse(w, r, err)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"data_issues": dataIssues}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
 // DeleteIssue godoc
 // @Summary Delete an issue
-// @Description This endpoint deletes an issue
+// @Description This endpoint deletes an issue. Deleted issues can be restored by managers
 // @Tags issues
 // @Produce json
 // @Param token header string true "Bearer token"
@@ -247,9 +615,9 @@ func (h *Handler) deleteIssue(w http.ResponseWriter, r *http.Request) {
 		h.notFoundResponse(w, r)
 		return
 	}
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
-	err = h.ctrl.DeleteIssue(ctx, issueID)
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	err = h.ctrl.DeleteIssue(ctx, issueID, userFromContext)
 	if err != nil {
 		switch {
 		case errors.Is(err, context.Canceled):
@@ -266,3 +634,83 @@ func (h *Handler) deleteIssue(w http.ResponseWriter, r *http.Request) {
 		h.serverErrorResponse(w, r, err)
 	}
 }
+
+// RestoreIssue godoc
+// @Summary Restore a deleted issue
+// @Description This endpoint restores a deleted issue. Only managers can restore issues
+// @Tags issues
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param issue_id path string true "ID of issue to restore"
+// @Success 200
+// @Failure 403
+// @Failure 404
+// @Failure 500
+// @Router /v1/issues/{issue_id}/restore [post]
+func (h *Handler) restoreIssue(w http.ResponseWriter, r *http.Request) {
+	issueID, err := h.readIDParam(r, "issue_id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	err = h.ctrl.RestoreIssue(ctx, issueID, userFromContext)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrNotPermitted):
+			h.notPermittedResponse(w, r)
+		case errors.Is(err, issuetracker.ErrNotFound):
+			h.notFoundResponse(w, r)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"message": "issue successfully restored"}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// PurgeIssue godoc
+// @Summary Permanently delete an issue
+// @Description This endpoint permanently deletes an issue, whether or not it has been deleted already. Only managers can purge issues
+// @Tags issues
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param issue_id path string true "ID of issue to purge"
+// @Success 200
+// @Failure 403
+// @Failure 404
+// @Failure 500
+// @Router /v1/issues/{issue_id}/purge [delete]
+func (h *Handler) purgeIssue(w http.ResponseWriter, r *http.Request) {
+	issueID, err := h.readIDParam(r, "issue_id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	err = h.ctrl.PurgeIssue(ctx, issueID, userFromContext)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrNotPermitted):
+			h.notPermittedResponse(w, r)
+		case errors.Is(err, issuetracker.ErrNotFound):
+			h.notFoundResponse(w, r)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"message": "issue successfully purged"}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
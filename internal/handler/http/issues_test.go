@@ -0,0 +1,91 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/emzola/issuetracker/config"
+	"github.com/emzola/issuetracker/internal/controller/issuetracker"
+	"github.com/emzola/issuetracker/pkg/model"
+	"github.com/julienschmidt/httprouter"
+	"go.uber.org/zap"
+)
+
+func TestIssueETag(t *testing.T) {
+	issue := &model.Issue{ID: 1, Version: 2}
+	links := []*model.IssueLink{{ID: 1, SourceID: 1, TargetID: 2, LinkType: "blocks"}}
+	etag, err := issueETag(issue, links)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := issueETag(issue, links); again != etag {
+		t.Errorf("issueETag() = %q, then %q, want the same ETag", etag, again)
+	}
+	if got, _ := issueETag(&model.Issue{ID: 1, Version: 3}, links); got == etag {
+		t.Errorf("issueETag() = %q after a new version, want a different ETag", got)
+	}
+	if got, _ := issueETag(issue, nil); got == etag {
+		t.Errorf("issueETag() = %q after the links changed, want a different ETag", got)
+	}
+	if got, _ := issueETag(&model.Issue{ID: 1, Version: 2, LoggedHours: 1.5}, links); got == etag {
+		t.Errorf("issueETag() = %q after work was logged, want a different ETag", got)
+	}
+}
+
+func TestMalformedIDParam(t *testing.T) {
+	var wg sync.WaitGroup
+	h := New(issuetracker.New(nil, config.App{}, &wg, zap.NewNop()), config.App{}, nil)
+	tests := []struct {
+		method  string
+		path    string
+		param   string
+		handler http.HandlerFunc
+	}{
+		{http.MethodGet, "/v1/issues/", "issue_id", h.getIssue},
+		{http.MethodDelete, "/v1/projects/", "project_id", h.deleteProject},
+	}
+	for _, tt := range tests {
+		for _, id := range []string{"abc", "-1", "0", "1.5"} {
+			t.Run(tt.method+" "+tt.path+id, func(t *testing.T) {
+				r := httptest.NewRequest(tt.method, tt.path+id, nil)
+				r = r.WithContext(context.WithValue(r.Context(), httprouter.ParamsKey, httprouter.Params{{Key: tt.param, Value: id}}))
+				r = h.contextSetUser(r, &model.User{ID: 1, Role: "manager", Activated: true})
+				w := httptest.NewRecorder()
+				tt.handler(w, r)
+				if w.Code != http.StatusNotFound {
+					t.Errorf("status = %v, want %v: %s", w.Code, http.StatusNotFound, w.Body)
+				}
+			})
+		}
+	}
+}
+
+func TestGetAllIssuesUnassignedConflicts(t *testing.T) {
+	var wg sync.WaitGroup
+	h := New(issuetracker.New(nil, config.App{}, &wg, zap.NewNop()), config.App{}, nil)
+	for _, query := range []string{"assigned_to=2&unassigned=true", "assignee_name=Ada&unassigned=true"} {
+		t.Run(query, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/v1/issues?"+query, nil)
+			r = h.contextSetUser(r, &model.User{ID: 1, Role: "manager", Activated: true})
+			w := httptest.NewRecorder()
+			h.getAllIssues(w, r)
+			if w.Code != http.StatusUnprocessableEntity {
+				t.Fatalf("getAllIssues() status = %v, want %v: %s", w.Code, http.StatusUnprocessableEntity, w.Body)
+			}
+			var body struct {
+				Error errorBody `json:"error"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			fields, _ := body.Error.Details["fields"].(map[string]any)
+			if _, ok := fields["unassigned"]; !ok {
+				t.Errorf("getAllIssues() invalid fields = %v, want unassigned", body.Error.Details["fields"])
+			}
+		})
+	}
+}
@@ -3,9 +3,10 @@ package http
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
-	"time"
 
+	"github.com/emzola/issuetracker/internal/controller/issuetracker"
 	"github.com/emzola/issuetracker/pkg/validator"
 )
 
@@ -13,32 +14,91 @@ import (
 // @Summary Get report of issue status for a project
 // @Description This endpoint gets report of issue status for a project
 // @Tags issuesreport
-// @Produce json
+// @Produce json,text/csv
 // @Param token header string true "Bearer token"
 // @Param project_id query string true "Query string param for project_id"
+// @Param from query string false "Query string param for the earliest reported date (YYYY-MM-DD)"
+// @Param to query string false "Query string param for the latest reported date (YYYY-MM-DD)"
+// @Param format query string false "Query string param for the response format (json|csv)"
 // @Success 200 {array} model.IssuesStatus
+// @Failure 422
 // @Failure 500
 // @Router /v1/issuesreport/status [get]
 func (h *Handler) getIssuesStatusReport(w http.ResponseWriter, r *http.Request) {
 	var queryParams struct {
 		ProjectID int64
+		From      string
+		To        string
+		Format    string
 	}
 	v := validator.New()
 	qs := r.URL.Query()
 	queryParams.ProjectID = int64(h.readInt(qs, "project_id", 0, v))
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
-	statuses, err := h.ctrl.GetIssuesStatusReport(ctx, queryParams.ProjectID)
+	queryParams.From = h.readString(qs, "from", "")
+	queryParams.To = h.readString(qs, "to", "")
+	queryParams.Format = h.readString(qs, "format", "json")
+	v.Check(validator.In(queryParams.Format, "json", "csv"), "format", "must be json or csv")
+	ctx := r.Context()
+	statuses, err := h.ctrl.GetIssuesStatusReport(ctx, queryParams.ProjectID, queryParams.From, queryParams.To, v)
 	if err != nil {
 		switch {
 		case errors.Is(err, context.Canceled):
 			return
+		case errors.Is(err, issuetracker.ErrFailedValidation):
+			h.failedValidationResponse(w, r, err)
 		default:
 			h.serverErrorResponse(w, r, err)
 		}
 		return
 	}
-	err = h.encodeJSON(w, http.StatusOK, envelop{"report": statuses}, nil)
+	err = h.encodeReport(w, queryParams.Format, fmt.Sprintf("project-%d-status-report.csv", queryParams.ProjectID), statuses)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// GetIssuesTypeReport godoc
+// @Summary Get report of issue types for a project
+// @Description This endpoint gets report of issue types for a project
+// @Tags issuesreport
+// @Produce json,text/csv
+// @Param token header string true "Bearer token"
+// @Param project_id query string true "Query string param for project_id"
+// @Param from query string false "Query string param for the earliest reported date (YYYY-MM-DD)"
+// @Param to query string false "Query string param for the latest reported date (YYYY-MM-DD)"
+// @Param format query string false "Query string param for the response format (json|csv)"
+// @Success 200 {array} model.IssuesType
+// @Failure 422
+// @Failure 500
+// @Router /v1/issuesreport/type [get]
+func (h *Handler) getIssuesTypeReport(w http.ResponseWriter, r *http.Request) {
+	var queryParams struct {
+		ProjectID int64
+		From      string
+		To        string
+		Format    string
+	}
+	v := validator.New()
+	qs := r.URL.Query()
+	queryParams.ProjectID = int64(h.readInt(qs, "project_id", 0, v))
+	queryParams.From = h.readString(qs, "from", "")
+	queryParams.To = h.readString(qs, "to", "")
+	queryParams.Format = h.readString(qs, "format", "json")
+	v.Check(validator.In(queryParams.Format, "json", "csv"), "format", "must be json or csv")
+	ctx := r.Context()
+	types, err := h.ctrl.GetIssuesTypeReport(ctx, queryParams.ProjectID, queryParams.From, queryParams.To, v)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrFailedValidation):
+			h.failedValidationResponse(w, r, err)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeReport(w, queryParams.Format, fmt.Sprintf("project-%d-type-report.csv", queryParams.ProjectID), types)
 	if err != nil {
 		h.serverErrorResponse(w, r, err)
 	}
@@ -48,32 +108,44 @@ func (h *Handler) getIssuesStatusReport(w http.ResponseWriter, r *http.Request)
 // @Summary Get report of issue assignees for a project
 // @Description This endpoint gets report of issue assignees for a project
 // @Tags issuesreport
-// @Produce json
+// @Produce json,text/csv
 // @Param token header string true "Bearer token"
 // @Param project_id query string true "Query string param for project_id"
+// @Param from query string false "Query string param for the earliest reported date (YYYY-MM-DD)"
+// @Param to query string false "Query string param for the latest reported date (YYYY-MM-DD)"
+// @Param format query string false "Query string param for the response format (json|csv)"
 // @Success 200 {array} model.IssuesAssignee
+// @Failure 422
 // @Failure 500
 // @Router /v1/issuesreport/assignee [get]
 func (h *Handler) getIssuesAssigneeReport(w http.ResponseWriter, r *http.Request) {
 	var queryParams struct {
 		ProjectID int64
+		From      string
+		To        string
+		Format    string
 	}
 	v := validator.New()
 	qs := r.URL.Query()
 	queryParams.ProjectID = int64(h.readInt(qs, "project_id", 0, v))
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
-	assignees, err := h.ctrl.GetIssuesAssigneeReport(ctx, queryParams.ProjectID)
+	queryParams.From = h.readString(qs, "from", "")
+	queryParams.To = h.readString(qs, "to", "")
+	queryParams.Format = h.readString(qs, "format", "json")
+	v.Check(validator.In(queryParams.Format, "json", "csv"), "format", "must be json or csv")
+	ctx := r.Context()
+	assignees, err := h.ctrl.GetIssuesAssigneeReport(ctx, queryParams.ProjectID, queryParams.From, queryParams.To, v)
 	if err != nil {
 		switch {
 		case errors.Is(err, context.Canceled):
 			return
+		case errors.Is(err, issuetracker.ErrFailedValidation):
+			h.failedValidationResponse(w, r, err)
 		default:
 			h.serverErrorResponse(w, r, err)
 		}
 		return
 	}
-	err = h.encodeJSON(w, http.StatusOK, envelop{"report": assignees}, nil)
+	err = h.encodeReport(w, queryParams.Format, fmt.Sprintf("project-%d-assignee-report.csv", queryParams.ProjectID), assignees)
 	if err != nil {
 		h.serverErrorResponse(w, r, err)
 	}
@@ -83,32 +155,44 @@ func (h *Handler) getIssuesAssigneeReport(w http.ResponseWriter, r *http.Request
 // @Summary Get report of issues reporter for a project
 // @Description This endpoint gets report of issues reporter for a project
 // @Tags issuesreport
-// @Produce json
+// @Produce json,text/csv
 // @Param token header string true "Bearer token"
 // @Param project_id query string true "Query string param for project_id"
+// @Param from query string false "Query string param for the earliest reported date (YYYY-MM-DD)"
+// @Param to query string false "Query string param for the latest reported date (YYYY-MM-DD)"
+// @Param format query string false "Query string param for the response format (json|csv)"
 // @Success 200 {array} model.IssuesReporter
+// @Failure 422
 // @Failure 500
 // @Router /v1/issuesreport/reporter [get]
 func (h *Handler) getIssuesReporterReport(w http.ResponseWriter, r *http.Request) {
 	var queryParams struct {
 		ProjectID int64
+		From      string
+		To        string
+		Format    string
 	}
 	v := validator.New()
 	qs := r.URL.Query()
 	queryParams.ProjectID = int64(h.readInt(qs, "project_id", 0, v))
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
-	reporters, err := h.ctrl.GetIssuesReporterReport(ctx, queryParams.ProjectID)
+	queryParams.From = h.readString(qs, "from", "")
+	queryParams.To = h.readString(qs, "to", "")
+	queryParams.Format = h.readString(qs, "format", "json")
+	v.Check(validator.In(queryParams.Format, "json", "csv"), "format", "must be json or csv")
+	ctx := r.Context()
+	reporters, err := h.ctrl.GetIssuesReporterReport(ctx, queryParams.ProjectID, queryParams.From, queryParams.To, v)
 	if err != nil {
 		switch {
 		case errors.Is(err, context.Canceled):
 			return
+		case errors.Is(err, issuetracker.ErrFailedValidation):
+			h.failedValidationResponse(w, r, err)
 		default:
 			h.serverErrorResponse(w, r, err)
 		}
 		return
 	}
-	err = h.encodeJSON(w, http.StatusOK, envelop{"report": reporters}, nil)
+	err = h.encodeReport(w, queryParams.Format, fmt.Sprintf("project-%d-reporter-report.csv", queryParams.ProjectID), reporters)
 	if err != nil {
 		h.serverErrorResponse(w, r, err)
 	}
@@ -118,32 +202,44 @@ func (h *Handler) getIssuesReporterReport(w http.ResponseWriter, r *http.Request
 // @Summary Get report of issues priority level for a project
 // @Description This endpoint gets report of issues priority level for a project
 // @Tags issuesreport
-// @Produce json
+// @Produce json,text/csv
 // @Param token header string true "Bearer token"
 // @Param project_id query string true "Query string param for project_id"
+// @Param from query string false "Query string param for the earliest reported date (YYYY-MM-DD)"
+// @Param to query string false "Query string param for the latest reported date (YYYY-MM-DD)"
+// @Param format query string false "Query string param for the response format (json|csv)"
 // @Success 200 {array} model.IssuesPriority
+// @Failure 422
 // @Failure 500
 // @Router /v1/issuesreport/priority [get]
 func (h *Handler) getIssuesPriorityLevelReport(w http.ResponseWriter, r *http.Request) {
 	var queryParams struct {
 		ProjectID int64
+		From      string
+		To        string
+		Format    string
 	}
 	v := validator.New()
 	qs := r.URL.Query()
 	queryParams.ProjectID = int64(h.readInt(qs, "project_id", 0, v))
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
-	priorityLevels, err := h.ctrl.GetIssuesPriorityLevelReport(ctx, queryParams.ProjectID)
+	queryParams.From = h.readString(qs, "from", "")
+	queryParams.To = h.readString(qs, "to", "")
+	queryParams.Format = h.readString(qs, "format", "json")
+	v.Check(validator.In(queryParams.Format, "json", "csv"), "format", "must be json or csv")
+	ctx := r.Context()
+	priorityLevels, err := h.ctrl.GetIssuesPriorityLevelReport(ctx, queryParams.ProjectID, queryParams.From, queryParams.To, v)
 	if err != nil {
 		switch {
 		case errors.Is(err, context.Canceled):
 			return
+		case errors.Is(err, issuetracker.ErrFailedValidation):
+			h.failedValidationResponse(w, r, err)
 		default:
 			h.serverErrorResponse(w, r, err)
 		}
 		return
 	}
-	err = h.encodeJSON(w, http.StatusOK, envelop{"report": priorityLevels}, nil)
+	err = h.encodeReport(w, queryParams.Format, fmt.Sprintf("project-%d-priority-report.csv", queryParams.ProjectID), priorityLevels)
 	if err != nil {
 		h.serverErrorResponse(w, r, err)
 	}
@@ -153,32 +249,223 @@ func (h *Handler) getIssuesPriorityLevelReport(w http.ResponseWriter, r *http.Re
 // @Summary Get report of issues target date for a project
 // @Description This endpoint gets report of issue target date for a project
 // @Tags issuesreport
-// @Produce json
+// @Produce json,text/csv
 // @Param token header string true "Bearer token"
 // @Param project_id query string true "Query string param for project_id"
+// @Param from query string false "Query string param for the earliest reported date (YYYY-MM-DD)"
+// @Param to query string false "Query string param for the latest reported date (YYYY-MM-DD)"
+// @Param format query string false "Query string param for the response format (json|csv)"
 // @Success 200 {array} model.IssuesTargetDate
+// @Failure 422
 // @Failure 500
 // @Router /v1/issuesreport/date [get]
 func (h *Handler) getIssuesTargetDateReport(w http.ResponseWriter, r *http.Request) {
 	var queryParams struct {
 		ProjectID int64
+		From      string
+		To        string
+		Format    string
+	}
+	v := validator.New()
+	qs := r.URL.Query()
+	queryParams.ProjectID = int64(h.readInt(qs, "project_id", 0, v))
+	queryParams.From = h.readString(qs, "from", "")
+	queryParams.To = h.readString(qs, "to", "")
+	queryParams.Format = h.readString(qs, "format", "json")
+	v.Check(validator.In(queryParams.Format, "json", "csv"), "format", "must be json or csv")
+	ctx := r.Context()
+	targetDates, err := h.ctrl.GetIssuesTargetDateReport(ctx, queryParams.ProjectID, queryParams.From, queryParams.To, v)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrFailedValidation):
+			h.failedValidationResponse(w, r, err)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeReport(w, queryParams.Format, fmt.Sprintf("project-%d-date-report.csv", queryParams.ProjectID), targetDates)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// GetIssuesEffortReport godoc
+// @Summary Get report of estimated and logged hours for a project
+// @Description This endpoint gets the total hours estimated for and logged against a project's issues, for each assignee
+// @Tags issuesreport
+// @Produce json,text/csv
+// @Param token header string true "Bearer token"
+// @Param project_id query string true "Query string param for project_id"
+// @Param from query string false "Query string param for the earliest reported date (YYYY-MM-DD)"
+// @Param to query string false "Query string param for the latest reported date (YYYY-MM-DD)"
+// @Param format query string false "Query string param for the response format (json|csv)"
+// @Success 200 {array} model.IssuesEffort
+// @Failure 422
+// @Failure 500
+// @Router /v1/issuesreport/effort [get]
+func (h *Handler) getIssuesEffortReport(w http.ResponseWriter, r *http.Request) {
+	var queryParams struct {
+		ProjectID int64
+		From      string
+		To        string
+		Format    string
 	}
 	v := validator.New()
 	qs := r.URL.Query()
 	queryParams.ProjectID = int64(h.readInt(qs, "project_id", 0, v))
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
-	targetDates, err := h.ctrl.GetIssuesTargetDateReport(ctx, queryParams.ProjectID)
+	queryParams.From = h.readString(qs, "from", "")
+	queryParams.To = h.readString(qs, "to", "")
+	queryParams.Format = h.readString(qs, "format", "json")
+	v.Check(validator.In(queryParams.Format, "json", "csv"), "format", "must be json or csv")
+	ctx := r.Context()
+	efforts, err := h.ctrl.GetIssuesEffortReport(ctx, queryParams.ProjectID, queryParams.From, queryParams.To, v)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrFailedValidation):
+			h.failedValidationResponse(w, r, err)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeReport(w, queryParams.Format, fmt.Sprintf("project-%d-effort-report.csv", queryParams.ProjectID), efforts)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// GetIssuesMilestoneReport godoc
+// @Summary Get report of milestone completion for a project
+// @Description This endpoint gets the number of issues planned into each of a project's milestones, how many of them are closed and the percentage of closed issues
+// @Tags issuesreport
+// @Produce json,text/csv
+// @Param token header string true "Bearer token"
+// @Param project_id query string true "Query string param for project_id"
+// @Param from query string false "Query string param for the earliest reported date (YYYY-MM-DD)"
+// @Param to query string false "Query string param for the latest reported date (YYYY-MM-DD)"
+// @Param format query string false "Query string param for the response format (json|csv)"
+// @Success 200 {array} model.IssuesByMilestone
+// @Failure 422
+// @Failure 500
+// @Router /v1/issuesreport/milestones [get]
+func (h *Handler) getIssuesMilestoneReport(w http.ResponseWriter, r *http.Request) {
+	var queryParams struct {
+		ProjectID int64
+		From      string
+		To        string
+		Format    string
+	}
+	v := validator.New()
+	qs := r.URL.Query()
+	queryParams.ProjectID = int64(h.readInt(qs, "project_id", 0, v))
+	queryParams.From = h.readString(qs, "from", "")
+	queryParams.To = h.readString(qs, "to", "")
+	queryParams.Format = h.readString(qs, "format", "json")
+	v.Check(validator.In(queryParams.Format, "json", "csv"), "format", "must be json or csv")
+	ctx := r.Context()
+	milestones, err := h.ctrl.GetIssuesMilestoneReport(ctx, queryParams.ProjectID, queryParams.From, queryParams.To, v)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrFailedValidation):
+			h.failedValidationResponse(w, r, err)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeReport(w, queryParams.Format, fmt.Sprintf("project-%d-milestone-report.csv", queryParams.ProjectID), milestones)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// GetIssuesBurndownReport godoc
+// @Summary Get burndown report of issues for a project
+// @Description This endpoint gets the number of a project's issues opened and closed on each day, based on reported date and actual resolution date, and the number left open at the end of each day. The range defaults to the last 30 days
+// @Tags issuesreport
+// @Produce json,text/csv
+// @Param token header string true "Bearer token"
+// @Param project_id query string true "Query string param for project_id"
+// @Param from query string false "Query string param for start date (YYYY-MM-DD)"
+// @Param to query string false "Query string param for end date (YYYY-MM-DD)"
+// @Param format query string false "Query string param for the response format (json|csv)"
+// @Success 200 {array} model.IssuesBurndown
+// @Failure 422
+// @Failure 500
+// @Router /v1/issuesreport/burndown [get]
+func (h *Handler) getIssuesBurndownReport(w http.ResponseWriter, r *http.Request) {
+	var queryParams struct {
+		ProjectID int64
+		From      string
+		To        string
+		Format    string
+	}
+	v := validator.New()
+	qs := r.URL.Query()
+	queryParams.ProjectID = int64(h.readInt(qs, "project_id", 0, v))
+	queryParams.From = h.readString(qs, "from", "")
+	queryParams.To = h.readString(qs, "to", "")
+	queryParams.Format = h.readString(qs, "format", "json")
+	v.Check(validator.In(queryParams.Format, "json", "csv"), "format", "must be json or csv")
+	ctx := r.Context()
+	burndown, err := h.ctrl.GetIssuesBurndownReport(ctx, queryParams.ProjectID, queryParams.From, queryParams.To, v)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrFailedValidation):
+			h.failedValidationResponse(w, r, err)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeReport(w, queryParams.Format, fmt.Sprintf("project-%d-burndown-report.csv", queryParams.ProjectID), burndown)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// GetIssuesByProjectReport godoc
+// @Summary Get report of issues grouped by project
+// @Description This endpoint gets open, closed and overdue issue counts for every project the user can access
+// @Tags issuesreport
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param lead_only query string false "Query string param for lead_only (true|false)"
+// @Success 200 {array} model.IssuesByProject
+// @Failure 422
+// @Failure 500
+// @Router /v1/issuesreport/by-project [get]
+func (h *Handler) getIssuesByProjectReport(w http.ResponseWriter, r *http.Request) {
+	var queryParams struct {
+		LeadOnly bool
+	}
+	v := validator.New()
+	qs := r.URL.Query()
+	queryParams.LeadOnly = h.readBool(qs, "lead_only", false, v)
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	projects, err := h.ctrl.GetIssuesByProjectReport(ctx, userFromContext, queryParams.LeadOnly, v)
 	if err != nil {
 		switch {
 		case errors.Is(err, context.Canceled):
 			return
+		case errors.Is(err, issuetracker.ErrFailedValidation):
+			h.failedValidationResponse(w, r, err)
 		default:
 			h.serverErrorResponse(w, r, err)
 		}
 		return
 	}
-	err = h.encodeJSON(w, http.StatusOK, envelop{"report": targetDates}, nil)
+	err = h.encodeJSON(w, http.StatusOK, envelop{"report": projects}, nil)
 	if err != nil {
 		h.serverErrorResponse(w, r, err)
 	}
@@ -0,0 +1,72 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/emzola/issuetracker/config"
+	"github.com/emzola/issuetracker/internal/controller/issuetracker"
+	"github.com/emzola/issuetracker/internal/repository/postgres"
+	"github.com/emzola/issuetracker/pkg/model"
+	"go.uber.org/zap"
+)
+
+// reportRepository serves fixed reports. Methods that are not overridden are not
+// expected to be called.
+type reportRepository struct {
+	*postgres.Repository
+}
+
+func (r *reportRepository) GetIssuesAssigneeReport(ctx context.Context, projectID int64, from, to time.Time) ([]*model.IssuesAssignee, error) {
+	return []*model.IssuesAssignee{
+		{AssigneeID: 1, AssigneeName: "Lovelace, Ada", IssuesAssigned: 3},
+		{AssigneeID: 2, AssigneeName: "@admin", IssuesAssigned: 1},
+	}, nil
+}
+
+func (r *reportRepository) GetIssuesTargetDateReport(ctx context.Context, projectID int64, from, to time.Time) ([]*model.IssuesTargetDate, error) {
+	return []*model.IssuesTargetDate{
+		{Title: "Login fails", TargetResolutionDate: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+	}, nil
+}
+
+func TestIssuesReportFormat(t *testing.T) {
+	var wg sync.WaitGroup
+	h := New(issuetracker.New(&reportRepository{}, config.App{}, &wg, zap.NewNop()), config.App{}, nil)
+	tests := []struct {
+		name            string
+		handler         http.HandlerFunc
+		query           string
+		wantStatus      int
+		wantContentType string
+		wantBody        string
+	}{
+		{"assignee csv", h.getIssuesAssigneeReport, "project_id=1&format=csv", http.StatusOK, "text/csv; charset=utf-8", "assignee_id,assignee_name,issues_assigned\n1,\"Lovelace, Ada\",3\n2,'@admin,1\n"},
+		{"date csv", h.getIssuesTargetDateReport, "project_id=1&format=csv", http.StatusOK, "text/csv; charset=utf-8", "issue_title,target_resolution_date\nLogin fails,2024-02-01\n"},
+		{"default json", h.getIssuesTargetDateReport, "project_id=1", http.StatusOK, "application/json", ""},
+		{"unknown format", h.getIssuesAssigneeReport, "project_id=1&format=xml", http.StatusUnprocessableEntity, "application/json", ""},
+		{"date range", h.getIssuesAssigneeReport, "project_id=1&from=2024-01-01&to=2024-01-31", http.StatusOK, "application/json", ""},
+		{"malformed date", h.getIssuesAssigneeReport, "project_id=1&from=01/01/2024", http.StatusUnprocessableEntity, "application/json", ""},
+		{"reversed date range", h.getIssuesTargetDateReport, "project_id=1&from=2024-02-01&to=2024-01-01", http.StatusUnprocessableEntity, "application/json", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/v1/issuesreport?"+tt.query, nil)
+			w := httptest.NewRecorder()
+			tt.handler(w, r)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if got := w.Header().Get("Content-Type"); got != tt.wantContentType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.wantContentType)
+			}
+			if tt.wantBody != "" && w.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/emzola/issuetracker/internal/controller/issuetracker"
+)
+
+// AddLabelToIssue godoc
+// @Summary Add a label to an issue
+// @Description Add a label to an issue with the request payload. The label is created if it doesn't exist yet. Label names are lowercased
+// @Tags issues
+// @Accept  json
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param issue_id path string true "ID of issue to label"
+// @Param payload body addLabelPayload true "Request payload"
+// @Success 200 {array} model.Label
+// @Failure 400
+// @Failure 403
+// @Failure 404
+// @Failure 422
+// @Failure 500
+// @Router /v1/issues/{issue_id}/labels [post]
+func (h *Handler) addLabelToIssue(w http.ResponseWriter, r *http.Request) {
+	var requestPayload struct {
+		Name string `json:"name"`
+	}
+	issueID, err := h.readIDParam(r, "issue_id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	err = h.decodeJSON(w, r, &requestPayload)
+	if err != nil {
+		h.badRequestResponse(w, r, err)
+		return
+	}
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	labels, err := h.ctrl.AddLabelToIssue(ctx, issueID, requestPayload.Name, userFromContext)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrNotPermitted):
+			h.notPermittedResponse(w, r)
+		case errors.Is(err, issuetracker.ErrNotFound):
+			h.notFoundResponse(w, r)
+		case errors.Is(err, issuetracker.ErrFailedValidation):
+			h.failedValidationResponse(w, r, err)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"labels": labels}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// RemoveLabelFromIssue godoc
+// @Summary Remove a label from an issue
+// @Description This endpoint removes a label from an issue
+// @Tags issues
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param issue_id path string true "ID of issue to remove label from"
+// @Param label_id path string true "ID of label to remove"
+// @Success 200
+// @Failure 403
+// @Failure 404
+// @Failure 500
+// @Router /v1/issues/{issue_id}/labels/{label_id} [delete]
+func (h *Handler) removeLabelFromIssue(w http.ResponseWriter, r *http.Request) {
+	issueID, err := h.readIDParam(r, "issue_id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	labelID, err := h.readIDParam(r, "label_id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	err = h.ctrl.RemoveLabelFromIssue(ctx, issueID, labelID, userFromContext)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrNotPermitted):
+			h.notPermittedResponse(w, r)
+		case errors.Is(err, issuetracker.ErrNotFound):
+			h.notFoundResponse(w, r)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"message": "label successfully removed"}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
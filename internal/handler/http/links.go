@@ -0,0 +1,147 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/emzola/issuetracker/internal/controller/issuetracker"
+)
+
+// CreateIssueLink godoc
+// @Summary Link an issue to another issue
+// @Description Link an issue to another issue with the request payload. Link types are blocks, blocked_by, duplicates and relates_to. Links of type blocks, blocked_by and relates_to also create the reciprocal link from the other issue
+// @Tags issues
+// @Accept  json
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param issue_id path string true "ID of issue to link from"
+// @Param payload body createIssueLinkPayload true "Request payload"
+// @Success 201 {object} model.IssueLink
+// @Failure 400
+// @Failure 403
+// @Failure 404
+// @Failure 422
+// @Failure 500
+// @Router /v1/issues/{issue_id}/links [post]
+func (h *Handler) createIssueLink(w http.ResponseWriter, r *http.Request) {
+	var requestPayload struct {
+		TargetID int64  `json:"target_id"`
+		LinkType string `json:"link_type"`
+	}
+	issueID, err := h.readIDParam(r, "issue_id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	err = h.decodeJSON(w, r, &requestPayload)
+	if err != nil {
+		h.badRequestResponse(w, r, err)
+		return
+	}
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	link, err := h.ctrl.CreateIssueLink(ctx, issueID, requestPayload.TargetID, requestPayload.LinkType, userFromContext)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrNotPermitted):
+			h.notPermittedResponse(w, r)
+		case errors.Is(err, issuetracker.ErrNotFound):
+			h.notFoundResponse(w, r)
+		case errors.Is(err, issuetracker.ErrFailedValidation):
+			h.failedValidationResponse(w, r, err)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusCreated, envelop{"link": link}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// DeleteIssueLink godoc
+// @Summary Remove a link from an issue
+// @Description This endpoint removes a link from an issue, along with its reciprocal link
+// @Tags issues
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param issue_id path string true "ID of issue to remove link from"
+// @Param link_id path string true "ID of link to remove"
+// @Success 200
+// @Failure 403
+// @Failure 404
+// @Failure 500
+// @Router /v1/issues/{issue_id}/links/{link_id} [delete]
+func (h *Handler) deleteIssueLink(w http.ResponseWriter, r *http.Request) {
+	issueID, err := h.readIDParam(r, "issue_id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	linkID, err := h.readIDParam(r, "link_id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	err = h.ctrl.DeleteIssueLink(ctx, issueID, linkID, userFromContext)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrNotPermitted):
+			h.notPermittedResponse(w, r)
+		case errors.Is(err, issuetracker.ErrNotFound):
+			h.notFoundResponse(w, r)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"message": "link successfully removed"}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// GetDuplicateChain godoc
+// @Summary Get the duplicate chain of an issue
+// @Description This endpoint follows the duplicates links from an issue to the canonical issue it ended up merged into, and gets the canonical issue followed by every issue merged into it. Links that lead back to an issue already followed are reported as a cycle without a canonical issue
+// @Tags issues
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param issue_id path string true "ID of issue to get duplicate chain"
+// @Success 200 {object} model.DuplicateChain
+// @Failure 404
+// @Failure 500
+// @Router /v1/issues/{issue_id}/duplicate-chain [get]
+func (h *Handler) getDuplicateChain(w http.ResponseWriter, r *http.Request) {
+	issueID, err := h.readIDParam(r, "issue_id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	chain, err := h.ctrl.GetDuplicateChain(ctx, issueID, userFromContext)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrNotFound):
+			h.notFoundResponse(w, r)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"duplicate_chain": chain}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
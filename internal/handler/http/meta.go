@@ -0,0 +1,68 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+
+	"github.com/emzola/issuetracker/internal/controller/issuetracker"
+	"github.com/emzola/issuetracker/pkg/model"
+	"github.com/emzola/issuetracker/pkg/validator"
+)
+
+// GetVocabularies godoc
+// @Summary Get API vocabularies
+// @Description This endpoint gets the allowed values for enumerated fields such as issue statuses, priorities, types and roles. Issue statuses are the states of the project's workflow if project_id is given, and of the default workflow otherwise
+// @Tags meta
+// @Produce json
+// @Param project_id query string false "Query string param for the project whose workflow states are the issue statuses"
+// @Success 200 {object} map[string][]string
+// @Failure 404
+// @Failure 422
+// @Failure 500
+// @Router /v1/meta/vocabularies [get]
+func (h *Handler) getVocabularies(w http.ResponseWriter, r *http.Request) {
+	v := validator.New()
+	projectID := int64(h.readInt(r.URL.Query(), "project_id", 0, v))
+	if !v.Valid() {
+		h.failedValidationResponse(w, r, &issuetracker.ValidationError{Fields: v.Errors})
+		return
+	}
+	workflow := model.DefaultWorkflow(0)
+	if projectID != 0 {
+		var err error
+		workflow, err = h.ctrl.GetProjectWorkflow(r.Context(), projectID)
+		if err != nil {
+			switch {
+			case errors.Is(err, context.Canceled):
+				return
+			case errors.Is(err, issuetracker.ErrNotFound):
+				h.notFoundResponse(w, r)
+			default:
+				h.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+	}
+	statuses := make([]string, len(workflow.States))
+	for i, state := range workflow.States {
+		statuses[i] = state.Name
+	}
+	authorizerRoles := h.authorizer.Roles()
+	roles := make([]string, 0, len(authorizerRoles))
+	for role := range authorizerRoles {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+	vocabularies := map[string][]string{
+		"issue_statuses":   statuses,
+		"issue_priorities": model.IssuePriorities,
+		"issue_types":      model.IssueTypes,
+		"roles":            roles,
+	}
+	err := h.encodeJSON(w, http.StatusOK, envelop{"vocabularies": vocabularies}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,73 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/emzola/issuetracker/config"
+	"github.com/emzola/issuetracker/internal/controller/issuetracker"
+	"github.com/emzola/issuetracker/internal/repository"
+	"github.com/emzola/issuetracker/internal/repository/postgres"
+	"github.com/emzola/issuetracker/pkg/model"
+	"github.com/emzola/issuetracker/pkg/rbac"
+	"go.uber.org/zap"
+)
+
+// workflowRepository serves project 1, which has a custom workflow.
+type workflowRepository struct {
+	*postgres.Repository
+}
+
+func (r *workflowRepository) GetProject(ctx context.Context, id int64) (*model.Project, error) {
+	if id != 1 {
+		return nil, repository.ErrNotFound
+	}
+	return &model.Project{ID: 1, Name: "Issue Tracker"}, nil
+}
+
+func (r *workflowRepository) GetProjectWorkflow(ctx context.Context, projectID int64) ([]model.WorkflowState, error) {
+	return []model.WorkflowState{{Name: "todo", Category: "open"}, {Name: "doing", Category: "open"}, {Name: "done", Category: "closed"}}, nil
+}
+
+func TestGetVocabulariesIssueStatuses(t *testing.T) {
+	var wg sync.WaitGroup
+	h := New(issuetracker.New(&workflowRepository{}, config.App{}, &wg, zap.NewNop()), config.App{}, rbac.New(rbac.Roles{"member": {}}))
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		want       []string
+	}{
+		{"default workflow", "", http.StatusOK, []string{"open", "in progress", "resolved", "closed"}},
+		{"project workflow", "?project_id=1", http.StatusOK, []string{"todo", "doing", "done"}},
+		{"missing project", "?project_id=2", http.StatusNotFound, nil},
+		{"malformed project", "?project_id=abc", http.StatusUnprocessableEntity, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/v1/meta/vocabularies"+tt.query, nil)
+			w := httptest.NewRecorder()
+			h.getVocabularies(w, r)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("getVocabularies() status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var body struct {
+				Vocabularies map[string][]string `json:"vocabularies"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			if got := body.Vocabularies["issue_statuses"]; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getVocabularies() issue statuses = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// metrics holds the Prometheus request metrics.
+type metrics struct {
+	requests  *prometheus.CounterVec
+	responses *prometheus.CounterVec
+	duration  *prometheus.HistogramVec
+}
+
+func newMetrics(reg prometheus.Registerer) *metrics {
+	m := &metrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "Total number of HTTP requests received.",
+		}, []string{"method", "route"}),
+		responses: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_responses_total",
+			Help: "Total number of HTTP responses sent, by status code.",
+		}, []string{"code"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "Duration of HTTP requests.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+	}
+	reg.MustRegister(m.requests, m.responses, m.duration)
+	return m
+}
+
+// statusRecorder records the status code written to a response.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (rec *statusRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *statusRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	return rec.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the underlying response writer, for http.ResponseController.
+func (rec *statusRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+// instrument records request metrics. Requests are labeled with the route pattern
+// they match in routers, e.g. /v1/issues/:issue_id, rather than their path, so that
+// the number of label values stays bounded.
+func (h *Handler) instrument(m *metrics, routers []*httprouter.Router, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+		defer func() {
+			// Panics are recovered further up the chain with a server error response.
+			err := recover()
+			if err != nil {
+				rec.status = http.StatusInternalServerError
+				defer panic(err)
+			}
+			if rec.status == 0 {
+				rec.status = http.StatusOK
+			}
+			route := routePattern(routers, r.Method, r.URL.Path)
+			m.requests.WithLabelValues(r.Method, route).Inc()
+			m.responses.WithLabelValues(strconv.Itoa(rec.status)).Inc()
+			m.duration.WithLabelValues(r.Method, route).Observe(time.Since(start).Seconds())
+		}()
+		next.ServeHTTP(rec, r)
+	})
+}
+
+// routePattern returns the pattern of the route matching the request method and path,
+// or "unmatched" if there is none.
+func routePattern(routers []*httprouter.Router, method, path string) string {
+	for _, router := range routers {
+		handle, params, _ := router.Lookup(method, path)
+		if handle == nil {
+			continue
+		}
+		// Substitute the parameter names for their values, which appear in the path
+		// in the same order as the parameters.
+		segments := strings.Split(path, "/")
+		i := 0
+		for _, param := range params {
+			// Catch-all parameters hold the rest of the path.
+			if strings.HasPrefix(param.Value, "/") {
+				return strings.TrimSuffix(path, param.Value) + "/*" + param.Key
+			}
+			for ; i < len(segments); i++ {
+				if segments[i] == param.Value {
+					segments[i] = ":" + param.Key
+					break
+				}
+			}
+		}
+		return strings.Join(segments, "/")
+	}
+	return "unmatched"
+}
@@ -0,0 +1,65 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/emzola/issuetracker/config"
+	"github.com/emzola/issuetracker/internal/controller/issuetracker"
+	"github.com/julienschmidt/httprouter"
+	"go.uber.org/zap"
+)
+
+func TestRoutePattern(t *testing.T) {
+	noop := func(w http.ResponseWriter, r *http.Request) {}
+	router := httprouter.New()
+	router.HandlerFunc(http.MethodGet, "/v1/issues", noop)
+	router.HandlerFunc(http.MethodGet, "/v1/issues/:issue_id", noop)
+	router.HandlerFunc(http.MethodDelete, "/v1/issues/:issue_id/labels/:label_id", noop)
+	router.HandlerFunc(http.MethodGet, "/docs/*any", noop)
+	tests := []struct {
+		method string
+		path   string
+		want   string
+	}{
+		{http.MethodGet, "/v1/issues", "/v1/issues"},
+		{http.MethodGet, "/v1/issues/12", "/v1/issues/:issue_id"},
+		{http.MethodDelete, "/v1/issues/12/labels/12", "/v1/issues/:issue_id/labels/:label_id"},
+		{http.MethodGet, "/docs/index.html", "/docs/*any"},
+		{http.MethodGet, "/v1/unknown", "unmatched"},
+		{http.MethodPost, "/v1/issues/12", "unmatched"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			if got := routePattern([]*httprouter.Router{router}, tt.method, tt.path); got != tt.want {
+				t.Errorf("routePattern() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	var cfg config.App
+	h := New(issuetracker.New(nil, cfg, nil, zap.NewNop()), cfg, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	routes := h.Routes(ctx)
+	routes.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/healthcheck", nil))
+	w := httptest.NewRecorder()
+	routes.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/metrics", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /v1/metrics status = %d, want %d", w.Code, http.StatusOK)
+	}
+	for _, want := range []string{
+		`http_requests_total{method="GET",route="/v1/healthcheck"} 1`,
+		`http_responses_total{code="200"} 1`,
+		`http_request_duration_seconds_count{method="GET",route="/v1/healthcheck"} 1`,
+	} {
+		if !strings.Contains(w.Body.String(), want) {
+			t.Errorf("GET /v1/metrics body does not contain %q", want)
+		}
+	}
+}
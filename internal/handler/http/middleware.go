@@ -2,10 +2,12 @@ package http
 
 import (
 	"context"
+	"crypto/rand"
 	"errors"
 	"fmt"
 	"net"
 	"net/http"
+	"regexp"
 	"strconv"
 	"strings"
 	"sync"
@@ -14,7 +16,6 @@ import (
 	"github.com/emzola/issuetracker/internal/controller/issuetracker"
 
 	"github.com/emzola/issuetracker/pkg/model"
-	"github.com/emzola/issuetracker/pkg/rbac"
 	"github.com/pascaldekloe/jwt"
 	"golang.org/x/time/rate"
 )
@@ -56,14 +57,32 @@ func (h *Handler) authenticate(next http.Handler) http.Handler {
 			h.invalidAuthenticationTokenResponse(w, r)
 			return
 		}
+		// Check that the token carries a JWT ID and hasn't been revoked by logging out.
+		if claims.ID == "" {
+			h.invalidAuthenticationTokenResponse(w, r)
+			return
+		}
+		ctx := r.Context()
+		revoked, err := h.ctrl.IsAuthenticationTokenRevoked(ctx, claims.ID)
+		if err != nil {
+			switch {
+			case errors.Is(err, context.Canceled):
+				return
+			default:
+				h.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+		if revoked {
+			h.invalidAuthenticationTokenResponse(w, r)
+			return
+		}
 		// Extract userID from claims subject and convert it from string to int64.
 		userID, err := strconv.ParseInt(claims.Subject, 10, 64)
 		if err != nil {
 			h.serverErrorResponse(w, r, err)
 			return
 		}
-		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-		defer cancel()
 		// Lookup the user record from the database.
 		user, err := h.ctrl.GetUserByID(ctx, userID)
 		if err != nil {
@@ -77,13 +96,46 @@ func (h *Handler) authenticate(next http.Handler) http.Handler {
 			}
 			return
 		}
-		// Add the user record to the request context and continue as normal.
+		// Check that the token was issued for the user's current token epoch. The
+		// epoch is bumped whenever the user's role or password changes.
+		epoch, ok := claims.Number("epoch")
+		if !ok || int(epoch) != user.TokenEpoch {
+			h.invalidAuthenticationTokenResponse(w, r)
+			return
+		}
+		// Add the user record and the token's claims to the request context and
+		// continue as normal.
 		r = h.contextSetUser(r, user)
-		// Check RBAC permission for authenticated user.
-		rbacAuthorizer := rbac.New(h.roles)
-		asset := strings.Split(strings.Trim(r.URL.Path, "/"), "/")[1]
-		action := rbacAuthorizer.ActionFromMethod(r.Method)
-		if !rbacAuthorizer.HasPermission(user, action, asset) {
+		r = h.contextSetClaims(r, claims)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// authorize checks the RBAC permission of authenticated users for the requested asset.
+// The asset is the first path segment after the API version, e.g. "issues" for
+// /v1/issues/12. Paths without an asset don't match any route and are not found.
+func (h *Handler) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := h.contextGetUser(r)
+		if user.IsAnonymous() {
+			next.ServeHTTP(w, r)
+			return
+		}
+		segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+		if len(segments) < 2 {
+			h.notFoundResponse(w, r)
+			return
+		}
+		asset := segments[1]
+		action := h.authorizer.ActionFromMethod(r.Method)
+		// Sub-resources such as /v1/issues/:issue_id/watchers or /v1/users/password can
+		// be granted separately from their parent resource, as "issues/watchers" and
+		// "users/password".
+		if sub := subResource(segments); sub != "" && h.authorizer.HasPermission(user, action, asset+"/"+sub) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !h.authorizer.HasPermission(user, action, asset) {
 			h.notPermittedResponse(w, r)
 			return
 		}
@@ -91,6 +143,21 @@ func (h *Handler) authenticate(next http.Handler) http.Handler {
 	})
 }
 
+// subResource returns the sub-resource named in the path segments, if any. It is the
+// segment after the resource, or the one after the resource ID if there is an ID.
+func subResource(segments []string) string {
+	if len(segments) < 3 {
+		return ""
+	}
+	if _, err := strconv.ParseInt(segments[2], 10, 64); err != nil {
+		return segments[2]
+	}
+	if len(segments) > 3 {
+		return segments[3]
+	}
+	return ""
+}
+
 // requireAuthenticatedUser checks that a user is not anonymous.
 func (h *Handler) requireAuthenticatedUser(next http.HandlerFunc) http.HandlerFunc {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -116,6 +183,73 @@ func (h *Handler) requireActivatedUser(next http.HandlerFunc) http.HandlerFunc {
 	return h.requireAuthenticatedUser(fn)
 }
 
+// requestIDRX matches the incoming request IDs that are reused. Anything else, such as
+// IDs long or odd enough to pollute the logs, is replaced with a new ID.
+var requestIDRX = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// requestID tags each request with a correlation ID, for tracing it across services. The
+// ID is taken from the X-Request-Id header, or generated if there is none, and is echoed
+// back in the response's X-Request-Id header, logs and error responses.
+func (h *Handler) requestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get("X-Request-Id")
+		if !requestIDRX.MatchString(requestID) {
+			var err error
+			requestID, err = newRequestID()
+			if err != nil {
+				h.serverErrorResponse(w, r, err)
+				return
+			}
+		}
+		w.Header().Set("X-Request-Id", requestID)
+		next.ServeHTTP(w, h.contextSetRequestID(r, requestID))
+	})
+}
+
+// newRequestID returns a random (version 4) UUID.
+func newRequestID() (string, error) {
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
+
+// defaultRequestTimeout is how long requests are given when no timeout is configured.
+const defaultRequestTimeout = 5 * time.Second
+
+// longRequestTimeouts holds the timeouts of requests that take longer than others, by
+// path: bulk updates check and update each issue in turn, and exports aren't paginated.
+var longRequestTimeouts = map[string]time.Duration{
+	"/v1/issues/bulk":   30 * time.Second,
+	"/v1/issues/export": 30 * time.Second,
+}
+
+// timeout cancels the context of requests that take longer than the configured request
+// timeout. Handlers that give up because of it get a timeout response, unless they have
+// already responded. Requests cancelled by clients disconnecting are unaffected.
+func (h *Handler) timeout(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		timeout, ok := longRequestTimeouts[r.URL.Path]
+		if !ok {
+			timeout = h.Config.RequestTimeout
+		}
+		if timeout == 0 {
+			timeout = defaultRequestTimeout
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r.WithContext(ctx))
+		if rec.status == 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			h.timeoutResponse(w, r)
+		}
+	})
+}
+
 // recoverPanic recovers from app-wide panics.
 func (h *Handler) recoverPanic(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -129,8 +263,41 @@ func (h *Handler) recoverPanic(next http.Handler) http.Handler {
 	})
 }
 
-// rateLimit implements IP-based rate limiting.
-func (h *Handler) rateLimit(next http.Handler) http.Handler {
+// rateLimitIP implements rate limiting per IP address with the authenticated limits. It
+// runs ahead of authentication, so that requests carrying invalid tokens are throttled
+// too and can't make unlimited token and user lookups. The background cleanup of stale
+// clients stops once ctx is done.
+func (h *Handler) rateLimitIP(ctx context.Context, next http.Handler) http.Handler {
+	return h.limit(ctx, next, func(r *http.Request, settings model.Settings) (string, rate.Limit, int, error) {
+		ip, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			return "", 0, 0, err
+		}
+		return "ip:" + ip, rate.Limit(settings.LimiterRps), settings.LimiterBurst, nil
+	})
+}
+
+// rateLimit implements rate limiting. Authenticated users are limited per user ID,
+// while anonymous requests are limited per IP address with stricter limits. The
+// background cleanup of stale clients stops once ctx is done.
+func (h *Handler) rateLimit(ctx context.Context, next http.Handler) http.Handler {
+	return h.limit(ctx, next, func(r *http.Request, settings model.Settings) (string, rate.Limit, int, error) {
+		user := h.contextGetUser(r)
+		if user.IsAnonymous() {
+			ip, _, err := net.SplitHostPort(r.RemoteAddr)
+			if err != nil {
+				return "", 0, 0, err
+			}
+			return "ip:" + ip, rate.Limit(settings.LimiterAnonymousRps), settings.LimiterAnonymousBurst, nil
+		}
+		return "user:" + strconv.FormatInt(user.ID, 10), rate.Limit(settings.LimiterRps), settings.LimiterBurst, nil
+	})
+}
+
+// limit rate limits requests with a limiter per client, while the limiter is enabled.
+// bucket returns the key identifying the client a request comes from, and the limits
+// that apply to it.
+func (h *Handler) limit(ctx context.Context, next http.Handler, bucket func(r *http.Request, settings model.Settings) (string, rate.Limit, int, error)) http.Handler {
 	// Define a client struct to hold rate limiter and last seen time.
 	type client struct {
 		limiter  *rate.Limiter
@@ -141,18 +308,24 @@ func (h *Handler) rateLimit(next http.Handler) http.Handler {
 		clients = make(map[string]*client)
 	)
 	// Launch a background goroutine which removes old entries from the clients maps
-	// once every minute.
+	// once every minute until ctx is done.
 	go func() {
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
 		for {
-			time.Sleep(time.Minute)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
 			// Lock the mutex to prevent any rate limiter checks from happening while
 			// the cleanup is taking place.
 			mu.Lock()
 			// Loop through all clients. If they haven't been seen within the last three
 			// minutes, delete the corresponding entry from the map.
-			for ip, client := range clients {
+			for key, client := range clients {
 				if time.Since(client.lastSeen) > 3*time.Minute {
-					delete(clients, ip)
+					delete(clients, key)
 				}
 			}
 			// Unlock the mutex when the cleanup is complete.
@@ -160,22 +333,28 @@ func (h *Handler) rateLimit(next http.Handler) http.Handler {
 		}
 	}()
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if h.Config.Limiter.Enabled {
-			ip, _, err := net.SplitHostPort(r.RemoteAddr)
+		// Limiter settings can change at runtime, so read them on every request.
+		settings := h.ctrl.Settings()
+		if settings.LimiterEnabled {
+			key, limit, burst, err := bucket(r, settings)
 			if err != nil {
 				h.serverErrorResponse(w, r, err)
 				return
 			}
 			mu.Lock()
-			if _, exists := clients[ip]; !exists {
+			if _, exists := clients[key]; !exists {
 				// Create and add a new client struct to the map if it doesn't already exist.
-				clients[ip] = &client{limiter: rate.NewLimiter(rate.Limit(h.Config.Limiter.Rps), h.Config.Limiter.Burst)}
+				clients[key] = &client{limiter: rate.NewLimiter(limit, burst)}
+			} else if clients[key].limiter.Limit() != limit || clients[key].limiter.Burst() != burst {
+				// Apply limits changed since the client's limiter was created.
+				clients[key].limiter.SetLimit(limit)
+				clients[key].limiter.SetBurst(burst)
 			}
 			// Update the last seen time for the client.
-			clients[ip].lastSeen = time.Now()
-			// Call the Allow() method on the rate limiter for the current IP address. If
+			clients[key].lastSeen = time.Now()
+			// Call the Allow() method on the rate limiter for the current client. If
 			// the request isn't allowed, unlock the mutex and send a 429 Too Many Requests.
-			if !clients[ip].limiter.Allow() {
+			if !clients[key].limiter.Allow() {
 				mu.Unlock()
 				h.rateLimitExceededResponse(w, r)
 				return
@@ -196,9 +375,10 @@ func (h *Handler) enableCORS(next http.Handler) http.Handler {
 			for i := range h.Config.Cors.TrustedOrigins {
 				if origin == h.Config.Cors.TrustedOrigins[i] {
 					w.Header().Set("Access-Control-Allow-Origin", origin)
+					w.Header().Set("Access-Control-Expose-Headers", "ETag, Link, X-Request-Id, X-Total-Count")
 					if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
 						w.Header().Set("Access-Control-Allow-Methods", "OPTIONS, PUT, PATCH, DELETE")
-						w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
+						w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type, If-Match, If-None-Match, X-Request-Id")
 						w.WriteHeader(http.StatusOK)
 						return
 					}
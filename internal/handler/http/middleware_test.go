@@ -0,0 +1,296 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emzola/issuetracker/config"
+	"github.com/emzola/issuetracker/internal/controller/issuetracker"
+	"github.com/emzola/issuetracker/pkg/model"
+	"github.com/emzola/issuetracker/pkg/rbac"
+	"go.uber.org/zap"
+)
+
+func TestRateLimit(t *testing.T) {
+	var cfg config.App
+	cfg.Limiter.Enabled = true
+	cfg.Limiter.Rps = 1
+	cfg.Limiter.Burst = 4
+	cfg.Limiter.AnonymousRps = 1
+	cfg.Limiter.AnonymousBurst = 2
+	h := New(issuetracker.New(nil, cfg, nil, zap.NewNop()), cfg, nil)
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	tests := []struct {
+		name string
+		user *model.User
+		want int
+	}{
+		{"anonymous", model.AnonymousUser, 2},
+		{"authenticated", &model.User{ID: 1}, 4},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limited := h.rateLimit(ctx, next)
+			got := 0
+			for i := 0; i < 10; i++ {
+				r := httptest.NewRequest(http.MethodGet, "/v1/issues", nil)
+				r = h.contextSetUser(r, tt.user)
+				w := httptest.NewRecorder()
+				limited.ServeHTTP(w, r)
+				if w.Code == http.StatusOK {
+					got++
+				}
+			}
+			if got != tt.want {
+				t.Errorf("rateLimit() allowed %v requests, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateLimitIPBeforeAuthentication(t *testing.T) {
+	var cfg config.App
+	cfg.Limiter.Enabled = true
+	cfg.Limiter.Rps = 1
+	cfg.Limiter.Burst = 4
+	cfg.Jwt.Secret = "secret"
+	h := New(issuetracker.New(nil, cfg, nil, zap.NewNop()), cfg, nil)
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	limited := h.rateLimitIP(ctx, h.authenticate(h.rateLimit(ctx, next)))
+	codes := map[int]int{}
+	for i := 0; i < 10; i++ {
+		r := httptest.NewRequest(http.MethodGet, "/v1/issues", nil)
+		r.Header.Set("Authorization", "Bearer not-a-token")
+		w := httptest.NewRecorder()
+		limited.ServeHTTP(w, r)
+		codes[w.Code]++
+	}
+	// Requests rejected by authentication still count against the client's IP address.
+	if codes[http.StatusUnauthorized] != 4 || codes[http.StatusTooManyRequests] != 6 {
+		t.Errorf("rateLimitIP() responded with %v, want 4 401s and 6 429s", codes)
+	}
+}
+
+func TestRateLimitCleanupStops(t *testing.T) {
+	h := New(nil, config.App{}, nil)
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	want := runtime.NumGoroutine()
+	ctx, cancel := context.WithCancel(context.Background())
+	for i := 0; i < 10; i++ {
+		h.rateLimit(ctx, next)
+	}
+	cancel()
+	got := runtime.NumGoroutine()
+	for deadline := time.Now().Add(time.Second); got > want && time.Now().Before(deadline); got = runtime.NumGoroutine() {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got > want {
+		t.Errorf("rateLimit() left %v goroutines running after cancel, want %v", got, want)
+	}
+}
+
+func TestSubResource(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/v1/issues", ""},
+		{"/v1/issues/12", ""},
+		{"/v1/issues/12/watchers", "watchers"},
+		{"/v1/users/me", "me"},
+		{"/v1/users/12/velocity", "velocity"},
+		{"/v1/users/12/involvement", "involvement"},
+		{"/v1/users/password", "password"},
+		{"/v1/users/password/change", "password"},
+		{"/v1/users/email/confirm", "email"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			segments := strings.Split(strings.Trim(tt.path, "/"), "/")
+			if got := subResource(segments); got != tt.want {
+				t.Errorf("subResource(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthorizeShortPaths(t *testing.T) {
+	h := New(nil, config.App{}, rbac.New(rbac.Roles{
+		"member": {"read": {"issues"}},
+	}))
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	tests := []struct {
+		path string
+		want int
+	}{
+		{"/", http.StatusNotFound},
+		{"/v1", http.StatusNotFound},
+		{"/healthz", http.StatusNotFound},
+		{"/v1/issues", http.StatusOK},
+		{"/v1/projects", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			r = h.contextSetUser(r, &model.User{ID: 1, Role: "member", Activated: true})
+			w := httptest.NewRecorder()
+			h.authorize(next).ServeHTTP(w, r)
+			if w.Code != tt.want {
+				t.Errorf("authorize() status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestHealthCheckBypassesMiddleware(t *testing.T) {
+	var cfg config.App
+	cfg.Env = "test"
+	cfg.Version = "1.2.3"
+	h := New(issuetracker.New(nil, cfg, nil, zap.NewNop()), cfg, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	routes := h.Routes(ctx)
+	tests := []struct {
+		path string
+		want int
+	}{
+		{"/v1/healthcheck", http.StatusOK},
+		{"/v1/issues", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			r.Header.Set("Authorization", "Bearer invalid")
+			w := httptest.NewRecorder()
+			routes.ServeHTTP(w, r)
+			if w.Code != tt.want {
+				t.Errorf("GET %s status = %d, want %d", tt.path, w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	var cfg config.App
+	h := New(issuetracker.New(nil, cfg, nil, zap.NewNop()), cfg, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	routes := h.Routes(ctx)
+	uuidRX := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	tests := []struct {
+		name      string
+		requestID string
+		wantSame  bool
+	}{
+		{"incoming", "req-42.frontend", true},
+		{"absent", "", false},
+		{"invalid", "req 42\nforged log line", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The invalid token is rejected by authenticate, so the ID must have made it
+			// through the middleware chain to reach the error response.
+			r := httptest.NewRequest(http.MethodGet, "/v1/issues", nil)
+			r.Header.Set("Authorization", "Bearer invalid")
+			if tt.requestID != "" {
+				r.Header.Set("X-Request-Id", tt.requestID)
+			}
+			w := httptest.NewRecorder()
+			routes.ServeHTTP(w, r)
+			got := w.Header().Get("X-Request-Id")
+			if tt.wantSame && got != tt.requestID {
+				t.Errorf("X-Request-Id = %q, want %q", got, tt.requestID)
+			}
+			if !tt.wantSame && !uuidRX.MatchString(got) {
+				t.Errorf("X-Request-Id = %q, want a generated UUID", got)
+			}
+			var body struct {
+				Error struct {
+					RequestID string `json:"request_id"`
+				} `json:"error"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Error.RequestID != got {
+				t.Errorf("error request_id = %q, want %q", body.Error.RequestID, got)
+			}
+		})
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	var cfg config.App
+	cfg.RequestTimeout = 10 * time.Millisecond
+	h := New(nil, cfg, nil)
+	tests := []struct {
+		name       string
+		path       string
+		handler    http.HandlerFunc
+		wantStatus int
+	}{
+		{
+			name: "gives up silently",
+			path: "/v1/issues",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				<-r.Context().Done()
+			},
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name: "server error",
+			path: "/v1/issues",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				<-r.Context().Done()
+				h.serverErrorResponse(w, r, fmt.Errorf("querying issues: %w", r.Context().Err()))
+			},
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name: "in time",
+			path: "/v1/issues",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			},
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name: "long request",
+			path: "/v1/issues/export",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if deadline, ok := r.Context().Deadline(); !ok || time.Until(deadline) < time.Second {
+					t.Errorf("deadline = %v, want the long request timeout", deadline)
+				}
+				w.WriteHeader(http.StatusOK)
+			},
+			wantStatus: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.timeout(tt.handler).ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,442 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/emzola/issuetracker/internal/controller/issuetracker"
+	"github.com/emzola/issuetracker/pkg/model"
+	"github.com/emzola/issuetracker/pkg/validator"
+)
+
+// CreateMilestone godoc
+// @Summary Create a milestone in a project
+// @Description Create a milestone, such as a sprint, in a project with the request payload. Leads can only create milestones in projects assigned to them
+// @Tags milestones
+// @Accept  json
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param project_id path string true "ID of project to create milestone in"
+// @Param payload body createMilestonePayload true "Request payload"
+// @Success 201 {object} model.Milestone
+// @Failure 400
+// @Failure 403
+// @Failure 404
+// @Failure 422
+// @Failure 500
+// @Router /v1/projects/{project_id}/milestones [post]
+func (h *Handler) createMilestone(w http.ResponseWriter, r *http.Request) {
+	var requestPayload struct {
+		Title   string `json:"title"`
+		DueDate string `json:"due_date"`
+	}
+	projectID, err := h.readIDParam(r, "project_id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	err = h.decodeJSON(w, r, &requestPayload)
+	if err != nil {
+		h.badRequestResponse(w, r, err)
+		return
+	}
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	milestone, err := h.ctrl.CreateMilestone(ctx, projectID, requestPayload.Title, requestPayload.DueDate, userFromContext)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrNotPermitted):
+			h.notPermittedResponse(w, r)
+		case errors.Is(err, issuetracker.ErrNotFound):
+			h.notFoundResponse(w, r)
+		case errors.Is(err, issuetracker.ErrFailedValidation):
+			h.failedValidationResponse(w, r, err)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusCreated, envelop{"milestone": milestone}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// GetAllMilestones godoc
+// @Summary Get all milestones of a project
+// @Description This endpoint gets all milestones of a project
+// @Tags milestones
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param project_id path string true "ID of project to get milestones"
+// @Param status query string false "Query string param for status (open|closed)"
+// @Param page query string false "Query string param for pagination (min 1)"
+// @Param page_size query string false "Query string param for pagination (max 100)"
+// @Param sort query string false "Sort by asc or desc order. Asc: id, title, due_date | Desc: -id, -title, -due_date"
+// @Success 200 {array} model.Milestone
+// @Failure 404
+// @Failure 422
+// @Failure 500
+// @Router /v1/projects/{project_id}/milestones [get]
+func (h *Handler) getAllMilestones(w http.ResponseWriter, r *http.Request) {
+	var queryParams struct {
+		Status  string
+		Filters model.Filters
+	}
+	projectID, err := h.readIDParam(r, "project_id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	v := validator.New()
+	qs := r.URL.Query()
+	queryParams.Status = h.readString(qs, "status", "")
+	queryParams.Filters.Page = h.readInt(qs, "page", 1, v)
+	queryParams.Filters.PageSize = h.readInt(qs, "page_size", 20, v)
+	queryParams.Filters.MaxPageSize = h.Config.Pagination.MaxPageSize
+	queryParams.Filters.Sort = h.readString(qs, "sort", "due_date")
+	queryParams.Filters.SortSafelist = []string{"id", "title", "due_date", "-id", "-title", "-due_date"}
+	ctx := r.Context()
+	milestones, metadata, err := h.ctrl.GetAllMilestones(ctx, projectID, queryParams.Status, queryParams.Filters, v)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrNotFound):
+			h.notFoundResponse(w, r)
+		case errors.Is(err, issuetracker.ErrFailedValidation):
+			h.failedValidationResponse(w, r, err)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"milestones": milestones, "metadata": metadata}, h.paginationHeaders(r, metadata))
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// GetMilestone godoc
+// @Summary Get milestone by ID
+// @Description This endpoint gets one of a project's milestones by ID
+// @Tags milestones
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param project_id path string true "ID of project the milestone belongs to"
+// @Param milestone_id path string true "ID of milestone to get"
+// @Success 200 {object} model.Milestone
+// @Failure 404
+// @Failure 500
+// @Router /v1/projects/{project_id}/milestones/{milestone_id} [get]
+func (h *Handler) getMilestone(w http.ResponseWriter, r *http.Request) {
+	projectID, err := h.readIDParam(r, "project_id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	milestoneID, err := h.readIDParam(r, "milestone_id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	ctx := r.Context()
+	milestone, err := h.ctrl.GetMilestone(ctx, projectID, milestoneID)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrNotFound):
+			h.notFoundResponse(w, r)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"milestone": milestone}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// UpdateMilestone godoc
+// @Summary Update a milestone
+// @Description This endpoint updates one of a project's milestones. Leads can only update milestones of projects assigned to them
+// @Tags milestones
+// @Accept  json
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param project_id path string true "ID of project the milestone belongs to"
+// @Param milestone_id path string true "ID of milestone to update"
+// @Param payload body updateMilestonePayload true "Request payload"
+// @Success 200 {object} model.Milestone
+// @Failure 400
+// @Failure 403
+// @Failure 404
+// @Failure 409
+// @Failure 422
+// @Failure 500
+// @Router /v1/projects/{project_id}/milestones/{milestone_id} [patch]
+func (h *Handler) updateMilestone(w http.ResponseWriter, r *http.Request) {
+	var requestPayload struct {
+		Title   *string `json:"title"`
+		DueDate *string `json:"due_date"`
+		Status  *string `json:"status"`
+	}
+	projectID, err := h.readIDParam(r, "project_id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	milestoneID, err := h.readIDParam(r, "milestone_id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	err = h.decodeJSON(w, r, &requestPayload)
+	if err != nil {
+		h.badRequestResponse(w, r, err)
+		return
+	}
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	milestone, err := h.ctrl.UpdateMilestone(ctx, projectID, milestoneID, requestPayload.Title, requestPayload.DueDate, requestPayload.Status, userFromContext)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrNotPermitted):
+			h.notPermittedResponse(w, r)
+		case errors.Is(err, issuetracker.ErrNotFound):
+			h.notFoundResponse(w, r)
+		case errors.Is(err, issuetracker.ErrFailedValidation):
+			h.failedValidationResponse(w, r, err)
+		case errors.Is(err, issuetracker.ErrEditConflict):
+			h.editConflictResponse(w, r)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"milestone": milestone}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// DeleteMilestone godoc
+// @Summary Delete a milestone
+// @Description This endpoint deletes one of a project's milestones. Its issues are kept, without a milestone. Leads can only delete milestones of projects assigned to them
+// @Tags milestones
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param project_id path string true "ID of project the milestone belongs to"
+// @Param milestone_id path string true "ID of milestone to delete"
+// @Success 200
+// @Failure 403
+// @Failure 404
+// @Failure 500
+// @Router /v1/projects/{project_id}/milestones/{milestone_id} [delete]
+func (h *Handler) deleteMilestone(w http.ResponseWriter, r *http.Request) {
+	projectID, err := h.readIDParam(r, "project_id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	milestoneID, err := h.readIDParam(r, "milestone_id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	err = h.ctrl.DeleteMilestone(ctx, projectID, milestoneID, userFromContext)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrNotPermitted):
+			h.notPermittedResponse(w, r)
+		case errors.Is(err, issuetracker.ErrNotFound):
+			h.notFoundResponse(w, r)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"message": "milestone successfully deleted"}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// GetMilestoneIssues godoc
+// @Summary Get the issues of a milestone
+// @Description This endpoint gets the issues planned into a milestone
+// @Tags milestones
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param milestone_id path string true "ID of milestone to get issues"
+// @Param status query string false "Query string param for status"
+// @Param page query string false "Query string param for pagination (min 1)"
+// @Param page_size query string false "Query string param for pagination (max 100)"
+// @Param sort query string false "Sort by asc or desc order. Asc: id, title, reported_date, assigned_to, status, priority | Desc: -id, -title, -reported_date, -assigned_to, -status, -priority"
+// @Success 200 {array} model.Issue
+// @Failure 404
+// @Failure 422
+// @Failure 500
+// @Router /v1/milestones/{milestone_id}/issues [get]
+func (h *Handler) getMilestoneIssues(w http.ResponseWriter, r *http.Request) {
+	var queryParams struct {
+		Status  string
+		Filters model.Filters
+	}
+	milestoneID, err := h.readIDParam(r, "milestone_id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	v := validator.New()
+	qs := r.URL.Query()
+	queryParams.Status = h.readString(qs, "status", "")
+	queryParams.Filters.Page = h.readInt(qs, "page", 1, v)
+	queryParams.Filters.PageSize = h.readInt(qs, "page_size", 20, v)
+	queryParams.Filters.MaxPageSize = h.Config.Pagination.MaxPageSize
+	queryParams.Filters.Sort = h.readString(qs, "sort", "id")
+	queryParams.Filters.SortSafelist = []string{"id", "title", "reported_date", "assigned_to", "status", "priority", "-id", "-title", "-reported_date", "-assigned_to", "-status", "-priority"}
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	issues, metadata, err := h.ctrl.GetMilestoneIssues(ctx, milestoneID, queryParams.Status, userFromContext, queryParams.Filters, v)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrNotFound):
+			h.notFoundResponse(w, r)
+		case errors.Is(err, issuetracker.ErrFailedValidation):
+			h.failedValidationResponse(w, r, err)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"issues": issues, "metadata": metadata}, h.paginationHeaders(r, metadata))
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// GetProjectBacklog godoc
+// @Summary Get the backlog of a project
+// @Description This endpoint gets the issues of a project that aren't planned into a milestone and aren't closed. For projects without milestones, that is every issue still open
+// @Tags milestones
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param project_id path string true "ID of project to get backlog"
+// @Param page query string false "Query string param for pagination (min 1)"
+// @Param page_size query string false "Query string param for pagination (max 100)"
+// @Param sort query string false "Sort by asc or desc order. Asc: id, title, reported_date, assigned_to, status, priority, target_resolution_date | Desc: -id, -title, -reported_date, -assigned_to, -status, -priority, -target_resolution_date"
+// @Success 200 {array} model.Issue
+// @Failure 404
+// @Failure 422
+// @Failure 500
+// @Router /v1/projects/{project_id}/backlog [get]
+func (h *Handler) getProjectBacklog(w http.ResponseWriter, r *http.Request) {
+	var queryParams struct {
+		Filters model.Filters
+	}
+	projectID, err := h.readIDParam(r, "project_id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	v := validator.New()
+	qs := r.URL.Query()
+	queryParams.Filters.Page = h.readInt(qs, "page", 1, v)
+	queryParams.Filters.PageSize = h.readInt(qs, "page_size", 20, v)
+	queryParams.Filters.MaxPageSize = h.Config.Pagination.MaxPageSize
+	queryParams.Filters.Sort = h.readString(qs, "sort", "id")
+	queryParams.Filters.SortSafelist = []string{"id", "title", "reported_date", "assigned_to", "status", "priority", "target_resolution_date", "-id", "-title", "-reported_date", "-assigned_to", "-status", "-priority", "-target_resolution_date"}
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	issues, metadata, err := h.ctrl.GetProjectBacklog(ctx, projectID, userFromContext, queryParams.Filters, v)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrNotFound):
+			h.notFoundResponse(w, r)
+		case errors.Is(err, issuetracker.ErrFailedValidation):
+			h.failedValidationResponse(w, r, err)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"issues": issues, "metadata": metadata}, h.paginationHeaders(r, metadata))
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// GetMilestoneAtRiskIssues godoc
+// @Summary Get the at-risk issues of a milestone
+// @Description This endpoint gets the issues of a milestone that aren't closed and are targeted for resolution after the milestone is due, with how many days past the milestone they are targeted
+// @Tags milestones
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param project_id path string true "ID of project"
+// @Param milestone_id path string true "ID of milestone to get at-risk issues"
+// @Param page query string false "Query string param for pagination (min 1)"
+// @Param page_size query string false "Query string param for pagination (max 100)"
+// @Param sort query string false "Sort by asc or desc order. Asc: id, title, reported_date, assigned_to, status, priority, target_resolution_date | Desc: -id, -title, -reported_date, -assigned_to, -status, -priority, -target_resolution_date"
+// @Success 200 {array} model.AtRiskIssue
+// @Failure 404
+// @Failure 422
+// @Failure 500
+// @Router /v1/projects/{project_id}/milestones/{milestone_id}/at-risk [get]
+func (h *Handler) getMilestoneAtRiskIssues(w http.ResponseWriter, r *http.Request) {
+	var queryParams struct {
+		Filters model.Filters
+	}
+	projectID, err := h.readIDParam(r, "project_id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	milestoneID, err := h.readIDParam(r, "milestone_id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	v := validator.New()
+	qs := r.URL.Query()
+	queryParams.Filters.Page = h.readInt(qs, "page", 1, v)
+	queryParams.Filters.PageSize = h.readInt(qs, "page_size", 20, v)
+	queryParams.Filters.MaxPageSize = h.Config.Pagination.MaxPageSize
+	queryParams.Filters.Sort = h.readString(qs, "sort", "-target_resolution_date")
+	queryParams.Filters.SortSafelist = []string{"id", "title", "reported_date", "assigned_to", "status", "priority", "target_resolution_date", "-id", "-title", "-reported_date", "-assigned_to", "-status", "-priority", "-target_resolution_date"}
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	issues, metadata, err := h.ctrl.GetMilestoneAtRiskIssues(ctx, projectID, milestoneID, userFromContext, queryParams.Filters, v)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrNotFound):
+			h.notFoundResponse(w, r)
+		case errors.Is(err, issuetracker.ErrFailedValidation):
+			h.failedValidationResponse(w, r, err)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"issues": issues, "metadata": metadata}, h.paginationHeaders(r, metadata))
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
-	"time"
 
 	"github.com/emzola/issuetracker/internal/controller/issuetracker"
 
@@ -29,21 +28,23 @@ import (
 // @Router /v1/projects [post]
 func (h *Handler) createProject(w http.ResponseWriter, r *http.Request) {
 	var requestPayload struct {
-		Name          string `json:"name"`
-		Description   string `json:"description"`
-		AssignedTo    *int64 `json:"assigned_to"`
-		StartDate     string `json:"start_date"`
-		TargetEndDate string `json:"target_end_date"`
+		Key                  string   `json:"key"`
+		Name                 string   `json:"name"`
+		Description          string   `json:"description"`
+		AssignedTo           *int64   `json:"assigned_to"`
+		StartDate            string   `json:"start_date"`
+		TargetEndDate        string   `json:"target_end_date"`
+		AutoCloseDays        *int     `json:"auto_close_days"`
+		NotificationChannels []string `json:"notification_channels"`
 	}
 	err := h.decodeJSON(w, r, &requestPayload)
 	if err != nil {
 		h.badRequestResponse(w, r, err)
 		return
 	}
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
+	ctx := r.Context()
 	userFromContext := h.contextGetUser(r)
-	project, err := h.ctrl.CreateProject(ctx, requestPayload.Name, requestPayload.Description, requestPayload.AssignedTo, requestPayload.StartDate, requestPayload.TargetEndDate, userFromContext.Name, userFromContext.Name)
+	project, err := h.ctrl.CreateProject(ctx, requestPayload.Key, requestPayload.Name, requestPayload.Description, requestPayload.AssignedTo, requestPayload.StartDate, requestPayload.TargetEndDate, requestPayload.AutoCloseDays, requestPayload.NotificationChannels, userFromContext.Name, userFromContext.Name)
 	if err != nil {
 		switch {
 		case errors.Is(err, context.Canceled):
@@ -69,12 +70,14 @@ func (h *Handler) createProject(w http.ResponseWriter, r *http.Request) {
 
 // GetProject godoc
 // @Summary Get project by ID
-// @Description This endpoint gets a project by ID
+// @Description This endpoint gets a project by ID. The response has an ETag of the project's version, and is 304 Not Modified if it matches If-None-Match
 // @Tags projects
 // @Produce json
 // @Param token header string true "Bearer token"
+// @Param If-None-Match header string false "ETag of a previously fetched version of the project"
 // @Param project_id path string true "ID of project to get"
 // @Success 200 {object} model.Project
+// @Success 304
 // @Failure 404
 // @Failure 500
 // @Router /v1/projects/{project_id} [get]
@@ -84,8 +87,7 @@ func (h *Handler) getProject(w http.ResponseWriter, r *http.Request) {
 		h.notFoundResponse(w, r)
 		return
 	}
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
+	ctx := r.Context()
 	project, err := h.ctrl.GetProject(ctx, projectID)
 	if err != nil {
 		switch {
@@ -98,6 +100,9 @@ func (h *Handler) getProject(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
+	if h.notModified(w, r, versionETag(project.Version)) {
+		return
+	}
 	err = h.encodeJSON(w, http.StatusOK, envelop{"project": project}, nil)
 	if err != nil {
 		h.serverErrorResponse(w, r, err)
@@ -116,6 +121,7 @@ func (h *Handler) getProject(w http.ResponseWriter, r *http.Request) {
 // @Param target_end_date query string false "Query string param for target_end_date"
 // @Param actual_end_date query string false "Query string param for actual_end_date"
 // @Param created_by query string false "Query string param for created_by"
+// @Param include_archived query string false "Query string param for including archived projects (true|false)"
 // @Param page query string false "Query string param for pagination (min 1)"
 // @Param page_size query string false "Query string param for pagination (max 100)"
 // @Param sort query string false "Sort by asc or desc order. Asc: id, name, assigned_to, start_date, target_end_date, actual_end_date, created_by | Desc: -id, -name, -assigned_to, -start_date, -target_end_date, -actual_end_date, -created_by"
@@ -125,13 +131,14 @@ func (h *Handler) getProject(w http.ResponseWriter, r *http.Request) {
 // @Router /v1/projects [get]
 func (h *Handler) getAllProjects(w http.ResponseWriter, r *http.Request) {
 	var queryParams struct {
-		Name          string
-		AssignedTo    int64
-		StartDate     string
-		TargetEndDate string
-		ActualEndDate string
-		CreatedBy     string
-		Filters       model.Filters
+		Name            string
+		AssignedTo      int64
+		StartDate       string
+		TargetEndDate   string
+		ActualEndDate   string
+		CreatedBy       string
+		IncludeArchived bool
+		Filters         model.Filters
 	}
 	v := validator.New()
 	qs := r.URL.Query()
@@ -141,13 +148,14 @@ func (h *Handler) getAllProjects(w http.ResponseWriter, r *http.Request) {
 	queryParams.TargetEndDate = h.readString(qs, "target_end_date", "")
 	queryParams.ActualEndDate = h.readString(qs, "actual_end_date", "")
 	queryParams.CreatedBy = h.readString(qs, "created_by", "")
+	queryParams.IncludeArchived = h.readBool(qs, "include_archived", false, v)
 	queryParams.Filters.Page = h.readInt(qs, "page", 1, v)
 	queryParams.Filters.PageSize = h.readInt(qs, "page_size", 20, v)
+	queryParams.Filters.MaxPageSize = h.Config.Pagination.MaxPageSize
 	queryParams.Filters.Sort = h.readString(qs, "sort", "id")
 	queryParams.Filters.SortSafelist = []string{"id", "name", "assigned_to", "start_date", "target_end_date", "actual_end_date", "created_by", "-id", "-name", "-assigned_to", "-start_date", "-target_end_date", "-actual_end_date", "-created_by"}
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
-	projects, metadata, err := h.ctrl.GetAllProjects(ctx, queryParams.Name, queryParams.AssignedTo, queryParams.StartDate, queryParams.TargetEndDate, queryParams.ActualEndDate, queryParams.CreatedBy, queryParams.Filters, v)
+	ctx := r.Context()
+	projects, metadata, err := h.ctrl.GetAllProjects(ctx, queryParams.Name, queryParams.AssignedTo, queryParams.StartDate, queryParams.TargetEndDate, queryParams.ActualEndDate, queryParams.CreatedBy, queryParams.IncludeArchived, queryParams.Filters, v)
 	if err != nil {
 		switch {
 		case errors.Is(err, context.Canceled):
@@ -159,7 +167,51 @@ func (h *Handler) getAllProjects(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
-	err = h.encodeJSON(w, http.StatusOK, envelop{"projects": projects, "metadata": metadata}, nil)
+	err = h.encodeJSON(w, http.StatusOK, envelop{"projects": projects, "metadata": metadata}, h.paginationHeaders(r, metadata))
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// GetAssignedProjects godoc
+// @Summary Get the projects assigned to the authenticated user
+// @Description This endpoint gets the projects the authenticated user leads. Users without assigned projects get an empty list
+// @Tags projects
+// @Produce json
+// @Param token header string false "Bearer token"
+// @Param page query string false "Query string param for pagination (min 1)"
+// @Param page_size query string false "Query string param for pagination (max 100)"
+// @Param sort query string false "Sort by asc or desc order. Asc: id, name, start_date, target_end_date, actual_end_date, created_by | Desc: -id, -name, -start_date, -target_end_date, -actual_end_date, -created_by"
+// @Success 200 {array} model.Project
+// @Failure 422
+// @Failure 500
+// @Router /v1/projects/mine [get]
+func (h *Handler) getAssignedProjects(w http.ResponseWriter, r *http.Request) {
+	var queryParams struct {
+		Filters model.Filters
+	}
+	v := validator.New()
+	qs := r.URL.Query()
+	queryParams.Filters.Page = h.readInt(qs, "page", 1, v)
+	queryParams.Filters.PageSize = h.readInt(qs, "page_size", 20, v)
+	queryParams.Filters.MaxPageSize = h.Config.Pagination.MaxPageSize
+	queryParams.Filters.Sort = h.readString(qs, "sort", "id")
+	queryParams.Filters.SortSafelist = []string{"id", "name", "start_date", "target_end_date", "actual_end_date", "created_by", "-id", "-name", "-start_date", "-target_end_date", "-actual_end_date", "-created_by"}
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	projects, metadata, err := h.ctrl.GetAssignedProjects(ctx, userFromContext, queryParams.Filters, v)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrFailedValidation):
+			h.failedValidationResponse(w, r, err)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"projects": projects, "metadata": metadata}, h.paginationHeaders(r, metadata))
 	if err != nil {
 		h.serverErrorResponse(w, r, err)
 	}
@@ -174,22 +226,28 @@ func (h *Handler) getAllProjects(w http.ResponseWriter, r *http.Request) {
 // @Param token header string true "Bearer token"
 // @Param payload body updateProjectPayload true "Request payload"
 // @Param project_id path string true "ID of project to update"
+// @Param If-Match header string false "ETag of the project version to update, from a previous response"
+// @Param Prefer header string false "return=minimal to only return changed fields and version"
+// @Param return query string false "Query string param for return (minimal|representation)"
 // @Success 200 {object} model.Project
 // @Failure 400
 // @Failure 403
 // @Failure 404
 // @Failure 409
+// @Failure 412
 // @Failure 422
 // @Failure 500
 // @Router /v1/projects/{project_id} [patch]
 func (h *Handler) updateProject(w http.ResponseWriter, r *http.Request) {
 	var requestPayload struct {
-		Name          *string `json:"name"`
-		Description   *string `json:"description"`
-		AssignedTo    *int64  `json:"assigned_to"`
-		StartDate     *string `json:"start_date"`
-		TargetEndDate *string `json:"target_end_date"`
-		ActualEndDate *string `json:"actual_end_date"`
+		Name                 *string   `json:"name"`
+		Description          *string   `json:"description"`
+		AssignedTo           *int64    `json:"assigned_to"`
+		StartDate            *string   `json:"start_date"`
+		TargetEndDate        *string   `json:"target_end_date"`
+		ActualEndDate        *string   `json:"actual_end_date"`
+		AutoCloseDays        *int      `json:"auto_close_days"`
+		NotificationChannels *[]string `json:"notification_channels"`
 	}
 	projectID, err := h.readIDParam(r, "project_id")
 	if err != nil {
@@ -201,10 +259,32 @@ func (h *Handler) updateProject(w http.ResponseWriter, r *http.Request) {
 		h.badRequestResponse(w, r, err)
 		return
 	}
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
+	version, err := h.readIfMatch(r)
+	if err != nil {
+		h.badRequestResponse(w, r, err)
+		return
+	}
+	ctx := r.Context()
+	// Fetch the pre-update state so that only the changed fields can be returned
+	// when the client asks for a minimal response.
+	var before *model.Project
+	if h.wantsMinimalReturn(r) {
+		before, err = h.ctrl.GetProject(ctx, projectID)
+		if err != nil {
+			switch {
+			case errors.Is(err, context.Canceled):
+				return
+			case errors.Is(err, issuetracker.ErrNotFound):
+				h.notFoundResponse(w, r)
+			default:
+				h.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+	}
 	userFromContext := h.contextGetUser(r)
-	project, err := h.ctrl.UpdateProject(ctx, projectID, requestPayload.Name, requestPayload.Description, requestPayload.AssignedTo, requestPayload.StartDate, requestPayload.TargetEndDate, requestPayload.ActualEndDate, userFromContext)
+	var conflictErr *issuetracker.TargetEndDateConflictError
+	project, conflicts, err := h.ctrl.UpdateProject(ctx, projectID, version, requestPayload.Name, requestPayload.Description, requestPayload.AssignedTo, requestPayload.StartDate, requestPayload.TargetEndDate, requestPayload.ActualEndDate, requestPayload.AutoCloseDays, requestPayload.NotificationChannels, userFromContext)
 	if err != nil {
 		switch {
 		case errors.Is(err, context.Canceled):
@@ -217,6 +297,83 @@ func (h *Handler) updateProject(w http.ResponseWriter, r *http.Request) {
 			h.failedValidationResponse(w, r, err)
 		case errors.Is(err, issuetracker.ErrEditConflict):
 			h.editConflictResponse(w, r)
+		case errors.Is(err, issuetracker.ErrPreconditionFailed):
+			h.preconditionFailedResponse(w, r)
+		case errors.As(err, &conflictErr):
+			h.targetEndDateConflictResponse(w, r, conflictErr.Issues)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	var extra envelop
+	if len(conflicts) > 0 {
+		extra = envelop{"warnings": envelop{"target_end_date": envelop{
+			"message":            "must not be before the target resolution date of the project's unresolved issues",
+			"conflicting_issues": conflicts,
+		}}}
+	}
+	w.Header().Set("ETag", versionETag(project.Version))
+	err = h.encodeUpdate(w, r, "project", before, project, project.Version, extra)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// ArchiveProject godoc
+// @Summary Archive a project
+// @Description This endpoint archives a project. Archived projects keep their issues and history, but are hidden from the list of projects and don't accept new issues. Only managers can archive projects.
+// @Tags projects
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param project_id path string true "ID of project to archive"
+// @Success 200 {object} model.Project
+// @Failure 403
+// @Failure 404
+// @Failure 409
+// @Failure 500
+// @Router /v1/projects/{project_id}/archive [post]
+func (h *Handler) archiveProject(w http.ResponseWriter, r *http.Request) {
+	h.setProjectArchived(w, r, h.ctrl.ArchiveProject)
+}
+
+// UnarchiveProject godoc
+// @Summary Unarchive a project
+// @Description This endpoint restores an archived project. Only managers can unarchive projects.
+// @Tags projects
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param project_id path string true "ID of project to unarchive"
+// @Success 200 {object} model.Project
+// @Failure 403
+// @Failure 404
+// @Failure 409
+// @Failure 500
+// @Router /v1/projects/{project_id}/unarchive [post]
+func (h *Handler) unarchiveProject(w http.ResponseWriter, r *http.Request) {
+	h.setProjectArchived(w, r, h.ctrl.UnarchiveProject)
+}
+
+// setProjectArchived archives or unarchives the requested project with fn.
+func (h *Handler) setProjectArchived(w http.ResponseWriter, r *http.Request, fn func(ctx context.Context, id int64, user *model.User) (*model.Project, error)) {
+	projectID, err := h.readIDParam(r, "project_id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	project, err := fn(ctx, projectID, userFromContext)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrNotPermitted):
+			h.notPermittedResponse(w, r)
+		case errors.Is(err, issuetracker.ErrNotFound):
+			h.notFoundResponse(w, r)
+		case errors.Is(err, issuetracker.ErrEditConflict):
+			h.editConflictResponse(w, r)
 		default:
 			h.serverErrorResponse(w, r, err)
 		}
@@ -230,28 +387,30 @@ func (h *Handler) updateProject(w http.ResponseWriter, r *http.Request) {
 
 // DeleteProject godoc
 // @Summary Delete a project
-// @Description This endpoint deletes a project
+// @Description This endpoint deletes a project along with all its issues and history. Projects that are no longer active should usually be archived instead. Only managers can delete projects.
 // @Tags projects
 // @Produce json
 // @Param token header string true "Bearer token"
 // @Param project_id path string true "ID of project to delete"
 // @Success 200
+// @Failure 403
 // @Failure 404
 // @Failure 500
 // @Router /v1/projects/{project_id} [delete]
 func (h *Handler) deleteProject(w http.ResponseWriter, r *http.Request) {
 	projectID, err := h.readIDParam(r, "project_id")
 	if err != nil {
-		h.badRequestResponse(w, r, err)
+		h.notFoundResponse(w, r)
 		return
 	}
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
-	err = h.ctrl.DeleteProject(ctx, projectID)
+	ctx := r.Context()
+	err = h.ctrl.DeleteProject(ctx, projectID, h.contextGetUser(r))
 	if err != nil {
 		switch {
 		case errors.Is(err, context.Canceled):
 			return
+		case errors.Is(err, issuetracker.ErrNotPermitted):
+			h.notPermittedResponse(w, r)
 		case errors.Is(err, issuetracker.ErrNotFound):
 			h.notFoundResponse(w, r)
 		default:
@@ -295,10 +454,10 @@ func (h *Handler) getProjectUsers(w http.ResponseWriter, r *http.Request) {
 	queryParams.Role = h.readString(qs, "role", "")
 	queryParams.Filters.Page = h.readInt(qs, "page", 1, v)
 	queryParams.Filters.PageSize = h.readInt(qs, "page_size", 20, v)
+	queryParams.Filters.MaxPageSize = h.Config.Pagination.MaxPageSize
 	queryParams.Filters.Sort = h.readString(qs, "sort", "id")
 	queryParams.Filters.SortSafelist = []string{"id", "-id"}
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
+	ctx := r.Context()
 	users, metadata, err := h.ctrl.GetProjectUsers(ctx, projectID, queryParams.Role, queryParams.Filters, v)
 	if err != nil {
 		switch {
@@ -311,7 +470,170 @@ func (h *Handler) getProjectUsers(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
-	err = h.encodeJSON(w, http.StatusOK, envelop{"users": users, "metadata": metadata}, nil)
+	err = h.encodeJSON(w, http.StatusOK, envelop{"users": users, "metadata": metadata}, h.paginationHeaders(r, metadata))
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// GetProjectUnassignedMembers godoc
+// @Summary Get project members without open issues
+// @Description This endpoint gets the project's members who have no open issues assigned to them in the project
+// @Tags projects
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param project_id path string true "ID of project to get unassigned members"
+// @Param page query string false "Query string param for pagination (min 1)"
+// @Param page_size query string false "Query string param for pagination (max 100)"
+// @Param sort query string false "Sort by asc or desc order. Asc: id, name | Desc: -id, -name"
+// @Success 200 {array} model.User
+// @Failure 403
+// @Failure 404
+// @Failure 422
+// @Failure 500
+// @Router /v1/projects/{project_id}/users/unassigned [get]
+func (h *Handler) getProjectUnassignedMembers(w http.ResponseWriter, r *http.Request) {
+	var queryParams struct {
+		Filters model.Filters
+	}
+	projectID, err := h.readIDParam(r, "project_id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	v := validator.New()
+	qs := r.URL.Query()
+	queryParams.Filters.Page = h.readInt(qs, "page", 1, v)
+	queryParams.Filters.PageSize = h.readInt(qs, "page_size", 20, v)
+	queryParams.Filters.MaxPageSize = h.Config.Pagination.MaxPageSize
+	queryParams.Filters.Sort = h.readString(qs, "sort", "id")
+	queryParams.Filters.SortSafelist = []string{"id", "name", "-id", "-name"}
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	users, metadata, err := h.ctrl.GetProjectUnassignedMembers(ctx, projectID, userFromContext, queryParams.Filters, v)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrNotFound):
+			h.notFoundResponse(w, r)
+		case errors.Is(err, issuetracker.ErrNotPermitted):
+			h.notPermittedResponse(w, r)
+		case errors.Is(err, issuetracker.ErrFailedValidation):
+			h.failedValidationResponse(w, r, err)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"users": users, "metadata": metadata}, h.paginationHeaders(r, metadata))
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// GetProjectActiveUsers godoc
+// @Summary Get recently active project members
+// @Description This endpoint gets the project's members who created, updated, closed or commented on the project's issues in the last days, with how many of each, most active first by default
+// @Tags projects
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param project_id path string true "ID of project to get active users"
+// @Param days query string false "Number of days to look back, between 1 and 365 (default 30)"
+// @Param page query string false "Query string param for pagination (min 1)"
+// @Param page_size query string false "Query string param for pagination (max 100)"
+// @Param sort query string false "Sort by asc or desc order. Asc: id, name, total | Desc: -id, -name, -total"
+// @Success 200 {array} model.ActiveUser
+// @Failure 403
+// @Failure 404
+// @Failure 422
+// @Failure 500
+// @Router /v1/projects/{project_id}/active-users [get]
+func (h *Handler) getProjectActiveUsers(w http.ResponseWriter, r *http.Request) {
+	var queryParams struct {
+		Days    int
+		Filters model.Filters
+	}
+	projectID, err := h.readIDParam(r, "project_id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	v := validator.New()
+	qs := r.URL.Query()
+	queryParams.Days = h.readInt(qs, "days", 30, v)
+	queryParams.Filters.Page = h.readInt(qs, "page", 1, v)
+	queryParams.Filters.PageSize = h.readInt(qs, "page_size", 20, v)
+	queryParams.Filters.MaxPageSize = h.Config.Pagination.MaxPageSize
+	queryParams.Filters.Sort = h.readString(qs, "sort", "-total")
+	queryParams.Filters.SortSafelist = []string{"id", "name", "total", "-id", "-name", "-total"}
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	users, metadata, err := h.ctrl.GetProjectActiveUsers(ctx, projectID, queryParams.Days, userFromContext, queryParams.Filters, v)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrNotFound):
+			h.notFoundResponse(w, r)
+		case errors.Is(err, issuetracker.ErrNotPermitted):
+			h.notPermittedResponse(w, r)
+		case errors.Is(err, issuetracker.ErrFailedValidation):
+			h.failedValidationResponse(w, r, err)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"users": users, "metadata": metadata}, h.paginationHeaders(r, metadata))
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// GetMyProjects godoc
+// @Summary Get projects the authenticated user can access
+// @Description This endpoint gets the id, key and name of every project that isn't archived and that the authenticated user leads or is a member of (all projects for managers), for use in project pickers
+// @Tags projects
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param page query string false "Query string param for pagination (min 1)"
+// @Param page_size query string false "Query string param for pagination (max 100)"
+// @Param sort query string false "Sort by asc or desc order. Asc: id, key, name | Desc: -id, -key, -name"
+// @Success 200 {array} model.ProjectSummary
+// @Failure 422
+// @Failure 500
+// @Router /v1/me/projects [get]
+func (h *Handler) getMyProjects(w http.ResponseWriter, r *http.Request) {
+	var queryParams struct {
+		Filters model.Filters
+	}
+	v := validator.New()
+	qs := r.URL.Query()
+	queryParams.Filters.Page = h.readInt(qs, "page", 1, v)
+	queryParams.Filters.PageSize = h.readInt(qs, "page_size", 20, v)
+	queryParams.Filters.MaxPageSize = h.Config.Pagination.MaxPageSize
+	queryParams.Filters.Sort = h.readString(qs, "sort", "name")
+	queryParams.Filters.SortSafelist = []string{"id", "key", "name", "-id", "-key", "-name"}
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	projects, metadata, err := h.ctrl.GetMyProjects(ctx, userFromContext, queryParams.Filters, v)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrFailedValidation):
+			h.failedValidationResponse(w, r, err)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	// The list depends on the caller and changes rarely, so let the client cache it
+	// briefly without allowing shared caches to store it.
+	headers := h.paginationHeaders(r, metadata)
+	headers.Set("Cache-Control", "private, max-age=60")
+	err = h.encodeJSON(w, http.StatusOK, envelop{"projects": projects, "metadata": metadata}, headers)
 	if err != nil {
 		h.serverErrorResponse(w, r, err)
 	}
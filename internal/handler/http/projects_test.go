@@ -0,0 +1,186 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/emzola/issuetracker/config"
+	"github.com/emzola/issuetracker/internal/controller/issuetracker"
+	"github.com/emzola/issuetracker/internal/repository/postgres"
+	"github.com/emzola/issuetracker/pkg/model"
+	"github.com/julienschmidt/httprouter"
+	"go.uber.org/zap"
+)
+
+// projectRepository serves a single project and records the projects it is asked to
+// create. Methods that are not overridden are not expected to be called.
+type projectRepository struct {
+	*postgres.Repository
+	project *model.Project
+	created *model.Project
+}
+
+func (r *projectRepository) GetProject(ctx context.Context, id int64) (*model.Project, error) {
+	project := *r.project
+	return &project, nil
+}
+
+func (r *projectRepository) CreateProject(ctx context.Context, project *model.Project) error {
+	r.created = project
+	project.ID = 1
+	return nil
+}
+
+func (r *projectRepository) UpdateProject(ctx context.Context, project *model.Project) error {
+	project.Version++
+	r.project = project
+	return nil
+}
+
+func (r *projectRepository) RecordProjectActivity(ctx context.Context, activity []*model.ProjectActivity) error {
+	return nil
+}
+
+func TestCreateProjectAuthor(t *testing.T) {
+	repo := &projectRepository{}
+	var wg sync.WaitGroup
+	ctrl := issuetracker.New(repo, config.App{}, &wg, zap.NewNop())
+	h := New(ctrl, config.App{}, nil)
+	body := `{"name": "Issue Tracker", "description": "Tracks issues", "start_date": "2024-01-01", "target_end_date": "2024-06-30"}`
+	r := httptest.NewRequest(http.MethodPost, "/v1/projects", strings.NewReader(body))
+	r = h.contextSetUser(r, &model.User{ID: 7, Name: "Ada Lovelace", Role: "manager", Activated: true})
+	w := httptest.NewRecorder()
+	h.createProject(w, r)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("createProject() status = %v, want %v: %s", w.Code, http.StatusCreated, w.Body)
+	}
+	if repo.created.CreatedBy != "Ada Lovelace" {
+		t.Errorf("stored created_by = %q, want %q", repo.created.CreatedBy, "Ada Lovelace")
+	}
+	if repo.created.ModifiedBy != "Ada Lovelace" {
+		t.Errorf("stored modified_by = %q, want %q", repo.created.ModifiedBy, "Ada Lovelace")
+	}
+}
+
+func TestUpdateUnassignedProjectAsLead(t *testing.T) {
+	repo := &projectRepository{
+		project: &model.Project{
+			ID:            1,
+			Name:          "Issue Tracker",
+			StartDate:     time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			TargetEndDate: time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC),
+		},
+	}
+	var wg sync.WaitGroup
+	ctrl := issuetracker.New(repo, config.App{}, &wg, zap.NewNop())
+	h := New(ctrl, config.App{}, nil)
+	r := httptest.NewRequest(http.MethodPatch, "/v1/projects/1", strings.NewReader(`{"name": "Bug Tracker"}`))
+	r = r.WithContext(context.WithValue(r.Context(), httprouter.ParamsKey, httprouter.Params{{Key: "project_id", Value: "1"}}))
+	r = h.contextSetUser(r, &model.User{ID: 2, Name: "Grace Hopper", Role: "lead", Activated: true})
+	w := httptest.NewRecorder()
+	h.updateProject(w, r)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("updateProject() status = %v, want %v: %s", w.Code, http.StatusForbidden, w.Body)
+	}
+}
+
+func TestGetProjectETag(t *testing.T) {
+	repo := &projectRepository{project: &model.Project{ID: 1, Name: "Issue Tracker", Version: 3}}
+	var wg sync.WaitGroup
+	h := New(issuetracker.New(repo, config.App{}, &wg, zap.NewNop()), config.App{}, nil)
+	get := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/v1/projects/1", nil)
+		r = r.WithContext(context.WithValue(r.Context(), httprouter.ParamsKey, httprouter.Params{{Key: "project_id", Value: "1"}}))
+		if ifNoneMatch != "" {
+			r.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		h.getProject(w, r)
+		return w
+	}
+	w := get("")
+	if w.Code != http.StatusOK {
+		t.Fatalf("getProject() status = %v, want %v: %s", w.Code, http.StatusOK, w.Body)
+	}
+	etag := w.Header().Get("ETag")
+	if etag != `"3"` {
+		t.Fatalf("getProject() ETag = %q, want %q", etag, `"3"`)
+	}
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		wantStatus  int
+	}{
+		{"matching", etag, http.StatusNotModified},
+		{"weak", "W/" + etag, http.StatusNotModified},
+		{"one of several", `"1", ` + etag, http.StatusNotModified},
+		{"any", "*", http.StatusNotModified},
+		{"mismatched", `"2"`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := get(tt.ifNoneMatch)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("getProject() status = %v, want %v", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get("ETag"); got != etag {
+				t.Errorf("getProject() ETag = %q, want %q", got, etag)
+			}
+			if tt.wantStatus == http.StatusNotModified && w.Body.Len() != 0 {
+				t.Errorf("getProject() body = %q, want none", w.Body)
+			}
+		})
+	}
+}
+
+func TestUpdateProjectIfMatch(t *testing.T) {
+	tests := []struct {
+		name       string
+		ifMatch    string
+		wantStatus int
+		wantETag   string
+	}{
+		{"no precondition", "", http.StatusOK, `"4"`},
+		{"current version", `"3"`, http.StatusOK, `"4"`},
+		{"any version", "*", http.StatusOK, `"4"`},
+		{"stale version", `"2"`, http.StatusPreconditionFailed, ""},
+		{"weak ETag", `W/"3"`, http.StatusBadRequest, ""},
+		{"not an ETag", "3", http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &projectRepository{project: &model.Project{
+				ID:            1,
+				Name:          "Issue Tracker",
+				Description:   "Tracks issues",
+				StartDate:     time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+				TargetEndDate: time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC),
+				Version:       3,
+			}}
+			var wg sync.WaitGroup
+			h := New(issuetracker.New(repo, config.App{}, &wg, zap.NewNop()), config.App{}, nil)
+			r := httptest.NewRequest(http.MethodPatch, "/v1/projects/1", strings.NewReader(`{"name": "Bug Tracker"}`))
+			r = r.WithContext(context.WithValue(r.Context(), httprouter.ParamsKey, httprouter.Params{{Key: "project_id", Value: "1"}}))
+			r = h.contextSetUser(r, &model.User{ID: 1, Name: "Ada Lovelace", Role: "manager", Activated: true})
+			if tt.ifMatch != "" {
+				r.Header.Set("If-Match", tt.ifMatch)
+			}
+			w := httptest.NewRecorder()
+			h.updateProject(w, r)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("updateProject() status = %v, want %v: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if got := w.Header().Get("ETag"); got != tt.wantETag {
+				t.Errorf("updateProject() ETag = %q, want %q", got, tt.wantETag)
+			}
+			if updated := repo.project.Name == "Bug Tracker"; updated != (tt.wantStatus == http.StatusOK) {
+				t.Errorf("updateProject() updated the project = %v, want %v", updated, tt.wantStatus == http.StatusOK)
+			}
+		})
+	}
+}
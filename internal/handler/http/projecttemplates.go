@@ -0,0 +1,274 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/emzola/issuetracker/internal/controller/issuetracker"
+	"github.com/emzola/issuetracker/pkg/model"
+)
+
+// GetAllProjectTemplates godoc
+// @Summary Get all project templates
+// @Description This endpoint gets all project templates. Only managers can manage project templates
+// @Tags project-templates
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Success 200 {array} model.ProjectTemplate
+// @Failure 403
+// @Failure 500
+// @Router /v1/project-templates [get]
+func (h *Handler) getAllProjectTemplates(w http.ResponseWriter, r *http.Request) {
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	templates, err := h.ctrl.GetAllProjectTemplates(ctx, userFromContext)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrNotPermitted):
+			h.notPermittedResponse(w, r)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"project_templates": templates}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// CreateProjectTemplate godoc
+// @Summary Create a project template
+// @Description This endpoint creates a project template. The body holds the project's description and duration in days, and the milestones and seed issues to create, dated in days after the project's start date. The body is validated against the project, milestone and issue models
+// @Tags project-templates
+// @Accept  json
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param payload body createProjectTemplatePayload true "Request payload"
+// @Success 201 {object} model.ProjectTemplate
+// @Failure 400
+// @Failure 403
+// @Failure 422
+// @Failure 500
+// @Router /v1/project-templates [post]
+func (h *Handler) createProjectTemplate(w http.ResponseWriter, r *http.Request) {
+	var requestPayload struct {
+		Name string                    `json:"name"`
+		Body model.ProjectTemplateBody `json:"body"`
+	}
+	err := h.decodeJSON(w, r, &requestPayload)
+	if err != nil {
+		h.badRequestResponse(w, r, err)
+		return
+	}
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	template, err := h.ctrl.CreateProjectTemplate(ctx, requestPayload.Name, requestPayload.Body, userFromContext)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrNotPermitted):
+			h.notPermittedResponse(w, r)
+		case errors.Is(err, issuetracker.ErrFailedValidation):
+			h.failedValidationResponse(w, r, err)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	header := make(http.Header)
+	header.Set("Location", fmt.Sprintf("/v1/project-templates/%d", template.ID))
+	err = h.encodeJSON(w, http.StatusCreated, envelop{"project_template": template}, header)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// GetProjectTemplate godoc
+// @Summary Get project template by ID
+// @Description This endpoint gets a project template by ID
+// @Tags project-templates
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param template_id path string true "ID of project template to get"
+// @Success 200 {object} model.ProjectTemplate
+// @Failure 403
+// @Failure 404
+// @Failure 500
+// @Router /v1/project-templates/{template_id} [get]
+func (h *Handler) getProjectTemplate(w http.ResponseWriter, r *http.Request) {
+	templateID, err := h.readIDParam(r, "template_id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	template, err := h.ctrl.GetProjectTemplate(ctx, templateID, userFromContext)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrNotPermitted):
+			h.notPermittedResponse(w, r)
+		case errors.Is(err, issuetracker.ErrNotFound):
+			h.notFoundResponse(w, r)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"project_template": template}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// UpdateProjectTemplate godoc
+// @Summary Update a project template
+// @Description This endpoint renames a project template or replaces its body. Projects already created from the template are not changed
+// @Tags project-templates
+// @Accept  json
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param template_id path string true "ID of project template to update"
+// @Param payload body updateProjectTemplatePayload true "Request payload"
+// @Success 200 {object} model.ProjectTemplate
+// @Failure 400
+// @Failure 403
+// @Failure 404
+// @Failure 409
+// @Failure 422
+// @Failure 500
+// @Router /v1/project-templates/{template_id} [patch]
+func (h *Handler) updateProjectTemplate(w http.ResponseWriter, r *http.Request) {
+	templateID, err := h.readIDParam(r, "template_id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	var requestPayload struct {
+		Name *string                    `json:"name"`
+		Body *model.ProjectTemplateBody `json:"body"`
+	}
+	err = h.decodeJSON(w, r, &requestPayload)
+	if err != nil {
+		h.badRequestResponse(w, r, err)
+		return
+	}
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	template, err := h.ctrl.UpdateProjectTemplate(ctx, templateID, requestPayload.Name, requestPayload.Body, userFromContext)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrNotPermitted):
+			h.notPermittedResponse(w, r)
+		case errors.Is(err, issuetracker.ErrNotFound):
+			h.notFoundResponse(w, r)
+		case errors.Is(err, issuetracker.ErrFailedValidation):
+			h.failedValidationResponse(w, r, err)
+		case errors.Is(err, issuetracker.ErrEditConflict):
+			h.editConflictResponse(w, r)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"project_template": template}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// DeleteProjectTemplate godoc
+// @Summary Delete a project template
+// @Description This endpoint deletes a project template. Projects already created from the template are not changed
+// @Tags project-templates
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param template_id path string true "ID of project template to delete"
+// @Success 200
+// @Failure 403
+// @Failure 404
+// @Failure 500
+// @Router /v1/project-templates/{template_id} [delete]
+func (h *Handler) deleteProjectTemplate(w http.ResponseWriter, r *http.Request) {
+	templateID, err := h.readIDParam(r, "template_id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	err = h.ctrl.DeleteProjectTemplate(ctx, templateID, userFromContext)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrNotPermitted):
+			h.notPermittedResponse(w, r)
+		case errors.Is(err, issuetracker.ErrNotFound):
+			h.notFoundResponse(w, r)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"message": "project template successfully deleted"}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// CreateProjectFromTemplate godoc
+// @Summary Create a project from a template
+// @Description This endpoint creates a project along with the milestones and seed issues of a project template, in a single transaction. Dates are counted from start_date, or from today if it isn't given. The template is validated against the project, milestone and issue models before it is applied
+// @Tags projects
+// @Accept  json
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param payload body createProjectFromTemplatePayload true "Request payload"
+// @Success 201 {object} model.Project
+// @Failure 400
+// @Failure 422
+// @Failure 500
+// @Router /v1/projects/from-template [post]
+func (h *Handler) createProjectFromTemplate(w http.ResponseWriter, r *http.Request) {
+	var requestPayload struct {
+		TemplateID int64  `json:"template_id"`
+		Name       string `json:"name"`
+		StartDate  string `json:"start_date"`
+	}
+	err := h.decodeJSON(w, r, &requestPayload)
+	if err != nil {
+		h.badRequestResponse(w, r, err)
+		return
+	}
+	ctx := r.Context()
+	userFromContext := h.contextGetUser(r)
+	project, err := h.ctrl.CreateProjectFromTemplate(ctx, requestPayload.TemplateID, requestPayload.Name, requestPayload.StartDate, userFromContext)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrFailedValidation):
+			h.failedValidationResponse(w, r, err)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	header := make(http.Header)
+	header.Set("Location", fmt.Sprintf("/v1/projects/%d", project.ID))
+	err = h.encodeJSON(w, http.StatusCreated, envelop{"project": project}, header)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,215 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/emzola/issuetracker/internal/controller/issuetracker"
+	"github.com/julienschmidt/httprouter"
+)
+
+// GetAllRoles godoc
+// @Summary Get all roles
+// @Description This endpoint gets all roles and the resources each role can create, read, update and delete. Only managers can manage roles
+// @Tags roles
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Success 200 {array} model.Role
+// @Failure 403
+// @Failure 500
+// @Router /v1/roles [get]
+func (h *Handler) getAllRoles(w http.ResponseWriter, r *http.Request) {
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	roles, err := h.ctrl.GetAllRoles(ctx, userFromContext)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrNotPermitted):
+			h.notPermittedResponse(w, r)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"roles": roles}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// CreateRole godoc
+// @Summary Create a role
+// @Description This endpoint creates a role. Permissions map the actions create, read, update and delete to the resources they are granted on, e.g. "issues" or "issues/watchers". The role applies to the next requests
+// @Tags roles
+// @Accept  json
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param payload body createRolePayload true "Request payload"
+// @Success 201 {object} model.Role
+// @Failure 400
+// @Failure 403
+// @Failure 422
+// @Failure 500
+// @Router /v1/roles [post]
+func (h *Handler) createRole(w http.ResponseWriter, r *http.Request) {
+	var requestPayload struct {
+		Name        string              `json:"name"`
+		Permissions map[string][]string `json:"permissions"`
+	}
+	err := h.decodeJSON(w, r, &requestPayload)
+	if err != nil {
+		h.badRequestResponse(w, r, err)
+		return
+	}
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	role, err := h.ctrl.CreateRole(ctx, requestPayload.Name, requestPayload.Permissions, userFromContext)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrNotPermitted):
+			h.notPermittedResponse(w, r)
+		case errors.Is(err, issuetracker.ErrFailedValidation):
+			h.failedValidationResponse(w, r, err)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusCreated, envelop{"role": role}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// GetRole godoc
+// @Summary Get role by name
+// @Description This endpoint gets a role and its permissions by name
+// @Tags roles
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param role path string true "Name of role to get"
+// @Success 200 {object} model.Role
+// @Failure 403
+// @Failure 404
+// @Failure 500
+// @Router /v1/roles/{role} [get]
+func (h *Handler) getRole(w http.ResponseWriter, r *http.Request) {
+	name := httprouter.ParamsFromContext(r.Context()).ByName("role")
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	role, err := h.ctrl.GetRole(ctx, name, userFromContext)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrNotPermitted):
+			h.notPermittedResponse(w, r)
+		case errors.Is(err, issuetracker.ErrNotFound):
+			h.notFoundResponse(w, r)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"role": role}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// UpdateRole godoc
+// @Summary Update a role
+// @Description This endpoint replaces the permissions of a role. Managers can't remove their own access to roles. The change applies to the next requests
+// @Tags roles
+// @Accept  json
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param role path string true "Name of role to update"
+// @Param payload body updateRolePayload true "Request payload"
+// @Success 200 {object} model.Role
+// @Failure 400
+// @Failure 403
+// @Failure 404
+// @Failure 409
+// @Failure 422
+// @Failure 500
+// @Router /v1/roles/{role} [patch]
+func (h *Handler) updateRole(w http.ResponseWriter, r *http.Request) {
+	var requestPayload struct {
+		Permissions *map[string][]string `json:"permissions"`
+	}
+	name := httprouter.ParamsFromContext(r.Context()).ByName("role")
+	err := h.decodeJSON(w, r, &requestPayload)
+	if err != nil {
+		h.badRequestResponse(w, r, err)
+		return
+	}
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	role, err := h.ctrl.UpdateRole(ctx, name, requestPayload.Permissions, userFromContext)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrNotPermitted):
+			h.notPermittedResponse(w, r)
+		case errors.Is(err, issuetracker.ErrNotFound):
+			h.notFoundResponse(w, r)
+		case errors.Is(err, issuetracker.ErrFailedValidation):
+			h.failedValidationResponse(w, r, err)
+		case errors.Is(err, issuetracker.ErrEditConflict):
+			h.editConflictResponse(w, r)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"role": role}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// DeleteRole godoc
+// @Summary Delete a role
+// @Description This endpoint deletes a role. Built-in roles (member, lead, manager) and roles assigned to users can't be deleted
+// @Tags roles
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param role path string true "Name of role to delete"
+// @Success 200
+// @Failure 403
+// @Failure 404
+// @Failure 422
+// @Failure 500
+// @Router /v1/roles/{role} [delete]
+func (h *Handler) deleteRole(w http.ResponseWriter, r *http.Request) {
+	name := httprouter.ParamsFromContext(r.Context()).ByName("role")
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	err := h.ctrl.DeleteRole(ctx, name, userFromContext)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrNotPermitted):
+			h.notPermittedResponse(w, r)
+		case errors.Is(err, issuetracker.ErrNotFound):
+			h.notFoundResponse(w, r)
+		case errors.Is(err, issuetracker.ErrFailedValidation):
+			h.failedValidationResponse(w, r, err)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"message": "role successfully deleted"}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
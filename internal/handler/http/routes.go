@@ -1,52 +1,176 @@
 package http
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/julienschmidt/httprouter"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	httpSwagger "github.com/swaggo/http-swagger"
 )
 
-func (h *Handler) Routes() http.Handler {
+// Routes returns the application's HTTP handler. Background work started by the
+// middleware, such as the rate limiter cleanup, stops once ctx is done.
+func (h *Handler) Routes(ctx context.Context) http.Handler {
 	router := httprouter.New()
 
 	router.NotFound = http.HandlerFunc(h.notFoundResponse)
 	router.MethodNotAllowed = http.HandlerFunc(h.methodNotAllowedResponse)
 
-	router.HandlerFunc(http.MethodGet, "/v1/health", h.healthCheck)
+	router.HandlerFunc(http.MethodGet, "/v1/meta/vocabularies", h.getVocabularies)
+
+	router.HandlerFunc(http.MethodGet, "/v1/me/projects", h.requireActivatedUser(h.getMyProjects))
+
+	router.HandlerFunc(http.MethodGet, "/v1/dashboard", h.requireActivatedUser(h.getDashboard))
+
+	router.HandlerFunc(http.MethodGet, "/v1/admin/config", h.requireActivatedUser(h.getConfig))
+	router.HandlerFunc(http.MethodPatch, "/v1/admin/config", h.requireActivatedUser(h.updateConfig))
+
+	router.HandlerFunc(http.MethodGet, "/v1/roles", h.requireActivatedUser(h.getAllRoles))
+	router.HandlerFunc(http.MethodPost, "/v1/roles", h.requireActivatedUser(h.createRole))
+	router.HandlerFunc(http.MethodGet, "/v1/roles/:role", h.requireActivatedUser(h.getRole))
+	router.HandlerFunc(http.MethodPatch, "/v1/roles/:role", h.requireActivatedUser(h.updateRole))
+	router.HandlerFunc(http.MethodDelete, "/v1/roles/:role", h.requireActivatedUser(h.deleteRole))
+
+	router.HandlerFunc(http.MethodGet, "/v1/project-templates", h.requireActivatedUser(h.getAllProjectTemplates))
+	router.HandlerFunc(http.MethodPost, "/v1/project-templates", h.requireActivatedUser(h.createProjectTemplate))
+	router.HandlerFunc(http.MethodGet, "/v1/project-templates/:template_id", h.requireActivatedUser(h.getProjectTemplate))
+	router.HandlerFunc(http.MethodPatch, "/v1/project-templates/:template_id", h.requireActivatedUser(h.updateProjectTemplate))
+	router.HandlerFunc(http.MethodDelete, "/v1/project-templates/:template_id", h.requireActivatedUser(h.deleteProjectTemplate))
 
 	router.HandlerFunc(http.MethodGet, "/v1/projects", h.requireActivatedUser(h.getAllProjects))
 	router.HandlerFunc(http.MethodPost, "/v1/projects", h.requireActivatedUser(h.createProject))
-	router.HandlerFunc(http.MethodGet, "/v1/projects/:project_id", h.requireActivatedUser(h.getProject))
+	router.HandlerFunc(http.MethodPost, "/v1/projects/:project_id", h.routeStatic("project_id", map[string]http.HandlerFunc{
+		"from-template": h.requireActivatedUser(h.createProjectFromTemplate),
+	}, h.notFoundResponse))
+	// Unlike the other routes, /v1/projects/mine is open to anonymous and unactivated
+	// users rather than rejecting them, since it only ever lists the caller's own assigned
+	// projects. Anonymous users get an empty list.
+	router.HandlerFunc(http.MethodGet, "/v1/projects/:project_id", h.routeStatic("project_id", map[string]http.HandlerFunc{
+		"mine": h.getAssignedProjects,
+	}, h.requireActivatedUser(h.getProject)))
 	router.HandlerFunc(http.MethodPatch, "/v1/projects/:project_id", h.requireActivatedUser(h.updateProject))
 	router.HandlerFunc(http.MethodDelete, "/v1/projects/:project_id", h.requireActivatedUser(h.deleteProject))
+	router.HandlerFunc(http.MethodPost, "/v1/projects/:project_id/archive", h.requireActivatedUser(h.archiveProject))
+	router.HandlerFunc(http.MethodPost, "/v1/projects/:project_id/unarchive", h.requireActivatedUser(h.unarchiveProject))
 	router.HandlerFunc(http.MethodGet, "/v1/projects/:project_id/users", h.requireActivatedUser(h.getProjectUsers))
+	router.HandlerFunc(http.MethodGet, "/v1/projects/:project_id/users/unassigned", h.requireActivatedUser(h.getProjectUnassignedMembers))
+	router.HandlerFunc(http.MethodGet, "/v1/projects/:project_id/active-users", h.requireActivatedUser(h.getProjectActiveUsers))
+	router.HandlerFunc(http.MethodGet, "/v1/projects/:project_id/activity", h.requireActivatedUser(h.getProjectActivity))
+	router.HandlerFunc(http.MethodGet, "/v1/projects/:project_id/workflow", h.requireActivatedUser(h.getProjectWorkflow))
+	router.HandlerFunc(http.MethodPut, "/v1/projects/:project_id/workflow", h.requireActivatedUser(h.setProjectWorkflow))
+	router.HandlerFunc(http.MethodGet, "/v1/projects/:project_id/milestones", h.requireActivatedUser(h.getAllMilestones))
+	router.HandlerFunc(http.MethodPost, "/v1/projects/:project_id/milestones", h.requireActivatedUser(h.createMilestone))
+	router.HandlerFunc(http.MethodGet, "/v1/projects/:project_id/milestones/:milestone_id", h.requireActivatedUser(h.getMilestone))
+	router.HandlerFunc(http.MethodPatch, "/v1/projects/:project_id/milestones/:milestone_id", h.requireActivatedUser(h.updateMilestone))
+	router.HandlerFunc(http.MethodDelete, "/v1/projects/:project_id/milestones/:milestone_id", h.requireActivatedUser(h.deleteMilestone))
+	router.HandlerFunc(http.MethodGet, "/v1/projects/:project_id/milestones/:milestone_id/at-risk", h.requireActivatedUser(h.getMilestoneAtRiskIssues))
+	router.HandlerFunc(http.MethodGet, "/v1/projects/:project_id/backlog", h.requireActivatedUser(h.getProjectBacklog))
+	router.HandlerFunc(http.MethodGet, "/v1/projects/:project_id/webhooks", h.requireActivatedUser(h.getProjectWebhooks))
+	router.HandlerFunc(http.MethodPost, "/v1/projects/:project_id/webhooks", h.requireActivatedUser(h.createWebhook))
+	router.HandlerFunc(http.MethodGet, "/v1/projects/:project_id/webhooks/:webhook_id", h.requireActivatedUser(h.getWebhook))
+	router.HandlerFunc(http.MethodPatch, "/v1/projects/:project_id/webhooks/:webhook_id", h.requireActivatedUser(h.updateWebhook))
+	router.HandlerFunc(http.MethodDelete, "/v1/projects/:project_id/webhooks/:webhook_id", h.requireActivatedUser(h.deleteWebhook))
+
+	router.HandlerFunc(http.MethodGet, "/v1/milestones/:milestone_id/issues", h.requireActivatedUser(h.getMilestoneIssues))
+
+	router.HandlerFunc(http.MethodGet, "/v1/saved-filters", h.requireActivatedUser(h.getAllSavedFilters))
+	router.HandlerFunc(http.MethodPost, "/v1/saved-filters", h.requireActivatedUser(h.createSavedFilter))
+	router.HandlerFunc(http.MethodGet, "/v1/saved-filters/:filter_id", h.requireActivatedUser(h.getSavedFilter))
+	router.HandlerFunc(http.MethodPatch, "/v1/saved-filters/:filter_id", h.requireActivatedUser(h.updateSavedFilter))
+	router.HandlerFunc(http.MethodDelete, "/v1/saved-filters/:filter_id", h.requireActivatedUser(h.deleteSavedFilter))
+	router.HandlerFunc(http.MethodGet, "/v1/saved-filters/:filter_id/run", h.requireActivatedUser(h.runSavedFilter))
 
 	router.HandlerFunc(http.MethodGet, "/v1/issuesreport/status", h.requireActivatedUser(h.getIssuesStatusReport))
+	router.HandlerFunc(http.MethodGet, "/v1/issuesreport/type", h.requireActivatedUser(h.getIssuesTypeReport))
 	router.HandlerFunc(http.MethodGet, "/v1/issuesreport/assignee", h.requireActivatedUser(h.getIssuesAssigneeReport))
 	router.HandlerFunc(http.MethodGet, "/v1/issuesreport/reporter", h.requireActivatedUser(h.getIssuesReporterReport))
 	router.HandlerFunc(http.MethodGet, "/v1/issuesreport/priority", h.requireActivatedUser(h.getIssuesPriorityLevelReport))
 	router.HandlerFunc(http.MethodGet, "/v1/issuesreport/date", h.requireActivatedUser(h.getIssuesTargetDateReport))
+	router.HandlerFunc(http.MethodGet, "/v1/issuesreport/burndown", h.requireActivatedUser(h.getIssuesBurndownReport))
+	router.HandlerFunc(http.MethodGet, "/v1/issuesreport/effort", h.requireActivatedUser(h.getIssuesEffortReport))
+	router.HandlerFunc(http.MethodGet, "/v1/issuesreport/milestones", h.requireActivatedUser(h.getIssuesMilestoneReport))
+	router.HandlerFunc(http.MethodGet, "/v1/issuesreport/by-project", h.requireActivatedUser(h.getIssuesByProjectReport))
 
 	router.HandlerFunc(http.MethodGet, "/v1/users", h.requireActivatedUser(h.getAllUsers))
 	router.HandlerFunc(http.MethodPost, "/v1/users", h.createUser)
+	router.HandlerFunc(http.MethodPost, "/v1/users/:user_id", h.routeStatic("user_id", map[string]http.HandlerFunc{
+		"import": h.requireActivatedUser(h.importUsers),
+	}, h.notFoundResponse))
 	router.HandlerFunc(http.MethodPut, "/v1/users/activated", h.activateUser)
-	router.HandlerFunc(http.MethodGet, "/v1/users/:user_id", h.requireActivatedUser(h.getUser))
-	router.HandlerFunc(http.MethodPatch, "/v1/users/:user_id", h.requireActivatedUser(h.updateUser))
+	router.HandlerFunc(http.MethodPut, "/v1/users/password", h.resetUserPassword)
+	router.HandlerFunc(http.MethodPut, "/v1/users/password/change", h.requireActivatedUser(h.changeUserPassword))
+	router.HandlerFunc(http.MethodPut, "/v1/users/email", h.requireActivatedUser(h.requestUserEmailChange))
+	router.HandlerFunc(http.MethodPut, "/v1/users/email/confirm", h.confirmUserEmailChange)
+	router.HandlerFunc(http.MethodGet, "/v1/users/:user_id", h.routeStatic("user_id", map[string]http.HandlerFunc{
+		"me": h.requireActivatedUser(h.getCurrentUser),
+	}, h.requireActivatedUser(h.getUser)))
+	router.HandlerFunc(http.MethodPatch, "/v1/users/:user_id", h.routeStatic("user_id", map[string]http.HandlerFunc{
+		"me": h.requireActivatedUser(h.updateCurrentUser),
+	}, h.requireActivatedUser(h.updateUser)))
 	router.HandlerFunc(http.MethodDelete, "/v1/users/:user_id", h.requireActivatedUser(h.deleteUser))
 	router.HandlerFunc(http.MethodPost, "/v1/users/:user_id/projects", h.requireActivatedUser(h.assignUserToProject))
 	router.HandlerFunc(http.MethodGet, "/v1/users/:user_id/projects", h.requireActivatedUser(h.getAllProjectsForUser))
+	router.HandlerFunc(http.MethodGet, "/v1/users/:user_id/involvement", h.requireActivatedUser(h.getUserInvolvement))
+	router.HandlerFunc(http.MethodGet, "/v1/users/:user_id/velocity", h.requireActivatedUser(h.getUserVelocity))
 
 	router.HandlerFunc(http.MethodGet, "/v1/issues", h.requireActivatedUser(h.getAllIssues))
 	router.HandlerFunc(http.MethodPost, "/v1/issues", h.requireActivatedUser(h.createIssue))
-	router.HandlerFunc(http.MethodGet, "/v1/issues/:issue_id", h.requireActivatedUser(h.getIssue))
+	router.HandlerFunc(http.MethodPost, "/v1/issues/:issue_id", h.routeStatic("issue_id", map[string]http.HandlerFunc{
+		"bulk": h.requireActivatedUser(h.bulkUpdateIssues),
+	}, h.notFoundResponse))
+	router.HandlerFunc(http.MethodGet, "/v1/issues/:issue_id", h.routeStatic("issue_id", map[string]http.HandlerFunc{
+		"data-issues":  h.requireActivatedUser(h.getDataIssues),
+		"export":       h.requireActivatedUser(h.exportIssues),
+		"calendar.ics": h.getIssuesCalendar,
+	}, h.requireActivatedUser(h.getIssue)))
 	router.HandlerFunc(http.MethodPatch, "/v1/issues/:issue_id", h.requireActivatedUser(h.updateIssue))
 	router.HandlerFunc(http.MethodDelete, "/v1/issues/:issue_id", h.requireActivatedUser(h.deleteIssue))
+	router.HandlerFunc(http.MethodPost, "/v1/issues/:issue_id/publish", h.requireActivatedUser(h.publishIssue))
+	router.HandlerFunc(http.MethodPost, "/v1/issues/:issue_id/reopen", h.requireActivatedUser(h.reopenIssue))
+	router.HandlerFunc(http.MethodPost, "/v1/issues/:issue_id/assign-self", h.requireActivatedUser(h.assignIssueToSelf))
+	router.HandlerFunc(http.MethodPost, "/v1/issues/:issue_id/restore", h.requireActivatedUser(h.restoreIssue))
+	router.HandlerFunc(http.MethodDelete, "/v1/issues/:issue_id/purge", h.requireActivatedUser(h.purgeIssue))
+	router.HandlerFunc(http.MethodPost, "/v1/issues/:issue_id/comments", h.requireActivatedUser(h.createComment))
+	router.HandlerFunc(http.MethodGet, "/v1/issues/:issue_id/comments", h.requireActivatedUser(h.getAllComments))
+	router.HandlerFunc(http.MethodPost, "/v1/issues/:issue_id/labels", h.requireActivatedUser(h.addLabelToIssue))
+	router.HandlerFunc(http.MethodDelete, "/v1/issues/:issue_id/labels/:label_id", h.requireActivatedUser(h.removeLabelFromIssue))
+	router.HandlerFunc(http.MethodPost, "/v1/issues/:issue_id/links", h.requireActivatedUser(h.createIssueLink))
+	router.HandlerFunc(http.MethodDelete, "/v1/issues/:issue_id/links/:link_id", h.requireActivatedUser(h.deleteIssueLink))
+	router.HandlerFunc(http.MethodGet, "/v1/issues/:issue_id/duplicate-chain", h.requireActivatedUser(h.getDuplicateChain))
+	router.HandlerFunc(http.MethodGet, "/v1/issues/:issue_id/activity", h.requireActivatedUser(h.getIssueActivity))
+	router.HandlerFunc(http.MethodGet, "/v1/issues/:issue_id/diff", h.requireActivatedUser(h.getIssueDiff))
+	router.HandlerFunc(http.MethodPost, "/v1/issues/:issue_id/worklog", h.requireActivatedUser(h.createWorklog))
+	router.HandlerFunc(http.MethodGet, "/v1/issues/:issue_id/worklog", h.requireActivatedUser(h.getIssueWorklogs))
+	router.HandlerFunc(http.MethodGet, "/v1/issues/:issue_id/watchers", h.requireActivatedUser(h.getIssueWatchers))
+	router.HandlerFunc(http.MethodPost, "/v1/issues/:issue_id/watchers", h.requireActivatedUser(h.subscribeToIssue))
+	router.HandlerFunc(http.MethodDelete, "/v1/issues/:issue_id/watchers", h.requireActivatedUser(h.unsubscribeFromIssue))
+
+	router.HandlerFunc(http.MethodPatch, "/v1/comments/:comment_id", h.requireActivatedUser(h.updateComment))
+	router.HandlerFunc(http.MethodDelete, "/v1/comments/:comment_id", h.requireActivatedUser(h.deleteComment))
 
 	router.HandlerFunc(http.MethodPost, "/v1/tokens/activation", h.requireAuthenticatedUser(h.createActivationToken))
 	router.HandlerFunc(http.MethodPost, "/v1/tokens/authentication", h.createAuthenticationToken)
+	router.HandlerFunc(http.MethodPost, "/v1/tokens/password-reset", h.createPasswordResetToken)
+	router.HandlerFunc(http.MethodPost, "/v1/tokens/refresh", h.refreshAuthenticationToken)
+	router.HandlerFunc(http.MethodDelete, "/v1/tokens/refresh", h.revokeRefreshToken)
+	router.HandlerFunc(http.MethodPost, "/v1/tokens/logout", h.requireAuthenticatedUser(h.logout))
+	router.HandlerFunc(http.MethodPost, "/v1/tokens/calendar", h.requireActivatedUser(h.createCalendarToken))
 
 	router.HandlerFunc(http.MethodGet, "/docs/*any", httpSwagger.WrapHandler)
 
-	return h.recoverPanic(h.enableCORS(h.rateLimit(h.authenticate(router))))
+	// Health probes and metrics are served ahead of the authentication and rate
+	// limiting middleware, so that they are never rejected or throttled. All other
+	// requests fall through to the application's routes.
+	registry := prometheus.NewRegistry()
+	probes := httprouter.New()
+	probes.MethodNotAllowed = http.HandlerFunc(h.methodNotAllowedResponse)
+	probes.HandlerFunc(http.MethodGet, "/v1/health", h.healthCheck)
+	probes.HandlerFunc(http.MethodGet, "/v1/healthcheck", h.healthCheck)
+	probes.HandlerFunc(http.MethodGet, "/v1/readiness", h.readiness)
+	probes.Handler(http.MethodGet, "/v1/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	probes.NotFound = h.timeout(h.enableCORS(h.rateLimitIP(ctx, h.authenticate(h.authorize(h.rateLimit(ctx, router))))))
+
+	return h.requestID(h.recoverPanic(h.instrument(newMetrics(registry), []*httprouter.Router{probes, router}, probes)))
 }
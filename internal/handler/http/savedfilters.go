@@ -0,0 +1,260 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/emzola/issuetracker/internal/controller/issuetracker"
+)
+
+// GetAllSavedFilters godoc
+// @Summary Get all saved filters
+// @Description This endpoint gets the saved filters of the authenticated user, ordered by name. Saved filters are private to the user who saved them
+// @Tags saved-filters
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Success 200 {array} model.SavedFilter
+// @Failure 500
+// @Router /v1/saved-filters [get]
+func (h *Handler) getAllSavedFilters(w http.ResponseWriter, r *http.Request) {
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	filters, err := h.ctrl.GetAllSavedFilters(ctx, userFromContext)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"saved_filters": filters}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// CreateSavedFilter godoc
+// @Summary Create a saved filter
+// @Description This endpoint saves a named issue search for the authenticated user. The query string holds the parameters of the issue list, e.g. status=open&priority=high&sort=-reported_date, and can't hold unknown parameters or page
+// @Tags saved-filters
+// @Accept  json
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param payload body createSavedFilterPayload true "Request payload"
+// @Success 201 {object} model.SavedFilter
+// @Failure 400
+// @Failure 422
+// @Failure 500
+// @Router /v1/saved-filters [post]
+func (h *Handler) createSavedFilter(w http.ResponseWriter, r *http.Request) {
+	var requestPayload struct {
+		Name        string `json:"name"`
+		QueryString string `json:"query_string"`
+	}
+	err := h.decodeJSON(w, r, &requestPayload)
+	if err != nil {
+		h.badRequestResponse(w, r, err)
+		return
+	}
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	filter, err := h.ctrl.CreateSavedFilter(ctx, requestPayload.Name, requestPayload.QueryString, userFromContext)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrFailedValidation):
+			h.failedValidationResponse(w, r, err)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	header := make(http.Header)
+	header.Set("Location", fmt.Sprintf("/v1/saved-filters/%d", filter.ID))
+	err = h.encodeJSON(w, http.StatusCreated, envelop{"saved_filter": filter}, header)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// GetSavedFilter godoc
+// @Summary Get saved filter by ID
+// @Description This endpoint gets one of the authenticated user's saved filters by ID
+// @Tags saved-filters
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param filter_id path string true "ID of saved filter to get"
+// @Success 200 {object} model.SavedFilter
+// @Failure 404
+// @Failure 500
+// @Router /v1/saved-filters/{filter_id} [get]
+func (h *Handler) getSavedFilter(w http.ResponseWriter, r *http.Request) {
+	filterID, err := h.readIDParam(r, "filter_id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	filter, err := h.ctrl.GetSavedFilter(ctx, filterID, userFromContext)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrNotFound):
+			h.notFoundResponse(w, r)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"saved_filter": filter}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// UpdateSavedFilter godoc
+// @Summary Update a saved filter
+// @Description This endpoint renames one of the authenticated user's saved filters or replaces its query string
+// @Tags saved-filters
+// @Accept  json
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param filter_id path string true "ID of saved filter to update"
+// @Param payload body updateSavedFilterPayload true "Request payload"
+// @Success 200 {object} model.SavedFilter
+// @Failure 400
+// @Failure 404
+// @Failure 409
+// @Failure 422
+// @Failure 500
+// @Router /v1/saved-filters/{filter_id} [patch]
+func (h *Handler) updateSavedFilter(w http.ResponseWriter, r *http.Request) {
+	filterID, err := h.readIDParam(r, "filter_id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	var requestPayload struct {
+		Name        *string `json:"name"`
+		QueryString *string `json:"query_string"`
+	}
+	err = h.decodeJSON(w, r, &requestPayload)
+	if err != nil {
+		h.badRequestResponse(w, r, err)
+		return
+	}
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	filter, err := h.ctrl.UpdateSavedFilter(ctx, filterID, requestPayload.Name, requestPayload.QueryString, userFromContext)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrNotFound):
+			h.notFoundResponse(w, r)
+		case errors.Is(err, issuetracker.ErrFailedValidation):
+			h.failedValidationResponse(w, r, err)
+		case errors.Is(err, issuetracker.ErrEditConflict):
+			h.editConflictResponse(w, r)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"saved_filter": filter}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// DeleteSavedFilter godoc
+// @Summary Delete a saved filter
+// @Description This endpoint deletes one of the authenticated user's saved filters
+// @Tags saved-filters
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param filter_id path string true "ID of saved filter to delete"
+// @Success 200
+// @Failure 404
+// @Failure 500
+// @Router /v1/saved-filters/{filter_id} [delete]
+func (h *Handler) deleteSavedFilter(w http.ResponseWriter, r *http.Request) {
+	filterID, err := h.readIDParam(r, "filter_id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	err = h.ctrl.DeleteSavedFilter(ctx, filterID, userFromContext)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrNotFound):
+			h.notFoundResponse(w, r)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"message": "saved filter successfully deleted"}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// RunSavedFilter godoc
+// @Summary Run a saved filter
+// @Description This endpoint gets the issues matching one of the authenticated user's saved filters, as the issue list would with the saved query string. The page and page_size of the request take precedence over the saved ones
+// @Tags saved-filters
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param filter_id path string true "ID of saved filter to run"
+// @Param page query string false "Query string param for pagination (min 1)"
+// @Param page_size query string false "Query string param for pagination (max 100)"
+// @Success 200 {array} model.Issue
+// @Failure 403
+// @Failure 404
+// @Failure 422
+// @Failure 500
+// @Router /v1/saved-filters/{filter_id}/run [get]
+func (h *Handler) runSavedFilter(w http.ResponseWriter, r *http.Request) {
+	filterID, err := h.readIDParam(r, "filter_id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	filter, err := h.ctrl.GetSavedFilter(ctx, filterID, userFromContext)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrNotFound):
+			h.notFoundResponse(w, r)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	qs, err := filter.Query()
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+		return
+	}
+	for _, key := range []string{"page", "page_size"} {
+		if value := r.URL.Query().Get(key); value != "" {
+			qs.Set(key, value)
+		}
+	}
+	h.listIssues(w, r, qs)
+}
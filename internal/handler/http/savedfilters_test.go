@@ -0,0 +1,79 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/emzola/issuetracker/config"
+	"github.com/emzola/issuetracker/internal/controller/issuetracker"
+	"github.com/emzola/issuetracker/internal/repository"
+	"github.com/emzola/issuetracker/internal/repository/postgres"
+	"github.com/emzola/issuetracker/pkg/model"
+	"github.com/julienschmidt/httprouter"
+	"go.uber.org/zap"
+)
+
+// savedFilterRepository serves a single saved filter of user 1 and records the issue list
+// query it runs. Methods that are not overridden are not expected to be called.
+type savedFilterRepository struct {
+	*postgres.Repository
+	filter   *model.SavedFilter
+	status   string
+	priority string
+	filters  model.Filters
+}
+
+func (r *savedFilterRepository) GetSavedFilter(ctx context.Context, id, userID int64) (*model.SavedFilter, error) {
+	if id != r.filter.ID || userID != r.filter.UserID {
+		return nil, repository.ErrNotFound
+	}
+	return r.filter, nil
+}
+
+func (r *savedFilterRepository) GetAllIssues(ctx context.Context, issueFilters model.IssueFilters, viewerID int64, filters model.Filters) ([]*model.Issue, model.Metadata, error) {
+	r.status, r.priority, r.filters = issueFilters.Status, issueFilters.Priority, filters
+	return []*model.Issue{}, model.Metadata{}, nil
+}
+
+func TestRunSavedFilter(t *testing.T) {
+	tests := []struct {
+		name         string
+		user         int64
+		query        string
+		wantStatus   int
+		wantPage     int
+		wantPageSize int
+	}{
+		{"saved query", 1, "", http.StatusOK, 1, 5},
+		{"requested page", 1, "?page=2&page_size=10", http.StatusOK, 2, 10},
+		{"requested filters are ignored", 1, "?status=closed", http.StatusOK, 1, 5},
+		{"another user's filter", 2, "", http.StatusNotFound, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &savedFilterRepository{filter: &model.SavedFilter{ID: 1, UserID: 1, Name: "Urgent", QueryString: "status=open&priority=high&sort=-reported_date&page_size=5"}}
+			var wg sync.WaitGroup
+			h := New(issuetracker.New(repo, config.App{}, &wg, zap.NewNop()), config.App{}, nil)
+			r := httptest.NewRequest(http.MethodGet, "/v1/saved-filters/1/run"+tt.query, nil)
+			r = r.WithContext(context.WithValue(r.Context(), httprouter.ParamsKey, httprouter.Params{{Key: "filter_id", Value: "1"}}))
+			r = h.contextSetUser(r, &model.User{ID: tt.user, Role: "member", Activated: true})
+			w := httptest.NewRecorder()
+			h.runSavedFilter(w, r)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("runSavedFilter() status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			if repo.status != "open" || repo.priority != "high" || repo.filters.Sort != "-reported_date" {
+				t.Errorf("runSavedFilter() ran status %q, priority %q, sort %q, want the saved query", repo.status, repo.priority, repo.filters.Sort)
+			}
+			if repo.filters.Page != tt.wantPage || repo.filters.PageSize != tt.wantPageSize {
+				t.Errorf("runSavedFilter() ran page %d of size %d, want page %d of size %d", repo.filters.Page, repo.filters.PageSize, tt.wantPage, tt.wantPageSize)
+			}
+		})
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"errors"
 	"net/http"
-	"time"
 
 	"github.com/emzola/issuetracker/internal/controller/issuetracker"
 )
@@ -18,6 +17,7 @@ import (
 // @Param payload body createActivationTokenPayload true "Request payload"
 // @Success 200
 // @Failure 400
+// @Failure 409
 // @Failure 422
 // @Failure 500
 // @Router /v1/tokens/activation [post]
@@ -30,8 +30,7 @@ func (h *Handler) createActivationToken(w http.ResponseWriter, r *http.Request)
 		h.badRequestResponse(w, r, err)
 		return
 	}
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
+	ctx := r.Context()
 	user, err := h.ctrl.GetUserByEmail(ctx, requestPayload.Email)
 	if err != nil {
 		switch {
@@ -64,12 +63,12 @@ func (h *Handler) createActivationToken(w http.ResponseWriter, r *http.Request)
 
 // CreateAuthenticationToken godoc
 // @Summary Create JWT authentication token
-// @Description This endpoint creates JWT token
+// @Description This endpoint creates a JWT token, valid for the configured expiry (24 hours by default). The response includes the token's jti, by which it is revoked on logout, and the time it was issued at and expires at
 // @Tags tokens
 // @Accept  json
 // @Produce json
 // @Param payload body createAuthenticationTokenPayload true "Request payload"
-// @Success 201 {object} model.Token
+// @Success 201 {object} authenticationTokenResponse
 // @Failure 400
 // @Failure 401
 // @Failure 422
@@ -85,9 +84,51 @@ func (h *Handler) createAuthenticationToken(w http.ResponseWriter, r *http.Reque
 		h.badRequestResponse(w, r, err)
 		return
 	}
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
-	jwtBytes, err := h.ctrl.CreateAuthenticationToken(ctx, requestPayload.Email, requestPayload.Password)
+	ctx := r.Context()
+	authToken, refreshToken, err := h.ctrl.CreateAuthenticationToken(ctx, requestPayload.Email, requestPayload.Password)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrFailedValidation):
+			h.failedValidationResponse(w, r, err)
+		case errors.Is(err, issuetracker.ErrInvalidCredentials):
+			h.invalidCredentialsResponse(w, r)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusCreated, envelop{"authentication_token": authToken.Token, "jti": authToken.ID, "issued_at": authToken.Issued, "expires_at": authToken.Expiry, "refresh_token": refreshToken}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// RefreshAuthenticationToken godoc
+// @Summary Refresh JWT authentication token
+// @Description This endpoint exchanges a refresh token for a new JWT and a new refresh token. The old refresh token can no longer be used.
+// @Tags tokens
+// @Accept  json
+// @Produce json
+// @Param payload body refreshAuthenticationTokenPayload true "Request payload"
+// @Success 201 {object} authenticationTokenResponse
+// @Failure 400
+// @Failure 401
+// @Failure 422
+// @Failure 500
+// @Router /v1/tokens/refresh [post]
+func (h *Handler) refreshAuthenticationToken(w http.ResponseWriter, r *http.Request) {
+	var requestPayload struct {
+		Token string `json:"token"`
+	}
+	err := h.decodeJSON(w, r, &requestPayload)
+	if err != nil {
+		h.badRequestResponse(w, r, err)
+		return
+	}
+	ctx := r.Context()
+	authToken, refreshToken, err := h.ctrl.RefreshAuthenticationToken(ctx, requestPayload.Token)
 	if err != nil {
 		switch {
 		case errors.Is(err, context.Canceled):
@@ -101,7 +142,152 @@ func (h *Handler) createAuthenticationToken(w http.ResponseWriter, r *http.Reque
 		}
 		return
 	}
-	err = h.encodeJSON(w, http.StatusCreated, envelop{"authentication_token": string(jwtBytes)}, nil)
+	err = h.encodeJSON(w, http.StatusCreated, envelop{"authentication_token": authToken.Token, "jti": authToken.ID, "issued_at": authToken.Issued, "expires_at": authToken.Expiry, "refresh_token": refreshToken}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// RevokeRefreshToken godoc
+// @Summary Revoke a refresh token
+// @Description This endpoint revokes a refresh token, for example when logging out
+// @Tags tokens
+// @Accept  json
+// @Produce json
+// @Param payload body revokeRefreshTokenPayload true "Request payload"
+// @Success 200
+// @Failure 400
+// @Failure 404
+// @Failure 422
+// @Failure 500
+// @Router /v1/tokens/refresh [delete]
+func (h *Handler) revokeRefreshToken(w http.ResponseWriter, r *http.Request) {
+	var requestPayload struct {
+		Token string `json:"token"`
+	}
+	err := h.decodeJSON(w, r, &requestPayload)
+	if err != nil {
+		h.badRequestResponse(w, r, err)
+		return
+	}
+	ctx := r.Context()
+	err = h.ctrl.RevokeRefreshToken(ctx, requestPayload.Token)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrFailedValidation):
+			h.failedValidationResponse(w, r, err)
+		case errors.Is(err, issuetracker.ErrNotFound):
+			h.notFoundResponse(w, r)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"message": "refresh token successfully revoked"}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// Logout godoc
+// @Summary Log out of the current session
+// @Description This endpoint revokes the authentication token used to make the request, until it expires. Refresh tokens are revoked separately
+// @Tags tokens
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Success 200
+// @Failure 401
+// @Failure 500
+// @Router /v1/tokens/logout [post]
+func (h *Handler) logout(w http.ResponseWriter, r *http.Request) {
+	claims, ok := h.contextGetClaims(r)
+	if !ok {
+		h.invalidAuthenticationTokenResponse(w, r)
+		return
+	}
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	err := h.ctrl.RevokeAuthenticationToken(ctx, userFromContext, claims.ID, claims.Expires.Time())
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"message": "successfully logged out"}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// CreateCalendarToken godoc
+// @Summary Create a calendar feed token
+// @Description This endpoint creates a token for the user's calendar feed of assigned issue due dates. Any previous calendar token is revoked.
+// @Tags tokens
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Success 201 {object} model.Token
+// @Failure 500
+// @Router /v1/tokens/calendar [post]
+func (h *Handler) createCalendarToken(w http.ResponseWriter, r *http.Request) {
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	token, err := h.ctrl.CreateCalendarToken(ctx, userFromContext)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusCreated, envelop{"calendar_token": token}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// CreatePasswordResetToken godoc
+// @Summary Create a password reset token
+// @Description This endpoint emails the user a token for resetting their password. The same response is returned whether or not the email address belongs to an activated user
+// @Tags tokens
+// @Accept  json
+// @Produce json
+// @Param payload body createPasswordResetTokenPayload true "Request payload"
+// @Success 202
+// @Failure 400
+// @Failure 422
+// @Failure 500
+// @Router /v1/tokens/password-reset [post]
+func (h *Handler) createPasswordResetToken(w http.ResponseWriter, r *http.Request) {
+	var requestPayload struct {
+		Email string `json:"email"`
+	}
+	err := h.decodeJSON(w, r, &requestPayload)
+	if err != nil {
+		h.badRequestResponse(w, r, err)
+		return
+	}
+	ctx := r.Context()
+	err = h.ctrl.CreatePasswordResetToken(ctx, requestPayload.Email)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrFailedValidation):
+			h.failedValidationResponse(w, r, err)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusAccepted, envelop{"message": "if the email address belongs to an activated account, an email will be sent to it containing password reset instructions"}, nil)
 	if err != nil {
 		h.serverErrorResponse(w, r, err)
 	}
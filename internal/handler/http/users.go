@@ -4,7 +4,6 @@ import (
 	"context"
 	"errors"
 	"net/http"
-	"time"
 
 	"github.com/emzola/issuetracker/internal/controller/issuetracker"
 	"github.com/emzola/issuetracker/pkg/model"
@@ -30,16 +29,20 @@ func (h *Handler) createUser(w http.ResponseWriter, r *http.Request) {
 		Email    string `json:"email"`
 		Password string `json:"password"`
 		Role     string `json:"role"`
+		Locale   string `json:"locale"`
 	}
 	err := h.decodeJSON(w, r, &requestPayload)
 	if err != nil {
 		h.badRequestResponse(w, r, err)
 		return
 	}
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
-	userFromContext := h.contextGetUser(r)
-	user, err := h.ctrl.CreateUser(ctx, requestPayload.Name, requestPayload.Email, requestPayload.Password, requestPayload.Role, userFromContext.Name, userFromContext.Name)
+	ctx := r.Context()
+	// Users who sign themselves up are recorded as their own author.
+	author := h.contextGetUser(r).Name
+	if h.contextGetUser(r).IsAnonymous() {
+		author = requestPayload.Name
+	}
+	user, err := h.ctrl.CreateUser(ctx, requestPayload.Name, requestPayload.Email, requestPayload.Password, requestPayload.Role, requestPayload.Locale, author, author)
 	if err != nil {
 		switch {
 		case errors.Is(err, context.Canceled):
@@ -78,9 +81,9 @@ func (h *Handler) activateUser(w http.ResponseWriter, r *http.Request) {
 	err := h.decodeJSON(w, r, &requestPayload)
 	if err != nil {
 		h.badRequestResponse(w, r, err)
+		return
 	}
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
+	ctx := r.Context()
 	user, err := h.ctrl.GetUserForToken(ctx, model.ScopeActivation, requestPayload.Token)
 	if err != nil {
 		switch {
@@ -93,12 +96,202 @@ func (h *Handler) activateUser(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
+	// Users who activate their own account with a token are recorded as the modifier.
+	author := h.contextGetUser(r).Name
+	if h.contextGetUser(r).IsAnonymous() {
+		author = user.Name
+	}
+	err = h.ctrl.ActivateUser(ctx, user, author)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrFailedValidation):
+			h.failedValidationResponse(w, r, err)
+		case errors.Is(err, issuetracker.ErrActivated):
+			h.alreadyActivatedResponse(w, r)
+		case errors.Is(err, issuetracker.ErrEditConflict):
+			h.editConflictResponse(w, r)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"user": user}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// ResetUserPassword godoc
+// @Summary Reset a user's password
+// @Description Set a new password with a password reset token
+// @Tags users
+// @Accept  json
+// @Produce json
+// @Param payload body resetUserPasswordPayload true "Request payload"
+// @Success 200
+// @Failure 400
+// @Failure 409
+// @Failure 422
+// @Failure 500
+// @Router /v1/users/password [put]
+func (h *Handler) resetUserPassword(w http.ResponseWriter, r *http.Request) {
+	var requestPayload struct {
+		Password string `json:"password"`
+		Token    string `json:"token"`
+	}
+	err := h.decodeJSON(w, r, &requestPayload)
+	if err != nil {
+		h.badRequestResponse(w, r, err)
+		return
+	}
+	ctx := r.Context()
+	_, err = h.ctrl.ResetPassword(ctx, requestPayload.Token, requestPayload.Password)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrFailedValidation):
+			h.failedValidationResponse(w, r, err)
+		case errors.Is(err, issuetracker.ErrEditConflict):
+			h.editConflictResponse(w, r)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"message": "your password was successfully reset"}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// ChangeUserPassword godoc
+// @Summary Change the authenticated user's password
+// @Description Replace the authenticated user's password after checking their current one
+// @Tags users
+// @Accept  json
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param payload body changeUserPasswordPayload true "Request payload"
+// @Success 200
+// @Failure 400
+// @Failure 401
+// @Failure 409
+// @Failure 422
+// @Failure 500
+// @Router /v1/users/password/change [put]
+func (h *Handler) changeUserPassword(w http.ResponseWriter, r *http.Request) {
+	var requestPayload struct {
+		CurrentPassword string `json:"current_password"`
+		NewPassword     string `json:"new_password"`
+	}
+	err := h.decodeJSON(w, r, &requestPayload)
+	if err != nil {
+		h.badRequestResponse(w, r, err)
+		return
+	}
 	userFromContext := h.contextGetUser(r)
-	err = h.ctrl.ActivateUser(ctx, user, userFromContext.Name)
+	ctx := r.Context()
+	_, err = h.ctrl.ChangePassword(ctx, userFromContext.ID, requestPayload.CurrentPassword, requestPayload.NewPassword)
 	if err != nil {
 		switch {
 		case errors.Is(err, context.Canceled):
 			return
+		case errors.Is(err, issuetracker.ErrInvalidCredentials):
+			h.invalidCredentialsResponse(w, r)
+		case errors.Is(err, issuetracker.ErrFailedValidation):
+			h.failedValidationResponse(w, r, err)
+		case errors.Is(err, issuetracker.ErrEditConflict):
+			h.editConflictResponse(w, r)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"message": "your password was successfully changed; please log in again"}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// RequestUserEmailChange godoc
+// @Summary Request a change of the authenticated user's email
+// @Description Email a token for confirming the change to the new address. The current email stays in use until the change is confirmed
+// @Tags users
+// @Accept  json
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param payload body requestUserEmailChangePayload true "Request payload"
+// @Success 202
+// @Failure 400
+// @Failure 401
+// @Failure 409
+// @Failure 422
+// @Failure 500
+// @Router /v1/users/email [put]
+func (h *Handler) requestUserEmailChange(w http.ResponseWriter, r *http.Request) {
+	var requestPayload struct {
+		Email string `json:"email"`
+	}
+	err := h.decodeJSON(w, r, &requestPayload)
+	if err != nil {
+		h.badRequestResponse(w, r, err)
+		return
+	}
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	err = h.ctrl.RequestEmailChange(ctx, userFromContext, requestPayload.Email)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrFailedValidation):
+			h.failedValidationResponse(w, r, err)
+		case errors.Is(err, issuetracker.ErrEditConflict):
+			h.editConflictResponse(w, r)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusAccepted, envelop{"message": "an email will be sent to the new address containing instructions to confirm it"}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// ConfirmUserEmailChange godoc
+// @Summary Confirm a change of a user's email
+// @Description Change a user's email to the one they asked to change to with an email change token
+// @Tags users
+// @Accept  json
+// @Produce json
+// @Param payload body confirmUserEmailChangePayload true "Request payload"
+// @Success 200 {object} model.User
+// @Failure 400
+// @Failure 409
+// @Failure 422
+// @Failure 500
+// @Router /v1/users/email/confirm [put]
+func (h *Handler) confirmUserEmailChange(w http.ResponseWriter, r *http.Request) {
+	var requestPayload struct {
+		Token string `json:"token"`
+	}
+	err := h.decodeJSON(w, r, &requestPayload)
+	if err != nil {
+		h.badRequestResponse(w, r, err)
+		return
+	}
+	ctx := r.Context()
+	user, err := h.ctrl.ConfirmEmailChange(ctx, requestPayload.Token)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrFailedValidation):
+			h.failedValidationResponse(w, r, err)
 		case errors.Is(err, issuetracker.ErrEditConflict):
 			h.editConflictResponse(w, r)
 		default:
@@ -114,12 +307,14 @@ func (h *Handler) activateUser(w http.ResponseWriter, r *http.Request) {
 
 // GetUser godoc
 // @Summary Get user by ID
-// @Description This endpoint gets a user by ID
+// @Description This endpoint gets a user by ID. The response has an ETag of the user's version, and is 304 Not Modified if it matches If-None-Match
 // @Tags users
 // @Produce json
 // @Param token header string true "Bearer token"
+// @Param If-None-Match header string false "ETag of a previously fetched version of the user"
 // @Param user_id path string true "ID of user to get"
 // @Success 200 {object} model.User
+// @Success 304
 // @Failure 404
 // @Failure 500
 // @Router /v1/users/{user_id} [get]
@@ -129,8 +324,7 @@ func (h *Handler) getUser(w http.ResponseWriter, r *http.Request) {
 		h.notFoundResponse(w, r)
 		return
 	}
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
+	ctx := r.Context()
 	user, err := h.ctrl.GetUserByID(ctx, userID)
 	if err != nil {
 		switch {
@@ -143,6 +337,72 @@ func (h *Handler) getUser(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
+	if h.notModified(w, r, versionETag(int64(user.Version))) {
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"user": user}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// GetCurrentUser godoc
+// @Summary Get the authenticated user
+// @Description This endpoint gets the authenticated user's own profile
+// @Tags users
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Success 200 {object} model.User
+// @Failure 401
+// @Failure 403
+// @Router /v1/users/me [get]
+func (h *Handler) getCurrentUser(w http.ResponseWriter, r *http.Request) {
+	err := h.encodeJSON(w, http.StatusOK, envelop{"user": h.contextGetUser(r)}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// UpdateCurrentUser godoc
+// @Summary Update the authenticated user
+// @Description This endpoint updates the authenticated user's own preferences: whether they get a daily email digest of their open assigned issues, and the locale of their emails
+// @Tags users
+// @Accept  json
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param payload body updateCurrentUserPayload true "Request payload"
+// @Success 200 {object} model.User
+// @Failure 400
+// @Failure 401
+// @Failure 403
+// @Failure 409
+// @Failure 422
+// @Failure 500
+// @Router /v1/users/me [patch]
+func (h *Handler) updateCurrentUser(w http.ResponseWriter, r *http.Request) {
+	var requestPayload struct {
+		DigestOptIn *bool   `json:"digest_opt_in"`
+		Locale      *string `json:"locale"`
+	}
+	err := h.decodeJSON(w, r, &requestPayload)
+	if err != nil {
+		h.badRequestResponse(w, r, err)
+		return
+	}
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	user, err := h.ctrl.UpdateCurrentUser(ctx, userFromContext, requestPayload.DigestOptIn, requestPayload.Locale)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrEditConflict):
+			h.editConflictResponse(w, r)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
 	err = h.encodeJSON(w, http.StatusOK, envelop{"user": user}, nil)
 	if err != nil {
 		h.serverErrorResponse(w, r, err)
@@ -158,6 +418,7 @@ func (h *Handler) getUser(w http.ResponseWriter, r *http.Request) {
 // @Param name query string false "Query string param for name"
 // @Param email query string false "Query string param for email"
 // @Param role query string false "Query string param for role"
+// @Param q query string false "Query string param for searching any part of names and emails"
 // @Param page query string false "Query string param for pagination (min 1)"
 // @Param page_size query string false "Query string param for pagination (max 100)"
 // @Param sort query string false "Sort by asc or desc order. Asc: id, name, email, created_on, modified_on | Desc: -id, -name, -email, -created_on, -modified_on"
@@ -170,6 +431,7 @@ func (h *Handler) getAllUsers(w http.ResponseWriter, r *http.Request) {
 		Name    string `json:"name"`
 		Email   string `json:"email"`
 		Role    string `json:"role"`
+		Query   string `json:"q"`
 		Filters model.Filters
 	}
 	v := validator.New()
@@ -177,13 +439,14 @@ func (h *Handler) getAllUsers(w http.ResponseWriter, r *http.Request) {
 	requestQuery.Name = h.readString(qs, "name", "")
 	requestQuery.Email = h.readString(qs, "email", "")
 	requestQuery.Role = h.readString(qs, "role", "")
+	requestQuery.Query = h.readString(qs, "q", "")
 	requestQuery.Filters.Page = h.readInt(qs, "page", 1, v)
 	requestQuery.Filters.PageSize = h.readInt(qs, "page_size", 20, v)
+	requestQuery.Filters.MaxPageSize = h.Config.Pagination.MaxPageSize
 	requestQuery.Filters.Sort = h.readString(qs, "sort", "id")
 	requestQuery.Filters.SortSafelist = []string{"id", "name", "email", "created_on", "modified_on", "-id", "-name", "-email", "-created_on", "-modified_on"}
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
-	users, metadata, err := h.ctrl.GetAllUsers(ctx, requestQuery.Name, requestQuery.Email, requestQuery.Role, requestQuery.Filters, v)
+	ctx := r.Context()
+	users, metadata, err := h.ctrl.GetAllUsers(ctx, requestQuery.Name, requestQuery.Email, requestQuery.Role, requestQuery.Query, requestQuery.Filters, v)
 	if err != nil {
 		switch {
 		case errors.Is(err, context.Canceled):
@@ -195,7 +458,7 @@ func (h *Handler) getAllUsers(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
-	err = h.encodeJSON(w, http.StatusOK, envelop{"users": users, "metadata": metadata}, nil)
+	err = h.encodeJSON(w, http.StatusOK, envelop{"users": users, "metadata": metadata}, h.paginationHeaders(r, metadata))
 	if err != nil {
 		h.serverErrorResponse(w, r, err)
 	}
@@ -203,15 +466,18 @@ func (h *Handler) getAllUsers(w http.ResponseWriter, r *http.Request) {
 
 // UpdateUser godoc
 // @Summary Update a user
-// @Description This endpoint updates a user
+// @Description This endpoint updates a user. Only managers can update other users or change roles. A new email only replaces the current one once the token emailed to it is confirmed
 // @Tags users
 // @Accept  json
 // @Produce json
 // @Param token header string true "Bearer token"
 // @Param payload body updateUserPayload true "Request payload"
 // @Param user_id path string true "ID of user to update"
+// @Param Prefer header string false "return=minimal to only return changed fields and version"
+// @Param return query string false "Query string param for return (minimal|representation)"
 // @Success 200 {object} model.User
 // @Failure 400
+// @Failure 403
 // @Failure 404
 // @Failure 409
 // @Failure 422
@@ -219,9 +485,10 @@ func (h *Handler) getAllUsers(w http.ResponseWriter, r *http.Request) {
 // @Router /v1/users/{user_id} [patch]
 func (h *Handler) updateUser(w http.ResponseWriter, r *http.Request) {
 	var requestPayload struct {
-		Name  *string `json:"name"`
-		Email *string `json:"email"`
-		Role  *string `json:"role"`
+		Name   *string `json:"name"`
+		Email  *string `json:"email"`
+		Role   *string `json:"role"`
+		Locale *string `json:"locale"`
 	}
 	userID, err := h.readIDParam(r, "user_id")
 	if err != nil {
@@ -234,13 +501,31 @@ func (h *Handler) updateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	userFromContext := h.contextGetUser(r)
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
-	user, err := h.ctrl.UpdateUser(ctx, userID, requestPayload.Name, requestPayload.Email, requestPayload.Role, userFromContext.Name)
+	ctx := r.Context()
+	// Fetch the pre-update state so that only the changed fields can be returned
+	// when the client asks for a minimal response.
+	var before *model.User
+	if h.wantsMinimalReturn(r) {
+		before, err = h.ctrl.GetUserByID(ctx, userID)
+		if err != nil {
+			switch {
+			case errors.Is(err, context.Canceled):
+				return
+			case errors.Is(err, issuetracker.ErrNotFound):
+				h.notFoundResponse(w, r)
+			default:
+				h.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+	}
+	user, err := h.ctrl.UpdateUser(ctx, userID, requestPayload.Name, requestPayload.Email, requestPayload.Role, requestPayload.Locale, userFromContext)
 	if err != nil {
 		switch {
 		case errors.Is(err, context.Canceled):
 			return
+		case errors.Is(err, issuetracker.ErrNotPermitted):
+			h.notPermittedResponse(w, r)
 		case errors.Is(err, issuetracker.ErrNotFound):
 			h.notFoundResponse(w, r)
 		case errors.Is(err, issuetracker.ErrFailedValidation):
@@ -252,7 +537,7 @@ func (h *Handler) updateUser(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
-	err = h.encodeJSON(w, http.StatusOK, envelop{"user": user}, nil)
+	err = h.encodeUpdate(w, r, "user", before, user, int64(user.Version), nil)
 	if err != nil {
 		h.serverErrorResponse(w, r, err)
 	}
@@ -260,13 +545,16 @@ func (h *Handler) updateUser(w http.ResponseWriter, r *http.Request) {
 
 // DeleteUser godoc
 // @Summary Delete a user
-// @Description This endpoint deletes a user
+// @Description This endpoint deletes a user, unassigning the issues and projects assigned to them. Users with open issues assigned to them are only deleted with force=true. Users who reported issues can't be deleted
 // @Tags users
 // @Produce json
 // @Param token header string true "Bearer token"
 // @Param user_id path string true "ID of user to delete"
+// @Param force query string false "Query string param for whether to unassign the user's open issues (true|false)"
 // @Success 200
 // @Failure 404
+// @Failure 409
+// @Failure 422
 // @Failure 500
 // @Router /v1/users/{user_id} [delete]
 func (h *Handler) deleteUser(w http.ResponseWriter, r *http.Request) {
@@ -275,13 +563,24 @@ func (h *Handler) deleteUser(w http.ResponseWriter, r *http.Request) {
 		h.notFoundResponse(w, r)
 		return
 	}
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
-	err = h.ctrl.DeleteUser(ctx, userID)
+	v := validator.New()
+	force := h.readBool(r.URL.Query(), "force", false, v)
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	var openIssuesErr *issuetracker.OpenIssuesError
+	err = h.ctrl.DeleteUser(ctx, userID, force, userFromContext, v)
 	if err != nil {
 		switch {
+		case errors.Is(err, context.Canceled):
+			return
 		case errors.Is(err, issuetracker.ErrNotFound):
 			h.notFoundResponse(w, r)
+		case errors.Is(err, issuetracker.ErrFailedValidation):
+			h.failedValidationResponse(w, r, err)
+		case errors.As(err, &openIssuesErr):
+			h.openIssuesResponse(w, r, openIssuesErr.Count)
+		case errors.Is(err, issuetracker.ErrReportedIssues):
+			h.reportedIssuesResponse(w, r)
 		default:
 			h.serverErrorResponse(w, r, err)
 		}
@@ -322,8 +621,7 @@ func (h *Handler) assignUserToProject(w http.ResponseWriter, r *http.Request) {
 		h.badRequestResponse(w, r, err)
 		return
 	}
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
+	ctx := r.Context()
 	err = h.ctrl.AssignUserToProject(ctx, userID, requestPayload.ProjectID)
 	if err != nil {
 		switch {
@@ -372,10 +670,10 @@ func (h *Handler) getAllProjectsForUser(w http.ResponseWriter, r *http.Request)
 	qs := r.URL.Query()
 	queryParams.Filters.Page = h.readInt(qs, "page", 1, v)
 	queryParams.Filters.PageSize = h.readInt(qs, "page_size", 20, v)
+	queryParams.Filters.MaxPageSize = h.Config.Pagination.MaxPageSize
 	queryParams.Filters.Sort = h.readString(qs, "sort", "id")
 	queryParams.Filters.SortSafelist = []string{"id", "-id"}
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
+	ctx := r.Context()
 	projects, metadata, err := h.ctrl.GetAllProjectsForUser(ctx, userID, queryParams.Filters, v)
 	if err != nil {
 		switch {
@@ -388,7 +686,116 @@ func (h *Handler) getAllProjectsForUser(w http.ResponseWriter, r *http.Request)
 		}
 		return
 	}
-	err = h.encodeJSON(w, http.StatusOK, envelop{"projects": projects, "metadata": metadata}, nil)
+	err = h.encodeJSON(w, http.StatusOK, envelop{"projects": projects, "metadata": metadata}, h.paginationHeaders(r, metadata))
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// GetUserInvolvement godoc
+// @Summary Get issues a user is involved in
+// @Description This endpoint gets the issues a user has reported, is assigned, has commented on or is mentioned in with "@" and their name, grouped by involvement
+// @Tags users
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param user_id path string true "ID of user to get involvement"
+// @Param page query string false "Query string param for pagination (min 1)"
+// @Param page_size query string false "Query string param for pagination (max 100)"
+// @Param sort query string false "Sort by asc or desc order. Asc: id, title, reported_date, project_id, status, priority | Desc: -id, -title, -reported_date, -project_id, -status, -priority"
+// @Success 200 {object} model.UserInvolvement
+// @Failure 404
+// @Failure 422
+// @Failure 500
+// @Router /v1/users/{user_id}/involvement [get]
+func (h *Handler) getUserInvolvement(w http.ResponseWriter, r *http.Request) {
+	var queryParams struct {
+		Filters model.Filters
+	}
+	userID, err := h.readIDParam(r, "user_id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	v := validator.New()
+	qs := r.URL.Query()
+	queryParams.Filters.Page = h.readInt(qs, "page", 1, v)
+	queryParams.Filters.PageSize = h.readInt(qs, "page_size", 20, v)
+	queryParams.Filters.MaxPageSize = h.Config.Pagination.MaxPageSize
+	queryParams.Filters.Sort = h.readString(qs, "sort", "id")
+	queryParams.Filters.SortSafelist = []string{"id", "title", "reported_date", "project_id", "status", "priority", "-id", "-title", "-reported_date", "-project_id", "-status", "-priority"}
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	involvement, err := h.ctrl.GetUserInvolvement(ctx, userID, userFromContext, queryParams.Filters, v)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrNotFound):
+			h.notFoundResponse(w, r)
+		case errors.Is(err, issuetracker.ErrFailedValidation):
+			h.failedValidationResponse(w, r, err)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"involvement": involvement}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// GetUserVelocity godoc
+// @Summary Get a user's issue resolution velocity
+// @Description This endpoint gets the number of issues a user closed per interval, based on actual resolution date
+// @Tags users
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param user_id path string true "ID of user to get velocity"
+// @Param project_id query string false "Query string param for project_id"
+// @Param interval query string false "Query string param for interval (day|week|month)"
+// @Param from query string false "Query string param for start date (YYYY-MM-DD)"
+// @Param to query string false "Query string param for end date (YYYY-MM-DD)"
+// @Success 200 {array} model.ResolutionVelocity
+// @Failure 404
+// @Failure 422
+// @Failure 500
+// @Router /v1/users/{user_id}/velocity [get]
+func (h *Handler) getUserVelocity(w http.ResponseWriter, r *http.Request) {
+	var queryParams struct {
+		ProjectID int64
+		Interval  string
+		From      string
+		To        string
+	}
+	userID, err := h.readIDParam(r, "user_id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	v := validator.New()
+	qs := r.URL.Query()
+	queryParams.ProjectID = int64(h.readInt(qs, "project_id", 0, v))
+	queryParams.Interval = h.readString(qs, "interval", "week")
+	queryParams.From = h.readString(qs, "from", "")
+	queryParams.To = h.readString(qs, "to", "")
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	velocity, err := h.ctrl.GetUserResolutionVelocity(ctx, userID, queryParams.ProjectID, queryParams.Interval, queryParams.From, queryParams.To, userFromContext, v)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrNotFound):
+			h.notFoundResponse(w, r)
+		case errors.Is(err, issuetracker.ErrFailedValidation):
+			h.failedValidationResponse(w, r, err)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"velocity": velocity}, nil)
 	if err != nil {
 		h.serverErrorResponse(w, r, err)
 	}
@@ -0,0 +1,120 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/emzola/issuetracker/internal/controller/issuetracker"
+)
+
+// SubscribeToIssue godoc
+// @Summary Watch an issue
+// @Description This endpoint subscribes the authenticated user to email notifications about changes to an issue's status, priority or assignee
+// @Tags issues
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param issue_id path string true "ID of issue to watch"
+// @Success 200
+// @Failure 404
+// @Failure 500
+// @Router /v1/issues/{issue_id}/watchers [post]
+func (h *Handler) subscribeToIssue(w http.ResponseWriter, r *http.Request) {
+	issueID, err := h.readIDParam(r, "issue_id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	err = h.ctrl.SubscribeToIssue(ctx, issueID, userFromContext)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrNotFound):
+			h.notFoundResponse(w, r)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"message": "you are now watching this issue"}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// UnsubscribeFromIssue godoc
+// @Summary Stop watching an issue
+// @Description This endpoint unsubscribes the authenticated user from notifications about an issue
+// @Tags issues
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param issue_id path string true "ID of issue to stop watching"
+// @Success 200
+// @Failure 404
+// @Failure 500
+// @Router /v1/issues/{issue_id}/watchers [delete]
+func (h *Handler) unsubscribeFromIssue(w http.ResponseWriter, r *http.Request) {
+	issueID, err := h.readIDParam(r, "issue_id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	err = h.ctrl.UnsubscribeFromIssue(ctx, issueID, userFromContext)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrNotFound):
+			h.notFoundResponse(w, r)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"message": "you are no longer watching this issue"}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// GetIssueWatchers godoc
+// @Summary Get issue watchers
+// @Description This endpoint gets the users watching an issue
+// @Tags issues
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param issue_id path string true "ID of issue to get watchers"
+// @Success 200 {array} model.Watcher
+// @Failure 404
+// @Failure 500
+// @Router /v1/issues/{issue_id}/watchers [get]
+func (h *Handler) getIssueWatchers(w http.ResponseWriter, r *http.Request) {
+	issueID, err := h.readIDParam(r, "issue_id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	watchers, err := h.ctrl.GetIssueWatchers(ctx, issueID, userFromContext)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrNotFound):
+			h.notFoundResponse(w, r)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"watchers": watchers}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,263 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/emzola/issuetracker/internal/controller/issuetracker"
+)
+
+// CreateWebhook godoc
+// @Summary Add a webhook to a project
+// @Description Add a webhook that is sent the project's issue events (issue.created, issue.updated, issue.closed) as signed JSON POST requests. The X-Signature header holds "sha256=" followed by the hex encoded HMAC-SHA256 of the body, keyed with the webhook secret. Leads can only add webhooks to projects assigned to them
+// @Tags webhooks
+// @Accept  json
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param project_id path string true "ID of project to add webhook to"
+// @Param payload body createWebhookPayload true "Request payload"
+// @Success 201 {object} model.Webhook
+// @Failure 400
+// @Failure 403
+// @Failure 404
+// @Failure 422
+// @Failure 500
+// @Router /v1/projects/{project_id}/webhooks [post]
+func (h *Handler) createWebhook(w http.ResponseWriter, r *http.Request) {
+	var requestPayload struct {
+		URL    string   `json:"url"`
+		Secret string   `json:"secret"`
+		Events []string `json:"events"`
+	}
+	projectID, err := h.readIDParam(r, "project_id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	err = h.decodeJSON(w, r, &requestPayload)
+	if err != nil {
+		h.badRequestResponse(w, r, err)
+		return
+	}
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	webhook, err := h.ctrl.CreateWebhook(ctx, projectID, requestPayload.URL, requestPayload.Secret, requestPayload.Events, userFromContext)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrNotPermitted):
+			h.notPermittedResponse(w, r)
+		case errors.Is(err, issuetracker.ErrNotFound):
+			h.notFoundResponse(w, r)
+		case errors.Is(err, issuetracker.ErrFailedValidation):
+			h.failedValidationResponse(w, r, err)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusCreated, envelop{"webhook": webhook}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// GetProjectWebhooks godoc
+// @Summary Get all webhooks of a project
+// @Description This endpoint gets all webhooks of a project. Leads can only get the webhooks of projects assigned to them
+// @Tags webhooks
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param project_id path string true "ID of project to get webhooks"
+// @Success 200 {array} model.Webhook
+// @Failure 403
+// @Failure 404
+// @Failure 500
+// @Router /v1/projects/{project_id}/webhooks [get]
+func (h *Handler) getProjectWebhooks(w http.ResponseWriter, r *http.Request) {
+	projectID, err := h.readIDParam(r, "project_id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	webhooks, err := h.ctrl.GetProjectWebhooks(ctx, projectID, userFromContext)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrNotPermitted):
+			h.notPermittedResponse(w, r)
+		case errors.Is(err, issuetracker.ErrNotFound):
+			h.notFoundResponse(w, r)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"webhooks": webhooks}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// GetWebhook godoc
+// @Summary Get webhook by ID
+// @Description This endpoint gets one of a project's webhooks by ID. Leads can only get the webhooks of projects assigned to them
+// @Tags webhooks
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param project_id path string true "ID of project the webhook belongs to"
+// @Param webhook_id path string true "ID of webhook to get"
+// @Success 200 {object} model.Webhook
+// @Failure 403
+// @Failure 404
+// @Failure 500
+// @Router /v1/projects/{project_id}/webhooks/{webhook_id} [get]
+func (h *Handler) getWebhook(w http.ResponseWriter, r *http.Request) {
+	projectID, err := h.readIDParam(r, "project_id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	webhookID, err := h.readIDParam(r, "webhook_id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	webhook, err := h.ctrl.GetWebhook(ctx, projectID, webhookID, userFromContext)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrNotPermitted):
+			h.notPermittedResponse(w, r)
+		case errors.Is(err, issuetracker.ErrNotFound):
+			h.notFoundResponse(w, r)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"webhook": webhook}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// UpdateWebhook godoc
+// @Summary Update a webhook
+// @Description This endpoint updates one of a project's webhooks. Inactive webhooks aren't sent events. Leads can only update the webhooks of projects assigned to them
+// @Tags webhooks
+// @Accept  json
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param project_id path string true "ID of project the webhook belongs to"
+// @Param webhook_id path string true "ID of webhook to update"
+// @Param payload body updateWebhookPayload true "Request payload"
+// @Success 200 {object} model.Webhook
+// @Failure 400
+// @Failure 403
+// @Failure 404
+// @Failure 409
+// @Failure 422
+// @Failure 500
+// @Router /v1/projects/{project_id}/webhooks/{webhook_id} [patch]
+func (h *Handler) updateWebhook(w http.ResponseWriter, r *http.Request) {
+	var requestPayload struct {
+		URL    *string   `json:"url"`
+		Secret *string   `json:"secret"`
+		Events *[]string `json:"events"`
+		Active *bool     `json:"active"`
+	}
+	projectID, err := h.readIDParam(r, "project_id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	webhookID, err := h.readIDParam(r, "webhook_id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	err = h.decodeJSON(w, r, &requestPayload)
+	if err != nil {
+		h.badRequestResponse(w, r, err)
+		return
+	}
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	webhook, err := h.ctrl.UpdateWebhook(ctx, projectID, webhookID, requestPayload.URL, requestPayload.Secret, requestPayload.Events, requestPayload.Active, userFromContext)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrNotPermitted):
+			h.notPermittedResponse(w, r)
+		case errors.Is(err, issuetracker.ErrNotFound):
+			h.notFoundResponse(w, r)
+		case errors.Is(err, issuetracker.ErrFailedValidation):
+			h.failedValidationResponse(w, r, err)
+		case errors.Is(err, issuetracker.ErrEditConflict):
+			h.editConflictResponse(w, r)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"webhook": webhook}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// DeleteWebhook godoc
+// @Summary Delete a webhook
+// @Description This endpoint deletes one of a project's webhooks. Leads can only delete the webhooks of projects assigned to them
+// @Tags webhooks
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param project_id path string true "ID of project the webhook belongs to"
+// @Param webhook_id path string true "ID of webhook to delete"
+// @Success 200
+// @Failure 403
+// @Failure 404
+// @Failure 500
+// @Router /v1/projects/{project_id}/webhooks/{webhook_id} [delete]
+func (h *Handler) deleteWebhook(w http.ResponseWriter, r *http.Request) {
+	projectID, err := h.readIDParam(r, "project_id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	webhookID, err := h.readIDParam(r, "webhook_id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	err = h.ctrl.DeleteWebhook(ctx, projectID, webhookID, userFromContext)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrNotPermitted):
+			h.notPermittedResponse(w, r)
+		case errors.Is(err, issuetracker.ErrNotFound):
+			h.notFoundResponse(w, r)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"message": "webhook successfully deleted"}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
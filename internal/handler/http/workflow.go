@@ -0,0 +1,100 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/emzola/issuetracker/internal/controller/issuetracker"
+	"github.com/emzola/issuetracker/pkg/model"
+)
+
+// GetProjectWorkflow godoc
+// @Summary Get project workflow
+// @Description This endpoint gets the ordered issue statuses of a project. Projects without a workflow of their own use the default open, in progress, resolved, closed workflow
+// @Tags projects
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param project_id path string true "ID of project to get workflow"
+// @Success 200 {object} model.Workflow
+// @Failure 404
+// @Failure 500
+// @Router /v1/projects/{project_id}/workflow [get]
+func (h *Handler) getProjectWorkflow(w http.ResponseWriter, r *http.Request) {
+	projectID, err := h.readIDParam(r, "project_id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	ctx := r.Context()
+	workflow, err := h.ctrl.GetProjectWorkflow(ctx, projectID)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrNotFound):
+			h.notFoundResponse(w, r)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"workflow": workflow}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// SetProjectWorkflow godoc
+// @Summary Set project workflow
+// @Description Replace the ordered issue statuses of a project with the request payload. New issues start in the first state. Issues move through the open states in order, can go back to any earlier open state and are closed from the last open state. Issues in a closed state can only be reopened to the first state
+// @Tags projects
+// @Accept  json
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param project_id path string true "ID of project to set workflow"
+// @Param payload body setProjectWorkflowPayload true "Request payload"
+// @Success 200 {object} model.Workflow
+// @Failure 400
+// @Failure 403
+// @Failure 404
+// @Failure 422
+// @Failure 500
+// @Router /v1/projects/{project_id}/workflow [put]
+func (h *Handler) setProjectWorkflow(w http.ResponseWriter, r *http.Request) {
+	projectID, err := h.readIDParam(r, "project_id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	var requestPayload struct {
+		States []model.WorkflowState `json:"states"`
+	}
+	err = h.decodeJSON(w, r, &requestPayload)
+	if err != nil {
+		h.badRequestResponse(w, r, err)
+		return
+	}
+	ctx := r.Context()
+	userFromContext := h.contextGetUser(r)
+	workflow, err := h.ctrl.SetProjectWorkflow(ctx, projectID, requestPayload.States, userFromContext)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrNotFound):
+			h.notFoundResponse(w, r)
+		case errors.Is(err, issuetracker.ErrNotPermitted):
+			h.notPermittedResponse(w, r)
+		case errors.Is(err, issuetracker.ErrFailedValidation):
+			h.failedValidationResponse(w, r, err)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"workflow": workflow}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
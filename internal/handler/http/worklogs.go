@@ -0,0 +1,101 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/emzola/issuetracker/internal/controller/issuetracker"
+)
+
+// CreateWorklog godoc
+// @Summary Log time against an issue
+// @Description This endpoint logs time against an issue with the request payload and adds it to the issue's logged hours
+// @Tags issues
+// @Accept  json
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param issue_id path string true "ID of issue to log time against"
+// @Param payload body createWorklogPayload true "Request payload"
+// @Success 201 {object} model.Worklog
+// @Failure 400
+// @Failure 403
+// @Failure 404
+// @Failure 422
+// @Failure 500
+// @Router /v1/issues/{issue_id}/worklog [post]
+func (h *Handler) createWorklog(w http.ResponseWriter, r *http.Request) {
+	var requestPayload struct {
+		Hours float64 `json:"hours"`
+		Note  string  `json:"note"`
+	}
+	issueID, err := h.readIDParam(r, "issue_id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	err = h.decodeJSON(w, r, &requestPayload)
+	if err != nil {
+		h.badRequestResponse(w, r, err)
+		return
+	}
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	worklog, effort, err := h.ctrl.CreateWorklog(ctx, issueID, requestPayload.Hours, requestPayload.Note, userFromContext)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrNotPermitted):
+			h.notPermittedResponse(w, r)
+		case errors.Is(err, issuetracker.ErrNotFound):
+			h.notFoundResponse(w, r)
+		case errors.Is(err, issuetracker.ErrFailedValidation):
+			h.failedValidationResponse(w, r, err)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusCreated, envelop{"worklog": worklog, "effort": effort}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// GetIssueWorklogs godoc
+// @Summary Get the time logged against an issue
+// @Description This endpoint gets the time logged against an issue, oldest first, along with the issue's estimated and total logged hours
+// @Tags issues
+// @Produce json
+// @Param token header string true "Bearer token"
+// @Param issue_id path string true "ID of issue to get worklogs"
+// @Success 200 {array} model.Worklog
+// @Failure 404
+// @Failure 500
+// @Router /v1/issues/{issue_id}/worklog [get]
+func (h *Handler) getIssueWorklogs(w http.ResponseWriter, r *http.Request) {
+	issueID, err := h.readIDParam(r, "issue_id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	userFromContext := h.contextGetUser(r)
+	ctx := r.Context()
+	worklogs, effort, err := h.ctrl.GetIssueWorklogs(ctx, issueID, userFromContext)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, issuetracker.ErrNotFound):
+			h.notFoundResponse(w, r)
+		default:
+			h.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"worklogs": worklogs, "effort": effort}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
@@ -1,10 +1,43 @@
 package repository
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
 	ErrNotFound         = errors.New("not found")
 	ErrFailedValidation = errors.New("failed validation")
 	ErrEditConflict     = errors.New("edit conflict")
 	ErrDuplicateKey     = errors.New("duplicate key")
+	ErrReferenced       = errors.New("referenced by other records")
+
+	// ErrDuplicateProjectKey is a duplicate key error caused by a project key, rather
+	// than a project name.
+	ErrDuplicateProjectKey = fmt.Errorf("project key: %w", ErrDuplicateKey)
 )
+
+// BatchError is returned when an item of a batch operation fails, in which case the
+// whole batch is rolled back. Index is the position of the failing item in the batch.
+type BatchError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("batch item %d: %v", e.Index, e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// OpenIssuesError is returned when a user with open issues assigned to them is deleted
+// without force. Count is the number of open issues.
+type OpenIssuesError struct {
+	Count int
+}
+
+func (e *OpenIssuesError) Error() string {
+	return fmt.Sprintf("user has %d open issues assigned", e.Count)
+}
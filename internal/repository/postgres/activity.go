@@ -0,0 +1,220 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/emzola/issuetracker/pkg/model"
+)
+
+// RecordIssueActivity records changes made to issues. Changes recorded together share
+// the same timestamp.
+func (r *Repository) RecordIssueActivity(ctx context.Context, activity []*model.IssueActivity) error {
+	if len(activity) == 0 {
+		return nil
+	}
+	issueIDs := make([]int64, len(activity))
+	versions := make([]int64, len(activity))
+	fields := make([]string, len(activity))
+	oldValues := make([]string, len(activity))
+	newValues := make([]string, len(activity))
+	changedBy := make([]string, len(activity))
+	changedByIDs := make([]int64, len(activity))
+	for i, a := range activity {
+		issueIDs[i] = a.IssueID
+		versions[i] = a.Version
+		fields[i] = a.Field
+		oldValues[i] = a.OldValue
+		newValues[i] = a.NewValue
+		changedBy[i] = a.ChangedBy
+		changedByIDs[i] = a.ChangedByID
+	}
+	query := `
+		INSERT INTO issue_activity (issue_id, version, field, old_value, new_value, changed_by, changed_by_id)
+		SELECT issue_id, version, field, old_value, new_value, changed_by, NULLIF(changed_by_id, 0)
+		FROM unnest($1::bigint[], $2::bigint[], $3::text[], $4::text[], $5::text[], $6::text[], $7::bigint[]) AS a (issue_id, version, field, old_value, new_value, changed_by, changed_by_id)`
+	_, err := r.db.ExecContext(ctx, query, issueIDs, versions, fields, oldValues, newValues, changedBy, changedByIDs)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return fmt.Errorf("%v: %w", err, ctx.Err())
+		default:
+			return err
+		}
+	}
+	return nil
+}
+
+// recordIssueActivityAt records changes made to issues against the given version.
+func (r *Repository) recordIssueActivityAt(ctx context.Context, activity []*model.IssueActivity, version int64) error {
+	for _, a := range activity {
+		a.Version = version
+	}
+	return r.RecordIssueActivity(ctx, activity)
+}
+
+func (r *Repository) GetIssueActivity(ctx context.Context, issueID int64, filters model.Filters) ([]*model.IssueActivity, model.Metadata, error) {
+	query := fmt.Sprintf(`
+		SELECT count(*) OVER(), id, issue_id, version, field, old_value, new_value, changed_by, COALESCE(changed_by_id, 0), changed_on
+		FROM issue_activity
+		WHERE issue_id = $1
+		ORDER BY %s, id %s
+		LIMIT $2 OFFSET $3`, filters.OrderBy(), filters.SortDirection())
+	args := []interface{}{issueID, filters.Limit(), filters.Offset()}
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return nil, model.Metadata{}, fmt.Errorf("%v: %w", err, ctx.Err())
+		default:
+			return nil, model.Metadata{}, err
+		}
+	}
+	defer rows.Close()
+	totalRecords := 0
+	activity := []*model.IssueActivity{}
+	for rows.Next() {
+		var a model.IssueActivity
+		err := rows.Scan(
+			&totalRecords,
+			&a.ID,
+			&a.IssueID,
+			&a.Version,
+			&a.Field,
+			&a.OldValue,
+			&a.NewValue,
+			&a.ChangedBy,
+			&a.ChangedByID,
+			&a.ChangedOn,
+		)
+		if err != nil {
+			return nil, model.Metadata{}, err
+		}
+		activity = append(activity, &a)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, model.Metadata{}, err
+	}
+	metadata := model.CalculateMetadata(totalRecords, filters.Page, filters.PageSize)
+	return activity, metadata, nil
+}
+
+// GetIssueActivitySince returns the changes that produced the versions of an issue after
+// version, newest first.
+func (r *Repository) GetIssueActivitySince(ctx context.Context, issueID, version int64) ([]*model.IssueActivity, error) {
+	query := `
+		SELECT id, issue_id, version, field, old_value, new_value, changed_by, COALESCE(changed_by_id, 0), changed_on
+		FROM issue_activity
+		WHERE issue_id = $1 AND version > $2
+		ORDER BY version DESC, id DESC`
+	rows, err := r.db.QueryContext(ctx, query, issueID, version)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return nil, fmt.Errorf("%v: %w", err, ctx.Err())
+		default:
+			return nil, err
+		}
+	}
+	defer rows.Close()
+	activity := []*model.IssueActivity{}
+	for rows.Next() {
+		var a model.IssueActivity
+		err := rows.Scan(
+			&a.ID,
+			&a.IssueID,
+			&a.Version,
+			&a.Field,
+			&a.OldValue,
+			&a.NewValue,
+			&a.ChangedBy,
+			&a.ChangedByID,
+			&a.ChangedOn,
+		)
+		if err != nil {
+			return nil, err
+		}
+		activity = append(activity, &a)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return activity, nil
+}
+
+// RecordProjectActivity records changes made to projects. Changes recorded together
+// share the same timestamp.
+func (r *Repository) RecordProjectActivity(ctx context.Context, activity []*model.ProjectActivity) error {
+	if len(activity) == 0 {
+		return nil
+	}
+	projectIDs := make([]int64, len(activity))
+	fields := make([]string, len(activity))
+	oldValues := make([]string, len(activity))
+	newValues := make([]string, len(activity))
+	changedBy := make([]string, len(activity))
+	for i, a := range activity {
+		projectIDs[i] = a.ProjectID
+		fields[i] = a.Field
+		oldValues[i] = a.OldValue
+		newValues[i] = a.NewValue
+		changedBy[i] = a.ChangedBy
+	}
+	query := `
+		INSERT INTO project_activity (project_id, field, old_value, new_value, changed_by)
+		SELECT * FROM unnest($1::bigint[], $2::text[], $3::text[], $4::text[], $5::text[])`
+	_, err := r.db.ExecContext(ctx, query, projectIDs, fields, oldValues, newValues, changedBy)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return fmt.Errorf("%v: %w", err, ctx.Err())
+		default:
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Repository) GetProjectActivity(ctx context.Context, projectID int64, filters model.Filters) ([]*model.ProjectActivity, model.Metadata, error) {
+	query := fmt.Sprintf(`
+		SELECT count(*) OVER(), id, project_id, field, old_value, new_value, changed_by, changed_on
+		FROM project_activity
+		WHERE project_id = $1
+		ORDER BY %s, id %s
+		LIMIT $2 OFFSET $3`, filters.OrderBy(), filters.SortDirection())
+	args := []interface{}{projectID, filters.Limit(), filters.Offset()}
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return nil, model.Metadata{}, fmt.Errorf("%v: %w", err, ctx.Err())
+		default:
+			return nil, model.Metadata{}, err
+		}
+	}
+	defer rows.Close()
+	totalRecords := 0
+	activity := []*model.ProjectActivity{}
+	for rows.Next() {
+		var a model.ProjectActivity
+		err := rows.Scan(
+			&totalRecords,
+			&a.ID,
+			&a.ProjectID,
+			&a.Field,
+			&a.OldValue,
+			&a.NewValue,
+			&a.ChangedBy,
+			&a.ChangedOn,
+		)
+		if err != nil {
+			return nil, model.Metadata{}, err
+		}
+		activity = append(activity, &a)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, model.Metadata{}, err
+	}
+	metadata := model.CalculateMetadata(totalRecords, filters.Page, filters.PageSize)
+	return activity, metadata, nil
+}
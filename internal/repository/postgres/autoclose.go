@@ -0,0 +1,92 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/emzola/issuetracker/internal/repository"
+	"github.com/emzola/issuetracker/pkg/model"
+)
+
+// GetIssuesDueForAutoClose returns the issues waiting to be closed that have not been
+// modified for the verification period of their project, along with the first closed
+// state of the project's workflow.
+func (r *Repository) GetIssuesDueForAutoClose(ctx context.Context) ([]*model.IssueAutoClose, error) {
+	query := fmt.Sprintf(`
+		SELECT issues.id, issues.title, issues.status, %s, projects.auto_close_days, 'email' = ANY(projects.notification_channels), users.name, users.email, users.locale
+		FROM issues
+		INNER JOIN projects ON projects.id = issues.project_id
+		INNER JOIN users ON users.id = issues.reporter_id
+		WHERE %s
+		AND issues.draft = false
+		AND issues.deleted_on IS NULL
+		AND projects.auto_close_days IS NOT NULL
+		AND issues.modified_on <= CURRENT_TIMESTAMP(0) - projects.auto_close_days * INTERVAL '1 day'`, issueFirstClosedStatus, issueResolved)
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return nil, fmt.Errorf("%v: %w", err, ctx.Err())
+		default:
+			return nil, err
+		}
+	}
+	defer rows.Close()
+	issues := []*model.IssueAutoClose{}
+	for rows.Next() {
+		var issue model.IssueAutoClose
+		err := rows.Scan(
+			&issue.IssueID,
+			&issue.Title,
+			&issue.Status,
+			&issue.ClosedStatus,
+			&issue.AutoCloseDays,
+			&issue.NotifyByEmail,
+			&issue.ReporterName,
+			&issue.ReporterEmail,
+			&issue.ReporterLocale,
+		)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, &issue)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return issues, nil
+}
+
+// AutoCloseIssue moves an issue waiting to be closed from status to closedStatus and
+// records the activity against its new version in the same transaction. The issue is
+// only closed if it is still in status and has not been modified since it became due, so
+// that an issue reopened or updated in the meantime is left alone.
+func (r *Repository) AutoCloseIssue(ctx context.Context, issueID int64, status, closedStatus string, activity []*model.IssueActivity) error {
+	return r.WithTx(ctx, func(tx *Repository) error {
+		query := `
+			UPDATE issues
+			SET status = $3, actual_resolution_date = COALESCE(actual_resolution_date, CURRENT_DATE), modified_by = 'system', modified_on = CURRENT_TIMESTAMP(0), version = version + 1
+			FROM projects
+			WHERE issues.id = $1
+			AND projects.id = issues.project_id
+			AND issues.status = $2
+			AND projects.auto_close_days IS NOT NULL
+			AND issues.modified_on <= CURRENT_TIMESTAMP(0) - projects.auto_close_days * INTERVAL '1 day'
+			RETURNING issues.version`
+		var version int64
+		err := tx.db.QueryRowContext(ctx, query, issueID, status, closedStatus).Scan(&version)
+		if err != nil {
+			switch {
+			case err.Error() == "ERROR: canceling statement due to user request":
+				return fmt.Errorf("%v: %w", err, ctx.Err())
+			case errors.Is(err, sql.ErrNoRows):
+				return repository.ErrEditConflict
+			default:
+				return err
+			}
+		}
+		return tx.recordIssueActivityAt(ctx, activity, version)
+	})
+}
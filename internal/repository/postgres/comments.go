@@ -0,0 +1,151 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/emzola/issuetracker/internal/repository"
+	"github.com/emzola/issuetracker/pkg/model"
+)
+
+func (r *Repository) CreateComment(ctx context.Context, comment *model.Comment) error {
+	query := `
+		INSERT INTO comments (issue_id, user_id, body)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_on, modified_on, version`
+	args := []interface{}{comment.IssueID, comment.UserID, comment.Body}
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(&comment.ID, &comment.CreatedOn, &comment.ModifiedOn, &comment.Version)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return fmt.Errorf("%v: %w", err, ctx.Err())
+		default:
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Repository) GetComment(ctx context.Context, id int64) (*model.Comment, error) {
+	if id < 1 {
+		return nil, repository.ErrNotFound
+	}
+	query := `
+		SELECT id, issue_id, user_id, body, created_on, modified_on, version
+		FROM comments
+		WHERE id = $1`
+	var comment model.Comment
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&comment.ID,
+		&comment.IssueID,
+		&comment.UserID,
+		&comment.Body,
+		&comment.CreatedOn,
+		&comment.ModifiedOn,
+		&comment.Version,
+	)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return nil, fmt.Errorf("%v: %w", err, ctx.Err())
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, repository.ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+	return &comment, nil
+}
+
+func (r *Repository) GetAllComments(ctx context.Context, issueID int64, filters model.Filters) ([]*model.Comment, model.Metadata, error) {
+	query := fmt.Sprintf(`
+		SELECT count(*) OVER(), id, issue_id, user_id, body, created_on, modified_on, version
+		FROM comments
+		WHERE issue_id = $1
+		ORDER BY %s, id ASC
+		LIMIT $2 OFFSET $3`, filters.OrderBy())
+	args := []interface{}{issueID, filters.Limit(), filters.Offset()}
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return nil, model.Metadata{}, fmt.Errorf("%v: %w", err, ctx.Err())
+		default:
+			return nil, model.Metadata{}, err
+		}
+	}
+	defer rows.Close()
+	totalRecords := 0
+	comments := []*model.Comment{}
+	for rows.Next() {
+		var comment model.Comment
+		err := rows.Scan(
+			&totalRecords,
+			&comment.ID,
+			&comment.IssueID,
+			&comment.UserID,
+			&comment.Body,
+			&comment.CreatedOn,
+			&comment.ModifiedOn,
+			&comment.Version,
+		)
+		if err != nil {
+			return nil, model.Metadata{}, err
+		}
+		comments = append(comments, &comment)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, model.Metadata{}, err
+	}
+	metadata := model.CalculateMetadata(totalRecords, filters.Page, filters.PageSize)
+	return comments, metadata, nil
+}
+
+func (r *Repository) UpdateComment(ctx context.Context, comment *model.Comment) error {
+	query := `
+		UPDATE comments
+		SET body = $1, modified_on = CURRENT_TIMESTAMP(0), version = version + 1
+		WHERE id = $2 AND version = $3
+		RETURNING modified_on, version`
+	args := []interface{}{comment.Body, comment.ID, comment.Version}
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(&comment.ModifiedOn, &comment.Version)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return fmt.Errorf("%v: %w", err, ctx.Err())
+		case errors.Is(err, sql.ErrNoRows):
+			return repository.ErrEditConflict
+		default:
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Repository) DeleteComment(ctx context.Context, id int64) error {
+	if id < 1 {
+		return repository.ErrNotFound
+	}
+	query := `
+		DELETE FROM comments
+		WHERE id = $1`
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return fmt.Errorf("%v: %w", err, ctx.Err())
+		default:
+			return err
+		}
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
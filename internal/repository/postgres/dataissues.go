@@ -0,0 +1,104 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/emzola/issuetracker/pkg/model"
+)
+
+// GetIssuesWithAssigneeNotOnProject returns issues assigned to a user who is not a
+// member of the issue's project.
+func (r *Repository) GetIssuesWithAssigneeNotOnProject(ctx context.Context, filters model.Filters) ([]*model.Issue, model.Metadata, error) {
+	condition := `assigned_to IS NOT NULL
+		AND NOT EXISTS (
+			SELECT 1 FROM projects_users
+			WHERE projects_users.project_id = issues.project_id
+			AND projects_users.user_id = issues.assigned_to)`
+	return r.getIssuesWhere(ctx, condition, filters)
+}
+
+// GetClosedIssuesWithoutResolutionSummary returns closed issues that have no
+// resolution summary.
+func (r *Repository) GetClosedIssuesWithoutResolutionSummary(ctx context.Context, filters model.Filters) ([]*model.Issue, model.Metadata, error) {
+	condition := issueClosed + ` AND TRIM(resolution_summary) = ''`
+	return r.getIssuesWhere(ctx, condition, filters)
+}
+
+// GetClosedIssuesWithoutResolutionDate returns closed issues that have no actual
+// resolution date.
+func (r *Repository) GetClosedIssuesWithoutResolutionDate(ctx context.Context, filters model.Filters) ([]*model.Issue, model.Metadata, error) {
+	condition := issueClosed + ` AND actual_resolution_date IS NULL`
+	return r.getIssuesWhere(ctx, condition, filters)
+}
+
+// GetIssuesWithTargetBeforeReported returns issues whose target resolution date is
+// before the date they were reported.
+func (r *Repository) GetIssuesWithTargetBeforeReported(ctx context.Context, filters model.Filters) ([]*model.Issue, model.Metadata, error) {
+	condition := `target_resolution_date < reported_date`
+	return r.getIssuesWhere(ctx, condition, filters)
+}
+
+// getIssuesWhere returns a page of published issues matching condition. condition is
+// interpolated into the query and must never contain user input.
+func (r *Repository) getIssuesWhere(ctx context.Context, condition string, filters model.Filters) ([]*model.Issue, model.Metadata, error) {
+	query := fmt.Sprintf(`
+		SELECT count(*) OVER(), id, title, description, reporter_id, reported_date, project_id, milestone_id, assigned_to, status, priority, type, target_resolution_date, progress, actual_resolution_date, resolution_summary, created_on, created_by, modified_on, modified_by, version, draft, estimated_hours, logged_hours
+		FROM issues
+		WHERE draft = false
+		AND deleted_on IS NULL
+		AND %s
+		ORDER BY %s, id ASC
+		LIMIT $1 OFFSET $2`, condition, filters.OrderBy())
+	args := []interface{}{filters.Limit(), filters.Offset()}
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return nil, model.Metadata{}, fmt.Errorf("%v: %w", err, ctx.Err())
+		default:
+			return nil, model.Metadata{}, err
+		}
+	}
+	defer rows.Close()
+	totalRecords := 0
+	issues := []*model.Issue{}
+	for rows.Next() {
+		var issue model.Issue
+		err := rows.Scan(
+			&totalRecords,
+			&issue.ID,
+			&issue.Title,
+			&issue.Description,
+			&issue.ReporterID,
+			&issue.ReportedDate,
+			&issue.ProjectID,
+			&issue.MilestoneID,
+			&issue.AssignedTo,
+			&issue.Status,
+			&issue.Priority,
+			&issue.Type,
+			&issue.TargetResolutionDate,
+			&issue.Progress,
+			&issue.ActualResolutionDate,
+			&issue.ResolutionSummary,
+			&issue.CreatedOn,
+			&issue.CreatedBy,
+			&issue.ModifiedOn,
+			&issue.ModifiedBy,
+			&issue.Version,
+			&issue.Draft,
+			&issue.EstimatedHours,
+			&issue.LoggedHours,
+		)
+		if err != nil {
+			return nil, model.Metadata{}, err
+		}
+		issues = append(issues, &issue)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, model.Metadata{}, err
+	}
+	metadata := model.CalculateMetadata(totalRecords, filters.Page, filters.PageSize)
+	return issues, metadata, nil
+}
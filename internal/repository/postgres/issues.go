@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/emzola/issuetracker/internal/repository"
@@ -13,10 +14,10 @@ import (
 
 func (r *Repository) CreateIssue(ctx context.Context, issue *model.Issue) error {
 	query := `
-		INSERT INTO issues (title, description, reporter_id, project_id, assigned_to, status, priority, target_resolution_date, created_by, modified_by)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		INSERT INTO issues (title, description, reporter_id, project_id, assigned_to, status, priority, target_resolution_date, created_by, modified_by, draft, estimated_hours, milestone_id, type)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
 		RETURNING id, reported_date, created_on, modified_on, version`
-	args := []interface{}{issue.Title, issue.Description, issue.ReporterID, issue.ProjectID, issue.AssignedTo, issue.Status, issue.Priority, issue.TargetResolutionDate, issue.CreatedBy, issue.ModifiedBy}
+	args := []interface{}{issue.Title, issue.Description, issue.ReporterID, issue.ProjectID, issue.AssignedTo, issue.Status, issue.Priority, issue.TargetResolutionDate, issue.CreatedBy, issue.ModifiedBy, issue.Draft, issue.EstimatedHours, issue.MilestoneID, issue.Type}
 	err := r.db.QueryRowContext(ctx, query, args...).Scan(&issue.ID, &issue.ReportedDate, &issue.CreatedOn, &issue.ModifiedOn, &issue.Version)
 	if err != nil {
 		switch {
@@ -29,14 +30,27 @@ func (r *Repository) CreateIssue(ctx context.Context, issue *model.Issue) error
 	return nil
 }
 
+// CreateIssueWithComment creates the issue along with its first comment in a single
+// transaction, so that neither is created if creating the other fails.
+func (r *Repository) CreateIssueWithComment(ctx context.Context, issue *model.Issue, comment *model.Comment) error {
+	return r.WithTx(ctx, func(tx *Repository) error {
+		err := tx.CreateIssue(ctx, issue)
+		if err != nil {
+			return err
+		}
+		comment.IssueID = issue.ID
+		return tx.CreateComment(ctx, comment)
+	})
+}
+
 func (r *Repository) GetIssue(ctx context.Context, id int64) (*model.Issue, error) {
 	if id < 1 {
 		return nil, repository.ErrNotFound
 	}
 	query := `
-		SELECT id, title, description, reporter_id, reported_date, project_id, assigned_to, status, priority, target_resolution_date, progress, actual_resolution_date, resolution_summary, created_on, created_by, modified_on, modified_by, version
+		SELECT id, title, description, reporter_id, reported_date, project_id, milestone_id, assigned_to, status, priority, type, target_resolution_date, progress, actual_resolution_date, resolution_summary, created_on, created_by, modified_on, modified_by, version, draft, estimated_hours, logged_hours
 		FROM issues
-		WHERE id = $1`
+		WHERE id = $1 AND deleted_on IS NULL`
 	var issue model.Issue
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
 		&issue.ID,
@@ -45,9 +59,11 @@ func (r *Repository) GetIssue(ctx context.Context, id int64) (*model.Issue, erro
 		&issue.ReporterID,
 		&issue.ReportedDate,
 		&issue.ProjectID,
+		&issue.MilestoneID,
 		&issue.AssignedTo,
 		&issue.Status,
 		&issue.Priority,
+		&issue.Type,
 		&issue.TargetResolutionDate,
 		&issue.Progress,
 		&issue.ActualResolutionDate,
@@ -57,6 +73,9 @@ func (r *Repository) GetIssue(ctx context.Context, id int64) (*model.Issue, erro
 		&issue.ModifiedOn,
 		&issue.ModifiedBy,
 		&issue.Version,
+		&issue.Draft,
+		&issue.EstimatedHours,
+		&issue.LoggedHours,
 	)
 	if err != nil {
 		switch {
@@ -71,19 +90,18 @@ func (r *Repository) GetIssue(ctx context.Context, id int64) (*model.Issue, erro
 	return &issue, nil
 }
 
-func (r *Repository) GetAllIssues(ctx context.Context, title string, reportedDate time.Time, projectID, assignedTo int64, status, priority string, filters model.Filters) ([]*model.Issue, model.Metadata, error) {
+// GetAllIssues returns the issues matching issueFilters. Issues can be sorted by rank,
+// their relevance to the Query filter. Draft issues are only returned to their reporter,
+// identified by viewerID, and deleted issues are only returned if IncludeDeleted is set.
+func (r *Repository) GetAllIssues(ctx context.Context, issueFilters model.IssueFilters, viewerID int64, filters model.Filters) ([]*model.Issue, model.Metadata, error) {
 	query := fmt.Sprintf(`
-		SELECT count(*) OVER(), id, title, description, reporter_id, reported_date, project_id, assigned_to, status, priority, target_resolution_date, progress, actual_resolution_date, resolution_summary, created_on, created_by, modified_on, modified_by, version
+		SELECT count(*) OVER(), id, title, description, reporter_id, reported_date, project_id, milestone_id, assigned_to, status, priority, type, target_resolution_date, progress, actual_resolution_date, resolution_summary, created_on, created_by, modified_on, modified_by, version, draft, estimated_hours, logged_hours, deleted_on,
+		CASE WHEN $13 = '' THEN 0 ELSE ts_rank(%[2]s, plainto_tsquery($9::regconfig, $13)) END AS rank
 		FROM issues
-		WHERE (to_tsvector('simple', title) @@ plainto_tsquery('simple', $1) OR $1 = '')
-		AND (reported_date = $2 OR $2 = '0001-01-01')
-		AND (project_id = $3 OR $3 = 0)
-		AND (assigned_to = $4 OR $4 = 0)
-		AND (LOWER(status) = LOWER($5) OR $5 = '')
-		AND (LOWER(priority) = LOWER($6) OR $6 = '')
-		ORDER BY %s %s, id ASC 
-		LIMIT $7 OFFSET $8`, filters.SortColumn(), filters.SortDirection())
-	args := []interface{}{title, reportedDate, projectID, assignedTo, status, priority, filters.Limit(), filters.Offset()}
+		WHERE %[3]s
+		ORDER BY %[1]s, id ASC 
+		LIMIT $7 OFFSET $8`, filters.OrderBy(), r.issueSearchVector(), r.issueConditions())
+	args := r.issueArgs(issueFilters, viewerID, filters.Limit(), filters.Offset())
 	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		switch {
@@ -96,6 +114,7 @@ func (r *Repository) GetAllIssues(ctx context.Context, title string, reportedDat
 	defer rows.Close()
 	totalRecords := 0
 	issues := []*model.Issue{}
+	var rank float32
 	for rows.Next() {
 		var issue model.Issue
 		err := rows.Scan(
@@ -106,9 +125,11 @@ func (r *Repository) GetAllIssues(ctx context.Context, title string, reportedDat
 			&issue.ReporterID,
 			&issue.ReportedDate,
 			&issue.ProjectID,
+			&issue.MilestoneID,
 			&issue.AssignedTo,
 			&issue.Status,
 			&issue.Priority,
+			&issue.Type,
 			&issue.TargetResolutionDate,
 			&issue.Progress,
 			&issue.ActualResolutionDate,
@@ -118,6 +139,11 @@ func (r *Repository) GetAllIssues(ctx context.Context, title string, reportedDat
 			&issue.ModifiedOn,
 			&issue.ModifiedBy,
 			&issue.Version,
+			&issue.Draft,
+			&issue.EstimatedHours,
+			&issue.LoggedHours,
+			&issue.DeletedOn,
+			&rank,
 		)
 		if err != nil {
 			return nil, model.Metadata{}, err
@@ -131,49 +157,437 @@ func (r *Repository) GetAllIssues(ctx context.Context, title string, reportedDat
 	return issues, metadata, nil
 }
 
-func (r *Repository) UpdateIssue(ctx context.Context, issue *model.Issue) error {
-	query := `
-		UPDATE issues
-		SET title = $1, description = $2, assigned_to = $3, status = $4, priority = $5, target_resolution_date = $6, progress = $7, actual_resolution_date = $8, resolution_summary = $9, modified_on = CURRENT_TIMESTAMP(0), modified_by = $10, version = version + 1
-		WHERE id = $11 AND version = $12
-		RETURNING modified_on, version`
-	args := []interface{}{issue.Title, issue.Description, issue.AssignedTo, issue.Status, issue.Priority, issue.TargetResolutionDate, issue.Progress, issue.ActualResolutionDate, issue.ResolutionSummary, issue.ModifiedBy, issue.ID, issue.Version}
-	err := r.db.QueryRowContext(ctx, query, args...).Scan(&issue.ModifiedOn, &issue.Version)
+// issueConditions returns the conditions of the GetAllIssues query, which filter issues by
+// the arguments returned by issueArgs.
+func (r *Repository) issueConditions() string {
+	return fmt.Sprintf(`(to_tsvector($9::regconfig, title) @@ plainto_tsquery($9::regconfig, $1) OR $1 = '')
+		AND (%[1]s @@ plainto_tsquery($9::regconfig, $13) OR $13 = '')
+		AND (reported_date = NULLIF($2, '')::date OR $2 = '')
+		AND reported_date BETWEEN COALESCE(NULLIF($19, '')::date, '-infinity') AND COALESCE(NULLIF($20, '')::date, 'infinity')
+		AND target_resolution_date BETWEEN COALESCE(NULLIF($21, '')::date, '-infinity') AND COALESCE(NULLIF($22, '')::date, 'infinity')
+		AND (project_id = $3 OR $3 = 0)
+		AND (milestone_id = $15 OR $15 = 0)
+		AND (assigned_to = $4 OR $4 = 0)
+		AND (assigned_to IS NULL OR NOT $23)
+		AND (reporter_id = $24 OR $24 = 0)
+		AND ($17 = '' OR EXISTS (
+			SELECT 1
+			FROM users
+			WHERE users.id = issues.assigned_to
+			AND LOWER(users.name) = LOWER($17)))
+		AND ($18 = '' OR EXISTS (
+			SELECT 1
+			FROM users
+			WHERE users.id = issues.reporter_id
+			AND LOWER(users.name) = LOWER($18)))
+		AND (LOWER(status) = LOWER($5) OR $5 = '')
+		AND (LOWER(priority) = LOWER($6) OR $6 = '')
+		AND (type = $16 OR $16 = '')
+		AND (draft = false OR reporter_id = $10)
+		AND (deleted_on IS NULL OR $14)
+		AND (COALESCE(cardinality($11::text[]), 0) = 0 OR (
+			SELECT count(*)
+			FROM issues_labels
+			INNER JOIN labels ON labels.id = issues_labels.label_id
+			WHERE issues_labels.issue_id = issues.id
+			AND labels.name = ANY($11::text[])) >= CASE WHEN $12 THEN cardinality($11::text[]) ELSE 1 END)`, r.issueSearchVector())
+}
+
+// issueArgs returns the arguments of queries using issueConditions, with the limit and
+// offset as $7 and $8. A nil limit returns every issue.
+func (r *Repository) issueArgs(f model.IssueFilters, viewerID int64, limit any, offset int) []interface{} {
+	return []interface{}{f.Title, f.ReportedDate, f.ProjectID, f.AssignedTo, f.Status, f.Priority, limit, offset, r.textSearchConfig, viewerID, f.Labels, f.LabelMatch == "all", f.Query, f.IncludeDeleted, f.MilestoneID, f.Type, f.AssigneeName, f.ReporterName, f.ReportedFrom, f.ReportedTo, f.TargetFrom, f.TargetTo, f.Unassigned, f.ReporterID}
+}
+
+// ExportIssues calls fn with each issue matching the same filters as GetAllIssues, in
+// sort order, or with a single page of them if sort has a page size. Issues are read one
+// at a time rather than all at once, and the database connection is held until they have
+// all been read. If fn returns an error, ExportIssues stops and returns it.
+func (r *Repository) ExportIssues(ctx context.Context, issueFilters model.IssueFilters, viewerID int64, sort model.Filters, fn func(*model.IssueExport) error) error {
+	query := fmt.Sprintf(`
+		SELECT id, title, status, priority, COALESCE((SELECT name FROM users WHERE users.id = issues.assigned_to), ''), reported_date, target_resolution_date, actual_resolution_date,
+		CASE WHEN $13 = '' THEN 0 ELSE ts_rank(%[2]s, plainto_tsquery($9::regconfig, $13)) END AS rank
+		FROM issues
+		WHERE %[3]s
+		ORDER BY %[1]s, id ASC
+		LIMIT $7 OFFSET $8`, sort.OrderBy(), r.issueSearchVector(), r.issueConditions())
+	// A NULL limit returns every issue.
+	var limit any
+	if sort.PageSize != 0 {
+		limit = sort.Limit()
+	}
+	args := r.issueArgs(issueFilters, viewerID, limit, sort.Offset())
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		switch {
 		case err.Error() == "ERROR: canceling statement due to user request":
 			return fmt.Errorf("%v: %w", err, ctx.Err())
-		case errors.Is(err, sql.ErrNoRows):
-			return repository.ErrEditConflict
 		default:
 			return err
 		}
 	}
-	return nil
+	defer rows.Close()
+	var rank float32
+	for rows.Next() {
+		var issue model.IssueExport
+		err := rows.Scan(
+			&issue.ID,
+			&issue.Title,
+			&issue.Status,
+			&issue.Priority,
+			&issue.Assignee,
+			&issue.ReportedDate,
+			&issue.TargetResolutionDate,
+			&issue.ActualResolutionDate,
+			&rank,
+		)
+		if err != nil {
+			return err
+		}
+		err = fn(&issue)
+		if err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// issueSearchVector returns the SQL expression of the text searched by the q filter of
+// GetAllIssues. The indexed search_vector column is built with the 'simple' text search
+// configuration, so the vector is computed on the fly for other configurations.
+func (r *Repository) issueSearchVector() string {
+	if r.textSearchConfig == "simple" {
+		return "search_vector"
+	}
+	return `(setweight(to_tsvector($9::regconfig, title), 'A') ||
+		setweight(to_tsvector($9::regconfig, description), 'B') ||
+		setweight(to_tsvector($9::regconfig, resolution_summary), 'C'))`
+}
+
+// UpdateIssue saves the changes to an issue and records the activity describing them in
+// a single transaction, so that the activity log can't miss an update. The activity is
+// recorded against the issue's new version.
+func (r *Repository) UpdateIssue(ctx context.Context, issue *model.Issue, activity []*model.IssueActivity) error {
+	return r.WithTx(ctx, func(tx *Repository) error {
+		query := `
+			UPDATE issues
+			SET title = $1, description = $2, assigned_to = $3, status = $4, priority = $5, target_resolution_date = $6, progress = $7, actual_resolution_date = $8, resolution_summary = $9, modified_on = CURRENT_TIMESTAMP(0), modified_by = $10, draft = $13, estimated_hours = $14, milestone_id = $15, type = $16, version = version + 1,
+			reminded_at = CASE WHEN target_resolution_date = $6 THEN reminded_at ELSE NULL END
+			WHERE id = $11 AND version = $12
+			RETURNING modified_on, version`
+		args := []interface{}{issue.Title, issue.Description, issue.AssignedTo, issue.Status, issue.Priority, issue.TargetResolutionDate, issue.Progress, issue.ActualResolutionDate, issue.ResolutionSummary, issue.ModifiedBy, issue.ID, issue.Version, issue.Draft, issue.EstimatedHours, issue.MilestoneID, issue.Type}
+		err := tx.db.QueryRowContext(ctx, query, args...).Scan(&issue.ModifiedOn, &issue.Version)
+		if err != nil {
+			switch {
+			case err.Error() == "ERROR: canceling statement due to user request":
+				return fmt.Errorf("%v: %w", err, ctx.Err())
+			case errors.Is(err, sql.ErrNoRows):
+				return repository.ErrEditConflict
+			default:
+				return err
+			}
+		}
+		return tx.recordIssueActivityAt(ctx, activity, issue.Version)
+	})
+}
+
+// UpdateIssues updates the issues in a single transaction, recording the activity at the
+// same index as each issue. If any of the updates fails, none of them are applied and a
+// *repository.BatchError identifying the failing issue is returned.
+func (r *Repository) UpdateIssues(ctx context.Context, issues []*model.Issue, activity [][]*model.IssueActivity) error {
+	return r.WithTx(ctx, func(tx *Repository) error {
+		for i, issue := range issues {
+			err := tx.UpdateIssue(ctx, issue, activity[i])
+			if err != nil {
+				return &repository.BatchError{Index: i, Err: err}
+			}
+		}
+		return nil
+	})
 }
 
-func (r *Repository) DeleteIssue(ctx context.Context, id int64) error {
+// DeleteIssue soft deletes an issue by setting its deleted_on time, and records the
+// activity in the same transaction. Deleted issues are left out of queries, but can be
+// restored with RestoreIssue.
+func (r *Repository) DeleteIssue(ctx context.Context, id int64, activity []*model.IssueActivity) error {
+	query := `
+		UPDATE issues
+		SET deleted_on = CURRENT_TIMESTAMP(0), version = version + 1
+		WHERE id = $1 AND deleted_on IS NULL
+		RETURNING version`
+	return r.changeIssue(ctx, query, id, activity)
+}
+
+// RestoreIssue restores a soft deleted issue, and records the activity in the same
+// transaction.
+func (r *Repository) RestoreIssue(ctx context.Context, id int64, activity []*model.IssueActivity) error {
+	query := `
+		UPDATE issues
+		SET deleted_on = NULL, version = version + 1
+		WHERE id = $1 AND deleted_on IS NOT NULL
+		RETURNING version`
+	return r.changeIssue(ctx, query, id, activity)
+}
+
+// changeIssue runs a query that changes the issue with the given id and returns its new
+// version, and records the activity against that version in the same transaction. It
+// returns repository.ErrNotFound if no issue was changed.
+func (r *Repository) changeIssue(ctx context.Context, query string, id int64, activity []*model.IssueActivity) error {
 	if id < 1 {
 		return repository.ErrNotFound
 	}
-	query := `
-		DELETE FROM issues
-		WHERE id = $1`
-	result, err := r.db.ExecContext(ctx, query, id)
+	return r.WithTx(ctx, func(tx *Repository) error {
+		var version int64
+		err := tx.db.QueryRowContext(ctx, query, id).Scan(&version)
+		if err != nil {
+			switch {
+			case err.Error() == "ERROR: canceling statement due to user request":
+				return fmt.Errorf("%v: %w", err, ctx.Err())
+			case errors.Is(err, sql.ErrNoRows):
+				return repository.ErrNotFound
+			default:
+				return err
+			}
+		}
+		return tx.recordIssueActivityAt(ctx, activity, version)
+	})
+}
+
+// PurgeIssue permanently deletes an issue, whether or not it has been soft deleted. The
+// issue's own activity is deleted with it, so the purge is recorded in its project's
+// activity, in the same transaction.
+func (r *Repository) PurgeIssue(ctx context.Context, id int64, purgedBy string) error {
+	if id < 1 {
+		return repository.ErrNotFound
+	}
+	return r.WithTx(ctx, func(tx *Repository) error {
+		query := `
+			DELETE FROM issues
+			WHERE id = $1
+			RETURNING project_id`
+		var projectID int64
+		err := tx.db.QueryRowContext(ctx, query, id).Scan(&projectID)
+		if err != nil {
+			switch {
+			case err.Error() == "ERROR: canceling statement due to user request":
+				return fmt.Errorf("%v: %w", err, ctx.Err())
+			case errors.Is(err, sql.ErrNoRows):
+				return repository.ErrNotFound
+			default:
+				return err
+			}
+		}
+		return tx.RecordProjectActivity(ctx, []*model.ProjectActivity{{
+			ProjectID: projectID,
+			Field:     "purged_issue",
+			OldValue:  strconv.FormatInt(id, 10),
+			ChangedBy: purgedBy,
+		}})
+	})
+}
+
+// GetUserInvolvedIssues returns the issues a user is involved in, as "reporter", as
+// "assignee", as "commenter" or as "mentioned". A user is mentioned by "@" followed by
+// their name, in any case, in the issue's description or one of its comments. Only issues
+// in projects the viewer can access are returned: managers can access every project,
+// while other users can access projects they lead or are members of. Draft issues are
+// only returned to their reporter.
+func (r *Repository) GetUserInvolvedIssues(ctx context.Context, userID int64, involvement string, viewerID int64, viewerRole string, filters model.Filters) ([]*model.Issue, model.Metadata, error) {
+	conditions := map[string]string{
+		"reporter":  "reporter_id = $1",
+		"assignee":  "assigned_to = $1",
+		"commenter": "EXISTS (SELECT 1 FROM comments WHERE comments.issue_id = issues.id AND comments.user_id = $1)",
+		"mentioned": `EXISTS (
+			SELECT 1 FROM users
+			WHERE users.id = $1
+			AND (strpos(lower(issues.description), lower('@' || users.name)) > 0
+			OR EXISTS (SELECT 1 FROM comments WHERE comments.issue_id = issues.id AND strpos(lower(comments.body), lower('@' || users.name)) > 0)))`,
+	}
+	condition, ok := conditions[involvement]
+	if !ok {
+		return nil, model.Metadata{}, fmt.Errorf("unknown involvement %q", involvement)
+	}
+	query := fmt.Sprintf(`
+		SELECT count(*) OVER(), id, title, description, reporter_id, reported_date, project_id, milestone_id, assigned_to, status, priority, type, target_resolution_date, progress, actual_resolution_date, resolution_summary, created_on, created_by, modified_on, modified_by, version, draft, estimated_hours, logged_hours
+		FROM issues
+		WHERE %s
+		AND ($2 = 'manager' OR project_id IN (
+			SELECT project_id FROM projects_users WHERE user_id = $3
+			UNION
+			SELECT id FROM projects WHERE assigned_to = $3))
+		AND (draft = false OR reporter_id = $3)
+		AND deleted_on IS NULL
+		ORDER BY %s, id ASC
+		LIMIT $4 OFFSET $5`, condition, filters.OrderBy())
+	args := []interface{}{userID, viewerRole, viewerID, filters.Limit(), filters.Offset()}
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		switch {
 		case err.Error() == "ERROR: canceling statement due to user request":
-			return fmt.Errorf("%v: %w", err, ctx.Err())
+			return nil, model.Metadata{}, fmt.Errorf("%v: %w", err, ctx.Err())
 		default:
-			return err
+			return nil, model.Metadata{}, err
 		}
 	}
-	rowsAffected, err := result.RowsAffected()
+	defer rows.Close()
+	totalRecords := 0
+	issues := []*model.Issue{}
+	for rows.Next() {
+		var issue model.Issue
+		err := rows.Scan(
+			&totalRecords,
+			&issue.ID,
+			&issue.Title,
+			&issue.Description,
+			&issue.ReporterID,
+			&issue.ReportedDate,
+			&issue.ProjectID,
+			&issue.MilestoneID,
+			&issue.AssignedTo,
+			&issue.Status,
+			&issue.Priority,
+			&issue.Type,
+			&issue.TargetResolutionDate,
+			&issue.Progress,
+			&issue.ActualResolutionDate,
+			&issue.ResolutionSummary,
+			&issue.CreatedOn,
+			&issue.CreatedBy,
+			&issue.ModifiedOn,
+			&issue.ModifiedBy,
+			&issue.Version,
+			&issue.Draft,
+			&issue.EstimatedHours,
+			&issue.LoggedHours,
+		)
+		if err != nil {
+			return nil, model.Metadata{}, err
+		}
+		issues = append(issues, &issue)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, model.Metadata{}, err
+	}
+	metadata := model.CalculateMetadata(totalRecords, filters.Page, filters.PageSize)
+	return issues, metadata, nil
+}
+
+// GetOpenIssuesAssignedTo returns the published issues assigned to a user that are
+// open and not waiting to be closed, ordered by target resolution date.
+func (r *Repository) GetOpenIssuesAssignedTo(ctx context.Context, userID int64) ([]*model.Issue, error) {
+	query := fmt.Sprintf(`
+		SELECT id, title, description, reporter_id, reported_date, project_id, milestone_id, assigned_to, status, priority, type, target_resolution_date, progress, actual_resolution_date, resolution_summary, created_on, created_by, modified_on, modified_by, version, draft, estimated_hours, logged_hours
+		FROM issues
+		WHERE assigned_to = $1
+		AND %s
+		AND draft = false
+		AND deleted_on IS NULL
+		ORDER BY target_resolution_date ASC, id ASC`, issueUnresolved)
+	rows, err := r.db.QueryContext(ctx, query, userID)
 	if err != nil {
-		return err
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return nil, fmt.Errorf("%v: %w", err, ctx.Err())
+		default:
+			return nil, err
+		}
 	}
-	if rowsAffected == 0 {
-		return repository.ErrNotFound
+	defer rows.Close()
+	issues := []*model.Issue{}
+	for rows.Next() {
+		var issue model.Issue
+		err := rows.Scan(
+			&issue.ID,
+			&issue.Title,
+			&issue.Description,
+			&issue.ReporterID,
+			&issue.ReportedDate,
+			&issue.ProjectID,
+			&issue.MilestoneID,
+			&issue.AssignedTo,
+			&issue.Status,
+			&issue.Priority,
+			&issue.Type,
+			&issue.TargetResolutionDate,
+			&issue.Progress,
+			&issue.ActualResolutionDate,
+			&issue.ResolutionSummary,
+			&issue.CreatedOn,
+			&issue.CreatedBy,
+			&issue.ModifiedOn,
+			&issue.ModifiedBy,
+			&issue.Version,
+			&issue.Draft,
+			&issue.EstimatedHours,
+			&issue.LoggedHours,
+		)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, &issue)
 	}
-	return nil
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return issues, nil
+}
+
+// GetIssuesTargetedAfter returns the project's unresolved issues whose target resolution
+// date is after date, latest target resolution date first.
+func (r *Repository) GetIssuesTargetedAfter(ctx context.Context, projectID int64, date time.Time) ([]*model.Issue, error) {
+	query := fmt.Sprintf(`
+		SELECT id, title, description, reporter_id, reported_date, project_id, milestone_id, assigned_to, status, priority, type, target_resolution_date, progress, actual_resolution_date, resolution_summary, created_on, created_by, modified_on, modified_by, version, draft, estimated_hours, logged_hours
+		FROM issues
+		WHERE project_id = $1
+		AND target_resolution_date > $2
+		AND %s
+		AND deleted_on IS NULL
+		ORDER BY target_resolution_date DESC, id ASC`, issueUnresolved)
+	rows, err := r.db.QueryContext(ctx, query, projectID, date)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return nil, fmt.Errorf("%v: %w", err, ctx.Err())
+		default:
+			return nil, err
+		}
+	}
+	defer rows.Close()
+	issues := []*model.Issue{}
+	for rows.Next() {
+		var issue model.Issue
+		err := rows.Scan(
+			&issue.ID,
+			&issue.Title,
+			&issue.Description,
+			&issue.ReporterID,
+			&issue.ReportedDate,
+			&issue.ProjectID,
+			&issue.MilestoneID,
+			&issue.AssignedTo,
+			&issue.Status,
+			&issue.Priority,
+			&issue.Type,
+			&issue.TargetResolutionDate,
+			&issue.Progress,
+			&issue.ActualResolutionDate,
+			&issue.ResolutionSummary,
+			&issue.CreatedOn,
+			&issue.CreatedBy,
+			&issue.ModifiedOn,
+			&issue.ModifiedBy,
+			&issue.Version,
+			&issue.Draft,
+			&issue.EstimatedHours,
+			&issue.LoggedHours,
+		)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, &issue)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return issues, nil
 }
@@ -0,0 +1,345 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emzola/issuetracker/internal/repository"
+	"github.com/emzola/issuetracker/pkg/model"
+)
+
+// testUser is recorded as the user making changes in tests.
+var testUser = &model.User{Name: "test"}
+
+// newTestIssue creates an issue in a new project, reported by a new user. name must be
+// unique to the test.
+func newTestIssue(t *testing.T, r *Repository, name string) *model.Issue {
+	t.Helper()
+	ctx := context.Background()
+	reporter := &model.User{Name: name + " Reporter", Email: strings.ToLower(name) + ".reporter@example.com", Role: "member", CreatedBy: "test", ModifiedBy: "test"}
+	reporter.Password.Hash = []byte("hash")
+	if err := r.CreateUser(ctx, reporter); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { r.DeleteUser(ctx, reporter.ID, true, testUser) })
+	project := &model.Project{Name: name + " Project", StartDate: time.Now(), TargetEndDate: time.Now().AddDate(0, 1, 0), NotificationChannels: model.NotificationChannels, CreatedBy: "test", ModifiedBy: "test"}
+	if err := r.CreateProject(ctx, project); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { r.DeleteProject(ctx, project.ID) })
+	issue := &model.Issue{Title: name + " fails", Description: "It times out", ReporterID: reporter.ID, ProjectID: project.ID, Status: "open", Priority: "low", TargetResolutionDate: time.Now().AddDate(0, 0, 7), CreatedBy: "test", ModifiedBy: "test"}
+	if err := r.CreateIssue(ctx, issue); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { r.PurgeIssue(ctx, issue.ID, "test") })
+	return issue
+}
+
+func TestDeleteIssueIsRestorable(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	issue := newTestIssue(t, r, "Delete")
+	filters := model.Filters{Page: 1, PageSize: 20, Sort: "id", SortSafelist: []string{"id"}}
+	countIssues := func(includeDeleted bool) int {
+		t.Helper()
+		issues, _, err := r.GetAllIssues(ctx, model.IssueFilters{ProjectID: issue.ProjectID, IncludeDeleted: includeDeleted}, issue.ReporterID, filters)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(issues)
+	}
+
+	deleted := []*model.IssueActivity{{IssueID: issue.ID, Field: "deleted", OldValue: "false", NewValue: "true", ChangedBy: "test"}}
+	if err := r.DeleteIssue(ctx, issue.ID, deleted); err != nil {
+		t.Fatalf("DeleteIssue() error = %v", err)
+	}
+	activity, err := r.GetIssueActivitySince(ctx, issue.ID, issue.Version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(activity) != 1 || activity[0].Field != "deleted" || activity[0].Version != issue.Version+1 {
+		t.Errorf("DeleteIssue() recorded activity %+v, want the deletion at version %d", activity, issue.Version+1)
+	}
+	if _, err := r.GetIssue(ctx, issue.ID); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("GetIssue() of a deleted issue error = %v, want ErrNotFound", err)
+	}
+	if n := countIssues(false); n != 0 {
+		t.Errorf("GetAllIssues() returned %d issues, want deleted issues left out", n)
+	}
+	if n := countIssues(true); n != 1 {
+		t.Errorf("GetAllIssues(includeDeleted) returned %d issues, want 1", n)
+	}
+	if err := r.DeleteIssue(ctx, issue.ID, nil); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("DeleteIssue() of a deleted issue error = %v, want ErrNotFound", err)
+	}
+
+	if err := r.RestoreIssue(ctx, issue.ID, nil); err != nil {
+		t.Fatalf("RestoreIssue() error = %v", err)
+	}
+	if _, err := r.GetIssue(ctx, issue.ID); err != nil {
+		t.Errorf("GetIssue() of a restored issue error = %v", err)
+	}
+	if err := r.RestoreIssue(ctx, issue.ID, nil); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("RestoreIssue() of an issue that isn't deleted error = %v, want ErrNotFound", err)
+	}
+
+	if err := r.PurgeIssue(ctx, issue.ID, "test"); err != nil {
+		t.Fatalf("PurgeIssue() error = %v", err)
+	}
+	if n := countIssues(true); n != 0 {
+		t.Errorf("GetAllIssues(includeDeleted) returned %d issues after purging, want 0", n)
+	}
+}
+
+func TestExportIssuesFilters(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	issue := newTestIssue(t, r, "Export")
+	sort := model.Filters{Sort: "id", SortSafelist: []string{"id"}}
+	tests := []struct {
+		status string
+		want   int
+	}{
+		{"", 1},
+		{"open", 1},
+		{"closed", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			var exported []*model.IssueExport
+			err := r.ExportIssues(ctx, model.IssueFilters{ProjectID: issue.ProjectID, Status: tt.status}, issue.ReporterID, sort, func(issue *model.IssueExport) error {
+				exported = append(exported, issue)
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(exported) != tt.want {
+				t.Errorf("ExportIssues(status=%q) exported %d issues, want %d", tt.status, len(exported), tt.want)
+			}
+		})
+	}
+}
+
+func TestGetAllIssuesNameFilters(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	issue := newTestIssue(t, r, "Names")
+	filters := model.Filters{Page: 1, PageSize: 20, Sort: "id", SortSafelist: []string{"id"}}
+	tests := []struct {
+		name                       string
+		assigneeName, reporterName string
+		want                       int
+	}{
+		{"no name filters include unassigned issues", "", "", 1},
+		{"reporter name ignores case", "", "names reporter", 1},
+		{"other reporter", "", "Someone Else", 0},
+		{"unassigned issue has no assignee name", "Names Reporter", "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues, _, err := r.GetAllIssues(ctx, model.IssueFilters{ProjectID: issue.ProjectID, AssigneeName: tt.assigneeName, ReporterName: tt.reporterName}, issue.ReporterID, filters)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(issues) != tt.want {
+				t.Errorf("GetAllIssues(assignee_name=%q, reporter_name=%q) returned %d issues, want %d", tt.assigneeName, tt.reporterName, len(issues), tt.want)
+			}
+		})
+	}
+}
+
+func TestGetAllIssuesDateRanges(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	issue := newTestIssue(t, r, "Ranges")
+	filters := model.Filters{Page: 1, PageSize: 20, Sort: "id", SortSafelist: []string{"id"}}
+	day := func(offset int) string {
+		return time.Now().UTC().AddDate(0, 0, offset).Format("2006-01-02")
+	}
+	tests := []struct {
+		name                                           string
+		reportedFrom, reportedTo, targetFrom, targetTo string
+		want                                           int
+	}{
+		{"no ranges", "", "", "", "", 1},
+		{"reported within range", day(-1), day(1), "", "", 1},
+		{"reported from tomorrow", day(1), "", "", "", 0},
+		{"target until today", "", "", "", day(0), 0},
+		{"target from today", "", "", day(0), "", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues, _, err := r.GetAllIssues(ctx, model.IssueFilters{ProjectID: issue.ProjectID, ReportedFrom: tt.reportedFrom, ReportedTo: tt.reportedTo, TargetFrom: tt.targetFrom, TargetTo: tt.targetTo}, issue.ReporterID, filters)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(issues) != tt.want {
+				t.Errorf("GetAllIssues() returned %d issues, want %d", len(issues), tt.want)
+			}
+		})
+	}
+}
+
+func TestGetAllIssuesMultiSort(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	first := newTestIssue(t, r, "MultiSort")
+	// The other issues share the first issue's project. Priorities are sorted as text.
+	var want []int64
+	for _, tt := range []struct {
+		priority string
+		days     int
+	}{
+		{"medium", 3},
+		{"medium", 1},
+		{"high", 5},
+	} {
+		issue := *first
+		issue.Priority = tt.priority
+		issue.TargetResolutionDate = time.Now().AddDate(0, 0, tt.days)
+		if err := r.CreateIssue(ctx, &issue); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { r.PurgeIssue(ctx, issue.ID, "test") })
+		want = append(want, issue.ID)
+	}
+	// The first issue has low priority and a target resolution date in 7 days.
+	want = []int64{want[1], want[0], first.ID, want[2]}
+	filters := model.Filters{Page: 1, PageSize: 20, Sort: "-priority,target_resolution_date", SortSafelist: []string{"-priority", "target_resolution_date"}}
+	issues, _, err := r.GetAllIssues(ctx, model.IssueFilters{ProjectID: first.ProjectID}, first.ReporterID, filters)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []int64
+	for _, issue := range issues {
+		got = append(got, issue.ID)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetAllIssues() issue IDs = %v, want %v", got, want)
+	}
+}
+
+func TestUpdateIssueClearsActualResolutionDate(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	issue := newTestIssue(t, r, "Reopen")
+	resolved := time.Now().AddDate(0, 0, 1)
+	issue.ActualResolutionDate = &resolved
+	if err := r.UpdateIssue(ctx, issue, nil); err != nil {
+		t.Fatal(err)
+	}
+	issue.ActualResolutionDate = nil
+	if err := r.UpdateIssue(ctx, issue, nil); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := r.GetIssue(ctx, issue.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.ActualResolutionDate != nil {
+		t.Errorf("stored actual_resolution_date = %v, want NULL", stored.ActualResolutionDate)
+	}
+}
+
+func TestGetAllIssuesUnassigned(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	issue := newTestIssue(t, r, "Unassigned")
+	filters := model.Filters{Page: 1, PageSize: 20, Sort: "id", SortSafelist: []string{"id"}}
+	countUnassigned := func() int {
+		t.Helper()
+		issues, _, err := r.GetAllIssues(ctx, model.IssueFilters{ProjectID: issue.ProjectID, Unassigned: true}, issue.ReporterID, filters)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(issues)
+	}
+	if n := countUnassigned(); n != 1 {
+		t.Errorf("GetAllIssues(unassigned) returned %d issues, want 1", n)
+	}
+	issue.AssignedTo = &issue.ReporterID
+	if err := r.UpdateIssue(ctx, issue, nil); err != nil {
+		t.Fatal(err)
+	}
+	if n := countUnassigned(); n != 0 {
+		t.Errorf("GetAllIssues(unassigned) returned %d issues after assigning the issue, want 0", n)
+	}
+}
+
+func TestCreateIssueWithCommentRollsBack(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	existing := newTestIssue(t, r, "Reproduce")
+	newIssue := func() *model.Issue {
+		return &model.Issue{Title: "Reproduce fails again", Description: "It times out", ReporterID: existing.ReporterID, ProjectID: existing.ProjectID, Status: "open", Priority: "low", TargetResolutionDate: time.Now().AddDate(0, 0, 7), CreatedBy: "test", ModifiedBy: "test"}
+	}
+
+	issue := newIssue()
+	comment := &model.Comment{UserID: existing.ReporterID, Body: "Sign in with a valid account"}
+	if err := r.CreateIssueWithComment(ctx, issue, comment); err != nil {
+		t.Fatalf("CreateIssueWithComment() error = %v", err)
+	}
+	t.Cleanup(func() { r.PurgeIssue(ctx, issue.ID, "test") })
+	if comment.IssueID != issue.ID {
+		t.Errorf("CreateIssueWithComment() commented on issue %d, want %d", comment.IssueID, issue.ID)
+	}
+	if _, err := r.GetComment(ctx, comment.ID); err != nil {
+		t.Errorf("GetComment() error = %v", err)
+	}
+
+	// The comment's author doesn't exist, so inserting the comment fails after the issue
+	// has been inserted.
+	issue = newIssue()
+	comment = &model.Comment{UserID: -1, Body: "Sign in with a valid account"}
+	if err := r.CreateIssueWithComment(ctx, issue, comment); err == nil {
+		t.Fatal("CreateIssueWithComment() with an unknown author error = nil, want an error")
+	}
+	if _, err := r.GetIssue(ctx, issue.ID); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("GetIssue() of the rolled back issue error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestGetUserInvolvedIssuesCommentedAndMentioned(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	issue := newTestIssue(t, r, "Involvement")
+	user := &model.User{Name: "Involvement Commenter", Email: "involvement.commenter@example.com", Role: "member", CreatedBy: "test", ModifiedBy: "test"}
+	user.Password.Hash = []byte("hash")
+	if err := r.CreateUser(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { r.DeleteUser(ctx, user.ID, true, testUser) })
+	filters := model.Filters{Page: 1, PageSize: 20, Sort: "id", SortSafelist: []string{"id"}}
+	countIssues := func(involvement string) int {
+		t.Helper()
+		issues, _, err := r.GetUserInvolvedIssues(ctx, user.ID, involvement, user.ID, "manager", filters)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(issues)
+	}
+
+	if n := countIssues("commenter"); n != 0 {
+		t.Errorf("GetUserInvolvedIssues(commenter) returned %d issues before commenting, want 0", n)
+	}
+	if err := r.CreateComment(ctx, &model.Comment{IssueID: issue.ID, UserID: user.ID, Body: "Looking into it"}); err != nil {
+		t.Fatal(err)
+	}
+	if n := countIssues("commenter"); n != 1 {
+		t.Errorf("GetUserInvolvedIssues(commenter) returned %d issues, want 1", n)
+	}
+	if n := countIssues("mentioned"); n != 0 {
+		t.Errorf("GetUserInvolvedIssues(mentioned) returned %d issues before a mention, want 0", n)
+	}
+	if err := r.CreateComment(ctx, &model.Comment{IssueID: issue.ID, UserID: issue.ReporterID, Body: "Thanks @involvement commenter"}); err != nil {
+		t.Fatal(err)
+	}
+	if n := countIssues("mentioned"); n != 1 {
+		t.Errorf("GetUserInvolvedIssues(mentioned) returned %d issues, want 1", n)
+	}
+}
@@ -3,17 +3,19 @@ package postgres
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/emzola/issuetracker/pkg/model"
 )
 
-func (r *Repository) GetIssuesStatusReport(ctx context.Context, projectID int64) ([]*model.IssuesStatus, error) {
+func (r *Repository) GetIssuesStatusReport(ctx context.Context, projectID int64, from, to time.Time) ([]*model.IssuesStatus, error) {
 	query := `
 		SELECT status, COUNT(status)
 		FROM issues
-		WHERE project_id = $1
+		WHERE project_id = $1 AND draft = false AND deleted_on IS NULL
+		AND reported_date BETWEEN COALESCE(NULLIF($2::date, '0001-01-01'), '-infinity') AND COALESCE(NULLIF($3::date, '0001-01-01'), 'infinity')
 		GROUP BY status`
-	rows, err := r.db.QueryContext(ctx, query, projectID)
+	rows, err := r.db.QueryContext(ctx, query, projectID, from, to)
 	if err != nil {
 		switch {
 		case err.Error() == "ERROR: canceling statement due to user request":
@@ -41,15 +43,51 @@ func (r *Repository) GetIssuesStatusReport(ctx context.Context, projectID int64)
 	return statuses, nil
 }
 
-func (r *Repository) GetIssuesAssigneeReport(ctx context.Context, projectID int64) ([]*model.IssuesAssignee, error) {
+func (r *Repository) GetIssuesTypeReport(ctx context.Context, projectID int64, from, to time.Time) ([]*model.IssuesType, error) {
+	query := `
+		SELECT type, COUNT(type)
+		FROM issues
+		WHERE project_id = $1 AND draft = false AND deleted_on IS NULL
+		AND reported_date BETWEEN COALESCE(NULLIF($2::date, '0001-01-01'), '-infinity') AND COALESCE(NULLIF($3::date, '0001-01-01'), 'infinity')
+		GROUP BY type`
+	rows, err := r.db.QueryContext(ctx, query, projectID, from, to)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return nil, fmt.Errorf("%v: %w", err, ctx.Err())
+		default:
+			return nil, err
+		}
+	}
+	defer rows.Close()
+	types := []*model.IssuesType{}
+	for rows.Next() {
+		var issueType model.IssuesType
+		err := rows.Scan(
+			&issueType.Type,
+			&issueType.IssuesCount,
+		)
+		if err != nil {
+			return nil, err
+		}
+		types = append(types, &issueType)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return types, nil
+}
+
+func (r *Repository) GetIssuesAssigneeReport(ctx context.Context, projectID int64, from, to time.Time) ([]*model.IssuesAssignee, error) {
 	query := `
 		SELECT users.id, users.name, COUNT(users.id)
 		FROM users
 		LEFT JOIN issues
 		ON users.id = issues.assigned_to
-		WHERE project_id = $1
+		WHERE project_id = $1 AND draft = false AND deleted_on IS NULL
+		AND reported_date BETWEEN COALESCE(NULLIF($2::date, '0001-01-01'), '-infinity') AND COALESCE(NULLIF($3::date, '0001-01-01'), 'infinity')
 		GROUP BY users.id`
-	rows, err := r.db.QueryContext(ctx, query, projectID)
+	rows, err := r.db.QueryContext(ctx, query, projectID, from, to)
 	if err != nil {
 		switch {
 		case err.Error() == "ERROR: canceling statement due to user request":
@@ -78,15 +116,16 @@ func (r *Repository) GetIssuesAssigneeReport(ctx context.Context, projectID int6
 	return assignees, nil
 }
 
-func (r *Repository) GetIssuesReporterReport(ctx context.Context, projectID int64) ([]*model.IssuesReporter, error) {
+func (r *Repository) GetIssuesReporterReport(ctx context.Context, projectID int64, from, to time.Time) ([]*model.IssuesReporter, error) {
 	query := `
 		SELECT users.id, users.name, COUNT(users.id)
 		FROM users
 		LEFT JOIN issues
 		ON users.id = issues.reporter_id
-		WHERE project_id = $1
+		WHERE project_id = $1 AND draft = false AND deleted_on IS NULL
+		AND reported_date BETWEEN COALESCE(NULLIF($2::date, '0001-01-01'), '-infinity') AND COALESCE(NULLIF($3::date, '0001-01-01'), 'infinity')
 		GROUP BY users.id`
-	rows, err := r.db.QueryContext(ctx, query, projectID)
+	rows, err := r.db.QueryContext(ctx, query, projectID, from, to)
 	if err != nil {
 		switch {
 		case err.Error() == "ERROR: canceling statement due to user request":
@@ -115,13 +154,14 @@ func (r *Repository) GetIssuesReporterReport(ctx context.Context, projectID int6
 	return reporters, nil
 }
 
-func (r *Repository) GetIssuesPriorityLevelReport(ctx context.Context, projectID int64) ([]*model.IssuesPriority, error) {
+func (r *Repository) GetIssuesPriorityLevelReport(ctx context.Context, projectID int64, from, to time.Time) ([]*model.IssuesPriority, error) {
 	query := `
 		SELECT priority, COUNT(priority)
 		FROM issues
-		WHERE project_id = $1
+		WHERE project_id = $1 AND draft = false AND deleted_on IS NULL
+		AND reported_date BETWEEN COALESCE(NULLIF($2::date, '0001-01-01'), '-infinity') AND COALESCE(NULLIF($3::date, '0001-01-01'), 'infinity')
 		GROUP BY priority`
-	rows, err := r.db.QueryContext(ctx, query, projectID)
+	rows, err := r.db.QueryContext(ctx, query, projectID, from, to)
 	if err != nil {
 		switch {
 		case err.Error() == "ERROR: canceling statement due to user request":
@@ -149,12 +189,13 @@ func (r *Repository) GetIssuesPriorityLevelReport(ctx context.Context, projectID
 	return priorities, nil
 }
 
-func (r *Repository) GetIssuesTargetDateReport(ctx context.Context, projectID int64) ([]*model.IssuesTargetDate, error) {
+func (r *Repository) GetIssuesTargetDateReport(ctx context.Context, projectID int64, from, to time.Time) ([]*model.IssuesTargetDate, error) {
 	query := `
 		SELECT title, target_resolution_date
 		FROM issues
-		WHERE project_id = $1`
-	rows, err := r.db.QueryContext(ctx, query, projectID)
+		WHERE project_id = $1 AND draft = false AND deleted_on IS NULL
+		AND reported_date BETWEEN COALESCE(NULLIF($2::date, '0001-01-01'), '-infinity') AND COALESCE(NULLIF($3::date, '0001-01-01'), 'infinity')`
+	rows, err := r.db.QueryContext(ctx, query, projectID, from, to)
 	if err != nil {
 		switch {
 		case err.Error() == "ERROR: canceling statement due to user request":
@@ -181,3 +222,236 @@ func (r *Repository) GetIssuesTargetDateReport(ctx context.Context, projectID in
 	}
 	return targetDates, nil
 }
+
+// GetIssuesByProjectReport returns open, closed and overdue issue counts for every project
+// the viewer can access. When leadOnly is true, only projects the viewer leads are included.
+func (r *Repository) GetIssuesByProjectReport(ctx context.Context, viewerID int64, viewerRole string, leadOnly bool) ([]*model.IssuesByProject, error) {
+	query := fmt.Sprintf(`
+		SELECT projects.id, projects.name,
+		COUNT(issues.id) FILTER (WHERE %[1]s),
+		COUNT(issues.id) FILTER (WHERE %[2]s),
+		COUNT(issues.id) FILTER (WHERE %[1]s AND issues.target_resolution_date < CURRENT_DATE)
+		FROM projects
+		LEFT JOIN issues ON issues.project_id = projects.id AND issues.draft = false AND issues.deleted_on IS NULL
+		WHERE ($1 = 'manager' OR projects.id IN (
+			SELECT project_id FROM projects_users WHERE user_id = $2
+			UNION
+			SELECT id FROM projects WHERE assigned_to = $2))
+		AND (projects.assigned_to = $2 OR NOT $3)
+		GROUP BY projects.id
+		ORDER BY projects.id`, issueOpen, issueClosed)
+	rows, err := r.db.QueryContext(ctx, query, viewerRole, viewerID, leadOnly)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return nil, fmt.Errorf("%v: %w", err, ctx.Err())
+		default:
+			return nil, err
+		}
+	}
+	defer rows.Close()
+	projects := []*model.IssuesByProject{}
+	for rows.Next() {
+		var project model.IssuesByProject
+		err := rows.Scan(
+			&project.ProjectID,
+			&project.ProjectName,
+			&project.OpenIssues,
+			&project.ClosedIssues,
+			&project.OverdueIssues,
+		)
+		if err != nil {
+			return nil, err
+		}
+		projects = append(projects, &project)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return projects, nil
+}
+
+// GetUserResolutionVelocity returns the number of issues assigned to a user that were
+// resolved in each interval between from and to, based on actual_resolution_date. Intervals
+// without resolved issues are included with a count of zero. Only issues in projects the
+// viewer can access are counted. If projectID is 0, issues in all projects are counted.
+func (r *Repository) GetUserResolutionVelocity(ctx context.Context, userID, projectID int64, interval string, from, to time.Time, viewerID int64, viewerRole string) ([]*model.ResolutionVelocity, error) {
+	query := fmt.Sprintf(`
+		SELECT periods.period, COUNT(issues.id)
+		FROM generate_series(date_trunc($3, $4::timestamptz), date_trunc($3, $5::timestamptz), ('1 ' || $3)::interval) AS periods(period)
+		LEFT JOIN issues ON date_trunc($3, issues.actual_resolution_date) = periods.period
+		AND issues.assigned_to = $1
+		AND %s
+		AND issues.draft = false
+		AND issues.deleted_on IS NULL
+		AND (issues.project_id = $2 OR $2 = 0)
+		AND ($6 = 'manager' OR issues.project_id IN (
+			SELECT project_id FROM projects_users WHERE user_id = $7
+			UNION
+			SELECT id FROM projects WHERE assigned_to = $7))
+		GROUP BY periods.period
+		ORDER BY periods.period`, issueClosed)
+	args := []interface{}{userID, projectID, interval, from, to, viewerRole, viewerID}
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return nil, fmt.Errorf("%v: %w", err, ctx.Err())
+		default:
+			return nil, err
+		}
+	}
+	defer rows.Close()
+	velocity := []*model.ResolutionVelocity{}
+	for rows.Next() {
+		var period model.ResolutionVelocity
+		err := rows.Scan(
+			&period.Period,
+			&period.IssuesClosed,
+		)
+		if err != nil {
+			return nil, err
+		}
+		velocity = append(velocity, &period)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return velocity, nil
+}
+
+// GetIssuesBurndownReport returns, for each day from from to to, the number of the
+// project's issues reported and resolved that day, based on reported_date and
+// actual_resolution_date, and the number of issues left open at the end of the day.
+func (r *Repository) GetIssuesBurndownReport(ctx context.Context, projectID int64, from, to time.Time) ([]*model.IssuesBurndown, error) {
+	query := `
+		WITH project_issues AS (
+			SELECT reported_date, actual_resolution_date
+			FROM issues
+			WHERE project_id = $1 AND draft = false AND deleted_on IS NULL
+		)
+		SELECT days.day::date,
+		(SELECT COUNT(*) FROM project_issues WHERE reported_date = days.day),
+		(SELECT COUNT(*) FROM project_issues WHERE actual_resolution_date = days.day),
+		(SELECT COUNT(*) FROM project_issues WHERE reported_date <= days.day AND (actual_resolution_date IS NULL OR actual_resolution_date > days.day))
+		FROM generate_series($2::date, $3::date, '1 day') AS days(day)
+		ORDER BY days.day`
+	rows, err := r.db.QueryContext(ctx, query, projectID, from, to)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return nil, fmt.Errorf("%v: %w", err, ctx.Err())
+		default:
+			return nil, err
+		}
+	}
+	defer rows.Close()
+	burndown := []*model.IssuesBurndown{}
+	for rows.Next() {
+		var day model.IssuesBurndown
+		err := rows.Scan(
+			&day.Date,
+			&day.Opened,
+			&day.Closed,
+			&day.OpenTotal,
+		)
+		if err != nil {
+			return nil, err
+		}
+		burndown = append(burndown, &day)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return burndown, nil
+}
+
+// GetIssuesEffortReport returns the total hours estimated for and logged against the
+// project's issues, for each assignee. Issues without an estimate count as estimated at
+// zero hours.
+func (r *Repository) GetIssuesEffortReport(ctx context.Context, projectID int64, from, to time.Time) ([]*model.IssuesEffort, error) {
+	query := `
+		SELECT users.id, users.name, COALESCE(SUM(issues.estimated_hours), 0), SUM(issues.logged_hours)
+		FROM users
+		INNER JOIN issues ON users.id = issues.assigned_to
+		WHERE issues.project_id = $1 AND issues.draft = false AND issues.deleted_on IS NULL
+		AND issues.reported_date BETWEEN COALESCE(NULLIF($2::date, '0001-01-01'), '-infinity') AND COALESCE(NULLIF($3::date, '0001-01-01'), 'infinity')
+		GROUP BY users.id
+		ORDER BY users.id`
+	rows, err := r.db.QueryContext(ctx, query, projectID, from, to)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return nil, fmt.Errorf("%v: %w", err, ctx.Err())
+		default:
+			return nil, err
+		}
+	}
+	defer rows.Close()
+	efforts := []*model.IssuesEffort{}
+	for rows.Next() {
+		var effort model.IssuesEffort
+		err := rows.Scan(
+			&effort.AssigneeID,
+			&effort.AssigneeName,
+			&effort.EstimatedHours,
+			&effort.LoggedHours,
+		)
+		if err != nil {
+			return nil, err
+		}
+		efforts = append(efforts, &effort)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return efforts, nil
+}
+
+// GetIssuesMilestoneReport returns the number of issues planned into each of the project's
+// milestones, how many of them are closed and the percentage of closed issues. Milestones
+// without issues are 0% complete.
+func (r *Repository) GetIssuesMilestoneReport(ctx context.Context, projectID int64, from, to time.Time) ([]*model.IssuesByMilestone, error) {
+	query := fmt.Sprintf(`
+		SELECT milestones.id, milestones.title, milestones.due_date, milestones.status,
+		COUNT(issues.id),
+		COUNT(issues.id) FILTER (WHERE %[1]s),
+		COALESCE(ROUND(100.0 * COUNT(issues.id) FILTER (WHERE %[1]s) / NULLIF(COUNT(issues.id), 0), 2), 0)
+		FROM milestones
+		LEFT JOIN issues ON issues.milestone_id = milestones.id AND issues.draft = false AND issues.deleted_on IS NULL
+			AND issues.reported_date BETWEEN COALESCE(NULLIF($2::date, '0001-01-01'), '-infinity') AND COALESCE(NULLIF($3::date, '0001-01-01'), 'infinity')
+		WHERE milestones.project_id = $1
+		GROUP BY milestones.id
+		ORDER BY milestones.due_date, milestones.id`, issueClosed)
+	rows, err := r.db.QueryContext(ctx, query, projectID, from, to)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return nil, fmt.Errorf("%v: %w", err, ctx.Err())
+		default:
+			return nil, err
+		}
+	}
+	defer rows.Close()
+	milestones := []*model.IssuesByMilestone{}
+	for rows.Next() {
+		var milestone model.IssuesByMilestone
+		err := rows.Scan(
+			&milestone.MilestoneID,
+			&milestone.MilestoneTitle,
+			&milestone.DueDate,
+			&milestone.Status,
+			&milestone.TotalIssues,
+			&milestone.ClosedIssues,
+			&milestone.CompletionPercentage,
+		)
+		if err != nil {
+			return nil, err
+		}
+		milestones = append(milestones, &milestone)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return milestones, nil
+}
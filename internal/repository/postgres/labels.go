@@ -0,0 +1,100 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/emzola/issuetracker/internal/repository"
+	"github.com/emzola/issuetracker/pkg/model"
+)
+
+// AddLabelToIssue adds the label to the issue, creating the label if it doesn't exist.
+// Adding a label the issue already has is not an error.
+func (r *Repository) AddLabelToIssue(ctx context.Context, issueID int64, label *model.Label) error {
+	query := `
+		INSERT INTO labels (name)
+		VALUES ($1)
+		ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+		RETURNING id`
+	err := r.db.QueryRowContext(ctx, query, label.Name).Scan(&label.ID)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return fmt.Errorf("%v: %w", err, ctx.Err())
+		default:
+			return err
+		}
+	}
+	query = `
+		INSERT INTO issues_labels (issue_id, label_id)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING`
+	_, err = r.db.ExecContext(ctx, query, issueID, label.ID)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return fmt.Errorf("%v: %w", err, ctx.Err())
+		default:
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Repository) RemoveLabelFromIssue(ctx context.Context, issueID, labelID int64) error {
+	query := `
+		DELETE FROM issues_labels
+		WHERE issue_id = $1 AND label_id = $2`
+	result, err := r.db.ExecContext(ctx, query, issueID, labelID)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return fmt.Errorf("%v: %w", err, ctx.Err())
+		default:
+			return err
+		}
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+func (r *Repository) GetIssueLabels(ctx context.Context, issueID int64) ([]*model.Label, error) {
+	query := `
+		SELECT labels.id, labels.name
+		FROM labels
+		INNER JOIN issues_labels ON issues_labels.label_id = labels.id
+		WHERE issues_labels.issue_id = $1
+		ORDER BY labels.name`
+	rows, err := r.db.QueryContext(ctx, query, issueID)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return nil, fmt.Errorf("%v: %w", err, ctx.Err())
+		default:
+			return nil, err
+		}
+	}
+	defer rows.Close()
+	labels := []*model.Label{}
+	for rows.Next() {
+		var label model.Label
+		err := rows.Scan(
+			&label.ID,
+			&label.Name,
+		)
+		if err != nil {
+			return nil, err
+		}
+		labels = append(labels, &label)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return labels, nil
+}
@@ -0,0 +1,184 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/emzola/issuetracker/internal/repository"
+	"github.com/emzola/issuetracker/pkg/model"
+)
+
+// CreateIssueLink creates the link, along with its reciprocal link from the target issue
+// back to the source issue if the link type has one. Creating a link that already exists
+// returns repository.ErrDuplicateKey.
+func (r *Repository) CreateIssueLink(ctx context.Context, link *model.IssueLink) error {
+	return r.WithTx(ctx, func(tx *Repository) error {
+		query := `
+			INSERT INTO issue_links (source_id, target_id, link_type, created_by)
+			VALUES ($1, $2, $3, $4)
+			RETURNING id, created_on`
+		err := tx.db.QueryRowContext(ctx, query, link.SourceID, link.TargetID, link.LinkType, link.CreatedBy).Scan(&link.ID, &link.CreatedOn)
+		if err != nil {
+			switch {
+			case err.Error() == "ERROR: canceling statement due to user request":
+				return fmt.Errorf("%v: %w", err, ctx.Err())
+			case err.Error() == `ERROR: duplicate key value violates unique constraint "issue_links_unique" (SQLSTATE 23505)`:
+				return repository.ErrDuplicateKey
+			default:
+				return err
+			}
+		}
+		if reciprocal, ok := model.IssueLinkReciprocal(link.LinkType); ok {
+			query = `
+				INSERT INTO issue_links (source_id, target_id, link_type, created_by)
+				VALUES ($1, $2, $3, $4)
+				ON CONFLICT DO NOTHING`
+			_, err = tx.db.ExecContext(ctx, query, link.TargetID, link.SourceID, reciprocal, link.CreatedBy)
+			if err != nil {
+				switch {
+				case err.Error() == "ERROR: canceling statement due to user request":
+					return fmt.Errorf("%v: %w", err, ctx.Err())
+				default:
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// DeleteIssueLink deletes a link from the issue, along with its reciprocal link.
+func (r *Repository) DeleteIssueLink(ctx context.Context, issueID, linkID int64) error {
+	return r.WithTx(ctx, func(tx *Repository) error {
+		query := `
+			DELETE FROM issue_links
+			WHERE id = $1 AND source_id = $2
+			RETURNING target_id, link_type`
+		var link model.IssueLink
+		err := tx.db.QueryRowContext(ctx, query, linkID, issueID).Scan(&link.TargetID, &link.LinkType)
+		if err != nil {
+			switch {
+			case err.Error() == "ERROR: canceling statement due to user request":
+				return fmt.Errorf("%v: %w", err, ctx.Err())
+			case errors.Is(err, sql.ErrNoRows):
+				return repository.ErrNotFound
+			default:
+				return err
+			}
+		}
+		if reciprocal, ok := model.IssueLinkReciprocal(link.LinkType); ok {
+			query = `
+				DELETE FROM issue_links
+				WHERE source_id = $1 AND target_id = $2 AND link_type = $3`
+			_, err = tx.db.ExecContext(ctx, query, link.TargetID, issueID, reciprocal)
+			if err != nil {
+				switch {
+				case err.Error() == "ERROR: canceling statement due to user request":
+					return fmt.Errorf("%v: %w", err, ctx.Err())
+				default:
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// GetIssueLinks returns the links from the issue to issues visible to the viewer. Drafts
+// are only visible to their reporter.
+func (r *Repository) GetIssueLinks(ctx context.Context, issueID, viewerID int64) ([]*model.IssueLink, error) {
+	query := `
+		SELECT issue_links.id, issue_links.source_id, issue_links.target_id, issue_links.link_type, issues.title, issues.status, issue_links.created_on, issue_links.created_by
+		FROM issue_links
+		INNER JOIN issues ON issues.id = issue_links.target_id
+		WHERE issue_links.source_id = $1
+		AND (issues.draft = false OR issues.reporter_id = $2)
+		AND issues.deleted_on IS NULL
+		ORDER BY issue_links.id`
+	rows, err := r.db.QueryContext(ctx, query, issueID, viewerID)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return nil, fmt.Errorf("%v: %w", err, ctx.Err())
+		default:
+			return nil, err
+		}
+	}
+	defer rows.Close()
+	links := []*model.IssueLink{}
+	for rows.Next() {
+		var link model.IssueLink
+		err := rows.Scan(
+			&link.ID,
+			&link.SourceID,
+			&link.TargetID,
+			&link.LinkType,
+			&link.TargetTitle,
+			&link.TargetStatus,
+			&link.CreatedOn,
+			&link.CreatedBy,
+		)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, &link)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+// GetDuplicateChain returns the issues connected to the issue through duplicates links in
+// either direction, with the issues each of them duplicates. Links are followed through
+// every issue, but drafts are only returned to their reporter and deleted issues are left
+// out. Cycles end the traversal, since issues already reached are not added again.
+func (r *Repository) GetDuplicateChain(ctx context.Context, issueID, viewerID int64) ([]*model.DuplicateChainIssue, error) {
+	query := `
+		WITH RECURSIVE chain (id) AS (
+			SELECT $1::bigint
+			UNION
+			SELECT CASE WHEN issue_links.source_id = chain.id THEN issue_links.target_id ELSE issue_links.source_id END
+			FROM issue_links
+			INNER JOIN chain ON chain.id IN (issue_links.source_id, issue_links.target_id)
+			WHERE issue_links.link_type = 'duplicates'
+		)
+		SELECT issues.id, issues.title, issues.status, COALESCE(array_agg(issue_links.target_id ORDER BY issue_links.target_id) FILTER (WHERE issue_links.target_id IS NOT NULL), '{}')
+		FROM chain
+		INNER JOIN issues ON issues.id = chain.id
+		LEFT JOIN issue_links ON issue_links.source_id = issues.id AND issue_links.link_type = 'duplicates'
+		WHERE (issues.draft = false OR issues.reporter_id = $2)
+		AND issues.deleted_on IS NULL
+		GROUP BY issues.id
+		ORDER BY issues.id`
+	rows, err := r.db.QueryContext(ctx, query, issueID, viewerID)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return nil, fmt.Errorf("%v: %w", err, ctx.Err())
+		default:
+			return nil, err
+		}
+	}
+	defer rows.Close()
+	issues := []*model.DuplicateChainIssue{}
+	for rows.Next() {
+		var issue model.DuplicateChainIssue
+		err := rows.Scan(
+			&issue.ID,
+			&issue.Title,
+			&issue.Status,
+			bigintArray(&issue.DuplicateOf),
+		)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, &issue)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return issues, nil
+}
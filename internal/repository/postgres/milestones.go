@@ -0,0 +1,300 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/emzola/issuetracker/internal/repository"
+	"github.com/emzola/issuetracker/pkg/model"
+)
+
+func (r *Repository) CreateMilestone(ctx context.Context, milestone *model.Milestone) error {
+	query := `
+		INSERT INTO milestones (project_id, title, due_date, status, created_by, modified_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_on, modified_on, version`
+	args := []interface{}{milestone.ProjectID, milestone.Title, milestone.DueDate, milestone.Status, milestone.CreatedBy, milestone.ModifiedBy}
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(&milestone.ID, &milestone.CreatedOn, &milestone.ModifiedOn, &milestone.Version)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return fmt.Errorf("%v: %w", err, ctx.Err())
+		case err.Error() == `ERROR: duplicate key value violates unique constraint "milestones_project_title_key" (SQLSTATE 23505)`:
+			return repository.ErrDuplicateKey
+		default:
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Repository) GetMilestone(ctx context.Context, id int64) (*model.Milestone, error) {
+	if id < 1 {
+		return nil, repository.ErrNotFound
+	}
+	query := `
+		SELECT id, project_id, title, due_date, status, created_on, created_by, modified_on, modified_by, version
+		FROM milestones
+		WHERE id = $1`
+	var milestone model.Milestone
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&milestone.ID,
+		&milestone.ProjectID,
+		&milestone.Title,
+		&milestone.DueDate,
+		&milestone.Status,
+		&milestone.CreatedOn,
+		&milestone.CreatedBy,
+		&milestone.ModifiedOn,
+		&milestone.ModifiedBy,
+		&milestone.Version,
+	)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return nil, fmt.Errorf("%v: %w", err, ctx.Err())
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, repository.ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+	return &milestone, nil
+}
+
+// GetAllMilestones returns a project's milestones, optionally filtered by status.
+func (r *Repository) GetAllMilestones(ctx context.Context, projectID int64, status string, filters model.Filters) ([]*model.Milestone, model.Metadata, error) {
+	query := fmt.Sprintf(`
+		SELECT count(*) OVER(), id, project_id, title, due_date, status, created_on, created_by, modified_on, modified_by, version
+		FROM milestones
+		WHERE project_id = $1
+		AND (status = $2 OR $2 = '')
+		ORDER BY %s, id ASC
+		LIMIT $3 OFFSET $4`, filters.OrderBy())
+	args := []interface{}{projectID, status, filters.Limit(), filters.Offset()}
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return nil, model.Metadata{}, fmt.Errorf("%v: %w", err, ctx.Err())
+		default:
+			return nil, model.Metadata{}, err
+		}
+	}
+	defer rows.Close()
+	totalRecords := 0
+	milestones := []*model.Milestone{}
+	for rows.Next() {
+		var milestone model.Milestone
+		err := rows.Scan(
+			&totalRecords,
+			&milestone.ID,
+			&milestone.ProjectID,
+			&milestone.Title,
+			&milestone.DueDate,
+			&milestone.Status,
+			&milestone.CreatedOn,
+			&milestone.CreatedBy,
+			&milestone.ModifiedOn,
+			&milestone.ModifiedBy,
+			&milestone.Version,
+		)
+		if err != nil {
+			return nil, model.Metadata{}, err
+		}
+		milestones = append(milestones, &milestone)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, model.Metadata{}, err
+	}
+	metadata := model.CalculateMetadata(totalRecords, filters.Page, filters.PageSize)
+	return milestones, metadata, nil
+}
+
+func (r *Repository) UpdateMilestone(ctx context.Context, milestone *model.Milestone) error {
+	query := `
+		UPDATE milestones
+		SET title = $1, due_date = $2, status = $3, modified_on = CURRENT_TIMESTAMP(0), modified_by = $4, version = version + 1
+		WHERE id = $5 AND version = $6
+		RETURNING modified_on, version`
+	args := []interface{}{milestone.Title, milestone.DueDate, milestone.Status, milestone.ModifiedBy, milestone.ID, milestone.Version}
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(&milestone.ModifiedOn, &milestone.Version)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return fmt.Errorf("%v: %w", err, ctx.Err())
+		case err.Error() == `ERROR: duplicate key value violates unique constraint "milestones_project_title_key" (SQLSTATE 23505)`:
+			return repository.ErrDuplicateKey
+		case errors.Is(err, sql.ErrNoRows):
+			return repository.ErrEditConflict
+		default:
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteMilestone deletes a milestone. Its issues are kept, without a milestone.
+func (r *Repository) DeleteMilestone(ctx context.Context, id int64) error {
+	if id < 1 {
+		return repository.ErrNotFound
+	}
+	query := `
+		DELETE FROM milestones
+		WHERE id = $1`
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return fmt.Errorf("%v: %w", err, ctx.Err())
+		default:
+			return err
+		}
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// GetProjectBacklog returns the issues of a project that aren't planned into a milestone
+// and aren't in one of the closed statuses. For projects without milestones, that is
+// every issue still open. Draft issues are only returned to their reporter.
+func (r *Repository) GetProjectBacklog(ctx context.Context, projectID int64, closedStatuses []string, viewerID int64, filters model.Filters) ([]*model.Issue, model.Metadata, error) {
+	query := fmt.Sprintf(`
+		SELECT count(*) OVER(), id, title, description, reporter_id, reported_date, project_id, milestone_id, assigned_to, status, priority, type, target_resolution_date, progress, actual_resolution_date, resolution_summary, created_on, created_by, modified_on, modified_by, version, draft, estimated_hours, logged_hours
+		FROM issues
+		WHERE project_id = $1
+		AND milestone_id IS NULL
+		AND NOT (status = ANY($2::text[]))
+		AND (draft = false OR reporter_id = $3)
+		AND deleted_on IS NULL
+		ORDER BY %s, id ASC
+		LIMIT $4 OFFSET $5`, filters.OrderBy())
+	args := []interface{}{projectID, closedStatuses, viewerID, filters.Limit(), filters.Offset()}
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return nil, model.Metadata{}, fmt.Errorf("%v: %w", err, ctx.Err())
+		default:
+			return nil, model.Metadata{}, err
+		}
+	}
+	defer rows.Close()
+	totalRecords := 0
+	issues := []*model.Issue{}
+	for rows.Next() {
+		var issue model.Issue
+		err := rows.Scan(
+			&totalRecords,
+			&issue.ID,
+			&issue.Title,
+			&issue.Description,
+			&issue.ReporterID,
+			&issue.ReportedDate,
+			&issue.ProjectID,
+			&issue.MilestoneID,
+			&issue.AssignedTo,
+			&issue.Status,
+			&issue.Priority,
+			&issue.Type,
+			&issue.TargetResolutionDate,
+			&issue.Progress,
+			&issue.ActualResolutionDate,
+			&issue.ResolutionSummary,
+			&issue.CreatedOn,
+			&issue.CreatedBy,
+			&issue.ModifiedOn,
+			&issue.ModifiedBy,
+			&issue.Version,
+			&issue.Draft,
+			&issue.EstimatedHours,
+			&issue.LoggedHours,
+		)
+		if err != nil {
+			return nil, model.Metadata{}, err
+		}
+		issues = append(issues, &issue)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, model.Metadata{}, err
+	}
+	metadata := model.CalculateMetadata(totalRecords, filters.Page, filters.PageSize)
+	return issues, metadata, nil
+}
+
+// GetMilestoneAtRiskIssues returns the issues of a milestone that aren't in one of the
+// closed statuses and are targeted for resolution after dueDate, with the number of days
+// between the two. Draft issues are only returned to their reporter.
+func (r *Repository) GetMilestoneAtRiskIssues(ctx context.Context, milestoneID int64, dueDate time.Time, closedStatuses []string, viewerID int64, filters model.Filters) ([]*model.AtRiskIssue, model.Metadata, error) {
+	query := fmt.Sprintf(`
+		SELECT count(*) OVER(), id, title, description, reporter_id, reported_date, project_id, milestone_id, assigned_to, status, priority, type, target_resolution_date, progress, actual_resolution_date, resolution_summary, created_on, created_by, modified_on, modified_by, version, draft, estimated_hours, logged_hours, target_resolution_date - $2::date
+		FROM issues
+		WHERE milestone_id = $1
+		AND target_resolution_date > $2::date
+		AND NOT (status = ANY($3::text[]))
+		AND (draft = false OR reporter_id = $4)
+		AND deleted_on IS NULL
+		ORDER BY %s, id ASC
+		LIMIT $5 OFFSET $6`, filters.OrderBy())
+	args := []interface{}{milestoneID, dueDate, closedStatuses, viewerID, filters.Limit(), filters.Offset()}
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return nil, model.Metadata{}, fmt.Errorf("%v: %w", err, ctx.Err())
+		default:
+			return nil, model.Metadata{}, err
+		}
+	}
+	defer rows.Close()
+	totalRecords := 0
+	issues := []*model.AtRiskIssue{}
+	for rows.Next() {
+		var issue model.AtRiskIssue
+		err := rows.Scan(
+			&totalRecords,
+			&issue.ID,
+			&issue.Title,
+			&issue.Description,
+			&issue.ReporterID,
+			&issue.ReportedDate,
+			&issue.ProjectID,
+			&issue.MilestoneID,
+			&issue.AssignedTo,
+			&issue.Status,
+			&issue.Priority,
+			&issue.Type,
+			&issue.TargetResolutionDate,
+			&issue.Progress,
+			&issue.ActualResolutionDate,
+			&issue.ResolutionSummary,
+			&issue.CreatedOn,
+			&issue.CreatedBy,
+			&issue.ModifiedOn,
+			&issue.ModifiedBy,
+			&issue.Version,
+			&issue.Draft,
+			&issue.EstimatedHours,
+			&issue.LoggedHours,
+			&issue.DaysPastMilestone,
+		)
+		if err != nil {
+			return nil, model.Metadata{}, err
+		}
+		issues = append(issues, &issue)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, model.Metadata{}, err
+	}
+	metadata := model.CalculateMetadata(totalRecords, filters.Page, filters.PageSize)
+	return issues, metadata, nil
+}
@@ -0,0 +1,60 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/emzola/issuetracker/pkg/model"
+)
+
+func TestGetProjectBacklog(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	planned := newTestIssue(t, r, "Backlog")
+	newIssue := func(title, status string) *model.Issue {
+		t.Helper()
+		issue := &model.Issue{Title: title, Description: "It times out", ReporterID: planned.ReporterID, ProjectID: planned.ProjectID, Status: status, Priority: "low", TargetResolutionDate: time.Now().AddDate(0, 0, 7), CreatedBy: "test", ModifiedBy: "test"}
+		if err := r.CreateIssue(ctx, issue); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { r.PurgeIssue(ctx, issue.ID, "test") })
+		return issue
+	}
+	unplanned := newIssue("Backlog is unplanned", "in progress")
+	newIssue("Backlog is closed", "closed")
+	filters := model.Filters{Page: 1, PageSize: 20, Sort: "id", SortSafelist: []string{"id"}}
+	backlog := func() []int64 {
+		t.Helper()
+		issues, metadata, err := r.GetProjectBacklog(ctx, planned.ProjectID, []string{"closed"}, planned.ReporterID, filters)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if metadata.TotalRecords != len(issues) {
+			t.Errorf("GetProjectBacklog() total records = %d, want %d", metadata.TotalRecords, len(issues))
+		}
+		ids := []int64{}
+		for _, issue := range issues {
+			ids = append(ids, issue.ID)
+		}
+		return ids
+	}
+
+	// Without milestones, every issue still open is in the backlog.
+	if got := backlog(); len(got) != 2 || got[0] != planned.ID || got[1] != unplanned.ID {
+		t.Errorf("GetProjectBacklog() without milestones = %v, want [%d %d]", got, planned.ID, unplanned.ID)
+	}
+
+	milestone := &model.Milestone{ProjectID: planned.ProjectID, Title: "Sprint 1", DueDate: time.Now().AddDate(0, 0, 14), Status: "open", CreatedBy: "test", ModifiedBy: "test"}
+	if err := r.CreateMilestone(ctx, milestone); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { r.DeleteMilestone(ctx, milestone.ID) })
+	planned.MilestoneID = &milestone.ID
+	if err := r.UpdateIssue(ctx, planned, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := backlog(); len(got) != 1 || got[0] != unplanned.ID {
+		t.Errorf("GetProjectBacklog() with a milestone = %v, want [%d]", got, unplanned.ID)
+	}
+}
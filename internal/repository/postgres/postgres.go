@@ -1,13 +1,61 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
+// DBTX is implemented by both *sql.DB and *sql.Tx, so that the repository's queries run
+// the same way on their own or in a transaction.
+type DBTX interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
 type Repository struct {
-	db *sql.DB
+	db               DBTX
+	pool             *sql.DB
+	textSearchConfig string
+}
+
+func New(db *sql.DB, textSearchConfig string) *Repository {
+	return &Repository{db: db, pool: db, textSearchConfig: textSearchConfig}
+}
+
+// WithTx runs fn with a repository bound to a new transaction, which is committed if fn
+// succeeds and rolled back otherwise. On a repository that is already bound to a
+// transaction, fn runs in that transaction, so that methods using WithTx can be combined
+// into a larger transaction.
+func (r *Repository) WithTx(ctx context.Context, fn func(tx *Repository) error) error {
+	if _, ok := r.db.(*sql.Tx); ok {
+		return fn(r)
+	}
+	tx, err := r.pool.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	err = fn(&Repository{db: tx, pool: r.pool, textSearchConfig: r.textSearchConfig})
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// textArray returns a scanner that reads a PostgreSQL text[] column into dst.
+func textArray(dst *[]string) sql.Scanner {
+	return pgtype.NewMap().SQLScanner(dst)
+}
+
+// bigintArray returns a scanner that reads a PostgreSQL bigint[] column into dst.
+func bigintArray(dst *[]int64) sql.Scanner {
+	return pgtype.NewMap().SQLScanner(dst)
 }
 
-func New(db *sql.DB) *Repository {
-	return &Repository{db}
+// Ping checks that the database is reachable.
+func (r *Repository) Ping(ctx context.Context) error {
+	return r.pool.PingContext(ctx)
 }
@@ -0,0 +1,69 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/emzola/issuetracker/pkg/model"
+)
+
+func TestWithTx(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	issue := newTestIssue(t, r, "Transaction")
+	filters := model.Filters{Page: 1, PageSize: 20, Sort: "id", SortSafelist: []string{"id"}}
+	update := func(tx *Repository, title string) error {
+		t.Helper()
+		updated, err := tx.GetIssue(ctx, issue.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		updated.Title = title
+		// UpdateIssues runs in its own transaction, unless it joins an outer one.
+		if err := tx.UpdateIssues(ctx, []*model.Issue{updated}, [][]*model.IssueActivity{nil}); err != nil {
+			return err
+		}
+		return tx.CreateComment(ctx, &model.Comment{IssueID: issue.ID, UserID: issue.ReporterID, Body: "Renamed to " + title})
+	}
+
+	errFailed := errors.New("failed")
+	err := r.WithTx(ctx, func(tx *Repository) error {
+		if err := update(tx, "Transaction rolled back"); err != nil {
+			return err
+		}
+		return errFailed
+	})
+	if !errors.Is(err, errFailed) {
+		t.Fatalf("WithTx() error = %v, want %v", err, errFailed)
+	}
+	got, err := r.GetIssue(ctx, issue.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	comments, _, err := r.GetAllComments(ctx, issue.ID, filters)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Title != issue.Title || len(comments) != 0 {
+		t.Errorf("WithTx() after a failure left title %q and %d comments, want %q and none", got.Title, len(comments), issue.Title)
+	}
+
+	err = r.WithTx(ctx, func(tx *Repository) error {
+		return update(tx, "Transaction committed")
+	})
+	if err != nil {
+		t.Fatalf("WithTx() error = %v", err)
+	}
+	got, err = r.GetIssue(ctx, issue.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	comments, _, err = r.GetAllComments(ctx, issue.ID, filters)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Title != "Transaction committed" || len(comments) != 1 {
+		t.Errorf("WithTx() left title %q and %d comments, want %q and 1", got.Title, len(comments), "Transaction committed")
+	}
+}
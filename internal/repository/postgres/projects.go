@@ -11,19 +11,26 @@ import (
 	"github.com/emzola/issuetracker/pkg/model"
 )
 
+// CreateProject creates a project. Projects without a key are given one made of the first
+// letters of their name followed by their ID, which cannot clash with chosen keys since
+// those are letters only.
 func (r *Repository) CreateProject(ctx context.Context, project *model.Project) error {
 	query := `
-		INSERT INTO projects (name, description, assigned_to, start_date, target_end_date, created_by, modified_by)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-		RETURNING id, created_on, modified_on, version`
-	args := []interface{}{project.Name, project.Description, project.AssignedTo, project.StartDate, project.TargetEndDate, project.CreatedBy, project.ModifiedBy}
-	err := r.db.QueryRowContext(ctx, query, args...).Scan(&project.ID, &project.CreatedOn, &project.ModifiedOn, &project.Version)
+		WITH next AS (SELECT nextval(pg_get_serial_sequence('projects', 'id')) AS id)
+		INSERT INTO projects (id, key, name, description, assigned_to, start_date, target_end_date, auto_close_days, notification_channels, created_by, modified_by)
+		SELECT next.id, COALESCE(NULLIF($1, ''), COALESCE(NULLIF(upper(left(regexp_replace($2, '[^a-zA-Z]', '', 'g'), 4)), ''), 'P') || next.id), $2, $3, $4, $5, $6, $7, $8, $9, $10
+		FROM next
+		RETURNING id, key, created_on, modified_on, version`
+	args := []interface{}{project.Key, project.Name, project.Description, project.AssignedTo, project.StartDate, project.TargetEndDate, project.AutoCloseDays, project.NotificationChannels, project.CreatedBy, project.ModifiedBy}
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(&project.ID, &project.Key, &project.CreatedOn, &project.ModifiedOn, &project.Version)
 	if err != nil {
 		switch {
 		case err.Error() == "ERROR: canceling statement due to user request":
 			return fmt.Errorf("%v: %w", err, ctx.Err())
 		case err.Error() == `ERROR: duplicate key value violates unique constraint "projects_name_key" (SQLSTATE 23505)`:
 			return repository.ErrDuplicateKey
+		case err.Error() == `ERROR: duplicate key value violates unique constraint "projects_key_key" (SQLSTATE 23505)`:
+			return repository.ErrDuplicateProjectKey
 		default:
 			return err
 		}
@@ -36,18 +43,23 @@ func (r *Repository) GetProject(ctx context.Context, id int64) (*model.Project,
 		return nil, repository.ErrNotFound
 	}
 	query := `
-		SELECT id, name, description, assigned_to, start_date, target_end_date, actual_end_date, created_on, modified_on, created_by, modified_by, version
+		SELECT id, key, name, description, assigned_to, start_date, target_end_date, actual_end_date, auto_close_days, notification_channels, archived, archived_on, created_on, modified_on, created_by, modified_by, version
 		FROM projects
 		WHERE id = $1`
 	var project model.Project
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
 		&project.ID,
+		&project.Key,
 		&project.Name,
 		&project.Description,
 		&project.AssignedTo,
 		&project.StartDate,
 		&project.TargetEndDate,
 		&project.ActualEndDate,
+		&project.AutoCloseDays,
+		textArray(&project.NotificationChannels),
+		&project.Archived,
+		&project.ArchivedOn,
 		&project.CreatedOn,
 		&project.ModifiedOn,
 		&project.CreatedBy,
@@ -67,19 +79,20 @@ func (r *Repository) GetProject(ctx context.Context, id int64) (*model.Project,
 	return &project, nil
 }
 
-func (r *Repository) GetAllProjects(ctx context.Context, name string, assignedTo int64, startDate, targetEndDate, actualEndDate time.Time, createdBy string, filters model.Filters) ([]*model.Project, model.Metadata, error) {
+func (r *Repository) GetAllProjects(ctx context.Context, name string, assignedTo int64, startDate, targetEndDate, actualEndDate time.Time, createdBy string, includeArchived bool, filters model.Filters) ([]*model.Project, model.Metadata, error) {
 	query := fmt.Sprintf(`
-		SELECT count(*) OVER(), id, name, description, assigned_to, start_date, target_end_date, actual_end_date, created_on, modified_on, created_by, modified_by, version
+		SELECT count(*) OVER(), id, key, name, description, assigned_to, start_date, target_end_date, actual_end_date, auto_close_days, notification_channels, archived, archived_on, created_on, modified_on, created_by, modified_by, version
 		FROM projects
-		WHERE (to_tsvector('simple', name) @@ plainto_tsquery('simple', $1) OR $1 = '')
+		WHERE (to_tsvector($9::regconfig, immutable_unaccent(name)) @@ plainto_tsquery($9::regconfig, immutable_unaccent($1)) OR $1 = '')
 		AND (assigned_to = $2 OR $2 = 0)
 		AND (start_date = $3 OR $3 = '0001-01-01')
 		AND (target_end_date = $4 OR $4 = '0001-01-01')
 		AND (actual_end_date = $5 OR $5 = '0001-01-01')
 		AND (LOWER(created_by) = LOWER($6) OR $6 = '')
-		ORDER BY %s %s, id ASC 
-		LIMIT $7 OFFSET $8`, filters.SortColumn(), filters.SortDirection())
-	args := []interface{}{name, assignedTo, startDate, targetEndDate, actualEndDate, createdBy, filters.Limit(), filters.Offset()}
+		AND (NOT archived OR $10)
+		ORDER BY %s, id ASC 
+		LIMIT $7 OFFSET $8`, filters.OrderBy())
+	args := []interface{}{name, assignedTo, startDate, targetEndDate, actualEndDate, createdBy, filters.Limit(), filters.Offset(), r.textSearchConfig, includeArchived}
 	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		switch {
@@ -97,12 +110,17 @@ func (r *Repository) GetAllProjects(ctx context.Context, name string, assignedTo
 		err := rows.Scan(
 			&totalRecords,
 			&project.ID,
+			&project.Key,
 			&project.Name,
 			&project.Description,
 			&project.AssignedTo,
 			&project.StartDate,
 			&project.TargetEndDate,
 			&project.ActualEndDate,
+			&project.AutoCloseDays,
+			textArray(&project.NotificationChannels),
+			&project.Archived,
+			&project.ArchivedOn,
 			&project.CreatedOn,
 			&project.ModifiedOn,
 			&project.CreatedBy,
@@ -124,10 +142,10 @@ func (r *Repository) GetAllProjects(ctx context.Context, name string, assignedTo
 func (r *Repository) UpdateProject(ctx context.Context, project *model.Project) error {
 	query := `
 		UPDATE projects
-		SET name = $1, description = $2, assigned_to = $3, start_date = $4, target_end_date = $5, actual_end_date = $6, modified_by = $7, modified_on = CURRENT_TIMESTAMP(0), version = version + 1
-		WHERE id = $8 AND version = $9
+		SET name = $1, description = $2, assigned_to = $3, start_date = $4, target_end_date = $5, actual_end_date = $6, auto_close_days = $7, notification_channels = $8, archived = $9, archived_on = $10, modified_by = $11, modified_on = CURRENT_TIMESTAMP(0), version = version + 1
+		WHERE id = $12 AND version = $13
 		RETURNING modified_on, version`
-	args := []interface{}{project.Name, project.Description, project.AssignedTo, project.StartDate, project.TargetEndDate, project.ActualEndDate, project.ModifiedBy, project.ID, project.Version}
+	args := []interface{}{project.Name, project.Description, project.AssignedTo, project.StartDate, project.TargetEndDate, project.ActualEndDate, project.AutoCloseDays, project.NotificationChannels, project.Archived, project.ArchivedOn, project.ModifiedBy, project.ID, project.Version}
 	err := r.db.QueryRowContext(ctx, query, args...).Scan(&project.ModifiedOn, &project.Version)
 	if err != nil {
 		switch {
@@ -176,8 +194,8 @@ func (r *Repository) GetProjectUsers(ctx context.Context, projectID int64, role
 		INNER JOIN projects ON projects_users.project_id = projects.id
 		WHERE projects.id = $1
 		AND (LOWER(users.role) = LOWER($2) OR $2 = '')
-		ORDER BY %s %s, id ASC
-		LIMIT $3 OFFSET $4`, filters.SortColumn(), filters.SortDirection())
+		ORDER BY %s, id ASC
+		LIMIT $3 OFFSET $4`, filters.OrderBy())
 	args := []interface{}{projectID, role, filters.Limit(), filters.Offset()}
 	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
@@ -219,9 +237,137 @@ func (r *Repository) GetProjectUsers(ctx context.Context, projectID int64, role
 	return users, metadata, nil
 }
 
+// GetProjectUnassignedMembers returns the project's users with role 'member' who have
+// no open issues assigned to them in the project, other than issues waiting to be closed.
+func (r *Repository) GetProjectUnassignedMembers(ctx context.Context, projectID int64, filters model.Filters) ([]*model.User, model.Metadata, error) {
+	query := fmt.Sprintf(`
+		SELECT count(*) OVER(), users.id, users.name, users.email, users.password_hash, users.activated, users.role, users.created_on, users.created_by, users.modified_on, users.modified_by, users.version
+		FROM users
+		INNER JOIN projects_users ON projects_users.user_id = users.id
+		WHERE projects_users.project_id = $1
+		AND users.role = 'member'
+		AND NOT EXISTS (
+			SELECT 1 FROM issues
+			WHERE issues.project_id = projects_users.project_id
+			AND issues.assigned_to = users.id
+			AND %s
+			AND issues.draft = false
+			AND issues.deleted_on IS NULL)
+		ORDER BY %s, id ASC
+		LIMIT $2 OFFSET $3`, issueUnresolved, filters.OrderBy())
+	args := []interface{}{projectID, filters.Limit(), filters.Offset()}
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return nil, model.Metadata{}, fmt.Errorf("%v: %w", err, ctx.Err())
+		default:
+			return nil, model.Metadata{}, err
+		}
+	}
+	defer rows.Close()
+	totalRecords := 0
+	users := []*model.User{}
+	for rows.Next() {
+		var user model.User
+		err := rows.Scan(
+			&totalRecords,
+			&user.ID,
+			&user.Name,
+			&user.Email,
+			&user.Password.Hash,
+			&user.Activated,
+			&user.Role,
+			&user.CreatedOn,
+			&user.CreatedBy,
+			&user.ModifiedOn,
+			&user.ModifiedBy,
+			&user.Version,
+		)
+		if err != nil {
+			return nil, model.Metadata{}, err
+		}
+		users = append(users, &user)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, model.Metadata{}, err
+	}
+	metadata := model.CalculateMetadata(totalRecords, filters.Page, filters.PageSize)
+	return users, metadata, nil
+}
+
+// GetProjectActiveUsers returns the project's users who created, updated, closed or
+// commented on the project's issues since the given time, with how many of each. Issues
+// are closed by moving them into one of closedStatuses.
+func (r *Repository) GetProjectActiveUsers(ctx context.Context, projectID int64, closedStatuses []string, since time.Time, filters model.Filters) ([]*model.ActiveUser, model.Metadata, error) {
+	query := fmt.Sprintf(`
+		SELECT count(*) OVER(), id, name, created, updated, closed, commented, total
+		FROM (
+			SELECT users.id, users.name, counts.created, counts.updated, counts.closed, counts.commented,
+			counts.created + counts.updated + counts.closed + counts.commented AS total
+			FROM users
+			INNER JOIN projects_users ON projects_users.user_id = users.id
+			CROSS JOIN LATERAL (
+				SELECT
+				(SELECT count(*) FROM issues
+					WHERE issues.project_id = $1 AND issues.reporter_id = users.id AND issues.created_on >= $3
+					AND issues.draft = false AND issues.deleted_on IS NULL) AS created,
+				(SELECT count(DISTINCT issue_activity.issue_id) FROM issue_activity
+					INNER JOIN issues ON issues.id = issue_activity.issue_id
+					WHERE issues.project_id = $1 AND issue_activity.changed_by_id = users.id AND issue_activity.changed_on >= $3) AS updated,
+				(SELECT count(DISTINCT issue_activity.issue_id) FROM issue_activity
+					INNER JOIN issues ON issues.id = issue_activity.issue_id
+					WHERE issues.project_id = $1 AND issue_activity.changed_by_id = users.id AND issue_activity.changed_on >= $3
+					AND issue_activity.field = 'status' AND issue_activity.new_value = ANY($2::text[])) AS closed,
+				(SELECT count(*) FROM comments
+					INNER JOIN issues ON issues.id = comments.issue_id
+					WHERE issues.project_id = $1 AND comments.user_id = users.id AND comments.created_on >= $3) AS commented
+			) AS counts
+			WHERE projects_users.project_id = $1
+		) AS active_users
+		WHERE total > 0
+		ORDER BY %s, id ASC
+		LIMIT $4 OFFSET $5`, filters.OrderBy())
+	args := []interface{}{projectID, closedStatuses, since, filters.Limit(), filters.Offset()}
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return nil, model.Metadata{}, fmt.Errorf("%v: %w", err, ctx.Err())
+		default:
+			return nil, model.Metadata{}, err
+		}
+	}
+	defer rows.Close()
+	totalRecords := 0
+	users := []*model.ActiveUser{}
+	for rows.Next() {
+		var user model.ActiveUser
+		err := rows.Scan(
+			&totalRecords,
+			&user.ID,
+			&user.Name,
+			&user.IssuesCreated,
+			&user.IssuesUpdated,
+			&user.IssuesClosed,
+			&user.Comments,
+			&user.Total,
+		)
+		if err != nil {
+			return nil, model.Metadata{}, err
+		}
+		users = append(users, &user)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, model.Metadata{}, err
+	}
+	metadata := model.CalculateMetadata(totalRecords, filters.Page, filters.PageSize)
+	return users, metadata, nil
+}
+
 func (r *Repository) GetProjectUser(ctx context.Context, projectID, userID int64) (*model.User, error) {
 	query := `
-		SELECT users.id, users.name, users.email, users.password_hash, users.activated, users.role, users.created_on, users.created_by, users.modified_on, users.modified_by, users.version
+		SELECT users.id, users.name, users.email, users.password_hash, users.activated, users.role, users.created_on, users.created_by, users.modified_on, users.modified_by, users.version, users.locale
 		FROM users
 		INNER JOIN projects_users ON projects_users.user_id = users.id
 		INNER JOIN projects ON projects_users.project_id = projects.id
@@ -240,6 +386,7 @@ func (r *Repository) GetProjectUser(ctx context.Context, projectID, userID int64
 		&user.ModifiedOn,
 		&user.ModifiedBy,
 		&user.Version,
+		&user.Locale,
 	)
 	if err != nil {
 		switch {
@@ -256,13 +403,13 @@ func (r *Repository) GetProjectUser(ctx context.Context, projectID, userID int64
 
 func (r *Repository) GetAllProjectsForUser(ctx context.Context, userID int64, filters model.Filters) ([]*model.Project, model.Metadata, error) {
 	query := fmt.Sprintf(`
-		SELECT count(*) OVER(), projects.id, projects.name, projects.description, projects.start_date, projects.target_end_date, projects.actual_end_date, projects.created_on, projects.modified_on, projects.created_by, projects.modified_by, projects.version
+		SELECT count(*) OVER(), projects.id, projects.key, projects.name, projects.description, projects.start_date, projects.target_end_date, projects.actual_end_date, projects.auto_close_days, projects.notification_channels, projects.archived, projects.archived_on, projects.created_on, projects.modified_on, projects.created_by, projects.modified_by, projects.version
 		FROM projects
 		INNER JOIN projects_users ON projects_users.project_id = projects.id
 		INNER JOIN users ON projects_users.user_id = users.id
 		WHERE users.id = $1
-		ORDER BY %s %s, id ASC 
-		LIMIT $2 OFFSET $3`, filters.SortColumn(), filters.SortDirection())
+		ORDER BY %s, id ASC 
+		LIMIT $2 OFFSET $3`, filters.OrderBy())
 	args := []interface{}{userID, filters.Limit(), filters.Offset()}
 	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
@@ -281,11 +428,16 @@ func (r *Repository) GetAllProjectsForUser(ctx context.Context, userID int64, fi
 		err := rows.Scan(
 			&totalRecords,
 			&project.ID,
+			&project.Key,
 			&project.Name,
 			&project.Description,
 			&project.StartDate,
 			&project.TargetEndDate,
 			&project.ActualEndDate,
+			&project.AutoCloseDays,
+			textArray(&project.NotificationChannels),
+			&project.Archived,
+			&project.ArchivedOn,
 			&project.CreatedOn,
 			&project.ModifiedOn,
 			&project.CreatedBy,
@@ -303,3 +455,50 @@ func (r *Repository) GetAllProjectsForUser(ctx context.Context, userID int64, fi
 	metadata := model.CalculateMetadata(totalRecords, filters.Page, filters.PageSize)
 	return projects, metadata, nil
 }
+
+// GetProjectSummariesForUser returns the projects a user can access, leaving out archived
+// ones: managers can access every project, while other users can access projects they
+// lead or are members of.
+func (r *Repository) GetProjectSummariesForUser(ctx context.Context, userID int64, role string, filters model.Filters) ([]*model.ProjectSummary, model.Metadata, error) {
+	query := fmt.Sprintf(`
+		SELECT count(*) OVER(), id, key, name
+		FROM projects
+		WHERE NOT archived
+		AND ($1 = 'manager' OR id IN (
+			SELECT project_id FROM projects_users WHERE user_id = $2
+			UNION
+			SELECT id FROM projects WHERE assigned_to = $2))
+		ORDER BY %s, id ASC
+		LIMIT $3 OFFSET $4`, filters.OrderBy())
+	args := []interface{}{role, userID, filters.Limit(), filters.Offset()}
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return nil, model.Metadata{}, fmt.Errorf("%v: %w", err, ctx.Err())
+		default:
+			return nil, model.Metadata{}, err
+		}
+	}
+	defer rows.Close()
+	totalRecords := 0
+	projects := []*model.ProjectSummary{}
+	for rows.Next() {
+		var project model.ProjectSummary
+		err := rows.Scan(
+			&totalRecords,
+			&project.ID,
+			&project.Key,
+			&project.Name,
+		)
+		if err != nil {
+			return nil, model.Metadata{}, err
+		}
+		projects = append(projects, &project)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, model.Metadata{}, err
+	}
+	metadata := model.CalculateMetadata(totalRecords, filters.Page, filters.PageSize)
+	return projects, metadata, nil
+}
@@ -0,0 +1,95 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/emzola/issuetracker/internal/repository"
+	"github.com/emzola/issuetracker/pkg/model"
+)
+
+func TestGetAllProjectsExcludesArchived(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	project := &model.Project{Name: "Archived Project", StartDate: time.Now(), TargetEndDate: time.Now().AddDate(0, 1, 0), NotificationChannels: model.NotificationChannels, CreatedBy: "test", ModifiedBy: "test"}
+	if err := r.CreateProject(ctx, project); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { r.DeleteProject(ctx, project.ID) })
+	archivedOn := time.Now()
+	project.Archived = true
+	project.ArchivedOn = &archivedOn
+	if err := r.UpdateProject(ctx, project); err != nil {
+		t.Fatal(err)
+	}
+	filters := model.Filters{Page: 1, PageSize: 20, Sort: "id", SortSafelist: []string{"id"}}
+	for _, includeArchived := range []bool{false, true} {
+		projects, _, err := r.GetAllProjects(ctx, project.Name, 0, time.Time{}, time.Time{}, time.Time{}, "", includeArchived, filters)
+		if err != nil {
+			t.Fatal(err)
+		}
+		found := false
+		for _, p := range projects {
+			found = found || p.ID == project.ID
+		}
+		if found != includeArchived {
+			t.Errorf("GetAllProjects(includeArchived %v) listed the archived project = %v, want %v", includeArchived, found, includeArchived)
+		}
+	}
+	got, err := r.GetProject(ctx, project.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Archived || got.ArchivedOn == nil {
+		t.Errorf("GetProject() archived = %v, archived on %v, want the project archived", got.Archived, got.ArchivedOn)
+	}
+}
+
+func TestGetProjectSummariesForUser(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	newProject := func(key, name string) *model.Project {
+		t.Helper()
+		project := &model.Project{Key: key, Name: name, StartDate: time.Now(), TargetEndDate: time.Now().AddDate(0, 1, 0), NotificationChannels: model.NotificationChannels, CreatedBy: "test", ModifiedBy: "test"}
+		if err := r.CreateProject(ctx, project); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { r.DeleteProject(ctx, project.ID) })
+		return project
+	}
+	chosen := newProject("PICKER", "Picker Project")
+	generated := newProject("", "Generated Project")
+	archived := newProject("", "Archived Picker Project")
+	archivedOn := time.Now()
+	archived.Archived = true
+	archived.ArchivedOn = &archivedOn
+	if err := r.UpdateProject(ctx, archived); err != nil {
+		t.Fatal(err)
+	}
+	if want := "GENE" + strconv.FormatInt(generated.ID, 10); generated.Key != want {
+		t.Errorf("CreateProject() generated key %q, want %q", generated.Key, want)
+	}
+	duplicate := &model.Project{Key: "PICKER", Name: "Duplicate Picker Project", StartDate: time.Now(), TargetEndDate: time.Now().AddDate(0, 1, 0), NotificationChannels: model.NotificationChannels, CreatedBy: "test", ModifiedBy: "test"}
+	if err := r.CreateProject(ctx, duplicate); !errors.Is(err, repository.ErrDuplicateProjectKey) {
+		t.Errorf("CreateProject() with a taken key error = %v, want ErrDuplicateProjectKey", err)
+	}
+
+	filters := model.Filters{Page: 1, PageSize: 100, Sort: "id", SortSafelist: []string{"id"}}
+	projects, _, err := r.GetProjectSummariesForUser(ctx, 0, "manager", filters)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := make(map[int64]string)
+	for _, p := range projects {
+		keys[p.ID] = p.Key
+	}
+	if keys[chosen.ID] != "PICKER" || keys[generated.ID] != generated.Key {
+		t.Errorf("GetProjectSummariesForUser() keys = %v, want PICKER and %s", keys, generated.Key)
+	}
+	if _, ok := keys[archived.ID]; ok {
+		t.Errorf("GetProjectSummariesForUser() listed an archived project")
+	}
+}
@@ -0,0 +1,200 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/emzola/issuetracker/internal/repository"
+	"github.com/emzola/issuetracker/pkg/model"
+)
+
+func (r *Repository) CreateProjectTemplate(ctx context.Context, template *model.ProjectTemplate) error {
+	body, err := json.Marshal(template.Body)
+	if err != nil {
+		return err
+	}
+	query := `
+		INSERT INTO project_templates (name, body, created_by, modified_by)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_on, modified_on, version`
+	args := []interface{}{template.Name, string(body), template.CreatedBy, template.ModifiedBy}
+	err = r.db.QueryRowContext(ctx, query, args...).Scan(&template.ID, &template.CreatedOn, &template.ModifiedOn, &template.Version)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return fmt.Errorf("%v: %w", err, ctx.Err())
+		case err.Error() == `ERROR: duplicate key value violates unique constraint "project_templates_name_key" (SQLSTATE 23505)`:
+			return repository.ErrDuplicateKey
+		default:
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Repository) GetProjectTemplate(ctx context.Context, id int64) (*model.ProjectTemplate, error) {
+	if id < 1 {
+		return nil, repository.ErrNotFound
+	}
+	query := `
+		SELECT id, name, body, created_on, created_by, modified_on, modified_by, version
+		FROM project_templates
+		WHERE id = $1`
+	var template model.ProjectTemplate
+	var body []byte
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&template.ID,
+		&template.Name,
+		&body,
+		&template.CreatedOn,
+		&template.CreatedBy,
+		&template.ModifiedOn,
+		&template.ModifiedBy,
+		&template.Version,
+	)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return nil, fmt.Errorf("%v: %w", err, ctx.Err())
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, repository.ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+	err = json.Unmarshal(body, &template.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+// GetAllProjectTemplates returns every project template, ordered by name.
+func (r *Repository) GetAllProjectTemplates(ctx context.Context) ([]*model.ProjectTemplate, error) {
+	query := `
+		SELECT id, name, body, created_on, created_by, modified_on, modified_by, version
+		FROM project_templates
+		ORDER BY name`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return nil, fmt.Errorf("%v: %w", err, ctx.Err())
+		default:
+			return nil, err
+		}
+	}
+	defer rows.Close()
+	templates := []*model.ProjectTemplate{}
+	for rows.Next() {
+		var template model.ProjectTemplate
+		var body []byte
+		err := rows.Scan(
+			&template.ID,
+			&template.Name,
+			&body,
+			&template.CreatedOn,
+			&template.CreatedBy,
+			&template.ModifiedOn,
+			&template.ModifiedBy,
+			&template.Version,
+		)
+		if err != nil {
+			return nil, err
+		}
+		err = json.Unmarshal(body, &template.Body)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, &template)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+func (r *Repository) UpdateProjectTemplate(ctx context.Context, template *model.ProjectTemplate) error {
+	body, err := json.Marshal(template.Body)
+	if err != nil {
+		return err
+	}
+	query := `
+		UPDATE project_templates
+		SET name = $1, body = $2, modified_on = CURRENT_TIMESTAMP(0), modified_by = $3, version = version + 1
+		WHERE id = $4 AND version = $5
+		RETURNING modified_on, version`
+	args := []interface{}{template.Name, string(body), template.ModifiedBy, template.ID, template.Version}
+	err = r.db.QueryRowContext(ctx, query, args...).Scan(&template.ModifiedOn, &template.Version)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return fmt.Errorf("%v: %w", err, ctx.Err())
+		case err.Error() == `ERROR: duplicate key value violates unique constraint "project_templates_name_key" (SQLSTATE 23505)`:
+			return repository.ErrDuplicateKey
+		case errors.Is(err, sql.ErrNoRows):
+			return repository.ErrEditConflict
+		default:
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Repository) DeleteProjectTemplate(ctx context.Context, id int64) error {
+	if id < 1 {
+		return repository.ErrNotFound
+	}
+	query := `
+		DELETE FROM project_templates
+		WHERE id = $1`
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return fmt.Errorf("%v: %w", err, ctx.Err())
+		default:
+			return err
+		}
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// CreateProjectFromPlan creates the project of a plan along with its milestones and
+// issues in a single transaction, so that nothing is created if any of them fails.
+func (r *Repository) CreateProjectFromPlan(ctx context.Context, plan *model.ProjectPlan) error {
+	return r.WithTx(ctx, func(tx *Repository) error {
+		err := tx.CreateProject(ctx, plan.Project)
+		if err != nil {
+			return err
+		}
+		for _, milestone := range plan.Milestones {
+			milestone.ProjectID = plan.Project.ID
+			err = tx.CreateMilestone(ctx, milestone)
+			if err != nil {
+				return err
+			}
+		}
+		for i, issue := range plan.Issues {
+			issue.ProjectID = plan.Project.ID
+			if m := plan.IssueMilestones[i]; m >= 0 {
+				issue.MilestoneID = &plan.Milestones[m].ID
+			}
+			err = tx.CreateIssue(ctx, issue)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
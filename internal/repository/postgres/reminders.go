@@ -0,0 +1,81 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/emzola/issuetracker/internal/repository"
+	"github.com/emzola/issuetracker/pkg/model"
+)
+
+func (r *Repository) GetIssuesDueForReminder(ctx context.Context, priority string, dueBefore time.Time) ([]*model.IssueReminder, error) {
+	query := fmt.Sprintf(`
+		SELECT issues.id, issues.title, issues.priority, issues.target_resolution_date, users.name, users.email, users.locale
+		FROM issues
+		INNER JOIN users ON users.id = issues.assigned_to
+		INNER JOIN projects ON projects.id = issues.project_id
+		WHERE LOWER(issues.priority) = LOWER($1)
+		AND 'email' = ANY(projects.notification_channels)
+		AND %s
+		AND issues.draft = false
+		AND issues.deleted_on IS NULL
+		AND issues.reminded_at IS NULL
+		AND issues.target_resolution_date >= CURRENT_DATE
+		AND issues.target_resolution_date <= $2::date`, issueUnresolved)
+	rows, err := r.db.QueryContext(ctx, query, priority, dueBefore)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return nil, fmt.Errorf("%v: %w", err, ctx.Err())
+		default:
+			return nil, err
+		}
+	}
+	defer rows.Close()
+	reminders := []*model.IssueReminder{}
+	for rows.Next() {
+		var reminder model.IssueReminder
+		err := rows.Scan(
+			&reminder.IssueID,
+			&reminder.Title,
+			&reminder.Priority,
+			&reminder.TargetResolutionDate,
+			&reminder.AssigneeName,
+			&reminder.AssigneeEmail,
+			&reminder.AssigneeLocale,
+		)
+		if err != nil {
+			return nil, err
+		}
+		reminders = append(reminders, &reminder)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return reminders, nil
+}
+
+func (r *Repository) MarkIssueReminded(ctx context.Context, issueID int64) error {
+	query := `
+		UPDATE issues
+		SET reminded_at = CURRENT_TIMESTAMP(0)
+		WHERE id = $1`
+	result, err := r.db.ExecContext(ctx, query, issueID)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return fmt.Errorf("%v: %w", err, ctx.Err())
+		default:
+			return err
+		}
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
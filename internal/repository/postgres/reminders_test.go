@@ -0,0 +1,45 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestGetIssuesDueForReminderSkipsResolvedIssues(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	issue := newTestIssue(t, r, "Reminder")
+	issue.AssignedTo = &issue.ReporterID
+	dueBefore := time.Now().AddDate(0, 0, 8)
+	remindedIssues := func() []int64 {
+		t.Helper()
+		reminders, err := r.GetIssuesDueForReminder(ctx, issue.Priority, dueBefore)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids := []int64{}
+		for _, reminder := range reminders {
+			if reminder.IssueID == issue.ID {
+				ids = append(ids, reminder.IssueID)
+			}
+		}
+		return ids
+	}
+	for _, tt := range []struct {
+		status string
+		want   int
+	}{
+		{"in progress", 1},
+		{"resolved", 0},
+		{"closed", 0},
+	} {
+		issue.Status = tt.status
+		if err := r.UpdateIssue(ctx, issue, nil); err != nil {
+			t.Fatal(err)
+		}
+		if got := remindedIssues(); len(got) != tt.want {
+			t.Errorf("GetIssuesDueForReminder() with a %s issue returned it %d times, want %d", tt.status, len(got), tt.want)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/emzola/issuetracker/internal/repository"
+	"github.com/emzola/issuetracker/pkg/model"
+)
+
+// GetAllRoles returns every role with its permissions, ordered by name.
+func (r *Repository) GetAllRoles(ctx context.Context) ([]*model.Role, error) {
+	query := `
+		SELECT roles.name, role_permissions.action, role_permissions.resource, roles.created_on, roles.created_by, roles.modified_on, roles.modified_by, roles.version
+		FROM roles
+		LEFT JOIN role_permissions ON role_permissions.role = roles.name
+		ORDER BY roles.name, role_permissions.action, role_permissions.resource`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return nil, fmt.Errorf("%v: %w", err, ctx.Err())
+		default:
+			return nil, err
+		}
+	}
+	defer rows.Close()
+	roles := []*model.Role{}
+	for rows.Next() {
+		var role model.Role
+		var action, resource sql.NullString
+		err := rows.Scan(
+			&role.Name,
+			&action,
+			&resource,
+			&role.CreatedOn,
+			&role.CreatedBy,
+			&role.ModifiedOn,
+			&role.ModifiedBy,
+			&role.Version,
+		)
+		if err != nil {
+			return nil, err
+		}
+		// Each row holds one permission, so rows of the same role are merged.
+		if len(roles) == 0 || roles[len(roles)-1].Name != role.Name {
+			role.Permissions = map[string][]string{}
+			roles = append(roles, &role)
+		}
+		if action.Valid {
+			permissions := roles[len(roles)-1].Permissions
+			permissions[action.String] = append(permissions[action.String], resource.String)
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+func (r *Repository) GetRole(ctx context.Context, name string) (*model.Role, error) {
+	query := `
+		SELECT name, created_on, created_by, modified_on, modified_by, version
+		FROM roles
+		WHERE name = $1`
+	role := model.Role{Permissions: map[string][]string{}}
+	err := r.db.QueryRowContext(ctx, query, name).Scan(
+		&role.Name,
+		&role.CreatedOn,
+		&role.CreatedBy,
+		&role.ModifiedOn,
+		&role.ModifiedBy,
+		&role.Version,
+	)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return nil, fmt.Errorf("%v: %w", err, ctx.Err())
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, repository.ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+	query = `
+		SELECT action, resource
+		FROM role_permissions
+		WHERE role = $1
+		ORDER BY action, resource`
+	rows, err := r.db.QueryContext(ctx, query, name)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return nil, fmt.Errorf("%v: %w", err, ctx.Err())
+		default:
+			return nil, err
+		}
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var action, resource string
+		if err := rows.Scan(&action, &resource); err != nil {
+			return nil, err
+		}
+		role.Permissions[action] = append(role.Permissions[action], resource)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+// CreateRole creates a role along with its permissions.
+func (r *Repository) CreateRole(ctx context.Context, role *model.Role) error {
+	return r.WithTx(ctx, func(tx *Repository) error {
+		query := `
+			INSERT INTO roles (name, created_by, modified_by)
+			VALUES ($1, $2, $3)
+			RETURNING created_on, modified_on, version`
+		err := tx.db.QueryRowContext(ctx, query, role.Name, role.CreatedBy, role.ModifiedBy).Scan(&role.CreatedOn, &role.ModifiedOn, &role.Version)
+		if err != nil {
+			switch {
+			case err.Error() == "ERROR: canceling statement due to user request":
+				return fmt.Errorf("%v: %w", err, ctx.Err())
+			case err.Error() == `ERROR: duplicate key value violates unique constraint "roles_pkey" (SQLSTATE 23505)`:
+				return repository.ErrDuplicateKey
+			default:
+				return err
+			}
+		}
+		err = tx.insertRolePermissions(ctx, role)
+		if err != nil {
+			return err
+		}
+		return nil
+	})
+}
+
+// UpdateRole replaces the permissions of a role.
+func (r *Repository) UpdateRole(ctx context.Context, role *model.Role) error {
+	return r.WithTx(ctx, func(tx *Repository) error {
+		query := `
+			UPDATE roles
+			SET modified_on = CURRENT_TIMESTAMP(0), modified_by = $1, version = version + 1
+			WHERE name = $2 AND version = $3
+			RETURNING modified_on, version`
+		err := tx.db.QueryRowContext(ctx, query, role.ModifiedBy, role.Name, role.Version).Scan(&role.ModifiedOn, &role.Version)
+		if err != nil {
+			switch {
+			case err.Error() == "ERROR: canceling statement due to user request":
+				return fmt.Errorf("%v: %w", err, ctx.Err())
+			case errors.Is(err, sql.ErrNoRows):
+				return repository.ErrEditConflict
+			default:
+				return err
+			}
+		}
+		_, err = tx.db.ExecContext(ctx, `DELETE FROM role_permissions WHERE role = $1`, role.Name)
+		if err != nil {
+			switch {
+			case err.Error() == "ERROR: canceling statement due to user request":
+				return fmt.Errorf("%v: %w", err, ctx.Err())
+			default:
+				return err
+			}
+		}
+		err = tx.insertRolePermissions(ctx, role)
+		if err != nil {
+			return err
+		}
+		return nil
+	})
+}
+
+// insertRolePermissions inserts the permissions of a role.
+func (r *Repository) insertRolePermissions(ctx context.Context, role *model.Role) error {
+	var actions, resources []string
+	for action, actionResources := range role.Permissions {
+		for _, resource := range actionResources {
+			actions = append(actions, action)
+			resources = append(resources, resource)
+		}
+	}
+	query := `
+		INSERT INTO role_permissions (role, action, resource)
+		SELECT $1, permissions.action, permissions.resource
+		FROM unnest($2::text[], $3::text[]) AS permissions(action, resource)`
+	_, err := r.db.ExecContext(ctx, query, role.Name, actions, resources)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return fmt.Errorf("%v: %w", err, ctx.Err())
+		default:
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Repository) DeleteRole(ctx context.Context, name string) error {
+	query := `
+		DELETE FROM roles
+		WHERE name = $1`
+	result, err := r.db.ExecContext(ctx, query, name)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return fmt.Errorf("%v: %w", err, ctx.Err())
+		default:
+			return err
+		}
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// CountRoleUsers returns the number of users with the role.
+func (r *Repository) CountRoleUsers(ctx context.Context, name string) (int, error) {
+	query := `
+		SELECT count(*)
+		FROM users
+		WHERE role = $1`
+	var count int
+	err := r.db.QueryRowContext(ctx, query, name).Scan(&count)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return 0, fmt.Errorf("%v: %w", err, ctx.Err())
+		default:
+			return 0, err
+		}
+	}
+	return count, nil
+}
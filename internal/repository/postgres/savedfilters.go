@@ -0,0 +1,155 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/emzola/issuetracker/internal/repository"
+	"github.com/emzola/issuetracker/pkg/model"
+)
+
+func (r *Repository) CreateSavedFilter(ctx context.Context, filter *model.SavedFilter) error {
+	query := `
+		INSERT INTO saved_filters (user_id, name, query_string)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_on, modified_on, version`
+	args := []interface{}{filter.UserID, filter.Name, filter.QueryString}
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(&filter.ID, &filter.CreatedOn, &filter.ModifiedOn, &filter.Version)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return fmt.Errorf("%v: %w", err, ctx.Err())
+		case err.Error() == `ERROR: duplicate key value violates unique constraint "saved_filters_user_name_key" (SQLSTATE 23505)`:
+			return repository.ErrDuplicateKey
+		default:
+			return err
+		}
+	}
+	return nil
+}
+
+// GetSavedFilter returns one of a user's saved filters. Saved filters of other users are
+// not found.
+func (r *Repository) GetSavedFilter(ctx context.Context, id, userID int64) (*model.SavedFilter, error) {
+	if id < 1 {
+		return nil, repository.ErrNotFound
+	}
+	query := `
+		SELECT id, user_id, name, query_string, created_on, modified_on, version
+		FROM saved_filters
+		WHERE id = $1 AND user_id = $2`
+	var filter model.SavedFilter
+	err := r.db.QueryRowContext(ctx, query, id, userID).Scan(
+		&filter.ID,
+		&filter.UserID,
+		&filter.Name,
+		&filter.QueryString,
+		&filter.CreatedOn,
+		&filter.ModifiedOn,
+		&filter.Version,
+	)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return nil, fmt.Errorf("%v: %w", err, ctx.Err())
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, repository.ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+	return &filter, nil
+}
+
+// GetAllSavedFilters returns a user's saved filters, ordered by name.
+func (r *Repository) GetAllSavedFilters(ctx context.Context, userID int64) ([]*model.SavedFilter, error) {
+	query := `
+		SELECT id, user_id, name, query_string, created_on, modified_on, version
+		FROM saved_filters
+		WHERE user_id = $1
+		ORDER BY name, id`
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return nil, fmt.Errorf("%v: %w", err, ctx.Err())
+		default:
+			return nil, err
+		}
+	}
+	defer rows.Close()
+	filters := []*model.SavedFilter{}
+	for rows.Next() {
+		var filter model.SavedFilter
+		err := rows.Scan(
+			&filter.ID,
+			&filter.UserID,
+			&filter.Name,
+			&filter.QueryString,
+			&filter.CreatedOn,
+			&filter.ModifiedOn,
+			&filter.Version,
+		)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, &filter)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return filters, nil
+}
+
+func (r *Repository) UpdateSavedFilter(ctx context.Context, filter *model.SavedFilter) error {
+	query := `
+		UPDATE saved_filters
+		SET name = $1, query_string = $2, modified_on = CURRENT_TIMESTAMP(0), version = version + 1
+		WHERE id = $3 AND user_id = $4 AND version = $5
+		RETURNING modified_on, version`
+	args := []interface{}{filter.Name, filter.QueryString, filter.ID, filter.UserID, filter.Version}
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(&filter.ModifiedOn, &filter.Version)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return fmt.Errorf("%v: %w", err, ctx.Err())
+		case err.Error() == `ERROR: duplicate key value violates unique constraint "saved_filters_user_name_key" (SQLSTATE 23505)`:
+			return repository.ErrDuplicateKey
+		case errors.Is(err, sql.ErrNoRows):
+			return repository.ErrEditConflict
+		default:
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteSavedFilter deletes one of a user's saved filters. Saved filters of other users
+// are not found.
+func (r *Repository) DeleteSavedFilter(ctx context.Context, id, userID int64) error {
+	if id < 1 {
+		return repository.ErrNotFound
+	}
+	query := `
+		DELETE FROM saved_filters
+		WHERE id = $1 AND user_id = $2`
+	result, err := r.db.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return fmt.Errorf("%v: %w", err, ctx.Err())
+		default:
+			return err
+		}
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
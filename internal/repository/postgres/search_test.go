@@ -0,0 +1,189 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/emzola/issuetracker/pkg/model"
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// newTestRepository connects to the migrated database in TEST_DSN, skipping the test
+// when it is not set.
+func newTestRepository(t *testing.T) *Repository {
+	t.Helper()
+	dsn := os.Getenv("TEST_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DSN not set")
+	}
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return New(db, "simple")
+}
+
+func TestGetAllUsersNameSearchIgnoresAccentsAndCase(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	user := &model.User{Name: "José Müller", Email: "jose.muller@example.com", Role: "member", CreatedBy: "test", ModifiedBy: "test"}
+	user.Password.Hash = []byte("hash")
+	if err := r.CreateUser(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { r.DeleteUser(ctx, user.ID, true, testUser) })
+	filters := model.Filters{Page: 1, PageSize: 20, Sort: "id", SortSafelist: []string{"id"}}
+	for _, name := range []string{"José", "jose", "JOSE", "muller", "Müller"} {
+		t.Run(name, func(t *testing.T) {
+			users, _, err := r.GetAllUsers(ctx, name, user.Email, "", "", filters)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(users) != 1 || users[0].ID != user.ID {
+				t.Errorf("GetAllUsers(%q) did not match %q", name, user.Name)
+			}
+		})
+	}
+}
+
+func TestGetAllUsersPartialSearch(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	var users []*model.User
+	for _, u := range []struct{ name, email string }{
+		{"Alice Smith", "alice.smith@example.com"},
+		{"Bob Partial", "bob@smith-partial.example.com"},
+	} {
+		user := &model.User{Name: u.name, Email: u.email, Role: "member", CreatedBy: "test", ModifiedBy: "test"}
+		user.Password.Hash = []byte("hash")
+		if err := r.CreateUser(ctx, user); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { r.DeleteUser(ctx, user.ID, true, testUser) })
+		users = append(users, user)
+	}
+	tests := []struct {
+		q    string
+		want []int64
+	}{
+		{"smith@example", []int64{users[0].ID}},
+		{"LICE.SMI", []int64{users[0].ID}},
+		{"partial", []int64{users[1].ID}},
+		{"smith-partial", []int64{users[1].ID}},
+		{"smith_partial", nil},
+		{"%partial", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.q, func(t *testing.T) {
+			filters := model.Filters{Page: 1, PageSize: 20, Sort: "id", SortSafelist: []string{"id"}}
+			got, _, err := r.GetAllUsers(ctx, "", "", "", tt.q, filters)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ids []int64
+			for _, user := range got {
+				ids = append(ids, user.ID)
+			}
+			if fmt.Sprint(ids) != fmt.Sprint(tt.want) {
+				t.Errorf("GetAllUsers(q=%q) = %v, want %v", tt.q, ids, tt.want)
+			}
+		})
+	}
+
+	// Both users' emails contain "smith", a page at a time.
+	filters := model.Filters{Page: 1, PageSize: 1, Sort: "id", SortSafelist: []string{"id"}}
+	var ids []int64
+	for {
+		got, metadata, err := r.GetAllUsers(ctx, "", "", "", "smith", filters)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) > 1 {
+			t.Fatalf("GetAllUsers(page %d) returned %d users, want at most 1", filters.Page, len(got))
+		}
+		for _, user := range got {
+			if user.ID == users[0].ID || user.ID == users[1].ID {
+				ids = append(ids, user.ID)
+			}
+		}
+		if filters.Page >= metadata.LastPage {
+			break
+		}
+		filters.Page++
+	}
+	if len(ids) != 2 || ids[0] == ids[1] {
+		t.Errorf("GetAllUsers(q=smith) paged through %v, want users %d and %d once each", ids, users[0].ID, users[1].ID)
+	}
+}
+
+func TestGetAllProjectsNameSearchIgnoresAccentsAndCase(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	project := &model.Project{Name: "Café Système", StartDate: time.Now(), TargetEndDate: time.Now().AddDate(0, 1, 0), NotificationChannels: model.NotificationChannels, CreatedBy: "test", ModifiedBy: "test"}
+	if err := r.CreateProject(ctx, project); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { r.DeleteProject(ctx, project.ID) })
+	filters := model.Filters{Page: 1, PageSize: 20, Sort: "id", SortSafelist: []string{"id"}}
+	for _, name := range []string{"Café", "cafe", "CAFE", "systeme", "Système"} {
+		t.Run(name, func(t *testing.T) {
+			projects, _, err := r.GetAllProjects(ctx, name, 0, time.Time{}, time.Time{}, time.Time{}, "", false, filters)
+			if err != nil {
+				t.Fatal(err)
+			}
+			found := false
+			for _, p := range projects {
+				found = found || p.ID == project.ID
+			}
+			if !found {
+				t.Errorf("GetAllProjects(%q) did not match %q", name, project.Name)
+			}
+		})
+	}
+}
+
+func TestGetAllIssuesSearchesAllTextFields(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	reporter := &model.User{Name: "Search Reporter", Email: "search.reporter@example.com", Role: "member", CreatedBy: "test", ModifiedBy: "test"}
+	reporter.Password.Hash = []byte("hash")
+	if err := r.CreateUser(ctx, reporter); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { r.DeleteUser(ctx, reporter.ID, true, testUser) })
+	project := &model.Project{Name: "Search Project", StartDate: time.Now(), TargetEndDate: time.Now().AddDate(0, 1, 0), NotificationChannels: model.NotificationChannels, CreatedBy: "test", ModifiedBy: "test"}
+	if err := r.CreateProject(ctx, project); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { r.DeleteProject(ctx, project.ID) })
+	issue := &model.Issue{Title: "Checkout fails", Description: "The payment gateway times out", ReporterID: reporter.ID, ProjectID: project.ID, Status: "open", Priority: "low", TargetResolutionDate: time.Now().AddDate(0, 0, 7), CreatedBy: "test", ModifiedBy: "test"}
+	if err := r.CreateIssue(ctx, issue); err != nil {
+		t.Fatal(err)
+	}
+	filters := model.Filters{Page: 1, PageSize: 20, Sort: "-rank", SortSafelist: []string{"-rank"}}
+	tests := []struct {
+		q    string
+		want int
+	}{
+		{"checkout", 1},
+		{"gateway", 1},
+		{"refund", 0},
+		{"", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.q, func(t *testing.T) {
+			issues, _, err := r.GetAllIssues(ctx, model.IssueFilters{ProjectID: project.ID, Query: tt.q}, reporter.ID, filters)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(issues) != tt.want {
+				t.Errorf("GetAllIssues(q=%q) returned %d issues, want %d", tt.q, len(issues), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/emzola/issuetracker/internal/repository"
+	"github.com/emzola/issuetracker/pkg/model"
+)
+
+// GetSettings reads the stored runtime settings into settings. Settings that were
+// never stored keep the values settings already holds.
+func (r *Repository) GetSettings(ctx context.Context, settings *model.Settings) error {
+	query := `
+		SELECT settings
+		FROM settings
+		WHERE id = 1`
+	var js []byte
+	err := r.db.QueryRowContext(ctx, query).Scan(&js)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return fmt.Errorf("%v: %w", err, ctx.Err())
+		case errors.Is(err, sql.ErrNoRows):
+			return repository.ErrNotFound
+		default:
+			return err
+		}
+	}
+	return json.Unmarshal(js, settings)
+}
+
+// SaveSettings stores the new values of the changed runtime settings and records the
+// changes in the settings audit log. Only settings that have been changed are stored, so
+// that the others keep following the command-line flags.
+func (r *Repository) SaveSettings(ctx context.Context, changes map[string]model.SettingChange, modifiedBy string) error {
+	values := make(map[string]any, len(changes))
+	for key, change := range changes {
+		values[key] = change.New
+	}
+	settingsJSON, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+	changesJSON, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+	return r.WithTx(ctx, func(tx *Repository) error {
+		query := `
+			INSERT INTO settings (id, settings, modified_by)
+			VALUES (1, $1, $2)
+			ON CONFLICT (id) DO UPDATE
+			SET settings = settings.settings || EXCLUDED.settings, modified_on = NOW(), modified_by = EXCLUDED.modified_by`
+		_, err := tx.db.ExecContext(ctx, query, string(settingsJSON), modifiedBy)
+		if err != nil {
+			switch {
+			case err.Error() == "ERROR: canceling statement due to user request":
+				return fmt.Errorf("%v: %w", err, ctx.Err())
+			default:
+				return err
+			}
+		}
+		query = `
+			INSERT INTO settings_audit (changes, changed_by)
+			VALUES ($1, $2)`
+		_, err = tx.db.ExecContext(ctx, query, string(changesJSON), modifiedBy)
+		if err != nil {
+			switch {
+			case err.Error() == "ERROR: canceling statement due to user request":
+				return fmt.Errorf("%v: %w", err, ctx.Err())
+			default:
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/emzola/issuetracker/internal/repository"
 	"github.com/emzola/issuetracker/pkg/model"
 )
 
@@ -72,3 +73,83 @@ func (r *Repository) DeleteAllTokensForUser(ctx context.Context, scope string, u
 	}
 	return nil
 }
+
+// DeleteToken deletes a single token of the given scope.
+func (r *Repository) DeleteToken(ctx context.Context, scope, tokenPlaintext string) error {
+	tokenHash := sha256.Sum256([]byte(tokenPlaintext))
+	query := `
+		DELETE FROM tokens
+		WHERE scope = $1 AND hash = $2`
+	result, err := r.db.ExecContext(ctx, query, scope, tokenHash[:])
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return fmt.Errorf("%v: %w", err, ctx.Err())
+		default:
+			return err
+		}
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// RevokeAuthenticationToken records the JWT ID of an authentication token as revoked
+// until the token expires. Revoking a token twice is not an error.
+func (r *Repository) RevokeAuthenticationToken(ctx context.Context, jti string, userID int64, expiry time.Time) error {
+	query := `
+		INSERT INTO revoked_tokens (jti, user_id, expiry)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (jti) DO NOTHING`
+	_, err := r.db.ExecContext(ctx, query, jti, userID, expiry)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return fmt.Errorf("%v: %w", err, ctx.Err())
+		default:
+			return err
+		}
+	}
+	return nil
+}
+
+// IsAuthenticationTokenRevoked reports whether the authentication token with the JWT
+// ID has been revoked.
+func (r *Repository) IsAuthenticationTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	query := `
+		SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = $1)`
+	var revoked bool
+	err := r.db.QueryRowContext(ctx, query, jti).Scan(&revoked)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return false, fmt.Errorf("%v: %w", err, ctx.Err())
+		default:
+			return false, err
+		}
+	}
+	return revoked, nil
+}
+
+// DeleteExpiredRevokedTokens deletes the revoked authentication tokens that have
+// expired, since they would be rejected anyway.
+func (r *Repository) DeleteExpiredRevokedTokens(ctx context.Context) error {
+	query := `
+		DELETE FROM revoked_tokens
+		WHERE expiry < NOW()`
+	_, err := r.db.ExecContext(ctx, query)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return fmt.Errorf("%v: %w", err, ctx.Err())
+		default:
+			return err
+		}
+	}
+	return nil
+}
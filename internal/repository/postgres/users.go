@@ -6,6 +6,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/emzola/issuetracker/internal/repository"
@@ -14,10 +15,10 @@ import (
 
 func (r *Repository) CreateUser(ctx context.Context, user *model.User) error {
 	query := `
-		INSERT INTO users (name, email, password_hash, activated, role, created_by, modified_by)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO users (name, email, password_hash, activated, role, locale, created_by, modified_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 		RETURNING id, created_on, modified_on, version`
-	args := []interface{}{user.Name, user.Email, user.Password.Hash, user.Activated, user.Role, user.CreatedBy, user.ModifiedBy}
+	args := []interface{}{user.Name, user.Email, user.Password.Hash, user.Activated, user.Role, user.Locale, user.CreatedBy, user.ModifiedBy}
 	err := r.db.QueryRowContext(ctx, query, args...).Scan(&user.ID, &user.CreatedOn, &user.ModifiedOn, &user.Version)
 	if err != nil {
 		switch {
@@ -32,9 +33,54 @@ func (r *Repository) CreateUser(ctx context.Context, user *model.User) error {
 	return nil
 }
 
+// CreateUsers creates several users in a single transaction, each with an activation
+// token valid for ttl, and returns the tokens in the order of users. If atomic is set,
+// a user that can't be created rolls back every user with a repository.BatchError.
+// Otherwise each user is created under a savepoint, so that a failure only undoes that
+// user: its error is returned at its position in the errors and its token is nil.
+func (r *Repository) CreateUsers(ctx context.Context, users []*model.User, ttl time.Duration, atomic bool) ([]*model.Token, []error, error) {
+	tokens := make([]*model.Token, len(users))
+	errs := make([]error, len(users))
+	err := r.WithTx(ctx, func(tx *Repository) error {
+		for i, user := range users {
+			if !atomic {
+				_, err := tx.db.ExecContext(ctx, `SAVEPOINT create_user`)
+				if err != nil {
+					return err
+				}
+			}
+			err := tx.CreateUser(ctx, user)
+			if err == nil {
+				tokens[i], err = tx.CreateToken(ctx, user.ID, ttl, model.ScopeActivation)
+			}
+			switch {
+			case err != nil && atomic:
+				return &repository.BatchError{Index: i, Err: err}
+			case err != nil:
+				errs[i] = err
+				tokens[i] = nil
+				_, err = tx.db.ExecContext(ctx, `ROLLBACK TO SAVEPOINT create_user`)
+				if err != nil {
+					return err
+				}
+			case !atomic:
+				_, err = tx.db.ExecContext(ctx, `RELEASE SAVEPOINT create_user`)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return tokens, errs, nil
+}
+
 func (r *Repository) GetUserByEmail(ctx context.Context, email string) (*model.User, error) {
 	query := `
-		SELECT id, name, email, password_hash, activated, role, created_on, created_by, modified_on, modified_by, version
+		SELECT id, name, email, password_hash, activated, role, created_on, created_by, modified_on, modified_by, version, token_epoch, digest_opt_in, locale, pending_email
 		FROM users
 		WHERE email = $1`
 	var user model.User
@@ -50,6 +96,10 @@ func (r *Repository) GetUserByEmail(ctx context.Context, email string) (*model.U
 		&user.ModifiedOn,
 		&user.ModifiedBy,
 		&user.Version,
+		&user.TokenEpoch,
+		&user.DigestOptIn,
+		&user.Locale,
+		&user.PendingEmail,
 	)
 	if err != nil {
 		switch {
@@ -66,7 +116,7 @@ func (r *Repository) GetUserByEmail(ctx context.Context, email string) (*model.U
 
 func (r *Repository) GetUserByID(ctx context.Context, id int64) (*model.User, error) {
 	query := `
-		SELECT id, name, email, password_hash, activated, role, created_on, created_by, modified_on, modified_by, version
+		SELECT id, name, email, password_hash, activated, role, created_on, created_by, modified_on, modified_by, version, token_epoch, digest_opt_in, locale, pending_email
 		FROM users
 		WHERE id = $1`
 	var user model.User
@@ -82,6 +132,10 @@ func (r *Repository) GetUserByID(ctx context.Context, id int64) (*model.User, er
 		&user.ModifiedOn,
 		&user.ModifiedBy,
 		&user.Version,
+		&user.TokenEpoch,
+		&user.DigestOptIn,
+		&user.Locale,
+		&user.PendingEmail,
 	)
 	if err != nil {
 		switch {
@@ -96,16 +150,19 @@ func (r *Repository) GetUserByID(ctx context.Context, id int64) (*model.User, er
 	return &user, nil
 }
 
-func (r *Repository) GetAllUsers(ctx context.Context, name, email, role string, filters model.Filters) ([]*model.User, model.Metadata, error) {
+// GetAllUsers returns the users matching the filters. name is a full-text search of
+// names and email an exact match, while q matches any part of a name or email.
+func (r *Repository) GetAllUsers(ctx context.Context, name, email, role, q string, filters model.Filters) ([]*model.User, model.Metadata, error) {
 	query := fmt.Sprintf(`
-		SELECT count(*) OVER(), id, name, email, password_hash, activated, role, created_on, created_by, modified_on, modified_by, version
+		SELECT count(*) OVER(), id, name, email, password_hash, activated, role, created_on, created_by, modified_on, modified_by, version, digest_opt_in, locale
 		FROM users
-		WHERE (to_tsvector('simple', name) @@ plainto_tsquery('simple', $1) OR $1 = '')
+		WHERE (to_tsvector($6::regconfig, immutable_unaccent(name)) @@ plainto_tsquery($6::regconfig, immutable_unaccent($1)) OR $1 = '')
 		AND (LOWER(email) = LOWER($2) OR $2 = '')
 		AND (LOWER(role) = LOWER($3) OR $3 = '')
-		ORDER BY %s %s, id ASC 
-		LIMIT $4 OFFSET $5`, filters.SortColumn(), filters.SortDirection())
-	args := []interface{}{name, email, role, filters.Limit(), filters.Offset()}
+		AND (immutable_unaccent(name) ILIKE immutable_unaccent($7) OR email ILIKE $7 OR $8 = '')
+		ORDER BY %s, id ASC 
+		LIMIT $4 OFFSET $5`, filters.OrderBy())
+	args := []interface{}{name, email, role, filters.Limit(), filters.Offset(), r.textSearchConfig, containsPattern(q), q}
 	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		switch {
@@ -133,6 +190,8 @@ func (r *Repository) GetAllUsers(ctx context.Context, name, email, role string,
 			&user.ModifiedOn,
 			&user.ModifiedBy,
 			&user.Version,
+			&user.DigestOptIn,
+			&user.Locale,
 		)
 		if err != nil {
 			return nil, model.Metadata{}, err
@@ -149,11 +208,12 @@ func (r *Repository) GetAllUsers(ctx context.Context, name, email, role string,
 func (r *Repository) UpdateUser(ctx context.Context, user *model.User) error {
 	query := `
 		UPDATE users
-		SET name = $1, email = $2, password_hash = $3, activated = $4, role = $5, version = version + 1
-		WHERE id = $6 AND version = $7
-		RETURNING version`
-	args := []interface{}{user.Name, user.Email, user.Password.Hash, user.Activated, user.Role, user.ID, user.Version}
-	err := r.db.QueryRowContext(ctx, query, args...).Scan(&user.Version)
+		SET name = $1, email = $2, password_hash = $3, activated = $4, role = $5, digest_opt_in = $9, locale = $10, pending_email = $11, modified_by = $6, modified_on = CURRENT_TIMESTAMP(0), version = version + 1,
+		token_epoch = CASE WHEN role = $5 AND password_hash = $3 THEN token_epoch ELSE token_epoch + 1 END
+		WHERE id = $7 AND version = $8
+		RETURNING modified_on, version, token_epoch`
+	args := []interface{}{user.Name, user.Email, user.Password.Hash, user.Activated, user.Role, user.ModifiedBy, user.ID, user.Version, user.DigestOptIn, user.Locale, user.PendingEmail}
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(&user.ModifiedOn, &user.Version, &user.TokenEpoch)
 	if err != nil {
 		switch {
 		case err.Error() == "ERROR: canceling statement due to user request":
@@ -172,7 +232,7 @@ func (r *Repository) UpdateUser(ctx context.Context, user *model.User) error {
 func (r *Repository) GetUserForToken(ctx context.Context, tokenScope, tokenPlaintext string) (*model.User, error) {
 	tokenHash := sha256.Sum256([]byte(tokenPlaintext))
 	query := `
-		SELECT users.id, users.name, users.email, users.password_hash, users.activated, users.role, users.created_on, users.created_by, users.modified_on, users.modified_by, users.version
+		SELECT users.id, users.name, users.email, users.password_hash, users.activated, users.role, users.created_on, users.created_by, users.modified_on, users.modified_by, users.version, users.token_epoch, users.digest_opt_in, users.locale, users.pending_email
 		FROM users
 		INNER JOIN tokens
 		ON users.id = tokens.user_id
@@ -193,6 +253,10 @@ func (r *Repository) GetUserForToken(ctx context.Context, tokenScope, tokenPlain
 		&user.ModifiedOn,
 		&user.ModifiedBy,
 		&user.Version,
+		&user.TokenEpoch,
+		&user.DigestOptIn,
+		&user.Locale,
+		&user.PendingEmail,
 	)
 	if err != nil {
 		switch {
@@ -207,30 +271,124 @@ func (r *Repository) GetUserForToken(ctx context.Context, tokenScope, tokenPlain
 	return &user, nil
 }
 
-func (r *Repository) DeleteUser(ctx context.Context, id int64) error {
+// DeleteUser deletes a user, after unassigning the issues and projects assigned to them
+// and recording the unassignments as made by deletedBy. Unless force is set, users with
+// open issues assigned to them aren't deleted, and a *repository.OpenIssuesError is
+// returned for them. Users who reported issues can't be deleted, and
+// repository.ErrReferenced is returned for them.
+func (r *Repository) DeleteUser(ctx context.Context, id int64, force bool, deletedBy *model.User) error {
 	if id < 1 {
 		return repository.ErrNotFound
 	}
-	query := `
-		DELETE FROM users
-		WHERE id = $1`
-	result, err := r.db.ExecContext(ctx, query, id)
+	return r.WithTx(ctx, func(tx *Repository) error {
+		// Locking the user makes issues being assigned to them wait for the deletion, so
+		// that their open issues can't change between counting and unassigning them.
+		query := `
+			SELECT id
+			FROM users
+			WHERE id = $1
+			FOR UPDATE`
+		err := tx.db.QueryRowContext(ctx, query, id).Scan(&id)
+		if err != nil {
+			switch {
+			case err.Error() == "ERROR: canceling statement due to user request":
+				return fmt.Errorf("%v: %w", err, ctx.Err())
+			case errors.Is(err, sql.ErrNoRows):
+				return repository.ErrNotFound
+			default:
+				return err
+			}
+		}
+		if !force {
+			count, err := tx.CountOpenIssuesForUser(ctx, id)
+			if err != nil {
+				return err
+			}
+			if count > 0 {
+				return &repository.OpenIssuesError{Count: count}
+			}
+		}
+		queries := []struct {
+			query string
+			args  []interface{}
+		}{
+			{`
+			WITH unassigned AS (
+				UPDATE issues
+				SET assigned_to = NULL, modified_on = CURRENT_TIMESTAMP(0), modified_by = $2, version = version + 1
+				WHERE assigned_to = $1
+				RETURNING id, version
+			)
+			INSERT INTO issue_activity (issue_id, version, field, old_value, new_value, changed_by, changed_by_id)
+			SELECT id, version, 'assigned_to', $1::bigint::text, '', $2, NULLIF($3::bigint, 0)
+			FROM unassigned`, []interface{}{id, deletedBy.Name, deletedBy.ID}},
+			{`
+			WITH unassigned AS (
+				UPDATE projects
+				SET assigned_to = NULL, modified_on = CURRENT_TIMESTAMP(0), modified_by = $2, version = version + 1
+				WHERE assigned_to = $1
+				RETURNING id
+			)
+			INSERT INTO project_activity (project_id, field, old_value, new_value, changed_by)
+			SELECT id, 'assigned_to', $1::bigint::text, '', $2
+			FROM unassigned`, []interface{}{id, deletedBy.Name}},
+		}
+		for _, q := range queries {
+			_, err := tx.db.ExecContext(ctx, q.query, q.args...)
+			if err != nil {
+				switch {
+				case err.Error() == "ERROR: canceling statement due to user request":
+					return fmt.Errorf("%v: %w", err, ctx.Err())
+				default:
+					return err
+				}
+			}
+		}
+		query = `
+			DELETE FROM users
+			WHERE id = $1`
+		result, err := tx.db.ExecContext(ctx, query, id)
+		if err != nil {
+			switch {
+			case err.Error() == "ERROR: canceling statement due to user request":
+				return fmt.Errorf("%v: %w", err, ctx.Err())
+			case err.Error() == `ERROR: update or delete on table "users" violates foreign key constraint "issues_reporter_id_fkey" on table "issues" (SQLSTATE 23503)`:
+				return repository.ErrReferenced
+			default:
+				return err
+			}
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rowsAffected == 0 {
+			return repository.ErrNotFound
+		}
+		return nil
+	})
+}
+
+// CountOpenIssuesForUser returns the number of issues assigned to the user that aren't
+// closed or deleted.
+func (r *Repository) CountOpenIssuesForUser(ctx context.Context, userID int64) (int, error) {
+	query := fmt.Sprintf(`
+		SELECT count(*)
+		FROM issues
+		WHERE assigned_to = $1
+		AND NOT %s
+		AND deleted_on IS NULL`, issueClosed)
+	var count int
+	err := r.db.QueryRowContext(ctx, query, userID).Scan(&count)
 	if err != nil {
 		switch {
 		case err.Error() == "ERROR: canceling statement due to user request":
-			return fmt.Errorf("%v: %w", err, ctx.Err())
+			return 0, fmt.Errorf("%v: %w", err, ctx.Err())
 		default:
-			return err
+			return 0, err
 		}
 	}
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return err
-	}
-	if rowsAffected == 0 {
-		return repository.ErrNotFound
-	}
-	return nil
+	return count, nil
 }
 
 func (r *Repository) AssignUserToProject(ctx context.Context, userID, projectID int64) error {
@@ -252,3 +410,44 @@ func (r *Repository) AssignUserToProject(ctx context.Context, userID, projectID
 	}
 	return nil
 }
+
+// GetDigestRecipients returns the activated users who opted in to the daily digest of
+// their assigned issues.
+func (r *Repository) GetDigestRecipients(ctx context.Context) ([]*model.User, error) {
+	query := `
+		SELECT id, name, email, role, locale
+		FROM users
+		WHERE activated = true
+		AND digest_opt_in = true
+		ORDER BY id`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return nil, fmt.Errorf("%v: %w", err, ctx.Err())
+		default:
+			return nil, err
+		}
+	}
+	defer rows.Close()
+	users := []*model.User{}
+	for rows.Next() {
+		user := model.User{Activated: true, DigestOptIn: true}
+		err := rows.Scan(&user.ID, &user.Name, &user.Email, &user.Role, &user.Locale)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, &user)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// containsPattern returns a LIKE pattern matching strings that contain s, with the
+// wildcards in s escaped so that they match literally.
+func containsPattern(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+	return "%" + s + "%"
+}
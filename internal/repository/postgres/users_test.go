@@ -0,0 +1,57 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/emzola/issuetracker/internal/repository"
+	"github.com/emzola/issuetracker/pkg/model"
+)
+
+func TestDeleteUserUnassignsIssues(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	issue := newTestIssue(t, r, "DeleteUser")
+	assignee := &model.User{Name: "DeleteUser Assignee", Email: "deleteuser.assignee@example.com", Role: "member", CreatedBy: "test", ModifiedBy: "test"}
+	assignee.Password.Hash = []byte("hash")
+	if err := r.CreateUser(ctx, assignee); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { r.DeleteUser(ctx, assignee.ID, true, testUser) })
+	issue.AssignedTo = &assignee.ID
+	if err := r.UpdateIssue(ctx, issue, nil); err != nil {
+		t.Fatal(err)
+	}
+	count, err := r.CountOpenIssuesForUser(ctx, assignee.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("CountOpenIssuesForUser() = %d, want 1", count)
+	}
+	var openIssuesErr *repository.OpenIssuesError
+	if err := r.DeleteUser(ctx, assignee.ID, false, testUser); !errors.As(err, &openIssuesErr) || openIssuesErr.Count != 1 {
+		t.Fatalf("DeleteUser() without force error = %v, want an OpenIssuesError for 1 issue", err)
+	}
+	if err := r.DeleteUser(ctx, assignee.ID, true, testUser); err != nil {
+		t.Fatalf("DeleteUser() error = %v", err)
+	}
+	stored, err := r.GetIssue(ctx, issue.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.AssignedTo != nil {
+		t.Errorf("stored assigned_to = %v, want NULL", *stored.AssignedTo)
+	}
+	activity, err := r.GetIssueActivitySince(ctx, issue.ID, issue.Version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(activity) != 1 || activity[0].Field != "assigned_to" || activity[0].NewValue != "" || activity[0].Version != stored.Version {
+		t.Errorf("DeleteUser() recorded activity %+v, want the unassignment at version %d", activity, stored.Version)
+	}
+	if err := r.DeleteUser(ctx, issue.ReporterID, true, testUser); !errors.Is(err, repository.ErrReferenced) {
+		t.Errorf("DeleteUser() of the reporter error = %v, want ErrReferenced", err)
+	}
+}
@@ -0,0 +1,90 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/emzola/issuetracker/internal/repository"
+	"github.com/emzola/issuetracker/pkg/model"
+)
+
+// SubscribeToIssue adds the user to the issue's watchers. Subscribing to an issue the
+// user already watches is not an error.
+func (r *Repository) SubscribeToIssue(ctx context.Context, issueID, userID int64) error {
+	query := `
+		INSERT INTO watchers (issue_id, user_id)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING`
+	_, err := r.db.ExecContext(ctx, query, issueID, userID)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return fmt.Errorf("%v: %w", err, ctx.Err())
+		default:
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Repository) UnsubscribeFromIssue(ctx context.Context, issueID, userID int64) error {
+	query := `
+		DELETE FROM watchers
+		WHERE issue_id = $1 AND user_id = $2`
+	result, err := r.db.ExecContext(ctx, query, issueID, userID)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return fmt.Errorf("%v: %w", err, ctx.Err())
+		default:
+			return err
+		}
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+func (r *Repository) GetIssueWatchers(ctx context.Context, issueID int64) ([]*model.Watcher, error) {
+	query := `
+		SELECT watchers.issue_id, users.id, users.name, users.email, users.locale, watchers.created_on
+		FROM watchers
+		INNER JOIN users ON users.id = watchers.user_id
+		WHERE watchers.issue_id = $1
+		ORDER BY watchers.created_on, users.id`
+	rows, err := r.db.QueryContext(ctx, query, issueID)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return nil, fmt.Errorf("%v: %w", err, ctx.Err())
+		default:
+			return nil, err
+		}
+	}
+	defer rows.Close()
+	watchers := []*model.Watcher{}
+	for rows.Next() {
+		var watcher model.Watcher
+		err := rows.Scan(
+			&watcher.IssueID,
+			&watcher.UserID,
+			&watcher.Name,
+			&watcher.Email,
+			&watcher.Locale,
+			&watcher.CreatedOn,
+		)
+		if err != nil {
+			return nil, err
+		}
+		watchers = append(watchers, &watcher)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return watchers, nil
+}
@@ -0,0 +1,155 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/emzola/issuetracker/internal/repository"
+	"github.com/emzola/issuetracker/pkg/model"
+)
+
+func (r *Repository) CreateWebhook(ctx context.Context, webhook *model.Webhook) error {
+	query := `
+		INSERT INTO webhooks (project_id, url, secret, events, active, created_by, modified_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_on, modified_on, version`
+	args := []interface{}{webhook.ProjectID, webhook.URL, webhook.Secret, webhook.Events, webhook.Active, webhook.CreatedBy, webhook.ModifiedBy}
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(&webhook.ID, &webhook.CreatedOn, &webhook.ModifiedOn, &webhook.Version)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return fmt.Errorf("%v: %w", err, ctx.Err())
+		default:
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Repository) GetWebhook(ctx context.Context, id int64) (*model.Webhook, error) {
+	if id < 1 {
+		return nil, repository.ErrNotFound
+	}
+	query := `
+		SELECT id, project_id, url, secret, events, active, created_on, created_by, modified_on, modified_by, version
+		FROM webhooks
+		WHERE id = $1`
+	var webhook model.Webhook
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&webhook.ID,
+		&webhook.ProjectID,
+		&webhook.URL,
+		&webhook.Secret,
+		textArray(&webhook.Events),
+		&webhook.Active,
+		&webhook.CreatedOn,
+		&webhook.CreatedBy,
+		&webhook.ModifiedOn,
+		&webhook.ModifiedBy,
+		&webhook.Version,
+	)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return nil, fmt.Errorf("%v: %w", err, ctx.Err())
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, repository.ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+	return &webhook, nil
+}
+
+// GetProjectWebhooks returns a project's webhooks, oldest first.
+func (r *Repository) GetProjectWebhooks(ctx context.Context, projectID int64) ([]*model.Webhook, error) {
+	query := `
+		SELECT id, project_id, url, secret, events, active, created_on, created_by, modified_on, modified_by, version
+		FROM webhooks
+		WHERE project_id = $1
+		ORDER BY id ASC`
+	rows, err := r.db.QueryContext(ctx, query, projectID)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return nil, fmt.Errorf("%v: %w", err, ctx.Err())
+		default:
+			return nil, err
+		}
+	}
+	defer rows.Close()
+	webhooks := []*model.Webhook{}
+	for rows.Next() {
+		var webhook model.Webhook
+		err := rows.Scan(
+			&webhook.ID,
+			&webhook.ProjectID,
+			&webhook.URL,
+			&webhook.Secret,
+			textArray(&webhook.Events),
+			&webhook.Active,
+			&webhook.CreatedOn,
+			&webhook.CreatedBy,
+			&webhook.ModifiedOn,
+			&webhook.ModifiedBy,
+			&webhook.Version,
+		)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, &webhook)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+func (r *Repository) UpdateWebhook(ctx context.Context, webhook *model.Webhook) error {
+	query := `
+		UPDATE webhooks
+		SET url = $1, secret = $2, events = $3, active = $4, modified_on = CURRENT_TIMESTAMP(0), modified_by = $5, version = version + 1
+		WHERE id = $6 AND version = $7
+		RETURNING modified_on, version`
+	args := []interface{}{webhook.URL, webhook.Secret, webhook.Events, webhook.Active, webhook.ModifiedBy, webhook.ID, webhook.Version}
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(&webhook.ModifiedOn, &webhook.Version)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return fmt.Errorf("%v: %w", err, ctx.Err())
+		case errors.Is(err, sql.ErrNoRows):
+			return repository.ErrEditConflict
+		default:
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Repository) DeleteWebhook(ctx context.Context, id int64) error {
+	if id < 1 {
+		return repository.ErrNotFound
+	}
+	query := `
+		DELETE FROM webhooks
+		WHERE id = $1`
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return fmt.Errorf("%v: %w", err, ctx.Err())
+		default:
+			return err
+		}
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/emzola/issuetracker/pkg/model"
+)
+
+// workflowStates is a subquery with the columns of project_workflow_states that holds
+// the workflow states of every project, using the default workflow for projects that
+// haven't defined their own. Queries use it to tell open issues from closed ones by the
+// category of their status, rather than by status names.
+var workflowStates = func() string {
+	defaults := make([]string, len(model.DefaultWorkflow(0).States))
+	for i, state := range model.DefaultWorkflow(0).States {
+		defaults[i] = fmt.Sprintf("(%d, %s, %s)", i+1, quoteLiteral(state.Name), quoteLiteral(state.Category))
+	}
+	return fmt.Sprintf(`(
+		SELECT project_id, position, name, category FROM project_workflow_states
+		UNION ALL
+		SELECT projects.id, defaults.position, defaults.name, defaults.category
+		FROM projects, (VALUES %s) AS defaults(position, name, category)
+		WHERE NOT EXISTS (SELECT 1 FROM project_workflow_states WHERE project_workflow_states.project_id = projects.id))`, strings.Join(defaults, ", "))
+}()
+
+var (
+	// issueOpen is a condition on the issues table that holds for issues in an open
+	// state of their project's workflow.
+	issueOpen = issueInCategory("open")
+
+	// issueClosed is a condition on the issues table that holds for issues in a closed
+	// state of their project's workflow.
+	issueClosed = issueInCategory("closed")
+
+	// issueResolved is a condition on the issues table that holds for issues waiting to
+	// be closed: those in the last open state of their project's workflow, unless it is
+	// also the state issues start in.
+	issueResolved = fmt.Sprintf(`COALESCE(issues.status = (
+		SELECT states.name FROM %[1]s AS states
+		WHERE states.project_id = issues.project_id AND states.category = 'open'
+		AND states.position > (SELECT min(earliest.position) FROM %[1]s AS earliest WHERE earliest.project_id = issues.project_id)
+		ORDER BY states.position DESC LIMIT 1), false)`, workflowStates)
+
+	// issueUnresolved is a condition on the issues table that holds for open issues that
+	// aren't waiting to be closed.
+	issueUnresolved = fmt.Sprintf(`(%s AND NOT %s)`, issueOpen, issueResolved)
+
+	// issueFirstClosedStatus is an expression on the issues table for the first closed
+	// state of the issue's project workflow.
+	issueFirstClosedStatus = fmt.Sprintf(`(
+		SELECT states.name FROM %s AS states
+		WHERE states.project_id = issues.project_id AND states.category = 'closed'
+		ORDER BY states.position ASC LIMIT 1)`, workflowStates)
+)
+
+// issueInCategory returns a condition on the issues table that holds for issues whose
+// status is in the given category of their project's workflow.
+func issueInCategory(category string) string {
+	return fmt.Sprintf(`EXISTS (
+		SELECT 1 FROM %s AS states
+		WHERE states.project_id = issues.project_id AND states.name = issues.status AND states.category = %s)`, workflowStates, quoteLiteral(category))
+}
+
+// quoteLiteral quotes s as an SQL string literal.
+func quoteLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// GetProjectWorkflow returns the project's workflow states in order. It returns no
+// states if the project hasn't defined a workflow.
+func (r *Repository) GetProjectWorkflow(ctx context.Context, projectID int64) ([]model.WorkflowState, error) {
+	query := `
+		SELECT name, category
+		FROM project_workflow_states
+		WHERE project_id = $1
+		ORDER BY position ASC`
+	rows, err := r.db.QueryContext(ctx, query, projectID)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return nil, fmt.Errorf("%v: %w", err, ctx.Err())
+		default:
+			return nil, err
+		}
+	}
+	defer rows.Close()
+	states := []model.WorkflowState{}
+	for rows.Next() {
+		var state model.WorkflowState
+		err := rows.Scan(
+			&state.Name,
+			&state.Category,
+		)
+		if err != nil {
+			return nil, err
+		}
+		states = append(states, state)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return states, nil
+}
+
+// SetProjectWorkflow replaces the project's workflow states.
+func (r *Repository) SetProjectWorkflow(ctx context.Context, projectID int64, states []model.WorkflowState) error {
+	return r.WithTx(ctx, func(tx *Repository) error {
+		_, err := tx.db.ExecContext(ctx, `DELETE FROM project_workflow_states WHERE project_id = $1`, projectID)
+		if err != nil {
+			switch {
+			case err.Error() == "ERROR: canceling statement due to user request":
+				return fmt.Errorf("%v: %w", err, ctx.Err())
+			default:
+				return err
+			}
+		}
+		names := make([]string, len(states))
+		categories := make([]string, len(states))
+		for i, state := range states {
+			names[i] = state.Name
+			categories[i] = state.Category
+		}
+		query := `
+			INSERT INTO project_workflow_states (project_id, position, name, category)
+			SELECT $1, states.position, states.name, states.category
+			FROM unnest($2::text[], $3::text[]) WITH ORDINALITY AS states(name, category, position)`
+		_, err = tx.db.ExecContext(ctx, query, projectID, names, categories)
+		if err != nil {
+			switch {
+			case err.Error() == "ERROR: canceling statement due to user request":
+				return fmt.Errorf("%v: %w", err, ctx.Err())
+			default:
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetProjectIssueStatuses returns the distinct statuses of the project's issues.
+func (r *Repository) GetProjectIssueStatuses(ctx context.Context, projectID int64) ([]string, error) {
+	query := `
+		SELECT DISTINCT status
+		FROM issues
+		WHERE project_id = $1
+		ORDER BY status`
+	rows, err := r.db.QueryContext(ctx, query, projectID)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return nil, fmt.Errorf("%v: %w", err, ctx.Err())
+		default:
+			return nil, err
+		}
+	}
+	defer rows.Close()
+	statuses := []string{}
+	for rows.Next() {
+		var status string
+		if err := rows.Scan(&status); err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, status)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return statuses, nil
+}
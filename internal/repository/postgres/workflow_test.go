@@ -0,0 +1,52 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/emzola/issuetracker/pkg/model"
+)
+
+func TestOpenIssuesFollowWorkflowCategories(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	issue := newTestIssue(t, r, "Workflow")
+	issue.AssignedTo = &issue.ReporterID
+	states := []model.WorkflowState{
+		{Name: "todo", Category: "open"},
+		{Name: "review", Category: "open"},
+		{Name: "done", Category: "closed"},
+		{Name: "wontfix", Category: "closed"},
+	}
+	if err := r.SetProjectWorkflow(ctx, issue.ProjectID, states); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		status       string
+		wantCount    int
+		wantAssigned int
+	}{
+		{"todo", 1, 1},
+		{"review", 1, 0},
+		{"wontfix", 0, 0},
+	} {
+		issue.Status = tt.status
+		if err := r.UpdateIssue(ctx, issue, nil); err != nil {
+			t.Fatal(err)
+		}
+		count, err := r.CountOpenIssuesForUser(ctx, issue.ReporterID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != tt.wantCount {
+			t.Errorf("CountOpenIssuesForUser() with a %s issue = %d, want %d", tt.status, count, tt.wantCount)
+		}
+		issues, err := r.GetOpenIssuesAssignedTo(ctx, issue.ReporterID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(issues) != tt.wantAssigned {
+			t.Errorf("GetOpenIssuesAssignedTo() with a %s issue returned %d issues, want %d", tt.status, len(issues), tt.wantAssigned)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/emzola/issuetracker/internal/repository"
+	"github.com/emzola/issuetracker/pkg/model"
+)
+
+// CreateWorklog logs time against an issue and adds it to the issue's logged hours,
+// returning the new total. The issue's version is left as it is, since logged hours
+// aren't changed by issue updates.
+func (r *Repository) CreateWorklog(ctx context.Context, worklog *model.Worklog) (float64, error) {
+	var loggedHours float64
+	err := r.WithTx(ctx, func(tx *Repository) error {
+		query := `
+			INSERT INTO worklogs (issue_id, hours, note, logged_by)
+			VALUES ($1, $2, $3, $4)
+			RETURNING id, logged_on`
+		err := tx.db.QueryRowContext(ctx, query, worklog.IssueID, worklog.Hours, worklog.Note, worklog.LoggedBy).Scan(&worklog.ID, &worklog.LoggedOn)
+		if err != nil {
+			switch {
+			case err.Error() == "ERROR: canceling statement due to user request":
+				return fmt.Errorf("%v: %w", err, ctx.Err())
+			default:
+				return err
+			}
+		}
+		query = `
+			UPDATE issues
+			SET logged_hours = logged_hours + $2
+			WHERE id = $1 AND deleted_on IS NULL
+			RETURNING logged_hours`
+		err = tx.db.QueryRowContext(ctx, query, worklog.IssueID, worklog.Hours).Scan(&loggedHours)
+		if err != nil {
+			switch {
+			case err.Error() == "ERROR: canceling statement due to user request":
+				return fmt.Errorf("%v: %w", err, ctx.Err())
+			case errors.Is(err, sql.ErrNoRows):
+				return repository.ErrNotFound
+			default:
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return loggedHours, nil
+}
+
+// GetIssueWorklogs returns the time logged against an issue, oldest first.
+func (r *Repository) GetIssueWorklogs(ctx context.Context, issueID int64) ([]*model.Worklog, error) {
+	query := `
+		SELECT id, issue_id, hours, note, logged_by, logged_on
+		FROM worklogs
+		WHERE issue_id = $1
+		ORDER BY logged_on, id`
+	rows, err := r.db.QueryContext(ctx, query, issueID)
+	if err != nil {
+		switch {
+		case err.Error() == "ERROR: canceling statement due to user request":
+			return nil, fmt.Errorf("%v: %w", err, ctx.Err())
+		default:
+			return nil, err
+		}
+	}
+	defer rows.Close()
+	worklogs := []*model.Worklog{}
+	for rows.Next() {
+		var worklog model.Worklog
+		err := rows.Scan(
+			&worklog.ID,
+			&worklog.IssueID,
+			&worklog.Hours,
+			&worklog.Note,
+			&worklog.LoggedBy,
+			&worklog.LoggedOn,
+		)
+		if err != nil {
+			return nil, err
+		}
+		worklogs = append(worklogs, &worklog)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return worklogs, nil
+}
@@ -0,0 +1 @@
+ALTER TABLE issues ADD COLUMN IF NOT EXISTS reminded_at timestamp(0) with time zone;
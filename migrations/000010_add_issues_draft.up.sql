@@ -0,0 +1 @@
+ALTER TABLE issues ADD COLUMN IF NOT EXISTS draft bool NOT NULL DEFAULT false;
@@ -0,0 +1 @@
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS auto_close_days integer;
@@ -0,0 +1 @@
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS notification_channels text[] NOT NULL DEFAULT '{email}';
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS users_name_idx;
+DROP INDEX IF EXISTS projects_name_idx;
+CREATE INDEX IF NOT EXISTS projects_name_idx ON projects USING GIN (to_tsvector('simple', name));
+DROP FUNCTION IF EXISTS immutable_unaccent(text);
+DROP EXTENSION IF EXISTS unaccent;
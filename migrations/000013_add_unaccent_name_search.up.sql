@@ -0,0 +1,8 @@
+CREATE EXTENSION IF NOT EXISTS unaccent;
+-- unaccent is only STABLE because its dictionary can change, so wrap it in an IMMUTABLE
+-- function with the dictionary pinned in order to use it in index expressions.
+CREATE OR REPLACE FUNCTION immutable_unaccent(text) RETURNS text AS
+$$ SELECT public.unaccent('public.unaccent', $1) $$ LANGUAGE sql IMMUTABLE PARALLEL SAFE STRICT;
+DROP INDEX IF EXISTS projects_name_idx;
+CREATE INDEX IF NOT EXISTS projects_name_idx ON projects USING GIN (to_tsvector('simple', immutable_unaccent(name)));
+CREATE INDEX IF NOT EXISTS users_name_idx ON users USING GIN (to_tsvector('simple', immutable_unaccent(name)));
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS project_workflow_states (
+    project_id bigint NOT NULL REFERENCES projects ON DELETE CASCADE,
+    position integer NOT NULL,
+    name text NOT NULL,
+    category text NOT NULL,
+    PRIMARY KEY (project_id, position),
+    UNIQUE (project_id, name)
+);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS settings (
+    id integer PRIMARY KEY CHECK (id = 1),
+    settings jsonb NOT NULL,
+    modified_on timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    modified_by text NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS settings_audit (
+    id bigserial PRIMARY KEY,
+    changes jsonb NOT NULL,
+    changed_on timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    changed_by text NOT NULL
+);
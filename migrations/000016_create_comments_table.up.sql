@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS comments (
+    id bigserial PRIMARY KEY,
+    issue_id bigint NOT NULL REFERENCES issues ON DELETE CASCADE,
+    user_id bigint NOT NULL REFERENCES users ON DELETE CASCADE,
+    body text NOT NULL,
+    created_on timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    modified_on timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    version integer NOT NULL DEFAULT 1
+);
+
+CREATE INDEX IF NOT EXISTS comments_issue_id_idx ON comments (issue_id);
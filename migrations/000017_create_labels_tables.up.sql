@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS labels (
+    id bigserial PRIMARY KEY,
+    name text UNIQUE NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS issues_labels (
+    issue_id bigint NOT NULL REFERENCES issues ON DELETE CASCADE,
+    label_id bigint NOT NULL REFERENCES labels ON DELETE CASCADE,
+    PRIMARY KEY (issue_id, label_id)
+);
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS watchers (
+    issue_id bigint NOT NULL REFERENCES issues ON DELETE CASCADE,
+    user_id bigint NOT NULL REFERENCES users ON DELETE CASCADE,
+    created_on timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (issue_id, user_id)
+);
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS issue_activity (
+    id bigserial PRIMARY KEY,
+    issue_id bigint NOT NULL REFERENCES issues ON DELETE CASCADE,
+    field text NOT NULL,
+    old_value text NOT NULL,
+    new_value text NOT NULL,
+    changed_by text NOT NULL,
+    changed_on timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS issue_activity_issue_id_idx ON issue_activity (issue_id, changed_on);
@@ -0,0 +1,6 @@
+ALTER TABLE issues ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', title), 'A') ||
+    setweight(to_tsvector('simple', description), 'B') ||
+    setweight(to_tsvector('simple', resolution_summary), 'C')
+) STORED;
+CREATE INDEX IF NOT EXISTS issues_search_vector_idx ON issues USING GIN (search_vector);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS issue_links (
+    id bigserial PRIMARY KEY,
+    source_id bigint NOT NULL REFERENCES issues ON DELETE CASCADE,
+    target_id bigint NOT NULL REFERENCES issues ON DELETE CASCADE,
+    link_type text NOT NULL,
+    created_on timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    created_by text NOT NULL,
+    CONSTRAINT issue_links_not_self CHECK (source_id <> target_id),
+    CONSTRAINT issue_links_unique UNIQUE (source_id, target_id, link_type)
+);
+
+CREATE INDEX IF NOT EXISTS issue_links_target_id_idx ON issue_links (target_id);
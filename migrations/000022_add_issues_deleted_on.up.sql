@@ -0,0 +1 @@
+ALTER TABLE issues ADD COLUMN IF NOT EXISTS deleted_on timestamp(0) with time zone;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS worklogs;
+ALTER TABLE issues DROP COLUMN IF EXISTS logged_hours;
+ALTER TABLE issues DROP COLUMN IF EXISTS estimated_hours;
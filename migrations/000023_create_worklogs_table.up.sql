@@ -0,0 +1,13 @@
+ALTER TABLE issues ADD COLUMN IF NOT EXISTS estimated_hours numeric(6, 2);
+ALTER TABLE issues ADD COLUMN IF NOT EXISTS logged_hours numeric(8, 2) NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS worklogs (
+    id bigserial PRIMARY KEY,
+    issue_id bigint NOT NULL REFERENCES issues ON DELETE CASCADE,
+    hours numeric(6, 2) NOT NULL CHECK (hours > 0),
+    note text NOT NULL DEFAULT '',
+    logged_by bigint NOT NULL REFERENCES users ON DELETE CASCADE,
+    logged_on timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS worklogs_issue_id_idx ON worklogs (issue_id);
@@ -0,0 +1,2 @@
+ALTER TABLE issues DROP COLUMN IF EXISTS milestone_id;
+DROP TABLE IF EXISTS milestones;
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS milestones (
+    id bigserial PRIMARY KEY,
+    project_id bigint NOT NULL REFERENCES projects ON DELETE CASCADE,
+    title text NOT NULL,
+    due_date date NOT NULL,
+    status text NOT NULL DEFAULT 'open',
+    created_on timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    created_by text NOT NULL,
+    modified_on timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    modified_by text NOT NULL,
+    version integer NOT NULL DEFAULT 1,
+    CONSTRAINT milestones_project_title_key UNIQUE (project_id, title)
+);
+
+ALTER TABLE issues ADD COLUMN IF NOT EXISTS milestone_id bigint REFERENCES milestones ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS issues_milestone_id_idx ON issues (milestone_id);
@@ -0,0 +1,2 @@
+-- Lowercased priorities are left as they are, since their original casing isn't kept,
+-- and neither are the priorities mapped into the safelist.
@@ -0,0 +1,14 @@
+-- Priorities were stored as given, so differently cased values are normalized before
+-- they are validated against the priority safelist.
+UPDATE issues SET priority = LOWER(TRIM(priority)) WHERE priority <> LOWER(TRIM(priority));
+
+-- Priorities outside the safelist would fail validation on every later update, so they
+-- are mapped to the closest safelisted priority, or to medium if there is none.
+UPDATE issues
+SET priority = CASE
+    WHEN priority IN ('urgent', 'blocker', 'highest') THEN 'critical'
+    WHEN priority IN ('major') THEN 'high'
+    WHEN priority IN ('minor', 'trivial', 'lowest') THEN 'low'
+    ELSE 'medium'
+END
+WHERE priority NOT IN ('low', 'medium', 'high', 'critical');
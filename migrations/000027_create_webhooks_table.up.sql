@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS webhooks (
+    id bigserial PRIMARY KEY,
+    project_id bigint NOT NULL REFERENCES projects ON DELETE CASCADE,
+    url text NOT NULL,
+    secret text NOT NULL,
+    events text[] NOT NULL,
+    active boolean NOT NULL DEFAULT true,
+    created_on timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    created_by text NOT NULL,
+    modified_on timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    modified_by text NOT NULL,
+    version integer NOT NULL DEFAULT 1
+);
+
+CREATE INDEX IF NOT EXISTS webhooks_project_id_idx ON webhooks (project_id);
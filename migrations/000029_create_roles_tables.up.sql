@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS roles (
+    name text PRIMARY KEY,
+    created_on timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    created_by text NOT NULL,
+    modified_on timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    modified_by text NOT NULL,
+    version integer NOT NULL DEFAULT 1
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role text NOT NULL REFERENCES roles ON DELETE CASCADE,
+    action text NOT NULL,
+    resource text NOT NULL,
+    PRIMARY KEY (role, action, resource)
+);
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS project_activity (
+    id bigserial PRIMARY KEY,
+    project_id bigint NOT NULL REFERENCES projects ON DELETE CASCADE,
+    field text NOT NULL,
+    old_value text NOT NULL,
+    new_value text NOT NULL,
+    changed_by text NOT NULL,
+    changed_on timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS project_activity_project_id_idx ON project_activity (project_id, changed_on);
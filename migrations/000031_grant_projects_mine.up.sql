@@ -0,0 +1,5 @@
+INSERT INTO role_permissions (role, action, resource)
+SELECT name, 'read', 'projects/mine'
+FROM roles
+WHERE name = 'member'
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,2 @@
+ALTER TABLE projects DROP COLUMN IF EXISTS archived_on;
+ALTER TABLE projects DROP COLUMN IF EXISTS archived;
@@ -0,0 +1 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS digest_opt_in boolean NOT NULL DEFAULT false;
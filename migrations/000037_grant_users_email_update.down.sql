@@ -0,0 +1,2 @@
+DELETE FROM role_permissions
+WHERE role IN ('member', 'lead') AND action = 'update' AND resource = 'users/email';
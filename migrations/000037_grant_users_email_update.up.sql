@@ -0,0 +1,5 @@
+INSERT INTO role_permissions (role, action, resource)
+SELECT name, 'update', 'users/email'
+FROM roles
+WHERE name IN ('member', 'lead')
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,2 @@
+DELETE FROM role_permissions
+WHERE role IN ('member', 'lead', 'manager') AND action = 'read' AND resource = 'dashboard';
@@ -0,0 +1,5 @@
+INSERT INTO role_permissions (role, action, resource)
+SELECT name, 'read', 'dashboard'
+FROM roles
+WHERE name IN ('member', 'lead', 'manager')
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,4 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+-- Trigram indexes let the partial name and email search (ILIKE '%term%') use an index.
+CREATE INDEX IF NOT EXISTS users_name_trgm_idx ON users USING GIN (immutable_unaccent(name) gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS users_email_trgm_idx ON users USING GIN (email gin_trgm_ops);
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS project_templates (
+    id bigserial PRIMARY KEY,
+    name text NOT NULL UNIQUE,
+    body jsonb NOT NULL,
+    created_on timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    created_by text NOT NULL,
+    modified_on timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    modified_by text NOT NULL,
+    version integer NOT NULL DEFAULT 1
+);
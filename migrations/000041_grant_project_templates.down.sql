@@ -0,0 +1,2 @@
+DELETE FROM role_permissions
+WHERE role = 'manager' AND resource = 'project-templates';
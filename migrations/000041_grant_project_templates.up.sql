@@ -0,0 +1,5 @@
+INSERT INTO role_permissions (role, action, resource)
+SELECT roles.name, actions.action, 'project-templates'
+FROM roles, unnest(ARRAY['create', 'read', 'update', 'delete']) AS actions(action)
+WHERE roles.name = 'manager'
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,2 @@
+DELETE FROM role_permissions
+WHERE role = 'member' AND action = 'read' AND resource = 'projects/backlog';
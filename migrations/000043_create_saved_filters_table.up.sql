@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS saved_filters (
+    id bigserial PRIMARY KEY,
+    user_id bigint NOT NULL REFERENCES users ON DELETE CASCADE,
+    name text NOT NULL,
+    query_string text NOT NULL,
+    created_on timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    modified_on timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    version integer NOT NULL DEFAULT 1,
+    CONSTRAINT saved_filters_user_name_key UNIQUE (user_id, name)
+);
@@ -0,0 +1 @@
+ALTER TABLE issue_activity ADD COLUMN IF NOT EXISTS version bigint NOT NULL DEFAULT 0;
@@ -0,0 +1 @@
+ALTER TABLE issue_activity DROP COLUMN IF EXISTS changed_by_id;
@@ -0,0 +1 @@
+ALTER TABLE issue_activity ADD COLUMN IF NOT EXISTS changed_by_id bigint REFERENCES users ON DELETE SET NULL;
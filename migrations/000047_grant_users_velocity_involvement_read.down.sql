@@ -0,0 +1,2 @@
+DELETE FROM role_permissions
+WHERE role = 'lead' AND action = 'read' AND resource IN ('users/velocity', 'users/involvement');
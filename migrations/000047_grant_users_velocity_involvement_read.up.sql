@@ -0,0 +1,5 @@
+INSERT INTO role_permissions (role, action, resource)
+SELECT roles.name, 'read', resources.resource
+FROM roles, unnest(ARRAY['users/velocity', 'users/involvement']) AS resources(resource)
+WHERE roles.name = 'lead'
+ON CONFLICT DO NOTHING;
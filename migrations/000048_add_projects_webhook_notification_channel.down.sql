@@ -0,0 +1,2 @@
+UPDATE projects SET notification_channels = array_remove(notification_channels, 'webhook');
+ALTER TABLE projects ALTER COLUMN notification_channels SET DEFAULT '{email}';
@@ -0,0 +1,3 @@
+ALTER TABLE projects ALTER COLUMN notification_channels SET DEFAULT '{email,webhook}';
+UPDATE projects SET notification_channels = array_append(notification_channels, 'webhook')
+WHERE NOT ('webhook' = ANY(notification_channels));
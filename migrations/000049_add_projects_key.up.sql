@@ -0,0 +1,4 @@
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS key text;
+UPDATE projects SET key = COALESCE(NULLIF(upper(left(regexp_replace(name, '[^a-zA-Z]', '', 'g'), 4)), ''), 'P') || id;
+ALTER TABLE projects ALTER COLUMN key SET NOT NULL;
+ALTER TABLE projects ADD CONSTRAINT projects_key_key UNIQUE (key);
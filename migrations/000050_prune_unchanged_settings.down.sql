@@ -0,0 +1 @@
+-- Pruned settings follow the command-line flags, which cannot be recovered here.
@@ -0,0 +1,5 @@
+UPDATE settings SET settings = COALESCE((
+    SELECT jsonb_object_agg(stored.key, stored.value)
+    FROM jsonb_each(settings.settings) AS stored
+    WHERE stored.key IN (SELECT jsonb_object_keys(changes) FROM settings_audit)
+), '{}');
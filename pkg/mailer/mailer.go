@@ -3,6 +3,8 @@ package mailer
 import (
 	"bytes"
 	"embed"
+	"io/fs"
+	"strings"
 	"text/template"
 	"time"
 
@@ -10,12 +12,21 @@ import (
 )
 
 //go:embed "templates"
-var templateFS embed.FS
+var templates embed.FS
 
-// Mailer contains a mail.Dialer instance and sender information.
+// templateFS holds the email templates. Tests replace it with their own templates.
+var templateFS fs.FS = templates
+
+// dialer sends messages to an SMTP server. It is satisfied by *mail.Dialer.
+type dialer interface {
+	DialAndSend(m ...*mail.Message) error
+}
+
+// Mailer contains a dialer instance and sender information.
 type Mailer struct {
-	dialer *mail.Dialer
-	sender string
+	dialer     dialer
+	sender     string
+	retryDelay time.Duration
 }
 
 // New creates a new Mailer.
@@ -23,15 +34,18 @@ func New(host string, port int, username, password, sender string) Mailer {
 	dialer := mail.NewDialer(host, port, username, password)
 	dialer.Timeout = 5 * time.Second
 	return Mailer{
-		dialer: dialer,
-		sender: sender,
+		dialer:     dialer,
+		sender:     sender,
+		retryDelay: 5 * time.Second,
 	}
 }
 
-// Send sends an email. It accepts a recipient, tempate file and data.
-func (m Mailer) Send(recipient, templateFile string, data any) error {
+// Send sends an email. It accepts a recipient, the recipient's locale, tempate file and
+// data. The template file must define the "subject" and "plainBody" templates, and may
+// define an "htmlBody" template for an HTML alternative of the body.
+func (m Mailer) Send(recipient, locale, templateFile string, data any) error {
 	// Parse template from embedded file system.
-	tmpl, err := template.New("email").ParseFS(templateFS, "templates/"+templateFile)
+	tmpl, err := template.New("email").ParseFS(templateFS, "templates/"+localizedTemplate(templateFile, locale))
 	if err != nil {
 		return err
 	}
@@ -49,12 +63,15 @@ func (m Mailer) Send(recipient, templateFile string, data any) error {
 	if err != nil {
 		return err
 	}
-	// Execute the named template "htmlBody", passing in the dynamic data and storing the
-	// result in a bytes.Buffer variable.
-	htmlBody := new(bytes.Buffer)
-	err = tmpl.ExecuteTemplate(htmlBody, "htmlBody", data)
-	if err != nil {
-		return err
+	// Execute the named template "htmlBody", if the template file defines one, passing in
+	// the dynamic data and storing the result in a bytes.Buffer variable.
+	var htmlBody *bytes.Buffer
+	if tmpl.Lookup("htmlBody") != nil {
+		htmlBody = new(bytes.Buffer)
+		err = tmpl.ExecuteTemplate(htmlBody, "htmlBody", data)
+		if err != nil {
+			return err
+		}
 	}
 	// Initialize a new mail.Message instance, then set header, body and alternative parts
 	// to the message.
@@ -63,15 +80,34 @@ func (m Mailer) Send(recipient, templateFile string, data any) error {
 	msg.SetHeader("From", m.sender)
 	msg.SetHeader("Subject", subject.String())
 	msg.SetBody("text/plain", plainBody.String())
-	msg.AddAlternative("text/html", htmlBody.String())
+	if htmlBody != nil {
+		msg.AddAlternative("text/html", htmlBody.String())
+	}
 	// Try sending the email up to three times before aborting and returning the final
-	// error. Sleep for 5 seconds between each attempt.
+	// error. Sleep for the retry delay between each attempt.
 	for i := 1; i <= 3; i++ {
 		err = m.dialer.DialAndSend(msg)
 		if err == nil {
 			return nil
 		}
-		time.Sleep(5 * time.Second)
+		if i < 3 {
+			time.Sleep(m.retryDelay)
+		}
+	}
+	return err
+}
+
+// localizedTemplate returns the variant of the template file for the locale, e.g.
+// user_welcome.fr.tmpl for user_welcome.tmpl and "fr". The template file itself is the
+// default for locales without a variant.
+func localizedTemplate(templateFile, locale string) string {
+	if locale == "" {
+		return templateFile
+	}
+	localized := strings.TrimSuffix(templateFile, ".tmpl") + "." + locale + ".tmpl"
+	_, err := fs.Stat(templateFS, "templates/"+localized)
+	if err != nil {
+		return templateFile
 	}
-	return nil
+	return localized
 }
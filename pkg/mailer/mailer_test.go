@@ -0,0 +1,142 @@
+package mailer
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/emzola/issuetracker/pkg/model"
+	"github.com/go-mail/mail/v2"
+)
+
+var errConnectionRefused = errors.New("connection refused")
+
+// failingDialer fails every attempt to send a message.
+type failingDialer struct {
+	attempts int
+}
+
+func (d *failingDialer) DialAndSend(m ...*mail.Message) error {
+	d.attempts++
+	return errConnectionRefused
+}
+
+func TestSendReturnsFinalError(t *testing.T) {
+	dialer := &failingDialer{}
+	m := Mailer{dialer: dialer, sender: "Issue Tracker <no-reply@example.com>"}
+	data := map[string]string{
+		"name":          "Ada Lovelace",
+		"issueID":       "1",
+		"issueTitle":    "Login fails",
+		"issuePriority": "high",
+	}
+	err := m.Send("ada@example.com", "en", "issue_assign.tmpl", data)
+	if !errors.Is(err, errConnectionRefused) {
+		t.Fatalf("Send() error = %v, want the final dial error", err)
+	}
+	if dialer.attempts != 3 {
+		t.Errorf("Send() made %d attempts, want 3", dialer.attempts)
+	}
+}
+
+// recordingDialer records the messages sent.
+type recordingDialer struct {
+	messages []*mail.Message
+}
+
+func (d *recordingDialer) DialAndSend(m ...*mail.Message) error {
+	d.messages = append(d.messages, m...)
+	return nil
+}
+
+func TestSendDailyDigest(t *testing.T) {
+	dialer := &recordingDialer{}
+	m := Mailer{dialer: dialer, sender: "Issue Tracker <no-reply@example.com>"}
+	data := map[string]any{
+		"name": "Ada Lovelace",
+		"issues": []*model.Issue{
+			{ID: 7, Title: "Login fails", Status: "open", Priority: "high", TargetResolutionDate: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		},
+	}
+	err := m.Send("ada@example.com", "en", "daily_digest.tmpl", data)
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if len(dialer.messages) != 1 {
+		t.Fatalf("Send() sent %d messages, want 1", len(dialer.messages))
+	}
+	var body strings.Builder
+	_, err = dialer.messages[0].WriteTo(&body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(body.String(), "Login fails") {
+		t.Errorf("Send() message does not list the digest issues:\n%s", body.String())
+	}
+}
+
+func TestSendOptionalHTMLBody(t *testing.T) {
+	const (
+		subject   = `{{define "subject"}}Hello{{end}}`
+		plainBody = `{{define "plainBody"}}Hi {{.name}}{{end}}`
+		htmlBody  = `{{define "htmlBody"}}<p>Hi {{.name}}</p>{{end}}`
+	)
+	tests := []struct {
+		name     string
+		template string
+		wantHTML bool
+		wantErr  bool
+	}{
+		{"plain and html", subject + plainBody + htmlBody, true, false},
+		{"plain only", subject + plainBody, false, false},
+		{"html only", subject + htmlBody, false, true},
+		{"no subject", plainBody + htmlBody, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			templateFS = fstest.MapFS{"templates/test.tmpl": {Data: []byte(tt.template)}}
+			t.Cleanup(func() { templateFS = templates })
+			dialer := &recordingDialer{}
+			m := Mailer{dialer: dialer, sender: "Issue Tracker <no-reply@example.com>"}
+			err := m.Send("ada@example.com", "en", "test.tmpl", map[string]string{"name": "Ada"})
+			if tt.wantErr {
+				if err == nil {
+					t.Error("Send() error = nil, want an error for the missing template")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Send() error = %v", err)
+			}
+			var body strings.Builder
+			_, err = dialer.messages[0].WriteTo(&body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(body.String(), "text/plain") {
+				t.Errorf("Send() message has no plain text body:\n%s", body.String())
+			}
+			if got := strings.Contains(body.String(), "text/html"); got != tt.wantHTML {
+				t.Errorf("Send() message has an HTML body = %v, want %v", got, tt.wantHTML)
+			}
+		})
+	}
+}
+
+func TestLocalizedTemplate(t *testing.T) {
+	tests := []struct {
+		locale string
+		want   string
+	}{
+		{"fr", "user_welcome.fr.tmpl"},
+		{"de", "user_welcome.tmpl"},
+		{"", "user_welcome.tmpl"},
+	}
+	for _, tt := range tests {
+		if got := localizedTemplate("user_welcome.tmpl", tt.locale); got != tt.want {
+			t.Errorf("localizedTemplate(%q) = %q, want %q", tt.locale, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package model
+
+import "time"
+
+// IssueActivity defines a change made to a single field of an issue. Version is the
+// version of the issue the change produced. ChangedByID is 0 for changes made by the
+// system, such as automatic closing.
+type IssueActivity struct {
+	ID          int64     `json:"id"`
+	IssueID     int64     `json:"issue_id"`
+	Version     int64     `json:"version"`
+	Field       string    `json:"field"`
+	OldValue    string    `json:"old_value"`
+	NewValue    string    `json:"new_value"`
+	ChangedBy   string    `json:"changed_by"`
+	ChangedByID int64     `json:"changed_by_id,omitempty"`
+	ChangedOn   time.Time `json:"changed_on"`
+}
+
+// IssueFieldDiff defines the values of an issue field at two versions of the issue.
+type IssueFieldDiff struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+// IssueDiff defines the fields that differ between two versions of an issue.
+type IssueDiff struct {
+	IssueID     int64            `json:"issue_id"`
+	FromVersion int64            `json:"from_version"`
+	ToVersion   int64            `json:"to_version"`
+	Changes     []IssueFieldDiff `json:"changes"`
+}
+
+// ProjectActivity defines a change made to a single field of a project.
+type ProjectActivity struct {
+	ID        int64     `json:"id"`
+	ProjectID int64     `json:"project_id"`
+	Field     string    `json:"field"`
+	OldValue  string    `json:"old_value"`
+	NewValue  string    `json:"new_value"`
+	ChangedBy string    `json:"changed_by"`
+	ChangedOn time.Time `json:"changed_on"`
+}
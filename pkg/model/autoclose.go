@@ -0,0 +1,14 @@
+package model
+
+// IssueAutoClose holds data for a resolved issue that is due to be closed automatically.
+type IssueAutoClose struct {
+	IssueID        int64  `json:"issue_id"`
+	Title          string `json:"issue_title"`
+	Status         string `json:"status"`
+	ClosedStatus   string `json:"closed_status"`
+	AutoCloseDays  int    `json:"auto_close_days"`
+	NotifyByEmail  bool   `json:"notify_by_email"`
+	ReporterName   string `json:"reporter_name"`
+	ReporterEmail  string `json:"reporter_email"`
+	ReporterLocale string `json:"reporter_locale"`
+}
@@ -0,0 +1,18 @@
+package model
+
+// BulkResult holds the outcome of a single item in a bulk operation. Status is the
+// HTTP status the item would have received as an individual request. Row is the
+// position of the item in an uploaded file, counting from 1, for bulk operations on
+// files.
+type BulkResult struct {
+	Row    int    `json:"row,omitempty"`
+	ID     int64  `json:"id"`
+	Status int    `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// BulkResponse is the body of the 207 Multi-Status response to a bulk operation, holding
+// the outcome of each item in the order the items were given.
+type BulkResponse struct {
+	Results []*BulkResult `json:"results"`
+}
@@ -0,0 +1,24 @@
+package model
+
+import (
+	"time"
+
+	"github.com/emzola/issuetracker/pkg/validator"
+)
+
+// Comment defines comment data.
+type Comment struct {
+	ID         int64     `json:"id"`
+	IssueID    int64     `json:"issue_id"`
+	UserID     int64     `json:"user_id"`
+	Body       string    `json:"body"`
+	CreatedOn  time.Time `json:"created_on"`
+	ModifiedOn time.Time `json:"modified_on"`
+	Version    int64     `json:"-"`
+}
+
+// Validate comment data.
+func (c Comment) Validate(v *validator.Validator) {
+	v.Check(c.Body != "", "body", "must be provided")
+	v.Check(len(c.Body) <= 5000, "body", "must not be more than 5000 bytes long")
+}
@@ -0,0 +1,13 @@
+package model
+
+// Dashboard is an overview of a user's work: the first few of their open assigned
+// issues, of the issues they reported and of the projects they're on, along with how
+// many there are of each.
+type Dashboard struct {
+	AssignedIssues      []*Issue   `json:"assigned_issues"`
+	AssignedIssuesCount int        `json:"assigned_issues_count"`
+	ReportedIssues      []*Issue   `json:"reported_issues"`
+	ReportedIssuesCount int        `json:"reported_issues_count"`
+	Projects            []*Project `json:"projects"`
+	ProjectsCount       int        `json:"projects_count"`
+}
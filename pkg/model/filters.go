@@ -1,15 +1,22 @@
 package model
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/emzola/issuetracker/pkg/validator"
 )
 
-// Filters defines sorting and pagination data.
+// DefaultMaxPageSize is the largest page size clients can request when no other maximum
+// is set.
+const DefaultMaxPageSize = 100
+
+// Filters defines sorting and pagination data. MaxPageSize is the largest page size
+// clients can request; 0 uses DefaultMaxPageSize.
 type Filters struct {
 	Page         int
 	PageSize     int
+	MaxPageSize  int
 	Sort         string
 	SortSafelist []string
 }
@@ -19,23 +26,59 @@ func (f Filters) Validate(v *validator.Validator) {
 	v.Check(f.Page > 0, "page", "must be greater than zero")
 	v.Check(f.Page <= 10_000_000, "page", "must be a maximum of 10 million")
 	v.Check(f.PageSize > 0, "page_size", "must be greater than zero")
-	v.Check(f.PageSize <= 100, "page_size", "must be a maximum of 100")
-	v.Check(validator.In(f.Sort, f.SortSafelist...), "sort", "invalid sort value")
+	maxPageSize := f.MaxPageSize
+	if maxPageSize == 0 {
+		maxPageSize = DefaultMaxPageSize
+	}
+	v.Check(f.PageSize <= maxPageSize, "page_size", fmt.Sprintf("must be a maximum of %d; request further pages with page", maxPageSize))
+	f.ValidateSort(v)
+}
+
+// ValidateSort validates Sort on its own, for lists that aren't paginated. Sort can list
+// several comma-separated fields, each of which must be in SortSafelist. Invalid fields
+// are reported along with the safelist, so that clients know which fields they can sort
+// by.
+func (f Filters) ValidateSort(v *validator.Validator) {
+	fields := f.sortFields()
+	columns := make([]string, len(fields))
+	for i, field := range fields {
+		v.Check(validator.In(field, f.SortSafelist...), "sort", "invalid sort value; must be one of "+strings.Join(f.SortSafelist, ", "))
+		columns[i] = strings.TrimPrefix(field, "-")
+	}
+	v.Check(validator.Unique(columns), "sort", "must not contain duplicate fields")
 }
 
-// SortColumn sorts
-func (f Filters) SortColumn() string {
-	for _, safeValue := range f.SortSafelist {
-		if f.Sort == safeValue {
-			return strings.TrimPrefix(f.Sort, "-")
+// sortFields returns the fields of the comma-separated sort list.
+func (f Filters) sortFields() []string {
+	fields := strings.Split(f.Sort, ",")
+	for i := range fields {
+		fields[i] = strings.TrimSpace(fields[i])
+	}
+	return fields
+}
+
+// OrderBy returns the ORDER BY list of the sort fields, e.g. "priority DESC,
+// target_resolution_date ASC". Only fields in SortSafelist are returned, so that the list
+// is safe to interpolate into a query.
+func (f Filters) OrderBy() string {
+	fields := f.sortFields()
+	clauses := make([]string, len(fields))
+	for i, field := range fields {
+		if !validator.In(field, f.SortSafelist...) {
+			panic("unsafe sort parameter:" + field)
+		}
+		direction := "ASC"
+		if strings.HasPrefix(field, "-") {
+			direction = "DESC"
 		}
+		clauses[i] = strings.TrimPrefix(field, "-") + " " + direction
 	}
-	panic("unsafe sort parameter:" + f.Sort)
+	return strings.Join(clauses, ", ")
 }
 
-// SortDirection sorts by ascending or descending order.
+// SortDirection returns the order, ascending or descending, of the first sort field.
 func (f Filters) SortDirection() string {
-	if strings.HasPrefix(f.Sort, "-") {
+	if strings.HasPrefix(f.sortFields()[0], "-") {
 		return "DESC"
 	}
 	return "ASC"
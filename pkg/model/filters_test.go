@@ -0,0 +1,85 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/emzola/issuetracker/pkg/validator"
+)
+
+func TestFiltersOrderBy(t *testing.T) {
+	safelist := []string{"id", "priority", "target_resolution_date", "-id", "-priority", "-target_resolution_date"}
+	tests := []struct {
+		sort      string
+		want      string
+		wantValid bool
+	}{
+		{"priority", "priority ASC", true},
+		{"-priority", "priority DESC", true},
+		{"-priority,target_resolution_date", "priority DESC, target_resolution_date ASC", true},
+		{"target_resolution_date, -priority, id", "target_resolution_date ASC, priority DESC, id ASC", true},
+		{"priority,-priority", "", false},
+		{"priority,title", "", false},
+		{"priority,", "", false},
+		{"priority;DROP TABLE issues", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sort, func(t *testing.T) {
+			f := Filters{Page: 1, PageSize: 20, Sort: tt.sort, SortSafelist: safelist}
+			v := validator.New()
+			f.Validate(v)
+			if v.Valid() != tt.wantValid {
+				t.Fatalf("Validate() errors = %v, want valid %v", v.Errors, tt.wantValid)
+			}
+			if !tt.wantValid {
+				return
+			}
+			if got := f.OrderBy(); got != tt.want {
+				t.Errorf("OrderBy() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFiltersOrderByUnsafe(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("OrderBy() didn't panic on a field outside the safelist")
+		}
+	}()
+	f := Filters{Sort: "id,title", SortSafelist: []string{"id"}}
+	f.OrderBy()
+}
+
+func TestFiltersValidateSortMessage(t *testing.T) {
+	f := Filters{Page: 1, PageSize: 20, Sort: "-priority,title", SortSafelist: []string{"id", "priority", "-id", "-priority"}}
+	v := validator.New()
+	f.Validate(v)
+	want := "invalid sort value; must be one of id, priority, -id, -priority"
+	if got := v.Errors["sort"]; got != want {
+		t.Errorf("Validate() sort error = %q, want %q", got, want)
+	}
+}
+
+func TestFiltersValidatePageSize(t *testing.T) {
+	tests := []struct {
+		name        string
+		pageSize    int
+		maxPageSize int
+		wantErr     string
+	}{
+		{"default maximum", 100, 0, ""},
+		{"above default maximum", 101, 0, "must be a maximum of 100; request further pages with page"},
+		{"custom maximum", 5000, 10_000, ""},
+		{"above custom maximum", 10_001, 10_000, "must be a maximum of 10000; request further pages with page"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := Filters{Page: 1, PageSize: tt.pageSize, MaxPageSize: tt.maxPageSize, Sort: "id", SortSafelist: []string{"id"}}
+			v := validator.New()
+			f.Validate(v)
+			if got := v.Errors["page_size"]; got != tt.wantErr {
+				t.Errorf("Validate() page_size error = %q, want %q", got, tt.wantErr)
+			}
+		})
+	}
+}
@@ -1,11 +1,21 @@
 package model
 
 import (
+	"strings"
 	"time"
 
 	"github.com/emzola/issuetracker/pkg/validator"
 )
 
+// IssueStatuses holds the statuses an issue can be in.
+var IssueStatuses = []string{"open", "in progress", "resolved", "closed"}
+
+// IssuePriorities holds the priority levels an issue can have.
+var IssuePriorities = []string{"low", "medium", "high", "critical"}
+
+// IssueTypes holds the types an issue can be.
+var IssueTypes = []string{"bug", "feature", "task", "improvement"}
+
 // Issue defines issue data.
 type Issue struct {
 	ID                   int64      `json:"id"`
@@ -14,9 +24,11 @@ type Issue struct {
 	ReporterID           int64      `json:"reporter_id"`
 	ReportedDate         time.Time  `json:"reported_date"`
 	ProjectID            int64      `json:"project_id"`
+	MilestoneID          *int64     `json:"milestone_id,omitempty"`
 	AssignedTo           *int64     `json:"assigned_to,omitempty"`
 	Status               string     `json:"status"`
 	Priority             string     `json:"priority"`
+	Type                 string     `json:"type"`
 	TargetResolutionDate time.Time  `json:"target_resolution_date"`
 	Progress             string     `json:"progress,omitempty"`
 	ActualResolutionDate *time.Time `json:"actual_resolution_date,omitempty"`
@@ -25,9 +37,53 @@ type Issue struct {
 	CreatedBy            string     `json:"created_by"`
 	ModifiedOn           time.Time  `json:"modified_on"`
 	ModifiedBy           string     `json:"modified_by"`
+	Draft                bool       `json:"draft"`
+	EstimatedHours       *float64   `json:"estimated_hours,omitempty"`
+	LoggedHours          float64    `json:"logged_hours"`
+	DeletedOn            *time.Time `json:"deleted_on,omitempty"`
 	Version              int64      `json:"-"`
 }
 
+// IssueExport defines the issue data included in issue exports.
+type IssueExport struct {
+	ID                   int64
+	Title                string
+	Status               string
+	Priority             string
+	Assignee             string
+	ReportedDate         time.Time
+	TargetResolutionDate time.Time
+	ActualResolutionDate *time.Time
+}
+
+// IssueFilters holds the filters of issue lists and exports. Filters left at their zero
+// value match every issue. Dates are in the YYYY-MM-DD format, and date ranges include
+// both bounds. Title only searches issue titles, whereas Query searches titles,
+// descriptions and resolution summaries. LabelMatch decides whether issues must have any
+// or all of Labels.
+type IssueFilters struct {
+	Title          string
+	Query          string
+	ReportedDate   string
+	ReportedFrom   string
+	ReportedTo     string
+	TargetFrom     string
+	TargetTo       string
+	ProjectID      int64
+	MilestoneID    int64
+	AssignedTo     int64
+	Unassigned     bool
+	ReporterID     int64
+	AssigneeName   string
+	ReporterName   string
+	Status         string
+	Priority       string
+	Type           string
+	Labels         []string
+	LabelMatch     string
+	IncludeDeleted bool
+}
+
 // Validate issue data.
 func (i Issue) Validate(v *validator.Validator) {
 	v.Check(i.Title != "", "title", "must be provided")
@@ -48,4 +104,64 @@ func (i Issue) Validate(v *validator.Validator) {
 	if i.ActualResolutionDate != nil {
 		v.Check(i.ActualResolutionDate.After(i.ReportedDate), "actual resolution date", "must not be before reported date")
 	}
+	ValidatePriority(v, i.Priority)
+	v.Check(validator.In(i.Type, IssueTypes...), "type", "must be bug, feature, task or improvement")
+	ValidateEstimatedHours(v, i.EstimatedHours)
+}
+
+// ValidateDraft validates draft issue data. Drafts only need a title; the
+// remaining fields are validated in full when the draft is published.
+func (i Issue) ValidateDraft(v *validator.Validator) {
+	v.Check(i.Title != "", "title", "must be provided")
+	v.Check(len(i.Title) <= 500, "title", "must not be more than 500 bytes")
+	v.Check(len(i.Description) <= 5000, "description", "must not be more than 5000 bytes long")
+	if !i.TargetResolutionDate.IsZero() {
+		v.Check(i.TargetResolutionDate.After(i.ReportedDate), "target resolution date", "must not be before reported date")
+	}
+	ValidatePriority(v, i.Priority)
+	v.Check(validator.In(i.Type, IssueTypes...), "type", "must be bug, feature, task or improvement")
+	ValidateEstimatedHours(v, i.EstimatedHours)
+}
+
+// NormalizePriority returns the priority as it is stored, trimmed and lowercased, so
+// that "High" and "high" are the same priority.
+func NormalizePriority(priority string) string {
+	return strings.ToLower(strings.TrimSpace(priority))
+}
+
+// ValidatePriority checks that the priority is in the IssuePriorities safelist.
+func ValidatePriority(v *validator.Validator, priority string) {
+	v.Check(validator.In(priority, IssuePriorities...), "priority", "must be one of "+strings.Join(IssuePriorities, ", "))
+}
+
+// ValidateEstimatedHours validates the estimated hours of an issue, if they are set.
+func ValidateEstimatedHours(v *validator.Validator, hours *float64) {
+	if hours != nil {
+		v.Check(*hours >= 0, "estimated_hours", "must not be negative")
+		v.Check(*hours <= 1000, "estimated_hours", "must not be more than 1000")
+	}
+}
+
+// IssueList holds a page of issues along with its pagination metadata.
+type IssueList struct {
+	Issues   []*Issue `json:"issues"`
+	Metadata Metadata `json:"metadata"`
+}
+
+// UserInvolvement holds the issues a user is involved in, grouped by how
+// they are involved.
+type UserInvolvement struct {
+	Reported  IssueList `json:"reported"`
+	Assigned  IssueList `json:"assigned"`
+	Commented IssueList `json:"commented"`
+	Mentioned IssueList `json:"mentioned"`
+}
+
+// DataIssues holds issues with inconsistent data, grouped by the kind of
+// inconsistency.
+type DataIssues struct {
+	AssigneeNotOnProject           IssueList `json:"assignee_not_on_project"`
+	ClosedWithoutResolutionSummary IssueList `json:"closed_without_resolution_summary"`
+	ClosedWithoutResolutionDate    IssueList `json:"closed_without_resolution_date"`
+	TargetBeforeReported           IssueList `json:"target_before_reported"`
 }
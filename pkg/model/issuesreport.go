@@ -8,6 +8,12 @@ type IssuesStatus struct {
 	IssuesCount int64  `json:"issues_count"`
 }
 
+// IssuesType holds data for issues type report.
+type IssuesType struct {
+	Type        string `json:"issue_type"`
+	IssuesCount int64  `json:"issues_count"`
+}
+
 // IssuesAssignee holds data for issues assignee report.
 type IssuesAssignee struct {
 	AssigneeID     int64  `json:"assignee_id"`
@@ -33,3 +39,50 @@ type IssuesTargetDate struct {
 	Title                string    `json:"issue_title"`
 	TargetResolutionDate time.Time `json:"target_resolution_date"`
 }
+
+// IssuesBurndown holds the number of a project's issues opened and closed on a single day,
+// and the number left open at the end of it.
+type IssuesBurndown struct {
+	Date      time.Time `json:"date"`
+	Opened    int64     `json:"opened"`
+	Closed    int64     `json:"closed"`
+	OpenTotal int64     `json:"open_total"`
+}
+
+// IssuesEffort holds the hours estimated for and logged against the issues assigned to
+// a single assignee.
+type IssuesEffort struct {
+	AssigneeID     int64   `json:"assignee_id"`
+	AssigneeName   string  `json:"assignee_name"`
+	EstimatedHours float64 `json:"estimated_hours"`
+	LoggedHours    float64 `json:"logged_hours"`
+}
+
+// IssuesByProject holds data for the cross-project issues report.
+type IssuesByProject struct {
+	ProjectID     int64  `json:"project_id"`
+	ProjectName   string `json:"project_name"`
+	OpenIssues    int64  `json:"open_issues"`
+	ClosedIssues  int64  `json:"closed_issues"`
+	OverdueIssues int64  `json:"overdue_issues"`
+}
+
+// IssuesByMilestone holds data for the milestone completion report.
+type IssuesByMilestone struct {
+	MilestoneID          int64     `json:"milestone_id"`
+	MilestoneTitle       string    `json:"milestone_title"`
+	DueDate              time.Time `json:"due_date"`
+	Status               string    `json:"status"`
+	TotalIssues          int64     `json:"total_issues"`
+	ClosedIssues         int64     `json:"closed_issues"`
+	CompletionPercentage float64   `json:"completion_percentage"`
+}
+
+// VelocityIntervals holds the intervals resolution velocity can be grouped by.
+var VelocityIntervals = []string{"day", "week", "month"}
+
+// ResolutionVelocity holds the number of issues a user closed in a single interval.
+type ResolutionVelocity struct {
+	Period       time.Time `json:"period"`
+	IssuesClosed int64     `json:"issues_closed"`
+}
@@ -0,0 +1,22 @@
+package model
+
+import (
+	"unicode/utf8"
+
+	"github.com/emzola/issuetracker/pkg/validator"
+)
+
+// LabelMatches holds the ways issues can be matched against a list of labels.
+var LabelMatches = []string{"any", "all"}
+
+// Label defines label data. Label names are stored in lowercase.
+type Label struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+// Validate label data.
+func (l Label) Validate(v *validator.Validator) {
+	v.Check(l.Name != "", "name", "must be provided")
+	v.Check(utf8.RuneCountInString(l.Name) <= 40, "name", "must not be more than 40 characters long")
+}
@@ -0,0 +1,66 @@
+package model
+
+import (
+	"time"
+
+	"github.com/emzola/issuetracker/pkg/validator"
+)
+
+// IssueLinkTypes holds the ways an issue can be linked to another issue.
+var IssueLinkTypes = []string{"blocks", "blocked_by", "duplicates", "relates_to"}
+
+// IssueLink defines a link from the source issue to the target issue. TargetTitle and
+// TargetStatus describe the target issue when links are read.
+type IssueLink struct {
+	ID           int64     `json:"id"`
+	SourceID     int64     `json:"source_id"`
+	TargetID     int64     `json:"target_id"`
+	LinkType     string    `json:"link_type"`
+	TargetTitle  string    `json:"target_title,omitempty"`
+	TargetStatus string    `json:"target_status,omitempty"`
+	CreatedOn    time.Time `json:"created_on"`
+	CreatedBy    string    `json:"created_by"`
+}
+
+// IssueLinkReciprocal returns the type of the link that is created from the target issue back
+// to the source issue along with a link of type linkType, if there is one.
+func IssueLinkReciprocal(linkType string) (string, bool) {
+	switch linkType {
+	case "blocks":
+		return "blocked_by", true
+	case "blocked_by":
+		return "blocks", true
+	case "relates_to":
+		return "relates_to", true
+	default:
+		return "", false
+	}
+}
+
+// Validate issue link data.
+func (l IssueLink) Validate(v *validator.Validator) {
+	v.Check(l.TargetID > 0, "target_id", "must be provided")
+	v.Check(l.TargetID != l.SourceID, "target_id", "must not be the issue itself")
+	v.Check(validator.In(l.LinkType, IssueLinkTypes...), "link_type", "must be one of blocks, blocked_by, duplicates or relates_to")
+}
+
+// DuplicateChainIssue defines an issue in a chain of duplicates. DuplicateOf holds the
+// issues it is marked as a duplicate of, and Depth how many duplicates links separate it
+// from the canonical issue.
+type DuplicateChainIssue struct {
+	ID          int64   `json:"id"`
+	Title       string  `json:"title"`
+	Status      string  `json:"status"`
+	DuplicateOf []int64 `json:"duplicate_of"`
+	Depth       int     `json:"depth"`
+}
+
+// DuplicateChain defines the canonical issue an issue ended up merged into through
+// duplicates links, followed by every issue merged into it, nearest first. When the
+// links form a cycle there is no canonical issue: CanonicalID is nil, Cycle is set and
+// Issues holds every issue linked into the cycle.
+type DuplicateChain struct {
+	CanonicalID *int64                 `json:"canonical_id"`
+	Cycle       bool                   `json:"cycle"`
+	Issues      []*DuplicateChainIssue `json:"issues"`
+}
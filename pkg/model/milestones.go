@@ -0,0 +1,41 @@
+package model
+
+import (
+	"time"
+
+	"github.com/emzola/issuetracker/pkg/validator"
+)
+
+// MilestoneStatuses holds the statuses a milestone can have.
+var MilestoneStatuses = []string{"open", "closed"}
+
+// Milestone defines a milestone, such as a sprint, that a project's issues can be
+// planned into.
+type Milestone struct {
+	ID         int64     `json:"id"`
+	ProjectID  int64     `json:"project_id"`
+	Title      string    `json:"title"`
+	DueDate    time.Time `json:"due_date"`
+	Status     string    `json:"status"`
+	CreatedOn  time.Time `json:"created_on"`
+	CreatedBy  string    `json:"created_by"`
+	ModifiedOn time.Time `json:"modified_on"`
+	ModifiedBy string    `json:"modified_by"`
+	Version    int64     `json:"-"`
+}
+
+// Validate milestone data. A milestone is due after the start of its project.
+func (m Milestone) Validate(v *validator.Validator, project *Project) {
+	v.Check(m.Title != "", "title", "must be provided")
+	v.Check(len(m.Title) <= 500, "title", "must not be more than 500 bytes long")
+	v.Check(!m.DueDate.IsZero(), "due date", "must be provided")
+	v.Check(m.DueDate.After(project.StartDate), "due date", "must be after project start date")
+	v.Check(validator.In(m.Status, MilestoneStatuses...), "status", "must be open or closed")
+}
+
+// AtRiskIssue is an issue of a milestone targeted for resolution after the milestone
+// is due.
+type AtRiskIssue struct {
+	Issue
+	DaysPastMilestone int `json:"days_past_milestone"`
+}
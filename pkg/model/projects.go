@@ -1,25 +1,64 @@
 package model
 
 import (
+	"regexp"
 	"time"
 
 	"github.com/emzola/issuetracker/pkg/validator"
 )
 
+// MinAutoCloseDays is the shortest verification period a project can configure before
+// resolved issues are closed automatically.
+const MinAutoCloseDays = 3
+
+// NotificationChannels holds the channels issue event notifications can be delivered
+// through: email to the people involved in the issue, and webhook to the project's
+// webhooks.
+var NotificationChannels = []string{"email", "webhook"}
+
+// projectKeyRX matches the keys that can be chosen for a project. Generated keys end
+// with the project ID, so that they cannot clash with chosen ones.
+var projectKeyRX = regexp.MustCompile(`^[A-Z]{2,10}$`)
+
 // Project defines project data.
 type Project struct {
-	ID            int64      `json:"id"`
-	Name          string     `json:"name"`
-	Description   string     `json:"description,omitempty"`
-	AssignedTo    *int64     `json:"assigned_to,omitempty"`
-	StartDate     time.Time  `json:"start_date"`
-	TargetEndDate time.Time  `json:"target_end_date"`
-	ActualEndDate *time.Time `json:"actual_end_date,omitempty"`
-	CreatedOn     time.Time  `json:"created_on"`
-	CreatedBy     string     `json:"created_by"`
-	ModifiedOn    time.Time  `json:"modified_on"`
-	ModifiedBy    string     `json:"modified_by"`
-	Version       int64      `json:"-"`
+	ID                   int64      `json:"id"`
+	Key                  string     `json:"key"`
+	Name                 string     `json:"name"`
+	Description          string     `json:"description,omitempty"`
+	AssignedTo           *int64     `json:"assigned_to,omitempty"`
+	StartDate            time.Time  `json:"start_date"`
+	TargetEndDate        time.Time  `json:"target_end_date"`
+	ActualEndDate        *time.Time `json:"actual_end_date,omitempty"`
+	AutoCloseDays        *int       `json:"auto_close_days,omitempty"`
+	NotificationChannels []string   `json:"notification_channels"`
+	Archived             bool       `json:"archived"`
+	ArchivedOn           *time.Time `json:"archived_on,omitempty"`
+	CreatedOn            time.Time  `json:"created_on"`
+	CreatedBy            string     `json:"created_by"`
+	ModifiedOn           time.Time  `json:"modified_on"`
+	ModifiedBy           string     `json:"modified_by"`
+	Version              int64      `json:"-"`
+}
+
+// ProjectSummary holds the fields of a project needed to pick it from a list.
+type ProjectSummary struct {
+	ID   int64  `json:"id"`
+	Key  string `json:"key"`
+	Name string `json:"name"`
+}
+
+// ActiveUser defines how active a project member has been on the project's issues over
+// a period. IssuesUpdated and IssuesClosed count issues rather than changes, and Total
+// is the sum of the counts.
+type ActiveUser struct {
+	ID            int64  `json:"id"`
+	Name          string `json:"name"`
+	IssuesCreated int    `json:"issues_created"`
+	IssuesUpdated int    `json:"issues_updated"`
+	IssuesClosed  int    `json:"issues_closed"`
+	Comments      int    `json:"comments"`
+	Total         int    `json:"total"`
 }
 
 // Validate project data.
@@ -27,6 +66,9 @@ func (p Project) Validate(v *validator.Validator) {
 	v.Check(p.Name != "", "name", "must be provided")
 	v.Check(len(p.Name) >= 5, "name", "must not be less than 5 bytes long")
 	v.Check(len(p.Name) <= 500, "name", "must not be more than 500 bytes long")
+	if p.Key != "" {
+		v.Check(validator.Matches(p.Key, projectKeyRX), "key", "must be 2 to 10 uppercase letters")
+	}
 	v.Check(len(p.Description) >= 5, "description", "must not be less than 5 bytes long")
 	v.Check(len(p.Description) <= 5000, "description", "must not be more than 5000 bytes long")
 	v.Check(!p.StartDate.IsZero(), "start date", "must be provided")
@@ -35,4 +77,12 @@ func (p Project) Validate(v *validator.Validator) {
 	if p.ActualEndDate != nil {
 		v.Check(p.StartDate.Before(*p.ActualEndDate), "actual end date", "must not be before start date")
 	}
+	if p.AutoCloseDays != nil {
+		v.Check(*p.AutoCloseDays >= MinAutoCloseDays, "auto close days", "must not be less than 3")
+		v.Check(*p.AutoCloseDays <= 365, "auto close days", "must not be more than 365")
+	}
+	for _, channel := range p.NotificationChannels {
+		v.Check(validator.In(channel, NotificationChannels...), "notification channels", "must only contain supported channels (email, webhook)")
+	}
+	v.Check(validator.Unique(p.NotificationChannels), "notification channels", "must not contain duplicate values")
 }
@@ -0,0 +1,32 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/emzola/issuetracker/pkg/validator"
+)
+
+func TestProjectValidateKey(t *testing.T) {
+	tests := []struct {
+		key   string
+		valid bool
+	}{
+		{"", true},
+		{"WEB", true},
+		{"ABCDEFGHIJ", true},
+		{"A", false},
+		{"ABCDEFGHIJK", false},
+		{"web", false},
+		{"WEB1", false},
+		{"WEB-API", false},
+	}
+	for _, tt := range tests {
+		p := Project{Key: tt.key, Name: "Website", Description: "Company website", StartDate: time.Now(), TargetEndDate: time.Now().AddDate(0, 1, 0)}
+		v := validator.New()
+		p.Validate(v)
+		if _, invalid := v.Errors["key"]; invalid == tt.valid {
+			t.Errorf("Validate() of key %q errors = %v, want valid %v", tt.key, v.Errors, tt.valid)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/emzola/issuetracker/pkg/validator"
+)
+
+// templateValidationName is the project name templates are validated with, as the name
+// of the projects created from a template is only known when it is applied.
+const templateValidationName = "project template"
+
+// ProjectTemplate defines a reusable setup of milestones and seed issues that projects
+// can be created from.
+type ProjectTemplate struct {
+	ID         int64               `json:"id"`
+	Name       string              `json:"name"`
+	Body       ProjectTemplateBody `json:"body"`
+	CreatedOn  time.Time           `json:"created_on"`
+	CreatedBy  string              `json:"created_by"`
+	ModifiedOn time.Time           `json:"modified_on"`
+	ModifiedBy string              `json:"modified_by"`
+	Version    int64               `json:"-"`
+}
+
+// ProjectTemplateBody holds what a template sets up. Dates are given in days after the
+// project's start date, so that a template can be applied at any time.
+type ProjectTemplateBody struct {
+	Description  string                     `json:"description"`
+	DurationDays int                        `json:"duration_days"`
+	Milestones   []ProjectTemplateMilestone `json:"milestones"`
+	Issues       []ProjectTemplateIssue     `json:"issues"`
+}
+
+// ProjectTemplateMilestone defines a milestone created by a template.
+type ProjectTemplateMilestone struct {
+	Title     string `json:"title"`
+	DueInDays int    `json:"due_in_days"`
+}
+
+// ProjectTemplateIssue defines a seed issue created by a template. Milestone is the
+// title of the template milestone the issue is planned into, if any.
+type ProjectTemplateIssue struct {
+	Title          string   `json:"title"`
+	Description    string   `json:"description"`
+	Priority       string   `json:"priority"`
+	Type           string   `json:"type"`
+	TargetInDays   int      `json:"target_in_days"`
+	EstimatedHours *float64 `json:"estimated_hours,omitempty"`
+	Milestone      string   `json:"milestone,omitempty"`
+}
+
+// ProjectPlan holds the project, milestones and issues a template creates.
+// IssueMilestones holds the index in Milestones of each issue's milestone, or -1 for
+// issues without a milestone.
+type ProjectPlan struct {
+	Project         *Project
+	Milestones      []*Milestone
+	Issues          []*Issue
+	IssueMilestones []int
+}
+
+// Validate project template data. The body is validated as if the template was applied
+// today.
+func (t ProjectTemplate) Validate(v *validator.Validator) {
+	v.Check(t.Name != "", "name", "must be provided")
+	v.Check(len(t.Name) <= 500, "name", "must not be more than 500 bytes long")
+	t.Body.Validate(v)
+}
+
+// Validate project template body data.
+func (b ProjectTemplateBody) Validate(v *validator.Validator) {
+	v.Check(b.DurationDays >= 1, "duration_days", "must be at least 1")
+	v.Check(b.DurationDays <= 3650, "duration_days", "must not be more than 3650")
+	titles := make([]string, len(b.Milestones))
+	for i, milestone := range b.Milestones {
+		titles[i] = milestone.Title
+		v.Check(milestone.DueInDays <= b.DurationDays, fmt.Sprintf("milestones[%d].due_in_days", i), "must not be more than duration_days")
+	}
+	v.Check(validator.Unique(titles), "milestones", "must not contain duplicate titles")
+	for i, issue := range b.Issues {
+		if issue.Milestone != "" {
+			v.Check(validator.In(issue.Milestone, titles...), fmt.Sprintf("issues[%d].milestone", i), "must be the title of one of the template's milestones")
+		}
+	}
+	b.Plan(templateValidationName, time.Now().UTC().Truncate(24*time.Hour), "", 0, "").Validate(v)
+}
+
+// Plan returns what the template body sets up for a project with the given name that
+// starts on start. Issues start in status and are reported by reporterID, and
+// everything is created by createdBy.
+func (b ProjectTemplateBody) Plan(name string, start time.Time, status string, reporterID int64, createdBy string) *ProjectPlan {
+	plan := &ProjectPlan{
+		Project: &Project{
+			Name:                 name,
+			Description:          b.Description,
+			StartDate:            start,
+			TargetEndDate:        start.AddDate(0, 0, b.DurationDays),
+			NotificationChannels: []string{"email", "webhook"},
+			CreatedBy:            createdBy,
+			ModifiedBy:           createdBy,
+		},
+	}
+	milestones := make(map[string]int, len(b.Milestones))
+	for i, m := range b.Milestones {
+		milestones[m.Title] = i
+		plan.Milestones = append(plan.Milestones, &Milestone{
+			Title:      m.Title,
+			DueDate:    start.AddDate(0, 0, m.DueInDays),
+			Status:     "open",
+			CreatedBy:  createdBy,
+			ModifiedBy: createdBy,
+		})
+	}
+	for _, ti := range b.Issues {
+		priority := NormalizePriority(ti.Priority)
+		if priority == "" {
+			priority = "low"
+		}
+		issueType := ti.Type
+		if issueType == "" {
+			issueType = "bug"
+		}
+		plan.Issues = append(plan.Issues, &Issue{
+			Title:                ti.Title,
+			Description:          ti.Description,
+			ReporterID:           reporterID,
+			ReportedDate:         start,
+			Status:               status,
+			Priority:             priority,
+			Type:                 issueType,
+			TargetResolutionDate: start.AddDate(0, 0, ti.TargetInDays),
+			EstimatedHours:       ti.EstimatedHours,
+			CreatedBy:            createdBy,
+			ModifiedBy:           createdBy,
+		})
+		milestone, ok := milestones[ti.Milestone]
+		if ti.Milestone == "" || !ok {
+			milestone = -1
+		}
+		plan.IssueMilestones = append(plan.IssueMilestones, milestone)
+	}
+	return plan
+}
+
+// Validate checks the plan against the project, milestone and issue models. Errors of
+// milestones and issues are keyed by their position, e.g. "issues[0].title".
+func (p ProjectPlan) Validate(v *validator.Validator) {
+	p.Project.Validate(v)
+	for i, milestone := range p.Milestones {
+		mv := validator.New()
+		milestone.Validate(mv, p.Project)
+		addItemErrors(v, fmt.Sprintf("milestones[%d]", i), mv)
+	}
+	for i, issue := range p.Issues {
+		iv := validator.New()
+		issue.Validate(iv)
+		addItemErrors(v, fmt.Sprintf("issues[%d]", i), iv)
+	}
+}
+
+// addItemErrors adds the errors of an item of a plan to v, keyed by the item's prefix.
+func addItemErrors(v *validator.Validator, prefix string, item *validator.Validator) {
+	for key, message := range item.Errors {
+		v.AddError(prefix+"."+key, message)
+	}
+}
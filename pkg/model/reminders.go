@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// IssueReminder holds data for an issue due date reminder.
+type IssueReminder struct {
+	IssueID              int64     `json:"issue_id"`
+	Title                string    `json:"issue_title"`
+	Priority             string    `json:"issue_priority"`
+	TargetResolutionDate time.Time `json:"target_resolution_date"`
+	AssigneeName         string    `json:"assignee_name"`
+	AssigneeEmail        string    `json:"assignee_email"`
+	AssigneeLocale       string    `json:"assignee_locale"`
+}
@@ -0,0 +1,52 @@
+package model
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/emzola/issuetracker/pkg/validator"
+)
+
+// RoleActions holds the actions a role can be granted on resources.
+var RoleActions = []string{"create", "read", "update", "delete"}
+
+// BuiltInRoles holds the roles the application relies on, which can be changed but not
+// deleted.
+var BuiltInRoles = []string{"member", "lead", "manager"}
+
+var (
+	roleNameRX = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+	resourceRX = regexp.MustCompile(`^[a-z0-9._-]+(/[a-z0-9._-]+)?$`)
+)
+
+// Role defines a user role and the resources it can act on, keyed by action.
+type Role struct {
+	Name        string              `json:"name"`
+	Permissions map[string][]string `json:"permissions"`
+	CreatedOn   time.Time           `json:"created_on"`
+	CreatedBy   string              `json:"created_by"`
+	ModifiedOn  time.Time           `json:"modified_on"`
+	ModifiedBy  string              `json:"modified_by"`
+	Version     int64               `json:"-"`
+}
+
+// Validate role data. Resources are the first path segment after the API version, e.g.
+// "issues", optionally followed by a sub-resource, e.g. "issues/watchers". Managers
+// must keep full access to roles, so that roles can't be locked out of being managed.
+func (r Role) Validate(v *validator.Validator) {
+	v.Check(r.Name != "", "name", "must be provided")
+	v.Check(len(r.Name) <= 50, "name", "must not be more than 50 bytes long")
+	v.Check(validator.Matches(r.Name, roleNameRX), "name", "must start with a lowercase letter and contain only lowercase letters, digits, hyphens and underscores")
+	for action, resources := range r.Permissions {
+		v.Check(validator.In(action, RoleActions...), "permissions", "must only contain create, read, update or delete actions")
+		v.Check(validator.Unique(resources), "permissions", "must not contain duplicate resources")
+		for _, resource := range resources {
+			v.Check(validator.Matches(resource, resourceRX), "permissions", "must contain resources such as issues or issues/watchers")
+		}
+	}
+	if r.Name == "manager" {
+		for _, action := range RoleActions {
+			v.Check(validator.In("roles", r.Permissions[action]...), "permissions", "must keep the manager's access to roles")
+		}
+	}
+}
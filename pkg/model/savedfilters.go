@@ -0,0 +1,62 @@
+package model
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/emzola/issuetracker/pkg/validator"
+)
+
+// IssueSortSafelist holds the fields issue lists can be sorted by.
+var IssueSortSafelist = []string{"id", "title", "reported_date", "target_resolution_date", "project_id", "assigned_to", "status", "priority", "rank", "-id", "-title", "-reported_date", "-target_resolution_date", "-project_id", "-assigned_to", "-status", "-priority", "-rank"}
+
+// SavedFilterKeys holds the query string parameters of the issue list that saved filters
+// can store. Saved filters always run from their first page, so page isn't one of them.
+var SavedFilterKeys = []string{"title", "q", "reported_date", "reported_from", "reported_to", "target_from", "target_to", "project_id", "milestone_id", "assigned_to", "unassigned", "assignee_name", "reporter_name", "status", "priority", "type", "label", "label_match", "include_deleted", "page_size", "sort"}
+
+// SavedFilter defines a named issue search that a user can run again, stored as the
+// query string of the issue list.
+type SavedFilter struct {
+	ID          int64     `json:"id"`
+	UserID      int64     `json:"user_id"`
+	Name        string    `json:"name"`
+	QueryString string    `json:"query_string"`
+	CreatedOn   time.Time `json:"created_on"`
+	ModifiedOn  time.Time `json:"modified_on"`
+	Version     int64     `json:"-"`
+}
+
+// Validate saved filter data. The query string can only hold the keys in SavedFilterKeys,
+// and can only sort by the fields in IssueSortSafelist.
+func (f SavedFilter) Validate(v *validator.Validator) {
+	v.Check(f.Name != "", "name", "must be provided")
+	v.Check(len(f.Name) <= 500, "name", "must not be more than 500 bytes long")
+	v.Check(len(f.QueryString) <= 2000, "query_string", "must not be more than 2000 bytes long")
+	query, err := f.Query()
+	if err != nil {
+		v.AddError("query_string", "must be a valid query string")
+		return
+	}
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		v.Check(validator.In(key, SavedFilterKeys...), "query_string", fmt.Sprintf("must not contain unknown key %q", key))
+	}
+	if query.Has("sort") {
+		sv := validator.New()
+		Filters{Sort: query.Get("sort"), SortSafelist: IssueSortSafelist}.ValidateSort(sv)
+		for _, message := range sv.Errors {
+			v.AddError("query_string", "sort: "+message)
+		}
+	}
+}
+
+// Query returns the parsed query string of the saved filter.
+func (f SavedFilter) Query() (url.Values, error) {
+	return url.ParseQuery(f.QueryString)
+}
@@ -0,0 +1,35 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/emzola/issuetracker/pkg/validator"
+)
+
+func TestSavedFilterValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		filterName  string
+		queryString string
+		wantValid   bool
+	}{
+		{"filters and sort", "My open bugs", "status=open&type=bug&assigned_to=2&sort=-priority,target_resolution_date&page_size=50", true},
+		{"labels", "UI", "label=ui,backend&label_match=all", true},
+		{"empty query string", "Everything", "", true},
+		{"missing name", "", "status=open", false},
+		{"unknown key", "My open bugs", "status=open&colour=red", false},
+		{"page", "My open bugs", "status=open&page=2", false},
+		{"unknown sort field", "My open bugs", "sort=description", false},
+		{"duplicate sort field", "My open bugs", "sort=priority,-priority", false},
+		{"malformed", "My open bugs", "status=%zz", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := validator.New()
+			SavedFilter{Name: tt.filterName, QueryString: tt.queryString}.Validate(v)
+			if v.Valid() != tt.wantValid {
+				t.Errorf("Validate() errors = %v, want valid %v", v.Errors, tt.wantValid)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"time"
+
+	"github.com/emzola/issuetracker/pkg/validator"
+)
+
+// SettingsRequiringRestart holds the settings that are stored when changed at runtime
+// but only take effect once the server is restarted.
+var SettingsRequiringRestart = []string{"reminder_interval", "auto_close_interval"}
+
+// Settings defines the subset of configuration that can be changed at runtime.
+type Settings struct {
+	LimiterEnabled            bool    `json:"limiter_enabled"`
+	LimiterRps                float64 `json:"limiter_rps"`
+	LimiterBurst              int     `json:"limiter_burst"`
+	LimiterAnonymousRps       float64 `json:"limiter_anonymous_rps"`
+	LimiterAnonymousBurst     int     `json:"limiter_anonymous_burst"`
+	ReminderEnabled           bool    `json:"reminder_enabled"`
+	ReminderInterval          string  `json:"reminder_interval"`
+	AutoCloseEnabled          bool    `json:"auto_close_enabled"`
+	AutoCloseInterval         string  `json:"auto_close_interval"`
+	ProjectTargetEndDateCheck string  `json:"project_target_end_date_check"`
+}
+
+// SettingChange holds the previous and new value of a changed setting.
+type SettingChange struct {
+	Old any `json:"old"`
+	New any `json:"new"`
+}
+
+// Validate settings data.
+func (s Settings) Validate(v *validator.Validator) {
+	v.Check(s.LimiterRps > 0, "limiter_rps", "must be greater than zero")
+	v.Check(s.LimiterBurst > 0, "limiter_burst", "must be greater than zero")
+	v.Check(s.LimiterAnonymousRps > 0, "limiter_anonymous_rps", "must be greater than zero")
+	v.Check(s.LimiterAnonymousBurst > 0, "limiter_anonymous_burst", "must be greater than zero")
+	interval, err := time.ParseDuration(s.ReminderInterval)
+	v.Check(err == nil && interval >= time.Minute, "reminder_interval", "must be a duration of at least 1m")
+	interval, err = time.ParseDuration(s.AutoCloseInterval)
+	v.Check(err == nil && interval >= time.Minute, "auto_close_interval", "must be a duration of at least 1m")
+	v.Check(validator.In(s.ProjectTargetEndDateCheck, "warn", "block", "ignore"), "project_target_end_date_check", "must be warn, block or ignore")
+}
@@ -7,7 +7,11 @@ import (
 )
 
 const (
-	ScopeActivation = "activation"
+	ScopeActivation    = "activation"
+	ScopeCalendar      = "calendar"
+	ScopeEmailChange   = "email-change"
+	ScopePasswordReset = "password-reset"
+	ScopeRefresh       = "refresh"
 )
 
 // Token holds data for an individual token.
@@ -19,6 +23,16 @@ type Token struct {
 	Scope     string    `json:"-"`
 }
 
+// AuthenticationToken holds a signed JWT authentication token along with the claims
+// clients need to track it: its JWT ID, by which it is revoked, and the window in
+// which it is valid.
+type AuthenticationToken struct {
+	Token  string    `json:"authentication_token"`
+	ID     string    `json:"jti"`
+	Issued time.Time `json:"issued_at"`
+	Expiry time.Time `json:"expires_at"`
+}
+
 // Validate token plaintext.
 func ValidateTokenPlaintext(v *validator.Validator, tokenPlaintext string) {
 	v.Check(tokenPlaintext != "", "token", "must be provided")
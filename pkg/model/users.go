@@ -1,13 +1,79 @@
 package model
 
 import (
+	"bytes"
 	"errors"
+	"strings"
 	"time"
+	"unicode"
 
 	"github.com/emzola/issuetracker/pkg/validator"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// DefaultBcryptCost is the bcrypt cost of password hashes when none is configured.
+const DefaultBcryptCost = 12
+
+// DefaultLocale is the locale of users who haven't chosen one.
+const DefaultLocale = "en"
+
+// Locales holds the locales users can receive emails in.
+var Locales = []string{"en", "fr", "de", "es"}
+
+// PasswordPolicy holds the optional complexity rules new passwords must follow on top
+// of their length. The zero value only checks the length.
+type PasswordPolicy struct {
+	RequireUpper bool
+	RequireLower bool
+	RequireDigit bool
+	RejectCommon bool
+}
+
+// commonPasswords holds commonly used passwords that are long enough to pass the length
+// check, in lowercase.
+var commonPasswords = map[string]bool{
+	"password":      true,
+	"password1":     true,
+	"password123":   true,
+	"passw0rd":      true,
+	"p@ssw0rd":      true,
+	"12345678":      true,
+	"123456789":     true,
+	"1234567890":    true,
+	"11111111":      true,
+	"00000000":      true,
+	"87654321":      true,
+	"qwertyuiop":    true,
+	"qwerty123":     true,
+	"qwerty12345":   true,
+	"1q2w3e4r":      true,
+	"1qaz2wsx":      true,
+	"asdfghjkl":     true,
+	"zxcvbnm123":    true,
+	"abcd1234":      true,
+	"abc12345":      true,
+	"iloveyou":      true,
+	"iloveyou1":     true,
+	"sunshine":      true,
+	"princess":      true,
+	"football":      true,
+	"baseball":      true,
+	"superman":      true,
+	"starwars":      true,
+	"trustno1":      true,
+	"welcome1":      true,
+	"welcome123":    true,
+	"letmein1":      true,
+	"changeme":      true,
+	"admin123":      true,
+	"administrator": true,
+	"whatever":      true,
+	"computer":      true,
+	"internet":      true,
+	"michelle":      true,
+	"jennifer":      true,
+}
+
 // AnonymousUser represents an inactivated user with no ID, name, email, password.
 var AnonymousUser = &User{}
 
@@ -24,6 +90,23 @@ type User struct {
 	ModifiedOn time.Time `json:"modified_on"`
 	ModifiedBy string    `json:"modified_by"`
 	Version    int       `json:"-"`
+	// TokenEpoch is embedded in authentication tokens and bumped whenever the user's
+	// role or password changes, invalidating all outstanding tokens.
+	TokenEpoch int `json:"-"`
+	// DigestOptIn is whether the user gets a daily email digest of their assigned
+	// issues.
+	DigestOptIn bool `json:"digest_opt_in"`
+	// Locale is the language the user's emails are sent in.
+	Locale string `json:"locale"`
+	// PendingEmail is the email the user asked to change to, until they confirm it.
+	PendingEmail string `json:"-"`
+}
+
+// UserImport holds a row of a user import.
+type UserImport struct {
+	Name  string
+	Email string
+	Role  string
 }
 
 // IsAnonymous checks if a user instance is the anonymous user.
@@ -37,10 +120,23 @@ type password struct {
 	Hash      []byte
 }
 
-// Set calculates the bcrypt hash of a plaintext password, and stores both
-// the hash and the plaintext versions in the struct.
+// Set calculates the bcrypt hash of a plaintext password with the default cost, and
+// stores both the hash and the plaintext versions in the struct.
 func (p *password) Set(plaintextPassword string) error {
-	hash, err := bcrypt.GenerateFromPassword([]byte(plaintextPassword), 12)
+	return p.SetWithCost(plaintextPassword, 0)
+}
+
+// SetWithCost calculates the bcrypt hash of a plaintext password with the given cost,
+// and stores both the hash and the plaintext versions in the struct. A cost of 0 uses
+// DefaultBcryptCost.
+func (p *password) SetWithCost(plaintextPassword string, cost int) error {
+	if cost == 0 {
+		cost = DefaultBcryptCost
+	}
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		return bcrypt.InvalidCostError(cost)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(plaintextPassword), cost)
 	if err != nil {
 		return err
 	}
@@ -49,9 +145,23 @@ func (p *password) Set(plaintextPassword string) error {
 	return nil
 }
 
+// unusableHash is the password hash of users who haven't chosen a password yet. It isn't
+// a bcrypt hash, so no password matches it.
+var unusableHash = []byte("!")
+
+// SetUnusable stores a hash that no password matches, for users who choose their password
+// later, without paying for hashing a password nobody will use.
+func (p *password) SetUnusable() {
+	p.Plaintext = nil
+	p.Hash = unusableHash
+}
+
 // Matches checks whether the provided plaintext password matches the hashed password
 // stored in the struct, returning true if it matches and false otherwise.
 func (p *password) Matches(plaintextPassword string) (bool, error) {
+	if bytes.Equal(p.Hash, unusableHash) {
+		return false, nil
+	}
 	err := bcrypt.CompareHashAndPassword(p.Hash, []byte(plaintextPassword))
 	if err != nil {
 		switch {
@@ -70,6 +180,7 @@ func (u User) Validate(v *validator.Validator) {
 	v.Check(len(u.Name) >= 3, "name", "must not be less than 3 bytes long")
 	v.Check(len(u.Name) <= 500, "name", "must not be more than 500 bytes long")
 	ValidateEmail(v, u.Email)
+	ValidateLocale(v, u.Locale)
 	if u.Password.Plaintext != nil {
 		ValidatePasswordPlaintext(v, *u.Password.Plaintext)
 	}
@@ -83,8 +194,27 @@ func ValidateEmail(v *validator.Validator, email string) {
 	v.Check(validator.Matches(email, validator.EmailRX), "email", "must be a valid email address")
 }
 
+func ValidateLocale(v *validator.Validator, locale string) {
+	v.Check(validator.In(locale, Locales...), "locale", "must be one of en, fr, de or es")
+}
+
 func ValidatePasswordPlaintext(v *validator.Validator, password string) {
 	v.Check(password != "", "password", "must be provided")
 	v.Check(len(password) >= 8, "password", "must be at least 8 bytes long")
 	v.Check(len(password) <= 72, "password", "must not be more than 72 bytes long")
 }
+
+// ValidatePasswordPolicy checks a new password against the rules of the policy. Each
+// rule reports its own key so that clients can tell which one wasn't followed.
+func ValidatePasswordPolicy(v *validator.Validator, password string, policy PasswordPolicy) {
+	var upper, lower, digit bool
+	for _, r := range password {
+		upper = upper || unicode.IsUpper(r)
+		lower = lower || unicode.IsLower(r)
+		digit = digit || unicode.IsDigit(r)
+	}
+	v.Check(!policy.RequireUpper || upper, "password_uppercase", "must contain an uppercase letter")
+	v.Check(!policy.RequireLower || lower, "password_lowercase", "must contain a lowercase letter")
+	v.Check(!policy.RequireDigit || digit, "password_digit", "must contain a digit")
+	v.Check(!policy.RejectCommon || !commonPasswords[strings.ToLower(password)], "password_common", "must not be a commonly used password")
+}
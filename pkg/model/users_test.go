@@ -0,0 +1,102 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/emzola/issuetracker/pkg/validator"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestPasswordSetWithCost(t *testing.T) {
+	tests := []struct {
+		cost     int
+		wantCost int
+		wantErr  bool
+	}{
+		{cost: 0, wantCost: DefaultBcryptCost},
+		{cost: bcrypt.MinCost, wantCost: bcrypt.MinCost},
+		{cost: bcrypt.MinCost - 1, wantErr: true},
+		{cost: bcrypt.MaxCost + 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		var p password
+		err := p.SetWithCost("pa55word", tt.cost)
+		if tt.wantErr {
+			var costErr bcrypt.InvalidCostError
+			if !errors.As(err, &costErr) {
+				t.Errorf("SetWithCost(cost %d) error = %v, want an InvalidCostError", tt.cost, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("SetWithCost(cost %d) error = %v", tt.cost, err)
+		}
+		cost, err := bcrypt.Cost(p.Hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cost != tt.wantCost {
+			t.Errorf("SetWithCost(cost %d) hashed with cost %d, want %d", tt.cost, cost, tt.wantCost)
+		}
+	}
+}
+
+func TestPasswordSetUnusable(t *testing.T) {
+	var p password
+	p.SetUnusable()
+	for _, plaintext := range []string{"", "!", "pa55word"} {
+		matches, err := p.Matches(plaintext)
+		if err != nil || matches {
+			t.Errorf("Matches(%q) = %v, %v, want false and no error", plaintext, matches, err)
+		}
+	}
+}
+
+func TestValidatePasswordPolicy(t *testing.T) {
+	strict := PasswordPolicy{RequireUpper: true, RequireLower: true, RequireDigit: true, RejectCommon: true}
+	tests := []struct {
+		name     string
+		password string
+		policy   PasswordPolicy
+		wantKey  string
+	}{
+		{"no policy", "password", PasswordPolicy{}, ""},
+		{"strict", "Correct-Horse-9", strict, ""},
+		{"missing uppercase", "lowercase-9", PasswordPolicy{RequireUpper: true}, "password_uppercase"},
+		{"missing lowercase", "UPPERCASE-9", PasswordPolicy{RequireLower: true}, "password_lowercase"},
+		{"missing digit", "No-Digits-Here", PasswordPolicy{RequireDigit: true}, "password_digit"},
+		{"common", "Password123", PasswordPolicy{RejectCommon: true}, "password_common"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := validator.New()
+			ValidatePasswordPolicy(v, tt.password, tt.policy)
+			if tt.wantKey == "" {
+				if !v.Valid() {
+					t.Errorf("ValidatePasswordPolicy(%q) errors = %v, want none", tt.password, v.Errors)
+				}
+				return
+			}
+			if _, ok := v.Errors[tt.wantKey]; !ok || len(v.Errors) != 1 {
+				t.Errorf("ValidatePasswordPolicy(%q) errors = %v, want only %q", tt.password, v.Errors, tt.wantKey)
+			}
+		})
+	}
+}
+
+// BenchmarkPasswordSetWithCost shows how the bcrypt cost affects the time taken to hash
+// a password. Each increment of the cost doubles it.
+func BenchmarkPasswordSetWithCost(b *testing.B) {
+	for _, cost := range []int{bcrypt.MinCost, 8, 10, DefaultBcryptCost} {
+		b.Run(fmt.Sprintf("cost=%d", cost), func(b *testing.B) {
+			var p password
+			for i := 0; i < b.N; i++ {
+				if err := p.SetWithCost("pa55word", cost); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
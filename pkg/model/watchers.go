@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+// Watcher defines a user following an issue.
+type Watcher struct {
+	IssueID   int64     `json:"issue_id"`
+	UserID    int64     `json:"user_id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"-"`
+	Locale    string    `json:"-"`
+	CreatedOn time.Time `json:"created_on"`
+}
@@ -0,0 +1,77 @@
+package model
+
+import (
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/emzola/issuetracker/pkg/validator"
+	"github.com/emzola/issuetracker/pkg/webhook"
+)
+
+// WebhookEvents holds the issue events a webhook can subscribe to. Updates that close
+// an issue are sent as issue.closed rather than issue.updated.
+var WebhookEvents = []string{"issue.created", "issue.updated", "issue.closed"}
+
+// Webhook defines a URL that is sent a project's issue events. Deliveries are signed
+// with the secret, which is never returned once set.
+type Webhook struct {
+	ID         int64     `json:"id"`
+	ProjectID  int64     `json:"project_id"`
+	URL        string    `json:"url"`
+	Secret     string    `json:"-"`
+	Events     []string  `json:"events"`
+	Active     bool      `json:"active"`
+	CreatedOn  time.Time `json:"created_on"`
+	CreatedBy  string    `json:"created_by"`
+	ModifiedOn time.Time `json:"modified_on"`
+	ModifiedBy string    `json:"modified_by"`
+	Version    int64     `json:"-"`
+}
+
+// WebhookPayload is the JSON body of a webhook delivery.
+type WebhookPayload struct {
+	Event     string    `json:"event"`
+	WebhookID int64     `json:"webhook_id"`
+	Issue     *Issue    `json:"issue"`
+	SentOn    time.Time `json:"sent_on"`
+}
+
+// Validate webhook data. URLs must not point at internal services: hosts that are
+// disallowed addresses or local names are rejected here, and host names are checked
+// again once resolved, when webhooks are delivered.
+func (w Webhook) Validate(v *validator.Validator) {
+	v.Check(w.URL != "", "url", "must be provided")
+	u, err := url.Parse(w.URL)
+	v.Check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "", "url", "must be an absolute http or https URL")
+	if err == nil && u.Host != "" {
+		v.Check(publicHost(u.Hostname()), "url", "must not point at a loopback, private, link-local or metadata address")
+	}
+	v.Check(len(w.Secret) >= 16, "secret", "must be at least 16 bytes long")
+	v.Check(len(w.Secret) <= 256, "secret", "must not be more than 256 bytes long")
+	v.Check(len(w.Events) > 0, "events", "must contain at least one event")
+	v.Check(validator.Unique(w.Events), "events", "must not contain duplicate values")
+	for _, event := range w.Events {
+		v.Check(validator.In(event, WebhookEvents...), "events", "must only contain issue.created, issue.updated or issue.closed")
+	}
+}
+
+// Subscribed reports whether the webhook is sent the event.
+func (w Webhook) Subscribed(event string) bool {
+	return w.Active && validator.In(event, w.Events...)
+}
+
+// localHostnames holds host names that resolve to the local machine or to cloud metadata
+// services.
+var localHostnames = []string{"localhost", "metadata", "metadata.google.internal"}
+
+// publicHost reports whether host is neither a disallowed webhook address nor a local
+// host name.
+func publicHost(host string) bool {
+	if ip := net.ParseIP(host); ip != nil {
+		return webhook.AllowedAddress(ip)
+	}
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	return !validator.In(host, localHostnames...) && !strings.HasSuffix(host, ".localhost")
+}
@@ -0,0 +1,36 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/emzola/issuetracker/pkg/validator"
+)
+
+func TestWebhookValidateURL(t *testing.T) {
+	tests := []struct {
+		url   string
+		valid bool
+	}{
+		{"https://hooks.example.com/issues", true},
+		{"http://93.184.216.34:8080/hook", true},
+		{"ftp://hooks.example.com/issues", false},
+		{"http://127.0.0.1/hook", false},
+		{"http://[::1]:8080/hook", false},
+		{"http://10.0.0.5/hook", false},
+		{"http://192.168.1.10/hook", false},
+		{"http://169.254.169.254/latest/meta-data", false},
+		{"http://localhost:9000/hook", false},
+		{"http://api.localhost/hook", false},
+		{"http://metadata.google.internal/computeMetadata/v1", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			hook := Webhook{URL: tt.url, Secret: "topsecretsecret1", Events: []string{"issue.created"}}
+			v := validator.New()
+			hook.Validate(v)
+			if _, invalid := v.Errors["url"]; invalid == tt.valid {
+				t.Errorf("Validate(%s) url errors = %v, want valid %v", tt.url, v.Errors["url"], tt.valid)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/emzola/issuetracker/pkg/validator"
+)
+
+// WorkflowCategories holds the categories a workflow state can belong to.
+var WorkflowCategories = []string{"open", "closed"}
+
+// MaxWorkflowStates is the maximum number of states a workflow can have.
+const MaxWorkflowStates = 20
+
+// WorkflowState defines a single issue status in a project's workflow.
+type WorkflowState struct {
+	Name     string `json:"name"`
+	Category string `json:"category"`
+}
+
+// Workflow defines the ordered issue statuses of a project. New issues start in
+// the first state and move through the open states in order, one at a time. They can
+// go back to any earlier open state, and are closed from the last open state. Issues
+// in a closed state can only be reopened to the first state.
+type Workflow struct {
+	ProjectID int64           `json:"project_id"`
+	States    []WorkflowState `json:"states"`
+}
+
+// DefaultWorkflow returns the workflow used by projects that haven't defined their own.
+func DefaultWorkflow(projectID int64) *Workflow {
+	return &Workflow{
+		ProjectID: projectID,
+		States: []WorkflowState{
+			{Name: "open", Category: "open"},
+			{Name: "in progress", Category: "open"},
+			{Name: "resolved", Category: "open"},
+			{Name: "closed", Category: "closed"},
+		},
+	}
+}
+
+// Initial returns the status new issues start in.
+func (w Workflow) Initial() string {
+	return w.States[0].Name
+}
+
+// State returns the workflow state with the given name, if any.
+func (w Workflow) State(name string) (WorkflowState, bool) {
+	for _, state := range w.States {
+		if state.Name == name {
+			return state, true
+		}
+	}
+	return WorkflowState{}, false
+}
+
+// FirstClosed returns the first state in the closed category.
+func (w Workflow) FirstClosed() string {
+	for _, state := range w.States {
+		if state.Category == "closed" {
+			return state.Name
+		}
+	}
+	return ""
+}
+
+// ClosedStatuses returns the names of the states in the closed category.
+func (w Workflow) ClosedStatuses() []string {
+	statuses := []string{}
+	for _, state := range w.States {
+		if state.Category == "closed" {
+			statuses = append(statuses, state.Name)
+		}
+	}
+	return statuses
+}
+
+// CanTransition reports whether an issue can move from one status to another. With
+// the default workflow, issues move from open to in progress, resolved and closed, and
+// can go back from resolved to in progress or open, or be reopened once closed.
+func (w Workflow) CanTransition(from, to string) bool {
+	if from == to {
+		return true
+	}
+	current, ok := w.State(from)
+	if !ok {
+		// Issues in a status the workflow doesn't know about can be moved to any state.
+		return true
+	}
+	next, ok := w.State(to)
+	if !ok {
+		return false
+	}
+	if current.Category == "closed" {
+		return to == w.Initial()
+	}
+	if next.Category == "closed" {
+		return from == w.lastOpen()
+	}
+	// Open states move forward to the next open state, or back to any earlier one.
+	var previous []string
+	for _, state := range w.States {
+		if state.Category != "open" {
+			continue
+		}
+		if state.Name == from {
+			return validator.In(to, previous...) || to == w.nextOpen(from)
+		}
+		previous = append(previous, state.Name)
+	}
+	return false
+}
+
+// ValidateTransition checks that to is a state in the workflow that an issue in from
+// can move to.
+func (w Workflow) ValidateTransition(v *validator.Validator, from, to string) {
+	if _, ok := w.State(to); !ok {
+		names := make([]string, len(w.States))
+		for i, state := range w.States {
+			names[i] = state.Name
+		}
+		v.AddError("status", "must be one of "+strings.Join(names, ", "))
+		return
+	}
+	v.Check(w.CanTransition(from, to), "status", fmt.Sprintf("cannot change from %q to %q", from, to))
+}
+
+// nextOpen returns the open state after the given one, if any.
+func (w Workflow) nextOpen(name string) string {
+	found := false
+	for _, state := range w.States {
+		if state.Category != "open" {
+			continue
+		}
+		if found {
+			return state.Name
+		}
+		found = state.Name == name
+	}
+	return ""
+}
+
+// lastOpen returns the last state in the open category.
+func (w Workflow) lastOpen() string {
+	last := ""
+	for _, state := range w.States {
+		if state.Category == "open" {
+			last = state.Name
+		}
+	}
+	return last
+}
+
+// Validate workflow data.
+func (w Workflow) Validate(v *validator.Validator) {
+	v.Check(len(w.States) > 0, "states", "must contain at least one state")
+	v.Check(len(w.States) <= MaxWorkflowStates, "states", "must not contain more than 20 states")
+	names := make([]string, 0, len(w.States))
+	open, closed := false, false
+	for _, state := range w.States {
+		v.Check(state.Name != "", "states", "must not contain a state without a name")
+		v.Check(len(state.Name) <= 50, "states", "must not contain a state name more than 50 bytes long")
+		v.Check(validator.In(state.Category, WorkflowCategories...), "states", "must only contain states with category open or closed")
+		names = append(names, state.Name)
+		open = open || state.Category == "open"
+		closed = closed || state.Category == "closed"
+	}
+	v.Check(validator.Unique(names), "states", "must not contain duplicate state names")
+	v.Check(open && closed, "states", "must contain at least one open and one closed state")
+	if len(w.States) > 0 {
+		v.Check(w.States[0].Category == "open", "states", "must start with an open state")
+	}
+}
@@ -0,0 +1,30 @@
+package model
+
+import (
+	"time"
+
+	"github.com/emzola/issuetracker/pkg/validator"
+)
+
+// Worklog defines an entry of time logged against an issue.
+type Worklog struct {
+	ID       int64     `json:"id"`
+	IssueID  int64     `json:"issue_id"`
+	Hours    float64   `json:"hours"`
+	Note     string    `json:"note,omitempty"`
+	LoggedBy int64     `json:"logged_by"`
+	LoggedOn time.Time `json:"logged_on"`
+}
+
+// Validate worklog data.
+func (w Worklog) Validate(v *validator.Validator) {
+	v.Check(w.Hours > 0, "hours", "must be greater than zero")
+	v.Check(w.Hours <= 1000, "hours", "must not be more than 1000")
+	v.Check(len(w.Note) <= 1000, "note", "must not be more than 1000 bytes long")
+}
+
+// IssueEffort holds an issue's estimated hours and the total hours logged against it.
+type IssueEffort struct {
+	EstimatedHours *float64 `json:"estimated_hours,omitempty"`
+	LoggedHours    float64  `json:"logged_hours"`
+}
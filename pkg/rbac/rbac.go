@@ -3,6 +3,7 @@ package rbac
 import (
 	"encoding/json"
 	"os"
+	"sync"
 
 	"github.com/emzola/issuetracker/pkg/model"
 )
@@ -16,24 +17,26 @@ type Actions map[string]Resources
 // Roles holds data for user roles.
 type Roles map[string]Actions
 
-// Authorizer defines roles.
+// Authorizer defines roles. The roles can be replaced at runtime with Reload, and it
+// is safe for concurrent use, so that concurrent requests see a consistent view.
 type Authorizer struct {
+	mu    sync.RWMutex
 	roles Roles
 }
 
 // New creates a new Authorizer instance.
-func New(roles Roles) Authorizer {
-	return Authorizer{roles: roles}
+func New(roles Roles) *Authorizer {
+	return &Authorizer{roles: roles}
 }
 
 // ActionFromMethod returns role actions from HTTP methods.
-func (a Authorizer) ActionFromMethod(httpMethod string) string {
+func (a *Authorizer) ActionFromMethod(httpMethod string) string {
 	switch httpMethod {
 	case "GET":
 		return "read"
 	case "POST":
 		return "create"
-	case "PATCH":
+	case "PATCH", "PUT":
 		return "update"
 	case "DELETE":
 		return "delete"
@@ -43,7 +46,9 @@ func (a Authorizer) ActionFromMethod(httpMethod string) string {
 }
 
 // HasPermission checks whether a user has permissions to access a resource.
-func (a Authorizer) HasPermission(user *model.User, action, asset string) bool {
+func (a *Authorizer) HasPermission(user *model.User, action, asset string) bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
 	userRole := user.Role
 	role, ok := a.roles[userRole]
 	if !ok {
@@ -61,6 +66,27 @@ func (a Authorizer) HasPermission(user *model.User, action, asset string) bool {
 	return false
 }
 
+// Roles returns a copy of the current roles.
+func (a *Authorizer) Roles() Roles {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	roles := make(Roles, len(a.roles))
+	for name, actions := range a.roles {
+		roles[name] = make(Actions, len(actions))
+		for action, resources := range actions {
+			roles[name][action] = append(Resources(nil), resources...)
+		}
+	}
+	return roles
+}
+
+// Reload replaces the roles. Permission checks in progress finish with the old roles.
+func (a *Authorizer) Reload(roles Roles) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.roles = roles
+}
+
 // LoadRoles loads roles from JSON file.
 func LoadRoles(filename string) (Roles, error) {
 	var roles Roles
@@ -0,0 +1,22 @@
+package rbac
+
+import (
+	"testing"
+
+	"github.com/emzola/issuetracker/pkg/model"
+)
+
+func TestReload(t *testing.T) {
+	a := New(Roles{"member": {"read": {"issues"}}})
+	member := &model.User{ID: 1, Role: "member"}
+	roles := a.Roles()
+	// Changing the copy doesn't change the authorizer's roles.
+	roles["member"]["read"] = append(roles["member"]["read"], "projects")
+	if a.HasPermission(member, "read", "projects") {
+		t.Fatal("HasPermission() = true after changing a copy of the roles, want false")
+	}
+	a.Reload(roles)
+	if !a.HasPermission(member, "read", "projects") {
+		t.Error("HasPermission() = false after Reload(), want true")
+	}
+}
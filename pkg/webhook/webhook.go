@@ -0,0 +1,115 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// SignatureHeader is the header that carries the signature of a delivery's body.
+const SignatureHeader = "X-Signature"
+
+// ErrDisallowedAddress is returned when a delivery would connect to an address that
+// webhooks aren't allowed to reach.
+var ErrDisallowedAddress = errors.New("webhook address is not allowed")
+
+// sharedAddressSpace is the carrier-grade NAT range, which some clouds also use for their
+// metadata services.
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// AllowedAddress reports whether webhooks can be delivered to ip. Loopback, private,
+// link-local (including cloud metadata services), shared, unspecified and multicast
+// addresses are not allowed, so that webhooks can't be used to reach internal services.
+func AllowedAddress(ip net.IP) bool {
+	return !ip.IsLoopback() &&
+		!ip.IsPrivate() &&
+		!ip.IsLinkLocalUnicast() &&
+		!ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() &&
+		!ip.IsMulticast() &&
+		!ip.IsUnspecified() &&
+		!sharedAddressSpace.Contains(ip)
+}
+
+// Sender delivers webhook payloads over HTTP.
+type Sender struct {
+	client     *http.Client
+	retryDelay time.Duration
+}
+
+// New creates a new Sender. Deliveries only connect to allowed addresses, which are
+// checked once host names have been resolved, so that names resolving to internal
+// addresses and redirects to them are refused too.
+func New() Sender {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(network, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !AllowedAddress(ip) {
+				return fmt.Errorf("%w: %s", ErrDisallowedAddress, host)
+			}
+			return nil
+		},
+	}
+	return Sender{
+		client:     &http.Client{Timeout: 5 * time.Second, Transport: &http.Transport{DialContext: dialer.DialContext}},
+		retryDelay: 5 * time.Second,
+	}
+}
+
+// Sign returns the signature of body with secret: "sha256=" followed by the hex encoded
+// HMAC-SHA256 of the body. Receivers verify deliveries by computing the same signature
+// and comparing it with the X-Signature header.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Send posts the JSON body to url, signed with secret. A delivery succeeds when the
+// receiver responds with a 2xx status code. Deliveries to disallowed addresses aren't
+// retried.
+func (s Sender) Send(url, secret string, body []byte) error {
+	signature := Sign(secret, body)
+	// Try delivering the payload up to three times before aborting and returning the
+	// final error. Sleep for the retry delay between each attempt.
+	var err error
+	for i := 1; i <= 3; i++ {
+		err = s.post(url, signature, body)
+		if err == nil || errors.Is(err, ErrDisallowedAddress) {
+			return err
+		}
+		if i < 3 {
+			time.Sleep(s.retryDelay)
+		}
+	}
+	return err
+}
+
+func (s Sender) post(url, signature string, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, signature)
+	res, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with status %d", res.StatusCode)
+	}
+	return nil
+}
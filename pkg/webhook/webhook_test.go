@@ -0,0 +1,94 @@
+package webhook
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSendSignsAndRetries(t *testing.T) {
+	body := []byte(`{"event":"issue.created"}`)
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		received, _ := io.ReadAll(r.Body)
+		if got, want := r.Header.Get(SignatureHeader), Sign("topsecretsecret1", received); got != want {
+			t.Errorf("%s = %q, want %q", SignatureHeader, got, want)
+		}
+		// Fail the first attempt so that the delivery is retried.
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+	s := Sender{client: server.Client()}
+	err := s.Send(server.URL, "topsecretsecret1", body)
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if attempts != 2 {
+		t.Errorf("Send() made %d attempts, want 2", attempts)
+	}
+}
+
+func TestSendReturnsFinalError(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+	s := Sender{client: server.Client()}
+	err := s.Send(server.URL, "topsecretsecret1", []byte(`{}`))
+	if err == nil {
+		t.Fatal("Send() error = nil, want the final delivery error")
+	}
+	if attempts != 3 {
+		t.Errorf("Send() made %d attempts, want 3", attempts)
+	}
+}
+
+func TestAllowedAddress(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"100.100.100.200", false},
+		{"fd00:ec2::254", false},
+		{"fe80::1", false},
+		{"0.0.0.0", false},
+		{"::ffff:127.0.0.1", false},
+	}
+	for _, tt := range tests {
+		if got := AllowedAddress(net.ParseIP(tt.ip)); got != tt.want {
+			t.Errorf("AllowedAddress(%s) = %v, want %v", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestSendRefusesDisallowedAddresses(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+	}))
+	defer server.Close()
+	// The test server listens on loopback, which New's senders refuse to connect to.
+	err := New().Send(server.URL, "topsecretsecret1", []byte(`{}`))
+	if !errors.Is(err, ErrDisallowedAddress) {
+		t.Fatalf("Send() error = %v, want ErrDisallowedAddress", err)
+	}
+	if attempts != 0 {
+		t.Errorf("Send() reached the server %d times, want 0", attempts)
+	}
+}